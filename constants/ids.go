@@ -17,6 +17,12 @@ import "github.com/richardwilkes/unison"
 const (
 	NewSheetItemID = unison.UserBaseID + iota
 	NewTemplateItemID
+	NewCampaignItemID
+	NewAncestryItemID
+	NewNameGeneratorItemID
+	CombatTrackerItemID
+	GenerateNPCsItemID
+	OpenShopItemID
 	NewTraitsLibraryItemID
 	NewTraitModifiersLibraryItemID
 	NewEquipmentLibraryItemID
@@ -30,16 +36,22 @@ const (
 	SaveItemID
 	SaveAsItemID
 	ExportToMenuID
+	ExportToJSONItemID
+	CompareWithItemID
 	PrintItemID
 	UndoItemID
 	RedoItemID
 	DuplicateItemID
 	ConvertToContainerItemID
+	ConvertToMetricItemID
+	ConvertToImperialItemID
 	ToggleStateItemID
 	IncrementItemID
 	DecrementItemID
 	IncrementUsesItemID
 	DecrementUsesItemID
+	FireWeaponItemID
+	ReloadWeaponItemID
 	IncrementSkillLevelItemID
 	DecrementSkillLevelItemID
 	IncrementTechLevelItemID
@@ -51,8 +63,10 @@ const (
 	CopyToSheetItemID
 	CopyToTemplateItemID
 	ApplyTemplateItemID
+	CreateFromTemplateItemID
 	OpenOnePageReferenceItemID
 	OpenEachPageReferenceItemID
+	RollItemID
 	LibraryMenuID
 	SettingsMenuID
 	PerSheetSettingsItemID
@@ -74,6 +88,7 @@ const (
 	WebSiteItemID
 	MailingListItemID
 	ChangeLibraryLocationsItemID
+	SearchLibrariesItemID
 
 	FirstNonContainerMarker // Keep this block grouped together
 	NewCarriedEquipmentItemID
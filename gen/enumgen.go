@@ -521,6 +521,24 @@ func main() {
 			},
 		},
 	})
+	processSourceTemplate(enumTmpl, &enumInfo{
+		Pkg:        "model/gurps/picker",
+		Name:       "type",
+		Desc:       "holds the type of selection a template picker requires",
+		StandAlone: true,
+		Values: []enumValue{
+			{
+				Key:    "not_applicable",
+				String: "N/A",
+			},
+			{
+				Key: "count",
+			},
+			{
+				Key: "points",
+			},
+		},
+	})
 	processSourceTemplate(enumTmpl, &enumInfo{
 		Pkg:        "model/gurps/prereq",
 		Name:       "type",
@@ -896,6 +914,20 @@ func main() {
 			},
 		},
 	})
+	processSourceTemplate(enumTmpl, &enumInfo{
+		Pkg:        "model/gurps/measure",
+		Name:       "unit_system",
+		Desc:       "holds the system of measurement used for lengths and weights",
+		StandAlone: true,
+		Values: []enumValue{
+			{
+				Key: "imperial",
+			},
+			{
+				Key: "metric",
+			},
+		},
+	})
 	processSourceTemplate(enumTmpl, &enumInfo{
 		Pkg:        "model/gurps",
 		Name:       "cell_type",
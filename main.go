@@ -13,9 +13,17 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/richardwilkes/gcs/v5/dbg"
 	"github.com/richardwilkes/gcs/v5/model/export"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/diff"
+	"github.com/richardwilkes/gcs/v5/model/gurps/importer"
+	"github.com/richardwilkes/gcs/v5/model/gurps/measure"
+	gsettings "github.com/richardwilkes/gcs/v5/model/gurps/settings"
+	"github.com/richardwilkes/gcs/v5/model/gurps/validation"
 	"github.com/richardwilkes/gcs/v5/model/library"
 	"github.com/richardwilkes/gcs/v5/model/settings"
 	"github.com/richardwilkes/gcs/v5/setup"
@@ -37,9 +45,46 @@ func main() {
 	unison.AttachConsole()
 	cl := cmdline.New(true)
 	var textTmplPath string
+	var pdfExport bool
+	var jsonExport bool
+	var legacyImport bool
+	var check bool
+	var diffSheets bool
+	var mergeSheets bool
+	var mergeOutput string
+	var convertUnits string
+	var paperSize, paperOrientation, topMargin, leftMargin, bottomMargin, rightMargin string
 	var showCopyrightDateAndExit bool
 	cl.NewGeneralOption(&textTmplPath).SetName("text").SetSingle('x').SetArg("file").
-		SetUsage(i18n.Text("Export sheets using the specified template file"))
+		SetUsage(i18n.Text("Export sheets using the specified template file. Templates ending in .tmpl, .gotmpl or .gohtml, or starting with {{/* gcs-template: text */}} or {{/* gcs-template: html */}}, are processed with Go's template engine"))
+	cl.NewGeneralOption(&jsonExport).SetName("json").
+		SetUsage(i18n.Text("Export the computed values of sheets to a versioned JSON document"))
+	cl.NewGeneralOption(&legacyImport).SetName("import").
+		SetUsage(i18n.Text("Convert files from older versions of GCS (XML) and from GCA (.gca4 and .gca5) into the current format, placing each one next to its source file. GCA5 items are matched against the libraries where possible"))
+	cl.NewGeneralOption(&check).SetName("check").
+		SetUsage(i18n.Text("Check sheets for problems, such as unmet prerequisites or exceeded point limits, and exit with a non-zero status if any are found"))
+	cl.NewGeneralOption(&diffSheets).SetName("diff").
+		SetUsage(i18n.Text("Report the differences between two sheets in game terms, and exit with a non-zero status if there are any"))
+	cl.NewGeneralOption(&mergeSheets).SetName("merge").
+		SetUsage(i18n.Text("Perform a three-way merge of sheets given as: base ours theirs. The result replaces ours unless --output is given. Exits with a non-zero status if there were conflicts, in which case the value from ours is kept"))
+	cl.NewGeneralOption(&mergeOutput).SetName("output").SetArg("file").
+		SetUsage(i18n.Text("The file to write the result of --merge to"))
+	cl.NewGeneralOption(&convertUnits).SetName("convert-units").SetArg("system").
+		SetUsage(i18n.Text("Convert the lengths and weights in sheets, templates and lists to the given system of measurement (imperial or metric), replacing the originals. Directories, such as a library, are processed recursively"))
+	cl.NewGeneralOption(&pdfExport).SetName("pdf").
+		SetUsage(i18n.Text("Export sheets to PDF using the sheet's page settings"))
+	cl.NewGeneralOption(&paperSize).SetName("paper").SetArg("size").
+		SetUsage(i18n.Text("Override the paper size used for PDF export (e.g. letter, legal, a4)"))
+	cl.NewGeneralOption(&paperOrientation).SetName("orientation").SetArg("orientation").
+		SetUsage(i18n.Text("Override the paper orientation used for PDF export (portrait or landscape)"))
+	cl.NewGeneralOption(&topMargin).SetName("top-margin").SetArg("length").
+		SetUsage(i18n.Text("Override the top margin used for PDF export (e.g. 0.5in, 1cm)"))
+	cl.NewGeneralOption(&leftMargin).SetName("left-margin").SetArg("length").
+		SetUsage(i18n.Text("Override the left margin used for PDF export (e.g. 0.5in, 1cm)"))
+	cl.NewGeneralOption(&bottomMargin).SetName("bottom-margin").SetArg("length").
+		SetUsage(i18n.Text("Override the bottom margin used for PDF export (e.g. 0.5in, 1cm)"))
+	cl.NewGeneralOption(&rightMargin).SetName("right-margin").SetArg("length").
+		SetUsage(i18n.Text("Override the right margin used for PDF export (e.g. 0.5in, 1cm)"))
 	cl.NewGeneralOption(&showCopyrightDateAndExit).SetName("copyright-date")
 	cl.NewGeneralOption(&dbg.VariableResolver).SetName("debug-variable-resolver")
 	fileList := jotrotate.ParseAndSetup(cl)
@@ -49,7 +94,62 @@ func main() {
 	}
 	setup.Setup()
 	settings.Global() // Here to force early initialization
-	if textTmplPath != "" {
+	if check {
+		if len(fileList) == 0 {
+			cl.FatalMsg(i18n.Text("No files to process."))
+		}
+		found, err := validation.CheckFiles(os.Stdout, fileList)
+		if err != nil {
+			cl.FatalMsg(err.Error())
+		}
+		if found {
+			atexit.Exit(1)
+		}
+	} else if diffSheets {
+		if len(fileList) != 2 {
+			cl.FatalMsg(i18n.Text("Exactly two files must be specified."))
+		}
+		different, err := diff.DiffFiles(os.Stdout, fileList[0], fileList[1])
+		if err != nil {
+			cl.FatalMsg(err.Error())
+		}
+		if different {
+			atexit.Exit(1)
+		}
+	} else if mergeSheets {
+		if len(fileList) != 3 {
+			cl.FatalMsg(i18n.Text("Exactly three files must be specified."))
+		}
+		if mergeOutput == "" {
+			mergeOutput = fileList[1]
+		}
+		conflicts, err := diff.MergeFiles(os.Stdout, fileList[0], fileList[1], fileList[2], mergeOutput)
+		if err != nil {
+			cl.FatalMsg(err.Error())
+		}
+		if conflicts {
+			atexit.Exit(1)
+		}
+	} else if convertUnits != "" {
+		if len(fileList) == 0 {
+			cl.FatalMsg(i18n.Text("No files to process."))
+		}
+		system := measure.ExtractUnitSystem(convertUnits)
+		if !strings.EqualFold(system.Key(), convertUnits) {
+			cl.FatalMsg(fmt.Sprintf(i18n.Text("Unknown system of measurement: %s. Must be one of: %s"), convertUnits,
+				strings.Join(measure.UnitSystemKeys(), ", ")))
+		}
+		if err := gurps.ConvertUnitsInFiles(os.Stdout, system, fileList); err != nil {
+			cl.FatalMsg(err.Error())
+		}
+	} else if legacyImport {
+		if len(fileList) == 0 {
+			cl.FatalMsg(i18n.Text("No files to process."))
+		}
+		if err := importer.ConvertFiles(os.Stdout, fileList); err != nil {
+			cl.FatalMsg(err.Error())
+		}
+	} else if textTmplPath != "" || pdfExport || jsonExport {
 		if len(fileList) == 0 {
 			cl.FatalMsg(i18n.Text("No files to process."))
 		}
@@ -58,8 +158,27 @@ func main() {
 				cl.FatalMsg(one + i18n.Text(" is not exportable."))
 			}
 		}
-		if err := export.ToText(textTmplPath, fileList); err != nil {
-			cl.FatalMsg(err.Error())
+		if textTmplPath != "" {
+			if err := export.ToText(textTmplPath, fileList); err != nil {
+				cl.FatalMsg(err.Error())
+			}
+		}
+		if jsonExport {
+			if err := export.ToJSON(fileList); err != nil {
+				cl.FatalMsg(err.Error())
+			}
+		}
+		if pdfExport {
+			var overrides gsettings.PageOverrides
+			overrides.ParseSize(paperSize)
+			overrides.ParseOrientation(paperOrientation)
+			overrides.ParseTopMargin(topMargin)
+			overrides.ParseLeftMargin(leftMargin)
+			overrides.ParseBottomMargin(bottomMargin)
+			overrides.ParseRightMargin(rightMargin)
+			if err := export.ToPDF(&overrides, fileList); err != nil {
+				cl.FatalMsg(err.Error())
+			}
 		}
 	} else {
 		ui.Start(fileList) // Never returns
@@ -32,7 +32,7 @@ func ToText(tmplPath string, fileList []string) error {
 			if err != nil {
 				return err
 			}
-			if err = export.LegacyExport(entity, tmplPath, fs.TrimExtension(one)+filepath.Ext(tmplPath)); err != nil {
+			if err = export.Export(entity, tmplPath, fs.TrimExtension(one)+export.TemplateOutputExtension(tmplPath)); err != nil {
 				return err
 			}
 		default:
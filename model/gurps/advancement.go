@@ -0,0 +1,334 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package gurps
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/model/jio"
+	"github.com/richardwilkes/rpgtools/calendar"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/i18n"
+)
+
+const (
+	advancementPointsKey    = "points"
+	advancementAttributeKey = "attr:"
+)
+
+// Advancement categories. These are stored in the log, so must remain stable.
+const (
+	AdvancementPointsCategory    = "points"
+	AdvancementAttributeCategory = "attribute"
+	AdvancementTraitCategory     = "trait"
+	AdvancementSkillCategory     = "skill"
+	AdvancementTechniqueCategory = "technique"
+	AdvancementSpellCategory     = "spell"
+)
+
+// AdvancementLog holds the history of point awards and purchases for a character. Entries are only ever appended.
+type AdvancementLog struct {
+	Entries  []*AdvancementEntry          `json:"entries,omitempty"`
+	Baseline map[string]*AdvancementState `json:"baseline,omitempty"`
+}
+
+// AdvancementEntry holds a single entry in the advancement log. An entry records either a point award, the changes
+// captured since the previous entry, or both.
+type AdvancementEntry struct {
+	RecordedOn jio.Time             `json:"recorded_on"`
+	Date       string               `json:"date,omitempty"`
+	Points     fxp.Int              `json:"points,omitempty"`
+	Reason     string               `json:"reason,omitempty"`
+	Changes    []*AdvancementChange `json:"changes,omitempty"`
+}
+
+// AdvancementState holds the state of a single point-bearing item at the time a baseline was taken.
+type AdvancementState struct {
+	Category string  `json:"category"`
+	Name     string  `json:"name"`
+	Level    string  `json:"level,omitempty"`
+	Points   fxp.Int `json:"points"`
+}
+
+// AdvancementChange holds a single change that was captured between two points in time.
+type AdvancementChange struct {
+	Category string  `json:"category"`
+	Name     string  `json:"name"`
+	Before   string  `json:"before,omitempty"`
+	After    string  `json:"after,omitempty"`
+	Added    bool    `json:"added,omitempty"`
+	Removed  bool    `json:"removed,omitempty"`
+	Points   fxp.Int `json:"points,omitempty"`
+}
+
+// Clone returns a copy of the log. The entries and states are shared, as they are never modified once created.
+func (l *AdvancementLog) Clone() *AdvancementLog {
+	if l == nil {
+		return nil
+	}
+	other := &AdvancementLog{
+		Entries:  make([]*AdvancementEntry, len(l.Entries)),
+		Baseline: make(map[string]*AdvancementState, len(l.Baseline)),
+	}
+	copy(other.Entries, l.Entries)
+	for k, v := range l.Baseline {
+		other.Baseline[k] = v
+	}
+	return other
+}
+
+// Spent returns the number of points spent on the changes in this entry.
+func (e *AdvancementEntry) Spent() fxp.Int {
+	var total fxp.Int
+	for _, one := range e.Changes {
+		total += one.Points
+	}
+	return total
+}
+
+// AdvancementCategoryTitle returns the localized title for an advancement category.
+func AdvancementCategoryTitle(category string) string {
+	switch category {
+	case AdvancementPointsCategory:
+		return i18n.Text("Total Points")
+	case AdvancementAttributeCategory:
+		return i18n.Text("Attribute")
+	case AdvancementTraitCategory:
+		return i18n.Text("Trait")
+	case AdvancementSkillCategory:
+		return i18n.Text("Skill")
+	case AdvancementTechniqueCategory:
+		return i18n.Text("Technique")
+	case AdvancementSpellCategory:
+		return i18n.Text("Spell")
+	default:
+		return category
+	}
+}
+
+func (c *AdvancementChange) String() string {
+	var buffer strings.Builder
+	buffer.WriteString(AdvancementCategoryTitle(c.Category))
+	if c.Name != "" {
+		buffer.WriteByte(' ')
+		buffer.WriteString(c.Name)
+	}
+	switch {
+	case c.Added:
+		buffer.WriteString(i18n.Text(" added"))
+		if c.After != "" {
+			fmt.Fprintf(&buffer, i18n.Text(" at %s"), c.After)
+		}
+	case c.Removed:
+		buffer.WriteString(i18n.Text(" removed"))
+	case c.Before != c.After:
+		fmt.Fprintf(&buffer, i18n.Text(" changed from %s to %s"), c.Before, c.After)
+	default:
+		buffer.WriteString(i18n.Text(" changed"))
+	}
+	if c.Points != 0 {
+		fmt.Fprintf(&buffer, " (%s)", c.Points.StringWithSign())
+	}
+	return buffer.String()
+}
+
+// NormalizeAdvancementDate parses the date using the configured calendar and returns it in a canonical form. An empty
+// date is permitted.
+func NormalizeAdvancementDate(date string) (string, error) {
+	date = strings.TrimSpace(date)
+	if date == "" {
+		return "", nil
+	}
+	cal := SettingsProvider.GeneralSettings().CalendarRef(SettingsProvider.Libraries()).Calendar
+	d, err := cal.ParseDate(date)
+	if err != nil {
+		return "", errs.NewWithCause(fmt.Sprintf(i18n.Text("%s is not a valid date"), date), err)
+	}
+	return d.Format(calendar.LongFormat), nil
+}
+
+// LastAdvancementDate returns the most recent in-game date recorded in the advancement log, or an empty string.
+func (e *Entity) LastAdvancementDate() string {
+	if e.Advancement != nil {
+		for i := len(e.Advancement.Entries) - 1; i >= 0; i-- {
+			if e.Advancement.Entries[i].Date != "" {
+				return e.Advancement.Entries[i].Date
+			}
+		}
+	}
+	return ""
+}
+
+// AwardPoints adds points to the character's total and records the award in the advancement log. Any changes made
+// since the last entry are captured first, so that they are attributed to the points that paid for them.
+func (e *Entity) AwardPoints(points fxp.Int, date, reason string) error {
+	date, err := NormalizeAdvancementDate(date)
+	if err != nil {
+		return err
+	}
+	e.CaptureAdvancement()
+	if e.Advancement == nil {
+		e.Advancement = &AdvancementLog{}
+	}
+	e.TotalPoints += points
+	e.Advancement.Entries = append(e.Advancement.Entries, &AdvancementEntry{
+		RecordedOn: jio.Time(time.Now()),
+		Date:       date,
+		Points:     points,
+		Reason:     strings.TrimSpace(reason),
+	})
+	e.Advancement.Baseline = e.advancementSnapshot()
+	return nil
+}
+
+// PendingAdvancement returns the changes made since the last advancement log entry. Returns nil if the log hasn't been
+// started yet.
+func (e *Entity) PendingAdvancement() []*AdvancementChange {
+	if e.Advancement == nil {
+		return nil
+	}
+	return diffAdvancement(e.Advancement.Baseline, e.advancementSnapshot())
+}
+
+// CaptureAdvancement appends an entry with the changes made since the last entry, if there are any. Nothing is
+// captured until the first points have been awarded, since the character's initial build isn't advancement. Returns
+// true if an entry was added.
+func (e *Entity) CaptureAdvancement() bool {
+	if e.Advancement == nil {
+		return false
+	}
+	current := e.advancementSnapshot()
+	changes := diffAdvancement(e.Advancement.Baseline, current)
+	if len(changes) == 0 {
+		return false
+	}
+	e.Advancement.Entries = append(e.Advancement.Entries, &AdvancementEntry{
+		RecordedOn: jio.Time(time.Now()),
+		Date:       e.LastAdvancementDate(),
+		Changes:    changes,
+	})
+	e.Advancement.Baseline = current
+	return true
+}
+
+func (e *Entity) advancementSnapshot() map[string]*AdvancementState {
+	m := make(map[string]*AdvancementState)
+	m[advancementPointsKey] = &AdvancementState{
+		Category: AdvancementPointsCategory,
+		Level:    e.TotalPoints.String(),
+	}
+	for attrID, attr := range e.Attributes.Set {
+		def := attr.AttributeDef()
+		if def == nil {
+			continue
+		}
+		m[advancementAttributeKey+attrID] = &AdvancementState{
+			Category: AdvancementAttributeCategory,
+			Name:     def.Name,
+			Level:    attr.Maximum().String(),
+			Points:   attr.PointCost(),
+		}
+	}
+	Traverse(func(t *Trait) bool {
+		var level string
+		if t.IsLeveled() {
+			level = t.Levels.String()
+		}
+		m[t.UUID().String()] = &AdvancementState{
+			Category: AdvancementTraitCategory,
+			Name:     t.Name,
+			Level:    level,
+			Points:   t.AdjustedPoints(),
+		}
+		return false
+	}, true, true, e.Traits...)
+	Traverse(func(s *Skill) bool {
+		category := AdvancementSkillCategory
+		if strings.HasPrefix(s.Type, gid.Technique) {
+			category = AdvancementTechniqueCategory
+		}
+		m[s.UUID().String()] = &AdvancementState{
+			Category: category,
+			Name:     s.String(),
+			Level:    advancementLevel(s.LevelData.Level),
+			Points:   s.AdjustedPoints(nil),
+		}
+		return false
+	}, true, true, e.Skills...)
+	Traverse(func(s *Spell) bool {
+		m[s.UUID().String()] = &AdvancementState{
+			Category: AdvancementSpellCategory,
+			Name:     s.String(),
+			Level:    advancementLevel(s.LevelData.Level),
+			Points:   s.AdjustedPoints(nil),
+		}
+		return false
+	}, true, true, e.Spells...)
+	return m
+}
+
+func advancementLevel(level fxp.Int) string {
+	if level <= 0 {
+		return "-"
+	}
+	return level.String()
+}
+
+func diffAdvancement(before, after map[string]*AdvancementState) []*AdvancementChange {
+	var changes []*AdvancementChange
+	for k, now := range after {
+		was, exists := before[k]
+		switch {
+		case !exists:
+			changes = append(changes, &AdvancementChange{
+				Category: now.Category,
+				Name:     now.Name,
+				After:    now.Level,
+				Added:    true,
+				Points:   now.Points,
+			})
+		case was.Points != now.Points || (k == advancementPointsKey && was.Level != now.Level):
+			// Changes that cost nothing, such as a skill's level shifting because its attribute was raised, are left
+			// to the entry that paid for them.
+			changes = append(changes, &AdvancementChange{
+				Category: now.Category,
+				Name:     now.Name,
+				Before:   was.Level,
+				After:    now.Level,
+				Points:   now.Points - was.Points,
+			})
+		}
+	}
+	for k, was := range before {
+		if _, exists := after[k]; !exists {
+			changes = append(changes, &AdvancementChange{
+				Category: was.Category,
+				Name:     was.Name,
+				Before:   was.Level,
+				Removed:  true,
+				Points:   -was.Points,
+			})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Category != changes[j].Category {
+			return changes[i].Category < changes[j].Category
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package gurps_test
+
+import (
+	"testing"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/datafile"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/model/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAdvancementEntity returns an entity with a single DX-based skill whose advancement log has been started.
+func newAdvancementEntity(t *testing.T) (entity *gurps.Entity, sk *gurps.Skill) {
+	t.Helper()
+	settings.Global()
+	entity = gurps.NewEntity(datafile.PC)
+	entity.Traits = nil
+	sk = gurps.NewSkill(entity, nil, false)
+	sk.Name = "Acrobatics"
+	sk.Points = fxp.Two
+	entity.Skills = []*gurps.Skill{sk}
+	entity.Recalculate()
+	require.NoError(t, entity.AwardPoints(fxp.Ten, "", "Session 1"))
+	return entity, sk
+}
+
+func TestAdvancementIgnoresFreeLevelChanges(t *testing.T) {
+	entity, sk := newAdvancementEntity(t)
+	assert.Empty(t, entity.PendingAdvancement())
+	before := sk.LevelData.Level
+
+	dx := entity.Attributes.Set[gurps.AttributeIDFor(entity, gid.Dexterity)]
+	require.NotNil(t, dx)
+	dx.SetMaximum(dx.Maximum() + fxp.One)
+	entity.Recalculate()
+	require.Equal(t, before+fxp.One, sk.LevelData.Level, "skill level should follow DX")
+
+	changes := entity.PendingAdvancement()
+	require.Len(t, changes, 1, "the skill's level shift cost nothing and must not be recorded")
+	assert.Equal(t, gurps.AdvancementAttributeCategory, changes[0].Category)
+	assert.Equal(t, "attribute", changes[0].Category, "categories are stored, so must not be localized")
+	assert.Equal(t, "10", changes[0].Before)
+	assert.Equal(t, "11", changes[0].After)
+	assert.Equal(t, fxp.From(20), changes[0].Points)
+
+	require.True(t, entity.CaptureAdvancement())
+	assert.Empty(t, entity.PendingAdvancement())
+	assert.False(t, entity.CaptureAdvancement())
+}
+
+func TestAdvancementRecordsPurchases(t *testing.T) {
+	entity, sk := newAdvancementEntity(t)
+	require.Len(t, entity.Advancement.Entries, 1)
+	award := entity.Advancement.Entries[0]
+	assert.Equal(t, fxp.Ten, award.Points)
+	assert.Equal(t, "Session 1", award.Reason)
+	assert.Empty(t, award.Changes)
+
+	sk.Points = fxp.From(4)
+	added := gurps.NewSkill(entity, nil, false)
+	added.Name = "Climbing"
+	entity.Skills = append(entity.Skills, added)
+	entity.Recalculate()
+
+	require.NoError(t, entity.AwardPoints(fxp.Ten, "", "Session 2"))
+	require.Len(t, entity.Advancement.Entries, 3, "pending changes are captured before the award")
+	captured := entity.Advancement.Entries[1]
+	require.Len(t, captured.Changes, 2)
+	for _, change := range captured.Changes {
+		assert.Equal(t, gurps.AdvancementSkillCategory, change.Category)
+		switch change.Name {
+		case "Acrobatics":
+			assert.False(t, change.Added)
+			assert.Equal(t, fxp.Two, change.Points)
+		case "Climbing":
+			assert.True(t, change.Added)
+			assert.Equal(t, fxp.One, change.Points)
+		default:
+			t.Errorf("unexpected change for %q", change.Name)
+		}
+	}
+	assert.Equal(t, fxp.Ten, entity.Advancement.Entries[2].Points)
+
+	entity.Skills = entity.Skills[:1]
+	entity.Recalculate()
+	changes := entity.PendingAdvancement()
+	require.Len(t, changes, 1)
+	assert.True(t, changes[0].Removed)
+	assert.Equal(t, "Climbing", changes[0].Name)
+	assert.Equal(t, -fxp.One, changes[0].Points)
+}
@@ -12,10 +12,12 @@
 package ancestry
 
 import (
+	"bytes"
 	"context"
 	"io/fs"
 	"strings"
 
+	"github.com/richardwilkes/gcs/v5/model/crc"
 	"github.com/richardwilkes/gcs/v5/model/gurps/measure"
 	"github.com/richardwilkes/gcs/v5/model/jio"
 	"github.com/richardwilkes/gcs/v5/model/library"
@@ -36,7 +38,7 @@ type Ancestry struct {
 
 // AvailableAncestries scans the libraries and returns the available ancestries.
 func AvailableAncestries(libraries library.Libraries) []*library.NamedFileSet {
-	return library.ScanForNamedFileSets(embeddedFS, "data", true, libraries, ".ancestry")
+	return library.ScanForNamedFileSets(embeddedFS, "data", true, libraries, library.AncestryExt)
 }
 
 // Lookup an Ancestry by name.
@@ -67,11 +69,43 @@ func NewAncestryFromFile(fileSystem fs.FS, filePath string) (*Ancestry, error) {
 	return &ancestry, nil
 }
 
+// NewAncestry creates a new, empty, Ancestry.
+func NewAncestry() *Ancestry {
+	return &Ancestry{CommonOptions: &Options{}}
+}
+
 // Save writes the Ancestry to the file as JSON.
 func (a *Ancestry) Save(filePath string) error {
 	return jio.SaveToFile(context.Background(), filePath, a)
 }
 
+// CRC64 computes a CRC-64 value for the canonical disk format of the data.
+func (a *Ancestry) CRC64() uint64 {
+	var buffer bytes.Buffer
+	if err := jio.Save(context.Background(), &buffer, a); err != nil {
+		return 0
+	}
+	return crc.Bytes(0, buffer.Bytes())
+}
+
+// Clone creates a copy of this Ancestry.
+func (a *Ancestry) Clone() *Ancestry {
+	other := &Ancestry{Name: a.Name}
+	if a.CommonOptions != nil {
+		other.CommonOptions = a.CommonOptions.Clone()
+	}
+	if len(a.GenderOptions) != 0 {
+		other.GenderOptions = make([]*WeightedAncestryOptions, len(a.GenderOptions))
+		for i, one := range a.GenderOptions {
+			other.GenderOptions[i] = &WeightedAncestryOptions{Weight: one.Weight}
+			if one.Value != nil {
+				other.GenderOptions[i].Value = one.Value.Clone()
+			}
+		}
+	}
+	return other
+}
+
 // RandomGender returns a randomized gender.
 func (a *Ancestry) RandomGender(not string) string {
 	if choice := ChooseWeightedAncestryOptions(a.GenderOptions, func(o *Options) bool {
@@ -12,11 +12,13 @@
 package ancestry
 
 import (
+	"bytes"
 	"context"
 	"io/fs"
 	"strings"
 	"unicode/utf8"
 
+	"github.com/richardwilkes/gcs/v5/model/crc"
 	"github.com/richardwilkes/gcs/v5/model/jio"
 	"github.com/richardwilkes/toolbox/txt"
 	"github.com/richardwilkes/toolbox/xmath/rand"
@@ -46,6 +48,31 @@ func NewNameGeneratorFromFS(fileSystem fs.FS, filePath string) (*NameGenerator,
 	return &generator, nil
 }
 
+// Save writes the NameGenerator to the file as JSON.
+func (n *NameGenerator) Save(filePath string) error {
+	return jio.SaveToFile(context.Background(), filePath, n)
+}
+
+// CRC64 computes a CRC-64 value for the canonical disk format of the data.
+func (n *NameGenerator) CRC64() uint64 {
+	var buffer bytes.Buffer
+	if err := jio.Save(context.Background(), &buffer, n); err != nil {
+		return 0
+	}
+	return crc.Bytes(0, buffer.Bytes())
+}
+
+// Clone creates a copy of this NameGenerator. Since generating a name normalizes the training data, a clone should be
+// used when the original training data needs to be preserved, such as when previewing the results while editing.
+func (n *NameGenerator) Clone() *NameGenerator {
+	other := &NameGenerator{Type: n.Type}
+	if len(n.TrainingData) != 0 {
+		other.TrainingData = make([]string, len(n.TrainingData))
+		copy(other.TrainingData, n.TrainingData)
+	}
+	return other
+}
+
 func (n *NameGenerator) initializeIfNeeded() {
 	if !n.initialized {
 		list := make([]string, 0, len(n.TrainingData))
@@ -44,6 +44,32 @@ type Options struct {
 	NameGenerators    []string        `json:"name_generators,omitempty"`
 }
 
+// Clone creates a copy of these Options.
+func (o *Options) Clone() *Options {
+	other := *o
+	other.HairOptions = cloneStringOptions(o.HairOptions)
+	other.EyeOptions = cloneStringOptions(o.EyeOptions)
+	other.SkinOptions = cloneStringOptions(o.SkinOptions)
+	other.HandednessOptions = cloneStringOptions(o.HandednessOptions)
+	if len(o.NameGenerators) != 0 {
+		other.NameGenerators = make([]string, len(o.NameGenerators))
+		copy(other.NameGenerators, o.NameGenerators)
+	}
+	return &other
+}
+
+func cloneStringOptions(options []*StringOption) []*StringOption {
+	if len(options) == 0 {
+		return nil
+	}
+	list := make([]*StringOption, len(options))
+	for i, one := range options {
+		o := *one
+		list[i] = &o
+	}
+	return list
+}
+
 // RandomHeight returns a randomized height.
 func (o *Options) RandomHeight(resolver eval.VariableResolver, not measure.Length) measure.Length {
 	def := measure.LengthFromInteger(defaultHeight, measure.Inch)
@@ -22,6 +22,7 @@ import (
 	"github.com/richardwilkes/gcs/v5/model/crc"
 	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
 	"github.com/richardwilkes/gcs/v5/model/jio"
+	"github.com/richardwilkes/gcs/v5/model/library"
 	"github.com/richardwilkes/rpgtools/dice"
 	"github.com/richardwilkes/toolbox/errs"
 	"github.com/richardwilkes/toolbox/log/jot"
@@ -71,7 +72,7 @@ func FactoryBodies() []*Body {
 	list := make([]*Body, 0, len(entries))
 	for _, entry := range entries {
 		name := entry.Name()
-		if path.Ext(name) == ".body" {
+		if path.Ext(name) == library.BodyExt {
 			var bodyType *Body
 			bodyType, err = NewBodyFromFile(embeddedFS, "data/body/"+name)
 			jot.FatalIfErr(err)
@@ -119,6 +120,7 @@ func (b *Body) Clone(entity *Entity, owningLocation *HitLocation) *Body {
 		Name:           b.Name,
 		Roll:           dice.New(b.Roll.String()),
 		Locations:      make([]*HitLocation, len(b.Locations)),
+		KeyPrefix:      b.KeyPrefix,
 		owningLocation: owningLocation,
 	}
 	for i, one := range b.Locations {
@@ -144,6 +146,11 @@ func (b *Body) Update(entity *Entity) {
 	b.populateMap(entity, b.locationLookup)
 }
 
+// OwningLocation returns the HitLocation this Body is a sub-table of, or nil if it is the top-level table.
+func (b *Body) OwningLocation() *HitLocation {
+	return b.owningLocation
+}
+
 // SetOwningLocation sets the owning HitLocation.
 func (b *Body) SetOwningLocation(loc *HitLocation) {
 	b.owningLocation = loc
@@ -171,6 +178,17 @@ func (b *Body) AddLocation(loc *HitLocation) {
 	loc.owningTable = b
 }
 
+// InsertLocation inserts a HitLocation at the given index. An index outside the list appends it to the end.
+func (b *Body) InsertLocation(index int, loc *HitLocation) {
+	if index < 0 || index > len(b.Locations) {
+		index = len(b.Locations)
+	}
+	b.Locations = append(b.Locations, nil)
+	copy(b.Locations[index+1:], b.Locations[index:])
+	b.Locations[index] = loc
+	loc.owningTable = b
+}
+
 // RemoveLocation removes a HitLocation.
 func (b *Body) RemoveLocation(loc *HitLocation) {
 	for i, one := range b.Locations {
@@ -0,0 +1,340 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package gurps
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/richardwilkes/gcs/v5/model/crc"
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/model/id"
+	"github.com/richardwilkes/gcs/v5/model/jio"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+)
+
+const (
+	campaignTypeKey = "campaign"
+	// CampaignTagPrefix marks an entry in a Campaign's trait lists as a tag rather than a trait name.
+	CampaignTagPrefix = "tag:"
+)
+
+// Campaign holds the house rules for a campaign. Sheets that are linked to a campaign adopt its sheet settings and are
+// checked against its rules.
+type Campaign struct {
+	Type              string         `json:"type"`
+	Version           int            `json:"version"`
+	ID                uuid.UUID      `json:"id"`
+	Name              string         `json:"name,omitempty"`
+	StartingPoints    fxp.Int        `json:"starting_points"`
+	DisadvantageLimit fxp.Int        `json:"disadvantage_limit,omitempty"`
+	QuirkLimit        fxp.Int        `json:"quirk_limit,omitempty"`
+	MinimumTechLevel  string         `json:"min_tech_level,omitempty"`
+	MaximumTechLevel  string         `json:"max_tech_level,omitempty"`
+	MinLegalityClass  string         `json:"min_legality_class,omitempty"`
+	ForbiddenTraits   []string       `json:"forbidden_traits,omitempty"`
+	RequiredTraits    []string       `json:"required_traits,omitempty"`
+	AllowedLibraries  []string       `json:"allowed_libraries,omitempty"`
+	SheetSettings     *SheetSettings `json:"sheet_settings,omitempty"`
+	libraryNames      map[string]map[string]bool
+}
+
+// NewCampaign creates a new Campaign, using the current general and sheet settings as its starting point.
+func NewCampaign() *Campaign {
+	general := SettingsProvider.GeneralSettings()
+	return &Campaign{
+		Type:              campaignTypeKey,
+		Version:           gid.CurrentDataVersion,
+		ID:                id.NewUUID(),
+		StartingPoints:    general.InitialPoints,
+		DisadvantageLimit: general.DisadvantageLimit,
+		QuirkLimit:        general.QuirkLimit,
+		SheetSettings:     SheetSettingsFor(nil).Clone(nil),
+	}
+}
+
+// NewCampaignFromFile loads a Campaign from a file.
+func NewCampaignFromFile(fileSystem fs.FS, filePath string) (*Campaign, error) {
+	var campaign Campaign
+	if err := jio.LoadFromFS(context.Background(), fileSystem, filePath, &campaign); err != nil {
+		return nil, errs.NewWithCause(gid.InvalidFileDataMsg, err)
+	}
+	if campaign.Type != campaignTypeKey {
+		return nil, errs.New(gid.UnexpectedFileDataMsg)
+	}
+	if err := gid.CheckVersion(campaign.Version); err != nil {
+		return nil, err
+	}
+	if campaign.SheetSettings == nil {
+		campaign.SheetSettings = FactorySheetSettings()
+	}
+	return &campaign, nil
+}
+
+// LoadCampaignForSheet loads the Campaign a sheet refers to. Relative references are resolved against the directory
+// the sheet resides in. If the campaign file can't be found where the reference says it should be, a file of the same
+// name next to the sheet will be used instead, as that is typically where it ends up when a campaign's files are shared
+// between players.
+func LoadCampaignForSheet(sheetPath, ref string) (*Campaign, string, error) {
+	dir := filepath.Dir(sheetPath)
+	candidates := make([]string, 0, 2)
+	if filepath.IsAbs(ref) {
+		candidates = append(candidates, ref)
+	} else {
+		candidates = append(candidates, filepath.Join(dir, ref))
+	}
+	candidates = append(candidates, filepath.Join(dir, filepath.Base(ref)))
+	for _, one := range candidates {
+		if fi, err := os.Stat(one); err == nil && fi.Mode().IsRegular() {
+			campaign, loadErr := NewCampaignFromFile(os.DirFS(filepath.Dir(one)), filepath.Base(one))
+			return campaign, one, loadErr
+		}
+	}
+	return nil, "", errs.New("unable to locate campaign file: " + ref)
+}
+
+// CampaignRef returns the reference a sheet at sheetPath should store to refer to the campaign at campaignPath. When
+// possible, this will be relative to the sheet.
+func CampaignRef(sheetPath, campaignPath string) string {
+	if filepath.IsAbs(sheetPath) {
+		if rel, err := filepath.Rel(filepath.Dir(sheetPath), campaignPath); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return campaignPath
+}
+
+// Save the Campaign to a file as JSON.
+func (c *Campaign) Save(filePath string) error {
+	return jio.SaveToFile(context.Background(), filePath, c)
+}
+
+// CRC64 computes a CRC-64 value for the canonical disk format of the data.
+func (c *Campaign) CRC64() uint64 {
+	var buffer bytes.Buffer
+	if err := jio.Save(context.Background(), &buffer, c); err != nil {
+		return 0
+	}
+	return crc.Bytes(0, buffer.Bytes())
+}
+
+// ApplyTo makes the entity adopt the campaign's sheet settings. Attributes the campaign doesn't define are kept, so that
+// they return intact should the entity stop using the campaign. Nothing is changed if the entity has already adopted the
+// campaign's sheet settings.
+func (c *Campaign) ApplyTo(entity *Entity) {
+	if c.AppliedTo(entity) {
+		return
+	}
+	entity.SheetSettings = c.SheetSettings.Clone(entity)
+	entity.SheetSettings.SetOwningEntity(entity)
+	entity.AddMissingAttributes()
+}
+
+// AppliedTo returns true if the entity has already adopted the campaign's sheet settings.
+func (c *Campaign) AppliedTo(entity *Entity) bool {
+	if entity.SheetSettings == nil {
+		return false
+	}
+	for attrID := range c.SheetSettings.Attributes.Set {
+		if _, exists := entity.Attributes.Set[attrID]; !exists {
+			return false
+		}
+	}
+	// Compare against a copy owned by the entity, as some of the data that gets written depends on the owner.
+	candidate := c.SheetSettings.Clone(entity)
+	candidate.SetOwningEntity(entity)
+	var ours, theirs bytes.Buffer
+	if jio.Save(context.Background(), &ours, candidate) != nil ||
+		jio.Save(context.Background(), &theirs, entity.SheetSettings) != nil {
+		return false
+	}
+	return bytes.Equal(ours.Bytes(), theirs.Bytes())
+}
+
+// Link the entity to the campaign. ref is the reference to the campaign file the entity should store, typically
+// obtained from CampaignRef(). The entity's own sheet settings are set aside, so that UnlinkCampaign() can restore them,
+// and the campaign's starting points are adopted unless the entity has already been given a custom amount.
+func (c *Campaign) Link(entity *Entity, ref string) {
+	if entity.TotalPoints == SettingsProvider.GeneralSettings().InitialPoints {
+		entity.TotalPoints = c.StartingPoints
+	}
+	if entity.PreCampaignSettings == nil {
+		entity.PreCampaignSettings = entity.SheetSettings.Clone(entity)
+	}
+	entity.Campaign = ref
+	c.ApplyTo(entity)
+}
+
+// UnlinkCampaign removes the entity's link to its campaign, restoring the sheet settings it had before it was linked.
+func (e *Entity) UnlinkCampaign() {
+	if e.PreCampaignSettings != nil {
+		e.SheetSettings = e.PreCampaignSettings
+		e.PreCampaignSettings = nil
+		e.SheetSettings.SetOwningEntity(e)
+		e.AddMissingAttributes()
+	}
+	e.Campaign = ""
+}
+
+// TechLevelRange returns the minimum and maximum tech levels permitted. A value of -1 means there is no limit.
+func (c *Campaign) TechLevelRange() (min, max fxp.Int) {
+	min = -fxp.One
+	max = -fxp.One
+	if tl, start, _ := ExtractTechLevel(c.MinimumTechLevel); start != -1 {
+		min = tl
+	}
+	if tl, start, _ := ExtractTechLevel(c.MaximumTechLevel); start != -1 {
+		max = tl
+	}
+	return min, max
+}
+
+// LegalityClassAllowed returns true if equipment with the given legality class may be acquired in this campaign.
+// Legality classes that don't start with a number are always allowed.
+func (c *Campaign) LegalityClassAllowed(lc string) bool {
+	min, ok := legalityClassValue(c.MinLegalityClass)
+	if !ok {
+		return true
+	}
+	value, ok := legalityClassValue(lc)
+	return !ok || value >= min
+}
+
+func legalityClassValue(lc string) (int, bool) {
+	lc = strings.TrimSpace(lc)
+	if lc == "" || lc[0] < '0' || lc[0] > '9' {
+		return 0, false
+	}
+	return int(lc[0] - '0'), true
+}
+
+// EquipmentRestriction returns the reason the equipment may not be acquired in this campaign, or an empty string if
+// there is no such restriction. If includeContents is true, anything the equipment contains is checked as well.
+func (c *Campaign) EquipmentRestriction(eqp *Equipment, includeContents bool) string {
+	min, max := c.TechLevelRange()
+	var reason string
+	check := func(one *Equipment) bool {
+		if tl, start, _ := ExtractTechLevel(one.TechLevel); start != -1 {
+			if max >= 0 && tl > max {
+				reason = fmt.Sprintf(i18n.Text("%s: tech level %s is above the campaign's maximum"), one.String(),
+					tl.String())
+				return true
+			}
+			if min >= 0 && tl < min {
+				reason = fmt.Sprintf(i18n.Text("%s: tech level %s is below the campaign's minimum"), one.String(),
+					tl.String())
+				return true
+			}
+		}
+		if !c.LegalityClassAllowed(one.LegalityClass) {
+			reason = fmt.Sprintf(i18n.Text("%s: legality class %s is below the campaign's minimum"), one.String(),
+				strings.TrimSpace(one.LegalityClass))
+			return true
+		}
+		return false
+	}
+	if includeContents {
+		Traverse(check, false, false, eqp)
+	} else {
+		check(eqp)
+	}
+	return reason
+}
+
+// TraitMatches returns true if the trait matches the rule, which is either a trait name or, if prefixed with
+// CampaignTagPrefix, a tag. Comparisons are case-insensitive.
+func TraitMatches(t *Trait, rule string) bool {
+	rule = strings.TrimSpace(rule)
+	if strings.HasPrefix(strings.ToLower(rule), CampaignTagPrefix) {
+		tag := strings.TrimSpace(rule[len(CampaignTagPrefix):])
+		for _, one := range t.Tags {
+			if strings.EqualFold(one, tag) {
+				return true
+			}
+		}
+		return false
+	}
+	return strings.EqualFold(t.Name, rule)
+}
+
+// LibrariesRestricted returns true if the campaign limits which libraries may be used.
+func (c *Campaign) LibrariesRestricted() bool {
+	return len(c.AllowedLibraries) != 0
+}
+
+// LibraryHas returns true if an item with the given name exists in one of the campaign's allowed libraries. ext is the
+// file extension used for that kind of data, e.g. library.TraitsExt. The library contents are loaded the first time
+// they are needed and not refreshed afterward, so the campaign should be reloaded to pick up any changes.
+func (c *Campaign) LibraryHas(ext, name string) bool {
+	if c.libraryNames == nil {
+		c.libraryNames = make(map[string]map[string]bool)
+	}
+	names, ok := c.libraryNames[ext]
+	if !ok {
+		names = c.loadLibraryNames(ext)
+		c.libraryNames[ext] = names
+	}
+	return names[strings.ToLower(strings.TrimSpace(name))]
+}
+
+func (c *Campaign) loadLibraryNames(ext string) map[string]bool {
+	names := make(map[string]bool)
+	all := SettingsProvider.Libraries()
+	allowed := make(library.Libraries)
+	for _, key := range c.AllowedLibraries {
+		if lib, ok := all[key]; ok {
+			allowed[key] = lib
+		}
+	}
+	add := func(name string) { names[strings.ToLower(strings.TrimSpace(name))] = true }
+	for _, set := range library.ScanForDataFiles(allowed, ext) {
+		for _, ref := range set.List {
+			var err error
+			switch ext {
+			case library.TraitsExt:
+				var rows []*Trait
+				if rows, err = NewTraitsFromFile(ref.FileSystem, ref.FilePath); err == nil {
+					Traverse(func(row *Trait) bool { add(row.Name); return false }, true, false, rows...)
+				}
+			case library.SkillsExt:
+				var rows []*Skill
+				if rows, err = NewSkillsFromFile(ref.FileSystem, ref.FilePath); err == nil {
+					Traverse(func(row *Skill) bool { add(row.Name); return false }, true, false, rows...)
+				}
+			case library.SpellsExt:
+				var rows []*Spell
+				if rows, err = NewSpellsFromFile(ref.FileSystem, ref.FilePath); err == nil {
+					Traverse(func(row *Spell) bool { add(row.Name); return false }, true, false, rows...)
+				}
+			case library.EquipmentExt:
+				var rows []*Equipment
+				if rows, err = NewEquipmentFromFile(ref.FileSystem, ref.FilePath); err == nil {
+					Traverse(func(row *Equipment) bool { add(row.Name); return false }, true, false, rows...)
+				}
+			}
+			if err != nil {
+				jot.Warn(err)
+			}
+		}
+	}
+	return names
+}
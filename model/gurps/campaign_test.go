@@ -0,0 +1,101 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package gurps_test
+
+import (
+	"testing"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/datafile"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/model/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCampaignLinkKeepsCustomAttributes(t *testing.T) {
+	settings.Global()
+	e := gurps.NewEntity(datafile.PC)
+	def := e.SheetSettings.Attributes.Set[gid.Will].Clone()
+	def.DefID = "san"
+	def.Name = "San"
+	def.Order = len(e.SheetSettings.Attributes.Set)
+	e.SheetSettings.Attributes.Set[def.DefID] = def
+	e.AddMissingAttributes()
+	require.Contains(t, e.Attributes.Set, "san")
+	e.Attributes.Set["san"].Adjustment = fxp.From(3)
+	e.Attributes.Set[gid.Strength].Adjustment = fxp.Two
+	sanMax := e.Attributes.Maximum("san")
+	ownSettings := e.SheetSettings
+
+	c := gurps.NewCampaign()
+	require.NotContains(t, c.SheetSettings.Attributes.Set, "san")
+	c.Link(e, "campaign.campaign")
+	assert.Equal(t, "campaign.campaign", e.Campaign)
+	assert.True(t, c.AppliedTo(e))
+	assert.NotContains(t, e.SheetSettings.Attributes.Set, "san")
+	require.Contains(t, e.Attributes.Set, "san", "attributes the campaign doesn't define must be kept")
+	assert.Equal(t, fxp.From(3), e.Attributes.Set["san"].Adjustment)
+	assert.Equal(t, fxp.Two, e.Attributes.Set[gid.Strength].Adjustment)
+	require.NotNil(t, e.PreCampaignSettings)
+	assert.NotSame(t, e.PreCampaignSettings, e.SheetSettings)
+
+	// Re-applying an already adopted campaign, as happens each time the sheet is opened, must not change anything.
+	applied := e.SheetSettings
+	c.ApplyTo(e)
+	assert.Same(t, applied, e.SheetSettings)
+
+	e.UnlinkCampaign()
+	assert.Empty(t, e.Campaign)
+	assert.Nil(t, e.PreCampaignSettings)
+	require.Contains(t, e.SheetSettings.Attributes.Set, "san")
+	assert.Same(t, e, e.SheetSettings.Entity)
+	assert.Equal(t, fxp.From(3), e.Attributes.Set["san"].Adjustment)
+	assert.Equal(t, sanMax, e.Attributes.Maximum("san"))
+	assert.Equal(t, fxp.Two, e.Attributes.Set[gid.Strength].Adjustment)
+	assert.NotSame(t, ownSettings, e.SheetSettings)
+}
+
+func TestCampaignEquipmentRestriction(t *testing.T) {
+	settings.Global()
+	c := gurps.NewCampaign()
+	c.MinimumTechLevel = "3"
+	c.MaximumTechLevel = "5"
+	for _, tc := range []struct {
+		tl       string
+		contents string
+		expected string
+	}{
+		{tl: "4"},
+		{tl: "3"},
+		{tl: "5"},
+		{tl: ""},
+		{tl: "2", expected: "Item: tech level 2 is below the campaign's minimum"},
+		{tl: "0", expected: "Item: tech level 0 is below the campaign's minimum"},
+		{tl: "6", expected: "Item: tech level 6 is above the campaign's maximum"},
+		{tl: "4", contents: "6", expected: "Contents: tech level 6 is above the campaign's maximum"},
+		{tl: "4", contents: "1", expected: "Contents: tech level 1 is below the campaign's minimum"},
+	} {
+		eqp := gurps.NewEquipment(nil, nil, tc.contents != "")
+		eqp.Name = "Item"
+		eqp.TechLevel = tc.tl
+		if tc.contents != "" {
+			child := gurps.NewEquipment(nil, eqp, false)
+			child.Name = "Contents"
+			child.TechLevel = tc.contents
+			eqp.Children = []*gurps.Equipment{child}
+			assert.Empty(t, c.EquipmentRestriction(eqp, false), tc.tl+"/"+tc.contents)
+		}
+		assert.Equal(t, tc.expected, c.EquipmentRestriction(eqp, true), tc.tl+"/"+tc.contents)
+	}
+}
@@ -0,0 +1,405 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+// Package combat provides the rules needed to run combat at the table, such as resolving the damage from an attack.
+package combat
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/i18n"
+)
+
+// DamageType holds the rules for a single type of damage.
+type DamageType struct {
+	Key           string
+	Name          string
+	WoundModifier fxp.Int
+	// Specializations holds the DR specializations (lower case) that apply to this type of damage, in addition to "all".
+	Specializations []string
+	Fatigue         bool
+	Affliction      bool
+	Piercing        bool
+	Impaling        bool
+	Crushing        bool
+	Cutting         bool
+	Burning         bool
+	Corrosion       bool
+	Toxic           bool
+}
+
+// DamageTypes holds the damage types that the resolver understands, in the order they are usually listed.
+var DamageTypes = []*DamageType{
+	{Key: "cr", Name: i18n.Text("Crushing"), WoundModifier: fxp.One, Specializations: []string{"cr", "crushing"}, Crushing: true},
+	{Key: "cut", Name: i18n.Text("Cutting"), WoundModifier: fxp.OneAndAHalf, Specializations: []string{"cut", "cutting"}, Cutting: true},
+	{Key: "imp", Name: i18n.Text("Impaling"), WoundModifier: fxp.Two, Specializations: []string{"imp", "impaling"}, Impaling: true},
+	{Key: "pi-", Name: i18n.Text("Small Piercing"), WoundModifier: fxp.Half, Specializations: []string{"pi-", "pi", "piercing"}, Piercing: true},
+	{Key: "pi", Name: i18n.Text("Piercing"), WoundModifier: fxp.One, Specializations: []string{"pi", "piercing"}, Piercing: true},
+	{Key: "pi+", Name: i18n.Text("Large Piercing"), WoundModifier: fxp.OneAndAHalf, Specializations: []string{"pi+", "pi", "piercing"}, Piercing: true},
+	{Key: "pi++", Name: i18n.Text("Huge Piercing"), WoundModifier: fxp.Two, Specializations: []string{"pi++", "pi", "piercing"}, Piercing: true},
+	{Key: "burn", Name: i18n.Text("Burning"), WoundModifier: fxp.One, Specializations: []string{"burn", "burning", "fire", "heat"}, Burning: true},
+	{Key: "cor", Name: i18n.Text("Corrosion"), WoundModifier: fxp.One, Specializations: []string{"cor", "corrosion", "corrosive", "acid"}, Corrosion: true},
+	{Key: "tox", Name: i18n.Text("Toxic"), WoundModifier: fxp.One, Specializations: []string{"tox", "toxic"}, Toxic: true},
+	{Key: "fat", Name: i18n.Text("Fatigue"), WoundModifier: fxp.One, Specializations: []string{"fat", "fatigue"}, Fatigue: true},
+	{Key: "aff", Name: i18n.Text("Affliction"), WoundModifier: 0, Specializations: []string{"aff", "affliction"}, Affliction: true},
+}
+
+// LookupDamageType returns the DamageType for the given key, e.g. "cut", or nil. Weapon damage strings such as "imp"
+// or "pi+" may be passed directly.
+func LookupDamageType(key string) *DamageType {
+	key = strings.ToLower(strings.TrimSpace(key))
+	for _, one := range DamageTypes {
+		if one.Key == key || strings.EqualFold(one.Name, key) {
+			return one
+		}
+	}
+	switch key {
+	case "crushing":
+		return DamageTypes[0]
+	case "cutting":
+		return DamageTypes[1]
+	case "impaling":
+		return DamageTypes[2]
+	case "piercing":
+		return DamageTypes[4]
+	case "burning", "fire":
+		return DamageTypes[7]
+	case "corrosive":
+		return DamageTypes[8]
+	case "fatigue":
+		return DamageTypes[10]
+	case "spec", "affliction":
+		return DamageTypes[11]
+	}
+	return nil
+}
+
+// Attack describes an incoming attack.
+type Attack struct {
+	// Damage is the basic damage rolled for the attack.
+	Damage int
+	// Type is the type of damage.
+	Type *DamageType
+	// ArmorDivisor divides the target's DR. Zero or one means no divisor. Values less than one, such as 0.5, multiply
+	// the target's DR instead.
+	ArmorDivisor fxp.Int
+	// LocationID is the ID of the hit location struck. If empty, the location is determined by rolling against the
+	// target's body.
+	LocationID string
+	// TightBeamBurning should be set for laser-like burning attacks, which may target the eyes and vitals.
+	TightBeamBurning bool
+}
+
+// Result holds the outcome of resolving an attack against a target.
+type Result struct {
+	Attack           *Attack
+	Location         *gurps.HitLocation
+	LocationRoll     int
+	DR               int
+	EffectiveDR      int
+	Penetrating      int
+	WoundModifier    fxp.Int
+	Injury           int
+	CripplingInjury  int
+	Crippled         bool
+	MajorWound       bool
+	ShockPenalty     int
+	PoolID           string
+	PoolName         string
+	PoolBefore       fxp.Int
+	PoolAfter        fxp.Int
+	Notes            []string
+	excessInjuryLost int
+}
+
+type locationKind int
+
+const (
+	torsoLocation locationKind = iota
+	skullLocation
+	eyeLocation
+	faceLocation
+	neckLocation
+	vitalsLocation
+	groinLocation
+	limbLocation
+	extremityLocation
+)
+
+func kindOfLocation(locID string) locationKind {
+	switch strings.ToLower(locID) {
+	case "skull", "brain":
+		return skullLocation
+	case "eye":
+		return eyeLocation
+	case "face":
+		return faceLocation
+	case "neck":
+		return neckLocation
+	case "vitals":
+		return vitalsLocation
+	case "groin":
+		return groinLocation
+	case "arm", "leg", "wing", "tail":
+		return limbLocation
+	case "hand", "foot", "fin":
+		return extremityLocation
+	default:
+		return torsoLocation
+	}
+}
+
+// Resolve works out the effect of the attack on the entity, without changing the entity. Call Apply() on the result
+// to update the entity's pools.
+func Resolve(entity *gurps.Entity, attack *Attack) (*Result, error) {
+	if attack.Type == nil {
+		return nil, errs.New(i18n.Text("a damage type is required"))
+	}
+	r := &Result{Attack: attack}
+	body := gurps.BodyFor(entity)
+	if attack.LocationID != "" {
+		if r.Location = body.LookupLocationByID(entity, attack.LocationID); r.Location == nil {
+			return nil, errs.New(fmt.Sprintf(i18n.Text("%s is not a hit location of %s"), attack.LocationID, body.Name))
+		}
+	} else {
+		r.Location, r.LocationRoll = RollLocation(entity, body)
+		if r.Location == nil {
+			return nil, errs.New(i18n.Text("unable to determine the hit location"))
+		}
+	}
+	kind := kindOfLocation(r.Location.LocID)
+	if (kind == eyeLocation || kind == vitalsLocation) && !attack.Type.Impaling && !attack.Type.Piercing &&
+		!(attack.Type.Burning && attack.TightBeamBurning) {
+		fallbackID := gid.Torso
+		if kind == eyeLocation {
+			fallbackID = "face"
+		}
+		if fallback := body.LookupLocationByID(entity, fallbackID); fallback != nil {
+			r.note(fmt.Sprintf(i18n.Text("%s damage can't target the %s, so the %s was hit instead"),
+				attack.Type.Name, r.Location.ChoiceName, fallback.ChoiceName))
+			r.Location = fallback
+			kind = kindOfLocation(fallbackID)
+		}
+	}
+
+	// Damage resistance
+	r.DR = locationDR(entity, r.Location, attack.Type)
+	r.EffectiveDR = r.DR
+	if attack.ArmorDivisor > 0 && attack.ArmorDivisor != fxp.One {
+		r.EffectiveDR = fxp.As[int](fxp.From(r.DR).Div(attack.ArmorDivisor).Trunc())
+		if attack.ArmorDivisor < fxp.One && r.EffectiveDR == 0 {
+			r.EffectiveDR = 1 // A fractional armor divisor gives at least DR 1
+		}
+	}
+	if r.Penetrating = attack.Damage - r.EffectiveDR; r.Penetrating < 0 {
+		r.Penetrating = 0
+	}
+
+	// Wounding
+	r.WoundModifier = woundModifier(attack.Type, kind, attack.TightBeamBurning)
+	if r.Penetrating > 0 && r.WoundModifier > 0 {
+		r.Injury = fxp.As[int](fxp.From(r.Penetrating).Mul(r.WoundModifier).Trunc())
+		if r.Injury < 1 {
+			r.Injury = 1
+		}
+	}
+
+	// Pool
+	r.PoolID = gid.HitPoints
+	if attack.Type.Fatigue {
+		r.PoolID = gid.FatiguePoints
+	}
+	attr, ok := entity.Attributes.Set[r.PoolID]
+	if !ok {
+		return nil, errs.New(fmt.Sprintf(i18n.Text("the target has no %s pool"), strings.ToUpper(r.PoolID)))
+	}
+	if def := attr.AttributeDef(); def != nil {
+		r.PoolName = def.Name
+	}
+	maxHP := fxp.As[int](attr.Maximum())
+
+	// Crippling, which only applies to injury (not fatigue)
+	if !attack.Type.Fatigue && r.Injury > 0 {
+		switch kind {
+		case limbLocation:
+			r.CripplingInjury = maxHP/2 + 1
+		case extremityLocation:
+			r.CripplingInjury = maxHP/3 + 1
+		case eyeLocation:
+			r.CripplingInjury = maxHP/10 + 1
+		}
+		if r.CripplingInjury > 0 && r.Injury >= r.CripplingInjury {
+			r.Crippled = true
+			if kind != eyeLocation && r.Injury > r.CripplingInjury {
+				r.excessInjuryLost = r.Injury - r.CripplingInjury
+				r.Injury = r.CripplingInjury
+				r.note(fmt.Sprintf(i18n.Text("%d injury beyond what was needed to cripple the %s was lost"),
+					r.excessInjuryLost, r.Location.ChoiceName))
+			}
+		}
+		if r.Injury > maxHP/2 || r.Crippled {
+			r.MajorWound = true
+		}
+		r.ShockPenalty = r.Injury
+		if maxHP >= 20 {
+			r.ShockPenalty = r.Injury / (maxHP / 10)
+		}
+		if r.ShockPenalty > 4 {
+			r.ShockPenalty = 4
+		}
+		r.locationNotes(kind)
+	}
+
+	r.PoolBefore = attr.Current()
+	r.PoolAfter = r.PoolBefore - fxp.From(r.Injury)
+	if r.PoolBefore > 0 && r.PoolAfter <= 0 {
+		if attack.Type.Fatigue {
+			r.note(i18n.Text("Fatigue has reached zero; further fatigue costs HP and the target must roll vs. Will to stay conscious"))
+		} else {
+			r.note(i18n.Text("HP has reached zero or less; the target must roll vs. HT each turn to remain conscious"))
+		}
+	}
+	if !attack.Type.Fatigue {
+		for _, multiple := range []int{1, 2, 3, 4, 5} {
+			limit := fxp.From(-multiple * maxHP)
+			if r.PoolBefore > limit && r.PoolAfter <= limit {
+				if multiple == 5 {
+					r.note(i18n.Text("HP has fallen to -5×HP; the target dies immediately"))
+				} else {
+					r.note(fmt.Sprintf(i18n.Text("HP has fallen to -%d×HP; the target must make a HT roll or die"), multiple))
+				}
+			}
+		}
+	}
+	return r, nil
+}
+
+// Apply the result to the entity's pool.
+func (r *Result) Apply(entity *gurps.Entity) {
+	if attr, ok := entity.Attributes.Set[r.PoolID]; ok {
+		attr.Damage += fxp.From(r.Injury)
+	}
+}
+
+func (r *Result) note(text string) {
+	r.Notes = append(r.Notes, text)
+}
+
+func (r *Result) locationNotes(kind locationKind) {
+	if r.MajorWound {
+		r.note(i18n.Text("Major wound: the target must roll vs. HT to avoid being stunned and knocked down"))
+	}
+	switch kind {
+	case skullLocation:
+		r.note(i18n.Text("Skull hit: knockdown rolls are at -10, and any major wound causes a roll vs. HT to avoid unconsciousness"))
+	case faceLocation:
+		r.note(i18n.Text("Face hit: knockdown rolls are at -5"))
+	case vitalsLocation:
+		r.note(i18n.Text("Vitals hit: knockdown rolls are at -5"))
+	case groinLocation:
+		if r.Attack.Type.Crushing {
+			r.note(i18n.Text("Groin hit with crushing damage: double shock penalties and knockdown rolls are at -5 for males"))
+		}
+	case eyeLocation:
+		if r.Crippled {
+			r.note(i18n.Text("The eye has been blinded"))
+		}
+	case limbLocation, extremityLocation:
+		if r.Crippled {
+			r.note(fmt.Sprintf(i18n.Text("The %s has been crippled"), r.Location.ChoiceName))
+		}
+	}
+}
+
+// RollLocation rolls against the body's hit location table, descending into sub-tables as needed.
+func RollLocation(entity *gurps.Entity, body *gurps.Body) (location *gurps.HitLocation, roll int) {
+	if body == nil || body.Roll == nil {
+		return nil, 0
+	}
+	roll = body.Roll.Roll(false)
+	location = locationForRoll(body, roll)
+	for location != nil && location.SubTable != nil && len(location.SubTable.Locations) != 0 {
+		location = locationForRoll(location.SubTable, location.SubTable.Roll.Roll(false))
+	}
+	if location != nil {
+		if loc := body.LookupLocationByID(entity, location.LocID); loc != nil {
+			location = loc
+		}
+	}
+	return location, roll
+}
+
+func locationForRoll(body *gurps.Body, roll int) *gurps.HitLocation {
+	start := body.Roll.Minimum(false)
+	for _, one := range body.Locations {
+		if one.Slots > 0 && roll >= start && roll < start+one.Slots {
+			return one
+		}
+		start += one.Slots
+	}
+	return nil
+}
+
+func locationDR(entity *gurps.Entity, location *gurps.HitLocation, damageType *DamageType) int {
+	drMap := location.DR(entity, nil, nil)
+	dr := drMap[gid.All]
+	best := 0
+	for _, spec := range damageType.Specializations {
+		if v, ok := drMap[spec]; ok && v > best {
+			best = v
+		}
+	}
+	return dr + best
+}
+
+func woundModifier(damageType *DamageType, kind locationKind, tightBeamBurning bool) fxp.Int {
+	if damageType.Affliction {
+		return 0
+	}
+	mod := damageType.WoundModifier
+	if damageType.Fatigue {
+		return mod // Fatigue damage isn't affected by the location struck
+	}
+	switch kind {
+	case skullLocation, eyeLocation:
+		if !damageType.Toxic {
+			return fxp.Four
+		}
+	case faceLocation:
+		if damageType.Corrosion {
+			return fxp.OneAndAHalf
+		}
+	case neckLocation:
+		switch {
+		case damageType.Crushing || damageType.Corrosion:
+			return fxp.OneAndAHalf
+		case damageType.Cutting:
+			return fxp.Two
+		}
+	case vitalsLocation:
+		switch {
+		case damageType.Impaling || damageType.Piercing:
+			return fxp.Three
+		case damageType.Burning && tightBeamBurning:
+			return fxp.Two
+		}
+	case limbLocation, extremityLocation:
+		if (damageType.Impaling || damageType.Piercing) && mod > fxp.One {
+			return fxp.One
+		}
+	}
+	return mod
+}
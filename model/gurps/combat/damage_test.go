@@ -0,0 +1,203 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package combat_test
+
+import (
+	"testing"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/combat"
+	"github.com/richardwilkes/gcs/v5/model/gurps/datafile"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/model/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	settings.Global()
+	for _, tc := range []struct {
+		name         string
+		attack       combat.Attack
+		location     string
+		pool         string
+		effectiveDR  int
+		injury       int
+		shock        int
+		crippled     bool
+		majorWound   bool
+		poolAfter    int
+		woundModText string
+	}{
+		{
+			name:         "crushing to the torso",
+			attack:       combat.Attack{Damage: 5, Type: combat.LookupDamageType("cr"), LocationID: gid.Torso},
+			location:     gid.Torso,
+			pool:         gid.HitPoints,
+			injury:       5,
+			shock:        4,
+			poolAfter:    5,
+			woundModText: "1",
+		},
+		{
+			name:         "cutting to the torso is a major wound",
+			attack:       combat.Attack{Damage: 4, Type: combat.LookupDamageType("cut"), LocationID: gid.Torso},
+			location:     gid.Torso,
+			pool:         gid.HitPoints,
+			injury:       6,
+			shock:        4,
+			majorWound:   true,
+			poolAfter:    4,
+			woundModText: "1.5",
+		},
+		{
+			name:         "small piercing rounds down but does at least 1",
+			attack:       combat.Attack{Damage: 1, Type: combat.LookupDamageType("pi-"), LocationID: gid.Torso},
+			location:     gid.Torso,
+			pool:         gid.HitPoints,
+			injury:       1,
+			shock:        1,
+			poolAfter:    9,
+			woundModText: "0.5",
+		},
+		{
+			name:         "impaling to the vitals",
+			attack:       combat.Attack{Damage: 3, Type: combat.LookupDamageType("imp"), LocationID: "vitals"},
+			location:     "vitals",
+			pool:         gid.HitPoints,
+			injury:       9,
+			shock:        4,
+			majorWound:   true,
+			poolAfter:    1,
+			woundModText: "3",
+		},
+		{
+			name:         "crushing can't target the vitals",
+			attack:       combat.Attack{Damage: 3, Type: combat.LookupDamageType("cr"), LocationID: "vitals"},
+			location:     gid.Torso,
+			pool:         gid.HitPoints,
+			injury:       3,
+			shock:        3,
+			poolAfter:    7,
+			woundModText: "1",
+		},
+		{
+			name:         "crushing to the skull, which has DR 2",
+			attack:       combat.Attack{Damage: 5, Type: combat.LookupDamageType("cr"), LocationID: "skull"},
+			location:     "skull",
+			pool:         gid.HitPoints,
+			effectiveDR:  2,
+			injury:       12,
+			shock:        4,
+			majorWound:   true,
+			poolAfter:    -2,
+			woundModText: "4",
+		},
+		{
+			name:         "impaling to a limb is capped at x1",
+			attack:       combat.Attack{Damage: 3, Type: combat.LookupDamageType("imp"), LocationID: "arm"},
+			location:     "arm",
+			pool:         gid.HitPoints,
+			injury:       3,
+			shock:        3,
+			poolAfter:    7,
+			woundModText: "1",
+		},
+		{
+			name:         "crippling a limb loses the excess injury",
+			attack:       combat.Attack{Damage: 10, Type: combat.LookupDamageType("cut"), LocationID: "arm"},
+			location:     "arm",
+			pool:         gid.HitPoints,
+			injury:       6,
+			shock:        4,
+			crippled:     true,
+			majorWound:   true,
+			poolAfter:    4,
+			woundModText: "1.5",
+		},
+		{
+			name: "a fractional armor divisor gives at least DR 1",
+			attack: combat.Attack{Damage: 3, Type: combat.LookupDamageType("cr"), LocationID: gid.Torso,
+				ArmorDivisor: fxp.Half},
+			location:     gid.Torso,
+			pool:         gid.HitPoints,
+			effectiveDR:  1,
+			injury:       2,
+			shock:        2,
+			poolAfter:    8,
+			woundModText: "1",
+		},
+		{
+			name:         "fatigue damage uses the fatigue pool and causes no shock",
+			attack:       combat.Attack{Damage: 4, Type: combat.LookupDamageType("fat"), LocationID: "vitals"},
+			location:     gid.Torso,
+			pool:         gid.FatiguePoints,
+			injury:       4,
+			poolAfter:    6,
+			woundModText: "1",
+		},
+		{
+			name:         "afflictions cause no injury",
+			attack:       combat.Attack{Damage: 4, Type: combat.LookupDamageType("aff"), LocationID: gid.Torso},
+			location:     gid.Torso,
+			pool:         gid.HitPoints,
+			poolAfter:    10,
+			woundModText: "0",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			entity := gurps.NewEntity(datafile.PC)
+			r, err := combat.Resolve(entity, &tc.attack)
+			require.NoError(t, err)
+			assert.Equal(t, tc.location, r.Location.LocID)
+			assert.Equal(t, tc.pool, r.PoolID)
+			assert.Equal(t, tc.effectiveDR, r.EffectiveDR)
+			assert.Equal(t, tc.woundModText, r.WoundModifier.String())
+			assert.Equal(t, tc.injury, r.Injury)
+			assert.Equal(t, tc.shock, r.ShockPenalty)
+			assert.Equal(t, tc.crippled, r.Crippled)
+			assert.Equal(t, tc.majorWound, r.MajorWound)
+			assert.Equal(t, fxp.From(tc.poolAfter), r.PoolAfter)
+
+			r.Apply(entity)
+			assert.Equal(t, fxp.From(tc.poolAfter), entity.Attributes.Set[tc.pool].Current())
+		})
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	settings.Global()
+	entity := gurps.NewEntity(datafile.PC)
+	_, err := combat.Resolve(entity, &combat.Attack{Damage: 1})
+	assert.Error(t, err)
+	_, err = combat.Resolve(entity, &combat.Attack{Damage: 1, Type: combat.LookupDamageType("cr"), LocationID: "nowhere"})
+	assert.Error(t, err)
+}
+
+func TestLookupDamageType(t *testing.T) {
+	for key, expected := range map[string]string{
+		"cr":        "cr",
+		" CUT ":     "cut",
+		"Impaling":  "imp",
+		"piercing":  "pi",
+		"pi++":      "pi++",
+		"fire":      "burn",
+		"corrosive": "cor",
+		"spec":      "aff",
+	} {
+		dt := combat.LookupDamageType(key)
+		require.NotNil(t, dt, key)
+		assert.Equal(t, expected, dt.Key, key)
+	}
+	assert.Nil(t, combat.LookupDamageType("bogus"))
+}
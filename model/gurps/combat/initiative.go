@@ -0,0 +1,56 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package combat
+
+import (
+	"sort"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+)
+
+// Initiative holds the values that determine when a combatant acts.
+type Initiative struct {
+	BasicSpeed fxp.Int
+	DX         fxp.Int
+}
+
+// InitiativeFor returns the initiative of the entity.
+func InitiativeFor(entity *gurps.Entity) Initiative {
+	return Initiative{
+		BasicSpeed: entity.ResolveAttributeCurrent(gid.BasicSpeed),
+		DX:         entity.ResolveAttributeCurrent(gid.Dexterity),
+	}
+}
+
+// Before returns true if this initiative acts before the other one. Higher Basic Speed goes first, with ties broken by
+// higher DX.
+func (i Initiative) Before(other Initiative) bool {
+	if i.BasicSpeed != other.BasicSpeed {
+		return i.BasicSpeed > other.BasicSpeed
+	}
+	return i.DX > other.DX
+}
+
+// SortByInitiative sorts the list into the order in which its combatants act. Combatants that are still tied keep
+// their existing relative order.
+func SortByInitiative[T any](list []T, entityOf func(T) *gurps.Entity) {
+	initiatives := make(map[*gurps.Entity]Initiative, len(list))
+	for _, one := range list {
+		entity := entityOf(one)
+		initiatives[entity] = InitiativeFor(entity)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return initiatives[entityOf(list[i])].Before(initiatives[entityOf(list[j])])
+	})
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package gurps
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps/measure"
+)
+
+// Currency defines a unit of money, such as a gold piece or a credit. Equipment values that don't specify a currency
+// are in the base currency, which is the "$" used throughout the GURPS rules.
+type Currency struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name,omitempty"`
+	Symbol      string         `json:"symbol,omitempty"`
+	SymbolAfter bool           `json:"symbol_after,omitempty"`
+	Rate        fxp.Int        `json:"rate"`
+	CoinWeight  measure.Weight `json:"coin_weight,omitempty"`
+}
+
+// NewCurrency creates a new Currency worth one unit of the base currency.
+func NewCurrency(id string) *Currency {
+	return &Currency{
+		ID:     id,
+		Symbol: id,
+		Rate:   fxp.One,
+	}
+}
+
+// Clone returns a copy of the currency.
+func (c *Currency) Clone() *Currency {
+	other := *c
+	return &other
+}
+
+// EnsureValidity checks the currency for validity and if it isn't valid, makes it so.
+func (c *Currency) EnsureValidity() {
+	c.ID = strings.TrimSpace(c.ID)
+	if c.Rate <= 0 {
+		c.Rate = fxp.One
+	}
+	if c.CoinWeight < 0 {
+		c.CoinWeight = 0
+	}
+}
+
+func (c *Currency) String() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.ID
+}
+
+// ToBase converts a value in this currency into the base currency.
+func (c *Currency) ToBase(value fxp.Int) fxp.Int {
+	return value.Mul(c.Rate)
+}
+
+// FromBase converts a value in the base currency into this currency.
+func (c *Currency) FromBase(value fxp.Int) fxp.Int {
+	return value.Div(c.Rate)
+}
+
+// Format returns the value with this currency's symbol. Symbols of more than one character are separated from the
+// value by a space.
+func (c *Currency) Format(value fxp.Int) string {
+	symbol := c.Symbol
+	if symbol == "" {
+		symbol = c.ID
+	}
+	sep := ""
+	if utf8.RuneCountInString(symbol) > 1 {
+		sep = " "
+	}
+	if c.SymbolAfter {
+		return value.String() + sep + symbol
+	}
+	return symbol + sep + value.String()
+}
+
+// Currency returns the currency with the given ID, or nil if the ID is empty or unknown, in which case the base
+// currency should be used.
+func (s *SheetSettings) Currency(id string) *Currency {
+	if id = strings.TrimSpace(id); id == "" {
+		return nil
+	}
+	for _, one := range s.Currencies {
+		if strings.EqualFold(one.ID, id) {
+			return one
+		}
+	}
+	return nil
+}
+
+// FormatValue converts a value in the base currency into the display currency and formats it. If no display currency
+// has been set, the value is returned as a plain number.
+func (s *SheetSettings) FormatValue(value fxp.Int) string {
+	if c := s.Currency(s.DisplayCurrency); c != nil {
+		return c.Format(c.FromBase(value))
+	}
+	return value.String()
+}
+
+// FormatWealth is the same as FormatValue, except that the base currency's "$" is used if no display currency has
+// been set.
+func (s *SheetSettings) FormatWealth(value fxp.Int) string {
+	if c := s.Currency(s.DisplayCurrency); c != nil {
+		return c.Format(c.FromBase(value))
+	}
+	return "$" + value.String()
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package gurps_test
+
+import (
+	"testing"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/datafile"
+	"github.com/richardwilkes/gcs/v5/model/gurps/measure"
+	"github.com/richardwilkes/gcs/v5/model/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCurrencyEntity() *gurps.Entity {
+	settings.Global()
+	e := gurps.NewEntity(datafile.PC)
+	e.CarriedEquipment = nil
+	e.OtherEquipment = nil
+	gp := gurps.NewCurrency("gp")
+	gp.Name = "Gold Piece"
+	gp.Rate = fxp.From(20)
+	gp.CoinWeight = measure.WeightFromInteger(1, measure.Ounce)
+	sp := gurps.NewCurrency("sp")
+	sp.Symbol = "s"
+	sp.SymbolAfter = true
+	sp.Rate = fxp.One
+	e.SheetSettings.Currencies = []*gurps.Currency{gp, sp}
+	return e
+}
+
+func TestCurrencyConversion(t *testing.T) {
+	c := gurps.NewCurrency("gp")
+	c.Rate = fxp.From(20)
+	for _, tc := range []struct {
+		value fxp.Int
+		base  fxp.Int
+	}{
+		{value: 0, base: 0},
+		{value: fxp.One, base: fxp.From(20)},
+		{value: fxp.Half, base: fxp.Ten},
+		{value: fxp.From(3), base: fxp.From(60)},
+		{value: -fxp.Two, base: fxp.From(-40)},
+	} {
+		assert.Equal(t, tc.base, c.ToBase(tc.value), tc.value.String())
+		assert.Equal(t, tc.value, c.FromBase(tc.base), tc.base.String())
+	}
+}
+
+func TestCurrencyFormat(t *testing.T) {
+	for _, tc := range []struct {
+		currency *gurps.Currency
+		expected string
+	}{
+		{currency: &gurps.Currency{ID: "cr", Symbol: "$"}, expected: "$12.5"},
+		{currency: &gurps.Currency{ID: "gp"}, expected: "gp 12.5"},
+		{currency: &gurps.Currency{ID: "sp", Symbol: "s", SymbolAfter: true}, expected: "12.5s"},
+		{currency: &gurps.Currency{ID: "cr", Symbol: "Cr", SymbolAfter: true}, expected: "12.5 Cr"},
+	} {
+		assert.Equal(t, tc.expected, tc.currency.Format(fxp.From(12.5)))
+	}
+}
+
+func TestCurrencyEnsureValidity(t *testing.T) {
+	c := &gurps.Currency{ID: "  gp ", Rate: -fxp.One, CoinWeight: -measure.WeightFromInteger(1, measure.Pound)}
+	c.EnsureValidity()
+	assert.Equal(t, "gp", c.ID)
+	assert.Equal(t, fxp.One, c.Rate)
+	assert.Equal(t, measure.Weight(0), c.CoinWeight)
+}
+
+func TestSheetSettingsCurrency(t *testing.T) {
+	s := newCurrencyEntity().SheetSettings
+	assert.Nil(t, s.Currency(""))
+	assert.Nil(t, s.Currency("cp"))
+	assert.Equal(t, "gp", s.Currency(" GP ").ID)
+
+	assert.Equal(t, "40", s.FormatValue(fxp.From(40)))
+	assert.Equal(t, "$40", s.FormatWealth(fxp.From(40)))
+	s.DisplayCurrency = "gp"
+	assert.Equal(t, "gp 2", s.FormatValue(fxp.From(40)))
+	assert.Equal(t, "gp 2", s.FormatWealth(fxp.From(40)))
+}
+
+func TestEquipmentCurrencyValues(t *testing.T) {
+	e := newCurrencyEntity()
+	for _, tc := range []struct {
+		name      string
+		currency  string
+		coins     bool
+		value     fxp.Int
+		quantity  fxp.Int
+		adjusted  fxp.Int
+		extended  fxp.Int
+		formatted string
+	}{
+		{name: "base currency", value: fxp.Ten, quantity: fxp.Two, adjusted: fxp.Ten, extended: fxp.From(20),
+			formatted: "10"},
+		{name: "other currency", currency: "gp", value: fxp.Two, quantity: fxp.Three, adjusted: fxp.Two,
+			extended: fxp.From(120), formatted: "gp 2"},
+		{name: "unknown currency is the base", currency: "zz", value: fxp.Two, quantity: fxp.One,
+			adjusted: fxp.Two, extended: fxp.Two, formatted: "2"},
+		{name: "coins are worth one unit", currency: "gp", coins: true, value: fxp.Ten, quantity: fxp.From(5),
+			adjusted: fxp.One, extended: fxp.From(100), formatted: "gp 1"},
+	} {
+		eqp := gurps.NewEquipment(e, nil, false)
+		eqp.Currency = tc.currency
+		eqp.Coins = tc.coins
+		eqp.Value = tc.value
+		eqp.Quantity = tc.quantity
+		assert.Equal(t, tc.adjusted, eqp.AdjustedValue(), tc.name)
+		assert.Equal(t, tc.extended, eqp.ExtendedValue(), tc.name)
+		assert.Equal(t, tc.formatted, eqp.FormattedValue(), tc.name)
+	}
+}
+
+func TestCoinWeight(t *testing.T) {
+	e := newCurrencyEntity()
+	coins := gurps.NewEquipment(e, nil, false)
+	coins.Currency = "gp"
+	coins.Coins = true
+	coins.Quantity = fxp.From(16)
+	coins.Weight = measure.WeightFromInteger(5, measure.Pound)
+	assert.Equal(t, measure.WeightFromInteger(1, measure.Pound), coins.ExtendedWeight(false, measure.Pound))
+	e.CarriedEquipment = []*gurps.Equipment{coins}
+	assert.Equal(t, fxp.From(320), e.WealthCarried())
+}
@@ -0,0 +1,668 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+// Package diff compares character sheets in game terms and merges concurrent edits to them. Rows are matched using
+// their stable IDs rather than their position, so reordering a list doesn't register as a change.
+package diff
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/jio"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/txt"
+)
+
+const maxValueTextLength = 80
+
+// Kind identifies the kind of change.
+type Kind int
+
+// Possible Kind values.
+const (
+	Added Kind = iota
+	Removed
+	Changed
+)
+
+// String implements fmt.Stringer.
+func (k Kind) String() string {
+	switch k {
+	case Added:
+		return i18n.Text("added")
+	case Removed:
+		return i18n.Text("removed")
+	default:
+		return i18n.Text("changed")
+	}
+}
+
+// FieldChange holds a change to a single field of an item.
+type FieldChange struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// String implements fmt.Stringer.
+func (f *FieldChange) String() string {
+	if f.Field == "" {
+		return fmt.Sprintf("%s → %s", f.Before, f.After)
+	}
+	return fmt.Sprintf("%s: %s → %s", f.Field, f.Before, f.After)
+}
+
+// Change holds a change to a single item, such as a trait, skill or the profile.
+type Change struct {
+	Kind   Kind
+	Where  string
+	Fields []*FieldChange
+}
+
+// String implements fmt.Stringer.
+func (c *Change) String() string {
+	if c.Kind != Changed {
+		return c.Where + " " + c.Kind.String()
+	}
+	parts := make([]string, len(c.Fields))
+	for i, one := range c.Fields {
+		parts[i] = one.String()
+	}
+	return c.Where + ": " + strings.Join(parts, "; ")
+}
+
+var (
+	// sectionOrder is the order top-level keys are reported in. Keys not listed here follow, sorted by name.
+	sectionOrder = []string{"total_points", "profile", "attributes", "traits", "skills", "spells", "equipment",
+		"other_equipment", "funds", "notes", "campaign", "advancement", "settings"}
+	ignoredKeys = map[string]bool{
+		"calc":          true,
+		"modified_date": true,
+	}
+	childrenKey = "children"
+)
+
+func sectionLabel(key string) string {
+	switch key {
+	case "total_points":
+		return i18n.Text("Total Points")
+	case "profile":
+		return i18n.Text("Profile")
+	case "attributes":
+		return i18n.Text("Attribute")
+	case "traits":
+		return i18n.Text("Trait")
+	case "skills":
+		return i18n.Text("Skill")
+	case "spells":
+		return i18n.Text("Spell")
+	case "equipment":
+		return i18n.Text("Carried Equipment")
+	case "other_equipment":
+		return i18n.Text("Other Equipment")
+	case "funds":
+		return i18n.Text("Funds")
+	case "notes":
+		return i18n.Text("Note")
+	case "weapons":
+		return i18n.Text("Weapon")
+	case "modifiers":
+		return i18n.Text("Modifier")
+	case "campaign":
+		return i18n.Text("Campaign")
+	case "advancement":
+		return i18n.Text("Advancement Log")
+	case "settings":
+		return i18n.Text("Sheet Settings")
+	case "pre_campaign_settings":
+		return i18n.Text("Sheet Settings Before Campaign")
+	default:
+		return fieldLabel(key)
+	}
+}
+
+// fieldLabel returns the display name for a field of an item. Fields without a known display name fall back to the key
+// itself, reworded as title case.
+func fieldLabel(key string) string {
+	switch key {
+	case "accuracy":
+		return i18n.Text("Accuracy")
+	case "adj":
+		return i18n.Text("Adjustment")
+	case "age":
+		return i18n.Text("Age")
+	case "ammo":
+		return i18n.Text("Ammunition")
+	case "armor_divisor":
+		return i18n.Text("Armor Divisor")
+	case "base":
+		return i18n.Text("Base")
+	case "base_points":
+		return i18n.Text("Base Points")
+	case "birthday":
+		return i18n.Text("Birthday")
+	case "block":
+		return i18n.Text("Block")
+	case "bulk":
+		return i18n.Text("Bulk")
+	case "capacity":
+		return i18n.Text("Capacity")
+	case "casting_cost":
+		return i18n.Text("Casting Cost")
+	case "casting_time":
+		return i18n.Text("Casting Time")
+	case "coins":
+		return i18n.Text("Coins")
+	case "college":
+		return i18n.Text("College")
+	case "cost":
+		return i18n.Text("Cost")
+	case "cost_type":
+		return i18n.Text("Cost Type")
+	case "cr":
+		return i18n.Text("Self-Control Roll")
+	case "cr_adj":
+		return i18n.Text("Self-Control Roll Adjustment")
+	case "currency":
+		return i18n.Text("Currency")
+	case "damage":
+		return i18n.Text("Damage")
+	case "defaults":
+		return i18n.Text("Defaults")
+	case "description":
+		return i18n.Text("Description")
+	case "difficulty":
+		return i18n.Text("Difficulty")
+	case "disabled":
+		return i18n.Text("Disabled")
+	case "duration":
+		return i18n.Text("Duration")
+	case "encumbrance_penalty_multiplier":
+		return i18n.Text("Encumbrance Penalty")
+	case "equipped":
+		return i18n.Text("Equipped")
+	case "eyes":
+		return i18n.Text("Eyes")
+	case "features":
+		return i18n.Text("Features")
+	case "fragmentation":
+		return i18n.Text("Fragmentation")
+	case "fragmentation_armor_divisor":
+		return i18n.Text("Fragmentation Armor Divisor")
+	case "fragmentation_type":
+		return i18n.Text("Fragmentation Type")
+	case "gender":
+		return i18n.Text("Gender")
+	case "hair":
+		return i18n.Text("Hair")
+	case "handedness":
+		return i18n.Text("Handedness")
+	case "height":
+		return i18n.Text("Height")
+	case "ignore_weight_for_skills":
+		return i18n.Text("Ignore Weight for Skills")
+	case "legality_class":
+		return i18n.Text("Legality Class")
+	case "levels":
+		return i18n.Text("Levels")
+	case "maintenance_cost":
+		return i18n.Text("Maintenance Cost")
+	case "max_uses":
+		return i18n.Text("Maximum Uses")
+	case "modifier_per_die":
+		return i18n.Text("Modifier per Die")
+	case "name":
+		return i18n.Text("Name")
+	case "notes":
+		return i18n.Text("Notes")
+	case "organization":
+		return i18n.Text("Organization")
+	case "parry":
+		return i18n.Text("Parry")
+	case "player_name":
+		return i18n.Text("Player")
+	case "points":
+		return i18n.Text("Points")
+	case "points_per_level":
+		return i18n.Text("Points per Level")
+	case "portrait":
+		return i18n.Text("Portrait")
+	case "power_source":
+		return i18n.Text("Power Source")
+	case "prereqs":
+		return i18n.Text("Prerequisites")
+	case "quantity":
+		return i18n.Text("Quantity")
+	case "range":
+		return i18n.Text("Range")
+	case "rate_of_fire":
+		return i18n.Text("Rate of Fire")
+	case "reach":
+		return i18n.Text("Reach")
+	case "recoil":
+		return i18n.Text("Recoil")
+	case "reference":
+		return i18n.Text("Page Reference")
+	case "religion":
+		return i18n.Text("Religion")
+	case "resist":
+		return i18n.Text("Resistance")
+	case "round_down":
+		return i18n.Text("Round Down")
+	case "shots":
+		return i18n.Text("Shots")
+	case "skin":
+		return i18n.Text("Skin")
+	case "specialization":
+		return i18n.Text("Specialization")
+	case "spell_class":
+		return i18n.Text("Class")
+	case "st":
+		return i18n.Text("Strength Based")
+	case "strength":
+		return i18n.Text("Minimum ST")
+	case "tags":
+		return i18n.Text("Tags")
+	case "tech_level":
+		return i18n.Text("Tech Level")
+	case "text":
+		return i18n.Text("Text")
+	case "title":
+		return i18n.Text("Title")
+	case "type":
+		return i18n.Text("Type")
+	case "usage":
+		return i18n.Text("Usage")
+	case "usage_notes":
+		return i18n.Text("Usage Notes")
+	case "userdesc":
+		return i18n.Text("User Description")
+	case "uses":
+		return i18n.Text("Uses")
+	case "value":
+		return i18n.Text("Value")
+	case "vtt_notes":
+		return i18n.Text("VTT Notes")
+	case "weight":
+		return i18n.Text("Weight")
+	default:
+		words := strings.Split(key, "_")
+		for i, one := range words {
+			words[i] = txt.FirstToUpper(one)
+		}
+		return strings.Join(words, " ")
+	}
+}
+
+// Entities returns the changes needed to turn 'before' into 'after'.
+func Entities(before, after *gurps.Entity) ([]*Change, error) {
+	a, err := toTree(before)
+	if err != nil {
+		return nil, err
+	}
+	var b map[string]any
+	if b, err = toTree(after); err != nil {
+		return nil, err
+	}
+	d := &differ{byWhere: make(map[string]*Change)}
+	for _, key := range topLevelKeys(a, b) {
+		label := sectionLabel(key)
+		av := a[key]
+		bv := b[key]
+		if keyed := listKey(av, bv); keyed != "" {
+			d.diffList(label, keyed, asList(av), asList(bv))
+		} else {
+			d.diffValue(label, "", av, bv)
+		}
+	}
+	return d.changes, nil
+}
+
+type differ struct {
+	changes []*Change
+	byWhere map[string]*Change
+}
+
+func (d *differ) fieldChanged(where, field string, before, after any) {
+	c, ok := d.byWhere[where]
+	if !ok {
+		c = &Change{Kind: Changed, Where: where}
+		d.byWhere[where] = c
+		d.changes = append(d.changes, c)
+	}
+	c.Fields = append(c.Fields, &FieldChange{
+		Field:  field,
+		Before: valueText(before),
+		After:  valueText(after),
+	})
+}
+
+func (d *differ) diffValue(where, field string, a, b any) {
+	if equal(a, b) {
+		return
+	}
+	am, aIsMap := a.(map[string]any)
+	bm, bIsMap := b.(map[string]any)
+	if aIsMap && bIsMap {
+		for _, key := range unionKeys(am, bm) {
+			sub := joinField(field, key)
+			if keyed := listKey(am[key], bm[key]); keyed != "" {
+				d.diffList(fmt.Sprintf("%s > %s", where, sectionLabel(key)), keyed, asList(am[key]), asList(bm[key]))
+			} else {
+				d.diffValue(where, sub, am[key], bm[key])
+			}
+		}
+		return
+	}
+	d.fieldChanged(where, field, a, b)
+}
+
+func (d *differ) diffList(label, keyField string, a, b []any) {
+	aNodes := flattenNodes(a, keyField, "")
+	bNodes := flattenNodes(b, keyField, "")
+	aByID := make(map[string]*node, len(aNodes))
+	for _, one := range aNodes {
+		aByID[one.id] = one
+	}
+	bByID := make(map[string]*node, len(bNodes))
+	for _, one := range bNodes {
+		bByID[one.id] = one
+	}
+	for _, one := range aNodes {
+		if _, exists := bByID[one.id]; !exists {
+			d.changes = append(d.changes, &Change{Kind: Removed, Where: nodeWhere(label, one.obj)})
+		}
+	}
+	for _, bn := range bNodes {
+		where := nodeWhere(label, bn.obj)
+		an, exists := aByID[bn.id]
+		if !exists {
+			d.changes = append(d.changes, &Change{Kind: Added, Where: where})
+			continue
+		}
+		if an.parent != bn.parent {
+			d.fieldChanged(where, i18n.Text("Container"), parentName(aByID, an.parent), parentName(bByID, bn.parent))
+		}
+		d.diffValue(where, "", withoutChildren(an.obj), withoutChildren(bn.obj))
+	}
+}
+
+type node struct {
+	id     string
+	parent string
+	obj    map[string]any
+}
+
+func flattenNodes(list []any, keyField, parent string) []*node {
+	var result []*node
+	for _, one := range list {
+		obj, ok := one.(map[string]any)
+		if !ok {
+			continue
+		}
+		id := fmt.Sprint(obj[keyField])
+		result = append(result, &node{id: id, parent: parent, obj: obj})
+		if children, hasChildren := obj[childrenKey].([]any); hasChildren {
+			result = append(result, flattenNodes(children, keyField, id)...)
+		}
+	}
+	return result
+}
+
+func parentName(m map[string]*node, id string) string {
+	if id == "" {
+		return i18n.Text("top level")
+	}
+	if n, ok := m[id]; ok {
+		return nodeName(n.obj)
+	}
+	return id
+}
+
+func withoutChildren(obj map[string]any) map[string]any {
+	if _, ok := obj[childrenKey]; !ok {
+		return obj
+	}
+	other := make(map[string]any, len(obj))
+	for k, v := range obj {
+		if k != childrenKey {
+			other[k] = v
+		}
+	}
+	return other
+}
+
+func nodeWhere(label string, obj map[string]any) string {
+	return fmt.Sprintf("%s \"%s\"", label, nodeName(obj))
+}
+
+func nodeName(obj map[string]any) string {
+	for _, key := range []string{"name", "usage", "description", "text", "attr_id", "id"} {
+		if s, ok := obj[key].(string); ok && s != "" {
+			return truncate(s, 40)
+		}
+	}
+	return "?"
+}
+
+// listKey returns the field used to identify the rows of the list(s), or an empty string if they aren't lists of
+// identifiable rows.
+func listKey(values ...any) string {
+	var key string
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		list, ok := v.([]any)
+		if !ok || len(list) == 0 {
+			if ok {
+				continue
+			}
+			return ""
+		}
+		obj, isObj := list[0].(map[string]any)
+		if !isObj {
+			return ""
+		}
+		switch {
+		case obj["id"] != nil:
+			if key != "" && key != "id" {
+				return ""
+			}
+			key = "id"
+		case obj["attr_id"] != nil:
+			if key != "" && key != "attr_id" {
+				return ""
+			}
+			key = "attr_id"
+		default:
+			return ""
+		}
+	}
+	return key
+}
+
+func asList(v any) []any {
+	list, _ := v.([]any) //nolint:errcheck // A nil list is the desired result when v isn't a list
+	return list
+}
+
+func joinField(base, key string) string {
+	label := fieldLabel(key)
+	if base == "" {
+		return label
+	}
+	return base + " > " + label
+}
+
+func topLevelKeys(maps ...map[string]any) []string {
+	all := unionKeys(maps...)
+	seen := make(map[string]bool, len(all))
+	for _, one := range all {
+		seen[one] = true
+	}
+	result := make([]string, 0, len(all))
+	for _, one := range sectionOrder {
+		if seen[one] {
+			result = append(result, one)
+			delete(seen, one)
+		}
+	}
+	for _, one := range all {
+		if seen[one] {
+			result = append(result, one)
+		}
+	}
+	return result
+}
+
+func unionKeys(maps ...map[string]any) []string {
+	set := make(map[string]bool)
+	for _, m := range maps {
+		for k := range m {
+			if !ignoredKeys[k] {
+				set[k] = true
+			}
+		}
+	}
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func equal(a, b any) bool {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			return false
+		}
+		for _, k := range unionKeys(av, bv) {
+			if !equal(av[k], bv[k]) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equal(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+func valueText(v any) string {
+	switch value := v.(type) {
+	case nil:
+		return i18n.Text("none")
+	case string:
+		if value == "" {
+			return i18n.Text("none")
+		}
+		return truncate(value, maxValueTextLength)
+	case json.Number:
+		return value.String()
+	case bool:
+		if value {
+			return i18n.Text("yes")
+		}
+		return i18n.Text("no")
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return truncate(string(data), maxValueTextLength)
+	}
+}
+
+func truncate(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > max {
+		return string(r[:max-1]) + "…"
+	}
+	return s
+}
+
+func toTree(entity *gurps.Entity) (map[string]any, error) {
+	var buffer bytes.Buffer
+	if err := jio.Save(context.Background(), &buffer, entity); err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(&buffer)
+	decoder.UseNumber()
+	var tree map[string]any
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+func fromTree(tree map[string]any) (*gurps.Entity, error) {
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return nil, err
+	}
+	var entity gurps.Entity
+	if err = jio.Load(context.Background(), bytes.NewReader(data), &entity); err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+func loadEntity(filePath string) (*gurps.Entity, error) {
+	return gurps.NewEntityFromFile(os.DirFS(filepath.Dir(filePath)), filepath.Base(filePath))
+}
+
+// DiffFiles writes the changes needed to turn the sheet at beforePath into the sheet at afterPath to w. Returns true if
+// there were any differences.
+func DiffFiles(w io.Writer, beforePath, afterPath string) (bool, error) {
+	before, err := loadEntity(beforePath)
+	if err != nil {
+		return false, err
+	}
+	var after *gurps.Entity
+	if after, err = loadEntity(afterPath); err != nil {
+		return false, err
+	}
+	var changes []*Change
+	if changes, err = Entities(before, after); err != nil {
+		return false, err
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(w, i18n.Text("No differences"))
+		return false, nil
+	}
+	for _, one := range changes {
+		fmt.Fprintln(w, one)
+	}
+	return true, nil
+}
@@ -0,0 +1,376 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package diff
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/toolbox/i18n"
+)
+
+// missing marks a value that isn't present at all, as opposed to one that is present but null.
+type missingValue struct{}
+
+var missing = missingValue{}
+
+// Conflict holds a change that was made differently by both sides of a merge. The value from "ours" is always the one
+// kept.
+type Conflict struct {
+	Where  string
+	Field  string
+	Base   string
+	Ours   string
+	Theirs string
+}
+
+// String implements fmt.Stringer.
+func (c *Conflict) String() string {
+	where := c.Where
+	if c.Field != "" {
+		where += ": " + c.Field
+	}
+	return fmt.Sprintf(i18n.Text("%s was %s, ours has %s, theirs has %s (kept ours)"), where, c.Base, c.Ours, c.Theirs)
+}
+
+// Merge performs a three-way merge of two sheets that were both derived from 'base'. Changes made by only one side are
+// taken from that side. Where both sides changed the same thing differently, the value from 'ours' is kept and a
+// Conflict is reported.
+func Merge(base, ours, theirs *gurps.Entity) (*gurps.Entity, []*Conflict, error) {
+	b, err := toTree(base)
+	if err != nil {
+		return nil, nil, err
+	}
+	var o, t map[string]any
+	if o, err = toTree(ours); err != nil {
+		return nil, nil, err
+	}
+	if t, err = toTree(theirs); err != nil {
+		return nil, nil, err
+	}
+	m := &merger{}
+	result := make(map[string]any)
+	for _, key := range topLevelKeys(b, o, t) {
+		if v := m.merge(sectionLabel(key), "", lookup(b, key), lookup(o, key), lookup(t, key)); v != missing {
+			result[key] = v
+		}
+	}
+	// Keep the bookkeeping fields from ours, since they aren't merged.
+	for key := range ignoredKeys {
+		if v, ok := o[key]; ok {
+			result[key] = v
+		}
+	}
+	var entity *gurps.Entity
+	if entity, err = fromTree(result); err != nil {
+		return nil, nil, err
+	}
+	return entity, m.conflicts, nil
+}
+
+type merger struct {
+	conflicts []*Conflict
+}
+
+func lookup(m map[string]any, key string) any {
+	if m == nil {
+		return missing
+	}
+	if v, ok := m[key]; ok {
+		return v
+	}
+	return missing
+}
+
+func same(a, b any) bool {
+	if a == missing || b == missing {
+		return a == b
+	}
+	return equal(a, b)
+}
+
+func (m *merger) conflict(where, field string, base, ours, theirs any) {
+	m.conflicts = append(m.conflicts, &Conflict{
+		Where:  where,
+		Field:  field,
+		Base:   mergeValueText(base),
+		Ours:   mergeValueText(ours),
+		Theirs: mergeValueText(theirs),
+	})
+}
+
+func mergeValueText(v any) string {
+	if v == missing {
+		return i18n.Text("nothing")
+	}
+	return valueText(v)
+}
+
+func (m *merger) merge(where, field string, base, ours, theirs any) any {
+	switch {
+	case same(ours, theirs):
+		return ours
+	case same(base, ours):
+		return theirs
+	case same(base, theirs):
+		return ours
+	}
+	om, oursIsMap := ours.(map[string]any)
+	tm, theirsIsMap := theirs.(map[string]any)
+	if oursIsMap && theirsIsMap {
+		bm, _ := base.(map[string]any) //nolint:errcheck // A nil map is fine if base isn't a map
+		result := make(map[string]any)
+		for _, key := range unionKeys(bm, om, tm) {
+			var v any
+			if keyed := listKey(nonMissing(lookup(bm, key), lookup(om, key), lookup(tm, key))...); keyed != "" {
+				v = m.mergeList(fmt.Sprintf("%s > %s", where, sectionLabel(key)), keyed, lookup(bm, key),
+					lookup(om, key), lookup(tm, key))
+			} else {
+				v = m.merge(where, joinField(field, key), lookup(bm, key), lookup(om, key), lookup(tm, key))
+			}
+			if v != missing {
+				result[key] = v
+			}
+		}
+		for key := range ignoredKeys {
+			if v, ok := om[key]; ok {
+				result[key] = v
+			}
+		}
+		return result
+	}
+	if field == "" {
+		if keyed := listKey(nonMissing(base, ours, theirs)...); keyed != "" {
+			return m.mergeList(where, keyed, base, ours, theirs)
+		}
+	}
+	m.conflict(where, field, base, ours, theirs)
+	return ours
+}
+
+func nonMissing(values ...any) []any {
+	result := make([]any, 0, len(values))
+	for _, v := range values {
+		if v != missing {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// listTrees holds every row of a list on each side of a merge, including those nested within containers, so that a row
+// can be matched by its ID no matter which container it was moved to.
+type listTrees struct {
+	base     map[string]*node
+	ours     map[string]*node
+	theirs   map[string]*node
+	included map[string]bool
+}
+
+func newListTrees(keyField string, base, ours, theirs any) *listTrees {
+	return &listTrees{
+		base:     nodesByID(asList(base), keyField),
+		ours:     nodesByID(asList(ours), keyField),
+		theirs:   nodesByID(asList(theirs), keyField),
+		included: make(map[string]bool),
+	}
+}
+
+func nodesByID(list []any, keyField string) map[string]*node {
+	nodes := flattenNodes(list, keyField, "")
+	m := make(map[string]*node, len(nodes))
+	for _, one := range nodes {
+		m[one.id] = one
+	}
+	return m
+}
+
+// placement returns the ID of the container the row belongs in after the merge, or an empty string for the top level.
+// A move made by only one side is taken from that side. Where both sides moved the row, ours wins, as it does when
+// theirs moved the row into a container that ours removed.
+func (lt *listTrees) placement(id string) string {
+	bn, inBase := lt.base[id]
+	on, inOurs := lt.ours[id]
+	tn, inTheirs := lt.theirs[id]
+	switch {
+	case !inTheirs:
+		return on.parent
+	case !inOurs:
+		return tn.parent
+	case !inBase || on.parent != bn.parent:
+		return on.parent
+	case tn.parent != "":
+		if _, exists := lt.ours[tn.parent]; !exists {
+			return on.parent
+		}
+	}
+	return tn.parent
+}
+
+func nodeObj(m map[string]*node, id string) (map[string]any, bool) {
+	if n, ok := m[id]; ok {
+		return n.obj, true
+	}
+	return nil, false
+}
+
+func (m *merger) mergeList(label, keyField string, base, ours, theirs any) any {
+	if ours == missing && theirs == missing {
+		return missing
+	}
+	return m.mergeRows(label, keyField, newListTrees(keyField, base, ours, theirs), "", ours, theirs)
+}
+
+// mergeRows merges the rows that belong directly within the container with the given ID, which is empty for the top
+// level. ours and theirs are that container's rows on each side. Rows that were moved in from elsewhere are merged
+// here, and rows that were moved out are left for their new container.
+func (m *merger) mergeRows(label, keyField string, lt *listTrees, parentID string, ours, theirs any) any {
+	o := indexList(asList(ours), keyField)
+	t := indexList(asList(theirs), keyField)
+	result := make([]any, 0, len(o.order)+len(t.order))
+	for _, id := range o.order {
+		if lt.included[id] || lt.placement(id) != parentID {
+			continue
+		}
+		oursObj := o.byID[id]
+		baseObj, inBase := nodeObj(lt.base, id)
+		theirsObj, inTheirs := nodeObj(lt.theirs, id)
+		where := nodeWhere(label, oursObj)
+		lt.included[id] = true
+		switch {
+		case inTheirs:
+			result = append(result, m.mergeNode(label, where, keyField, lt, id, valueOrMissing(baseObj, inBase), oursObj,
+				theirsObj))
+		case !inBase:
+			result = append(result, oursObj)
+		case equal(baseObj, oursObj):
+			continue // Removed by theirs and untouched by ours
+		default:
+			m.conflict(where, "", baseObj, oursObj, missing)
+			result = append(result, oursObj)
+		}
+	}
+	for i, id := range t.order {
+		if lt.included[id] || lt.placement(id) != parentID {
+			continue
+		}
+		lt.included[id] = true
+		theirsObj := t.byID[id]
+		baseObj, inBase := nodeObj(lt.base, id)
+		var obj any = theirsObj
+		if oursObj, inOurs := nodeObj(lt.ours, id); inOurs {
+			// Moved here by theirs, so merge it with ours.
+			obj = m.mergeNode(label, nodeWhere(label, oursObj), keyField, lt, id, valueOrMissing(baseObj, inBase),
+				oursObj, theirsObj)
+		} else if inBase {
+			if equal(baseObj, theirsObj) {
+				continue // Removed by ours and untouched by theirs
+			}
+			m.conflict(nodeWhere(label, theirsObj), "", baseObj, missing, theirsObj)
+			continue // Ours wins, so the removal stands
+		}
+		// Place it after the row that precedes it in theirs, if that row made it into the result.
+		pos := len(result)
+		if i > 0 {
+			prev := t.order[i-1]
+			for j, one := range result {
+				if rowObj, ok := one.(map[string]any); ok && fmt.Sprint(rowObj[keyField]) == prev {
+					pos = j + 1
+					break
+				}
+			}
+		}
+		result = append(result, nil)
+		copy(result[pos+1:], result[pos:])
+		result[pos] = obj
+	}
+	if len(result) == 0 && (ours == missing || len(asList(ours)) == 0) {
+		return ours
+	}
+	return result
+}
+
+func valueOrMissing(obj map[string]any, ok bool) any {
+	if ok {
+		return obj
+	}
+	return missing
+}
+
+func (m *merger) mergeNode(label, where, keyField string, lt *listTrees, id string, base any, ours,
+	theirs map[string]any) any {
+	merged := m.merge(where, "", withoutChildren(mapOrNil(base)), withoutChildren(ours), withoutChildren(theirs))
+	mm, ok := merged.(map[string]any)
+	if !ok {
+		return ours
+	}
+	_, oc := ours[childrenKey]
+	_, tc := theirs[childrenKey]
+	if oc || tc {
+		if children := m.mergeRows(label, keyField, lt, id, lookup(ours, childrenKey),
+			lookup(theirs, childrenKey)); children != missing {
+			mm[childrenKey] = children
+		}
+	}
+	return mm
+}
+
+func mapOrNil(v any) map[string]any {
+	m, _ := v.(map[string]any) //nolint:errcheck // A nil map is the desired result when v isn't a map
+	return m
+}
+
+type indexedList struct {
+	order []string
+	byID  map[string]map[string]any
+}
+
+func indexList(list []any, keyField string) *indexedList {
+	idx := &indexedList{byID: make(map[string]map[string]any, len(list))}
+	for _, one := range list {
+		if obj, ok := one.(map[string]any); ok {
+			id := fmt.Sprint(obj[keyField])
+			idx.order = append(idx.order, id)
+			idx.byID[id] = obj
+		}
+	}
+	return idx
+}
+
+// MergeFiles performs a three-way merge of the sheets at oursPath and theirsPath, which were both derived from the
+// sheet at basePath, writing the result to outPath and any conflicts to w. Returns true if there were conflicts.
+func MergeFiles(w io.Writer, basePath, oursPath, theirsPath, outPath string) (bool, error) {
+	base, err := loadEntity(basePath)
+	if err != nil {
+		return false, err
+	}
+	var ours, theirs *gurps.Entity
+	if ours, err = loadEntity(oursPath); err != nil {
+		return false, err
+	}
+	if theirs, err = loadEntity(theirsPath); err != nil {
+		return false, err
+	}
+	merged, conflicts, mergeErr := Merge(base, ours, theirs)
+	if mergeErr != nil {
+		return false, mergeErr
+	}
+	if err = merged.Save(outPath); err != nil {
+		return false, err
+	}
+	for _, one := range conflicts {
+		fmt.Fprintln(w, one)
+	}
+	fmt.Fprintf(w, i18n.Text("Merged into %s with %d conflict(s)\n"), outPath, len(conflicts))
+	return len(conflicts) != 0, nil
+}
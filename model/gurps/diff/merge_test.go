@@ -0,0 +1,283 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package diff_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/datafile"
+	"github.com/richardwilkes/gcs/v5/model/gurps/diff"
+	"github.com/richardwilkes/gcs/v5/model/jio"
+	"github.com/richardwilkes/gcs/v5/model/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBaseEntity() *gurps.Entity {
+	settings.Global()
+	e := gurps.NewEntity(datafile.PC)
+	e.Profile.Name = "Base"
+	e.Traits = nil
+	for _, name := range []string{"Alpha", "Beta", "Gamma"} {
+		t := gurps.NewTrait(e, nil, false)
+		t.Name = name
+		e.Traits = append(e.Traits, t)
+	}
+	return e
+}
+
+func cloneEntity(t *testing.T, e *gurps.Entity) *gurps.Entity {
+	var buffer bytes.Buffer
+	require.NoError(t, jio.Save(context.Background(), &buffer, e))
+	var other gurps.Entity
+	require.NoError(t, jio.Load(context.Background(), &buffer, &other))
+	return &other
+}
+
+func traitNames(e *gurps.Entity) []string {
+	names := make([]string, len(e.Traits))
+	for i, one := range e.Traits {
+		names[i] = one.Name
+	}
+	return names
+}
+
+func TestMerge(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		ours      func(e *gurps.Entity)
+		theirs    func(e *gurps.Entity)
+		conflicts int
+		check     func(t *testing.T, e *gurps.Entity)
+	}{
+		{
+			name:   "no changes",
+			ours:   func(e *gurps.Entity) {},
+			theirs: func(e *gurps.Entity) {},
+			check: func(t *testing.T, e *gurps.Entity) {
+				assert.Equal(t, "Base", e.Profile.Name)
+				assert.Equal(t, []string{"Alpha", "Beta", "Gamma"}, traitNames(e))
+			},
+		},
+		{
+			name:   "one-sided changes on each side",
+			ours:   func(e *gurps.Entity) { e.Profile.Name = "Ours" },
+			theirs: func(e *gurps.Entity) { e.Funds = fxp.From(250) },
+			check: func(t *testing.T, e *gurps.Entity) {
+				assert.Equal(t, "Ours", e.Profile.Name)
+				assert.Equal(t, fxp.From(250), e.Funds)
+			},
+		},
+		{
+			name:   "same change on both sides",
+			ours:   func(e *gurps.Entity) { e.Profile.Name = "Same" },
+			theirs: func(e *gurps.Entity) { e.Profile.Name = "Same" },
+			check: func(t *testing.T, e *gurps.Entity) {
+				assert.Equal(t, "Same", e.Profile.Name)
+			},
+		},
+		{
+			name:      "conflicting change keeps ours",
+			ours:      func(e *gurps.Entity) { e.Profile.Name = "Ours" },
+			theirs:    func(e *gurps.Entity) { e.Profile.Name = "Theirs" },
+			conflicts: 1,
+			check: func(t *testing.T, e *gurps.Entity) {
+				assert.Equal(t, "Ours", e.Profile.Name)
+			},
+		},
+		{
+			name:   "list items are matched by identity, not position",
+			ours:   func(e *gurps.Entity) { e.Traits[1].Name = "Beta Prime" },
+			theirs: func(e *gurps.Entity) { e.Traits = append(e.Traits[:0:0], e.Traits[2], e.Traits[0], e.Traits[1]) },
+			check: func(t *testing.T, e *gurps.Entity) {
+				assert.Equal(t, []string{"Alpha", "Beta Prime", "Gamma"}, traitNames(e))
+			},
+		},
+		{
+			name: "item added by theirs is placed after its predecessor",
+			ours: func(e *gurps.Entity) {},
+			theirs: func(e *gurps.Entity) {
+				t := gurps.NewTrait(e, nil, false)
+				t.Name = "Delta"
+				e.Traits = []*gurps.Trait{e.Traits[0], t, e.Traits[1], e.Traits[2]}
+			},
+			check: func(t *testing.T, e *gurps.Entity) {
+				assert.Equal(t, []string{"Alpha", "Delta", "Beta", "Gamma"}, traitNames(e))
+			},
+		},
+		{
+			name:   "item removed by one side and untouched by the other",
+			ours:   func(e *gurps.Entity) {},
+			theirs: func(e *gurps.Entity) { e.Traits = []*gurps.Trait{e.Traits[0], e.Traits[2]} },
+			check: func(t *testing.T, e *gurps.Entity) {
+				assert.Equal(t, []string{"Alpha", "Gamma"}, traitNames(e))
+			},
+		},
+		{
+			name:      "item removed by theirs but changed by ours",
+			ours:      func(e *gurps.Entity) { e.Traits[1].Name = "Beta Prime" },
+			theirs:    func(e *gurps.Entity) { e.Traits = []*gurps.Trait{e.Traits[0], e.Traits[2]} },
+			conflicts: 1,
+			check: func(t *testing.T, e *gurps.Entity) {
+				assert.Equal(t, []string{"Alpha", "Beta Prime", "Gamma"}, traitNames(e))
+			},
+		},
+		{
+			name:      "item removed by ours but changed by theirs",
+			ours:      func(e *gurps.Entity) { e.Traits = []*gurps.Trait{e.Traits[0], e.Traits[2]} },
+			theirs:    func(e *gurps.Entity) { e.Traits[1].Name = "Beta Prime" },
+			conflicts: 1,
+			check: func(t *testing.T, e *gurps.Entity) {
+				assert.Equal(t, []string{"Alpha", "Gamma"}, traitNames(e))
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			base := newBaseEntity()
+			ours := cloneEntity(t, base)
+			tc.ours(ours)
+			theirs := cloneEntity(t, base)
+			tc.theirs(theirs)
+			merged, conflicts, err := diff.Merge(base, ours, theirs)
+			require.NoError(t, err)
+			assert.Len(t, conflicts, tc.conflicts)
+			tc.check(t, merged)
+		})
+	}
+}
+
+func TestEntities(t *testing.T) {
+	base := newContainerEntity()
+	base.Profile.TechLevel = "3"
+	after := cloneEntity(t, base)
+	after.Profile.Name = "After"
+	after.Profile.TechLevel = "4"
+	after.Traits[1].Name = "Beta Prime"
+	after.Traits[2].BasePoints = fxp.From(5)
+	group := after.Traits[3]
+	after.Traits = append(after.Traits[1:3], group, group.Children[0])
+	group.Children = group.Children[1:]
+	after.Attributes.Set["st"].Adjustment = fxp.Two
+	changes, err := diff.Entities(base, after)
+	require.NoError(t, err)
+	list := make([]string, len(changes))
+	for i, one := range changes {
+		list[i] = one.String()
+	}
+	assert.Equal(t, []string{
+		"Profile: Name: Base → After; Tech Level: 3 → 4",
+		`Attribute "st": Adjustment: 0 → 2`,
+		`Trait "Alpha" removed`,
+		`Trait "Beta Prime": Name: Beta → Beta Prime`,
+		`Trait "Gamma": Base Points: none → 5`,
+		`Trait "Delta": Container: Group → top level`,
+	}, list)
+
+	changes, err = diff.Entities(base, cloneEntity(t, base))
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func newContainerEntity() *gurps.Entity {
+	e := newBaseEntity()
+	group := gurps.NewTrait(e, nil, true)
+	group.Name = "Group"
+	for _, name := range []string{"Delta", "Epsilon"} {
+		t := gurps.NewTrait(e, group, false)
+		t.Name = name
+		group.Children = append(group.Children, t)
+	}
+	e.Traits = append(e.Traits, group)
+	return e
+}
+
+// traitPaths returns the path of every trait, including those within containers.
+func traitPaths(e *gurps.Entity) []string {
+	var paths []string
+	var collect func(prefix string, list []*gurps.Trait)
+	collect = func(prefix string, list []*gurps.Trait) {
+		for _, one := range list {
+			paths = append(paths, prefix+one.Name)
+			collect(prefix+one.Name+"/", one.Children)
+		}
+	}
+	collect("", e.Traits)
+	return paths
+}
+
+func TestMergeMovedItems(t *testing.T) {
+	moveDeltaToTop := func(e *gurps.Entity) {
+		group := e.Traits[3]
+		delta := group.Children[0]
+		group.Children = group.Children[1:]
+		e.Traits = append(e.Traits, delta)
+	}
+	moveAlphaIntoGroup := func(e *gurps.Entity) {
+		alpha := e.Traits[0]
+		e.Traits = e.Traits[1:]
+		group := e.Traits[2]
+		group.Children = append(group.Children, alpha)
+	}
+	for _, tc := range []struct {
+		name      string
+		ours      func(e *gurps.Entity)
+		theirs    func(e *gurps.Entity)
+		conflicts int
+		expected  []string
+	}{
+		{
+			name:     "moved out of a container by theirs, edited by ours",
+			ours:     func(e *gurps.Entity) { e.Traits[3].Children[0].Name = "Delta Prime" },
+			theirs:   moveDeltaToTop,
+			expected: []string{"Alpha", "Beta", "Gamma", "Group", "Group/Epsilon", "Delta Prime"},
+		},
+		{
+			name:     "moved out of a container by ours, edited by theirs",
+			ours:     moveDeltaToTop,
+			theirs:   func(e *gurps.Entity) { e.Traits[3].Children[0].Name = "Delta Prime" },
+			expected: []string{"Alpha", "Beta", "Gamma", "Group", "Group/Epsilon", "Delta Prime"},
+		},
+		{
+			name:     "moved into a container by theirs, edited by ours",
+			ours:     func(e *gurps.Entity) { e.Traits[0].Name = "Alpha Prime" },
+			theirs:   moveAlphaIntoGroup,
+			expected: []string{"Beta", "Gamma", "Group", "Group/Delta", "Group/Epsilon", "Group/Alpha Prime"},
+		},
+		{
+			name: "different items moved out of a container by each side",
+			ours: moveDeltaToTop,
+			theirs: func(e *gurps.Entity) {
+				group := e.Traits[3]
+				epsilon := group.Children[1]
+				group.Children = group.Children[:1]
+				e.Traits = append(e.Traits, epsilon)
+			},
+			expected: []string{"Alpha", "Beta", "Gamma", "Group", "Epsilon", "Delta"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			base := newContainerEntity()
+			ours := cloneEntity(t, base)
+			tc.ours(ours)
+			theirs := cloneEntity(t, base)
+			tc.theirs(theirs)
+			merged, conflicts, err := diff.Merge(base, ours, theirs)
+			require.NoError(t, err)
+			assert.Len(t, conflicts, tc.conflicts)
+			assert.Equal(t, tc.expected, traitPaths(merged))
+		})
+	}
+}
@@ -58,22 +58,27 @@ type EntityProvider interface {
 
 // EntityData holds the Entity data that is written to disk.
 type EntityData struct {
-	Type             datafile.Type  `json:"type"`
-	Version          int            `json:"version"`
-	ID               uuid.UUID      `json:"id"`
-	TotalPoints      fxp.Int        `json:"total_points"`
-	Profile          *Profile       `json:"profile,omitempty"`
-	SheetSettings    *SheetSettings `json:"settings,omitempty"`
-	Attributes       *Attributes    `json:"attributes,omitempty"`
-	Traits           []*Trait       `json:"traits,alt=advantages,omitempty"`
-	Skills           []*Skill       `json:"skills,omitempty"`
-	Spells           []*Spell       `json:"spells,omitempty"`
-	CarriedEquipment []*Equipment   `json:"equipment,omitempty"`
-	OtherEquipment   []*Equipment   `json:"other_equipment,omitempty"`
-	Notes            []*Note        `json:"notes,omitempty"`
-	CreatedOn        jio.Time       `json:"created_date"`
-	ModifiedOn       jio.Time       `json:"modified_date"`
-	ThirdParty       map[string]any `json:"third_party,omitempty"`
+	Type                datafile.Type   `json:"type"`
+	Version             int             `json:"version"`
+	ID                  uuid.UUID       `json:"id"`
+	TotalPoints         fxp.Int         `json:"total_points"`
+	Campaign            string          `json:"campaign,omitempty"`
+	Advancement         *AdvancementLog `json:"advancement,omitempty"`
+	Profile             *Profile        `json:"profile,omitempty"`
+	SheetSettings       *SheetSettings  `json:"settings,omitempty"`
+	PreCampaignSettings *SheetSettings  `json:"pre_campaign_settings,omitempty"`
+	Attributes          *Attributes     `json:"attributes,omitempty"`
+	Traits              []*Trait        `json:"traits,alt=advantages,omitempty"`
+	Skills              []*Skill        `json:"skills,omitempty"`
+	Spells              []*Spell        `json:"spells,omitempty"`
+	CarriedEquipment    []*Equipment    `json:"equipment,omitempty"`
+	OtherEquipment      []*Equipment    `json:"other_equipment,omitempty"`
+	Funds               fxp.Int         `json:"funds,omitempty"`
+	Loadouts            []*Loadout      `json:"loadouts,omitempty"`
+	Notes               []*Note         `json:"notes,omitempty"`
+	CreatedOn           jio.Time        `json:"created_date"`
+	ModifiedOn          jio.Time        `json:"modified_date"`
+	ThirdParty          map[string]any  `json:"third_party,omitempty"`
 }
 
 // Entity holds the base information for various types of entities: PC, NPC, Creature, etc.
@@ -410,6 +415,30 @@ func (e *Entity) SpentPoints() fxp.Int {
 	return total
 }
 
+// SyncAttributesWithDefs adds any attributes that are defined in the sheet settings but missing from the entity,
+// removes any that are no longer defined, and updates the order of the remainder to match their definitions.
+func (e *Entity) SyncAttributesWithDefs() {
+	e.AddMissingAttributes()
+	for attrID := range e.Attributes.Set {
+		if _, exists := e.SheetSettings.Attributes.Set[attrID]; !exists {
+			delete(e.Attributes.Set, attrID)
+		}
+	}
+}
+
+// AddMissingAttributes adds any attributes that are defined in the sheet settings but missing from the entity and
+// updates the order of the existing ones to match their definitions. Attributes that are no longer defined are kept, so
+// that they return intact should their definitions be restored.
+func (e *Entity) AddMissingAttributes() {
+	for attrID, def := range e.SheetSettings.Attributes.Set {
+		if attr, exists := e.Attributes.Set[attrID]; exists {
+			attr.Order = def.Order
+		} else {
+			e.Attributes.Set[attrID] = NewAttribute(e, attrID, def.Order)
+		}
+	}
+}
+
 // UnspentPoints returns the number of unspent points.
 func (e *Entity) UnspentPoints() fxp.Int {
 	return e.TotalPoints - e.SpentPoints()
@@ -251,11 +251,11 @@ func (e *Equipment) CellData(column int, data *CellData) {
 		data.Alignment = unison.EndAlignment
 	case EquipmentCostColumn:
 		data.Type = Text
-		data.Primary = e.AdjustedValue().String()
+		data.Primary = e.FormattedValue()
 		data.Alignment = unison.EndAlignment
 	case EquipmentExtendedCostColumn:
 		data.Type = Text
-		data.Primary = e.ExtendedValue().String()
+		data.Primary = SheetSettingsFor(e.Entity).FormatValue(e.ExtendedValue())
 		data.Alignment = unison.EndAlignment
 	case EquipmentWeightColumn:
 		data.Type = Text
@@ -355,17 +355,27 @@ func (e *Equipment) TagList() []string {
 	return e.Tags
 }
 
-// AdjustedValue returns the value after adjustments for any modifiers. Does not include the value of children.
+// AdjustedValue returns the value after adjustments for any modifiers, in the equipment's currency. Does not include
+// the value of children.
 func (e *Equipment) AdjustedValue() fxp.Int {
-	return ValueAdjustedForModifiers(e.Value, e.Modifiers)
+	return ValueAdjustedForModifiers(e.EffectiveValue(e.Entity), e.Modifiers)
 }
 
-// ExtendedValue returns the extended value.
+// FormattedValue returns the adjusted value, with the currency symbol if the equipment isn't valued in the base
+// currency.
+func (e *Equipment) FormattedValue() string {
+	if c := e.CurrencyFor(e.Entity); c != nil {
+		return c.Format(e.AdjustedValue())
+	}
+	return e.AdjustedValue().String()
+}
+
+// ExtendedValue returns the extended value, in the base currency.
 func (e *Equipment) ExtendedValue() fxp.Int {
 	if e.Quantity <= 0 {
 		return 0
 	}
-	value := e.AdjustedValue()
+	value := e.ValueToBase(e.Entity, e.AdjustedValue())
 	if e.Container() {
 		for _, one := range e.Children {
 			value += one.ExtendedValue()
@@ -379,12 +389,27 @@ func (e *Equipment) AdjustedWeight(forSkills bool, defUnits measure.WeightUnits)
 	if forSkills && e.WeightIgnoredForSkills {
 		return 0
 	}
-	return WeightAdjustedForModifiers(e.Weight, e.Modifiers, defUnits)
+	return WeightAdjustedForModifiers(e.EffectiveWeight(e.Entity), e.Modifiers, defUnits)
 }
 
 // ExtendedWeight returns the extended weight.
 func (e *Equipment) ExtendedWeight(forSkills bool, defUnits measure.WeightUnits) measure.Weight {
-	return ExtendedWeightAdjustedForModifiers(defUnits, e.Quantity, e.Weight, e.Modifiers, e.Features, e.Children, forSkills, e.WeightIgnoredForSkills)
+	return ExtendedWeightAdjustedForModifiers(defUnits, e.Quantity, e.EffectiveWeight(e.Entity), e.Modifiers, e.Features, e.Children, forSkills, e.WeightIgnoredForSkills)
+}
+
+// ContentsWeight returns the weight of a single instance's contents, without any reductions the container itself
+// provides.
+func (e *Equipment) ContentsWeight(defUnits measure.WeightUnits) measure.Weight {
+	var weight measure.Weight
+	for _, one := range e.Children {
+		weight += one.ExtendedWeight(false, defUnits)
+	}
+	return weight
+}
+
+// OverCapacity returns true if the equipment is a container with a capacity and its contents weigh more than that.
+func (e *Equipment) OverCapacity(defUnits measure.WeightUnits) bool {
+	return e.Container() && e.Capacity > 0 && e.ContentsWeight(defUnits) > e.Capacity
 }
 
 // ExtendedWeightAdjustedForModifiers calculates the extended weight.
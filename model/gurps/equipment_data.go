@@ -27,4 +27,7 @@ func (d *EquipmentData) Kind() string {
 // ClearUnusedFieldsForType zeroes out the fields that are not applicable to this type (container vs not-container).
 func (d *EquipmentData) ClearUnusedFieldsForType() {
 	d.clearUnusedFields()
+	if !d.Container() || !d.TemplatePicker.IsSet() {
+		d.TemplatePicker = nil
+	}
 }
@@ -29,10 +29,13 @@ type EquipmentEditData struct {
 	TechLevel              string               `json:"tech_level,omitempty"`
 	LegalityClass          string               `json:"legality_class,omitempty"`
 	Tags                   []string             `json:"tags,omitempty"`
+	TemplatePicker         *TemplatePicker      `json:"template_picker,omitempty"` // Container only
 	Modifiers              []*EquipmentModifier `json:"modifiers,omitempty"`
 	Quantity               fxp.Int              `json:"quantity,omitempty"`
 	Value                  fxp.Int              `json:"value,omitempty"`
+	Currency               string               `json:"currency,omitempty"`
 	Weight                 measure.Weight       `json:"weight,omitempty"`
+	Capacity               measure.Weight       `json:"capacity,omitempty"` // Container only
 	MaxUses                int                  `json:"max_uses,omitempty"`
 	Uses                   int                  `json:"uses,omitempty"`
 	Prereq                 *PrereqList          `json:"prereqs,omitempty"`
@@ -40,19 +43,20 @@ type EquipmentEditData struct {
 	Features               feature.Features     `json:"features,omitempty"`
 	Equipped               bool                 `json:"equipped,omitempty"`
 	WeightIgnoredForSkills bool                 `json:"ignore_weight_for_skills,omitempty"`
+	Coins                  bool                 `json:"coins,omitempty"`
 }
 
 // CopyFrom implements node.EditorData.
 func (d *EquipmentEditData) CopyFrom(e *Equipment) {
-	d.copyFrom(e.Entity, &e.EquipmentEditData, false)
+	d.copyFrom(e.Entity, &e.EquipmentEditData, e.Container(), false)
 }
 
 // ApplyTo implements node.EditorData.
 func (d *EquipmentEditData) ApplyTo(e *Equipment) {
-	e.EquipmentEditData.copyFrom(e.Entity, d, true)
+	e.EquipmentEditData.copyFrom(e.Entity, d, e.Container(), true)
 }
 
-func (d *EquipmentEditData) copyFrom(entity *Entity, other *EquipmentEditData, isApply bool) {
+func (d *EquipmentEditData) copyFrom(entity *Entity, other *EquipmentEditData, isContainer, isApply bool) {
 	*d = *other
 	d.Tags = txt.CloneStringSlice(d.Tags)
 	d.Modifiers = nil
@@ -63,6 +67,7 @@ func (d *EquipmentEditData) copyFrom(entity *Entity, other *EquipmentEditData, i
 		}
 	}
 	d.Prereq = d.Prereq.CloneResolvingEmpty(false, isApply)
+	d.TemplatePicker = d.TemplatePicker.CloneResolvingEmpty(isContainer, isApply)
 	d.Weapons = nil
 	if len(other.Weapons) != 0 {
 		d.Weapons = make([]*Weapon, 0, len(other.Weapons))
@@ -72,3 +77,36 @@ func (d *EquipmentEditData) copyFrom(entity *Entity, other *EquipmentEditData, i
 	}
 	d.Features = other.Features.Clone()
 }
+
+// CurrencyFor returns the currency the value is expressed in, or nil if it is in the base currency.
+func (d *EquipmentEditData) CurrencyFor(entity *Entity) *Currency {
+	return SheetSettingsFor(entity).Currency(d.Currency)
+}
+
+// EffectiveValue returns the value of a single item, prior to any modifiers. Coins are always worth one unit of their
+// currency.
+func (d *EquipmentEditData) EffectiveValue(entity *Entity) fxp.Int {
+	if d.Coins && d.CurrencyFor(entity) != nil {
+		return fxp.One
+	}
+	return d.Value
+}
+
+// EffectiveWeight returns the weight of a single item, prior to any modifiers. Coins weigh whatever their currency's
+// coins have been set to weigh.
+func (d *EquipmentEditData) EffectiveWeight(entity *Entity) measure.Weight {
+	if d.Coins {
+		if c := d.CurrencyFor(entity); c != nil && c.CoinWeight > 0 {
+			return c.CoinWeight
+		}
+	}
+	return d.Weight
+}
+
+// ValueToBase converts a value expressed in this data's currency into the base currency.
+func (d *EquipmentEditData) ValueToBase(entity *Entity, value fxp.Int) fxp.Int {
+	if c := d.CurrencyFor(entity); c != nil {
+		return c.ToBase(value)
+	}
+	return value
+}
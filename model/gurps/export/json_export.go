@@ -0,0 +1,58 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package export
+
+import (
+	"context"
+	"io"
+
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/jio"
+	"github.com/richardwilkes/toolbox/cmdline"
+)
+
+// ComputedDataVersion is the version of the document written by JSONExport. It must be incremented whenever a field is
+// removed or its meaning changes, so that consumers can detect documents they don't understand. Adding fields does not
+// require a new version.
+const ComputedDataVersion = 1
+
+// ComputedDataType is the value of the "type" field of the document written by JSONExport.
+const ComputedDataType = "computed_character"
+
+// JSONExt is the file extension used for computed data exports.
+const JSONExt = ".json"
+
+type computedDocument struct {
+	Type      string `json:"type"`
+	Version   int    `json:"version"`
+	Generator string `json:"generator"`
+	*SheetData
+}
+
+// JSONExport writes the fully computed values of the entity as a versioned JSON document.
+func JSONExport(entity *gurps.Entity, exportPath string) error {
+	return jio.SaveToFile(context.Background(), exportPath, newComputedDocument(entity))
+}
+
+// WriteEntityJSON writes the fully computed values of the entity as a versioned JSON document.
+func WriteEntityJSON(entity *gurps.Entity, w io.Writer) error {
+	return jio.Save(context.Background(), w, newComputedDocument(entity))
+}
+
+func newComputedDocument(entity *gurps.Entity) *computedDocument {
+	return &computedDocument{
+		Type:      ComputedDataType,
+		Version:   ComputedDataVersion,
+		Generator: cmdline.AppName + " " + cmdline.AppVersion,
+		SheetData: NewSheetData(entity),
+	}
+}
@@ -280,9 +280,9 @@ func (ex *legacyExporter) emitKey(key string) error {
 	case "CURRENT_MOVE":
 		ex.writeEncodedText(strconv.Itoa(ex.entity.Move(ex.entity.EncumbranceLevel(false))))
 	case "BEST_CURRENT_PARRY":
-		ex.writeEncodedText(ex.bestWeaponDefense(func(w *gurps.Weapon) string { return w.ResolvedParry(nil) }))
+		ex.writeEncodedText(bestWeaponDefense(ex.entity, func(w *gurps.Weapon) string { return w.ResolvedParry(nil) }))
 	case "BEST_CURRENT_BLOCK":
-		ex.writeEncodedText(ex.bestWeaponDefense(func(w *gurps.Weapon) string { return w.ResolvedBlock(nil) }))
+		ex.writeEncodedText(bestWeaponDefense(ex.entity, func(w *gurps.Weapon) string { return w.ResolvedBlock(nil) }))
 	case "TIRED":
 		ex.writeEncodedText(ex.entity.Attributes.PoolThreshold(gid.FatiguePoints, "tired").String())
 	case "FP_COLLAPSE":
@@ -320,9 +320,9 @@ func (ex *legacyExporter) emitKey(key string) error {
 	case "CARRIED_WEIGHT":
 		ex.writeEncodedText(ex.entity.SheetSettings.DefaultWeightUnits.Format(ex.entity.WeightCarried(false)))
 	case "CARRIED_VALUE":
-		ex.writeEncodedText("$" + ex.entity.WealthCarried().String())
+		ex.writeEncodedText(ex.entity.SheetSettings.FormatWealth(ex.entity.WealthCarried()))
 	case "OTHER_EQUIPMENT_VALUE":
-		ex.writeEncodedText("$" + ex.entity.WealthNotCarried().String())
+		ex.writeEncodedText(ex.entity.SheetSettings.FormatWealth(ex.entity.WealthNotCarried()))
 	case "NOTES":
 		needBlanks := false
 		gurps.Traverse[*gurps.Note](func(n *gurps.Note) bool {
@@ -610,10 +610,10 @@ func (ex *legacyExporter) writeEncodedText(text string) {
 	}
 }
 
-func (ex *legacyExporter) bestWeaponDefense(f func(weapon *gurps.Weapon) string) string {
+func bestWeaponDefense(entity *gurps.Entity, f func(weapon *gurps.Weapon) string) string {
 	best := "-"
 	bestValue := fxp.Min
-	for _, w := range ex.entity.EquippedWeapons(weapon.Melee) {
+	for _, w := range entity.EquippedWeapons(weapon.Melee) {
 		if s := f(w); s != "" && !strings.EqualFold(s, "no") {
 			if v, rem := fxp.Extract(s); v != 0 || rem != s {
 				if bestValue < v {
@@ -748,9 +748,9 @@ func (ex *legacyExporter) processHitLocationLoop(buffer []byte) {
 				location.DisplayDR(ex.entity, &tooltip)
 				ex.writeEncodedText(tooltip.String())
 			case "EQUIPMENT":
-				ex.writeEncodedText(strings.Join(ex.hitLocationEquipment(location), ", "))
+				ex.writeEncodedText(strings.Join(hitLocationEquipment(ex.entity, location), ", "))
 			case "EQUIPMENT_FORMATTED":
-				for _, one := range ex.hitLocationEquipment(location) {
+				for _, one := range hitLocationEquipment(ex.entity, location) {
 					ex.out.WriteString("<p>")
 					ex.writeEncodedText(one)
 					ex.out.WriteString("</p>\n")
@@ -763,7 +763,7 @@ func (ex *legacyExporter) processHitLocationLoop(buffer []byte) {
 	}
 }
 
-func (ex *legacyExporter) hitLocationEquipment(location *gurps.HitLocation) []string {
+func hitLocationEquipment(entity *gurps.Entity, location *gurps.HitLocation) []string {
 	var list []string
 	gurps.Traverse[*gurps.Equipment](func(eqp *gurps.Equipment) bool {
 		if eqp.Equipped {
@@ -776,7 +776,7 @@ func (ex *legacyExporter) hitLocationEquipment(location *gurps.HitLocation) []st
 			}
 		}
 		return false
-	}, false, false, ex.entity.CarriedEquipment...)
+	}, false, false, entity.CarriedEquipment...)
 	return list
 }
 
@@ -1054,11 +1054,11 @@ func (ex *legacyExporter) processEquipmentLoop(buffer []byte, carried bool) {
 				case "QTY":
 					ex.writeEncodedText(eqp.Quantity.String())
 				case "COST":
-					ex.writeEncodedText(eqp.AdjustedValue().String())
+					ex.writeEncodedText(eqp.FormattedValue())
 				case weightKey:
 					ex.writeEncodedText(ex.entity.SheetSettings.DefaultWeightUnits.Format(eqp.AdjustedWeight(false, ex.entity.SheetSettings.DefaultWeightUnits)))
 				case "COST_SUMMARY":
-					ex.writeEncodedText(eqp.ExtendedValue().String())
+					ex.writeEncodedText(ex.entity.SheetSettings.FormatValue(eqp.ExtendedValue()))
 				case "WEIGHT_SUMMARY":
 					ex.writeEncodedText(ex.entity.SheetSettings.DefaultWeightUnits.Format(eqp.ExtendedWeight(false, ex.entity.SheetSettings.DefaultWeightUnits)))
 				case "WEIGHT_RAW":
@@ -1360,7 +1360,7 @@ func (ex *legacyExporter) processWeaponKeys(key string, currentID int, w *gurps.
 		ex.writeEncodedText(v.String())
 	case "COST":
 		if eqp, ok := w.Owner.(*gurps.Equipment); ok {
-			ex.writeEncodedText(eqp.AdjustedValue().String())
+			ex.writeEncodedText(eqp.FormattedValue())
 		}
 	case "LEGALITY_CLASS", "LC":
 		if eqp, ok := w.Owner.(*gurps.Equipment); ok {
@@ -1376,7 +1376,7 @@ func (ex *legacyExporter) processWeaponKeys(key string, currentID int, w *gurps.
 		}
 	case "AMMO":
 		if eqp, ok := w.Owner.(*gurps.Equipment); ok {
-			ex.writeEncodedText(ex.ammoFor(eqp).String())
+			ex.writeEncodedText(ammoFor(ex.entity, eqp).String())
 		}
 	default:
 		switch {
@@ -1388,7 +1388,7 @@ func (ex *legacyExporter) processWeaponKeys(key string, currentID int, w *gurps.
 	}
 }
 
-func (ex *legacyExporter) ammoFor(weaponEqp *gurps.Equipment) fxp.Int {
+func ammoFor(entity *gurps.Entity, weaponEqp *gurps.Equipment) fxp.Int {
 	uses := ""
 	for _, cat := range weaponEqp.TagList() {
 		if strings.HasPrefix(strings.ToLower(cat), "usesammotype:") {
@@ -1412,7 +1412,7 @@ func (ex *legacyExporter) ammoFor(weaponEqp *gurps.Equipment) fxp.Int {
 			}
 		}
 		return false
-	}, false, false, ex.entity.CarriedEquipment...)
+	}, false, false, entity.CarriedEquipment...)
 	return total
 }
 
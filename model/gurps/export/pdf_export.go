@@ -0,0 +1,424 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"  // Register the gif decoder for portraits
+	_ "image/jpeg" // Register the jpeg decoder for portraits
+	_ "image/png"  // Register the png decoder for portraits
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/attribute"
+	"github.com/richardwilkes/gcs/v5/model/gurps/datafile"
+	"github.com/richardwilkes/gcs/v5/model/gurps/settings"
+	"github.com/richardwilkes/gcs/v5/model/gurps/weapon"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+)
+
+// PDFExport exports the entity's sheet to a PDF file, using the provided page settings for paper size, orientation and
+// margins. If page is nil, the entity's own page settings are used.
+func PDFExport(entity *gurps.Entity, page *settings.Page, exportPath string) (err error) {
+	var f *os.File
+	if f, err = os.Create(exportPath); err != nil {
+		return errs.Wrap(err)
+	}
+	w := bufio.NewWriter(f)
+	defer func() {
+		if flushErr := w.Flush(); flushErr != nil && err == nil {
+			err = errs.Wrap(flushErr)
+		}
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = errs.Wrap(closeErr)
+		}
+	}()
+	return WriteEntityPDF(entity, page, w)
+}
+
+// WriteEntityPDF writes a PDF rendition of the entity's sheet. If page is nil, the entity's own page settings are used.
+func WriteEntityPDF(entity *gurps.Entity, page *settings.Page, w io.Writer) error {
+	sheetSettings := gurps.SheetSettingsFor(entity)
+	if page == nil {
+		page = sheetSettings.Page
+	}
+	r := newPDFRenderer(page)
+	r.doc.Title = entity.Profile.Name
+	r.doc.Author = entity.Profile.PlayerName
+	r.doc.Subject = entity.Profile.Title
+	at := r.drawEntityTopBlock(entity, pdfCursor{y: r.top})
+	for _, row := range sheetSettings.BlockLayout.ByRow() {
+		tables := make([]*pdfTable, 0, len(row))
+		for _, key := range row {
+			if t := entityPDFTable(entity, key); t != nil {
+				tables = append(tables, t)
+			}
+		}
+		at = r.drawTables(at, tables...)
+	}
+	title := entity.Profile.Name
+	if sheetSettings.UseTitleInFooter {
+		title = entity.Profile.Title
+	}
+	r.drawFooters(title, fmt.Sprintf(i18n.Text("Modified %s"), entity.ModifiedOn.String()))
+	return r.doc.Write(w)
+}
+
+// WriteTemplatePDF writes a PDF rendition of the template. If page is nil, the global sheet page settings are used.
+func WriteTemplatePDF(template *gurps.Template, title string, page *settings.Page, w io.Writer) error {
+	sheetSettings := gurps.SheetSettingsFor(nil)
+	if page == nil {
+		page = sheetSettings.Page
+	}
+	r := newPDFRenderer(page)
+	r.doc.Title = title
+	at := pdfCursor{y: r.top}
+	for _, row := range sheetSettings.BlockLayout.ByRow() {
+		tables := make([]*pdfTable, 0, len(row))
+		for _, key := range row {
+			if t := templatePDFTable(template, key); t != nil {
+				tables = append(tables, t)
+			}
+		}
+		at = r.drawTables(at, tables...)
+	}
+	r.page(0)
+	r.drawFooters(title, "")
+	return r.doc.Write(w)
+}
+
+func (r *pdfRenderer) drawEntityTopBlock(entity *gurps.Entity, at pdfCursor) pdfCursor {
+	portrait := newPDFPanel(i18n.Text("Portrait"))
+	portrait.image = entityPortrait(entity)
+	x := r.left
+	width := r.contentWidth()
+	portraitWidth := float64(gurps.PortraitWidth + pdfPadding*2)
+	rest := width - portraitWidth - pdfGap
+	start := at
+	at = r.drawPanelColumns(at, x+portraitWidth+pdfGap, rest, pdfColumn{entityIdentityPanel(entity)},
+		pdfColumn{entityMiscPanel(entity)}, pdfColumn{entityPointsPanel(entity)})
+	at = r.drawPanelColumns(at, x+portraitWidth+pdfGap, rest, pdfColumn{entityDescriptionPanel(entity)})
+	portrait.draw(r.page(start.page), x, start.y, portraitWidth, at.y-pdfGap-start.y)
+	return r.drawPanelColumns(at, x, width,
+		pdfColumn{entityAttributesPanel(entity, i18n.Text("Primary Attributes"), true), entityDamagePanel(entity)},
+		pdfColumn{entityAttributesPanel(entity, i18n.Text("Secondary Attributes"), false), entityPoolsPanel(entity)},
+		pdfColumn{entityBodyPanel(entity)},
+		pdfColumn{entityEncumbrancePanel(entity), entityLiftingPanel(entity)})
+}
+
+func entityPortrait(entity *gurps.Entity) image.Image {
+	if len(entity.Profile.PortraitData) == 0 {
+		return nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(entity.Profile.PortraitData))
+	if err != nil {
+		jot.Warn(errs.NewWithCause("unable to decode portrait for PDF export", err))
+		return nil
+	}
+	return img
+}
+
+func entityIdentityPanel(entity *gurps.Entity) *pdfPanel {
+	p := newPDFPanel(i18n.Text("Identity"))
+	p.grab = 1
+	p.addRow(pdfLabelEnd(i18n.Text("Name")), pdfField(entity.Profile.Name))
+	p.addRow(pdfLabelEnd(i18n.Text("Title")), pdfField(entity.Profile.Title))
+	p.addRow(pdfLabelEnd(i18n.Text("Organization")), pdfField(entity.Profile.Organization))
+	return p
+}
+
+func entityMiscPanel(entity *gurps.Entity) *pdfPanel {
+	p := newPDFPanel(i18n.Text("Miscellaneous"))
+	p.grab = 1
+	p.addRow(pdfLabelEnd(i18n.Text("Created")), pdfField(entity.CreatedOn.String()))
+	p.addRow(pdfLabelEnd(i18n.Text("Modified")), pdfField(entity.ModifiedOn.String()))
+	p.addRow(pdfLabelEnd(i18n.Text("Player")), pdfField(entity.Profile.PlayerName))
+	return p
+}
+
+func entityPointsPanel(entity *gurps.Entity) *pdfPanel {
+	p := newPDFPanel(fmt.Sprintf(i18n.Text("%s Points"), entity.TotalPoints.String()))
+	p.banded = true
+	p.grab = 1
+	ad, disad, race, quirk := entity.TraitPoints()
+	p.addRow(pdfFieldEnd(entity.UnspentPoints().String()), pdfLabel(i18n.Text("Unspent")))
+	p.addRow(pdfFieldEnd(race.String()), pdfLabel(i18n.Text("Race")))
+	p.addRow(pdfFieldEnd(entity.AttributePoints().String()), pdfLabel(i18n.Text("Attributes")))
+	p.addRow(pdfFieldEnd(ad.String()), pdfLabel(i18n.Text("Advantages")))
+	p.addRow(pdfFieldEnd(disad.String()), pdfLabel(i18n.Text("Disadvantages")))
+	p.addRow(pdfFieldEnd(quirk.String()), pdfLabel(i18n.Text("Quirks")))
+	p.addRow(pdfFieldEnd(entity.SkillPoints().String()), pdfLabel(i18n.Text("Skills")))
+	p.addRow(pdfFieldEnd(entity.SpellPoints().String()), pdfLabel(i18n.Text("Spells")))
+	return p
+}
+
+func entityDescriptionPanel(entity *gurps.Entity) *pdfPanel {
+	sheetSettings := gurps.SheetSettingsFor(entity)
+	profile := entity.Profile
+	p := newPDFPanel(i18n.Text("Description"))
+	p.addRow(pdfLabelEnd(i18n.Text("Gender")), pdfField(profile.Gender),
+		pdfLabelEnd(i18n.Text("Height")), pdfField(sheetSettings.DefaultLengthUnits.Format(profile.Height)),
+		pdfLabelEnd(i18n.Text("Hair")), pdfField(profile.Hair))
+	p.addRow(pdfLabelEnd(i18n.Text("Age")), pdfField(profile.Age),
+		pdfLabelEnd(i18n.Text("Weight")), pdfField(sheetSettings.DefaultWeightUnits.Format(profile.Weight)),
+		pdfLabelEnd(i18n.Text("Eyes")), pdfField(profile.Eyes))
+	p.addRow(pdfLabelEnd(i18n.Text("Birthday")), pdfField(profile.Birthday),
+		pdfLabelEnd(i18n.Text("Size")), pdfField(strconv.Itoa(profile.AdjustedSizeModifier())),
+		pdfLabelEnd(i18n.Text("Skin")), pdfField(profile.Skin))
+	p.addRow(pdfLabelEnd(i18n.Text("Religion")), pdfField(profile.Religion),
+		pdfLabelEnd(i18n.Text("TL")), pdfField(profile.TechLevel),
+		pdfLabelEnd(i18n.Text("Hand")), pdfField(profile.Handedness))
+	return p
+}
+
+func entityAttributesPanel(entity *gurps.Entity, title string, primary bool) *pdfPanel {
+	p := newPDFPanel(title)
+	p.banded = true
+	p.grab = 2
+	for _, def := range gurps.SheetSettingsFor(entity).Attributes.List() {
+		if def.Type == attribute.Pool || def.Primary() != primary {
+			continue
+		}
+		if attr, ok := entity.Attributes.Set[def.ID()]; ok {
+			p.addRow(pdfPoints("["+attr.PointCost().String()+"]"), pdfFieldEnd(attr.Maximum().String()),
+				pdfLabel(def.CombinedName()))
+		}
+	}
+	return p
+}
+
+func entityPoolsPanel(entity *gurps.Entity) *pdfPanel {
+	p := newPDFPanel(i18n.Text("Point Pools"))
+	p.banded = true
+	p.grab = 5
+	for _, def := range gurps.SheetSettingsFor(entity).Attributes.List() {
+		if def.Type != attribute.Pool {
+			continue
+		}
+		if attr, ok := entity.Attributes.Set[def.ID()]; ok {
+			var state string
+			if threshold := attr.CurrentThreshold(); threshold != nil {
+				state = "[" + threshold.State + "]"
+			}
+			p.addRow(pdfPoints("["+attr.PointCost().String()+"]"), pdfFieldEnd(attr.Current().String()),
+				pdfLabel(i18n.Text("of")), pdfFieldEnd(attr.Maximum().String()), pdfLabel(def.Name), pdfLabel(state))
+		}
+	}
+	return p
+}
+
+func entityDamagePanel(entity *gurps.Entity) *pdfPanel {
+	p := newPDFPanel(i18n.Text("Basic Damage"))
+	p.banded = true
+	p.grab = 1
+	p.addRow(pdfFieldEnd(entity.Thrust().String()), pdfLabel(i18n.Text("Basic Thrust")))
+	p.addRow(pdfFieldEnd(entity.Swing().String()), pdfLabel(i18n.Text("Basic Swing")))
+	return p
+}
+
+func entityBodyPanel(entity *gurps.Entity) *pdfPanel {
+	body := gurps.SheetSettingsFor(entity).BodyType
+	p := newPDFPanel(body.Name)
+	p.header = true
+	p.banded = true
+	p.grab = 1
+	p.addRow(pdfHeader(i18n.Text("Roll")), pdfHeader(i18n.Text("Location")), pdfHeader(""), pdfHeader(i18n.Text("DR")))
+	addBodyRows(p, entity, body, 0)
+	return p
+}
+
+func addBodyRows(p *pdfPanel, entity *gurps.Entity, body *gurps.Body, depth int) {
+	prefix := strings.Repeat("   ", depth)
+	for _, location := range body.Locations {
+		p.addRow(pdfFieldCenter(prefix+location.RollRange), pdfLabel(prefix+location.TableName),
+			pdfFieldEnd(fmt.Sprintf("%+d", location.HitPenalty)), pdfFieldCenter(location.DisplayDR(entity, nil)))
+		if location.SubTable != nil {
+			addBodyRows(p, entity, location.SubTable, depth+1)
+		}
+	}
+}
+
+func entityEncumbrancePanel(entity *gurps.Entity) *pdfPanel {
+	sheetSettings := gurps.SheetSettingsFor(entity)
+	p := newPDFPanel(i18n.Text("Encumbrance, Move & Dodge"))
+	p.header = true
+	p.banded = true
+	p.grab = 1
+	p.addRow(pdfHeader(i18n.Text("Level")), pdfHeader(""), pdfHeader(i18n.Text("Max Load")), pdfHeader(i18n.Text("Move")),
+		pdfHeader(i18n.Text("Dodge")))
+	current := entity.EncumbranceLevel(true)
+	for i, enc := range datafile.AllEncumbrance {
+		if enc == current {
+			p.marked = i + 1
+		}
+		p.addRow(pdfFieldEnd(strconv.Itoa(int(enc))), pdfLabel(enc.String()),
+			pdfFieldEnd(sheetSettings.DefaultWeightUnits.Format(entity.MaximumCarry(enc))),
+			pdfFieldEnd(strconv.Itoa(entity.Move(enc))), pdfFieldEnd(strconv.Itoa(entity.Dodge(enc))))
+	}
+	return p
+}
+
+func entityLiftingPanel(entity *gurps.Entity) *pdfPanel {
+	units := gurps.SheetSettingsFor(entity).DefaultWeightUnits
+	p := newPDFPanel(i18n.Text("Lifting & Moving Things"))
+	p.banded = true
+	p.grab = 1
+	p.addRow(pdfFieldEnd(units.Format(entity.BasicLift())), pdfLabel(i18n.Text("Basic Lift")))
+	p.addRow(pdfFieldEnd(units.Format(entity.OneHandedLift())), pdfLabel(i18n.Text("One-Handed Lift")))
+	p.addRow(pdfFieldEnd(units.Format(entity.TwoHandedLift())), pdfLabel(i18n.Text("Two-Handed Lift")))
+	p.addRow(pdfFieldEnd(units.Format(entity.ShoveAndKnockOver())), pdfLabel(i18n.Text("Shove & Knock Over")))
+	p.addRow(pdfFieldEnd(units.Format(entity.RunningShoveAndKnockOver())),
+		pdfLabel(i18n.Text("Running Shove & Knock Over")))
+	p.addRow(pdfFieldEnd(units.Format(entity.CarryOnBack())), pdfLabel(i18n.Text("Carry On Back")))
+	p.addRow(pdfFieldEnd(units.Format(entity.ShiftSlightly())), pdfLabel(i18n.Text("Shift Slightly")))
+	return p
+}
+
+func entityPDFTable(entity *gurps.Entity, key string) *pdfTable {
+	switch key {
+	case gurps.BlockLayoutReactionsKey:
+		return newPDFTable([]*pdfTableColumn{
+			{title: "±", id: gurps.ConditionalModifierValueColumn},
+			{title: i18n.Text("Reaction"), id: gurps.ConditionalModifierDescriptionColumn},
+		}, 1, entity.Reactions())
+	case gurps.BlockLayoutConditionalModifiersKey:
+		return newPDFTable([]*pdfTableColumn{
+			{title: "±", id: gurps.ConditionalModifierValueColumn},
+			{title: i18n.Text("Condition"), id: gurps.ConditionalModifierDescriptionColumn},
+		}, 1, entity.ConditionalModifiers())
+	case gurps.BlockLayoutMeleeKey:
+		return newPDFTable([]*pdfTableColumn{
+			{title: weapon.Melee.String(), id: gurps.WeaponDescriptionColumn},
+			{title: i18n.Text("Usage"), id: gurps.WeaponUsageColumn},
+			{title: i18n.Text("SL"), id: gurps.WeaponSLColumn},
+			{title: i18n.Text("Parry"), id: gurps.WeaponParryColumn},
+			{title: i18n.Text("Block"), id: gurps.WeaponBlockColumn},
+			{title: i18n.Text("Damage"), id: gurps.WeaponDamageColumn},
+			{title: i18n.Text("Reach"), id: gurps.WeaponReachColumn},
+			{title: i18n.Text("ST"), id: gurps.WeaponSTColumn},
+		}, 0, entity.Weapons(weapon.Melee))
+	case gurps.BlockLayoutRangedKey:
+		return newPDFTable([]*pdfTableColumn{
+			{title: weapon.Ranged.String(), id: gurps.WeaponDescriptionColumn},
+			{title: i18n.Text("Usage"), id: gurps.WeaponUsageColumn},
+			{title: i18n.Text("SL"), id: gurps.WeaponSLColumn},
+			{title: i18n.Text("Acc"), id: gurps.WeaponAccColumn},
+			{title: i18n.Text("Damage"), id: gurps.WeaponDamageColumn},
+			{title: i18n.Text("Range"), id: gurps.WeaponRangeColumn},
+			{title: i18n.Text("RoF"), id: gurps.WeaponRoFColumn},
+			{title: i18n.Text("Shots"), id: gurps.WeaponShotsColumn},
+			{title: i18n.Text("Bulk"), id: gurps.WeaponBulkColumn},
+			{title: i18n.Text("Recoil"), id: gurps.WeaponRecoilColumn},
+			{title: i18n.Text("ST"), id: gurps.WeaponSTColumn},
+		}, 0, entity.Weapons(weapon.Ranged))
+	case gurps.BlockLayoutTraitsKey:
+		return traitsPDFTable(entity.Traits)
+	case gurps.BlockLayoutSkillsKey:
+		return newPDFTable([]*pdfTableColumn{
+			{title: i18n.Text("Skill / Technique"), id: gurps.SkillDescriptionColumn},
+			{title: i18n.Text("SL"), id: gurps.SkillLevelColumn},
+			{title: i18n.Text("RSL"), id: gurps.SkillRelativeLevelColumn},
+			{title: i18n.Text("Pts"), id: gurps.SkillPointsColumn},
+			{title: i18n.Text("Ref"), id: gurps.SkillReferenceColumn},
+		}, 0, entity.Skills)
+	case gurps.BlockLayoutSpellsKey:
+		return newPDFTable([]*pdfTableColumn{
+			{title: i18n.Text("Spell"), id: gurps.SpellDescriptionForPageColumn},
+			{title: i18n.Text("College"), id: gurps.SpellCollegeColumn},
+			{title: i18n.Text("SL"), id: gurps.SpellLevelColumn},
+			{title: i18n.Text("RSL"), id: gurps.SpellRelativeLevelColumn},
+			{title: i18n.Text("Pts"), id: gurps.SpellPointsColumn},
+			{title: i18n.Text("Ref"), id: gurps.SpellReferenceColumn},
+		}, 0, entity.Spells)
+	case gurps.BlockLayoutEquipmentKey:
+		return newPDFTable(carriedEquipmentPDFColumns(fmt.Sprintf(i18n.Text("Carried Equipment (%s; %s)"),
+			entity.SheetSettings.DefaultWeightUnits.Format(entity.WeightCarried(false)),
+			entity.SheetSettings.FormatWealth(entity.WealthCarried()))), 2, entity.CarriedEquipment)
+	case gurps.BlockLayoutOtherEquipmentKey:
+		return newPDFTable(equipmentPDFColumns(fmt.Sprintf(i18n.Text("Other Equipment (%s)"),
+			entity.SheetSettings.FormatWealth(entity.WealthNotCarried()))), 1, entity.OtherEquipment)
+	case gurps.BlockLayoutNotesKey:
+		return notesPDFTable(entity.Notes)
+	default:
+		return nil
+	}
+}
+
+func templatePDFTable(template *gurps.Template, key string) *pdfTable {
+	switch key {
+	case gurps.BlockLayoutTraitsKey:
+		return traitsPDFTable(template.Traits)
+	case gurps.BlockLayoutSkillsKey:
+		return newPDFTable([]*pdfTableColumn{
+			{title: i18n.Text("Skill / Technique"), id: gurps.SkillDescriptionColumn},
+			{title: i18n.Text("Pts"), id: gurps.SkillPointsColumn},
+			{title: i18n.Text("Ref"), id: gurps.SkillReferenceColumn},
+		}, 0, template.Skills)
+	case gurps.BlockLayoutSpellsKey:
+		return newPDFTable([]*pdfTableColumn{
+			{title: i18n.Text("Spell"), id: gurps.SpellDescriptionForPageColumn},
+			{title: i18n.Text("College"), id: gurps.SpellCollegeColumn},
+			{title: i18n.Text("Diff"), id: gurps.SpellDifficultyColumn},
+			{title: i18n.Text("Pts"), id: gurps.SpellPointsColumn},
+			{title: i18n.Text("Ref"), id: gurps.SpellReferenceColumn},
+		}, 0, template.Spells)
+	case gurps.BlockLayoutEquipmentKey:
+		return newPDFTable(carriedEquipmentPDFColumns(i18n.Text("Equipment")), 2, template.Equipment)
+	case gurps.BlockLayoutNotesKey:
+		return notesPDFTable(template.Notes)
+	default:
+		return nil
+	}
+}
+
+func traitsPDFTable(traits []*gurps.Trait) *pdfTable {
+	return newPDFTable([]*pdfTableColumn{
+		{title: i18n.Text("Trait"), id: gurps.TraitDescriptionColumn},
+		{title: i18n.Text("Pts"), id: gurps.TraitPointsColumn},
+		{title: i18n.Text("Ref"), id: gurps.TraitReferenceColumn},
+	}, 0, traits)
+}
+
+func carriedEquipmentPDFColumns(title string) []*pdfTableColumn {
+	return append([]*pdfTableColumn{{title: i18n.Text("E"), id: gurps.EquipmentEquippedColumn}},
+		equipmentPDFColumns(title)...)
+}
+
+func equipmentPDFColumns(title string) []*pdfTableColumn {
+	return []*pdfTableColumn{
+		{title: i18n.Text("#"), id: gurps.EquipmentQuantityColumn},
+		{title: title, id: gurps.EquipmentDescriptionColumn},
+		{title: i18n.Text("Uses"), id: gurps.EquipmentUsesColumn},
+		{title: i18n.Text("TL"), id: gurps.EquipmentTLColumn},
+		{title: i18n.Text("LC"), id: gurps.EquipmentLCColumn},
+		{title: "$", id: gurps.EquipmentCostColumn},
+		{title: i18n.Text("Wt"), id: gurps.EquipmentWeightColumn},
+		{title: i18n.Text("Ext $"), id: gurps.EquipmentExtendedCostColumn},
+		{title: i18n.Text("Ext Wt"), id: gurps.EquipmentExtendedWeightColumn},
+		{title: i18n.Text("Ref"), id: gurps.EquipmentReferenceColumn},
+	}
+}
+
+func notesPDFTable(notes []*gurps.Note) *pdfTable {
+	return newPDFTable([]*pdfTableColumn{
+		{title: i18n.Text("Note"), id: gurps.NoteTextColumn},
+		{title: i18n.Text("Ref"), id: gurps.NoteReferenceColumn},
+	}, 0, notes)
+}
@@ -0,0 +1,608 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package export
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/richardwilkes/gcs/v5/constants"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/settings"
+	"github.com/richardwilkes/gcs/v5/model/pdfdoc"
+	"github.com/richardwilkes/gcs/v5/model/theme"
+	"github.com/richardwilkes/toolbox/cmdline"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/xmath"
+	"github.com/richardwilkes/unison"
+)
+
+const (
+	pdfFieldSize         = 7
+	pdfSecondarySize     = 6
+	pdfFooterPrimarySize = 7
+	pdfFooterSize        = 6
+	pdfPadding           = 2
+	pdfGap               = 2
+	pdfIndent            = 8
+	pdfMinDescription    = 0.35
+)
+
+var (
+	pdfTextColor      = theme.OnPageColor.Light
+	pdfDimColor       = unison.Grey
+	pdfHeaderColor    = theme.HeaderColor.Light
+	pdfOnHeaderColor  = theme.OnHeaderColor.Light
+	pdfBandingColor   = unison.BandingColor.Light
+	pdfDividerColor   = unison.DividerColor.Light
+	pdfMarkerColor    = theme.MarkerColor.Light
+	pdfErrorColor     = unison.ErrorColor.Light
+	pdfLineHeight     = pdfdoc.Helvetica.LineHeight(pdfFieldSize)
+	pdfTitleBarHeight = pdfLineHeight + 2
+)
+
+// pdfCursor identifies a vertical position within a paginated document.
+type pdfCursor struct {
+	page int
+	y    float64
+}
+
+func (c pdfCursor) max(other pdfCursor) pdfCursor {
+	if other.page > c.page || (other.page == c.page && other.y > c.y) {
+		return other
+	}
+	return c
+}
+
+// pdfRenderer lays out content onto pages sized and margined according to a settings.Page.
+type pdfRenderer struct {
+	doc    *pdfdoc.Document
+	pages  []*pdfdoc.Page
+	width  float64
+	height float64
+	left   float64
+	top    float64
+	right  float64
+	bottom float64
+	footer float64
+}
+
+func newPDFRenderer(page *settings.Page) *pdfRenderer {
+	w, h := page.Orientation.Dimensions(page.Size.Dimensions())
+	r := &pdfRenderer{
+		doc:    pdfdoc.NewDocument(),
+		width:  float64(w.Pixels()),
+		height: float64(h.Pixels()),
+		left:   float64(page.LeftMargin.Pixels()),
+		top:    float64(page.TopMargin.Pixels()),
+	}
+	r.doc.Creator = cmdline.AppName + " " + cmdline.AppVersion
+	r.right = r.width - float64(page.RightMargin.Pixels())
+	r.footer = r.height - float64(page.BottomMargin.Pixels()) - (pdfdoc.Helvetica.LineHeight(pdfFooterPrimarySize) +
+		pdfdoc.Helvetica.LineHeight(pdfFooterSize))
+	r.bottom = r.footer - pdfGap
+	return r
+}
+
+func (r *pdfRenderer) contentWidth() float64 {
+	return r.right - r.left
+}
+
+func (r *pdfRenderer) page(index int) *pdfdoc.Page {
+	for len(r.pages) <= index {
+		r.pages = append(r.pages, r.doc.NewPage(r.width, r.height))
+	}
+	return r.pages[index]
+}
+
+func (r *pdfRenderer) nextPage(at pdfCursor) pdfCursor {
+	r.page(at.page + 1)
+	return pdfCursor{page: at.page + 1, y: r.top}
+}
+
+// drawFooters adds the footer to each page, mirroring the layout used by the on-screen sheet.
+func (r *pdfRenderer) drawFooters(title, detail string) {
+	primaryHeight := pdfdoc.Helvetica.LineHeight(pdfFooterPrimarySize)
+	for i, p := range r.pages {
+		pageNumber := i + 1
+		left := fmt.Sprintf(i18n.Text("%s is copyrighted ©%s by %s"), cmdline.AppName, cmdline.ResolveCopyrightYears(),
+			cmdline.CopyrightHolder)
+		right := detail
+		if pageNumber&1 == 0 {
+			left, right = right, left
+		}
+		y := r.footer + pdfdoc.Helvetica.Ascent(pdfFooterPrimarySize)
+		r.drawFooterLine(p, y, left, title, right, pdfdoc.HelveticaBold, pdfFooterPrimarySize)
+		left = i18n.Text("All rights reserved")
+		right = fmt.Sprintf(i18n.Text("Page %d of %d"), pageNumber, len(r.pages))
+		if pageNumber&1 == 0 {
+			left, right = right, left
+		}
+		r.drawFooterLine(p, y+primaryHeight, left, constants.WebSiteDomain, right, pdfdoc.Helvetica, pdfFooterSize)
+	}
+}
+
+func (r *pdfRenderer) drawFooterLine(p *pdfdoc.Page, baseline float64, left, center, right string, centerFont pdfdoc.Font, centerSize float64) {
+	p.Text(r.left, baseline, pdfdoc.Helvetica, pdfFooterSize, pdfTextColor, left)
+	p.Text(r.left+(r.contentWidth()-centerFont.Width(center, centerSize))/2, baseline, centerFont, centerSize,
+		pdfTextColor, center)
+	p.Text(r.right-pdfdoc.Helvetica.Width(right, pdfFooterSize), baseline, pdfdoc.Helvetica, pdfFooterSize,
+		pdfTextColor, right)
+}
+
+// pdfCell holds a single piece of text within a pdfPanel.
+type pdfCell struct {
+	text  string
+	font  pdfdoc.Font
+	size  float64
+	align unison.Alignment
+}
+
+func pdfLabel(text string) pdfCell {
+	return pdfCell{text: text, font: pdfdoc.Helvetica, size: pdfFieldSize}
+}
+
+func pdfLabelEnd(text string) pdfCell {
+	return pdfCell{text: text, font: pdfdoc.Helvetica, size: pdfFieldSize, align: unison.EndAlignment}
+}
+
+func pdfField(text string) pdfCell {
+	return pdfCell{text: text, font: pdfdoc.HelveticaBold, size: pdfFieldSize}
+}
+
+func pdfFieldEnd(text string) pdfCell {
+	return pdfCell{text: text, font: pdfdoc.HelveticaBold, size: pdfFieldSize, align: unison.EndAlignment}
+}
+
+func pdfFieldCenter(text string) pdfCell {
+	return pdfCell{text: text, font: pdfdoc.HelveticaBold, size: pdfFieldSize, align: unison.MiddleAlignment}
+}
+
+func pdfPoints(text string) pdfCell {
+	return pdfCell{text: text, font: pdfdoc.Helvetica, size: pdfSecondarySize, align: unison.EndAlignment}
+}
+
+func pdfHeader(text string) pdfCell {
+	return pdfCell{text: text, font: pdfdoc.HelveticaBold, size: pdfSecondarySize, align: unison.MiddleAlignment}
+}
+
+func (c *pdfCell) width() float64 {
+	return c.font.Width(c.text, c.size)
+}
+
+func (c *pdfCell) draw(p *pdfdoc.Page, x, baseline, width float64, color unison.Color) {
+	switch c.align {
+	case unison.MiddleAlignment:
+		x += (width - c.width()) / 2
+	case unison.EndAlignment:
+		x += width - c.width()
+	default:
+	}
+	p.Text(x, baseline, c.font, c.size, color, c.text)
+}
+
+// pdfPanel is a titled block of rows and columns, analogous to the fixed panels at the top of a sheet.
+type pdfPanel struct {
+	title  string
+	rows   [][]pdfCell
+	header bool
+	banded bool
+	marked int
+	grab   int
+	image  image.Image
+}
+
+func newPDFPanel(title string) *pdfPanel {
+	return &pdfPanel{
+		title:  title,
+		marked: -1,
+		grab:   -1,
+	}
+}
+
+func (p *pdfPanel) addRow(cells ...pdfCell) {
+	p.rows = append(p.rows, cells)
+}
+
+func (p *pdfPanel) columnWidths() []float64 {
+	var widths []float64
+	for _, row := range p.rows {
+		for i := range row {
+			if len(widths) <= i {
+				widths = append(widths, 0)
+			}
+			widths[i] = xmath.Max(widths[i], row[i].width())
+		}
+	}
+	return widths
+}
+
+func (p *pdfPanel) preferredWidth() float64 {
+	width := 0.0
+	widths := p.columnWidths()
+	for _, w := range widths {
+		width += w
+	}
+	if len(widths) > 1 {
+		width += float64(len(widths)-1) * pdfPadding * 2
+	}
+	if p.image != nil {
+		width = xmath.Max(width, gurps.PortraitWidth)
+	}
+	return xmath.Max(width, pdfdoc.HelveticaBold.Width(p.title, pdfFieldSize)) + pdfPadding*2
+}
+
+func (p *pdfPanel) preferredHeight() float64 {
+	height := pdfTitleBarHeight + pdfPadding*2 + float64(len(p.rows))*pdfLineHeight
+	if p.image != nil {
+		height += gurps.PortraitHeight
+	}
+	return height
+}
+
+func (p *pdfPanel) draw(page *pdfdoc.Page, x, y, width, height float64) {
+	page.FillRect(x, y, width, pdfTitleBarHeight, pdfHeaderColor)
+	page.Text(x+(width-pdfdoc.HelveticaBold.Width(p.title, pdfFieldSize))/2,
+		y+1+pdfdoc.HelveticaBold.Ascent(pdfFieldSize), pdfdoc.HelveticaBold, pdfFieldSize, pdfOnHeaderColor, p.title)
+	page.StrokeRect(x, y, width, height, 1, pdfHeaderColor)
+	top := y + pdfTitleBarHeight + pdfPadding
+	inner := width - pdfPadding*2
+	if p.image != nil {
+		bounds := p.image.Bounds()
+		scale := xmath.Min(inner/float64(bounds.Dx()), (height-pdfTitleBarHeight-pdfPadding*2)/float64(bounds.Dy()))
+		w := float64(bounds.Dx()) * scale
+		h := float64(bounds.Dy()) * scale
+		page.Image(x+(width-w)/2, top, w, h, p.image)
+		return
+	}
+	widths := p.columnWidths()
+	used := 0.0
+	for _, w := range widths {
+		used += w
+	}
+	if len(widths) > 1 {
+		used += float64(len(widths)-1) * pdfPadding * 2
+	}
+	if p.grab >= 0 && p.grab < len(widths) && used < inner {
+		widths[p.grab] += inner - used
+	}
+	page.PushClip(x, y, width, height)
+	for i, row := range p.rows {
+		rowTop := top + float64(i)*pdfLineHeight
+		switch {
+		case i == p.marked:
+			page.FillRect(x+1, rowTop, width-2, pdfLineHeight, pdfMarkerColor)
+		case p.banded && i&1 == 1 && !(p.header && i == 0):
+			page.FillRect(x+1, rowTop, width-2, pdfLineHeight, pdfBandingColor)
+		}
+		cx := x + pdfPadding
+		baseline := rowTop + pdfdoc.Helvetica.Ascent(pdfFieldSize)
+		for j := range row {
+			row[j].draw(page, cx, baseline, widths[j], pdfTextColor)
+			cx += widths[j] + pdfPadding*2
+		}
+		if p.header && i == 0 {
+			page.Line(x, rowTop+pdfLineHeight, x+width, rowTop+pdfLineHeight, 0.5, pdfHeaderColor)
+		}
+	}
+	page.PopClip()
+}
+
+// pdfColumn is a vertical stack of panels sharing the same width.
+type pdfColumn []*pdfPanel
+
+func (c pdfColumn) preferredWidth() float64 {
+	width := 0.0
+	for _, p := range c {
+		width = xmath.Max(width, p.preferredWidth())
+	}
+	return width
+}
+
+func (c pdfColumn) preferredHeight() float64 {
+	height := 0.0
+	for i, p := range c {
+		if i != 0 {
+			height += pdfGap
+		}
+		height += p.preferredHeight()
+	}
+	return height
+}
+
+// drawPanelColumns lays out the columns side-by-side, giving any extra width to the last column and stretching the
+// last panel in each column to fill the full height of the row.
+func (r *pdfRenderer) drawPanelColumns(at pdfCursor, x, width float64, columns ...pdfColumn) pdfCursor {
+	widths := make([]float64, len(columns))
+	total := float64(len(columns)-1) * pdfGap
+	height := 0.0
+	for i, c := range columns {
+		widths[i] = c.preferredWidth()
+		total += widths[i]
+		height = xmath.Max(height, c.preferredHeight())
+	}
+	if total <= width {
+		widths[len(widths)-1] += width - total
+	} else {
+		scale := (width - float64(len(columns)-1)*pdfGap) / (total - float64(len(columns)-1)*pdfGap)
+		for i := range widths {
+			widths[i] *= scale
+		}
+	}
+	if at.y+height > r.bottom && at.y > r.top {
+		at = r.nextPage(at)
+	}
+	page := r.page(at.page)
+	for i, c := range columns {
+		y := at.y
+		for j, p := range c {
+			h := p.preferredHeight()
+			if j == len(c)-1 {
+				h = at.y + height - y
+			}
+			p.draw(page, x, y, widths[i], h)
+			y += h + pdfGap
+		}
+		x += widths[i] + pdfGap
+	}
+	at.y += height + pdfGap
+	return at
+}
+
+// pdfTableColumn describes a column within a pdfTable.
+type pdfTableColumn struct {
+	title string
+	id    int
+	width float64
+}
+
+// pdfTableRow holds the cell data for a single row within a pdfTable.
+type pdfTableRow struct {
+	depth  int
+	cells  []gurps.CellData
+	height float64
+}
+
+// pdfTable is a paginated list, analogous to the page lists shown on a sheet.
+type pdfTable struct {
+	columns   []*pdfTableColumn
+	rows      []*pdfTableRow
+	hierarchy int
+}
+
+func newPDFTable[T gurps.NodeConstraint[T]](columns []*pdfTableColumn, hierarchy int, roots []T) *pdfTable {
+	t := &pdfTable{
+		columns:   columns,
+		hierarchy: hierarchy,
+	}
+	pdfAddRows(t, roots, 0)
+	return t
+}
+
+func pdfAddRows[T gurps.NodeConstraint[T]](t *pdfTable, list []T, depth int) {
+	for _, one := range list {
+		row := &pdfTableRow{
+			depth: depth,
+			cells: make([]gurps.CellData, len(t.columns)),
+		}
+		for i, col := range t.columns {
+			cell := &row.cells[i]
+			one.CellData(col.id, cell)
+			if cell.Type == gurps.PageRef {
+				// The secondary text of a page reference is only used for highlighting within the PDF viewer
+				cell.Secondary = ""
+			}
+		}
+		t.rows = append(t.rows, row)
+		if one.Container() && one.Open() {
+			pdfAddRows(t, one.NodeChildren(), depth+1)
+		}
+	}
+}
+
+func (t *pdfTable) layout(width float64) {
+	total := 0.0
+	for i, col := range t.columns {
+		if i == t.hierarchy {
+			continue
+		}
+		col.width = pdfdoc.HelveticaBold.Width(col.title, pdfSecondarySize)
+		for _, row := range t.rows {
+			cell := &row.cells[i]
+			col.width = xmath.Max(col.width, pdfdoc.Helvetica.Width(cell.Primary, pdfFieldSize))
+			col.width = xmath.Max(col.width, pdfdoc.Helvetica.Width(cell.Secondary, pdfSecondarySize))
+		}
+		col.width += pdfPadding * 2
+		total += col.width
+	}
+	if available := width * (1 - pdfMinDescription); total > available {
+		scale := available / total
+		total = 0
+		for i, col := range t.columns {
+			if i != t.hierarchy {
+				col.width *= scale
+				total += col.width
+			}
+		}
+	}
+	if t.hierarchy >= 0 && t.hierarchy < len(t.columns) {
+		t.columns[t.hierarchy].width = width - total
+	} else if len(t.columns) != 0 {
+		t.columns[len(t.columns)-1].width += width - total
+	}
+	for _, row := range t.rows {
+		row.height = 0
+		for i, col := range t.columns {
+			row.height = xmath.Max(row.height, t.cellHeight(row, i, col.width))
+		}
+	}
+}
+
+func (t *pdfTable) textWidth(row *pdfTableRow, column int, width float64) float64 {
+	width -= pdfPadding * 2
+	if column == t.hierarchy {
+		width -= float64(row.depth) * pdfIndent
+	}
+	return width
+}
+
+func (t *pdfTable) cellHeight(row *pdfTableRow, column int, width float64) float64 {
+	cell := &row.cells[column]
+	width = t.textWidth(row, column, width)
+	height := pdfLineHeight
+	if cell.Type == gurps.Text || cell.Type == gurps.PageRef {
+		height = float64(len(pdfdoc.Helvetica.Wrap(cell.Primary, pdfFieldSize, width))) * pdfLineHeight
+		if cell.Secondary != "" {
+			height += float64(len(pdfdoc.Helvetica.Wrap(cell.Secondary, pdfSecondarySize, width))) *
+				pdfdoc.Helvetica.LineHeight(pdfSecondarySize)
+		}
+		if cell.UnsatisfiedReason != "" {
+			height += float64(len(pdfdoc.HelveticaOblique.Wrap(cell.UnsatisfiedReason, pdfSecondarySize, width))) *
+				pdfdoc.Helvetica.LineHeight(pdfSecondarySize)
+		}
+	}
+	return height + pdfPadding
+}
+
+func (t *pdfTable) drawHeader(page *pdfdoc.Page, x, y float64) float64 {
+	width := 0.0
+	for _, col := range t.columns {
+		width += col.width
+	}
+	page.FillRect(x, y, width, pdfTitleBarHeight, pdfHeaderColor)
+	baseline := y + 1 + pdfdoc.HelveticaBold.Ascent(pdfFieldSize)
+	for i, col := range t.columns {
+		page.PushClip(x, y, col.width, pdfTitleBarHeight)
+		cell := pdfHeader(col.title)
+		if i == t.hierarchy {
+			cell.font = pdfdoc.HelveticaBold
+			cell.size = pdfFieldSize
+			cell.align = unison.StartAlignment
+		}
+		cell.draw(page, x+pdfPadding, baseline, col.width-pdfPadding*2, pdfOnHeaderColor)
+		page.PopClip()
+		x += col.width
+	}
+	return y + pdfTitleBarHeight
+}
+
+func (t *pdfTable) drawRow(page *pdfdoc.Page, index int, x, y float64) {
+	row := t.rows[index]
+	width := 0.0
+	for _, col := range t.columns {
+		width += col.width
+	}
+	if index&1 == 1 {
+		page.FillRect(x, y, width, row.height, pdfBandingColor)
+	}
+	for i, col := range t.columns {
+		cell := &row.cells[i]
+		color := pdfTextColor
+		if cell.Dim || cell.Disabled {
+			color = pdfDimColor
+		}
+		left := x + pdfPadding
+		if i == t.hierarchy {
+			left += float64(row.depth) * pdfIndent
+		}
+		textWidth := t.textWidth(row, i, col.width)
+		page.PushClip(x, y, col.width, row.height)
+		switch cell.Type {
+		case gurps.Toggle:
+			if cell.Checked {
+				cx := x + col.width/2
+				cy := y + row.height/2
+				page.Polyline(1, color, cx-2.5, cy, cx-0.5, cy+2, cx+2.5, cy-2.5)
+			}
+		default:
+			baseline := y + pdfPadding/2 + pdfdoc.Helvetica.Ascent(pdfFieldSize)
+			for _, line := range pdfdoc.Helvetica.Wrap(cell.Primary, pdfFieldSize, textWidth) {
+				c := pdfCell{text: line, font: pdfdoc.Helvetica, size: pdfFieldSize, align: cell.Alignment}
+				c.draw(page, left, baseline, textWidth, color)
+				baseline += pdfLineHeight
+			}
+			baseline += pdfdoc.Helvetica.Ascent(pdfSecondarySize) - pdfdoc.Helvetica.Ascent(pdfFieldSize)
+			if cell.Secondary != "" {
+				for _, line := range pdfdoc.Helvetica.Wrap(cell.Secondary, pdfSecondarySize, textWidth) {
+					c := pdfCell{text: line, font: pdfdoc.Helvetica, size: pdfSecondarySize, align: cell.Alignment}
+					c.draw(page, left, baseline, textWidth, color)
+					baseline += pdfdoc.Helvetica.LineHeight(pdfSecondarySize)
+				}
+			}
+			if cell.UnsatisfiedReason != "" {
+				for _, line := range pdfdoc.HelveticaOblique.Wrap(cell.UnsatisfiedReason, pdfSecondarySize, textWidth) {
+					page.Text(left, baseline, pdfdoc.HelveticaOblique, pdfSecondarySize, pdfErrorColor, line)
+					baseline += pdfdoc.Helvetica.LineHeight(pdfSecondarySize)
+				}
+			}
+		}
+		page.PopClip()
+		x += col.width
+	}
+}
+
+func (t *pdfTable) drawDividers(page *pdfdoc.Page, x, top, bottom float64) {
+	width := 0.0
+	for i, col := range t.columns {
+		if i != 0 {
+			page.Line(x+width, top+pdfTitleBarHeight, x+width, bottom, 0.5, pdfDividerColor)
+		}
+		width += col.width
+	}
+	page.StrokeRect(x, top, width, bottom-top, 1, pdfHeaderColor)
+}
+
+// draw the table starting at the cursor, continuing onto subsequent pages as needed. The header is repeated at the top
+// of each continuation.
+func (r *pdfRenderer) drawTable(t *pdfTable, at pdfCursor, x, width float64) pdfCursor {
+	t.layout(width)
+	needed := pdfTitleBarHeight
+	if len(t.rows) != 0 {
+		needed += t.rows[0].height
+	}
+	if at.y+needed > r.bottom && at.y > r.top {
+		at = r.nextPage(at)
+	}
+	page := r.page(at.page)
+	top := at.y
+	at.y = t.drawHeader(page, x, at.y)
+	for i, row := range t.rows {
+		if at.y+row.height > r.bottom && at.y > r.top+pdfTitleBarHeight {
+			t.drawDividers(page, x, top, at.y)
+			at = r.nextPage(at)
+			page = r.page(at.page)
+			top = at.y
+			at.y = t.drawHeader(page, x, at.y)
+		}
+		t.drawRow(page, i, x, at.y)
+		at.y += row.height
+	}
+	t.drawDividers(page, x, top, at.y)
+	at.y += pdfGap
+	return at
+}
+
+// drawTables lays out the tables side-by-side in equal width columns, each paginating independently. The returned
+// cursor is positioned below the longest of them.
+func (r *pdfRenderer) drawTables(at pdfCursor, tables ...*pdfTable) pdfCursor {
+	if len(tables) == 0 {
+		return at
+	}
+	width := (r.contentWidth() - float64(len(tables)-1)*pdfGap) / float64(len(tables))
+	end := at
+	x := r.left
+	for _, t := range tables {
+		end = end.max(r.drawTable(t, at, x, width))
+		x += width + pdfGap
+	}
+	return end
+}
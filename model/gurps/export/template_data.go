@@ -0,0 +1,628 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package export
+
+import (
+	"encoding/base64"
+	"net/http"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/attribute"
+	"github.com/richardwilkes/gcs/v5/model/gurps/datafile"
+	"github.com/richardwilkes/gcs/v5/model/gurps/weapon"
+)
+
+// SheetData holds the computed contents of a character sheet in a form suitable for use by templates.
+type SheetData struct {
+	Entity               *gurps.Entity             `json:"-"`
+	GridTemplate         string                    `json:"-"`
+	Profile              ProfileData               `json:"profile"`
+	Points               PointsData                `json:"points"`
+	PrimaryAttributes    []*AttributeData          `json:"primary_attributes,omitempty"`
+	SecondaryAttributes  []*AttributeData          `json:"secondary_attributes,omitempty"`
+	PointPools           []*AttributeData          `json:"point_pools,omitempty"`
+	Attributes           map[string]*AttributeData `json:"-"`
+	Thrust               string                    `json:"thrust"`
+	Swing                string                    `json:"swing"`
+	BasicLift            string                    `json:"basic_lift"`
+	OneHandedLift        string                    `json:"one_handed_lift"`
+	TwoHandedLift        string                    `json:"two_handed_lift"`
+	Shove                string                    `json:"shove"`
+	RunningShove         string                    `json:"running_shove"`
+	CarryOnBack          string                    `json:"carry_on_back"`
+	ShiftSlightly        string                    `json:"shift_slightly"`
+	CurrentMove          int                       `json:"current_move"`
+	CurrentDodge         int                       `json:"current_dodge"`
+	BestParry            string                    `json:"best_parry"`
+	BestBlock            string                    `json:"best_block"`
+	Encumbrance          []*EncumbranceData        `json:"encumbrance,omitempty"`
+	BodyType             string                    `json:"body_type"`
+	HitLocations         []*HitLocationData        `json:"hit_locations,omitempty"`
+	Traits               []*TraitData              `json:"traits,omitempty"`
+	Skills               []*SkillData              `json:"skills,omitempty"`
+	Spells               []*SpellData              `json:"spells,omitempty"`
+	MeleeWeapons         []*WeaponData             `json:"melee_weapons,omitempty"`
+	RangedWeapons        []*WeaponData             `json:"ranged_weapons,omitempty"`
+	CarriedEquipment     []*EquipmentData          `json:"carried_equipment,omitempty"`
+	OtherEquipment       []*EquipmentData          `json:"other_equipment,omitempty"`
+	CarriedWeight        string                    `json:"carried_weight"`
+	CarriedValue         fxp.Int                   `json:"carried_value"`
+	CarriedValueText     string                    `json:"carried_value_text"`
+	OtherValue           fxp.Int                   `json:"other_value"`
+	OtherValueText       string                    `json:"other_value_text"`
+	Notes                []*NoteData               `json:"notes,omitempty"`
+	Reactions            []*ModifierData           `json:"reactions,omitempty"`
+	ConditionalModifiers []*ModifierData           `json:"conditional_modifiers,omitempty"`
+	Advancement          []*AdvancementData        `json:"advancement,omitempty"`
+	CreatedOn            string                    `json:"created_on"`
+	ModifiedOn           string                    `json:"modified_on"`
+}
+
+// ProfileData holds the descriptive portion of a character sheet.
+type ProfileData struct {
+	Name         string `json:"name,omitempty"`
+	Title        string `json:"title,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	Religion     string `json:"religion,omitempty"`
+	Player       string `json:"player,omitempty"`
+	Ancestry     string `json:"ancestry,omitempty"`
+	Gender       string `json:"gender,omitempty"`
+	Age          string `json:"age,omitempty"`
+	Birthday     string `json:"birthday,omitempty"`
+	Eyes         string `json:"eyes,omitempty"`
+	Hair         string `json:"hair,omitempty"`
+	Skin         string `json:"skin,omitempty"`
+	Handedness   string `json:"handedness,omitempty"`
+	Height       string `json:"height,omitempty"`
+	Weight       string `json:"weight,omitempty"`
+	TechLevel    string `json:"tech_level,omitempty"`
+	SizeModifier int    `json:"size_modifier"`
+	PortraitURI  string `json:"portrait,omitempty"`
+}
+
+// PointsData holds the point breakdown of a character sheet.
+type PointsData struct {
+	Total         fxp.Int `json:"total"`
+	Spent         fxp.Int `json:"spent"`
+	Unspent       fxp.Int `json:"unspent"`
+	Ancestry      fxp.Int `json:"ancestry"`
+	Attributes    fxp.Int `json:"attributes"`
+	Advantages    fxp.Int `json:"advantages"`
+	Disadvantages fxp.Int `json:"disadvantages"`
+	Quirks        fxp.Int `json:"quirks"`
+	Skills        fxp.Int `json:"skills"`
+	Spells        fxp.Int `json:"spells"`
+}
+
+// AttributeData holds a single attribute or point pool.
+type AttributeData struct {
+	ID           string               `json:"id"`
+	Name         string               `json:"name"`
+	FullName     string               `json:"full_name"`
+	CombinedName string               `json:"combined_name"`
+	Value        fxp.Int              `json:"value"`
+	Current      fxp.Int              `json:"current"`
+	Points       fxp.Int              `json:"points"`
+	Thresholds   []*PoolThresholdData `json:"thresholds,omitempty"`
+}
+
+// PoolThresholdData holds a threshold of a point pool.
+type PoolThresholdData struct {
+	State       string  `json:"state"`
+	Explanation string  `json:"explanation,omitempty"`
+	Value       fxp.Int `json:"value"`
+	Active      bool    `json:"active,omitempty"`
+}
+
+// EncumbranceData holds a single encumbrance level.
+type EncumbranceData struct {
+	Level        int     `json:"level"`
+	Name         string  `json:"name"`
+	Penalty      fxp.Int `json:"penalty"`
+	MaximumCarry string  `json:"maximum_carry"`
+	Move         int     `json:"move"`
+	Dodge        int     `json:"dodge"`
+	Current      bool    `json:"current,omitempty"`
+}
+
+// HitLocationData holds a single hit location of the body type. The locations of a sub-table follow the location that
+// owns them, with Depth and ParentID available for reconstructing the hierarchy.
+type HitLocationData struct {
+	ID         string         `json:"id"`
+	ParentID   string         `json:"parent_id,omitempty"`
+	Depth      int            `json:"depth,omitempty"`
+	Name       string         `json:"name"`
+	Roll       string         `json:"roll"`
+	HitPenalty int            `json:"hit_penalty"`
+	DR         string         `json:"dr"`
+	DRByType   map[string]int `json:"dr_by_type,omitempty"`
+	Armor      []string       `json:"armor,omitempty"`
+}
+
+// RowData holds the fields common to the hierarchical lists. Rows are presented in display order, with Depth and
+// ParentID available for reconstructing the hierarchy.
+type RowData struct {
+	ID            string   `json:"id"`
+	ParentID      string   `json:"parent_id,omitempty"`
+	Depth         int      `json:"depth,omitempty"`
+	Container     bool     `json:"container,omitempty"`
+	Name          string   `json:"name"`
+	Notes         string   `json:"notes,omitempty"`
+	ModifierNotes string   `json:"modifier_notes,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+	Reference     string   `json:"reference,omitempty"`
+	Unsatisfied   string   `json:"unsatisfied,omitempty"`
+}
+
+// TraitData holds a single trait.
+type TraitData struct {
+	RowData
+	Points          fxp.Int `json:"points"`
+	Levels          fxp.Int `json:"levels,omitempty"`
+	UserDescription string  `json:"user_description,omitempty"`
+	Enabled         bool    `json:"enabled"`
+}
+
+// SkillData holds a single skill or technique.
+type SkillData struct {
+	RowData
+	Points        fxp.Int `json:"points"`
+	Level         fxp.Int `json:"level"`
+	LevelText     string  `json:"level_text,omitempty"`
+	RelativeLevel string  `json:"relative_level,omitempty"`
+	Difficulty    string  `json:"difficulty,omitempty"`
+}
+
+// SpellData holds a single spell or ritual magic spell.
+type SpellData struct {
+	SkillData
+	Class           string   `json:"class,omitempty"`
+	Colleges        []string `json:"colleges,omitempty"`
+	CastingCost     string   `json:"casting_cost,omitempty"`
+	MaintenanceCost string   `json:"maintenance_cost,omitempty"`
+	CastingTime     string   `json:"casting_time,omitempty"`
+	Duration        string   `json:"duration,omitempty"`
+	Resist          string   `json:"resist,omitempty"`
+	Rituals         string   `json:"rituals,omitempty"`
+}
+
+// EquipmentData holds a single piece of equipment.
+type EquipmentData struct {
+	RowData
+	Quantity       fxp.Int `json:"quantity"`
+	Equipped       bool    `json:"equipped,omitempty"`
+	TechLevel      string  `json:"tech_level,omitempty"`
+	LegalityClass  string  `json:"legality_class,omitempty"`
+	Value          fxp.Int `json:"value"` // In the base currency
+	Currency       string  `json:"currency,omitempty"`
+	CurrencyValue  fxp.Int `json:"currency_value,omitempty"` // In Currency, when set
+	ExtendedValue  fxp.Int `json:"extended_value"`
+	Weight         string  `json:"weight"`
+	ExtendedWeight string  `json:"extended_weight"`
+	Uses           int     `json:"uses,omitempty"`
+	MaxUses        int     `json:"max_uses,omitempty"`
+}
+
+// WeaponData holds a single weapon attack with all values resolved against the character.
+type WeaponData struct {
+	Name             string  `json:"name"`
+	Notes            string  `json:"notes,omitempty"`
+	Usage            string  `json:"usage,omitempty"`
+	Level            fxp.Int `json:"level"`
+	Damage           string  `json:"damage"`
+	UnmodifiedDamage string  `json:"unmodified_damage,omitempty"`
+	Strength         string  `json:"strength,omitempty"`
+	Parry            string  `json:"parry,omitempty"`
+	Block            string  `json:"block,omitempty"`
+	Reach            string  `json:"reach,omitempty"`
+	Accuracy         string  `json:"accuracy,omitempty"`
+	Range            string  `json:"range,omitempty"`
+	RateOfFire       string  `json:"rate_of_fire,omitempty"`
+	Shots            string  `json:"shots,omitempty"`
+	Bulk             string  `json:"bulk,omitempty"`
+	Recoil           string  `json:"recoil,omitempty"`
+	Ammo             fxp.Int `json:"ammo,omitempty"`
+}
+
+// NoteData holds a single note.
+type NoteData struct {
+	ID        string `json:"id"`
+	ParentID  string `json:"parent_id,omitempty"`
+	Depth     int    `json:"depth,omitempty"`
+	Container bool   `json:"container,omitempty"`
+	Text      string `json:"text"`
+	Reference string `json:"reference,omitempty"`
+}
+
+// ModifierData holds a single reaction or conditional modifier.
+type ModifierData struct {
+	Situation string  `json:"situation"`
+	Modifier  fxp.Int `json:"modifier"`
+}
+
+// AdvancementData holds a single entry from the advancement log.
+type AdvancementData struct {
+	RecordedOn string   `json:"recorded_on"`
+	Date       string   `json:"date,omitempty"`
+	Points     fxp.Int  `json:"points,omitempty"`
+	Spent      fxp.Int  `json:"spent,omitempty"`
+	Reason     string   `json:"reason,omitempty"`
+	Changes    []string `json:"changes,omitempty"`
+}
+
+// NewSheetData collects the computed contents of the entity.
+func NewSheetData(entity *gurps.Entity) *SheetData {
+	entity.Recalculate()
+	weightUnits := entity.SheetSettings.DefaultWeightUnits
+	data := &SheetData{
+		Entity:           entity,
+		GridTemplate:     entity.SheetSettings.BlockLayout.HTMLGridTemplate(),
+		Attributes:       make(map[string]*AttributeData),
+		Thrust:           entity.Thrust().String(),
+		Swing:            entity.Swing().String(),
+		BasicLift:        weightUnits.Format(entity.BasicLift()),
+		OneHandedLift:    weightUnits.Format(entity.OneHandedLift()),
+		TwoHandedLift:    weightUnits.Format(entity.TwoHandedLift()),
+		Shove:            weightUnits.Format(entity.ShoveAndKnockOver()),
+		RunningShove:     weightUnits.Format(entity.RunningShoveAndKnockOver()),
+		CarryOnBack:      weightUnits.Format(entity.CarryOnBack()),
+		ShiftSlightly:    weightUnits.Format(entity.ShiftSlightly()),
+		CurrentMove:      entity.Move(entity.EncumbranceLevel(false)),
+		CurrentDodge:     entity.Dodge(entity.EncumbranceLevel(false)),
+		BestParry:        bestWeaponDefense(entity, func(w *gurps.Weapon) string { return w.ResolvedParry(nil) }),
+		BestBlock:        bestWeaponDefense(entity, func(w *gurps.Weapon) string { return w.ResolvedBlock(nil) }),
+		BodyType:         entity.SheetSettings.BodyType.Name,
+		CarriedWeight:    weightUnits.Format(entity.WeightCarried(false)),
+		CarriedValue:     entity.WealthCarried(),
+		CarriedValueText: entity.SheetSettings.FormatWealth(entity.WealthCarried()),
+		OtherValue:       entity.WealthNotCarried(),
+		OtherValueText:   entity.SheetSettings.FormatWealth(entity.WealthNotCarried()),
+		CreatedOn:        entity.CreatedOn.String(),
+		ModifiedOn:       entity.ModifiedOn.String(),
+	}
+	data.collectProfile()
+	data.collectPoints()
+	data.collectAttributes()
+	data.collectEncumbrance()
+	data.collectHitLocations()
+	data.collectTraits()
+	data.collectSkills()
+	data.collectSpells()
+	data.MeleeWeapons = collectWeapons(entity, weapon.Melee)
+	data.RangedWeapons = collectWeapons(entity, weapon.Ranged)
+	data.CarriedEquipment = collectEquipment(entity, entity.CarriedEquipment, true)
+	data.OtherEquipment = collectEquipment(entity, entity.OtherEquipment, false)
+	data.collectNotes()
+	data.Reactions = collectModifiers(entity.Reactions())
+	data.ConditionalModifiers = collectModifiers(entity.ConditionalModifiers())
+	data.collectAdvancement()
+	return data
+}
+
+func (d *SheetData) collectProfile() {
+	e := d.Entity
+	d.Profile = ProfileData{
+		Name:         e.Profile.Name,
+		Title:        e.Profile.Title,
+		Organization: e.Profile.Organization,
+		Religion:     e.Profile.Religion,
+		Player:       e.Profile.PlayerName,
+		Ancestry:     e.Ancestry().Name,
+		Gender:       e.Profile.Gender,
+		Age:          e.Profile.Age,
+		Birthday:     e.Profile.Birthday,
+		Eyes:         e.Profile.Eyes,
+		Hair:         e.Profile.Hair,
+		Skin:         e.Profile.Skin,
+		Handedness:   e.Profile.Handedness,
+		Height:       e.SheetSettings.DefaultLengthUnits.Format(e.Profile.Height),
+		Weight:       e.SheetSettings.DefaultWeightUnits.Format(e.Profile.Weight),
+		TechLevel:    e.Profile.TechLevel,
+		SizeModifier: e.Profile.AdjustedSizeModifier(),
+	}
+	if len(e.Profile.PortraitData) != 0 {
+		d.Profile.PortraitURI = "data:" + http.DetectContentType(e.Profile.PortraitData) + ";base64," + base64.StdEncoding.EncodeToString(e.Profile.PortraitData)
+	}
+}
+
+func (d *SheetData) collectPoints() {
+	e := d.Entity
+	ad, disad, race, quirk := e.TraitPoints()
+	d.Points = PointsData{
+		Total:         e.TotalPoints,
+		Spent:         e.SpentPoints(),
+		Unspent:       e.UnspentPoints(),
+		Ancestry:      race,
+		Attributes:    e.AttributePoints(),
+		Advantages:    ad,
+		Disadvantages: disad,
+		Quirks:        quirk,
+		Skills:        e.SkillPoints(),
+		Spells:        e.SpellPoints(),
+	}
+}
+
+func (d *SheetData) collectAttributes() {
+	for _, def := range d.Entity.SheetSettings.Attributes.List() {
+		attr, ok := d.Entity.Attributes.Set[def.DefID]
+		if !ok {
+			continue
+		}
+		one := &AttributeData{
+			ID:           def.DefID,
+			Name:         def.Name,
+			FullName:     def.ResolveFullName(),
+			CombinedName: def.CombinedName(),
+			Value:        attr.Maximum(),
+			Current:      attr.Current(),
+			Points:       attr.PointCost(),
+		}
+		d.Attributes[def.DefID] = one
+		switch {
+		case def.Type == attribute.Pool:
+			active := attr.CurrentThreshold()
+			for _, threshold := range def.Thresholds {
+				one.Thresholds = append(one.Thresholds, &PoolThresholdData{
+					State:       threshold.State,
+					Explanation: threshold.Explanation,
+					Value:       threshold.Threshold(d.Entity),
+					Active:      threshold == active,
+				})
+			}
+			d.PointPools = append(d.PointPools, one)
+		case def.Primary():
+			d.PrimaryAttributes = append(d.PrimaryAttributes, one)
+		default:
+			d.SecondaryAttributes = append(d.SecondaryAttributes, one)
+		}
+	}
+}
+
+func (d *SheetData) collectEncumbrance() {
+	e := d.Entity
+	current := e.EncumbranceLevel(false)
+	for _, enc := range datafile.AllEncumbrance {
+		d.Encumbrance = append(d.Encumbrance, &EncumbranceData{
+			Level:        int(enc),
+			Name:         enc.String(),
+			Penalty:      enc.Penalty(),
+			MaximumCarry: e.SheetSettings.DefaultWeightUnits.Format(e.MaximumCarry(enc)),
+			Move:         e.Move(enc),
+			Dodge:        e.Dodge(enc),
+			Current:      enc == current,
+		})
+	}
+}
+
+func (d *SheetData) collectHitLocations() {
+	d.addHitLocations(d.Entity.SheetSettings.BodyType, "", 0)
+}
+
+// addHitLocations adds the body's locations, each followed by the locations of its sub-table, if any.
+func (d *SheetData) addHitLocations(body *gurps.Body, parentID string, depth int) {
+	for _, location := range body.Locations {
+		d.HitLocations = append(d.HitLocations, &HitLocationData{
+			ID:         location.LocID,
+			ParentID:   parentID,
+			Depth:      depth,
+			Name:       location.TableName,
+			Roll:       location.RollRange,
+			HitPenalty: location.HitPenalty,
+			DR:         location.DisplayDR(d.Entity, nil),
+			DRByType:   location.DR(d.Entity, nil, nil),
+			Armor:      hitLocationEquipment(d.Entity, location),
+		})
+		if location.SubTable != nil {
+			d.addHitLocations(location.SubTable, location.LocID, depth+1)
+		}
+	}
+}
+
+func (d *SheetData) collectTraits() {
+	gurps.Traverse[*gurps.Trait](func(t *gurps.Trait) bool {
+		one := &TraitData{
+			RowData:         newRowData(t, t.String(), t.Notes(), t.ModifierNotes(), t.Tags, t.PageRef, t.UnsatisfiedReason),
+			Points:          t.AdjustedPoints(),
+			UserDescription: t.UserDesc,
+			Enabled:         t.Enabled(),
+		}
+		if t.IsLeveled() {
+			one.Levels = t.Levels
+		}
+		d.Traits = append(d.Traits, one)
+		return false
+	}, false, false, d.Entity.Traits...)
+}
+
+func (d *SheetData) collectSkills() {
+	gurps.Traverse[*gurps.Skill](func(s *gurps.Skill) bool {
+		level := s.CalculateLevel()
+		one := &SkillData{
+			RowData:       newRowData(s, s.String(), s.Notes(), s.ModifierNotes(), s.Tags, s.PageRef, s.UnsatisfiedReason),
+			Points:        s.AdjustedPoints(nil),
+			Level:         level.Level,
+			LevelText:     level.LevelAsString(s.Container()),
+			RelativeLevel: s.RelativeLevel(),
+		}
+		if !s.Container() {
+			one.Difficulty = s.Difficulty.Description(s.Entity)
+		}
+		d.Skills = append(d.Skills, one)
+		return false
+	}, false, false, d.Entity.Skills...)
+}
+
+func (d *SheetData) collectSpells() {
+	gurps.Traverse[*gurps.Spell](func(s *gurps.Spell) bool {
+		level := s.CalculateLevel()
+		one := &SpellData{
+			SkillData: SkillData{
+				RowData:       newRowData(s, s.String(), s.Notes(), "", s.Tags, s.PageRef, s.UnsatisfiedReason),
+				Points:        s.AdjustedPoints(nil),
+				Level:         level.Level,
+				LevelText:     level.LevelAsString(s.Container()),
+				RelativeLevel: s.RelativeLevel(),
+			},
+			Rituals: s.Rituals(),
+		}
+		if !s.Container() {
+			one.Difficulty = s.Difficulty.Description(s.Entity)
+			one.Class = s.Class
+			one.Colleges = s.College
+			one.CastingCost = s.CastingCost
+			one.MaintenanceCost = s.MaintenanceCost
+			one.CastingTime = s.CastingTime
+			one.Duration = s.Duration
+			one.Resist = s.Resist
+		}
+		d.Spells = append(d.Spells, one)
+		return false
+	}, false, false, d.Entity.Spells...)
+}
+
+func (d *SheetData) collectNotes() {
+	gurps.Traverse[*gurps.Note](func(n *gurps.Note) bool {
+		row := newRowData(n, "", "", "", nil, n.PageRef, "")
+		d.Notes = append(d.Notes, &NoteData{
+			ID:        row.ID,
+			ParentID:  row.ParentID,
+			Depth:     row.Depth,
+			Container: row.Container,
+			Text:      n.Text,
+			Reference: n.PageRef,
+		})
+		return false
+	}, false, false, d.Entity.Notes...)
+}
+
+func (d *SheetData) collectAdvancement() {
+	if d.Entity.Advancement == nil {
+		return
+	}
+	for _, entry := range d.Entity.Advancement.Entries {
+		one := &AdvancementData{
+			RecordedOn: entry.RecordedOn.String(),
+			Date:       entry.Date,
+			Points:     entry.Points,
+			Spent:      entry.Spent(),
+			Reason:     entry.Reason,
+		}
+		for _, change := range entry.Changes {
+			one.Changes = append(one.Changes, change.String())
+		}
+		d.Advancement = append(d.Advancement, one)
+	}
+}
+
+func collectWeapons(entity *gurps.Entity, weaponType weapon.Type) []*WeaponData {
+	list := entity.EquippedWeapons(weaponType)
+	result := make([]*WeaponData, 0, len(list))
+	for _, w := range list {
+		one := &WeaponData{
+			Name:             w.String(),
+			Notes:            w.Notes(),
+			Usage:            w.Usage,
+			Level:            w.SkillLevel(nil),
+			Damage:           w.Damage.ResolvedDamage(nil),
+			UnmodifiedDamage: w.Damage.String(),
+			Strength:         w.MinimumStrength,
+		}
+		switch weaponType {
+		case weapon.Melee:
+			one.Parry = w.ResolvedParry(nil)
+			one.Block = w.ResolvedBlock(nil)
+			one.Reach = w.Reach
+		case weapon.Ranged:
+			one.Accuracy = w.Accuracy
+			one.Range = w.ResolvedRange()
+			one.RateOfFire = w.RateOfFire
+			one.Shots = w.Shots
+			one.Bulk = w.Bulk
+			one.Recoil = w.Recoil
+		}
+		if eqp, ok := w.Owner.(*gurps.Equipment); ok {
+			one.Ammo = ammoFor(entity, eqp)
+		}
+		result = append(result, one)
+	}
+	return result
+}
+
+func collectEquipment(entity *gurps.Entity, list []*gurps.Equipment, carried bool) []*EquipmentData {
+	weightUnits := entity.SheetSettings.DefaultWeightUnits
+	var result []*EquipmentData
+	gurps.Traverse[*gurps.Equipment](func(eqp *gurps.Equipment) bool {
+		value := eqp.AdjustedValue()
+		one := &EquipmentData{
+			RowData:        newRowData(eqp, eqp.String(), eqp.Notes(), eqp.ModifierNotes(), eqp.Tags, eqp.PageRef, eqp.UnsatisfiedReason),
+			Quantity:       eqp.Quantity,
+			Equipped:       carried && eqp.Equipped,
+			TechLevel:      eqp.TechLevel,
+			LegalityClass:  eqp.LegalityClass,
+			Value:          eqp.ValueToBase(entity, value),
+			ExtendedValue:  eqp.ExtendedValue(),
+			Weight:         weightUnits.Format(eqp.AdjustedWeight(false, weightUnits)),
+			ExtendedWeight: weightUnits.Format(eqp.ExtendedWeight(false, weightUnits)),
+			Uses:           eqp.Uses,
+			MaxUses:        eqp.MaxUses,
+		}
+		if c := eqp.CurrencyFor(entity); c != nil {
+			one.Currency = c.ID
+			one.CurrencyValue = value
+		}
+		result = append(result, one)
+		return false
+	}, false, false, list...)
+	return result
+}
+
+func collectModifiers(list []*gurps.ConditionalModifier) []*ModifierData {
+	result := make([]*ModifierData, 0, len(list))
+	for _, one := range list {
+		result = append(result, &ModifierData{
+			Situation: one.From,
+			Modifier:  one.Total(),
+		})
+	}
+	return result
+}
+
+func newRowData[T gurps.NodeConstraint[T]](row T, name, notes, modifierNotes string, tags []string, ref, unsatisfied string) RowData {
+	var zero T
+	data := RowData{
+		ID:            row.UUID().String(),
+		Container:     row.Container(),
+		Name:          name,
+		Notes:         notes,
+		ModifierNotes: modifierNotes,
+		Tags:          tags,
+		Reference:     ref,
+		Unsatisfied:   unsatisfied,
+	}
+	if parent := row.Parent(); parent != zero {
+		data.ParentID = parent.UUID().String()
+		for ; parent != zero; parent = parent.Parent() {
+			data.Depth++
+		}
+	}
+	return data
+}
+
+// Attribute returns the attribute with the given ID. If the attribute doesn't exist, an empty one with just the ID set
+// is returned.
+func (d *SheetData) Attribute(id string) *AttributeData {
+	if attr, ok := d.Attributes[id]; ok {
+		return attr
+	}
+	return &AttributeData{ID: id}
+}
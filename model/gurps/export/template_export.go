@@ -0,0 +1,222 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	htmltmpl "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+// TemplateEngine identifies the engine used to process an export template.
+type TemplateEngine uint8
+
+// Possible TemplateEngine values.
+const (
+	LegacyTemplateEngine TemplateEngine = iota
+	TextTemplateEngine
+	HTMLTemplateEngine
+)
+
+// Template file extensions that select the Go template engines. For ".tmpl" and ".gotmpl", the extension preceding
+// them determines both the extension of the output and, when it is ".html" or ".htm", use of the HTML engine.
+const (
+	goTemplateExt     = ".tmpl"
+	goTemplateAltExt  = ".gotmpl"
+	goHTMLTemplateExt = ".gohtml"
+)
+
+// engineDirective matches the header directive that may be placed at the start of a template to select the engine
+// regardless of its file extension, e.g. {{/* gcs-template: html */}}.
+var engineDirective = regexp.MustCompile(`^\s*{{-?\s*/\*\s*gcs-template:\s*(text|html)\s*\*/\s*-?}}`)
+
+// DetectTemplateEngine determines which engine should process the template.
+func DetectTemplateEngine(templatePath string, data []byte) TemplateEngine {
+	if match := engineDirective.FindSubmatch(data); match != nil {
+		if string(match[1]) == "html" {
+			return HTMLTemplateEngine
+		}
+		return TextTemplateEngine
+	}
+	switch strings.ToLower(filepath.Ext(templatePath)) {
+	case goHTMLTemplateExt:
+		return HTMLTemplateEngine
+	case goTemplateExt, goTemplateAltExt:
+		switch strings.ToLower(filepath.Ext(strings.TrimSuffix(templatePath, filepath.Ext(templatePath)))) {
+		case ".html", ".htm":
+			return HTMLTemplateEngine
+		default:
+			return TextTemplateEngine
+		}
+	default:
+		return LegacyTemplateEngine
+	}
+}
+
+// TemplateOutputExtension returns the file extension that output produced from the template should use.
+func TemplateOutputExtension(templatePath string) string {
+	ext := filepath.Ext(templatePath)
+	switch strings.ToLower(ext) {
+	case goHTMLTemplateExt:
+		return ".html"
+	case goTemplateExt, goTemplateAltExt:
+		if inner := filepath.Ext(strings.TrimSuffix(templatePath, ext)); inner != "" {
+			return inner
+		}
+		return ".txt"
+	default:
+		return ext
+	}
+}
+
+// TemplateTitle returns a title for the template, suitable for display in menus.
+func TemplateTitle(templatePath string) string {
+	name := filepath.Base(templatePath)
+	ext := filepath.Ext(name)
+	name = strings.TrimSuffix(name, ext)
+	switch strings.ToLower(ext) {
+	case goTemplateExt, goTemplateAltExt:
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return name
+}
+
+// Export the entity using the template, selecting the engine based on the template's header directive or file
+// extension.
+func Export(entity *gurps.Entity, templatePath, exportPath string) error {
+	data, err := os.ReadFile(templatePath)
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	if DetectTemplateEngine(templatePath, data) == LegacyTemplateEngine {
+		return LegacyExport(entity, templatePath, exportPath)
+	}
+	return TemplateExport(entity, templatePath, exportPath)
+}
+
+// TemplateExport performs the export using Go's text/template or html/template engine. The template is given a
+// *SheetData as its data.
+func TemplateExport(entity *gurps.Entity, templatePath, exportPath string) (err error) {
+	var data []byte
+	if data, err = os.ReadFile(templatePath); err != nil {
+		return errs.Wrap(err)
+	}
+	var out *os.File
+	if out, err = os.Create(exportPath); err != nil {
+		return errs.Wrap(err)
+	}
+	w := bufio.NewWriter(out)
+	defer func() { //nolint:gosec // Yes, this is safe
+		if flushErr := w.Flush(); flushErr != nil && err == nil {
+			err = errs.Wrap(flushErr)
+		}
+		if closeErr := out.Close(); closeErr != nil && err == nil {
+			err = errs.Wrap(closeErr)
+		}
+	}()
+	return WriteTemplate(w, DetectTemplateEngine(templatePath, data), filepath.Base(templatePath), string(data),
+		NewSheetData(entity))
+}
+
+// WriteTemplate processes the template text with the engine and writes the result.
+func WriteTemplate(w io.Writer, engine TemplateEngine, name, text string, data *SheetData) error {
+	funcs := templateFuncs()
+	if engine == HTMLTemplateEngine {
+		funcs["safeHTML"] = func(s string) htmltmpl.HTML { return htmltmpl.HTML(s) }    //nolint:gosec // Explicitly requested by the template
+		funcs["safeCSS"] = func(s string) htmltmpl.CSS { return htmltmpl.CSS(s) }       //nolint:gosec // Explicitly requested by the template
+		funcs["safeURL"] = func(s string) htmltmpl.URL { return htmltmpl.URL(s) }       //nolint:gosec // Explicitly requested by the template
+		funcs["paragraphs"] = func(s string) htmltmpl.HTML { return htmlParagraphs(s) } //nolint:gosec // Input is escaped
+		tmpl, err := htmltmpl.New(name).Funcs(funcs).Parse(text)
+		if err != nil {
+			return errs.Wrap(err)
+		}
+		if err = tmpl.Execute(w, data); err != nil {
+			return errs.Wrap(err)
+		}
+		return nil
+	}
+	tmpl, err := template.New(name).Funcs(funcs).Parse(text)
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	if err = tmpl.Execute(w, data); err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+func templateFuncs() map[string]any {
+	return map[string]any{
+		"add":     func(a, b int) int { return a + b },
+		"sub":     func(a, b int) int { return a - b },
+		"upper":   strings.ToUpper,
+		"lower":   strings.ToLower,
+		"trim":    strings.TrimSpace,
+		"join":    func(sep string, list []string) string { return strings.Join(list, sep) },
+		"lines":   func(s string) []string { return strings.Split(strings.TrimSpace(s), "\n") },
+		"repeat":  templateRepeat,
+		"hasTag":  func(tag string, tags []string) bool { return gurps.HasTag(tag, tags) },
+		"signed":  func(value fxp.Int) string { return value.StringWithSign() },
+		"default": templateDefault,
+		"json":    templateJSON,
+	}
+}
+
+func templateRepeat(count int, s string) string {
+	if count < 1 {
+		return ""
+	}
+	return strings.Repeat(s, count)
+}
+
+func templateDefault(def string, value any) any {
+	switch v := value.(type) {
+	case nil:
+		return def
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return def
+		}
+	}
+	return value
+}
+
+func templateJSON(value any) (string, error) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return "", errs.Wrap(err)
+	}
+	return string(data), nil
+}
+
+func htmlParagraphs(text string) htmltmpl.HTML {
+	var buffer bytes.Buffer
+	for _, one := range strings.Split(strings.TrimSpace(text), "\n") {
+		if one = strings.TrimSpace(one); one != "" {
+			buffer.WriteString("<p>")
+			buffer.WriteString(htmltmpl.HTMLEscapeString(one))
+			buffer.WriteString("</p>\n")
+		}
+	}
+	return htmltmpl.HTML(buffer.String()) //nolint:gosec // Input is escaped
+}
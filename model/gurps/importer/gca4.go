@@ -0,0 +1,377 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/datafile"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/model/gurps/measure"
+	"github.com/richardwilkes/gcs/v5/model/gurps/trait"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/i18n"
+)
+
+// GCA4Ext is the extension used by GURPS Character Assistant 4 character files.
+const GCA4Ext = ".gca4"
+
+// gca4Importer converts GURPS Character Assistant 4 character files. These are line-oriented, grouped into
+// [Section]s. Simple values are written as key=value, while the entries in the trait, skill, spell & equipment sections
+// use the item syntax of GCA's data files: the name, then the cost or points, then any number of tag(value) pairs, all
+// separated by commas. Only the parts that map onto GCS data are used; everything else is reported.
+type gca4Importer struct {
+	report *Report
+	entity *gurps.Entity
+}
+
+func importGCA4(data []byte, report *Report) (*Result, error) {
+	x := &gca4Importer{report: report}
+	x.entity = gurps.NewEntity(datafile.PC)
+	x.entity.Profile = &gurps.Profile{}
+	x.entity.Traits = nil
+	x.entity.TotalPoints = 0
+	section := ""
+	found := false
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "*") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			found = true
+			continue
+		}
+		x.line(section, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	if !found {
+		return nil, errs.New(i18n.Text("no GCA4 sections found"))
+	}
+	x.entity.Recalculate()
+	return &Result{Entity: x.entity, Report: report, ext: library.SheetExt}, nil
+}
+
+func (x *gca4Importer) line(section, line string) {
+	switch section {
+	case "", "version", "options":
+	case "character", "profile", "bio", "description":
+		x.profileValue(line)
+	case "stats", "attributes":
+		x.attribute(line)
+	case "advantages", "perks":
+		x.trait(line, "Advantage")
+	case "disadvantages", "quirks":
+		x.trait(line, "Disadvantage")
+	case "languages":
+		x.trait(line, "Language")
+	case "cultures":
+		x.trait(line, "Culture")
+	case "races", "templates":
+		x.trait(line, "Ancestry")
+	case "skills":
+		x.skill(line)
+	case "spells":
+		x.spell(line)
+	case "equipment":
+		x.equipment(line)
+	case "notes":
+		note := gurps.NewNote(x.entity, nil, false)
+		note.Text = line
+		x.entity.Notes = append(x.entity.Notes, note)
+	default:
+		x.report.Addf(i18n.Text("section [%s]"), section)
+	}
+}
+
+func (x *gca4Importer) profileValue(line string) {
+	key, value, ok := splitKeyValue(line)
+	if !ok {
+		x.report.Addf(i18n.Text("profile line \"%s\""), line)
+		return
+	}
+	p := x.entity.Profile
+	switch key {
+	case "name", "charname":
+		p.Name = value
+	case "player", "playername":
+		p.PlayerName = value
+	case "title":
+		p.Title = value
+	case "age":
+		p.Age = value
+	case "appearance", "description":
+		if value != "" {
+			note := gurps.NewNote(x.entity, nil, false)
+			note.Text = value
+			x.entity.Notes = append(x.entity.Notes, note)
+		}
+	case "eyes":
+		p.Eyes = value
+	case "hair":
+		p.Hair = value
+	case "skin":
+		p.Skin = value
+	case "gender", "sex":
+		p.Gender = value
+	case "religion":
+		p.Religion = value
+	case "height":
+		p.Height = measure.LengthFromStringForced(value, x.entity.SheetSettings.DefaultLengthUnits)
+	case "weight":
+		p.Weight = measure.WeightFromStringForced(value, x.entity.SheetSettings.DefaultWeightUnits)
+	case "tl", "techlevel":
+		p.TechLevel = value
+	case "totalpoints", "points":
+		x.entity.TotalPoints = parseNumber(value)
+	default:
+		if value != "" {
+			x.report.Addf(i18n.Text("profile value \"%s\""), key)
+		}
+	}
+}
+
+func (x *gca4Importer) attribute(line string) {
+	key, value, ok := splitKeyValue(line)
+	if !ok {
+		name, tags, cost := parseGCAItem(line)
+		key = strings.ToLower(name)
+		if value = tags["score"]; value == "" {
+			value = cost
+		}
+	}
+	attrID := legacyAttributeID(key)
+	switch key {
+	case "st", "strength", "dx", "dexterity", "iq", "intelligence", "ht", "health", "will", "per", "perception",
+		"hp", "hit points", "fp", "fatigue points", "basic speed", "speed", "basic move", "move":
+		if key == "hit points" {
+			attrID = gid.HitPoints
+		} else if key == "fatigue points" {
+			attrID = gid.FatiguePoints
+		}
+		if attr, exists := x.entity.Attributes.Set[attrID]; exists {
+			attr.SetMaximum(parseNumber(value))
+		}
+	default:
+		x.report.Addf(i18n.Text("attribute \"%s\""), key)
+	}
+}
+
+func (x *gca4Importer) trait(line, defaultTag string) {
+	name, tags, cost := parseGCAItem(line)
+	if name == "" {
+		return
+	}
+	t := gurps.NewTrait(x.entity, nil, false)
+	t.Name = name
+	t.Tags = []string{defaultTag}
+	if cat := tags["cat"]; cat != "" {
+		for _, one := range strings.Split(cat, ",") {
+			if one = strings.TrimSpace(one); one != "" {
+				t.Tags = appendTag(t.Tags, one)
+			}
+		}
+	}
+	t.PageRef = tags["page"]
+	t.LocalNotes = tags["notes"]
+	if level := tags["level"]; level != "" {
+		t.Levels = parseNumber(level)
+	}
+	points := tags["points"]
+	if points == "" {
+		points = cost
+	}
+	if t.Levels > 0 {
+		t.PointsPerLevel = parseNumber(points).Div(t.Levels)
+	} else {
+		t.BasePoints = parseNumber(points)
+	}
+	if cr := tags["selfcontrol"]; cr != "" {
+		t.CR = trait.SelfControlRoll(fxp.As[int](parseNumber(cr)))
+	}
+	x.reportUnused(tags, name, "cat", "page", "notes", "level", "points", "selfcontrol", "step", "upto")
+	x.entity.Traits = append(x.entity.Traits, t)
+}
+
+func (x *gca4Importer) skill(line string) {
+	name, tags, cost := parseGCAItem(line)
+	if name == "" {
+		return
+	}
+	s := gurps.NewSkill(x.entity, nil, false)
+	s.Name, s.Specialization = splitSpecialization(name)
+	setDifficulty(x.entity, &s.Difficulty, tags["type"], gid.Dexterity)
+	s.Points = x.points(tags, cost)
+	s.PageRef = tags["page"]
+	if tl := tags["tl"]; tl != "" {
+		s.TechLevel = &tl
+	}
+	x.reportUnused(tags, name, "type", "page", "points", "tl", "level", "step")
+	x.entity.Skills = append(x.entity.Skills, s)
+}
+
+func (x *gca4Importer) spell(line string) {
+	name, tags, cost := parseGCAItem(line)
+	if name == "" {
+		return
+	}
+	s := gurps.NewSpell(x.entity, nil, false)
+	s.Name = name
+	setDifficulty(x.entity, &s.Difficulty, tags["type"], gid.Intelligence)
+	s.Points = x.points(tags, cost)
+	s.PageRef = tags["page"]
+	if college := tags["cat"]; college != "" {
+		s.College = nil
+		for _, one := range strings.Split(college, ",") {
+			if one = strings.TrimSpace(one); one != "" {
+				s.College = append(s.College, one)
+			}
+		}
+	}
+	s.Class = tags["class"]
+	s.CastingCost = tags["castingcost"]
+	s.MaintenanceCost = tags["maintain"]
+	s.CastingTime = tags["time"]
+	s.Duration = tags["duration"]
+	x.reportUnused(tags, name, "type", "page", "points", "cat", "class", "castingcost", "maintain", "time",
+		"duration", "level", "step", "tl")
+	x.entity.Spells = append(x.entity.Spells, s)
+}
+
+func (x *gca4Importer) equipment(line string) {
+	name, tags, cost := parseGCAItem(line)
+	if name == "" {
+		return
+	}
+	eqp := gurps.NewEquipment(x.entity, nil, false)
+	eqp.Name = name
+	eqp.Quantity = fxp.One
+	if count := tags["count"]; count != "" {
+		eqp.Quantity = parseNumber(count)
+	}
+	eqp.Value = parseNumber(cost)
+	if weight := tags["weight"]; weight != "" {
+		eqp.Weight = measure.WeightFromStringForced(weight, measure.Pound)
+	}
+	eqp.TechLevel = tags["techlvl"]
+	eqp.LegalityClass = tags["lc"]
+	eqp.PageRef = tags["page"]
+	eqp.LocalNotes = tags["notes"]
+	eqp.Equipped = true
+	x.reportUnused(tags, name, "count", "weight", "techlvl", "lc", "page", "notes", "cat")
+	x.entity.CarriedEquipment = append(x.entity.CarriedEquipment, eqp)
+	if tags["damage"] != "" || tags["acc"] != "" {
+		x.report.Addf(i18n.Text("weapon statistics of \"%s\""), name)
+	}
+}
+
+func (x *gca4Importer) points(tags map[string]string, cost string) fxp.Int {
+	if points := tags["points"]; points != "" {
+		return parseNumber(points)
+	}
+	return parseNumber(cost)
+}
+
+func (x *gca4Importer) reportUnused(tags map[string]string, owner string, used ...string) {
+	for key := range tags {
+		unused := true
+		for _, one := range used {
+			if key == one {
+				unused = false
+				break
+			}
+		}
+		if unused {
+			x.report.Addf(i18n.Text("%s(…) of \"%s\""), key, owner)
+		}
+	}
+}
+
+func splitKeyValue(line string) (key, value string, ok bool) {
+	i := strings.IndexByte(line, '=')
+	if i < 0 {
+		return "", "", false
+	}
+	return strings.ToLower(strings.TrimSpace(line[:i])), unquote(strings.TrimSpace(line[i+1:])), true
+}
+
+// parseGCAItem splits an item line of the form: name, cost, tag(value), tag(value)... Commas within parentheses or
+// quotes do not split the line.
+func parseGCAItem(line string) (name string, tags map[string]string, cost string) {
+	tags = make(map[string]string)
+	for i, field := range splitGCAFields(line) {
+		switch {
+		case i == 0:
+			name = unquote(field)
+		case strings.HasSuffix(field, ")") && strings.IndexByte(field, '(') > 0:
+			open := strings.IndexByte(field, '(')
+			tags[strings.ToLower(strings.TrimSpace(field[:open]))] = unquote(strings.TrimSpace(field[open+1 : len(field)-1]))
+		case i == 1:
+			cost = strings.TrimSuffix(field, "/")
+		}
+	}
+	return name, tags, cost
+}
+
+func splitGCAFields(line string) []string {
+	var fields []string
+	depth := 0
+	quoted := false
+	start := 0
+	for i, ch := range line {
+		switch ch {
+		case '"':
+			quoted = !quoted
+		case '(':
+			if !quoted {
+				depth++
+			}
+		case ')':
+			if !quoted && depth > 0 {
+				depth--
+			}
+		case ',':
+			if !quoted && depth == 0 {
+				fields = append(fields, strings.TrimSpace(line[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(fields, strings.TrimSpace(line[start:]))
+}
+
+func splitSpecialization(name string) (base, specialization string) {
+	if strings.HasSuffix(name, ")") {
+		if i := strings.LastIndexByte(name, '('); i > 0 {
+			return strings.TrimSpace(name[:i]), strings.TrimSpace(name[i+1 : len(name)-1])
+		}
+	}
+	return name, ""
+}
+
+func unquote(text string) string {
+	if s, err := strconv.Unquote(text); err == nil {
+		return s
+	}
+	return text
+}
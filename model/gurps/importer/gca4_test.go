@@ -0,0 +1,123 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package importer
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/gcs/v5/model/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGCAItem(t *testing.T) {
+	for _, tc := range []struct {
+		line string
+		name string
+		cost string
+		tags map[string]string
+	}{
+		{line: "Combat Reflexes, 15", name: "Combat Reflexes", cost: "15", tags: map[string]string{}},
+		{
+			line: `Broadsword, 4/, type(DX/A), page(B208)`,
+			name: "Broadsword",
+			cost: "4",
+			tags: map[string]string{"type": "DX/A", "page": "B208"},
+		},
+		{
+			line: `"Rope, 10 yards", 5, weight(1.5 lb), notes("coiled, tied")`,
+			name: "Rope, 10 yards",
+			cost: "5",
+			tags: map[string]string{"weight": "1.5 lb", "notes": "coiled, tied"},
+		},
+		{
+			line: "Fireball, points(2), cat(Fire, Attack)",
+			name: "Fireball",
+			tags: map[string]string{"points": "2", "cat": "Fire, Attack"},
+		},
+	} {
+		name, tags, cost := parseGCAItem(tc.line)
+		assert.Equal(t, tc.name, name, tc.line)
+		assert.Equal(t, tc.cost, cost, tc.line)
+		assert.Equal(t, tc.tags, tags, tc.line)
+	}
+}
+
+func TestSplitSpecialization(t *testing.T) {
+	for _, tc := range []struct {
+		text           string
+		name           string
+		specialization string
+	}{
+		{text: "Broadsword", name: "Broadsword"},
+		{text: "Guns (Pistol)", name: "Guns", specialization: "Pistol"},
+		{text: "(Odd)", name: "(Odd)"},
+	} {
+		name, specialization := splitSpecialization(tc.text)
+		assert.Equal(t, tc.name, name, tc.text)
+		assert.Equal(t, tc.specialization, specialization, tc.text)
+	}
+}
+
+func TestImportGCA4(t *testing.T) {
+	settings.Global()
+	fileSystem := fstest.MapFS{"test.gca4": &fstest.MapFile{Data: []byte(`[Character]
+name=Test Subject
+player=Someone
+totalpoints=150
+[Stats]
+ST=12
+[Advantages]
+Combat Reflexes, 15, page(B43)
+[Skills]
+Guns (Pistol), 2, type(DX/E), tl(8)
+[Equipment]
+Backpack, 60, weight(10 lb), count(2), scent(pine)
+[Oddities]
+something=else
+`)}}
+	result, err := Import(fileSystem, "test.gca4")
+	require.NoError(t, err)
+	assert.Equal(t, library.SheetExt, result.Extension())
+	e := result.Entity
+	require.NotNil(t, e)
+	assert.Equal(t, "Test Subject", e.Profile.Name)
+	assert.Equal(t, "Someone", e.Profile.PlayerName)
+	assert.Equal(t, fxp.From(150), e.TotalPoints)
+	assert.Equal(t, fxp.From(12), e.Attributes.Set[gid.Strength].Maximum())
+	require.Len(t, e.Traits, 1)
+	assert.Equal(t, "Combat Reflexes", e.Traits[0].Name)
+	assert.Equal(t, fxp.From(15), e.Traits[0].BasePoints)
+	assert.Equal(t, "B43", e.Traits[0].PageRef)
+	require.Len(t, e.Skills, 1)
+	assert.Equal(t, "Guns", e.Skills[0].Name)
+	assert.Equal(t, "Pistol", e.Skills[0].Specialization)
+	assert.Equal(t, fxp.Two, e.Skills[0].Points)
+	require.NotNil(t, e.Skills[0].TechLevel)
+	assert.Equal(t, "8", *e.Skills[0].TechLevel)
+	require.Len(t, e.CarriedEquipment, 1)
+	assert.Equal(t, fxp.Two, e.CarriedEquipment[0].Quantity)
+	assert.Equal(t, fxp.From(60), e.CarriedEquipment[0].Value)
+
+	issues := result.Report.Issues()
+	assert.Contains(t, issues, `scent(…) of "Backpack"`)
+	assert.Contains(t, issues, "section [oddities]")
+}
+
+func TestImportGCA4WithoutSections(t *testing.T) {
+	_, err := Import(fstest.MapFS{"bad.gca4": &fstest.MapFile{Data: []byte("nothing here")}}, "bad.gca4")
+	assert.Error(t, err)
+}
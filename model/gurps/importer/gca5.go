@@ -0,0 +1,482 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package importer
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/datafile"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/model/gurps/measure"
+	"github.com/richardwilkes/gcs/v5/model/gurps/trait"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/i18n"
+)
+
+// GCA5Ext is the extension used by GURPS Character Assistant 5 character files.
+const GCA5Ext = ".gca5"
+
+var gcaDefaultRegex = regexp.MustCompile(`^(.*?)\s*([+-]\s*\d+)?$`)
+
+// gca5Importer converts GURPS Character Assistant 5 character files. These are XML, with every trait, skill, spell &
+// piece of equipment written as a <trait> element whose type attribute identifies what it is. Values may appear
+// directly on the element or within its <calcs> (computed) or <ref> (data file) children, so lookups check all three.
+// Each item is matched against the libraries by name; matches are copied from the library so that page references,
+// features, prerequisites and weapons come from our own data. Items that can't be matched are created from the GCA
+// data alone and given the ReviewTag.
+type gca5Importer struct {
+	report  *Report
+	matcher *libraryMatcher
+	entity  *gurps.Entity
+	scores  map[string]fxp.Int
+}
+
+func importGCA5(data []byte, report *Report, libraries library.Libraries) (*Result, error) {
+	root, err := parseXML(data)
+	if err != nil {
+		return nil, errs.NewWithCause(i18n.Text("unable to parse XML"), err)
+	}
+	character := root
+	if root.Name != "character" {
+		if character = root.child("character"); character == nil {
+			return nil, errs.New(i18n.Text("no character data found"))
+		}
+	}
+	x := &gca5Importer{
+		report:  report,
+		matcher: newLibraryMatcher(libraries),
+		entity:  gurps.NewEntity(datafile.PC),
+		scores:  make(map[string]fxp.Int),
+	}
+	x.entity.Profile = &gurps.Profile{}
+	x.entity.Traits = nil
+	x.entity.TotalPoints = 0
+	x.profile(character)
+	if traits := character.child("traits"); traits != nil {
+		x.items(traits)
+	}
+	// Attribute scores include any bonuses granted by traits, so they can only be applied once the traits are in place.
+	x.entity.Recalculate()
+	for attrID, score := range x.scores {
+		if attr, ok := x.entity.Attributes.Set[attrID]; ok {
+			attr.SetMaximum(score)
+		}
+	}
+	x.entity.Recalculate()
+	return &Result{Entity: x.entity, Report: report, ext: library.SheetExt}, nil
+}
+
+func (x *gca5Importer) profile(character *xmlNode) {
+	p := x.entity.Profile
+	p.Name = character.text("name")
+	p.PlayerName = character.text("player")
+	if vitals := character.child("vitals"); vitals != nil {
+		p.Age = vitals.text("age")
+		p.Eyes = vitals.text("eyes")
+		p.Hair = vitals.text("hair")
+		p.Skin = vitals.text("skin")
+		p.Gender = vitals.text("gender")
+		if height := vitals.text("height"); height != "" {
+			p.Height = measure.LengthFromStringForced(height, x.entity.SheetSettings.DefaultLengthUnits)
+		}
+		if weight := vitals.text("weight"); weight != "" {
+			p.Weight = measure.WeightFromStringForced(gcaWeight(weight), x.entity.SheetSettings.DefaultWeightUnits)
+		}
+		if race := vitals.text("race"); race != "" && !strings.EqualFold(race, "human") {
+			x.report.Addf(i18n.Text("race \"%s\" (add the matching ancestry trait)"), race)
+		}
+	}
+	if tl := findNode(character, "tl"); tl != nil {
+		p.TechLevel = strings.TrimSpace(tl.Text)
+	}
+	if points := findNode(character, "totalpoints"); points != nil {
+		x.entity.TotalPoints = parseNumber(points.Text)
+	} else if points = findNode(character, "startingpoints"); points != nil {
+		x.entity.TotalPoints = parseNumber(points.Text)
+	}
+	for _, key := range []string{"description", "notes"} {
+		if text := character.text(key); text != "" {
+			note := gurps.NewNote(x.entity, nil, false)
+			note.Text = text
+			x.entity.Notes = append(x.entity.Notes, note)
+		}
+	}
+	if bodyType := character.text("bodytype"); bodyType != "" && !strings.EqualFold(bodyType, "humanoid") {
+		x.report.Addf(i18n.Text("body type \"%s\""), bodyType)
+	}
+}
+
+func (x *gca5Importer) items(node *xmlNode) {
+	for _, child := range node.Children {
+		if child.Name != "trait" {
+			x.items(child)
+			continue
+		}
+		switch kind := strings.ToLower(child.attr("type")); kind {
+		case "stats", "attributes":
+			x.attribute(child)
+		case "advantages", "perks", "features":
+			x.trait(child, "Advantage")
+		case "disadvantages", "quirks":
+			x.trait(child, "Disadvantage")
+		case "languages":
+			x.trait(child, "Language")
+		case "cultures":
+			x.trait(child, "Culture")
+		case "templates", "races":
+			x.trait(child, "Ancestry")
+		case "skills":
+			x.skill(child)
+		case "spells":
+			x.spell(child)
+		case "equipment":
+			x.equipment(child)
+		default:
+			x.report.Addf(i18n.Text("items of type \"%s\""), kind)
+		}
+	}
+}
+
+func (x *gca5Importer) attribute(item *xmlNode) {
+	name := gcaField(item, "name")
+	score := gcaField(item, "score", "value")
+	if name == "" || score == "" {
+		return
+	}
+	switch attrID := legacyAttributeID(strings.ReplaceAll(strings.ToLower(name), " ", "_")); attrID {
+	case gid.Strength, gid.Dexterity, gid.Intelligence, gid.Health, gid.Will, gid.Perception, gid.Vision, gid.Hearing,
+		gid.TasteSmell, gid.Touch, gid.BasicSpeed, gid.BasicMove, gid.HitPoints, gid.FatiguePoints:
+		x.scores[attrID] = parseNumber(score)
+	case "hit_points":
+		x.scores[gid.HitPoints] = parseNumber(score)
+	case "fatigue_points":
+		x.scores[gid.FatiguePoints] = parseNumber(score)
+	default:
+		// GCA tracks a great many derived values (dodge, lifting ST, etc.) that GCS computes itself.
+	}
+}
+
+func (x *gca5Importer) trait(item *xmlNode, typeTag string) {
+	name := gcaField(item, "name")
+	if name == "" {
+		return
+	}
+	ext := gcaField(item, "nameext")
+	points := parseNumber(gcaField(item, "points"))
+	levels := parseNumber(gcaField(item, "level"))
+	var t *gurps.Trait
+	if found := x.matcher.trait(fullName(name, ext)); found != nil {
+		t = found.Clone(x.entity, nil, false)
+	} else if found = x.matcher.trait(name); found != nil {
+		t = found.Clone(x.entity, nil, false)
+		t.LocalNotes = joinNotes(t.LocalNotes, ext)
+	}
+	if t != nil {
+		if t.IsLeveled() && levels > 0 {
+			t.Levels = levels
+		}
+		x.traitModifiers(t, item)
+		if t.AdjustedPoints() != points {
+			t.Tags = appendTag(t.Tags, ReviewTag)
+			x.report.Addf(i18n.Text("point cost of \"%s\" differs from the library version"), t.String())
+		}
+	} else {
+		t = gurps.NewTrait(x.entity, nil, false)
+		t.Name = name
+		t.LocalNotes = ext
+		t.PageRef = gcaField(item, "page")
+		t.Tags = []string{typeTag}
+		x.traitModifiers(t, item)
+		// The cost prior to modifiers is needed here, since the modifiers will be applied again
+		if pre := gcaField(item, "premodspoints"); pre != "" {
+			points = parseNumber(pre)
+		}
+		if levels > fxp.One {
+			t.Levels = levels
+			t.PointsPerLevel = points.Div(levels)
+		} else {
+			t.BasePoints = points
+		}
+		t.Tags = appendTag(t.Tags, ReviewTag)
+	}
+	x.entity.Traits = append(x.entity.Traits, t)
+}
+
+// traitModifiers enables any of the trait's existing modifiers that GCA lists as applied, disabling the rest, then adds
+// the remaining ones.
+func (x *gca5Importer) traitModifiers(t *gurps.Trait, item *xmlNode) {
+	applied := make(map[string]*xmlNode)
+	var order []string
+	if modifiers := item.child("modifiers"); modifiers != nil {
+		for _, one := range modifiers.Children {
+			if one.Name == "modifier" {
+				key := matchKey(fullName(gcaField(one, "name"), gcaField(one, "nameext")), "")
+				applied[key] = one
+				order = append(order, key)
+			}
+		}
+	}
+	gurps.Traverse(func(m *gurps.TraitModifier) bool {
+		key := matchKey(m.Name, "")
+		if _, ok := applied[key]; ok {
+			m.Disabled = false
+			delete(applied, key)
+		} else {
+			m.Disabled = true
+		}
+		return false
+	}, true, false, t.Modifiers...)
+	for _, key := range order {
+		node, ok := applied[key]
+		if !ok {
+			continue
+		}
+		name := gcaField(node, "name")
+		var m *gurps.TraitModifier
+		if found := x.matcher.traitModifier(name); found != nil {
+			m = found.Clone(x.entity, nil, false)
+		} else {
+			m = gurps.NewTraitModifier(x.entity, nil, false)
+			m.Name = name
+			m.PageRef = gcaField(node, "page")
+			m.Tags = []string{ReviewTag}
+			m.CostType, m.Cost = gcaModifierCost(gcaField(node, "value", "cost"))
+		}
+		m.LocalNotes = joinNotes(m.LocalNotes, gcaField(node, "nameext"))
+		if level := parseNumber(gcaField(node, "level")); level > fxp.One && m.CostType == trait.Percentage {
+			m.Levels = level
+		}
+		t.Modifiers = append(t.Modifiers, m)
+	}
+}
+
+func (x *gca5Importer) skill(item *xmlNode) {
+	name := gcaField(item, "name")
+	if name == "" {
+		return
+	}
+	specialization := gcaField(item, "nameext")
+	var s *gurps.Skill
+	if found := x.matcher.skill(name, specialization); found != nil {
+		s = found.Clone(x.entity, nil, false)
+		s.Specialization = specialization
+	} else {
+		s = gurps.NewSkill(x.entity, nil, false)
+		s.Name = name
+		s.Specialization = specialization
+		s.PageRef = gcaField(item, "page")
+		if tl := gcaField(item, "tl"); tl != "" {
+			s.TechLevel = &tl
+		}
+		setDifficulty(x.entity, &s.Difficulty, gcaField(item, "type"), gid.Dexterity)
+		s.Defaults = gcaDefaults(gcaField(item, "default"))
+		s.Tags = []string{ReviewTag}
+	}
+	s.Points = parseNumber(gcaField(item, "points"))
+	x.entity.Skills = append(x.entity.Skills, s)
+}
+
+func (x *gca5Importer) spell(item *xmlNode) {
+	name := gcaField(item, "name")
+	if name == "" {
+		return
+	}
+	var s *gurps.Spell
+	if found := x.matcher.spell(name); found != nil {
+		s = found.Clone(x.entity, nil, false)
+	} else {
+		s = gurps.NewSpell(x.entity, nil, false)
+		s.Name = name
+		s.PageRef = gcaField(item, "page")
+		setDifficulty(x.entity, &s.Difficulty, gcaField(item, "type"), gid.Intelligence)
+		if college := gcaField(item, "cat", "college"); college != "" {
+			s.College = nil
+			for _, one := range strings.Split(college, ",") {
+				if one = strings.TrimSpace(one); one != "" {
+					s.College = append(s.College, one)
+				}
+			}
+		}
+		s.Class = gcaField(item, "class")
+		s.CastingCost = gcaField(item, "castingcost")
+		s.MaintenanceCost = gcaField(item, "maintain")
+		s.CastingTime = gcaField(item, "time")
+		s.Duration = gcaField(item, "duration")
+		s.Tags = []string{ReviewTag}
+	}
+	s.Points = parseNumber(gcaField(item, "points"))
+	x.entity.Spells = append(x.entity.Spells, s)
+}
+
+func (x *gca5Importer) equipment(item *xmlNode) {
+	name := gcaField(item, "name")
+	if name == "" {
+		return
+	}
+	var eqp *gurps.Equipment
+	if found := x.matcher.equipmentItem(fullName(name, gcaField(item, "nameext"))); found != nil {
+		eqp = found.Clone(x.entity, nil, false)
+	} else if found = x.matcher.equipmentItem(name); found != nil {
+		eqp = found.Clone(x.entity, nil, false)
+	} else {
+		eqp = gurps.NewEquipment(x.entity, nil, false)
+		eqp.Name = name
+		eqp.LocalNotes = gcaField(item, "nameext")
+		eqp.PageRef = gcaField(item, "page")
+		eqp.TechLevel = gcaField(item, "techlvl", "tl")
+		eqp.LegalityClass = gcaField(item, "lc")
+		eqp.Value = parseNumber(gcaField(item, "basecost", "cost"))
+		if weight := gcaField(item, "baseweight", "weight"); weight != "" {
+			eqp.Weight = measure.WeightFromStringForced(gcaWeight(weight), measure.Pound)
+		}
+		eqp.Tags = []string{ReviewTag}
+	}
+	eqp.Quantity = fxp.One
+	if count := gcaField(item, "count"); count != "" {
+		eqp.Quantity = parseNumber(count)
+	}
+	eqp.Equipped = true
+	if modifiers := item.child("modifiers"); modifiers != nil {
+		for _, one := range modifiers.Children {
+			if one.Name != "modifier" {
+				continue
+			}
+			modName := gcaField(one, "name")
+			var m *gurps.EquipmentModifier
+			if found := x.matcher.equipmentModifier(modName); found != nil {
+				m = found.Clone(x.entity, nil, false)
+			} else {
+				m = gurps.NewEquipmentModifier(x.entity, nil, false)
+				m.Name = modName
+				m.LocalNotes = gcaField(one, "nameext")
+				m.PageRef = gcaField(one, "page")
+				m.Tags = []string{ReviewTag}
+				if cost := strings.TrimSpace(gcaField(one, "value", "cost")); cost != "" {
+					if strings.HasPrefix(cost, "*") {
+						cost = "x" + cost[1:]
+					}
+					m.CostAmount = m.CostType.Format(cost)
+				}
+			}
+			eqp.Modifiers = append(eqp.Modifiers, m)
+		}
+	}
+	if gcaField(item, "parentkey") != "" {
+		x.report.Addf(i18n.Text("container placement of \"%s\""), name)
+	}
+	x.entity.CarriedEquipment = append(x.entity.CarriedEquipment, eqp)
+}
+
+// gcaField returns the first non-empty value found for the names, checking the item itself, then its computed values,
+// then its data file values.
+func gcaField(item *xmlNode, names ...string) string {
+	for _, name := range names {
+		if v := item.text(name); v != "" {
+			return v
+		}
+		if calcs := item.child("calcs"); calcs != nil {
+			if v := calcs.text(name); v != "" {
+				return v
+			}
+		}
+		if ref := item.child("ref"); ref != nil {
+			if v := ref.text(name); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// gcaModifierCost interprets a GCA modifier value, e.g. "-40%", "+5" or "x2".
+func gcaModifierCost(value string) (trait.ModifierCostType, fxp.Int) {
+	value = strings.TrimSpace(value)
+	switch {
+	case strings.HasSuffix(value, "%"):
+		return trait.Percentage, parseNumber(strings.TrimSuffix(value, "%"))
+	case strings.HasPrefix(value, "x"), strings.HasPrefix(value, "*"):
+		return trait.Multiplier, parseNumber(value[1:])
+	default:
+		return trait.Points, parseNumber(value)
+	}
+}
+
+// gcaDefaults interprets a GCA default list, e.g. "DX-5, SK:Broadsword-2".
+func gcaDefaults(text string) []*gurps.SkillDefault {
+	var list []*gurps.SkillDefault
+	for _, one := range splitGCAFields(text) {
+		one = strings.TrimSpace(one)
+		if one == "" {
+			continue
+		}
+		parts := gcaDefaultRegex.FindStringSubmatch(one)
+		if parts == nil {
+			continue
+		}
+		def := &gurps.SkillDefault{Modifier: parseNumber(strings.ReplaceAll(parts[2], " ", ""))}
+		name := strings.TrimSpace(parts[1])
+		if strings.HasPrefix(strings.ToUpper(name), "SK:") {
+			def.DefaultType = gid.Skill
+			def.Name, def.Specialization = splitSpecialization(strings.TrimSpace(name[3:]))
+		} else {
+			switch attrID := legacyAttributeID(name); attrID {
+			case gid.Strength, gid.Dexterity, gid.Intelligence, gid.Health, gid.Will, gid.Perception:
+				def.DefaultType = attrID
+			default:
+				def.DefaultType = gid.Skill
+				def.Name, def.Specialization = splitSpecialization(name)
+			}
+		}
+		list = append(list, def)
+	}
+	return list
+}
+
+// gcaWeight adjusts the unit spellings GCA uses that GCS does not recognize.
+func gcaWeight(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(text, "lbs", "lb"), "kgs", "kg")
+}
+
+func fullName(name, ext string) string {
+	if ext == "" {
+		return name
+	}
+	return name + " (" + ext + ")"
+}
+
+func joinNotes(notes, extra string) string {
+	switch {
+	case extra == "":
+		return notes
+	case notes == "":
+		return extra
+	default:
+		return notes + "; " + extra
+	}
+}
+
+// findNode returns the first node with the name, searching depth-first.
+func findNode(node *xmlNode, name string) *xmlNode {
+	for _, child := range node.Children {
+		if child.Name == name {
+			return child
+		}
+		if found := findNode(child, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package importer
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/datafile"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/gcs/v5/model/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLibraries(t *testing.T) library.Libraries {
+	settings.Global()
+	dir := t.TempDir()
+	entity := gurps.NewEntity(datafile.PC)
+
+	combatReflexes := gurps.NewTrait(nil, nil, false)
+	combatReflexes.Name = "Combat Reflexes"
+	combatReflexes.BasePoints = fxp.From(15)
+	combatReflexes.PageRef = "B43"
+	require.NoError(t, gurps.SaveTraits([]*gurps.Trait{combatReflexes}, filepath.Join(dir, "test"+library.TraitsExt)))
+
+	guns := gurps.NewSkill(nil, nil, false)
+	guns.Name = "Guns"
+	guns.PageRef = "B198"
+	broadsword := gurps.NewSkill(nil, nil, false)
+	broadsword.Name = "Broadsword"
+	broadsword.PageRef = "B208"
+	require.NoError(t, gurps.SaveSkills([]*gurps.Skill{guns, broadsword}, filepath.Join(dir, "test"+library.SkillsExt)))
+
+	backpack := gurps.NewEquipment(entity, nil, false)
+	backpack.Name = "Backpack (Small)"
+	backpack.PageRef = "B288"
+	backpack.Value = fxp.From(60)
+	require.NoError(t, gurps.SaveEquipment([]*gurps.Equipment{backpack},
+		filepath.Join(dir, "test"+library.EquipmentExt)))
+
+	fine := gurps.NewEquipmentModifier(entity, nil, false)
+	fine.Name = "Fine"
+	fine.PageRef = "B274"
+	require.NoError(t, gurps.SaveEquipmentModifiers([]*gurps.EquipmentModifier{fine},
+		filepath.Join(dir, "test"+library.EquipmentModifiersExt)))
+
+	lib := library.NewLibrary("Test", "test", "library", dir)
+	return library.Libraries{lib.Key(): lib}
+}
+
+func TestLibraryMatcher(t *testing.T) {
+	m := newLibraryMatcher(newTestLibraries(t))
+	for _, tc := range []struct {
+		name           string
+		specialization string
+		page           string
+	}{
+		{name: "Guns", page: "B198"},
+		{name: "guns", specialization: "Pistol", page: "B198"},
+		{name: " BROADSWORD ", page: "B208"},
+		{name: "Broadsword", specialization: "Two-Handed", page: "B208"},
+		{name: "Shortsword"},
+	} {
+		s := m.skill(tc.name, tc.specialization)
+		if tc.page == "" {
+			assert.Nil(t, s, tc.name)
+			continue
+		}
+		require.NotNil(t, s, tc.name)
+		assert.Equal(t, tc.page, s.PageRef, tc.name)
+	}
+	assert.NotNil(t, m.trait("combat reflexes"))
+	assert.Nil(t, m.trait("Combat Reflexes (Improved)"))
+	assert.NotNil(t, m.equipmentItem("backpack (small)"))
+	assert.NotNil(t, m.equipmentModifier("FINE"))
+	assert.Nil(t, m.spell("Fireball"))
+
+	empty := newLibraryMatcher(nil)
+	assert.Nil(t, empty.trait("Combat Reflexes"))
+	assert.Nil(t, empty.skill("Guns", ""))
+}
+
+func TestImportGCA5Matching(t *testing.T) {
+	report := NewReport("test.gca5")
+	result, err := importGCA5([]byte(`<?xml version="1.0"?>
+<root>
+  <character>
+    <name>Test Subject</name>
+    <traits>
+      <advantages>
+        <trait type="Advantages"><name>Combat Reflexes</name><points>15</points></trait>
+        <trait type="Advantages"><name>Danger Sense</name><points>15</points><page>B47</page></trait>
+      </advantages>
+      <skills>
+        <trait type="Skills"><name>Guns</name><nameext>Pistol</nameext><points>2</points></trait>
+        <trait type="Skills"><name>Knot-Tying</name><points>1</points><type>DX/E</type></trait>
+      </skills>
+      <equipment>
+        <trait type="Equipment">
+          <name>Backpack</name><nameext>Small</nameext>
+          <modifiers>
+            <modifier><name>Fine</name></modifier>
+            <modifier><name>Waterproof</name><value>*2</value><page>LT12</page></modifier>
+          </modifiers>
+        </trait>
+      </equipment>
+    </traits>
+  </character>
+</root>`), report, newTestLibraries(t))
+	require.NoError(t, err)
+	e := result.Entity
+
+	require.Len(t, e.Traits, 2)
+	assert.Equal(t, "B43", e.Traits[0].PageRef, "matched traits are copied from the library")
+	assert.NotContains(t, e.Traits[0].Tags, ReviewTag)
+	assert.Equal(t, "Danger Sense", e.Traits[1].Name)
+	assert.Equal(t, "B47", e.Traits[1].PageRef)
+	assert.Contains(t, e.Traits[1].Tags, ReviewTag)
+
+	require.Len(t, e.Skills, 2)
+	assert.Equal(t, "B198", e.Skills[0].PageRef)
+	assert.Equal(t, "Pistol", e.Skills[0].Specialization, "the GCA specialization replaces the library's empty one")
+	assert.NotContains(t, e.Skills[0].Tags, ReviewTag)
+	assert.Contains(t, e.Skills[1].Tags, ReviewTag)
+
+	require.Len(t, e.CarriedEquipment, 1)
+	eqp := e.CarriedEquipment[0]
+	assert.Equal(t, "B288", eqp.PageRef, "equipment is matched using its name extension")
+	require.Len(t, eqp.Modifiers, 2)
+	assert.Equal(t, "B274", eqp.Modifiers[0].PageRef)
+	assert.NotContains(t, eqp.Modifiers[0].Tags, ReviewTag)
+	assert.Equal(t, "Waterproof", eqp.Modifiers[1].Name, "unmatched modifiers are kept")
+	assert.Equal(t, "LT12", eqp.Modifiers[1].PageRef)
+	assert.Equal(t, "x2", eqp.Modifiers[1].CostAmount)
+	assert.Contains(t, eqp.Modifiers[1].Tags, ReviewTag)
+}
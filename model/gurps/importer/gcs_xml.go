@@ -0,0 +1,952 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package importer
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/attribute"
+	"github.com/richardwilkes/gcs/v5/model/gurps/datafile"
+	"github.com/richardwilkes/gcs/v5/model/gurps/equipment"
+	"github.com/richardwilkes/gcs/v5/model/gurps/feature"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/model/gurps/measure"
+	"github.com/richardwilkes/gcs/v5/model/gurps/skill"
+	"github.com/richardwilkes/gcs/v5/model/gurps/trait"
+	"github.com/richardwilkes/gcs/v5/model/gurps/weapon"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/rpgtools/dice"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/i18n"
+)
+
+// gcsXMLImporter converts the XML formats written by the Java versions of GCS prior to 4.20.
+type gcsXMLImporter struct {
+	report *Report
+	entity *gurps.Entity
+}
+
+func importGCSXML(data []byte, report *Report) (*Result, error) {
+	root, err := parseXML(data)
+	if err != nil {
+		return nil, errs.NewWithCause(i18n.Text("unable to parse XML"), err)
+	}
+	x := &gcsXMLImporter{report: report}
+	r := &Result{Report: report}
+	switch root.Name {
+	case "character":
+		r.Entity = x.character(root)
+		r.ext = library.SheetExt
+	case "template":
+		r.Template = x.template(root)
+		r.ext = library.TemplatesExt
+	case "advantage_list":
+		r.Traits = x.traits(root, nil)
+		r.ext = library.TraitsExt
+	case "modifier_list", "advantage_modifier_list":
+		r.TraitModifiers = x.traitModifiers(root, nil)
+		r.ext = library.TraitModifiersExt
+	case "skill_list":
+		r.Skills = x.skills(root, nil)
+		r.ext = library.SkillsExt
+	case "spell_list":
+		r.Spells = x.spells(root, nil)
+		r.ext = library.SpellsExt
+	case "equipment_list":
+		r.Equipment = x.equipment(root, nil, false)
+		r.ext = library.EquipmentExt
+	case "eqp_modifier_list":
+		r.EquipmentModifiers = x.equipmentModifiers(root, nil)
+		r.ext = library.EquipmentModifiersExt
+	case "note_list":
+		r.Notes = x.notes(root, nil)
+		r.ext = library.NotesExt
+	default:
+		return nil, errs.Newf(i18n.Text("unrecognized legacy data: <%s>"), root.Name)
+	}
+	return r, nil
+}
+
+func (x *gcsXMLImporter) character(root *xmlNode) *gurps.Entity {
+	e := gurps.NewEntity(datafile.PC)
+	e.Profile = &gurps.Profile{}
+	e.Traits = nil
+	x.entity = e
+	e.TotalPoints = fxp.From(0)
+	for _, child := range root.Children {
+		switch strings.ToLower(child.Name) {
+		case "profile":
+			x.profile(child)
+		case "total_points":
+			e.TotalPoints = parseNumber(child.Text)
+		case "st", "strength":
+			x.attributeValue(gid.Strength, child.Text)
+		case "dx", "dexterity":
+			x.attributeValue(gid.Dexterity, child.Text)
+		case "iq", "intelligence":
+			x.attributeValue(gid.Intelligence, child.Text)
+		case "ht", "health":
+			x.attributeValue(gid.Health, child.Text)
+		case "will":
+			x.attributeAdjustment(gid.Will, child.Text)
+		case "perception", "per":
+			x.attributeAdjustment(gid.Perception, child.Text)
+		case "hp":
+			x.attributeAdjustment(gid.HitPoints, child.Text)
+		case "fp":
+			x.attributeAdjustment(gid.FatiguePoints, child.Text)
+		case "speed", "basic_speed":
+			x.attributeAdjustment(gid.BasicSpeed, child.Text)
+		case "move", "basic_move":
+			x.attributeAdjustment(gid.BasicMove, child.Text)
+		case "hp_damage":
+			x.attributeDamage(gid.HitPoints, child.Text)
+		case "fp_damage":
+			x.attributeDamage(gid.FatiguePoints, child.Text)
+		case "advantage_list":
+			e.Traits = x.traits(child, nil)
+		case "skill_list":
+			e.Skills = x.skills(child, nil)
+		case "spell_list":
+			e.Spells = x.spells(child, nil)
+		case "equipment_list":
+			x.characterEquipment(child)
+		case "other_equipment_list":
+			e.OtherEquipment = append(e.OtherEquipment, x.equipment(child, nil, false)...)
+		case "note_list":
+			e.Notes = append(e.Notes, x.notes(child, nil)...)
+		case "created_date", "modified_date", "include_punch", "include_kick", "include_kick_with_boots",
+			"print_settings", "settings":
+			// Not carried forward; these are either recomputed or replaced by the current defaults.
+		default:
+			x.report.Addf(i18n.Text("character data <%s>"), child.Name)
+		}
+	}
+	e.Recalculate()
+	return e
+}
+
+func (x *gcsXMLImporter) characterEquipment(list *xmlNode) {
+	// Older files held only carried equipment in this list. Later ones mark items that weren't carried with a state
+	// attribute instead of using a separate list.
+	for _, row := range x.equipment(list, nil, true) {
+		if row.Quantity < 0 {
+			row.Quantity = -row.Quantity
+			x.entity.OtherEquipment = append(x.entity.OtherEquipment, row)
+		} else {
+			x.entity.CarriedEquipment = append(x.entity.CarriedEquipment, row)
+		}
+	}
+}
+
+func (x *gcsXMLImporter) profile(node *xmlNode) {
+	p := x.entity.Profile
+	for _, child := range node.Children {
+		text := strings.TrimSpace(child.Text)
+		switch child.Name {
+		case "player_name":
+			p.PlayerName = text
+		case "name":
+			p.Name = text
+		case "title":
+			p.Title = text
+		case "organization":
+			p.Organization = text
+		case "religion":
+			p.Religion = text
+		case "age":
+			p.Age = text
+		case "birthday":
+			p.Birthday = text
+		case "eyes":
+			p.Eyes = text
+		case "hair":
+			p.Hair = text
+		case "skin":
+			p.Skin = text
+		case "handedness":
+			p.Handedness = text
+		case "gender":
+			p.Gender = text
+		case "tech_level":
+			p.TechLevel = text
+		case "height":
+			p.Height = measure.LengthFromStringForced(text, x.entity.SheetSettings.DefaultLengthUnits)
+		case "weight":
+			p.Weight = measure.WeightFromStringForced(text, x.entity.SheetSettings.DefaultWeightUnits)
+		case "size_modifier", "SM":
+			p.SizeModifier, _ = strconv.Atoi(text) //nolint:errcheck // Default to 0 on error
+		case "portrait":
+			if text != "" {
+				if data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), "")); err == nil {
+					p.PortraitData = data
+				} else {
+					x.report.Addf(i18n.Text("portrait (unreadable image data)"))
+				}
+			}
+		case "notes":
+			if text != "" {
+				note := gurps.NewNote(x.entity, nil, false)
+				note.Text = text
+				x.entity.Notes = append(x.entity.Notes, note)
+			}
+		case "race":
+			if text != "" && !strings.EqualFold(text, "human") {
+				x.report.Addf(i18n.Text("race \"%s\" (add the matching ancestry trait)"), text)
+			}
+		case "body_type":
+			if text != "" && !strings.EqualFold(text, "humanoid") {
+				x.report.Addf(i18n.Text("body type \"%s\""), text)
+			}
+		default:
+			if text != "" {
+				x.report.Addf(i18n.Text("profile data <%s>"), child.Name)
+			}
+		}
+	}
+}
+
+func (x *gcsXMLImporter) attributeValue(attrID, text string) {
+	if attr, ok := x.entity.Attributes.Set[attrID]; ok {
+		attr.SetMaximum(parseNumber(text))
+	}
+}
+
+func (x *gcsXMLImporter) attributeAdjustment(attrID, text string) {
+	if attr, ok := x.entity.Attributes.Set[attrID]; ok {
+		attr.Adjustment = parseNumber(text)
+	}
+}
+
+func (x *gcsXMLImporter) attributeDamage(attrID, text string) {
+	if attr, ok := x.entity.Attributes.Set[attrID]; ok {
+		attr.Damage = parseNumber(text)
+	}
+}
+
+func (x *gcsXMLImporter) template(root *xmlNode) *gurps.Template {
+	t := gurps.NewTemplate()
+	for _, child := range root.Children {
+		switch child.Name {
+		case "advantage_list":
+			t.Traits = x.traits(child, nil)
+		case "skill_list":
+			t.Skills = x.skills(child, nil)
+		case "spell_list":
+			t.Spells = x.spells(child, nil)
+		case "equipment_list":
+			t.Equipment = x.equipment(child, nil, false)
+		case "note_list":
+			t.Notes = x.notes(child, nil)
+		case "notes":
+			if text := strings.TrimSpace(child.Text); text != "" {
+				note := gurps.NewNote(nil, nil, false)
+				note.Text = text
+				t.Notes = append(t.Notes, note)
+			}
+		default:
+			x.report.Addf(i18n.Text("template data <%s>"), child.Name)
+		}
+	}
+	return t
+}
+
+func (x *gcsXMLImporter) traits(list *xmlNode, parent *gurps.Trait) []*gurps.Trait {
+	var rows []*gurps.Trait
+	for _, node := range list.Children {
+		var t *gurps.Trait
+		switch node.Name {
+		case "advantage":
+			t = gurps.NewTrait(x.entity, parent, false)
+		case "advantage_container":
+			t = gurps.NewTrait(x.entity, parent, true)
+			t.ContainerType = trait.ExtractContainerType(node.attr("type"))
+			t.IsOpen = node.flag("open")
+		default:
+			if parent != nil {
+				continue // Handled by the field loop below
+			}
+			x.report.Addf(i18n.Text("trait list entry <%s>"), node.Name)
+			continue
+		}
+		t.Disabled = node.attr("enabled") == "no" || node.flag("disabled")
+		t.RoundCostDown = node.flag("round_down")
+		for _, child := range node.Children {
+			text := strings.TrimSpace(child.Text)
+			switch child.Name {
+			case "name":
+				t.Name = text
+			case "notes":
+				t.LocalNotes = text
+			case "reference":
+				t.PageRef = text
+			case "userdesc":
+				t.UserDesc = text
+			case "base_points":
+				t.BasePoints = parseNumber(text)
+			case "levels":
+				t.Levels = parseNumber(text)
+			case "points_per_level":
+				t.PointsPerLevel = parseNumber(text)
+			case "points":
+				// Very old files stored a single points value
+				t.BasePoints = parseNumber(text)
+			case "type":
+				for _, one := range strings.Split(text, ",") {
+					if one = strings.TrimSpace(one); one != "" && !strings.EqualFold(one, "none") {
+						t.Tags = appendTag(t.Tags, one)
+					}
+				}
+			case "categories":
+				t.Tags = x.tags(child, t.Tags)
+			case "cr":
+				t.CR = trait.SelfControlRoll(fxp.As[int](parseNumber(text)))
+				if adj := child.attr("adj"); adj != "" {
+					t.CRAdj = gurps.ExtractSelfControlRollAdj(adj)
+				}
+			case "cr_adj":
+				t.CRAdj = gurps.ExtractSelfControlRollAdj(text)
+			case "modifier":
+				t.Modifiers = append(t.Modifiers, x.traitModifier(child, nil))
+			case "melee_weapon", "ranged_weapon":
+				if w := x.weapon(child, t); w != nil {
+					t.Weapons = append(t.Weapons, w)
+				}
+			case "advantage", "advantage_container":
+				// Children are handled below
+			case "prereq_list":
+				x.report.Addf(i18n.Text("prerequisites of trait \"%s\""), t.Name)
+			default:
+				if f := x.feature(child, t.Name); f != nil {
+					t.Features = append(t.Features, f)
+				}
+			}
+		}
+		if t.Container() {
+			t.Children = x.traits(node, t)
+		}
+		rows = append(rows, t)
+	}
+	return rows
+}
+
+func (x *gcsXMLImporter) traitModifiers(list *xmlNode, parent *gurps.TraitModifier) []*gurps.TraitModifier {
+	var rows []*gurps.TraitModifier
+	for _, node := range list.Children {
+		switch node.Name {
+		case "modifier":
+			rows = append(rows, x.traitModifier(node, parent))
+		case "modifier_container":
+			m := gurps.NewTraitModifier(x.entity, parent, true)
+			m.Name = node.text("name")
+			m.LocalNotes = node.text("notes")
+			m.PageRef = node.text("reference")
+			m.Children = x.traitModifiers(node, m)
+			rows = append(rows, m)
+		case "name", "notes", "reference", "categories":
+		default:
+			x.report.Addf(i18n.Text("trait modifier list entry <%s>"), node.Name)
+		}
+	}
+	return rows
+}
+
+func (x *gcsXMLImporter) traitModifier(node *xmlNode, parent *gurps.TraitModifier) *gurps.TraitModifier {
+	m := gurps.NewTraitModifier(x.entity, parent, false)
+	m.Disabled = node.attr("enabled") == "no" || node.flag("disabled")
+	for _, child := range node.Children {
+		text := strings.TrimSpace(child.Text)
+		switch child.Name {
+		case "name":
+			m.Name = text
+		case "notes":
+			m.LocalNotes = text
+		case "reference":
+			m.PageRef = text
+		case "cost":
+			m.Cost = parseNumber(text)
+			if costType := child.attr("type"); costType != "" {
+				m.CostType = trait.ExtractModifierCostType(costType)
+			}
+		case "levels":
+			m.Levels = parseNumber(text)
+		case "affects":
+			m.Affects = trait.ExtractAffects(text)
+		case "categories":
+			m.Tags = x.tags(child, m.Tags)
+		default:
+			if f := x.feature(child, m.Name); f != nil {
+				m.Features = append(m.Features, f)
+			}
+		}
+	}
+	return m
+}
+
+func (x *gcsXMLImporter) skills(list *xmlNode, parent *gurps.Skill) []*gurps.Skill {
+	var rows []*gurps.Skill
+	for _, node := range list.Children {
+		var s *gurps.Skill
+		switch node.Name {
+		case "skill":
+			s = gurps.NewSkill(x.entity, parent, false)
+		case "technique":
+			s = gurps.NewTechnique(x.entity, parent, "")
+			if limit := node.attr("limit"); limit != "" {
+				v := parseNumber(limit)
+				s.TechniqueLimitModifier = &v
+			}
+		case "skill_container":
+			s = gurps.NewSkill(x.entity, parent, true)
+			s.IsOpen = node.flag("open")
+		default:
+			if parent == nil {
+				x.report.Addf(i18n.Text("skill list entry <%s>"), node.Name)
+			}
+			continue
+		}
+		for _, child := range node.Children {
+			text := strings.TrimSpace(child.Text)
+			switch child.Name {
+			case "name":
+				s.Name = text
+			case "specialization":
+				s.Specialization = text
+			case "tech_level":
+				tl := text
+				s.TechLevel = &tl
+			case "difficulty":
+				setDifficulty(x.entity, &s.Difficulty, text, gid.Dexterity)
+			case "points":
+				s.Points = parseNumber(text)
+			case "reference":
+				s.PageRef = text
+			case "notes":
+				s.LocalNotes = text
+			case "encumbrance_penalty_multiplier":
+				s.EncumbrancePenaltyMultiplier = parseNumber(text)
+			case "categories":
+				s.Tags = x.tags(child, s.Tags)
+			case "default":
+				def := x.skillDefault(child)
+				if s.Type == gid.Technique {
+					s.TechniqueDefault = def
+				} else {
+					s.Defaults = append(s.Defaults, def)
+				}
+			case "melee_weapon", "ranged_weapon":
+				if w := x.weapon(child, s); w != nil {
+					s.Weapons = append(s.Weapons, w)
+				}
+			case "skill", "technique", "skill_container":
+			case "prereq_list":
+				x.report.Addf(i18n.Text("prerequisites of skill \"%s\""), s.Name)
+			default:
+				if f := x.feature(child, s.Name); f != nil {
+					s.Features = append(s.Features, f)
+				}
+			}
+		}
+		if s.Container() {
+			s.Children = x.skills(node, s)
+		}
+		rows = append(rows, s)
+	}
+	return rows
+}
+
+func (x *gcsXMLImporter) spells(list *xmlNode, parent *gurps.Spell) []*gurps.Spell {
+	var rows []*gurps.Spell
+	for _, node := range list.Children {
+		var s *gurps.Spell
+		switch node.Name {
+		case "spell":
+			s = gurps.NewSpell(x.entity, parent, false)
+			if node.flag("very_hard") {
+				s.Difficulty.Difficulty = skill.VeryHard
+			}
+		case "ritual_magic_spell":
+			s = gurps.NewRitualMagicSpell(x.entity, parent, false)
+			if base := node.attr("base_skill"); base != "" {
+				s.RitualSkillName = base
+			}
+			if count := node.attr("prereq_count"); count != "" {
+				s.RitualPrereqCount = fxp.As[int](parseNumber(count))
+			}
+		case "spell_container":
+			s = gurps.NewSpell(x.entity, parent, true)
+			s.IsOpen = node.flag("open")
+		default:
+			if parent == nil {
+				x.report.Addf(i18n.Text("spell list entry <%s>"), node.Name)
+			}
+			continue
+		}
+		for _, child := range node.Children {
+			text := strings.TrimSpace(child.Text)
+			switch child.Name {
+			case "name":
+				s.Name = text
+			case "tech_level":
+				tl := text
+				s.TechLevel = &tl
+			case "difficulty":
+				setDifficulty(x.entity, &s.Difficulty, text, gid.Intelligence)
+			case "college":
+				s.College = nil
+				for _, one := range strings.Split(text, "/") {
+					if one = strings.TrimSpace(one); one != "" {
+						s.College = append(s.College, one)
+					}
+				}
+			case "power_source":
+				s.PowerSource = text
+			case "spell_class":
+				s.Class = text
+			case "resist":
+				s.Resist = text
+			case "casting_cost":
+				s.CastingCost = text
+			case "maintenance_cost":
+				s.MaintenanceCost = text
+			case "casting_time":
+				s.CastingTime = text
+			case "duration":
+				s.Duration = text
+			case "points":
+				s.Points = parseNumber(text)
+			case "reference":
+				s.PageRef = text
+			case "notes":
+				s.LocalNotes = text
+			case "categories":
+				s.Tags = x.tags(child, s.Tags)
+			case "melee_weapon", "ranged_weapon":
+				if w := x.weapon(child, s); w != nil {
+					s.Weapons = append(s.Weapons, w)
+				}
+			case "spell", "ritual_magic_spell", "spell_container":
+			case "prereq_list":
+				x.report.Addf(i18n.Text("prerequisites of spell \"%s\""), s.Name)
+			default:
+				x.report.Addf(i18n.Text("spell data <%s>"), child.Name)
+			}
+		}
+		if s.Container() {
+			s.Children = x.spells(node, s)
+		}
+		rows = append(rows, s)
+	}
+	return rows
+}
+
+// equipment converts an equipment list. Items that the data marks as not carried are returned with a negated quantity
+// when carried is true, so that the caller can move them to the other equipment list.
+func (x *gcsXMLImporter) equipment(list *xmlNode, parent *gurps.Equipment, carried bool) []*gurps.Equipment {
+	var rows []*gurps.Equipment
+	for _, node := range list.Children {
+		var eqp *gurps.Equipment
+		switch node.Name {
+		case "equipment":
+			eqp = gurps.NewEquipment(x.entity, parent, false)
+		case "equipment_container":
+			eqp = gurps.NewEquipment(x.entity, parent, true)
+			eqp.IsOpen = node.flag("open")
+		default:
+			if parent == nil {
+				x.report.Addf(i18n.Text("equipment list entry <%s>"), node.Name)
+			}
+			continue
+		}
+		notCarried := false
+		switch strings.ToLower(node.attr("state")) {
+		case "equipped":
+			eqp.Equipped = true
+		case "carried":
+			eqp.Equipped = false
+		case "not carried":
+			eqp.Equipped = false
+			notCarried = true
+		default:
+			eqp.Equipped = node.Attrs["equipped"] == "" || node.flag("equipped")
+		}
+		eqp.Quantity = fxp.One
+		for _, child := range node.Children {
+			text := strings.TrimSpace(child.Text)
+			switch child.Name {
+			case "description", "name":
+				eqp.Name = text
+			case "quantity":
+				eqp.Quantity = parseNumber(text)
+			case "tech_level":
+				eqp.TechLevel = text
+			case "legality_class":
+				eqp.LegalityClass = text
+			case "value":
+				eqp.Value = parseNumber(text)
+			case "weight":
+				eqp.Weight = measure.WeightFromStringForced(text, measure.Pound)
+			case "reference":
+				eqp.PageRef = text
+			case "notes":
+				eqp.LocalNotes = text
+			case "uses":
+				eqp.Uses = fxp.As[int](parseNumber(text))
+			case "max_uses":
+				eqp.MaxUses = fxp.As[int](parseNumber(text))
+			case "ignore_weight_for_skills":
+				eqp.WeightIgnoredForSkills = strings.EqualFold(text, "yes") || strings.EqualFold(text, "true")
+			case "categories":
+				eqp.Tags = x.tags(child, eqp.Tags)
+			case "eqp_modifier":
+				eqp.Modifiers = append(eqp.Modifiers, x.equipmentModifier(child, nil))
+			case "melee_weapon", "ranged_weapon":
+				if w := x.weapon(child, eqp); w != nil {
+					eqp.Weapons = append(eqp.Weapons, w)
+				}
+			case "equipment", "equipment_container":
+			case "prereq_list":
+				x.report.Addf(i18n.Text("prerequisites of equipment \"%s\""), eqp.Name)
+			default:
+				if f := x.feature(child, eqp.Name); f != nil {
+					eqp.Features = append(eqp.Features, f)
+				}
+			}
+		}
+		if eqp.Container() {
+			eqp.Children = x.equipment(node, eqp, false)
+		}
+		if carried && notCarried && parent == nil {
+			eqp.Quantity = -eqp.Quantity
+		}
+		rows = append(rows, eqp)
+	}
+	return rows
+}
+
+func (x *gcsXMLImporter) equipmentModifiers(list *xmlNode, parent *gurps.EquipmentModifier) []*gurps.EquipmentModifier {
+	var rows []*gurps.EquipmentModifier
+	for _, node := range list.Children {
+		switch node.Name {
+		case "eqp_modifier":
+			rows = append(rows, x.equipmentModifier(node, parent))
+		case "eqp_modifier_container":
+			m := gurps.NewEquipmentModifier(x.entity, parent, true)
+			m.Name = node.text("name")
+			m.LocalNotes = node.text("notes")
+			m.PageRef = node.text("reference")
+			m.Children = x.equipmentModifiers(node, m)
+			rows = append(rows, m)
+		case "name", "notes", "reference", "categories":
+		default:
+			x.report.Addf(i18n.Text("equipment modifier list entry <%s>"), node.Name)
+		}
+	}
+	return rows
+}
+
+func (x *gcsXMLImporter) equipmentModifier(node *xmlNode, parent *gurps.EquipmentModifier) *gurps.EquipmentModifier {
+	m := gurps.NewEquipmentModifier(x.entity, parent, false)
+	m.Disabled = node.attr("enabled") == "no" || node.flag("disabled")
+	for _, child := range node.Children {
+		text := strings.TrimSpace(child.Text)
+		switch child.Name {
+		case "name":
+			m.Name = text
+		case "notes":
+			m.LocalNotes = text
+		case "reference":
+			m.PageRef = text
+		case "tech_level":
+			m.TechLevel = text
+		case "cost":
+			m.CostAmount = text
+			if costType := child.attr("type"); costType != "" {
+				m.CostType = equipment.ExtractModifierCostType(strings.ReplaceAll(costType, " ", "_"))
+			}
+		case "weight":
+			m.WeightAmount = text
+			if weightType := child.attr("type"); weightType != "" {
+				m.WeightType = equipment.ExtractModifierWeightType(strings.ReplaceAll(weightType, " ", "_"))
+			}
+		case "categories":
+			m.Tags = x.tags(child, m.Tags)
+		default:
+			if f := x.feature(child, m.Name); f != nil {
+				m.Features = append(m.Features, f)
+			}
+		}
+	}
+	return m
+}
+
+func (x *gcsXMLImporter) notes(list *xmlNode, parent *gurps.Note) []*gurps.Note {
+	var rows []*gurps.Note
+	for _, node := range list.Children {
+		var n *gurps.Note
+		switch node.Name {
+		case "note":
+			n = gurps.NewNote(x.entity, parent, false)
+		case "note_container":
+			n = gurps.NewNote(x.entity, parent, true)
+			n.IsOpen = node.flag("open")
+		default:
+			if parent == nil {
+				x.report.Addf(i18n.Text("note list entry <%s>"), node.Name)
+			}
+			continue
+		}
+		if textNode := node.child("text"); textNode != nil {
+			n.Text = strings.TrimSpace(textNode.Text)
+		} else {
+			n.Text = strings.TrimSpace(node.Text)
+		}
+		n.PageRef = node.text("reference")
+		if n.Container() {
+			n.Children = x.notes(node, n)
+		}
+		rows = append(rows, n)
+	}
+	return rows
+}
+
+func (x *gcsXMLImporter) tags(node *xmlNode, tags []string) []string {
+	for _, child := range node.Children {
+		if child.Name == "category" {
+			if text := strings.TrimSpace(child.Text); text != "" {
+				tags = appendTag(tags, text)
+			}
+		}
+	}
+	return tags
+}
+
+// setDifficulty interprets difficulty text, e.g. "DX/A", or just "A" when the attribute is implied.
+func setDifficulty(entity *gurps.Entity, diff *gurps.AttributeDifficulty, text, defAttr string) {
+	parts := strings.SplitN(text, "/", 2)
+	if len(parts) == 2 {
+		diff.Attribute = legacyAttributeID(strings.TrimSpace(parts[0]))
+		diff.Difficulty = skill.ExtractDifficulty(strings.TrimSpace(parts[1]))
+	} else {
+		diff.Attribute = defAttr
+		if text = strings.TrimSpace(text); text != "" {
+			diff.Difficulty = skill.ExtractDifficulty(text)
+		}
+	}
+	diff.Normalize(entity)
+}
+
+func (x *gcsXMLImporter) skillDefault(node *xmlNode) *gurps.SkillDefault {
+	def := &gurps.SkillDefault{
+		DefaultType:    strings.ToLower(node.text("type")),
+		Name:           node.text("name"),
+		Specialization: node.text("specialization"),
+		Modifier:       node.number("modifier"),
+	}
+	if def.DefaultType == "" {
+		def.DefaultType = gid.Skill
+	}
+	return def
+}
+
+func (x *gcsXMLImporter) weapon(node *xmlNode, owner gurps.WeaponOwner) *gurps.Weapon {
+	weaponType := weapon.Melee
+	if node.Name == "ranged_weapon" {
+		weaponType = weapon.Ranged
+	}
+	w := gurps.NewWeapon(owner, weaponType)
+	for _, child := range node.Children {
+		text := strings.TrimSpace(child.Text)
+		switch child.Name {
+		case "damage":
+			x.weaponDamage(&w.Damage, child)
+		case "strength":
+			w.MinimumStrength = text
+		case "usage":
+			w.Usage = text
+		case "usage_notes":
+			w.UsageNotes = text
+		case "reach":
+			w.Reach = text
+		case "parry":
+			w.Parry = text
+		case "block":
+			w.Block = text
+		case "accuracy":
+			w.Accuracy = text
+		case "range":
+			w.Range = text
+		case "rate_of_fire":
+			w.RateOfFire = text
+		case "shots":
+			w.Shots = text
+		case "bulk":
+			w.Bulk = text
+		case "recoil":
+			w.Recoil = text
+		case "default":
+			w.Defaults = append(w.Defaults, x.skillDefault(child))
+		default:
+			x.report.Addf(i18n.Text("weapon data <%s>"), child.Name)
+		}
+	}
+	return w
+}
+
+// weaponDamage handles both the attribute form used by later XML files (type, st & base attributes) and the free-form
+// text used by earlier ones, e.g. "sw+2 cut".
+func (x *gcsXMLImporter) weaponDamage(damage *gurps.WeaponDamage, node *xmlNode) {
+	damage.StrengthType = weapon.None
+	damage.Base = nil
+	if len(node.Attrs) != 0 {
+		damage.Type = node.attr("type")
+		damage.StrengthType = weapon.ExtractStrengthDamage(node.attr("st"))
+		if base := node.attr("base"); base != "" {
+			damage.Base = dice.New(base)
+		}
+		if divisor := node.attr("armor_divisor"); divisor != "" {
+			damage.ArmorDivisor = parseNumber(divisor)
+		}
+		return
+	}
+	fields := strings.Fields(strings.TrimSpace(node.Text))
+	if len(fields) == 0 {
+		return
+	}
+	spec := strings.ToLower(fields[0])
+	switch {
+	case strings.HasPrefix(spec, "sw"):
+		damage.StrengthType = weapon.Swing
+		spec = spec[2:]
+	case strings.HasPrefix(spec, "thr"):
+		damage.StrengthType = weapon.Thrust
+		spec = spec[3:]
+	}
+	if spec != "" {
+		damage.Base = dice.New(spec)
+	}
+	var remaining []string
+	for _, one := range fields[1:] {
+		if strings.HasPrefix(one, "(") && strings.HasSuffix(one, ")") {
+			if divisor := parseNumber(strings.Trim(one, "()")); divisor > 0 {
+				damage.ArmorDivisor = divisor
+				continue
+			}
+		}
+		remaining = append(remaining, one)
+	}
+	damage.Type = strings.Join(remaining, " ")
+}
+
+// feature converts the feature types that map directly onto current ones. Anything else is reported.
+func (x *gcsXMLImporter) feature(node *xmlNode, owner string) feature.Feature {
+	switch node.Name {
+	case "attribute_bonus":
+		attrNode := node.child("attribute")
+		if attrNode == nil {
+			break
+		}
+		bonus := feature.NewAttributeBonus(legacyAttributeID(strings.TrimSpace(attrNode.Text)))
+		if limitation := attrNode.attr("limitation"); limitation != "" {
+			bonus.Limitation = attribute.ExtractBonusLimitation(limitation)
+		}
+		x.leveledAmount(&bonus.LeveledAmount, node.child("amount"))
+		return bonus
+	case "dr_bonus":
+		bonus := feature.NewDRBonus()
+		if location := node.text("location"); location != "" {
+			bonus.Location = strings.ToLower(location)
+		}
+		x.leveledAmount(&bonus.LeveledAmount, node.child("amount"))
+		return bonus
+	case "reaction_bonus":
+		bonus := feature.NewReactionBonus()
+		bonus.Situation = node.text("situation")
+		x.leveledAmount(&bonus.LeveledAmount, node.child("amount"))
+		return bonus
+	case "conditional_modifier":
+		bonus := feature.NewConditionalModifierBonus()
+		bonus.Situation = node.text("situation")
+		x.leveledAmount(&bonus.LeveledAmount, node.child("amount"))
+		return bonus
+	}
+	if owner == "" {
+		x.report.Addf(i18n.Text("<%s>"), node.Name)
+	} else {
+		x.report.Addf(i18n.Text("<%s> of \"%s\""), node.Name, owner)
+	}
+	return nil
+}
+
+func (x *gcsXMLImporter) leveledAmount(amount *feature.LeveledAmount, node *xmlNode) {
+	if node != nil {
+		amount.Amount = parseNumber(node.Text)
+		amount.PerLevel = node.flag("per_level")
+	}
+}
+
+// legacyAttributeID maps the attribute names used by the old bonus data onto current attribute IDs.
+func legacyAttributeID(name string) string {
+	switch strings.ToLower(name) {
+	case "st", "strength":
+		return gid.Strength
+	case "dx", "dexterity":
+		return gid.Dexterity
+	case "iq", "intelligence":
+		return gid.Intelligence
+	case "ht", "health":
+		return gid.Health
+	case "will":
+		return gid.Will
+	case "perception", "per":
+		return gid.Perception
+	case "vision":
+		return gid.Vision
+	case "hearing":
+		return gid.Hearing
+	case "taste_smell":
+		return gid.TasteSmell
+	case "touch":
+		return gid.Touch
+	case "dodge":
+		return "dodge"
+	case "parry":
+		return "parry"
+	case "block":
+		return "block"
+	case "speed", "basic_speed":
+		return gid.BasicSpeed
+	case "move", "basic_move":
+		return gid.BasicMove
+	case "fp":
+		return gid.FatiguePoints
+	case "hp":
+		return gid.HitPoints
+	case "sm", "size_modifier":
+		return gid.SizeModifier
+	default:
+		return strings.ToLower(name)
+	}
+}
+
+func appendTag(tags []string, tag string) []string {
+	for _, one := range tags {
+		if strings.EqualFold(one, tag) {
+			return tags
+		}
+	}
+	return append(tags, tag)
+}
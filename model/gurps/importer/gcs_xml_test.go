@@ -0,0 +1,338 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package importer
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/model/gurps/skill"
+	"github.com/richardwilkes/gcs/v5/model/gurps/weapon"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/gcs/v5/model/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func importXML(t *testing.T, data string) *Result {
+	t.Helper()
+	settings.Global()
+	result, err := Import(fstest.MapFS{"test.gcs": &fstest.MapFile{Data: []byte(data)}}, "test.gcs")
+	require.NoError(t, err)
+	return result
+}
+
+func TestImportGCSXMLCharacter(t *testing.T) {
+	result := importXML(t, `<?xml version="1.0" encoding="UTF-8"?>
+<character version="3">
+	<profile>
+		<name>Test Subject</name>
+		<player_name>Someone</player_name>
+		<tech_level>3</tech_level>
+		<notes>Profile notes</notes>
+	</profile>
+	<total_points>100</total_points>
+	<ST>12</ST>
+	<HP>2</HP>
+	<hp_damage>3</hp_damage>
+	<advantage_list>
+		<advantage_container type="Group" open="yes">
+			<name>Mental</name>
+			<advantage>
+				<name>Combat Reflexes</name>
+				<base_points>15</base_points>
+				<reference>B43</reference>
+			</advantage>
+		</advantage_container>
+		<advantage>
+			<name>Striking ST</name>
+			<levels>2</levels>
+			<points_per_level>5</points_per_level>
+		</advantage>
+	</advantage_list>
+	<skill_list>
+		<skill>
+			<name>Broadsword</name>
+			<difficulty>DX/A</difficulty>
+			<points>4</points>
+			<melee_weapon>
+				<damage>sw+1 cut</damage>
+				<usage>Swung</usage>
+				<reach>1</reach>
+				<parry>0</parry>
+			</melee_weapon>
+		</skill>
+	</skill_list>
+	<equipment_list>
+		<equipment_container state="equipped">
+			<description>Backpack</description>
+			<equipment state="carried">
+				<description>Rope</description>
+				<quantity>2</quantity>
+			</equipment>
+		</equipment_container>
+		<equipment state="not carried">
+			<description>Tent</description>
+		</equipment>
+	</equipment_list>
+	<note_list>
+		<note><text>A note</text></note>
+	</note_list>
+	<mystery>?</mystery>
+</character>`)
+	assert.Equal(t, library.SheetExt, result.Extension())
+	e := result.Entity
+	require.NotNil(t, e)
+	assert.Equal(t, "Test Subject", e.Profile.Name)
+	assert.Equal(t, "Someone", e.Profile.PlayerName)
+	assert.Equal(t, "3", e.Profile.TechLevel)
+	assert.Equal(t, fxp.From(100), e.TotalPoints)
+	assert.Equal(t, fxp.From(12), e.Attributes.Set[gid.Strength].Maximum())
+	assert.Equal(t, fxp.Two, e.Attributes.Set[gid.HitPoints].Adjustment)
+	assert.Equal(t, fxp.From(3), e.Attributes.Set[gid.HitPoints].Damage)
+
+	require.Len(t, e.Traits, 2)
+	require.True(t, e.Traits[0].Container())
+	assert.Equal(t, "Mental", e.Traits[0].Name)
+	assert.True(t, e.Traits[0].IsOpen)
+	require.Len(t, e.Traits[0].Children, 1)
+	child := e.Traits[0].Children[0]
+	assert.Equal(t, "Combat Reflexes", child.Name)
+	assert.Equal(t, "B43", child.PageRef)
+	assert.Equal(t, fxp.From(15), child.AdjustedPoints())
+	assert.Same(t, e.Traits[0], child.Parent())
+	assert.Equal(t, "Striking ST", e.Traits[1].Name)
+	assert.Equal(t, fxp.Ten, e.Traits[1].AdjustedPoints())
+
+	require.Len(t, e.Skills, 1)
+	s := e.Skills[0]
+	assert.Equal(t, "Broadsword", s.Name)
+	assert.Equal(t, gid.Dexterity, s.Difficulty.Attribute)
+	assert.Equal(t, skill.Average, s.Difficulty.Difficulty)
+	assert.Equal(t, fxp.From(4), s.Points)
+	require.Len(t, s.Weapons, 1)
+	w := s.Weapons[0]
+	assert.Equal(t, weapon.Melee, w.Type)
+	assert.Equal(t, "Swung", w.Usage)
+	assert.Equal(t, "1", w.Reach)
+	assert.Equal(t, weapon.Swing, w.Damage.StrengthType)
+	assert.Equal(t, "cut", w.Damage.Type)
+
+	require.Len(t, e.CarriedEquipment, 1)
+	pack := e.CarriedEquipment[0]
+	assert.Equal(t, "Backpack", pack.Name)
+	assert.True(t, pack.Equipped)
+	require.Len(t, pack.Children, 1)
+	assert.Equal(t, "Rope", pack.Children[0].Name)
+	assert.Equal(t, fxp.Two, pack.Children[0].Quantity)
+	assert.False(t, pack.Children[0].Equipped)
+	assert.Same(t, pack, pack.Children[0].Parent())
+	require.Len(t, e.OtherEquipment, 1)
+	assert.Equal(t, "Tent", e.OtherEquipment[0].Name)
+	assert.Equal(t, fxp.One, e.OtherEquipment[0].Quantity)
+
+	require.Len(t, e.Notes, 2)
+	assert.Equal(t, "Profile notes", e.Notes[0].Text)
+	assert.Equal(t, "A note", e.Notes[1].Text)
+
+	assert.Contains(t, result.Report.Issues(), "character data <mystery>")
+}
+
+func TestImportGCSXMLLists(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		data  string
+		ext   string
+		check func(t *testing.T, r *Result)
+	}{
+		{
+			name: "traits",
+			data: `<advantage_list>
+	<advantage_container open="yes">
+		<name>Perks</name>
+		<advantage><name>Fearlessness</name><levels>3</levels><points_per_level>2</points_per_level></advantage>
+	</advantage_container>
+	<advantage>
+		<name>Innate Attack</name>
+		<base_points>5</base_points>
+		<categories><category>Advantage</category></categories>
+		<ranged_weapon><damage type="burn" base="1d"/><range>10/100</range></ranged_weapon>
+	</advantage>
+</advantage_list>`,
+			ext: library.TraitsExt,
+			check: func(t *testing.T, r *Result) {
+				require.Len(t, r.Traits, 2)
+				require.Len(t, r.Traits[0].Children, 1)
+				assert.Equal(t, "Fearlessness", r.Traits[0].Children[0].Name)
+				assert.Equal(t, fxp.From(6), r.Traits[0].Children[0].AdjustedPoints())
+				assert.Same(t, r.Traits[0], r.Traits[0].Children[0].Parent())
+				assert.Equal(t, []string{"Advantage"}, r.Traits[1].Tags)
+				require.Len(t, r.Traits[1].Weapons, 1)
+				assert.Equal(t, weapon.Ranged, r.Traits[1].Weapons[0].Type)
+				assert.Equal(t, "10/100", r.Traits[1].Weapons[0].Range)
+				assert.Equal(t, "burn", r.Traits[1].Weapons[0].Damage.Type)
+			},
+		},
+		{
+			name: "skills",
+			data: `<skill_list>
+	<skill_container>
+		<name>Combat</name>
+		<skill><name>Guns</name><specialization>Pistol</specialization><tech_level>8</tech_level><difficulty>DX/E</difficulty><points>2</points></skill>
+		<technique limit="0"><name>Off-Hand Weapon Training</name><difficulty>H</difficulty><points>3</points><default><type>Skill</type><name>Guns</name><modifier>-4</modifier></default></technique>
+	</skill_container>
+</skill_list>`,
+			ext: library.SkillsExt,
+			check: func(t *testing.T, r *Result) {
+				require.Len(t, r.Skills, 1)
+				assert.Equal(t, "Combat", r.Skills[0].Name)
+				require.Len(t, r.Skills[0].Children, 2)
+				guns := r.Skills[0].Children[0]
+				assert.Equal(t, "Guns", guns.Name)
+				assert.Equal(t, "Pistol", guns.Specialization)
+				require.NotNil(t, guns.TechLevel)
+				assert.Equal(t, "8", *guns.TechLevel)
+				assert.Equal(t, skill.Easy, guns.Difficulty.Difficulty)
+				assert.Equal(t, fxp.Two, guns.Points)
+				tech := r.Skills[0].Children[1]
+				assert.Equal(t, gid.Technique, tech.Type)
+				assert.Equal(t, fxp.From(3), tech.Points)
+				require.NotNil(t, tech.TechniqueDefault)
+				assert.Equal(t, "Guns", tech.TechniqueDefault.Name)
+				assert.Equal(t, fxp.From(-4), tech.TechniqueDefault.Modifier)
+				require.NotNil(t, tech.TechniqueLimitModifier)
+				assert.Equal(t, fxp.From(0), *tech.TechniqueLimitModifier)
+			},
+		},
+		{
+			name: "spells",
+			data: `<spell_list>
+	<spell_container>
+		<name>Fire</name>
+		<spell very_hard="yes"><name>Fireball</name><college>Fire / Air</college><points>4</points><casting_cost>1 to 3</casting_cost></spell>
+	</spell_container>
+	<ritual_magic_spell base_skill="Ritual Magic"><name>Ignite Fire</name><points>1</points></ritual_magic_spell>
+</spell_list>`,
+			ext: library.SpellsExt,
+			check: func(t *testing.T, r *Result) {
+				require.Len(t, r.Spells, 2)
+				require.Len(t, r.Spells[0].Children, 1)
+				fireball := r.Spells[0].Children[0]
+				assert.Equal(t, "Fireball", fireball.Name)
+				assert.Equal(t, gurps.CollegeList{"Fire", "Air"}, fireball.College)
+				assert.Equal(t, skill.VeryHard, fireball.Difficulty.Difficulty)
+				assert.Equal(t, fxp.From(4), fireball.Points)
+				assert.Equal(t, "1 to 3", fireball.CastingCost)
+				assert.Same(t, r.Spells[0], fireball.Parent())
+				assert.Equal(t, "Ignite Fire", r.Spells[1].Name)
+				assert.Equal(t, "Ritual Magic", r.Spells[1].RitualSkillName)
+			},
+		},
+		{
+			name: "equipment",
+			data: `<equipment_list>
+	<equipment_container>
+		<description>Pouch</description>
+		<equipment>
+			<description>Knife</description>
+			<quantity>3</quantity>
+			<value>40</value>
+			<weight>1 lb</weight>
+			<tech_level>0</tech_level>
+			<legality_class>4</legality_class>
+			<melee_weapon><damage>thr-1 imp</damage><usage>Thrust</usage></melee_weapon>
+			<eqp_modifier><name>Fine</name><cost type="to base cost">+3 CF</cost></eqp_modifier>
+		</equipment>
+	</equipment_container>
+</equipment_list>`,
+			ext: library.EquipmentExt,
+			check: func(t *testing.T, r *Result) {
+				require.Len(t, r.Equipment, 1)
+				assert.Equal(t, "Pouch", r.Equipment[0].Name)
+				require.Len(t, r.Equipment[0].Children, 1)
+				knife := r.Equipment[0].Children[0]
+				assert.Equal(t, "Knife", knife.Name)
+				assert.Equal(t, fxp.From(3), knife.Quantity)
+				assert.Equal(t, fxp.From(40), knife.Value)
+				assert.Equal(t, "0", knife.TechLevel)
+				assert.Same(t, r.Equipment[0], knife.Parent())
+				require.Len(t, knife.Weapons, 1)
+				assert.Equal(t, weapon.Thrust, knife.Weapons[0].Damage.StrengthType)
+				assert.Equal(t, "imp", knife.Weapons[0].Damage.Type)
+				require.Len(t, knife.Modifiers, 1)
+				assert.Equal(t, "Fine", knife.Modifiers[0].Name)
+				assert.Equal(t, "+3 CF", knife.Modifiers[0].CostAmount)
+			},
+		},
+		{
+			name: "notes",
+			data: `<note_list>
+	<note_container open="yes">
+		<text>Background</text>
+		<note><text>Born on a farm</text><reference>p1</reference></note>
+	</note_container>
+	<note>Old style note</note>
+</note_list>`,
+			ext: library.NotesExt,
+			check: func(t *testing.T, r *Result) {
+				require.Len(t, r.Notes, 2)
+				assert.Equal(t, "Background", r.Notes[0].Text)
+				assert.True(t, r.Notes[0].IsOpen)
+				require.Len(t, r.Notes[0].Children, 1)
+				assert.Equal(t, "Born on a farm", r.Notes[0].Children[0].Text)
+				assert.Equal(t, "p1", r.Notes[0].Children[0].PageRef)
+				assert.Same(t, r.Notes[0], r.Notes[0].Children[0].Parent())
+				assert.Equal(t, "Old style note", r.Notes[1].Text)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result := importXML(t, tc.data)
+			assert.Equal(t, tc.ext, result.Extension())
+			tc.check(t, result)
+		})
+	}
+}
+
+func TestImportGCSXMLUnrecognized(t *testing.T) {
+	_, err := Import(fstest.MapFS{"test.gcs": &fstest.MapFile{Data: []byte("<mystery/>")}}, "test.gcs")
+	assert.Error(t, err)
+}
+
+func TestLegacyWeaponDamage(t *testing.T) {
+	x := &gcsXMLImporter{report: NewReport("test")}
+	for _, tc := range []struct {
+		text    string
+		st      weapon.StrengthDamage
+		base    string
+		divisor fxp.Int
+		kind    string
+	}{
+		{text: "sw+2 cut", st: weapon.Swing, base: "2", kind: "cut"},
+		{text: "thr imp", st: weapon.Thrust, kind: "imp"},
+		{text: "2d (2) pi+", st: weapon.None, base: "2d", divisor: fxp.Two, kind: "pi+"},
+	} {
+		var damage gurps.WeaponDamage
+		x.weaponDamage(&damage, &xmlNode{Name: "damage", Text: tc.text})
+		assert.Equal(t, tc.st, damage.StrengthType, tc.text)
+		if tc.base == "" {
+			assert.Nil(t, damage.Base, tc.text)
+		} else if assert.NotNil(t, damage.Base, tc.text) {
+			assert.Equal(t, tc.base, damage.Base.String(), tc.text)
+		}
+		assert.Equal(t, tc.divisor, damage.ArmorDivisor, tc.text)
+		assert.Equal(t, tc.kind, damage.Type, tc.text)
+	}
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+// Package importer converts data written by older versions of GCS and by other character generators into the current
+// data model.
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/i18n"
+)
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// Result holds the data produced by an import. Exactly one of the data fields will be set.
+type Result struct {
+	Entity             *gurps.Entity
+	Template           *gurps.Template
+	Traits             []*gurps.Trait
+	TraitModifiers     []*gurps.TraitModifier
+	Skills             []*gurps.Skill
+	Spells             []*gurps.Spell
+	Equipment          []*gurps.Equipment
+	EquipmentModifiers []*gurps.EquipmentModifier
+	Notes              []*gurps.Note
+	Report             *Report
+	ext                string
+}
+
+// Extension returns the file extension the result should be saved with.
+func (r *Result) Extension() string {
+	return r.ext
+}
+
+// Save the result to the file in the current format.
+func (r *Result) Save(filePath string) error {
+	switch r.ext {
+	case library.SheetExt:
+		return r.Entity.Save(filePath)
+	case library.TemplatesExt:
+		return r.Template.Save(filePath)
+	case library.TraitsExt:
+		return gurps.SaveTraits(r.Traits, filePath)
+	case library.TraitModifiersExt:
+		return gurps.SaveTraitModifiers(r.TraitModifiers, filePath)
+	case library.SkillsExt:
+		return gurps.SaveSkills(r.Skills, filePath)
+	case library.SpellsExt:
+		return gurps.SaveSpells(r.Spells, filePath)
+	case library.EquipmentExt:
+		return gurps.SaveEquipment(r.Equipment, filePath)
+	case library.EquipmentModifiersExt:
+		return gurps.SaveEquipmentModifiers(r.EquipmentModifiers, filePath)
+	default:
+		return gurps.SaveNotes(r.Notes, filePath)
+	}
+}
+
+// IsLegacyFile returns true if the file holds data in a format that must be imported rather than loaded directly.
+func IsLegacyFile(fileSystem fs.FS, filePath string) bool {
+	if ext := filepath.Ext(filePath); strings.EqualFold(ext, GCA4Ext) || strings.EqualFold(ext, GCA5Ext) {
+		return true
+	}
+	f, err := fileSystem.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = f.Close() }() //nolint:errcheck // Nothing useful can be done with the error
+	buffer := make([]byte, 512)
+	n, _ := f.Read(buffer) //nolint:errcheck // A short or failed read just means the content isn't recognized
+	return isXML(buffer[:n])
+}
+
+// Import the file, converting it into the current data model.
+func Import(fileSystem fs.FS, filePath string) (*Result, error) {
+	data, err := fs.ReadFile(fileSystem, filePath)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	report := NewReport(filepath.Base(filePath))
+	var result *Result
+	switch {
+	case strings.EqualFold(filepath.Ext(filePath), GCA4Ext):
+		result, err = importGCA4(data, report)
+	case strings.EqualFold(filepath.Ext(filePath), GCA5Ext):
+		result, err = importGCA5(data, report, gurps.SettingsProvider.Libraries())
+	case isXML(data):
+		result, err = importGCSXML(data, report)
+	default:
+		err = errs.New(i18n.Text("unrecognized file format"))
+	}
+	if err != nil {
+		return nil, errs.NewWithCause(filePath, err)
+	}
+	return result, nil
+}
+
+// Convert imports the file and saves the result in the current format next to the original, returning the path it was
+// written to. An existing file of the same name will be overwritten, but the original will never be.
+func Convert(filePath string) (outPath string, report *Report, err error) {
+	var result *Result
+	if result, err = Import(os.DirFS(filepath.Dir(filePath)), filepath.Base(filePath)); err != nil {
+		return "", nil, err
+	}
+	base := strings.TrimSuffix(filePath, filepath.Ext(filePath))
+	outPath = base + result.Extension()
+	if outPath == filePath {
+		outPath = base + i18n.Text(" (imported)") + result.Extension()
+	}
+	if err = result.Save(outPath); err != nil {
+		return "", nil, err
+	}
+	return outPath, result.Report, nil
+}
+
+// ConvertFiles converts each of the files, writing the resulting path and any issues to w.
+func ConvertFiles(w io.Writer, fileList []string) error {
+	for _, one := range fileList {
+		outPath, report, err := Convert(one)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, i18n.Text("%s -> %s\n"), one, outPath)
+		if !report.Empty() {
+			fmt.Fprintln(w, report)
+		}
+	}
+	return nil
+}
+
+func isXML(data []byte) bool {
+	data = bytes.TrimLeft(bytes.TrimPrefix(data, utf8BOM), " \t\r\n")
+	return len(data) != 0 && data[0] == '<'
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package importer
+
+import (
+	"io/fs"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/toolbox/log/jot"
+)
+
+// ReviewTag is added to imported items that could not be matched against the libraries, so that they can be found and
+// checked by hand.
+const ReviewTag = "Needs Review"
+
+// libraryMatcher locates items in the libraries by name. Each kind of data is only loaded the first time it is asked
+// for.
+type libraryMatcher struct {
+	libraries          library.Libraries
+	traits             map[string]*gurps.Trait
+	traitModifiers     map[string]*gurps.TraitModifier
+	skills             map[string]*gurps.Skill
+	spells             map[string]*gurps.Spell
+	equipment          map[string]*gurps.Equipment
+	equipmentModifiers map[string]*gurps.EquipmentModifier
+}
+
+func newLibraryMatcher(libraries library.Libraries) *libraryMatcher {
+	return &libraryMatcher{libraries: libraries}
+}
+
+func matchKey(name, specialization string) string {
+	return strings.ToLower(strings.TrimSpace(name)) + "\x00" + strings.ToLower(strings.TrimSpace(specialization))
+}
+
+func (m *libraryMatcher) trait(name string) *gurps.Trait {
+	if m.traits == nil {
+		m.traits = make(map[string]*gurps.Trait)
+		loadForMatching(m, library.TraitsExt, gurps.NewTraitsFromFile, func(t *gurps.Trait) string {
+			return matchKey(t.Name, "")
+		}, m.traits)
+	}
+	return m.traits[matchKey(name, "")]
+}
+
+func (m *libraryMatcher) traitModifier(name string) *gurps.TraitModifier {
+	if m.traitModifiers == nil {
+		m.traitModifiers = make(map[string]*gurps.TraitModifier)
+		loadForMatching(m, library.TraitModifiersExt, gurps.NewTraitModifiersFromFile,
+			func(t *gurps.TraitModifier) string { return matchKey(t.Name, "") }, m.traitModifiers)
+	}
+	return m.traitModifiers[matchKey(name, "")]
+}
+
+func (m *libraryMatcher) skill(name, specialization string) *gurps.Skill {
+	if m.skills == nil {
+		m.skills = make(map[string]*gurps.Skill)
+		loadForMatching(m, library.SkillsExt, gurps.NewSkillsFromFile, func(s *gurps.Skill) string {
+			return matchKey(s.Name, s.Specialization)
+		}, m.skills)
+	}
+	if s, ok := m.skills[matchKey(name, specialization)]; ok {
+		return s
+	}
+	// Libraries frequently carry a skill with an empty specialization that the user is expected to fill in.
+	if s, ok := m.skills[matchKey(name, "")]; ok && specialization != "" {
+		return s
+	}
+	return nil
+}
+
+func (m *libraryMatcher) spell(name string) *gurps.Spell {
+	if m.spells == nil {
+		m.spells = make(map[string]*gurps.Spell)
+		loadForMatching(m, library.SpellsExt, gurps.NewSpellsFromFile, func(s *gurps.Spell) string {
+			return matchKey(s.Name, "")
+		}, m.spells)
+	}
+	return m.spells[matchKey(name, "")]
+}
+
+func (m *libraryMatcher) equipmentItem(name string) *gurps.Equipment {
+	if m.equipment == nil {
+		m.equipment = make(map[string]*gurps.Equipment)
+		loadForMatching(m, library.EquipmentExt, gurps.NewEquipmentFromFile, func(e *gurps.Equipment) string {
+			return matchKey(e.Name, "")
+		}, m.equipment)
+	}
+	return m.equipment[matchKey(name, "")]
+}
+
+func (m *libraryMatcher) equipmentModifier(name string) *gurps.EquipmentModifier {
+	if m.equipmentModifiers == nil {
+		m.equipmentModifiers = make(map[string]*gurps.EquipmentModifier)
+		loadForMatching(m, library.EquipmentModifiersExt, gurps.NewEquipmentModifiersFromFile,
+			func(e *gurps.EquipmentModifier) string { return matchKey(e.Name, "") }, m.equipmentModifiers)
+	}
+	return m.equipmentModifiers[matchKey(name, "")]
+}
+
+// loadForMatching indexes the non-container rows of every library file with the extension. When more than one row has
+// the same key, the first one encountered is kept.
+func loadForMatching[T gurps.NodeConstraint[T]](m *libraryMatcher, ext string, loader func(fs.FS, string) ([]T, error), key func(T) string, index map[string]T) {
+	if m.libraries == nil {
+		return
+	}
+	for _, set := range library.ScanForDataFiles(m.libraries, ext) {
+		for _, ref := range set.List {
+			rows, err := loader(ref.FileSystem, ref.FilePath)
+			if err != nil {
+				jot.Warn(err)
+				continue
+			}
+			gurps.Traverse(func(row T) bool {
+				k := key(row)
+				if _, exists := index[k]; !exists {
+					index[k] = row
+				}
+				return false
+			}, true, false, rows...)
+		}
+	}
+}
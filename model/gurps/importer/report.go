@@ -0,0 +1,75 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package importer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/richardwilkes/toolbox/i18n"
+)
+
+// Report collects the things an import could not map onto the current data model.
+type Report struct {
+	Source string
+	issues []string
+	counts map[string]int
+}
+
+// NewReport creates a new, empty report for the source.
+func NewReport(source string) *Report {
+	return &Report{
+		Source: source,
+		counts: make(map[string]int),
+	}
+}
+
+// Addf adds an issue to the report. Repeated issues are coalesced.
+func (r *Report) Addf(format string, args ...any) {
+	issue := fmt.Sprintf(format, args...)
+	if r.counts[issue] == 0 {
+		r.issues = append(r.issues, issue)
+	}
+	r.counts[issue]++
+}
+
+// Empty returns true if no issues were recorded.
+func (r *Report) Empty() bool {
+	return len(r.issues) == 0
+}
+
+// Issues returns the recorded issues, in the order they were first encountered.
+func (r *Report) Issues() []string {
+	list := make([]string, len(r.issues))
+	for i, one := range r.issues {
+		if count := r.counts[one]; count > 1 {
+			list[i] = fmt.Sprintf(i18n.Text("%s (%d times)"), one, count)
+		} else {
+			list[i] = one
+		}
+	}
+	return list
+}
+
+// String implements fmt.Stringer.
+func (r *Report) String() string {
+	if r.Empty() {
+		return fmt.Sprintf(i18n.Text("%s: imported without issues"), r.Source)
+	}
+	var buffer strings.Builder
+	fmt.Fprintf(&buffer, i18n.Text("%s: the following could not be imported:"), r.Source)
+	for _, one := range r.Issues() {
+		buffer.WriteString("\n• ")
+		buffer.WriteString(one)
+	}
+	return buffer.String()
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package importer
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+// xmlNode is a simplified, order-preserving view of an XML element. The legacy formats vary too much between versions
+// for struct-based decoding to be practical.
+type xmlNode struct {
+	Name     string
+	Attrs    map[string]string
+	Children []*xmlNode
+	Text     string
+}
+
+func parseXML(data []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	var stack []*xmlNode
+	var root *xmlNode
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF { //nolint:errorlint // io.EOF is never wrapped by the decoder
+				break
+			}
+			return nil, errs.Wrap(err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{
+				Name:  t.Name.Local,
+				Attrs: make(map[string]string, len(t.Attr)),
+			}
+			for _, attr := range t.Attr {
+				node.Attrs[attr.Name.Local] = attr.Value
+			}
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, errs.New("multiple root elements")
+				}
+				root = node
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, node)
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) != 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) != 0 {
+				stack[len(stack)-1].Text += string(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, errs.New("no root element")
+	}
+	return root, nil
+}
+
+// child returns the first child with the given name, or nil.
+func (n *xmlNode) child(name string) *xmlNode {
+	for _, one := range n.Children {
+		if one.Name == name {
+			return one
+		}
+	}
+	return nil
+}
+
+// text returns the trimmed text of the first child with the given name.
+func (n *xmlNode) text(name string) string {
+	if c := n.child(name); c != nil {
+		return strings.TrimSpace(c.Text)
+	}
+	return ""
+}
+
+// number returns the value of the first child with the given name as a number.
+func (n *xmlNode) number(name string) fxp.Int {
+	return parseNumber(n.text(name))
+}
+
+// integer returns the value of the first child with the given name as an integer.
+func (n *xmlNode) integer(name string) int {
+	v, _ := strconv.Atoi(n.text(name)) //nolint:errcheck // Default to 0 on error
+	return v
+}
+
+// attr returns the trimmed value of the attribute.
+func (n *xmlNode) attr(name string) string {
+	return strings.TrimSpace(n.Attrs[name])
+}
+
+// flag returns true if the attribute holds one of the truthy values used by the legacy formats.
+func (n *xmlNode) flag(name string) bool {
+	switch strings.ToLower(n.attr(name)) {
+	case "yes", "true", "1":
+		return true
+	default:
+		return false
+	}
+}
+
+func parseNumber(text string) fxp.Int {
+	v, _ := fxp.Extract(strings.ReplaceAll(strings.TrimSpace(text), ",", "")) //nolint:errcheck // Default to 0 on error
+	return v
+}
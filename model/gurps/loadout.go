@@ -0,0 +1,196 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package gurps
+
+import (
+	"github.com/google/uuid"
+	"github.com/richardwilkes/gcs/v5/model/gurps/datafile"
+	"github.com/richardwilkes/gcs/v5/model/gurps/measure"
+)
+
+// Loadout holds a named set of equipment states, such as "travel" or "combat" gear. Equipment that didn't exist when
+// the loadout was recorded is left alone when the loadout is applied.
+type Loadout struct {
+	Name     string      `json:"name"`
+	Equipped []uuid.UUID `json:"equipped,omitempty"`
+	Carried  []uuid.UUID `json:"carried,omitempty"`
+	Other    []uuid.UUID `json:"other,omitempty"`
+}
+
+// NewLoadout creates a new Loadout from the entity's current equipment.
+func NewLoadout(entity *Entity, name string) *Loadout {
+	l := &Loadout{Name: name}
+	l.Record(entity)
+	return l
+}
+
+// Record replaces the contents of the loadout with the entity's current equipment.
+func (l *Loadout) Record(entity *Entity) {
+	l.Equipped = nil
+	l.Carried = nil
+	l.Other = nil
+	Traverse(func(eqp *Equipment) bool {
+		l.Carried = append(l.Carried, eqp.ID)
+		if eqp.Equipped {
+			l.Equipped = append(l.Equipped, eqp.ID)
+		}
+		return false
+	}, false, false, entity.CarriedEquipment...)
+	Traverse(func(eqp *Equipment) bool {
+		l.Other = append(l.Other, eqp.ID)
+		if eqp.Equipped {
+			l.Equipped = append(l.Equipped, eqp.ID)
+		}
+		return false
+	}, false, false, entity.OtherEquipment...)
+}
+
+// Clone returns a copy of the loadout.
+func (l *Loadout) Clone() *Loadout {
+	other := *l
+	other.Equipped = append([]uuid.UUID(nil), l.Equipped...)
+	other.Carried = append([]uuid.UUID(nil), l.Carried...)
+	other.Other = append([]uuid.UUID(nil), l.Other...)
+	return &other
+}
+
+// Apply the loadout to the entity. The equipped state of each piece of equipment the loadout knows about is set, and
+// top-level equipment is moved between the carried and other equipment lists as needed. Equipment within a container
+// always stays with its container. The entity is recalculated afterwards. Returns the number of pieces of equipment
+// the loadout recorded that no longer exist.
+func (l *Loadout) Apply(entity *Entity) (missing int) {
+	missing = l.Missing(entity)
+	equipped := uuidSet(l.Equipped)
+	carried := uuidSet(l.Carried)
+	other := uuidSet(l.Other)
+	update := func(eqp *Equipment) bool {
+		if carried[eqp.ID] || other[eqp.ID] {
+			eqp.Equipped = equipped[eqp.ID]
+		}
+		return false
+	}
+	Traverse(update, false, false, entity.CarriedEquipment...)
+	Traverse(update, false, false, entity.OtherEquipment...)
+	var newCarried, newOther []*Equipment
+	for _, eqp := range entity.CarriedEquipment {
+		if other[eqp.ID] {
+			newOther = append(newOther, eqp)
+		} else {
+			newCarried = append(newCarried, eqp)
+		}
+	}
+	for _, eqp := range entity.OtherEquipment {
+		if carried[eqp.ID] {
+			newCarried = append(newCarried, eqp)
+		} else {
+			newOther = append(newOther, eqp)
+		}
+	}
+	entity.CarriedEquipment = newCarried
+	entity.OtherEquipment = newOther
+	entity.Recalculate()
+	return missing
+}
+
+// Missing returns the number of pieces of equipment the loadout recorded that no longer exist in the entity.
+func (l *Loadout) Missing(entity *Entity) int {
+	present := make(map[uuid.UUID]bool)
+	record := func(eqp *Equipment) bool {
+		present[eqp.ID] = true
+		return false
+	}
+	Traverse(record, false, false, entity.CarriedEquipment...)
+	Traverse(record, false, false, entity.OtherEquipment...)
+	missing := 0
+	for _, list := range [][]uuid.UUID{l.Carried, l.Other} {
+		for _, id := range list {
+			if !present[id] {
+				missing++
+			}
+		}
+	}
+	return missing
+}
+
+func uuidSet(list []uuid.UUID) map[uuid.UUID]bool {
+	m := make(map[uuid.UUID]bool, len(list))
+	for _, one := range list {
+		m[one] = true
+	}
+	return m
+}
+
+// EquipmentState holds a snapshot of which equipment is carried and equipped, suitable for restoring later, such as
+// when undoing a loadout switch.
+type EquipmentState struct {
+	entity   *Entity
+	carried  []*Equipment
+	other    []*Equipment
+	equipped map[*Equipment]bool
+}
+
+// NewEquipmentState captures the current equipment state of the entity.
+func NewEquipmentState(entity *Entity) *EquipmentState {
+	s := &EquipmentState{
+		entity:   entity,
+		carried:  append([]*Equipment(nil), entity.CarriedEquipment...),
+		other:    append([]*Equipment(nil), entity.OtherEquipment...),
+		equipped: make(map[*Equipment]bool),
+	}
+	record := func(eqp *Equipment) bool {
+		s.equipped[eqp] = eqp.Equipped
+		return false
+	}
+	Traverse(record, false, false, entity.CarriedEquipment...)
+	Traverse(record, false, false, entity.OtherEquipment...)
+	return s
+}
+
+// Restore the captured equipment state to the entity and recalculate it.
+func (s *EquipmentState) Restore() {
+	s.entity.CarriedEquipment = append([]*Equipment(nil), s.carried...)
+	s.entity.OtherEquipment = append([]*Equipment(nil), s.other...)
+	for eqp, equipped := range s.equipped {
+		eqp.Equipped = equipped
+	}
+	s.entity.Recalculate()
+}
+
+// LoadoutSummary holds the values affected by switching loadouts.
+type LoadoutSummary struct {
+	Weight      measure.Weight
+	Encumbrance datafile.Encumbrance
+	Move        int
+	Dodge       int
+}
+
+// NewLoadoutSummary returns the summary of the entity's current loadout.
+func NewLoadoutSummary(entity *Entity) LoadoutSummary {
+	enc := entity.EncumbranceLevel(false)
+	return LoadoutSummary{
+		Weight:      entity.WeightCarried(false),
+		Encumbrance: enc,
+		Move:        entity.Move(enc),
+		Dodge:       entity.Dodge(enc),
+	}
+}
+
+// PreviewLoadout returns the summaries of the entity before and after applying the loadout. The entity is left
+// unchanged.
+func PreviewLoadout(entity *Entity, l *Loadout) (before, after LoadoutSummary) {
+	state := NewEquipmentState(entity)
+	before = NewLoadoutSummary(entity)
+	l.Apply(entity)
+	after = NewLoadoutSummary(entity)
+	state.Restore()
+	return before, after
+}
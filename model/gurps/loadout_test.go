@@ -0,0 +1,92 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package gurps_test
+
+import (
+	"testing"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/datafile"
+	"github.com/richardwilkes/gcs/v5/model/gurps/measure"
+	"github.com/richardwilkes/gcs/v5/model/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLoadoutItem(entity *gurps.Entity, parent *gurps.Equipment, name string, weight int) *gurps.Equipment {
+	eqp := gurps.NewEquipment(entity, parent, parent == nil)
+	eqp.Name = name
+	eqp.Weight = measure.Weight(fxp.From(weight))
+	if parent != nil {
+		parent.Children = append(parent.Children, eqp)
+	}
+	return eqp
+}
+
+func equipmentNames(list []*gurps.Equipment) []string {
+	names := make([]string, 0, len(list))
+	for _, eqp := range list {
+		names = append(names, eqp.Name)
+	}
+	return names
+}
+
+func TestLoadoutApply(t *testing.T) {
+	settings.Global()
+	entity := gurps.NewEntity(datafile.PC)
+	entity.Traits = nil
+	pack := newLoadoutItem(entity, nil, "Backpack", 3)
+	rope := newLoadoutItem(entity, pack, "Rope", 5)
+	sword := newLoadoutItem(entity, nil, "Sword", 3)
+	tent := newLoadoutItem(entity, nil, "Tent", 40)
+	entity.CarriedEquipment = []*gurps.Equipment{pack, sword, tent}
+	entity.OtherEquipment = nil
+	entity.Recalculate()
+
+	travel := gurps.NewLoadout(entity, "Travel")
+	assert.Equal(t, 0, travel.Missing(entity))
+
+	// Switch to combat gear: stash the pack, unequip the rope, and get rid of the tent.
+	rope.Equipped = false
+	entity.CarriedEquipment = []*gurps.Equipment{sword}
+	entity.OtherEquipment = []*gurps.Equipment{pack}
+	entity.Recalculate()
+	combat := gurps.NewLoadout(entity, "Combat")
+
+	before, after := gurps.PreviewLoadout(entity, travel)
+	assert.Equal(t, []string{"Sword"}, equipmentNames(entity.CarriedEquipment), "preview must leave the entity unchanged")
+	assert.Equal(t, []string{"Backpack"}, equipmentNames(entity.OtherEquipment), "preview must leave the entity unchanged")
+	assert.False(t, rope.Equipped, "preview must leave the entity unchanged")
+	assert.Less(t, int64(before.Weight), int64(after.Weight))
+
+	assert.Equal(t, 1, travel.Missing(entity))
+	var missing int
+	require.NotPanics(t, func() { missing = travel.Apply(entity) })
+	assert.Equal(t, 1, missing, "the tent no longer exists")
+	assert.ElementsMatch(t, []string{"Backpack", "Sword"}, equipmentNames(entity.CarriedEquipment))
+	assert.Empty(t, entity.OtherEquipment)
+	assert.True(t, rope.Equipped)
+	assert.Same(t, pack, rope.Parent(), "contents stay with their container")
+
+	assert.Equal(t, 0, combat.Apply(entity))
+	assert.Equal(t, []string{"Sword"}, equipmentNames(entity.CarriedEquipment))
+	assert.Equal(t, []string{"Backpack"}, equipmentNames(entity.OtherEquipment))
+	assert.False(t, rope.Equipped)
+
+	// Equipment added after the loadout was recorded is left alone.
+	torch := newLoadoutItem(entity, nil, "Torch", 1)
+	entity.OtherEquipment = append(entity.OtherEquipment, torch)
+	assert.Equal(t, 1, travel.Apply(entity))
+	assert.Contains(t, entity.OtherEquipment, torch)
+	assert.True(t, torch.Equipped)
+}
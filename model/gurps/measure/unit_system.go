@@ -0,0 +1,166 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package measure
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+)
+
+var (
+	lengthInTextRegex = regexp.MustCompile(`(?i)(\d[\d,]*(?:\.\d+)?|\.\d+)(\s*)(ft|yd|mi|in|cm|km|m)\b`)
+	// rangeInTextRegex matches a pair of numbers separated by a slash, such as "100/1,500", where the first has no units.
+	// The leading character is captured so that multipliers, such as "x10/x15", can be excluded.
+	rangeInTextRegex = regexp.MustCompile(`(?i)(^|[^\w.,×])(\d[\d,]*(?:\.\d+)?)(\s*/\s*)(\d[\d,]*(?:\.\d+)?)(\s*)(ft|yd|mi|in|cm|km|m)?\b`)
+)
+
+// UnitSystemKeys returns the keys of all possible values.
+func UnitSystemKeys() []string {
+	keys := make([]string, len(AllUnitSystem))
+	for i, one := range AllUnitSystem {
+		keys[i] = one.Key()
+	}
+	return keys
+}
+
+// LengthUnits returns the units used for lengths by default in this system.
+func (enum UnitSystem) LengthUnits() LengthUnits {
+	if enum.EnsureValid() == Metric {
+		return Centimeter
+	}
+	return FeetAndInches
+}
+
+// WeightUnits returns the units used for weights by default in this system.
+func (enum UnitSystem) WeightUnits() WeightUnits {
+	if enum.EnsureValid() == Metric {
+		return Kilogram
+	}
+	return Pound
+}
+
+// EquivalentLengthUnits returns the units in this system that correspond to the given units.
+func (enum UnitSystem) EquivalentLengthUnits(units LengthUnits) LengthUnits {
+	if enum.EnsureValid() == Metric {
+		switch units {
+		case FeetAndInches, Inch:
+			return Centimeter
+		case Feet, Yard:
+			return Meter
+		case Mile:
+			return Kilometer
+		default:
+			return units
+		}
+	}
+	switch units {
+	case Centimeter:
+		return Inch
+	case Meter:
+		return Yard
+	case Kilometer:
+		return Mile
+	default:
+		return units
+	}
+}
+
+// EquivalentWeightUnits returns the units in this system that correspond to the given units.
+func (enum UnitSystem) EquivalentWeightUnits(units WeightUnits) WeightUnits {
+	if enum.EnsureValid() == Metric {
+		switch units {
+		case Pound, PoundAlt, Ton, TonAlt:
+			return Kilogram
+		case Ounce:
+			return Gram
+		default:
+			return units
+		}
+	}
+	switch units {
+	case Kilogram:
+		return Pound
+	case Gram:
+		return Ounce
+	default:
+		return units
+	}
+}
+
+// ConvertLengthsInText rewrites any lengths within the text, such as "100 yd" or "3.5 km", to use the units of this
+// system. Ranges whose numbers lack units, such as "100/1,500", are in yards, unless the last number has units, as in
+// "10/20 mi". Multipliers, such as the "x10/x15" of a muscle-powered weapon's range, are left untouched.
+func (enum UnitSystem) ConvertLengthsInText(text string) string {
+	text = rangeInTextRegex.ReplaceAllStringFunc(text, func(match string) string {
+		parts := rangeInTextRegex.FindStringSubmatch(match)
+		units := Yard
+		if parts[6] != "" {
+			units = ExtractLengthUnits(parts[6])
+		}
+		first, ok := enum.convertLength(parts[2], units)
+		if !ok {
+			return match
+		}
+		second, ok := enum.convertLength(parts[4], units)
+		if !ok {
+			return match
+		}
+		if parts[6] == "" {
+			second += parts[5]
+		}
+		return parts[1] + first + parts[3] + second
+	})
+	return lengthInTextRegex.ReplaceAllStringFunc(text, func(match string) string {
+		parts := lengthInTextRegex.FindStringSubmatch(match)
+		if converted, ok := enum.convertLength(parts[1], ExtractLengthUnits(parts[3])); ok {
+			return converted
+		}
+		return match
+	})
+}
+
+// convertLength returns the value, which is in the given units, formatted using the equivalent units of this system.
+// Returns false if the units are already those of this system or the value can't be parsed.
+func (enum UnitSystem) convertLength(value string, units LengthUnits) (string, bool) {
+	target := enum.EquivalentLengthUnits(units)
+	if target == units {
+		return "", false
+	}
+	v, err := fxp.FromString(strings.ReplaceAll(value, ",", ""))
+	if err != nil {
+		return "", false
+	}
+	return target.Format(Length(units.ToInches(v))), true
+}
+
+// ConvertWeightText rewrites a weight, which may be signed, fractional (e.g. "+1/2 lb") or lack units, to use the units
+// of this system. Weights without units are interpreted using defUnits and are given explicit units, since the default
+// units may not be the same wherever the text ends up. The text is returned unchanged if it is already in this system.
+func (enum UnitSystem) ConvertWeightText(text string, defUnits WeightUnits) string {
+	trimmed := strings.TrimSpace(text)
+	units := TrailingWeightUnitsFromString(trimmed, defUnits)
+	target := enum.EquivalentWeightUnits(units)
+	value := trimmed
+	if lower := strings.ToLower(trimmed); strings.HasSuffix(lower, units.Key()) {
+		if target == units {
+			return text
+		}
+		value = strings.TrimSpace(value[:len(value)-len(units.Key())])
+	}
+	result := target.Format(Weight(units.ToPounds(fxp.NewFraction(value).Value())))
+	if strings.HasPrefix(value, "+") {
+		result = "+" + result
+	}
+	return result
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package measure_test
+
+import (
+	"testing"
+
+	"github.com/richardwilkes/gcs/v5/model/gurps/measure"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertLengthsInText(t *testing.T) {
+	for _, one := range []struct {
+		text     string
+		system   measure.UnitSystem
+		expected string
+	}{
+		{text: "", system: measure.Metric, expected: ""},
+		{text: "100 yd", system: measure.Metric, expected: "100 m"},
+		{text: "88 km", system: measure.Imperial, expected: "50 mi"},
+		{text: "100 yd", system: measure.Imperial, expected: "100 yd"},
+		{text: "1,500 yd", system: measure.Metric, expected: "1500 m"},
+		{text: "10 ft", system: measure.Metric, expected: "3.3333 m"},
+		{text: "100/1,500", system: measure.Metric, expected: "100 m/1500 m"},
+		{text: "100/1,500", system: measure.Imperial, expected: "100/1,500"},
+		{text: "100 / 1,500", system: measure.Metric, expected: "100 m / 1500 m"},
+		{text: "50/100 mi", system: measure.Metric, expected: "88 km/176 km"},
+		{text: "100 m/1500 m", system: measure.Imperial, expected: "100 yd/1500 yd"},
+		{text: "100/1,500 m", system: measure.Imperial, expected: "100 yd/1500 yd"},
+		{text: "x10/x15", system: measure.Metric, expected: "x10/x15"},
+		{text: "x10/x15", system: measure.Imperial, expected: "x10/x15"},
+		{text: "1/2D 20", system: measure.Metric, expected: "1/2D 20"},
+		{text: "10", system: measure.Metric, expected: "10"},
+		{text: "Max 100 yd", system: measure.Metric, expected: "Max 100 m"},
+		{text: "(100/1,500)", system: measure.Metric, expected: "(100 m/1500 m)"},
+	} {
+		assert.Equal(t, one.expected, one.system.ConvertLengthsInText(one.text), "%q to %s", one.text, one.system)
+	}
+}
+
+func TestConvertLengthsInTextRoundTrip(t *testing.T) {
+	for _, text := range []string{"100 yd", "50 mi", "36 in", "100 yd/1500 yd", "x10/x15"} {
+		assert.Equal(t, text, measure.Imperial.ConvertLengthsInText(measure.Metric.ConvertLengthsInText(text)), text)
+	}
+	for _, text := range []string{"100 m", "88 km", "100 cm", "100 m/1500 m", "x10/x15"} {
+		assert.Equal(t, text, measure.Metric.ConvertLengthsInText(measure.Imperial.ConvertLengthsInText(text)), text)
+	}
+}
+
+func TestConvertWeightText(t *testing.T) {
+	for _, one := range []struct {
+		text     string
+		system   measure.UnitSystem
+		defUnits measure.WeightUnits
+		expected string
+	}{
+		{text: "1 lb", system: measure.Metric, defUnits: measure.Pound, expected: "0.5 kg"},
+		{text: "2 kg", system: measure.Imperial, defUnits: measure.Pound, expected: "4 lb"},
+		{text: "2 kg", system: measure.Metric, defUnits: measure.Pound, expected: "2 kg"},
+		{text: "+1/2 lb", system: measure.Metric, defUnits: measure.Pound, expected: "+0.25 kg"},
+		{text: "4", system: measure.Metric, defUnits: measure.Pound, expected: "2 kg"},
+		{text: "4", system: measure.Imperial, defUnits: measure.Pound, expected: "4 lb"},
+		{text: "4", system: measure.Imperial, defUnits: measure.Kilogram, expected: "8 lb"},
+	} {
+		assert.Equal(t, one.expected, one.system.ConvertWeightText(one.text, one.defUnits), "%q to %s", one.text,
+			one.system)
+	}
+}
+
+func TestConvertWeightTextRoundTrip(t *testing.T) {
+	for _, text := range []string{"1 lb", "+3 lb", "10 lb"} {
+		assert.Equal(t, text, measure.Imperial.ConvertWeightText(measure.Metric.ConvertWeightText(text, measure.Pound),
+			measure.Kilogram), text)
+	}
+	for _, text := range []string{"1 kg", "+3 kg", "10 kg"} {
+		assert.Equal(t, text, measure.Metric.ConvertWeightText(measure.Imperial.ConvertWeightText(text, measure.Kilogram),
+			measure.Pound), text)
+	}
+}
+
+func TestEquivalentUnitsRoundTrip(t *testing.T) {
+	for _, units := range []measure.LengthUnits{measure.Inch, measure.Yard, measure.Mile} {
+		assert.Equal(t, units, measure.Imperial.EquivalentLengthUnits(measure.Metric.EquivalentLengthUnits(units)))
+	}
+	for _, units := range measure.AllLengthUnits {
+		metric := measure.Metric.EquivalentLengthUnits(units)
+		assert.Equal(t, metric, measure.Metric.EquivalentLengthUnits(metric), "%s", units)
+		imperial := measure.Imperial.EquivalentLengthUnits(units)
+		assert.Equal(t, imperial, measure.Imperial.EquivalentLengthUnits(imperial), "%s", units)
+	}
+	for _, units := range []measure.WeightUnits{measure.Pound, measure.Ounce} {
+		assert.Equal(t, units, measure.Imperial.EquivalentWeightUnits(measure.Metric.EquivalentWeightUnits(units)))
+	}
+	for _, units := range []measure.WeightUnits{measure.Kilogram, measure.Gram} {
+		assert.Equal(t, units, measure.Metric.EquivalentWeightUnits(measure.Imperial.EquivalentWeightUnits(units)))
+	}
+	assert.Equal(t, measure.Centimeter, measure.Metric.LengthUnits())
+	assert.Equal(t, measure.FeetAndInches, measure.Imperial.LengthUnits())
+	assert.Equal(t, measure.Kilogram, measure.Metric.WeightUnits())
+	assert.Equal(t, measure.Pound, measure.Imperial.WeightUnits())
+}
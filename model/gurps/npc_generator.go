@@ -0,0 +1,215 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package gurps
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps/ancestry"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/xio/fs"
+	"github.com/richardwilkes/toolbox/xmath"
+	"github.com/richardwilkes/toolbox/xmath/rand"
+)
+
+// NPCGenerator creates randomized characters from a template, an ancestry and a point total.
+type NPCGenerator struct {
+	// Template provides the traits, skills, spells, equipment and notes. Any choices within it are made at random. May
+	// be nil.
+	Template *Template
+	// Ancestry is used to randomize the profile. May be nil, in which case the ancestry specified by the character's
+	// traits is used.
+	Ancestry    *ancestry.Ancestry
+	TotalPoints fxp.Int
+}
+
+// npcAttributeIDs holds the attributes that any points left over after applying the template are spent on.
+var npcAttributeIDs = []string{gid.Strength, gid.Dexterity, gid.Intelligence, gid.Health}
+
+// Generate creates a new randomized character. The template's choices are made so that the character fits within the
+// point total where possible, then any points left over are spent raising the primary attributes. Whatever remains is
+// settled by adjusting hit points and fatigue points, so that the character lands on the point total exactly.
+func (g *NPCGenerator) Generate() *Entity {
+	template := g.Template
+	if template == nil {
+		template = NewTemplate()
+	}
+	entity := g.randomizeWithinBudget(NewTemplateBuilder(template, true))
+	entity.TotalPoints = g.TotalPoints
+	a := g.Ancestry
+	if a == nil {
+		a = entity.Ancestry()
+	}
+	entity.Profile.AutoFillFromAncestry(entity, a)
+	spendOnAttributes(entity)
+	settleRemainder(entity)
+	entity.Recalculate()
+	return entity
+}
+
+// randomizeWithinBudget makes random choices until the character's cost fits within the point total. If no selection
+// fits, the cheapest one found is used.
+func (g *NPCGenerator) randomizeWithinBudget(b *TemplateBuilder) *Entity {
+	var best [][]bool
+	var bestSpent fxp.Int
+	for i := 0; i < maxRandomChoiceAttempts; i++ {
+		b.RandomizeChoices()
+		spent := b.Entity().SpentPoints()
+		if spent <= g.TotalPoints || len(b.Choices) == 0 {
+			return b.Entity()
+		}
+		if best == nil || spent < bestSpent {
+			best = templateSelections(b)
+			bestSpent = spent
+		}
+	}
+	for i, choice := range b.Choices {
+		for j, option := range choice.Options {
+			option.Selected = best[i][j]
+		}
+	}
+	return b.Entity()
+}
+
+func templateSelections(b *TemplateBuilder) [][]bool {
+	selections := make([][]bool, len(b.Choices))
+	for i, choice := range b.Choices {
+		selections[i] = make([]bool, len(choice.Options))
+		for j, option := range choice.Options {
+			selections[i][j] = option.Selected
+		}
+	}
+	return selections
+}
+
+// spendOnAttributes raises randomly chosen primary attributes, one level at a time, until no further level can be
+// afforded with the character's unspent points.
+func spendOnAttributes(entity *Entity) {
+	var candidates []*Attribute
+	for _, attrID := range npcAttributeIDs {
+		if attr, exists := entity.Attributes.Set[attrID]; exists {
+			candidates = append(candidates, attr)
+		}
+	}
+	rnd := rand.NewCryptoRand()
+	unspent := entity.UnspentPoints()
+	for len(candidates) != 0 && unspent > 0 {
+		i := rnd.Intn(len(candidates))
+		attr := candidates[i]
+		attr.Adjustment += fxp.One
+		if remaining := entity.UnspentPoints(); remaining >= 0 && remaining < unspent {
+			unspent = remaining
+			continue
+		}
+		// Either too expensive or free; in both cases, stop considering this attribute.
+		attr.Adjustment -= fxp.One
+		candidates = append(candidates[:i], candidates[i+1:]...)
+	}
+}
+
+// settleRemainder spends the character's unspent points, which may be negative, by adjusting hit points and fatigue
+// points. As long as their costs per level have no common factor, as with the default 2 and 3 points, any whole number
+// of points can be spent this way. Nothing is changed if no suitable adjustment exists.
+func settleRemainder(entity *Entity) {
+	remainder := entity.UnspentPoints()
+	if remainder == 0 || remainder != remainder.Trunc() {
+		return
+	}
+	hp, hpExists := entity.Attributes.Set[gid.HitPoints]
+	fp, fpExists := entity.Attributes.Set[gid.FatiguePoints]
+	if !hpExists || !fpExists {
+		return
+	}
+	hpCost := attributeLevelCost(entity, hp)
+	fpCost := attributeLevelCost(entity, fp)
+	if hpCost <= 0 || fpCost <= 0 {
+		return
+	}
+	// Every residue of fpLevels*fpCost modulo hpCost is reachable with |fpLevels| < hpCost, so only that range needs to
+	// be searched. The smallest overall adjustment is preferred.
+	found := false
+	var hpLevels, fpLevels int
+	for f := 1 - hpCost; f < hpCost; f++ {
+		rest := remainder - fxp.From(f*fpCost)
+		if rest%fxp.From(hpCost) != 0 {
+			continue
+		}
+		h := fxp.As[int](rest) / hpCost
+		if hp.Maximum()+fxp.From(h) < fxp.One || fp.Maximum()+fxp.From(f) < fxp.One {
+			continue
+		}
+		if !found || xmath.Abs(h)+xmath.Abs(f) < xmath.Abs(hpLevels)+xmath.Abs(fpLevels) {
+			found = true
+			hpLevels = h
+			fpLevels = f
+		}
+	}
+	if !found {
+		return
+	}
+	hp.Adjustment += fxp.From(hpLevels)
+	fp.Adjustment += fxp.From(fpLevels)
+	if entity.UnspentPoints() != 0 {
+		// The cost per level wasn't constant across the adjustment, so put things back the way they were.
+		hp.Adjustment -= fxp.From(hpLevels)
+		fp.Adjustment -= fxp.From(fpLevels)
+	}
+}
+
+// attributeLevelCost returns the whole number of points raising the attribute by one level costs, or 0 if it isn't a
+// whole number.
+func attributeLevelCost(entity *Entity, attr *Attribute) int {
+	before := entity.SpentPoints()
+	attr.Adjustment += fxp.One
+	cost := entity.SpentPoints() - before
+	attr.Adjustment -= fxp.One
+	if cost != cost.Trunc() {
+		return 0
+	}
+	return fxp.As[int](cost)
+}
+
+// GenerateToFolder creates count randomized characters, saving each one as a sheet within the folder. The paths of the
+// files that were written are returned.
+func (g *NPCGenerator) GenerateToFolder(dirPath string, count int) ([]string, error) {
+	if err := os.MkdirAll(dirPath, 0o750); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	paths := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		entity := g.Generate()
+		p := uniqueFilePath(dirPath, entity.Profile.Name, library.SheetExt)
+		if err := entity.Save(p); err != nil {
+			return paths, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
+
+func uniqueFilePath(dirPath, name, ext string) string {
+	if name = fs.SanitizeName(strings.TrimSpace(name)); name == "" {
+		name = i18n.Text("NPC")
+	}
+	p := filepath.Join(dirPath, name+ext)
+	for i := 2; fs.FileExists(p); i++ {
+		p = filepath.Join(dirPath, fmt.Sprintf("%s %d%s", name, i, ext))
+	}
+	return p
+}
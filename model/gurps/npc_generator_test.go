@@ -0,0 +1,43 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package gurps_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/model/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNPCGeneratorSpendsExactTotal(t *testing.T) {
+	settings.Global()
+	// Primary attributes cost 10 or 20 points a level, so most of these totals leave a remainder smaller than any
+	// primary attribute step.
+	for _, total := range []int{0, 1, 2, 7, 9, 19, 100, 101, 153, 250} {
+		t.Run(fmt.Sprintf("%d points", total), func(t *testing.T) {
+			for i := 0; i < 3; i++ {
+				g := &gurps.NPCGenerator{TotalPoints: fxp.From(total)}
+				entity := g.Generate()
+				assert.Equal(t, fxp.From(total), entity.TotalPoints)
+				assert.Equal(t, fxp.From(0), entity.UnspentPoints(), "spent %s of %d",
+					entity.SpentPoints().String(), total)
+				for _, attrID := range []string{gid.HitPoints, gid.FatiguePoints} {
+					assert.GreaterOrEqual(t, int64(entity.Attributes.Set[attrID].Maximum()), int64(fxp.One))
+				}
+			}
+		})
+	}
+}
@@ -87,10 +87,15 @@ func (p *Profile) SetAdjustedSizeModifier(value int) {
 
 // AutoFill fills in the default profile entries.
 func (p *Profile) AutoFill(entity *Entity) {
+	p.AutoFillFromAncestry(entity, entity.Ancestry())
+}
+
+// AutoFillFromAncestry fills in the default profile entries, using the given ancestry rather than the one the entity's
+// traits specify.
+func (p *Profile) AutoFillFromAncestry(entity *Entity, a *ancestry.Ancestry) {
 	generalSettings := SettingsProvider.GeneralSettings()
 	p.TechLevel = generalSettings.DefaultTechLevel
 	p.PlayerName = generalSettings.DefaultPlayerName
-	a := entity.Ancestry()
 	p.Gender = a.RandomGender("")
 	p.Age = strconv.Itoa(a.RandomAge(entity, p.Gender, 0))
 	p.Eyes = a.RandomEyes(p.Gender, "")
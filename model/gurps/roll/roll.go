@@ -0,0 +1,200 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+// Package roll provides success and damage rolls, following the rules for them in the Basic Set.
+package roll
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/richardwilkes/rpgtools/dice"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/i18n"
+)
+
+// Possible Outcome values.
+const (
+	CriticalFailure Outcome = iota
+	Failure
+	Success
+	CriticalSuccess
+)
+
+// Outcome holds the outcome of a success roll.
+type Outcome uint8
+
+// String implements fmt.Stringer.
+func (o Outcome) String() string {
+	switch o {
+	case CriticalFailure:
+		return i18n.Text("Critical Failure")
+	case Failure:
+		return i18n.Text("Failure")
+	case Success:
+		return i18n.Text("Success")
+	case CriticalSuccess:
+		return i18n.Text("Critical Success")
+	default:
+		return ""
+	}
+}
+
+// Succeeded returns true if the outcome is a success of some kind.
+func (o Outcome) Succeeded() bool {
+	return o >= Success
+}
+
+// Modifier holds a situational modifier applied to a roll.
+type Modifier struct {
+	Description string
+	Amount      int
+}
+
+// SuccessRoll holds the result of a success roll made against a level, such as an attribute or a skill.
+type SuccessRoll struct {
+	When        time.Time
+	Description string
+	Level       int
+	Modifiers   []Modifier
+	Rolled      int
+	Outcome     Outcome
+	Margin      int
+	// NoCriticals is set for rolls that don't use the critical success and failure rules, such as self-control rolls.
+	NoCriticals bool
+}
+
+// Effective returns the level the roll is made against, after modifiers.
+func (r *SuccessRoll) Effective() int {
+	level := r.Level
+	for _, one := range r.Modifiers {
+		level += one.Amount
+	}
+	return level
+}
+
+// String implements fmt.Stringer.
+func (r *SuccessRoll) String() string {
+	var buffer strings.Builder
+	fmt.Fprintf(&buffer, i18n.Text("%s: rolled %d vs. %d"), r.Description, r.Rolled, r.Effective())
+	if len(r.Modifiers) != 0 {
+		parts := make([]string, 0, len(r.Modifiers))
+		for _, one := range r.Modifiers {
+			parts = append(parts, fmt.Sprintf("%+d %s", one.Amount, one.Description))
+		}
+		fmt.Fprintf(&buffer, i18n.Text(" (base %d; %s)"), r.Level, strings.Join(parts, ", "))
+	}
+	fmt.Fprintf(&buffer, i18n.Text(" — %s by %d"), r.Outcome, absInt(r.Margin))
+	return buffer.String()
+}
+
+// NewSuccessRoll makes a success roll of 3d6 against the level, after applying the modifiers.
+func NewSuccessRoll(description string, level int, modifiers []Modifier, noCriticals bool) *SuccessRoll {
+	r := &SuccessRoll{
+		When:        time.Now(),
+		Description: description,
+		Level:       level,
+		Modifiers:   modifiers,
+		Rolled:      dice.New("3d6").Roll(false),
+		NoCriticals: noCriticals,
+	}
+	if noCriticals {
+		r.Margin = r.Effective() - r.Rolled
+		if r.Margin >= 0 {
+			r.Outcome = Success
+		} else {
+			r.Outcome = Failure
+		}
+	} else {
+		r.Outcome, r.Margin = Evaluate(r.Effective(), r.Rolled)
+	}
+	return r
+}
+
+// Evaluate determines the outcome of a 3d6 roll made against the effective level, per B347-348, along with the margin
+// of success (positive) or failure (negative).
+func Evaluate(effective, rolled int) (outcome Outcome, margin int) {
+	margin = effective - rolled
+	switch {
+	case rolled <= 4,
+		rolled == 5 && effective >= 15,
+		rolled == 6 && effective >= 16:
+		return CriticalSuccess, margin
+	case rolled >= 18,
+		rolled == 17 && effective <= 15,
+		margin <= -10:
+		return CriticalFailure, margin
+	case rolled == 17:
+		return Failure, margin
+	case margin >= 0:
+		return Success, margin
+	default:
+		return Failure, margin
+	}
+}
+
+// DamageRoll holds the result of a damage roll.
+type DamageRoll struct {
+	When        time.Time
+	Description string
+	Spec        string
+	Type        string
+	Total       int
+}
+
+// String implements fmt.Stringer.
+func (r *DamageRoll) String() string {
+	text := fmt.Sprintf(i18n.Text("%s: rolled %d on %s"), r.Description, r.Total, r.Spec)
+	if r.Type != "" {
+		text += " " + r.Type
+	}
+	return text
+}
+
+// NewDamageRoll rolls the damage. The spec may be a dice expression, such as "2d+1", or a fixed amount of damage.
+// Anything following the dice expression, such as an armor divisor or the type of damage, is ignored, so that the
+// resolved damage of a weapon may be passed directly.
+func NewDamageRoll(description, spec, damageType string) (*DamageRoll, error) {
+	spec = strings.TrimSpace(spec)
+	if i := strings.IndexAny(spec, " ("); i != -1 {
+		spec = spec[:i]
+	}
+	r := &DamageRoll{
+		When:        time.Now(),
+		Description: description,
+		Spec:        spec,
+		Type:        damageType,
+	}
+	if spec == "" {
+		return nil, errs.New(i18n.Text("no damage to roll"))
+	}
+	if !strings.ContainsAny(spec, "dD") {
+		amount, err := strconv.Atoi(spec)
+		if err != nil {
+			return nil, errs.NewWithCause(fmt.Sprintf(i18n.Text("%s is not a valid amount of damage"), spec), err)
+		}
+		r.Total = amount
+		return r, nil
+	}
+	if r.Total = dice.New(spec).Roll(false); r.Total < 0 {
+		r.Total = 0
+	}
+	return r, nil
+}
+
+func absInt(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
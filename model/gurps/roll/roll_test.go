@@ -0,0 +1,75 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package roll_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/richardwilkes/gcs/v5/model/gurps/roll"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	for _, tc := range []struct {
+		effective int
+		rolled    int
+		outcome   roll.Outcome
+		margin    int
+	}{
+		// Very low skills: 3 and 4 still succeed critically, and failing by 10 or more is a critical failure.
+		{effective: 3, rolled: 3, outcome: roll.CriticalSuccess, margin: 0},
+		{effective: 3, rolled: 4, outcome: roll.CriticalSuccess, margin: -1},
+		{effective: 3, rolled: 5, outcome: roll.Failure, margin: -2},
+		{effective: 3, rolled: 12, outcome: roll.Failure, margin: -9},
+		{effective: 3, rolled: 13, outcome: roll.CriticalFailure, margin: -10},
+		{effective: 4, rolled: 13, outcome: roll.Failure, margin: -9},
+		{effective: 4, rolled: 14, outcome: roll.CriticalFailure, margin: -10},
+		{effective: 5, rolled: 5, outcome: roll.Success, margin: 0},
+		{effective: 5, rolled: 15, outcome: roll.CriticalFailure, margin: -10},
+		{effective: 6, rolled: 6, outcome: roll.Success, margin: 0},
+		{effective: 6, rolled: 15, outcome: roll.Failure, margin: -9},
+		{effective: 6, rolled: 16, outcome: roll.CriticalFailure, margin: -10},
+		{effective: 0, rolled: 10, outcome: roll.CriticalFailure, margin: -10},
+		{effective: -5, rolled: 3, outcome: roll.CriticalSuccess, margin: -8},
+
+		// Average skills.
+		{effective: 12, rolled: 12, outcome: roll.Success, margin: 0},
+		{effective: 12, rolled: 13, outcome: roll.Failure, margin: -1},
+		{effective: 12, rolled: 5, outcome: roll.Success, margin: 7},
+		{effective: 12, rolled: 16, outcome: roll.Failure, margin: -4},
+		{effective: 12, rolled: 17, outcome: roll.CriticalFailure, margin: -5},
+
+		// Skill 15: 5 becomes a critical success; 17 is still a critical failure.
+		{effective: 14, rolled: 5, outcome: roll.Success, margin: 9},
+		{effective: 15, rolled: 5, outcome: roll.CriticalSuccess, margin: 10},
+		{effective: 15, rolled: 6, outcome: roll.Success, margin: 9},
+		{effective: 15, rolled: 16, outcome: roll.Failure, margin: -1},
+		{effective: 15, rolled: 17, outcome: roll.CriticalFailure, margin: -2},
+		{effective: 15, rolled: 18, outcome: roll.CriticalFailure, margin: -3},
+
+		// Skill 16+: 6 becomes a critical success, 16 succeeds and 17 is only an ordinary failure.
+		{effective: 16, rolled: 6, outcome: roll.CriticalSuccess, margin: 10},
+		{effective: 16, rolled: 16, outcome: roll.Success, margin: 0},
+		{effective: 16, rolled: 17, outcome: roll.Failure, margin: -1},
+		{effective: 16, rolled: 18, outcome: roll.CriticalFailure, margin: -2},
+		{effective: 20, rolled: 17, outcome: roll.Failure, margin: 3},
+		{effective: 20, rolled: 18, outcome: roll.CriticalFailure, margin: 2},
+		{effective: 25, rolled: 7, outcome: roll.Success, margin: 18},
+	} {
+		outcome, margin := roll.Evaluate(tc.effective, tc.rolled)
+		what := fmt.Sprintf("rolled %d vs. %d", tc.rolled, tc.effective)
+		assert.Equal(t, tc.outcome, outcome, what)
+		assert.Equal(t, tc.margin, margin, what)
+		assert.Equal(t, tc.outcome >= roll.Success, outcome.Succeeded(), what)
+	}
+}
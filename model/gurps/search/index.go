@@ -0,0 +1,541 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+// Package search provides an index of the data files within the libraries, allowing their contents to be searched.
+package search
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/feature"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/log/jot"
+	"github.com/richardwilkes/toolbox/txt"
+	"github.com/rjeczalik/notify"
+)
+
+// TemplateKind is the kind used for entries that represent a template file as a whole.
+const TemplateKind = "template"
+
+// Entry holds the indexed information for a single item within a library data file.
+type Entry struct {
+	Library   *library.Library
+	FilePath  string
+	ID        uuid.UUID
+	Kind      string
+	Name      string
+	Tags      []string
+	PageRef   string
+	TechLevel string
+	Features  []feature.Type
+	Points    fxp.Int
+	Cost      fxp.Int
+	HasPoints bool
+	HasCost   bool
+	Container bool
+	text      string
+}
+
+// Load the item this entry refers to from its file. Returns one of *gurps.Trait, *gurps.TraitModifier, *gurps.Skill,
+// *gurps.Spell, *gurps.Equipment, *gurps.EquipmentModifier, *gurps.Note or *gurps.Template.
+func (e *Entry) Load() (any, error) {
+	fileSystem, name := os.DirFS(filepath.Dir(e.FilePath)), filepath.Base(e.FilePath)
+	var found any
+	switch strings.ToLower(filepath.Ext(e.FilePath)) {
+	case library.TraitsExt:
+		list, err := gurps.NewTraitsFromFile(fileSystem, name)
+		if err != nil {
+			return nil, err
+		}
+		found = findByID(list, e.ID)
+	case library.TraitModifiersExt:
+		list, err := gurps.NewTraitModifiersFromFile(fileSystem, name)
+		if err != nil {
+			return nil, err
+		}
+		found = findByID(list, e.ID)
+	case library.SkillsExt:
+		list, err := gurps.NewSkillsFromFile(fileSystem, name)
+		if err != nil {
+			return nil, err
+		}
+		found = findByID(list, e.ID)
+	case library.SpellsExt:
+		list, err := gurps.NewSpellsFromFile(fileSystem, name)
+		if err != nil {
+			return nil, err
+		}
+		found = findByID(list, e.ID)
+	case library.EquipmentExt:
+		list, err := gurps.NewEquipmentFromFile(fileSystem, name)
+		if err != nil {
+			return nil, err
+		}
+		found = findByID(list, e.ID)
+	case library.EquipmentModifiersExt:
+		list, err := gurps.NewEquipmentModifiersFromFile(fileSystem, name)
+		if err != nil {
+			return nil, err
+		}
+		found = findByID(list, e.ID)
+	case library.NotesExt:
+		list, err := gurps.NewNotesFromFile(fileSystem, name)
+		if err != nil {
+			return nil, err
+		}
+		found = findByID(list, e.ID)
+	case library.TemplatesExt:
+		t, err := gurps.NewTemplateFromFile(fileSystem, name)
+		if err != nil {
+			return nil, err
+		}
+		found = t
+	}
+	if found == nil {
+		return nil, errs.Newf("unable to locate %s in %s", e.Name, e.FilePath)
+	}
+	return found, nil
+}
+
+// Index holds the searchable entries for the data files within a set of libraries. It is safe to use from multiple
+// goroutines.
+type Index struct {
+	lock     sync.RWMutex
+	files    map[string][]*Entry
+	tokens   []*library.MonitorToken
+	changed  func()
+	building bool
+	stopped  bool
+}
+
+// NewIndex creates a new, empty, index.
+func NewIndex() *Index {
+	return &Index{files: make(map[string][]*Entry)}
+}
+
+// SetChangeCallback sets the function to call after the contents of the index have changed. It will be called from a
+// goroutine other than the one that is making the change.
+func (x *Index) SetChangeCallback(f func()) {
+	x.lock.Lock()
+	x.changed = f
+	x.lock.Unlock()
+}
+
+// Building returns true while the index is being built.
+func (x *Index) Building() bool {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+	return x.building
+}
+
+// Build discards any existing content and indexes every data file in the libraries, then watches the libraries for
+// changes, updating the index incrementally as files are added, removed or renamed.
+func (x *Index) Build(libraries library.Libraries) {
+	x.Stop()
+	x.lock.Lock()
+	x.files = make(map[string][]*Entry)
+	x.building = true
+	x.stopped = false
+	x.lock.Unlock()
+	libs := libraries.List()
+	for _, lib := range libs {
+		x.indexTree(lib, lib.Path())
+	}
+	tokens := make([]*library.MonitorToken, 0, len(libs))
+	for _, lib := range libs {
+		tokens = append(tokens, lib.Watch(x.libraryChanged, false))
+	}
+	x.lock.Lock()
+	x.building = false
+	stopped := x.stopped
+	if !stopped {
+		x.tokens = tokens
+	}
+	x.lock.Unlock()
+	if stopped {
+		// Stop was called while the index was being built
+		for _, token := range tokens {
+			token.Stop()
+		}
+		return
+	}
+	x.notify()
+}
+
+// Stop watching the libraries for changes.
+func (x *Index) Stop() {
+	x.lock.Lock()
+	tokens := x.tokens
+	x.tokens = nil
+	x.stopped = true
+	x.lock.Unlock()
+	for _, token := range tokens {
+		token.Stop()
+	}
+}
+
+// Count returns the number of entries in the index.
+func (x *Index) Count() int {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+	count := 0
+	for _, entries := range x.files {
+		count += len(entries)
+	}
+	return count
+}
+
+// Search returns the entries that match the query, sorted by name.
+func (x *Index) Search(query *Query) []*Entry {
+	var result []*Entry
+	x.lock.RLock()
+	for _, entries := range x.files {
+		for _, one := range entries {
+			if query.Matches(one) {
+				result = append(result, one)
+			}
+		}
+	}
+	x.lock.RUnlock()
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return txt.NaturalLess(result[i].Name, result[j].Name, true)
+		}
+		return txt.NaturalLess(result[i].FilePath, result[j].FilePath, true)
+	})
+	return result
+}
+
+func (x *Index) libraryChanged(lib *library.Library, fullPath string, what notify.Event) {
+	if what == library.EventRootSync {
+		x.removeTree(lib.Path())
+		x.indexTree(lib, lib.Path())
+	} else {
+		x.removeTree(fullPath)
+		if fi, err := os.Stat(fullPath); err == nil {
+			if fi.IsDir() {
+				x.indexTree(lib, fullPath)
+			} else {
+				x.indexFile(lib, fullPath)
+			}
+		}
+	}
+	x.notify()
+}
+
+func (x *Index) notify() {
+	x.lock.RLock()
+	f := x.changed
+	x.lock.RUnlock()
+	if f != nil {
+		f()
+	}
+}
+
+func (x *Index) removeTree(fullPath string) {
+	prefix := fullPath + string(filepath.Separator)
+	x.lock.Lock()
+	for p := range x.files {
+		if p == fullPath || strings.HasPrefix(p, prefix) {
+			delete(x.files, p)
+		}
+	}
+	x.lock.Unlock()
+}
+
+func (x *Index) indexTree(lib *library.Library, root string) {
+	if err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil //nolint:nilerr // Skip anything we can't read, rather than abandoning the whole tree
+		}
+		name := d.Name()
+		if p != root && strings.HasPrefix(name, ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			x.indexFile(lib, p)
+		}
+		return nil
+	}); err != nil {
+		jot.Warn(errs.NewWithCause("unable to index "+root, err))
+	}
+}
+
+func (x *Index) indexFile(lib *library.Library, fullPath string) {
+	entries, err := loadEntries(lib, fullPath)
+	if err != nil {
+		jot.Warn(errs.NewWithCause("unable to index "+fullPath, err))
+		return
+	}
+	if entries != nil {
+		x.lock.Lock()
+		x.files[fullPath] = entries
+		x.lock.Unlock()
+	}
+}
+
+func loadEntries(lib *library.Library, fullPath string) ([]*Entry, error) {
+	fileSystem, name := os.DirFS(filepath.Dir(fullPath)), filepath.Base(fullPath)
+	b := &builder{lib: lib, filePath: fullPath}
+	switch strings.ToLower(filepath.Ext(fullPath)) {
+	case library.TraitsExt:
+		list, err := gurps.NewTraitsFromFile(fileSystem, name)
+		if err != nil {
+			return nil, err
+		}
+		walk(list, b.addTrait)
+	case library.TraitModifiersExt:
+		list, err := gurps.NewTraitModifiersFromFile(fileSystem, name)
+		if err != nil {
+			return nil, err
+		}
+		walk(list, b.addTraitModifier)
+	case library.SkillsExt:
+		list, err := gurps.NewSkillsFromFile(fileSystem, name)
+		if err != nil {
+			return nil, err
+		}
+		walk(list, b.addSkill)
+	case library.SpellsExt:
+		list, err := gurps.NewSpellsFromFile(fileSystem, name)
+		if err != nil {
+			return nil, err
+		}
+		walk(list, b.addSpell)
+	case library.EquipmentExt:
+		list, err := gurps.NewEquipmentFromFile(fileSystem, name)
+		if err != nil {
+			return nil, err
+		}
+		walk(list, b.addEquipment)
+	case library.EquipmentModifiersExt:
+		list, err := gurps.NewEquipmentModifiersFromFile(fileSystem, name)
+		if err != nil {
+			return nil, err
+		}
+		walk(list, b.addEquipmentModifier)
+	case library.NotesExt:
+		list, err := gurps.NewNotesFromFile(fileSystem, name)
+		if err != nil {
+			return nil, err
+		}
+		walk(list, b.addNote)
+	case library.TemplatesExt:
+		t, err := gurps.NewTemplateFromFile(fileSystem, name)
+		if err != nil {
+			return nil, err
+		}
+		b.addTemplate(t)
+	default:
+		return nil, nil
+	}
+	if b.entries == nil {
+		b.entries = []*Entry{}
+	}
+	return b.entries, nil
+}
+
+type builder struct {
+	lib      *library.Library
+	filePath string
+	entries  []*Entry
+}
+
+func (b *builder) add(e *Entry, extraText ...string) {
+	e.Library = b.lib
+	e.FilePath = b.filePath
+	parts := make([]string, 0, 3+len(e.Tags)+len(extraText))
+	parts = append(parts, e.Name, e.PageRef)
+	parts = append(parts, e.Tags...)
+	parts = append(parts, extraText...)
+	e.text = strings.ToLower(strings.Join(parts, "\n"))
+	b.entries = append(b.entries, e)
+}
+
+func (b *builder) addTrait(t *gurps.Trait) {
+	b.add(&Entry{
+		ID:        t.ID,
+		Kind:      gid.Trait,
+		Name:      t.Name,
+		Tags:      t.Tags,
+		PageRef:   t.PageRef,
+		Features:  featureTypes(t.Features),
+		Points:    t.AdjustedPoints(),
+		HasPoints: true,
+		Container: t.Container(),
+	}, t.LocalNotes, t.UserDesc)
+}
+
+func (b *builder) addTraitModifier(m *gurps.TraitModifier) {
+	b.add(&Entry{
+		ID:        m.ID,
+		Kind:      gid.TraitModifier,
+		Name:      m.Name,
+		Tags:      m.Tags,
+		PageRef:   m.PageRef,
+		Features:  featureTypes(m.Features),
+		Cost:      m.Cost,
+		HasCost:   !m.Container(),
+		Container: m.Container(),
+	}, m.LocalNotes)
+}
+
+func (b *builder) addSkill(s *gurps.Skill) {
+	name := s.Name
+	if s.Specialization != "" {
+		name += " (" + s.Specialization + ")"
+	}
+	e := &Entry{
+		ID:        s.ID,
+		Kind:      gid.Skill,
+		Name:      name,
+		Tags:      s.Tags,
+		PageRef:   s.PageRef,
+		Features:  featureTypes(s.Features),
+		Points:    s.Points,
+		HasPoints: !s.Container(),
+		Container: s.Container(),
+	}
+	if s.TechLevel != nil {
+		e.TechLevel = *s.TechLevel
+	}
+	b.add(e, s.LocalNotes)
+}
+
+func (b *builder) addSpell(s *gurps.Spell) {
+	e := &Entry{
+		ID:        s.ID,
+		Kind:      gid.Spell,
+		Name:      s.Name,
+		Tags:      s.Tags,
+		PageRef:   s.PageRef,
+		Points:    s.Points,
+		HasPoints: !s.Container(),
+		Container: s.Container(),
+	}
+	if s.TechLevel != nil {
+		e.TechLevel = *s.TechLevel
+	}
+	extra := append([]string{s.LocalNotes, s.PowerSource, s.Class}, s.College...)
+	b.add(e, extra...)
+}
+
+func (b *builder) addEquipment(eqp *gurps.Equipment) {
+	b.add(&Entry{
+		ID:        eqp.ID,
+		Kind:      gid.Equipment,
+		Name:      eqp.Name,
+		Tags:      eqp.Tags,
+		PageRef:   eqp.PageRef,
+		TechLevel: eqp.TechLevel,
+		Features:  featureTypes(eqp.Features),
+		Cost:      eqp.ValueToBase(eqp.Entity, eqp.AdjustedValue()),
+		HasCost:   true,
+		Container: eqp.Container(),
+	}, eqp.LocalNotes)
+}
+
+func (b *builder) addEquipmentModifier(m *gurps.EquipmentModifier) {
+	b.add(&Entry{
+		ID:        m.ID,
+		Kind:      gid.EquipmentModifier,
+		Name:      m.Name,
+		Tags:      m.Tags,
+		PageRef:   m.PageRef,
+		TechLevel: m.TechLevel,
+		Features:  featureTypes(m.Features),
+		Container: m.Container(),
+	}, m.LocalNotes, m.CostAmount)
+}
+
+func (b *builder) addNote(n *gurps.Note) {
+	name := strings.TrimSpace(n.Text)
+	if i := strings.IndexByte(name, '\n'); i != -1 {
+		name = strings.TrimSpace(name[:i])
+	}
+	b.add(&Entry{
+		ID:        n.ID,
+		Kind:      gid.Note,
+		Name:      name,
+		PageRef:   n.PageRef,
+		Container: n.Container(),
+	}, n.Text)
+}
+
+func (b *builder) addTemplate(t *gurps.Template) {
+	e := &Entry{
+		ID:        t.ID,
+		Kind:      TemplateKind,
+		Name:      strings.TrimSuffix(filepath.Base(b.filePath), filepath.Ext(b.filePath)),
+		HasPoints: true,
+	}
+	var extra []string
+	for _, one := range t.Traits {
+		e.Points += one.AdjustedPoints()
+	}
+	walk(t.Traits, func(one *gurps.Trait) {
+		extra = append(extra, one.Name)
+		e.Features = append(e.Features, featureTypes(one.Features)...)
+	})
+	walk(t.Skills, func(one *gurps.Skill) {
+		e.Points += one.Points
+		extra = append(extra, one.Name)
+	})
+	walk(t.Spells, func(one *gurps.Spell) {
+		e.Points += one.Points
+		extra = append(extra, one.Name)
+	})
+	walk(t.Equipment, func(one *gurps.Equipment) { extra = append(extra, one.Name) })
+	b.add(e, extra...)
+}
+
+func featureTypes(features feature.Features) []feature.Type {
+	if len(features) == 0 {
+		return nil
+	}
+	types := make([]feature.Type, 0, len(features))
+	for _, one := range features {
+		types = append(types, one.FeatureType())
+	}
+	return types
+}
+
+func walk[T gurps.Node[T]](list []T, f func(T)) {
+	for _, one := range list {
+		f(one)
+		if one.HasChildren() {
+			walk(one.NodeChildren(), f)
+		}
+	}
+}
+
+func findByID[T gurps.Node[T]](list []T, id uuid.UUID) any {
+	var found any
+	walk(list, func(one T) {
+		if found == nil && one.UUID() == id {
+			found = one
+		}
+	})
+	return found
+}
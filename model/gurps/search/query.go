@@ -0,0 +1,235 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/i18n"
+)
+
+// QuerySyntax describes the syntax accepted by ParseQuery.
+var QuerySyntax = i18n.Text(`Words without a prefix are matched against the name, tags, page reference and notes.
+Values containing spaces may be quoted. The following prefixes are also available:
+
+name:text       the name contains the text
+tag:text        one of the tags contains the text
+ref:text        the page reference contains the text
+points:10..20   the points fall within the range; either end may be omitted, e.g. points:..5
+cost:..100      the cost or value falls within the range
+tl:3            the tech level contains the text
+feature:dr      one of the features has a type containing the text, e.g. dr_bonus
+type:skill      the item is of the type: trait, trait_modifier, skill, spell, equipment, equipment_modifier, note
+                or template`)
+
+var kindAliases = map[string]string{
+	"advantage":          gid.Trait,
+	"trait":              gid.Trait,
+	"modifier":           gid.TraitModifier,
+	"trait_modifier":     gid.TraitModifier,
+	"skill":              gid.Skill,
+	"technique":          gid.Skill,
+	"spell":              gid.Spell,
+	"equipment":          gid.Equipment,
+	"eqp":                gid.Equipment,
+	"equipment_modifier": gid.EquipmentModifier,
+	"eqm":                gid.EquipmentModifier,
+	"note":               gid.Note,
+	"template":           TemplateKind,
+}
+
+// Range holds an inclusive range of values. Either end may be left open.
+type Range struct {
+	Min    fxp.Int
+	Max    fxp.Int
+	HasMin bool
+	HasMax bool
+}
+
+// IsSet returns true if either end of the range has been set.
+func (r Range) IsSet() bool {
+	return r.HasMin || r.HasMax
+}
+
+// Contains returns true if the value falls within the range.
+func (r Range) Contains(value fxp.Int) bool {
+	return (!r.HasMin || value >= r.Min) && (!r.HasMax || value <= r.Max)
+}
+
+// Query holds the criteria for a search. Text criteria are lowercase and are matched as substrings. All criteria that
+// are set must be satisfied for an entry to match.
+type Query struct {
+	Words     []string
+	Name      string
+	Tag       string
+	PageRef   string
+	TechLevel string
+	Feature   string
+	Kinds     map[string]bool
+	Points    Range
+	Cost      Range
+}
+
+// ParseQuery parses the text of a query. See QuerySyntax for a description of what is accepted.
+func ParseQuery(text string) (*Query, error) {
+	q := &Query{}
+	for _, term := range splitTerms(text) {
+		key, value, found := strings.Cut(term, ":")
+		if !found {
+			q.Words = append(q.Words, strings.ToLower(term))
+			continue
+		}
+		value = strings.ToLower(value)
+		var err error
+		switch strings.ToLower(key) {
+		case "name":
+			q.Name = value
+		case "tag":
+			q.Tag = value
+		case "ref":
+			q.PageRef = value
+		case "tl":
+			q.TechLevel = value
+		case "feature":
+			q.Feature = value
+		case "points":
+			q.Points, err = parseRange(value)
+		case "cost":
+			q.Cost, err = parseRange(value)
+		case "type":
+			kind, ok := kindAliases[value]
+			if !ok {
+				return nil, errs.New(fmt.Sprintf(i18n.Text("unknown type: %s"), value))
+			}
+			if q.Kinds == nil {
+				q.Kinds = make(map[string]bool)
+			}
+			q.Kinds[kind] = true
+		default:
+			q.Words = append(q.Words, strings.ToLower(term))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+// Empty returns true if the query has no criteria.
+func (q *Query) Empty() bool {
+	return len(q.Words) == 0 && q.Name == "" && q.Tag == "" && q.PageRef == "" && q.TechLevel == "" &&
+		q.Feature == "" && len(q.Kinds) == 0 && !q.Points.IsSet() && !q.Cost.IsSet()
+}
+
+// Matches returns true if the entry satisfies the query.
+func (q *Query) Matches(e *Entry) bool {
+	if len(q.Kinds) != 0 && !q.Kinds[e.Kind] {
+		return false
+	}
+	for _, word := range q.Words {
+		if !strings.Contains(e.text, word) {
+			return false
+		}
+	}
+	if q.Name != "" && !strings.Contains(strings.ToLower(e.Name), q.Name) {
+		return false
+	}
+	if q.PageRef != "" && !strings.Contains(strings.ToLower(e.PageRef), q.PageRef) {
+		return false
+	}
+	if q.TechLevel != "" && !strings.Contains(strings.ToLower(e.TechLevel), q.TechLevel) {
+		return false
+	}
+	if q.Tag != "" && !anyContains(e.Tags, q.Tag) {
+		return false
+	}
+	if q.Feature != "" {
+		found := false
+		for _, one := range e.Features {
+			if strings.Contains(one.Key(), q.Feature) || strings.Contains(strings.ToLower(one.String()), q.Feature) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.Points.IsSet() && (!e.HasPoints || !q.Points.Contains(e.Points)) {
+		return false
+	}
+	if q.Cost.IsSet() && (!e.HasCost || !q.Cost.Contains(e.Cost)) {
+		return false
+	}
+	return true
+}
+
+func anyContains(list []string, text string) bool {
+	for _, one := range list {
+		if strings.Contains(strings.ToLower(one), text) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseRange(text string) (Range, error) {
+	var r Range
+	minText, maxText, isRange := strings.Cut(text, "..")
+	if !isRange {
+		maxText = minText
+	}
+	var err error
+	if minText = strings.TrimSpace(minText); minText != "" {
+		if r.Min, err = fxp.FromString(minText); err != nil {
+			return r, errs.NewWithCause(fmt.Sprintf(i18n.Text("invalid range: %s"), text), err)
+		}
+		r.HasMin = true
+	}
+	if maxText = strings.TrimSpace(maxText); maxText != "" {
+		if r.Max, err = fxp.FromString(maxText); err != nil {
+			return r, errs.NewWithCause(fmt.Sprintf(i18n.Text("invalid range: %s"), text), err)
+		}
+		r.HasMax = true
+	}
+	return r, nil
+}
+
+// splitTerms splits the text on whitespace, keeping quoted sections together. Quotes may appear anywhere within a
+// term, so that both "a b" and name:"a b" are treated as single terms.
+func splitTerms(text string) []string {
+	var terms []string
+	var buffer strings.Builder
+	inQuote := false
+	for _, ch := range text {
+		switch {
+		case ch == '"':
+			inQuote = !inQuote
+		case unicode.IsSpace(ch) && !inQuote:
+			if buffer.Len() != 0 {
+				terms = append(terms, buffer.String())
+				buffer.Reset()
+			}
+		default:
+			buffer.WriteRune(ch)
+		}
+	}
+	if buffer.Len() != 0 {
+		terms = append(terms, buffer.String())
+	}
+	return terms
+}
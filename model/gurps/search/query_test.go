@@ -0,0 +1,78 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package search_test
+
+import (
+	"testing"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/model/gurps/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuery(t *testing.T) {
+	for _, tc := range []struct {
+		text     string
+		expected *search.Query
+	}{
+		{text: "", expected: &search.Query{}},
+		{text: "  Broad  Sword ", expected: &search.Query{Words: []string{"broad", "sword"}}},
+		{text: `"Combat Reflexes" perk`, expected: &search.Query{Words: []string{"combat reflexes", "perk"}}},
+		{text: `name:"Area Knowledge"`, expected: &search.Query{Name: "area knowledge"}},
+		{text: "Tag:Combat ref:B43 tl:8 feature:DR", expected: &search.Query{
+			Tag:       "combat",
+			PageRef:   "b43",
+			TechLevel: "8",
+			Feature:   "dr",
+		}},
+		{text: "points:10..20", expected: &search.Query{
+			Points: search.Range{Min: fxp.From(10), Max: fxp.From(20), HasMin: true, HasMax: true},
+		}},
+		{text: "points:..5", expected: &search.Query{Points: search.Range{Max: fxp.From(5), HasMax: true}}},
+		{text: "cost:100..", expected: &search.Query{Cost: search.Range{Min: fxp.From(100), HasMin: true}}},
+		{text: "cost:2.5", expected: &search.Query{
+			Cost: search.Range{Min: fxp.From(2.5), Max: fxp.From(2.5), HasMin: true, HasMax: true},
+		}},
+		{text: "type:skill type:eqp", expected: &search.Query{
+			Kinds: map[string]bool{gid.Skill: true, gid.Equipment: true},
+		}},
+		{text: "type:template", expected: &search.Query{Kinds: map[string]bool{search.TemplateKind: true}}},
+		{text: "odd:prefix", expected: &search.Query{Words: []string{"odd:prefix"}}},
+	} {
+		q, err := search.ParseQuery(tc.text)
+		require.NoError(t, err, tc.text)
+		assert.Equal(t, tc.expected, q, tc.text)
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	for _, text := range []string{"type:widget", "points:ten", "cost:1..x", "points:a..b"} {
+		_, err := search.ParseQuery(text)
+		assert.Error(t, err, text)
+	}
+}
+
+func TestQueryEmpty(t *testing.T) {
+	for text, empty := range map[string]bool{
+		"":           true,
+		"   ":        true,
+		"word":       false,
+		"points:..1": false,
+		"type:note":  false,
+	} {
+		q, err := search.ParseQuery(text)
+		require.NoError(t, err, text)
+		assert.Equal(t, empty, q.Empty(), text)
+	}
+}
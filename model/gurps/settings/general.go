@@ -13,6 +13,8 @@ package settings
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"io/fs"
 	"os"
 	"os/user"
@@ -21,6 +23,7 @@ import (
 	"github.com/richardwilkes/gcs/v5/model/fxp"
 	"github.com/richardwilkes/gcs/v5/model/jio"
 	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/json"
 	"github.com/richardwilkes/toolbox/log/jot"
 	"github.com/richardwilkes/unison"
 )
@@ -43,6 +46,15 @@ var (
 	InitialUIScaleMax      = 400
 	InitialListUIScaleDef  = 100
 	InitialSheetUIScaleDef = 133
+	DisadvantageLimitDef   = fxp.From(75)
+	DisadvantageLimitMin   fxp.Int
+	DisadvantageLimitMax   = fxp.From(9999999)
+	QuirkLimitDef          = fxp.Five
+	QuirkLimitMin          fxp.Int
+	QuirkLimitMax          = fxp.From(9999999)
+	APIServerPortDef       = 8998
+	APIServerPortMin       = 1024
+	APIServerPortMax       = 65535
 )
 
 // General holds settings for a sheet.
@@ -59,6 +71,13 @@ type General struct {
 	AutoFillProfile             bool    `json:"auto_fill_profile"`
 	AutoAddNaturalAttacks       bool    `json:"add_natural_attacks"`
 	IncludeUnspentPointsInTotal bool    `json:"include_unspent_points_in_total"`
+	DisadvantageLimit           fxp.Int `json:"disadvantage_limit"`
+	QuirkLimit                  fxp.Int `json:"quirk_limit"`
+	APIServerEnabled            bool    `json:"api_server_enabled,omitempty"`
+	APIServerPort               int     `json:"api_server_port"`
+	// APIServerToken is kept out of the settings file, so that it isn't handed out along with exported settings. It is
+	// stored in a file of its own, readable only by the user.
+	APIServerToken string `json:"-"`
 }
 
 // NewGeneral creates settings with factory defaults.
@@ -81,29 +100,56 @@ func NewGeneral() *General {
 		AutoFillProfile:             true,
 		AutoAddNaturalAttacks:       true,
 		IncludeUnspentPointsInTotal: true,
+		DisadvantageLimit:           DisadvantageLimitDef,
+		QuirkLimit:                  QuirkLimitDef,
+		APIServerPort:               APIServerPortDef,
+		APIServerToken:              NewAPIServerToken(),
 	}
 }
 
+// NewAPIServerToken creates a new random token for clients of the local API server to present.
+func NewAPIServerToken() string {
+	var buffer [16]byte
+	if _, err := rand.Read(buffer[:]); err != nil {
+		jot.Error(err)
+	}
+	return hex.EncodeToString(buffer[:])
+}
+
 // NewGeneralFromFile loads new settings from a file.
 func NewGeneralFromFile(fileSystem fs.FS, filePath string) (*General, error) {
 	var data struct {
-		General     `json:",inline"`
 		OldLocation *General `json:"general"`
 	}
 	if err := jio.LoadFromFS(context.Background(), fileSystem, filePath, &data); err != nil {
 		return nil, err
 	}
-	var s *General
-	if data.OldLocation != nil {
-		s = data.OldLocation
-	} else {
-		settings := data.General
-		s = &settings
+	s := data.OldLocation
+	if s == nil {
+		s = &General{}
+		if err := jio.LoadFromFS(context.Background(), fileSystem, filePath, s); err != nil {
+			return nil, err
+		}
 	}
 	s.EnsureValidity()
 	return s, nil
 }
 
+// UnmarshalJSON implements json.Unmarshaler. Settings written before the disadvantage and quirk limits existed don't
+// have them, and since zero means "no limit", they are given their defaults instead.
+func (s *General) UnmarshalJSON(data []byte) error {
+	type generalNoUnmarshal General
+	g := generalNoUnmarshal{
+		DisadvantageLimit: DisadvantageLimitDef,
+		QuirkLimit:        QuirkLimitDef,
+	}
+	if err := json.Unmarshal(data, &g); err != nil {
+		return err
+	}
+	*s = General(g)
+	return nil
+}
+
 // Save writes the settings to the file as JSON.
 func (s *General) Save(filePath string) error {
 	return jio.SaveToFile(context.Background(), filePath, s)
@@ -134,4 +180,10 @@ func (s *General) EnsureValidity() {
 	s.ImageResolution = fxp.ResetIfOutOfRangeInt(s.ImageResolution, ImageResolutionMin, ImageResolutionMax, ImageResolutionDef)
 	s.InitialListUIScale = fxp.ResetIfOutOfRangeInt(s.InitialListUIScale, InitialUIScaleMin, InitialUIScaleMax, InitialListUIScaleDef)
 	s.InitialSheetUIScale = fxp.ResetIfOutOfRangeInt(s.InitialSheetUIScale, InitialUIScaleMin, InitialUIScaleMax, InitialSheetUIScaleDef)
+	s.DisadvantageLimit = fxp.ResetIfOutOfRange(s.DisadvantageLimit, DisadvantageLimitMin, DisadvantageLimitMax, DisadvantageLimitDef)
+	s.QuirkLimit = fxp.ResetIfOutOfRange(s.QuirkLimit, QuirkLimitMin, QuirkLimitMax, QuirkLimitDef)
+	s.APIServerPort = fxp.ResetIfOutOfRangeInt(s.APIServerPort, APIServerPortMin, APIServerPortMax, APIServerPortDef)
+	if s.APIServerToken == "" {
+		s.APIServerToken = NewAPIServerToken()
+	}
 }
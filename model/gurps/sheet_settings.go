@@ -51,6 +51,8 @@ type SheetSettingsData struct {
 	DamageProgression          attribute.DamageProgression `json:"damage_progression"`
 	DefaultLengthUnits         measure.LengthUnits         `json:"default_length_units"`
 	DefaultWeightUnits         measure.WeightUnits         `json:"default_weight_units"`
+	Currencies                 []*Currency                 `json:"currencies,omitempty"`
+	DisplayCurrency            string                      `json:"display_currency,omitempty"`
 	UserDescriptionDisplay     display.Option              `json:"user_description_display"`
 	ModifiersDisplay           display.Option              `json:"modifiers_display"`
 	NotesDisplay               display.Option              `json:"notes_display"`
@@ -145,6 +147,16 @@ func (s *SheetSettings) EnsureValidity() {
 	s.DamageProgression = s.DamageProgression.EnsureValid()
 	s.DefaultLengthUnits = s.DefaultLengthUnits.EnsureValid()
 	s.DefaultWeightUnits = s.DefaultWeightUnits.EnsureValid()
+	if len(s.Currencies) != 0 {
+		currencies := make([]*Currency, 0, len(s.Currencies))
+		for _, one := range s.Currencies {
+			if one != nil {
+				one.EnsureValidity()
+				currencies = append(currencies, one)
+			}
+		}
+		s.Currencies = currencies
+	}
 	s.UserDescriptionDisplay = s.UserDescriptionDisplay.EnsureValid()
 	s.ModifiersDisplay = s.ModifiersDisplay.EnsureValid()
 	s.NotesDisplay = s.NotesDisplay.EnsureValid()
@@ -173,6 +185,12 @@ func (s *SheetSettings) Clone(entity *Entity) *SheetSettings {
 	clone.BlockLayout = s.BlockLayout.Clone()
 	clone.Attributes = s.Attributes.Clone()
 	clone.BodyType = s.BodyType.Clone(entity, nil)
+	if s.Currencies != nil {
+		clone.Currencies = make([]*Currency, len(s.Currencies))
+		for i, one := range s.Currencies {
+			clone.Currencies[i] = one.Clone()
+		}
+	}
 	return &clone
 }
 
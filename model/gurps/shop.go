@@ -0,0 +1,215 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package gurps
+
+import (
+	"fmt"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/i18n"
+	"golang.org/x/exp/slices"
+)
+
+// Shop holds the equipment a merchant has for sale and the terms the merchant trades on.
+type Shop struct {
+	Stock []*Equipment
+	// PriceMultiplier is applied to the value of equipment bought from the shop.
+	PriceMultiplier fxp.Int
+	// SellRate is the fraction of its value the shop pays for equipment sold to it.
+	SellRate fxp.Int
+}
+
+// NewShop creates a new Shop that charges full price and buys equipment back at half its value.
+func NewShop(stock []*Equipment) *Shop {
+	return &Shop{
+		Stock:           stock,
+		PriceMultiplier: fxp.One,
+		SellRate:        fxp.Half,
+	}
+}
+
+// Price returns what the entity would pay for the equipment, including its contents, in the base currency. The
+// equipment's value is interpreted using the entity's currencies, since that is where it will end up.
+func (s *Shop) Price(entity *Entity, eqp *Equipment) fxp.Int {
+	return eqp.Clone(entity, nil, false).ExtendedValue().Mul(s.PriceMultiplier)
+}
+
+// Offer returns what the shop would pay for the entity's equipment, including its contents, in the base currency.
+func (s *Shop) Offer(eqp *Equipment) fxp.Int {
+	return eqp.ExtendedValue().Mul(s.SellRate)
+}
+
+// Buy the equipment for the entity, paying for it from the purse. A nil purse pays from the entity's funds. Payment
+// from a purse is made in whole coins, with any change going to the entity's funds. The purchased copies are added to
+// the entity's carried equipment and returned.
+func (s *Shop) Buy(entity *Entity, purse *Equipment, items []*Equipment) ([]*Equipment, error) {
+	var total fxp.Int
+	for _, one := range items {
+		total += s.Price(entity, one)
+	}
+	if total > entity.Money(purse) {
+		return nil, errs.New(fmt.Sprintf(i18n.Text("%s is needed, but only %s is available"),
+			entity.SheetSettings.FormatWealth(total), entity.SheetSettings.FormatWealth(entity.Money(purse))))
+	}
+	bought := make([]*Equipment, 0, len(items))
+	for _, one := range items {
+		bought = append(bought, one.Clone(entity, nil, false))
+	}
+	entity.AdjustMoney(purse, -total)
+	entity.CarriedEquipment = append(entity.CarriedEquipment, bought...)
+	entity.Recalculate()
+	return bought, nil
+}
+
+// Sell the entity's equipment, placing the proceeds into the purse. A nil purse places them into the entity's funds, as
+// does any part of the proceeds too small to be paid in the purse's coins.
+// Equipment that is, or contains, the purse is not sold. Returns the amount received, in the base currency.
+func (s *Shop) Sell(entity *Entity, purse *Equipment, items []*Equipment) fxp.Int {
+	var total fxp.Int
+	for _, one := range items {
+		if purse != nil && containsEquipment(one, purse) {
+			continue
+		}
+		total += s.Offer(one)
+		removeEquipment(entity, one)
+	}
+	entity.AdjustMoney(purse, total)
+	entity.Recalculate()
+	return total
+}
+
+func containsEquipment(eqp, target *Equipment) bool {
+	found := false
+	Traverse(func(one *Equipment) bool {
+		found = one == target
+		return found
+	}, false, false, eqp)
+	return found
+}
+
+func removeEquipment(entity *Entity, eqp *Equipment) {
+	if parent := eqp.Parent(); parent != nil {
+		if i := slices.Index(parent.Children, eqp); i != -1 {
+			parent.Children = slices.Delete(slices.Clone(parent.Children), i, i+1)
+		}
+		return
+	}
+	if i := slices.Index(entity.CarriedEquipment, eqp); i != -1 {
+		entity.CarriedEquipment = slices.Delete(slices.Clone(entity.CarriedEquipment), i, i+1)
+	} else if i = slices.Index(entity.OtherEquipment, eqp); i != -1 {
+		entity.OtherEquipment = slices.Delete(slices.Clone(entity.OtherEquipment), i, i+1)
+	}
+}
+
+// Purses returns the equipment the entity can pay with, i.e. the equipment marked as coins.
+func (e *Entity) Purses() []*Equipment {
+	var list []*Equipment
+	f := func(eqp *Equipment) bool {
+		if eqp.Coins {
+			list = append(list, eqp)
+		}
+		return false
+	}
+	Traverse(f, false, false, e.CarriedEquipment...)
+	Traverse(f, false, false, e.OtherEquipment...)
+	return list
+}
+
+// Money returns the amount of money in the purse, in the base currency. Only whole coins count. A nil purse refers to
+// the entity's funds.
+func (e *Entity) Money(purse *Equipment) fxp.Int {
+	if purse == nil {
+		return e.Funds
+	}
+	return purseUnitValue(e, purse).Mul(purse.Quantity.Max(0).Trunc())
+}
+
+// AdjustMoney adds the amount, in the base currency, to the purse. A nil purse refers to the entity's funds. Purses
+// only deal in whole coins, so a payment takes enough coins to cover it and money received is added as the number of
+// whole coins it buys. In either case, the difference is added to the entity's funds as change.
+func (e *Entity) AdjustMoney(purse *Equipment, amount fxp.Int) {
+	var unit fxp.Int
+	if purse != nil {
+		unit = purseUnitValue(e, purse)
+	}
+	if unit <= 0 {
+		e.Funds += amount
+		return
+	}
+	coins := amount.Div(unit).Trunc()
+	if coins.Mul(unit) > amount {
+		coins -= fxp.One
+	}
+	purse.Quantity += coins
+	e.Funds += amount - coins.Mul(unit)
+}
+
+func purseUnitValue(entity *Entity, purse *Equipment) fxp.Int {
+	return purse.ValueToBase(entity, purse.AdjustedValue())
+}
+
+// TransactionState holds a snapshot of an entity's equipment, money and the contents of its containers, suitable for
+// restoring later, such as when undoing a purchase or sale.
+type TransactionState struct {
+	entity     *Entity
+	carried    []*Equipment
+	other      []*Equipment
+	children   map[*Equipment][]*Equipment
+	quantities map[*Equipment]fxp.Int
+	funds      fxp.Int
+}
+
+// NewTransactionState captures the current transaction state of the entity.
+func NewTransactionState(entity *Entity) *TransactionState {
+	s := &TransactionState{
+		entity:     entity,
+		carried:    slices.Clone(entity.CarriedEquipment),
+		other:      slices.Clone(entity.OtherEquipment),
+		children:   make(map[*Equipment][]*Equipment),
+		quantities: make(map[*Equipment]fxp.Int),
+		funds:      entity.Funds,
+	}
+	record := func(eqp *Equipment) bool {
+		if eqp.Container() {
+			s.children[eqp] = slices.Clone(eqp.Children)
+		}
+		s.quantities[eqp] = eqp.Quantity
+		return false
+	}
+	Traverse(record, false, false, entity.CarriedEquipment...)
+	Traverse(record, false, false, entity.OtherEquipment...)
+	return s
+}
+
+// Restore the captured transaction state to the entity and recalculate it.
+func (s *TransactionState) Restore() {
+	s.entity.CarriedEquipment = slices.Clone(s.carried)
+	s.entity.OtherEquipment = slices.Clone(s.other)
+	for _, one := range s.carried {
+		one.SetParent(nil)
+	}
+	for _, one := range s.other {
+		one.SetParent(nil)
+	}
+	for eqp, children := range s.children {
+		eqp.Children = slices.Clone(children)
+		for _, child := range children {
+			child.SetParent(eqp)
+		}
+	}
+	for eqp, quantity := range s.quantities {
+		eqp.Quantity = quantity
+	}
+	s.entity.Funds = s.funds
+	s.entity.Recalculate()
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package gurps_test
+
+import (
+	"testing"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newShopEntity returns an entity carrying a purse of 10 gold pieces, each worth 20 in the base currency.
+func newShopEntity() (entity *gurps.Entity, purse *gurps.Equipment) {
+	entity = newCurrencyEntity()
+	purse = gurps.NewEquipment(entity, nil, false)
+	purse.Name = "Purse"
+	purse.Coins = true
+	purse.Currency = "gp"
+	purse.Quantity = fxp.Ten
+	entity.CarriedEquipment = []*gurps.Equipment{purse}
+	return entity, purse
+}
+
+func newShopItem(entity *gurps.Entity, parent *gurps.Equipment, name string, value int) *gurps.Equipment {
+	eqp := gurps.NewEquipment(entity, parent, false)
+	eqp.Name = name
+	eqp.Value = fxp.From(value)
+	if parent != nil {
+		parent.Children = append(parent.Children, eqp)
+	}
+	return eqp
+}
+
+func TestShopBuy(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		value int
+		coins fxp.Int
+		funds fxp.Int
+	}{
+		{name: "Exact", value: 40, coins: fxp.From(8), funds: 0},
+		{name: "Change", value: 25, coins: fxp.From(8), funds: fxp.From(15)},
+		{name: "Less than a coin", value: 1, coins: fxp.From(9), funds: fxp.From(19)},
+		{name: "Everything", value: 200, coins: 0, funds: 0},
+	} {
+		e, purse := newShopEntity()
+		item := newShopItem(nil, nil, "Sword", tc.value)
+		bought, err := gurps.NewShop([]*gurps.Equipment{item}).Buy(e, purse, []*gurps.Equipment{item})
+		require.NoError(t, err, tc.name)
+		require.Len(t, bought, 1, tc.name)
+		assert.NotSame(t, item, bought[0], tc.name)
+		assert.Same(t, e, bought[0].Entity, tc.name)
+		assert.Contains(t, e.CarriedEquipment, bought[0], tc.name)
+		assert.Equal(t, tc.coins, purse.Quantity, tc.name)
+		assert.Equal(t, tc.funds, e.Funds, tc.name)
+		assert.Equal(t, fxp.From(200-tc.value), e.Money(purse)+e.Money(nil), tc.name)
+	}
+}
+
+func TestShopBuyTooExpensive(t *testing.T) {
+	e, purse := newShopEntity()
+	// Funds aren't drawn upon when paying from a purse, so they can't make up the difference.
+	e.Funds = fxp.From(15)
+	item := newShopItem(nil, nil, "Armor", 201)
+	_, err := gurps.NewShop(nil).Buy(e, purse, []*gurps.Equipment{item})
+	assert.Error(t, err)
+	assert.Equal(t, fxp.Ten, purse.Quantity)
+	assert.Equal(t, fxp.From(15), e.Funds)
+	assert.Len(t, e.CarriedEquipment, 1)
+}
+
+func TestShopSell(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		value int
+		coins fxp.Int
+		funds fxp.Int
+	}{
+		{name: "Whole coins", value: 80, coins: fxp.From(12), funds: 0},
+		{name: "Coins and change", value: 50, coins: fxp.From(11), funds: fxp.From(5)},
+		{name: "Less than a coin", value: 10, coins: fxp.Ten, funds: fxp.From(5)},
+	} {
+		e, purse := newShopEntity()
+		item := newShopItem(e, nil, "Lamp", tc.value)
+		e.CarriedEquipment = append(e.CarriedEquipment, item)
+		received := gurps.NewShop(nil).Sell(e, purse, []*gurps.Equipment{item})
+		assert.Equal(t, fxp.From(tc.value).Mul(fxp.Half), received, tc.name)
+		assert.Equal(t, tc.coins, purse.Quantity, tc.name)
+		assert.Equal(t, tc.funds, e.Funds, tc.name)
+		assert.NotContains(t, e.CarriedEquipment, item, tc.name)
+	}
+}
+
+func TestShopSellContainerHoldingPurse(t *testing.T) {
+	e, purse := newShopEntity()
+	pack := gurps.NewEquipment(e, nil, true)
+	pack.Name = "Backpack"
+	pack.Value = fxp.From(60)
+	purse.SetParent(pack)
+	pack.Children = []*gurps.Equipment{purse}
+	lamp := newShopItem(e, pack, "Lamp", 40)
+	e.CarriedEquipment = []*gurps.Equipment{pack}
+
+	// The backpack holds the purse, so only the lamp within it may be sold.
+	received := gurps.NewShop(nil).Sell(e, purse, []*gurps.Equipment{pack, lamp})
+	assert.Equal(t, fxp.From(20), received)
+	assert.Equal(t, []*gurps.Equipment{pack}, e.CarriedEquipment)
+	assert.Equal(t, []*gurps.Equipment{purse}, pack.Children)
+	assert.Equal(t, fxp.Ten+fxp.One, purse.Quantity)
+	assert.Equal(t, fxp.Int(0), e.Funds)
+}
+
+func TestTransactionStateRestore(t *testing.T) {
+	e, purse := newShopEntity()
+	e.Funds = fxp.From(7)
+	pack := gurps.NewEquipment(e, nil, true)
+	pack.Name = "Backpack"
+	pouch := gurps.NewEquipment(e, pack, true)
+	pouch.Name = "Pouch"
+	pack.Children = []*gurps.Equipment{pouch}
+	gem := newShopItem(e, pouch, "Gem", 100)
+	ring := newShopItem(e, pouch, "Ring", 30)
+	tent := newShopItem(e, nil, "Tent", 50)
+	e.CarriedEquipment = append(e.CarriedEquipment, pack)
+	e.OtherEquipment = []*gurps.Equipment{tent}
+
+	state := gurps.NewTransactionState(e)
+	shop := gurps.NewShop(nil)
+	shop.Sell(e, purse, []*gurps.Equipment{gem, tent})
+	_, err := shop.Buy(e, purse, []*gurps.Equipment{newShopItem(nil, nil, "Rope", 5)})
+	require.NoError(t, err)
+	require.Equal(t, []*gurps.Equipment{ring}, pouch.Children)
+	require.Empty(t, e.OtherEquipment)
+
+	state.Restore()
+	assert.Equal(t, []*gurps.Equipment{purse, pack}, e.CarriedEquipment)
+	assert.Equal(t, []*gurps.Equipment{tent}, e.OtherEquipment)
+	assert.Equal(t, []*gurps.Equipment{pouch}, pack.Children)
+	assert.Equal(t, []*gurps.Equipment{gem, ring}, pouch.Children)
+	assert.Same(t, pouch, gem.Parent())
+	assert.Same(t, pack, pouch.Parent())
+	assert.Nil(t, pack.Parent())
+	assert.Equal(t, fxp.Ten, purse.Quantity)
+	assert.Equal(t, fxp.From(7), e.Funds)
+
+	// Restoring a second time, as a redo followed by another undo would, must give the same result.
+	shop.Sell(e, purse, []*gurps.Equipment{pouch})
+	state.Restore()
+	assert.Equal(t, []*gurps.Equipment{pouch}, pack.Children)
+	assert.Equal(t, []*gurps.Equipment{gem, ring}, pouch.Children)
+	assert.Equal(t, fxp.Ten, purse.Quantity)
+	assert.Equal(t, fxp.From(7), e.Funds)
+}
@@ -51,4 +51,7 @@ func (d *SkillData) ClearUnusedFieldsForType() {
 	} else {
 		d.Difficulty.omit = false
 	}
+	if !d.Container() || !d.TemplatePicker.IsSet() {
+		d.TemplatePicker = nil
+	}
 }
@@ -27,6 +27,7 @@ type SkillEditData struct {
 	LocalNotes                   string              `json:"notes,omitempty"`
 	VTTNotes                     string              `json:"vtt_notes,omitempty"`
 	Tags                         []string            `json:"tags,omitempty"`
+	TemplatePicker               *TemplatePicker     `json:"template_picker,omitempty"`                // Container only
 	Specialization               string              `json:"specialization,omitempty"`                 // Non-container only
 	TechLevel                    *string             `json:"tech_level,omitempty"`                     // Non-container only
 	Difficulty                   AttributeDifficulty `json:"difficulty,omitempty"`                     // Non-container only
@@ -83,6 +84,7 @@ func (d *SkillEditData) copyFrom(entity *Entity, other *SkillEditData, isContain
 		d.TechniqueLimitModifier = &mod
 	}
 	d.Prereq = d.Prereq.CloneResolvingEmpty(isContainer, isApply)
+	d.TemplatePicker = d.TemplatePicker.CloneResolvingEmpty(isContainer, isApply)
 	d.Weapons = nil
 	if len(other.Weapons) != 0 {
 		d.Weapons = make([]*Weapon, 0, len(other.Weapons))
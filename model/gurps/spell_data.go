@@ -48,4 +48,7 @@ func (d *SpellData) ClearUnusedFieldsForType() {
 	} else {
 		d.Difficulty.omit = false
 	}
+	if !d.Container() || !d.TemplatePicker.IsSet() {
+		d.TemplatePicker = nil
+	}
 }
@@ -25,6 +25,7 @@ type SpellEditData struct {
 	LocalNotes        string              `json:"notes,omitempty"`
 	VTTNotes          string              `json:"vtt_notes,omitempty"`
 	Tags              []string            `json:"tags,omitempty"`
+	TemplatePicker    *TemplatePicker     `json:"template_picker,omitempty"`  // Container only
 	TechLevel         *string             `json:"tech_level,omitempty"`       // Non-container only
 	Difficulty        AttributeDifficulty `json:"difficulty,omitempty"`       // Non-container only
 	College           CollegeList         `json:"college,omitempty"`          // Non-container only
@@ -61,6 +62,7 @@ func (d *SpellEditData) copyFrom(entity *Entity, other *SpellEditData, isContain
 	}
 	d.College = txt.CloneStringSlice(d.College)
 	d.Prereq = d.Prereq.CloneResolvingEmpty(isContainer, isApply)
+	d.TemplatePicker = d.TemplatePicker.CloneResolvingEmpty(isContainer, isApply)
 	d.Weapons = nil
 	if len(other.Weapons) != 0 {
 		d.Weapons = make([]*Weapon, 0, len(other.Weapons))
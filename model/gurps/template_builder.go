@@ -0,0 +1,376 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package gurps
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps/datafile"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/model/gurps/picker"
+	"github.com/richardwilkes/gcs/v5/model/gurps/trait"
+	"github.com/richardwilkes/toolbox/xmath"
+	"github.com/richardwilkes/toolbox/xmath/rand"
+)
+
+const maxRandomChoiceAttempts = 20
+
+var (
+	choiceBudgetRegex   = regexp.MustCompile(`(?i)(-?\d+)\s*(?:character\s+)?(?:points?|pts\.?)\s+(?:(?:chosen|selected|taken)\s+)?(?:from|of|in|among)\b`)
+	choiceSpendRegex    = regexp.MustCompile(`(?i)\b(?:choose|select|pick|spend)\s+(-?\d+)\s*(?:character\s+)?(?:points?|pts\b)`)
+	choiceCountRegex    = regexp.MustCompile(`(?i)\b(?:choose|select|pick|take)\s+(?:any\s+)?(one|two|three|four|five|six|seven|eight|nine|ten|\d+)\b`)
+	choiceCountOfRegex  = regexp.MustCompile(`(?i)\b(one|two|three|four|five|six|seven|eight|nine|ten|\d+)\s+of\s+(?:the\s+following|these)\b`)
+	choiceCountWordsMap = map[string]int{
+		"one":   1,
+		"two":   2,
+		"three": 3,
+		"four":  4,
+		"five":  5,
+		"six":   6,
+		"seven": 7,
+		"eight": 8,
+		"nine":  9,
+		"ten":   10,
+	}
+)
+
+// TemplateChoice holds a container within a template whose children are offered as options, of which a selection
+// must be made. Inferred is true if the choice was derived from the wording of the container's name or notes rather
+// than from its TemplatePicker.
+type TemplateChoice struct {
+	Kind     string
+	Title    string
+	Notes    string
+	Picker   *TemplatePicker
+	Inferred bool
+	Options  []*TemplateOption
+}
+
+// TemplateOption holds one of the options of a TemplateChoice.
+type TemplateOption struct {
+	Name     string
+	Points   fxp.Int
+	Selected bool
+	node     any
+}
+
+type templateSlot[T NodeConstraint[T]] struct {
+	fixed  T
+	choice *TemplateChoice
+}
+
+// TemplateBuilder creates a new character from a template, one choice at a time. Containers within the template that
+// have a TemplatePicker are turned into TemplateChoices. Optionally, so are containers whose name or notes ask for a
+// selection to be made, e.g. "Choose one of these" or "20 points chosen from". Everything else in the template is
+// always included.
+type TemplateBuilder struct {
+	Template   *Template
+	Choices    []*TemplateChoice
+	entity     *Entity
+	baseTraits []*Trait
+	traits     []templateSlot[*Trait]
+	skills     []templateSlot[*Skill]
+	spells     []templateSlot[*Spell]
+	equipment  []templateSlot[*Equipment]
+}
+
+// NewTemplateBuilder creates a new TemplateBuilder for the template. If inferChoices is true, containers without a
+// TemplatePicker whose wording asks for a selection to be made are also turned into choices.
+func NewTemplateBuilder(template *Template, inferChoices bool) *TemplateBuilder {
+	b := &TemplateBuilder{
+		Template: template,
+		entity:   NewEntity(datafile.PC),
+	}
+	b.baseTraits = b.entity.Traits
+	b.traits = collectTemplateSlots(b, inferChoices, gid.Trait, template.Traits, func(t *Trait) templateContainerInfo {
+		return templateContainerInfo{notes: t.LocalNotes, picker: t.TemplatePicker, isGroup: t.ContainerType == trait.Group}
+	}, func(t *Trait) fxp.Int { return t.AdjustedPoints() })
+	b.skills = collectTemplateSlots(b, inferChoices, gid.Skill, template.Skills, func(s *Skill) templateContainerInfo {
+		return templateContainerInfo{notes: s.LocalNotes, picker: s.TemplatePicker, isGroup: true}
+	}, func(s *Skill) fxp.Int { return s.AdjustedPoints(nil) })
+	b.spells = collectTemplateSlots(b, inferChoices, gid.Spell, template.Spells, func(s *Spell) templateContainerInfo {
+		return templateContainerInfo{notes: s.LocalNotes, picker: s.TemplatePicker, isGroup: true}
+	}, func(s *Spell) fxp.Int { return s.AdjustedPoints(nil) })
+	b.equipment = collectTemplateSlots(b, inferChoices, gid.Equipment, template.Equipment,
+		func(e *Equipment) templateContainerInfo {
+			return templateContainerInfo{notes: e.LocalNotes, picker: e.TemplatePicker, isGroup: true}
+		}, func(e *Equipment) fxp.Int { return e.ExtendedValue() })
+	b.Entity()
+	return b
+}
+
+type templateContainerInfo struct {
+	notes   string
+	picker  *TemplatePicker
+	isGroup bool
+}
+
+// choicePicker returns the picker to use for the container, or nil if the container is not a choice.
+func (info *templateContainerInfo) choicePicker(title string, inferChoices bool) (p *TemplatePicker, inferred bool) {
+	if info.picker.IsSet() {
+		return info.picker, false
+	}
+	if inferChoices && info.isGroup {
+		if p = inferTemplatePicker(title, info.notes); p != nil {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+func collectTemplateSlots[T NodeConstraint[T]](b *TemplateBuilder, inferChoices bool, kind string, list []T, describe func(T) templateContainerInfo, points func(T) fxp.Int) []templateSlot[T] {
+	var slots []templateSlot[T]
+	for _, one := range list {
+		if one.Container() {
+			info := describe(one)
+			title := fmt.Sprint(one)
+			if p, inferred := info.choicePicker(title, inferChoices); p != nil {
+				choice := &TemplateChoice{
+					Kind:     kind,
+					Title:    title,
+					Notes:    info.notes,
+					Picker:   p,
+					Inferred: inferred,
+				}
+				for _, child := range one.NodeChildren() {
+					choice.Options = append(choice.Options, &TemplateOption{
+						Name:   fmt.Sprint(child),
+						Points: points(child),
+						node:   child,
+					})
+				}
+				b.Choices = append(b.Choices, choice)
+				slots = append(slots, templateSlot[T]{choice: choice})
+				continue
+			}
+			if hasTemplateChoice(one.NodeChildren(), inferChoices, describe) {
+				slots = append(slots, collectTemplateSlots(b, inferChoices, kind, one.NodeChildren(), describe, points)...)
+				continue
+			}
+		}
+		slots = append(slots, templateSlot[T]{fixed: one})
+	}
+	return slots
+}
+
+func hasTemplateChoice[T NodeConstraint[T]](list []T, inferChoices bool, describe func(T) templateContainerInfo) bool {
+	for _, one := range list {
+		if one.Container() {
+			info := describe(one)
+			if p, _ := info.choicePicker(fmt.Sprint(one), inferChoices); p != nil {
+				return true
+			}
+			if hasTemplateChoice(one.NodeChildren(), inferChoices, describe) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inferTemplatePicker looks for the phrases commonly used in templates to ask for a selection, returning a picker for
+// the number of options to select or the point budget to select from. A point budget need not be completely spent,
+// but may not be exceeded.
+func inferTemplatePicker(title, notes string) *TemplatePicker {
+	for _, text := range []string{title, notes} {
+		for _, re := range []*regexp.Regexp{choiceBudgetRegex, choiceSpendRegex} {
+			if match := re.FindStringSubmatch(text); match != nil {
+				if value, err := strconv.Atoi(match[1]); err == nil {
+					p := &TemplatePicker{Type: picker.Points}
+					if value < 0 {
+						p.Min = fxp.From(value)
+					} else {
+						p.Max = fxp.From(value)
+					}
+					return p
+				}
+			}
+		}
+		for _, re := range []*regexp.Regexp{choiceCountRegex, choiceCountOfRegex} {
+			if match := re.FindStringSubmatch(text); match != nil {
+				value, ok := choiceCountWordsMap[strings.ToLower(match[1])]
+				if !ok {
+					var err error
+					if value, err = strconv.Atoi(match[1]); err != nil {
+						continue
+					}
+				}
+				if value > 0 {
+					return &TemplatePicker{
+						Type: picker.Count,
+						Min:  fxp.From(value),
+						Max:  fxp.From(value),
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// SelectedCount returns the number of options currently selected.
+func (c *TemplateChoice) SelectedCount() int {
+	count := 0
+	for _, one := range c.Options {
+		if one.Selected {
+			count++
+		}
+	}
+	return count
+}
+
+// SelectedPoints returns the total points of the options currently selected. For equipment, this is the total value
+// instead.
+func (c *TemplateChoice) SelectedPoints() fxp.Int {
+	var total fxp.Int
+	for _, one := range c.Options {
+		if one.Selected {
+			total += one.Points
+		}
+	}
+	return total
+}
+
+// Satisfied returns true if the current selection meets the constraints of the choice.
+func (c *TemplateChoice) Satisfied() bool {
+	return c.Picker.Satisfied(c.SelectedCount(), c.SelectedPoints())
+}
+
+// RandomizeChoices makes a random selection for each of the choices. An attempt is made to satisfy the constraints of
+// each choice, but if no satisfactory selection is found after a number of tries, the last one tried is kept.
+func (b *TemplateBuilder) RandomizeChoices() {
+	rnd := rand.NewCryptoRand()
+	for _, choice := range b.Choices {
+		for i := 0; i < maxRandomChoiceAttempts; i++ {
+			choice.randomize(rnd)
+			if choice.Satisfied() {
+				break
+			}
+		}
+	}
+}
+
+func (c *TemplateChoice) randomize(rnd rand.Randomizer) {
+	order := make([]int, len(c.Options))
+	for i := range order {
+		j := rnd.Intn(i + 1)
+		order[i] = order[j]
+		order[j] = i
+	}
+	for _, one := range c.Options {
+		one.Selected = false
+	}
+	switch c.Picker.Type {
+	case picker.Count:
+		low := xmath.Min(xmath.Max(fxp.As[int](c.Picker.Min), 0), len(order))
+		high := xmath.Min(xmath.Max(fxp.As[int](c.Picker.Max), low), len(order))
+		for _, i := range order[:low+rnd.Intn(high-low+1)] {
+			c.Options[i].Selected = true
+		}
+	case picker.Points:
+		// Pick a target within the range, then take options in random order so long as each brings the total closer to
+		// that target.
+		target := c.Picker.Min
+		if c.Picker.Max > c.Picker.Min {
+			target += fxp.From(rnd.Intn(fxp.As[int](c.Picker.Max-c.Picker.Min) + 1))
+		}
+		var total fxp.Int
+		for _, i := range order {
+			option := c.Options[i]
+			if (target - total - option.Points).Abs() < (target - total).Abs() {
+				option.Selected = true
+				total += option.Points
+			}
+		}
+	}
+}
+
+// Resolve returns a new template containing the template's fixed items and the options currently selected.
+func (b *TemplateBuilder) Resolve() *Template {
+	t := NewTemplate()
+	t.Traits = resolveTemplateSlots(nil, b.traits)
+	t.Skills = resolveTemplateSlots(nil, b.skills)
+	t.Spells = resolveTemplateSlots(nil, b.spells)
+	t.Equipment = resolveTemplateSlots(nil, b.equipment)
+	t.Notes = CloneNodes(nil, nil, false, b.Template.Notes)
+	return t
+}
+
+// Entity rebuilds and returns the character, based on the template and the options currently selected. The same
+// Entity is returned each time, with its lists replaced.
+func (b *TemplateBuilder) Entity() *Entity {
+	b.entity.Traits = append(CloneNodes(b.entity, nil, false, b.baseTraits), resolveTemplateSlots(b.entity, b.traits)...)
+	b.entity.Skills = resolveTemplateSlots(b.entity, b.skills)
+	b.entity.Spells = resolveTemplateSlots(b.entity, b.spells)
+	b.entity.CarriedEquipment = resolveTemplateSlots(b.entity, b.equipment)
+	b.entity.Notes = CloneNodes(b.entity, nil, false, b.Template.Notes)
+	b.entity.Recalculate()
+	return b.entity
+}
+
+func resolveTemplateSlots[T NodeConstraint[T]](entity *Entity, slots []templateSlot[T]) []T {
+	var zero T
+	var list []T
+	for _, slot := range slots {
+		if slot.choice == nil {
+			list = append(list, slot.fixed.Clone(entity, zero, false))
+			continue
+		}
+		for _, option := range slot.choice.Options {
+			if option.Selected {
+				if node, ok := option.node.(T); ok {
+					list = append(list, node.Clone(entity, zero, false))
+				}
+			}
+		}
+	}
+	return list
+}
+
+// NameableKeys returns the nameable keys found within the current character.
+func (b *TemplateBuilder) NameableKeys() map[string]string {
+	m := make(map[string]string)
+	fillNameableKeys(m, b.entity.Traits)
+	fillNameableKeys(m, b.entity.Skills)
+	fillNameableKeys(m, b.entity.Spells)
+	fillNameableKeys(m, b.entity.CarriedEquipment)
+	fillNameableKeys(m, b.entity.Notes)
+	return m
+}
+
+// ApplyNameableKeys replaces the nameable keys found within the current character with the values in the map.
+func (b *TemplateBuilder) ApplyNameableKeys(m map[string]string) {
+	applyNameableKeys(m, b.entity.Traits)
+	applyNameableKeys(m, b.entity.Skills)
+	applyNameableKeys(m, b.entity.Spells)
+	applyNameableKeys(m, b.entity.CarriedEquipment)
+	applyNameableKeys(m, b.entity.Notes)
+	b.entity.Recalculate()
+}
+
+func fillNameableKeys[T NodeConstraint[T]](m map[string]string, list []T) {
+	Traverse(func(node T) bool {
+		node.FillWithNameableKeys(m)
+		return false
+	}, false, false, list...)
+}
+
+func applyNameableKeys[T NodeConstraint[T]](m map[string]string, list []T) {
+	Traverse(func(node T) bool {
+		node.ApplyNameableKeys(m)
+		return false
+	}, false, false, list...)
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package gurps
+
+import (
+	"fmt"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps/picker"
+	"github.com/richardwilkes/toolbox/i18n"
+)
+
+// TemplatePicker holds the constraints on the selection that must be made from the children of a container within a
+// template, e.g. "choose one of these three skills" or "20 points chosen from these advantages".
+type TemplatePicker struct {
+	Type picker.Type `json:"type"`
+	Min  fxp.Int     `json:"min"`
+	Max  fxp.Int     `json:"max"`
+}
+
+// CloneResolvingEmpty clones this template picker. If the result would be nil and it isn't a container, nil is
+// returned. If the result would be nil and it is a container, a new, unset, picker is created. If the result would not
+// be nil but pruneIfEmpty is true and the picker is unset, then nil is returned.
+func (p *TemplatePicker) CloneResolvingEmpty(isContainer, pruneIfEmpty bool) *TemplatePicker {
+	if !isContainer {
+		return nil
+	}
+	if p == nil {
+		if pruneIfEmpty {
+			return nil
+		}
+		return &TemplatePicker{}
+	}
+	if pruneIfEmpty && p.Type == picker.NotApplicable {
+		return nil
+	}
+	other := *p
+	return &other
+}
+
+// IsSet returns true if the picker requires a selection to be made.
+func (p *TemplatePicker) IsSet() bool {
+	return p != nil && p.Type != picker.NotApplicable
+}
+
+// Satisfied returns true if a selection of the given number of options with the given total points meets the
+// constraints.
+func (p *TemplatePicker) Satisfied(count int, points fxp.Int) bool {
+	var value fxp.Int
+	switch p.Type {
+	case picker.Count:
+		value = fxp.From(count)
+	case picker.Points:
+		value = points
+	default:
+		return true
+	}
+	return value >= p.Min && value <= p.Max
+}
+
+// Description returns a description of the selection that must be made.
+func (p *TemplatePicker) Description() string {
+	switch p.Type {
+	case picker.Count:
+		if p.Min == p.Max {
+			if p.Min == fxp.One {
+				return i18n.Text("Select one")
+			}
+			return fmt.Sprintf(i18n.Text("Select %s"), p.Min.String())
+		}
+		return fmt.Sprintf(i18n.Text("Select from %s to %s"), p.Min.String(), p.Max.String())
+	case picker.Points:
+		if p.Min == p.Max {
+			return fmt.Sprintf(i18n.Text("Select %s points"), p.Min.String())
+		}
+		return fmt.Sprintf(i18n.Text("Select from %s to %s points"), p.Min.String(), p.Max.String())
+	default:
+		return ""
+	}
+}
+
+// Problem returns a description of why the picker can't be satisfied by any selection of the given options, or an
+// empty string if it can be.
+func (p *TemplatePicker) Problem(optionPoints []fxp.Int) string {
+	if !p.IsSet() {
+		return ""
+	}
+	if p.Min > p.Max {
+		return i18n.Text("the minimum is greater than the maximum")
+	}
+	if len(optionPoints) == 0 {
+		return i18n.Text("there are no options to select from")
+	}
+	switch p.Type {
+	case picker.Count:
+		if p.Max < 0 {
+			return i18n.Text("the maximum number of selections may not be negative")
+		}
+		if p.Min > fxp.From(len(optionPoints)) {
+			return fmt.Sprintf(i18n.Text("requires at least %s selections, but only %d options are available"),
+				p.Min.String(), len(optionPoints))
+		}
+	case picker.Points:
+		var lowest, highest fxp.Int
+		for _, one := range optionPoints {
+			if one < 0 {
+				lowest += one
+			} else {
+				highest += one
+			}
+		}
+		if p.Min > highest || p.Max < lowest {
+			return fmt.Sprintf(i18n.Text("the options can only total from %s to %s points"), lowest.String(),
+				highest.String())
+		}
+	}
+	return ""
+}
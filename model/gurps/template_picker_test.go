@@ -0,0 +1,79 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package gurps_test
+
+import (
+	"testing"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/picker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplatePickerSatisfied(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		picker    gurps.TemplatePicker
+		count     int
+		points    fxp.Int
+		satisfied bool
+	}{
+		{name: "unset", picker: gurps.TemplatePicker{}, satisfied: true},
+		{name: "count below", picker: countPicker(1, 2), count: 0, points: fxp.Ten, satisfied: false},
+		{name: "count at min", picker: countPicker(1, 2), count: 1, satisfied: true},
+		{name: "count at max", picker: countPicker(1, 2), count: 2, satisfied: true},
+		{name: "count above", picker: countPicker(1, 2), count: 3, satisfied: false},
+		{name: "points ignore count", picker: pointsPicker(20, 20), count: 5, points: fxp.From(20), satisfied: true},
+		{name: "points below", picker: pointsPicker(10, 20), count: 1, points: fxp.Five, satisfied: false},
+		{name: "points above", picker: pointsPicker(10, 20), count: 1, points: fxp.From(25), satisfied: false},
+		{name: "negative points", picker: pointsPicker(-15, -10), points: fxp.From(-12), satisfied: true},
+	} {
+		assert.Equal(t, tc.satisfied, tc.picker.Satisfied(tc.count, tc.points), tc.name)
+	}
+}
+
+func TestTemplatePickerProblem(t *testing.T) {
+	options := []fxp.Int{fxp.Five, fxp.Ten, -fxp.Five}
+	for _, tc := range []struct {
+		name    string
+		picker  *gurps.TemplatePicker
+		options []fxp.Int
+		problem bool
+	}{
+		{name: "nil", picker: nil, problem: false},
+		{name: "unset", picker: &gurps.TemplatePicker{}, problem: false},
+		{name: "min above max", picker: ptr(countPicker(2, 1)), options: options, problem: true},
+		{name: "no options", picker: ptr(countPicker(1, 1)), problem: true},
+		{name: "count possible", picker: ptr(countPicker(1, 3)), options: options, problem: false},
+		{name: "too few options", picker: ptr(countPicker(4, 4)), options: options, problem: true},
+		{name: "negative count", picker: ptr(countPicker(-2, -1)), options: options, problem: true},
+		{name: "points possible", picker: ptr(pointsPicker(15, 15)), options: options, problem: false},
+		{name: "points too high", picker: ptr(pointsPicker(16, 20)), options: options, problem: true},
+		{name: "points too low", picker: ptr(pointsPicker(-10, -6)), options: options, problem: true},
+		{name: "negative points possible", picker: ptr(pointsPicker(-5, 0)), options: options, problem: false},
+	} {
+		assert.Equal(t, tc.problem, tc.picker.Problem(tc.options) != "", tc.name)
+	}
+}
+
+func countPicker(min, max int) gurps.TemplatePicker {
+	return gurps.TemplatePicker{Type: picker.Count, Min: fxp.From(min), Max: fxp.From(max)}
+}
+
+func pointsPicker(min, max int) gurps.TemplatePicker {
+	return gurps.TemplatePicker{Type: picker.Points, Min: fxp.From(min), Max: fxp.From(max)}
+}
+
+func ptr(p gurps.TemplatePicker) *gurps.TemplatePicker {
+	return &p
+}
@@ -41,4 +41,7 @@ func (d *TraitData) ClearUnusedFieldsForType() {
 		d.ContainerType = 0
 		d.Ancestry = ""
 	}
+	if !d.Container() || !d.TemplatePicker.IsSet() {
+		d.TemplatePicker = nil
+	}
 }
@@ -38,7 +38,8 @@ type TraitEditData struct {
 	Features       feature.Features      `json:"features,omitempty"`         // Non-container only
 	CR             trait.SelfControlRoll `json:"cr,omitempty"`
 	CRAdj          SelfControlRollAdj    `json:"cr_adj,omitempty"`
-	ContainerType  trait.ContainerType   `json:"container_type,omitempty"` // Container only
+	ContainerType  trait.ContainerType   `json:"container_type,omitempty"`  // Container only
+	TemplatePicker *TemplatePicker       `json:"template_picker,omitempty"` // Container only
 	Disabled       bool                  `json:"disabled,omitempty"`
 	RoundCostDown  bool                  `json:"round_down,omitempty"` // Non-container only
 }
@@ -64,6 +65,7 @@ func (d *TraitEditData) copyFrom(entity *Entity, other *TraitEditData, isContain
 		}
 	}
 	d.Prereq = d.Prereq.CloneResolvingEmpty(isContainer, isApply)
+	d.TemplatePicker = d.TemplatePicker.CloneResolvingEmpty(isContainer, isApply)
 	d.Weapons = nil
 	if len(other.Weapons) != 0 {
 		d.Weapons = make([]*Weapon, 0, len(other.Weapons))
@@ -0,0 +1,293 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package gurps
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/model/gurps/equipment"
+	"github.com/richardwilkes/gcs/v5/model/gurps/feature"
+	"github.com/richardwilkes/gcs/v5/model/gurps/measure"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+)
+
+// UnitConverter rewrites the lengths and weights held as text, such as weapon ranges and equipment modifier weights, to
+// use a particular system of measurement. Lengths and weights held as values are always stored the same way and are
+// displayed using the sheet settings, so they need no conversion. Each change is recorded, so that it can be undone.
+type UnitConverter struct {
+	System measure.UnitSystem
+	// DefaultWeightUnits is used to interpret weights that were entered without units.
+	DefaultWeightUnits measure.WeightUnits
+	edits              []*unitEdit
+}
+
+type unitEdit struct {
+	restore func()
+	apply   func()
+}
+
+// NewUnitConverter creates a new UnitConverter for the system, interpreting weights without units using the entity's
+// sheet settings. The entity may be nil, in which case the global sheet settings are used.
+func NewUnitConverter(entity *Entity, system measure.UnitSystem) *UnitConverter {
+	return &UnitConverter{
+		System:             system,
+		DefaultWeightUnits: SheetSettingsFor(entity).DefaultWeightUnits,
+	}
+}
+
+// Entity converts the entity, including its sheet settings' default units.
+func (c *UnitConverter) Entity(entity *Entity) {
+	c.Traits(entity.Traits)
+	c.Skills(entity.Skills)
+	c.Spells(entity.Spells)
+	c.Equipment(entity.CarriedEquipment)
+	c.Equipment(entity.OtherEquipment)
+	if s := entity.SheetSettings; s != nil {
+		setConverted(c, &s.DefaultLengthUnits, c.System.LengthUnits())
+		setConverted(c, &s.DefaultWeightUnits, c.System.WeightUnits())
+	}
+}
+
+// Template converts the template.
+func (c *UnitConverter) Template(template *Template) {
+	c.Traits(template.Traits)
+	c.Skills(template.Skills)
+	c.Spells(template.Spells)
+	c.Equipment(template.Equipment)
+}
+
+// Traits converts the traits and their modifiers.
+func (c *UnitConverter) Traits(list []*Trait) {
+	forEachNode(list, func(t *Trait) {
+		c.weapons(t.Weapons)
+		c.features(t.Features)
+		c.TraitModifiers(t.Modifiers)
+	})
+}
+
+// TraitModifiers converts the trait modifiers.
+func (c *UnitConverter) TraitModifiers(list []*TraitModifier) {
+	forEachNode(list, func(mod *TraitModifier) { c.features(mod.Features) })
+}
+
+// Skills converts the skills.
+func (c *UnitConverter) Skills(list []*Skill) {
+	forEachNode(list, func(s *Skill) {
+		c.weapons(s.Weapons)
+		c.features(s.Features)
+	})
+}
+
+// Spells converts the spells.
+func (c *UnitConverter) Spells(list []*Spell) {
+	forEachNode(list, func(s *Spell) { c.weapons(s.Weapons) })
+}
+
+// Equipment converts the equipment and its modifiers.
+func (c *UnitConverter) Equipment(list []*Equipment) {
+	forEachNode(list, func(eqp *Equipment) {
+		c.weapons(eqp.Weapons)
+		c.features(eqp.Features)
+		c.EquipmentModifiers(eqp.Modifiers)
+	})
+}
+
+// EquipmentModifiers converts the equipment modifiers.
+func (c *UnitConverter) EquipmentModifiers(list []*EquipmentModifier) {
+	forEachNode(list, func(mod *EquipmentModifier) {
+		c.features(mod.Features)
+		// Only additions carry units; multipliers and percentages are the same in any system.
+		if mod.WeightType.DetermineModifierWeightValueTypeFromString(mod.WeightAmount) == equipment.WeightAddition {
+			setConverted(c, &mod.WeightAmount, c.System.ConvertWeightText(mod.WeightAmount, c.DefaultWeightUnits))
+		}
+	})
+}
+
+func (c *UnitConverter) weapons(list []*Weapon) {
+	for _, w := range list {
+		setConverted(c, &w.Range, c.System.ConvertLengthsInText(w.Range))
+	}
+}
+
+func (c *UnitConverter) features(list feature.Features) {
+	for _, one := range list {
+		if f, ok := one.(*feature.ContainedWeightReduction); ok && !f.IsPercentageReduction() {
+			setConverted(c, &f.Reduction, c.System.ConvertWeightText(f.Reduction, c.DefaultWeightUnits))
+		}
+	}
+}
+
+// Changed returns true if anything was rewritten.
+func (c *UnitConverter) Changed() bool {
+	return len(c.edits) != 0
+}
+
+// Restore the original values of everything that was rewritten.
+func (c *UnitConverter) Restore() {
+	for i := len(c.edits) - 1; i >= 0; i-- {
+		c.edits[i].restore()
+	}
+}
+
+// Reapply the converted values, such as after a call to Restore().
+func (c *UnitConverter) Reapply() {
+	for _, one := range c.edits {
+		one.apply()
+	}
+}
+
+func setConverted[T comparable](c *UnitConverter, field *T, value T) {
+	if original := *field; original != value {
+		*field = value
+		c.edits = append(c.edits, &unitEdit{
+			restore: func() { *field = original },
+			apply:   func() { *field = value },
+		})
+	}
+}
+
+// forEachNode calls f for each node and its children, including those that are disabled.
+func forEachNode[T Node[T]](list []T, f func(T)) {
+	for _, one := range list {
+		f(one)
+		if one.HasChildren() {
+			forEachNode(one.NodeChildren(), f)
+		}
+	}
+}
+
+// ConvertUnitsInFiles converts the sheets, templates and lists in the file list to the system, replacing the originals.
+// Directories, such as a library, are processed recursively, skipping any files that aren't sheets, templates or lists.
+func ConvertUnitsInFiles(w io.Writer, system measure.UnitSystem, fileList []string) error {
+	for _, one := range fileList {
+		fi, err := os.Stat(one)
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			if !unitConvertible(one) {
+				jot.Warn("ignoring: " + one)
+				continue
+			}
+			if err = convertUnitsInFile(w, system, one); err != nil {
+				return err
+			}
+			continue
+		}
+		if err = filepath.WalkDir(one, func(p string, d fs.DirEntry, walkErr error) error {
+			if walkErr != nil {
+				return walkErr
+			}
+			if d.IsDir() || !unitConvertible(p) {
+				return nil
+			}
+			return convertUnitsInFile(w, system, p)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unitConvertible(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case library.SheetExt, library.TemplatesExt, library.TraitsExt, library.TraitModifiersExt, library.SkillsExt,
+		library.SpellsExt, library.EquipmentExt, library.EquipmentModifiersExt:
+		return true
+	default:
+		return false
+	}
+}
+
+func convertUnitsInFile(w io.Writer, system measure.UnitSystem, filePath string) error {
+	fileSystem := os.DirFS(filepath.Dir(filePath))
+	name := filepath.Base(filePath)
+	c := NewUnitConverter(nil, system)
+	var save func() error
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case library.SheetExt:
+		entity, err := NewEntityFromFile(fileSystem, name)
+		if err != nil {
+			return err
+		}
+		c = NewUnitConverter(entity, system)
+		c.Entity(entity)
+		save = func() error { return entity.Save(filePath) }
+	case library.TemplatesExt:
+		template, err := NewTemplateFromFile(fileSystem, name)
+		if err != nil {
+			return err
+		}
+		c.Template(template)
+		save = func() error { return template.Save(filePath) }
+	case library.TraitsExt:
+		list, err := NewTraitsFromFile(fileSystem, name)
+		if err != nil {
+			return err
+		}
+		c.Traits(list)
+		save = func() error { return SaveTraits(list, filePath) }
+	case library.TraitModifiersExt:
+		list, err := NewTraitModifiersFromFile(fileSystem, name)
+		if err != nil {
+			return err
+		}
+		c.TraitModifiers(list)
+		save = func() error { return SaveTraitModifiers(list, filePath) }
+	case library.SkillsExt:
+		list, err := NewSkillsFromFile(fileSystem, name)
+		if err != nil {
+			return err
+		}
+		c.Skills(list)
+		save = func() error { return SaveSkills(list, filePath) }
+	case library.SpellsExt:
+		list, err := NewSpellsFromFile(fileSystem, name)
+		if err != nil {
+			return err
+		}
+		c.Spells(list)
+		save = func() error { return SaveSpells(list, filePath) }
+	case library.EquipmentExt:
+		list, err := NewEquipmentFromFile(fileSystem, name)
+		if err != nil {
+			return err
+		}
+		c.Equipment(list)
+		save = func() error { return SaveEquipment(list, filePath) }
+	case library.EquipmentModifiersExt:
+		list, err := NewEquipmentModifiersFromFile(fileSystem, name)
+		if err != nil {
+			return err
+		}
+		c.EquipmentModifiers(list)
+		save = func() error { return SaveEquipmentModifiers(list, filePath) }
+	default:
+		return nil
+	}
+	if !c.Changed() {
+		fmt.Fprintf(w, i18n.Text("%s: no changes\n"), filePath)
+		return nil
+	}
+	if err := save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, i18n.Text("%s: converted to %s\n"), filePath, system)
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/toolbox/i18n"
+)
+
+// ValidateBody checks the body type, returning the issues found. This verifies that the slots of each table, including
+// any sub-tables, exactly cover the range of its roll, and that each hit location has an ID. Hit locations may share an
+// ID, as is done for paired limbs, in which case they are treated as the same location when choosing armor coverage.
+func ValidateBody(body *gurps.Body) []*Issue {
+	v := &validator{}
+	checkBodyTable(v, body, body.Name)
+	return v.issues
+}
+
+func checkBodyTable(v *validator, body *gurps.Body, subject string) {
+	category := i18n.Text("Hit Locations")
+	if body.Roll == nil || body.Roll.Count < 1 || body.Roll.Sides < 1 {
+		v.add(Error, category, subject, i18n.Text("the roll must use at least one die with at least one side"))
+	} else {
+		low := body.Roll.Minimum(false)
+		high := body.Roll.Maximum(false)
+		slots := 0
+		for _, loc := range body.Locations {
+			slots += loc.Slots
+		}
+		switch last := low + slots - 1; {
+		case slots == 0:
+			v.add(Error, category, subject, fmt.Sprintf(i18n.Text("no locations have slots, so rolls of %d-%d hit nothing"),
+				low, high))
+		case last < high:
+			v.add(Error, category, subject, fmt.Sprintf(i18n.Text("slots only cover rolls of %d-%d, leaving %s uncovered"),
+				low, last, rollRangeText(last+1, high)))
+		case last > high:
+			v.add(Error, category, subject, fmt.Sprintf(i18n.Text("slots cover rolls of %d-%d, but %s can only produce %d-%d"),
+				low, last, body.Roll.String(), low, high))
+		}
+	}
+	for _, loc := range body.Locations {
+		name := loc.TableName
+		if strings.TrimSpace(name) == "" {
+			name = loc.ChoiceName
+		}
+		locSubject := subject
+		if locSubject != "" {
+			locSubject += " / "
+		}
+		locSubject += name
+		if loc.LocID == "" {
+			v.add(Error, category, locSubject, i18n.Text("an ID is required"))
+		}
+		if strings.TrimSpace(loc.ChoiceName) == "" || strings.TrimSpace(loc.TableName) == "" {
+			v.add(Warning, category, locSubject, i18n.Text("both a choice name and a table name should be provided"))
+		}
+		if loc.Slots < 0 {
+			v.add(Error, category, locSubject, i18n.Text("slots may not be negative"))
+		}
+		if loc.SubTable != nil {
+			checkBodyTable(v, loc.SubTable, locSubject)
+		}
+	}
+}
+
+func rollRangeText(low, high int) string {
+	if low == high {
+		return fmt.Sprint(low)
+	}
+	return fmt.Sprintf("%d-%d", low, high)
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package validation_test
+
+import (
+	"testing"
+
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/validation"
+	"github.com/richardwilkes/gcs/v5/model/settings"
+	"github.com/richardwilkes/rpgtools/dice"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBody(roll string, slots ...int) *gurps.Body {
+	settings.Global()
+	body := &gurps.Body{
+		Name: "Test",
+		Roll: dice.New(roll),
+	}
+	for i, n := range slots {
+		loc := &gurps.HitLocation{}
+		loc.LocID = string(rune('a' + i))
+		loc.ChoiceName = loc.LocID
+		loc.TableName = loc.LocID
+		loc.Slots = n
+		body.AddLocation(loc)
+	}
+	return body
+}
+
+func bodyIssues(body *gurps.Body) []string {
+	issues := validation.ValidateBody(body)
+	list := make([]string, 0, len(issues))
+	for _, one := range issues {
+		list = append(list, one.Subject+": "+one.Message)
+	}
+	return list
+}
+
+func TestValidateBodySlots(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		roll     string
+		slots    []int
+		expected []string
+	}{
+		{name: "exact", roll: "3d", slots: []int{6, 6, 4}},
+		{name: "gap", roll: "3d", slots: []int{6, 6, 2}, expected: []string{
+			"Test: slots only cover rolls of 3-16, leaving 17-18 uncovered",
+		}},
+		{name: "single roll gap", roll: "3d", slots: []int{6, 6, 3}, expected: []string{
+			"Test: slots only cover rolls of 3-17, leaving 18 uncovered",
+		}},
+		{name: "overlap", roll: "3d", slots: []int{6, 6, 6}, expected: []string{
+			"Test: slots cover rolls of 3-20, but 3d can only produce 3-18",
+		}},
+		{name: "no slots", roll: "1d", slots: []int{0, 0}, expected: []string{
+			"Test: no locations have slots, so rolls of 1-6 hit nothing",
+		}},
+		{name: "negative slots", roll: "1d", slots: []int{7, -1}, expected: []string{
+			"Test / b: slots may not be negative",
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, emptyAsNil(bodyIssues(newBody(tc.roll, tc.slots...))))
+		})
+	}
+}
+
+func TestValidateBodySubTables(t *testing.T) {
+	body := newBody("3d", 6, 6, 4)
+	body.Locations[0].SubTable = newBody("1d", 3, 3)
+	assert.Empty(t, bodyIssues(body), "a sub-table exactly covering its roll is fine")
+
+	body.Locations[1].SubTable = newBody("1d", 3, 2)
+	body.Locations[2].SubTable = newBody("1d", 3, 3, 1)
+	body.Locations[2].SubTable.Locations[0].SubTable = newBody("2d", 10)
+	assert.Equal(t, []string{
+		"Test / b: slots only cover rolls of 1-5, leaving 6 uncovered",
+		"Test / c: slots cover rolls of 1-7, but 1d can only produce 1-6",
+		"Test / c / a: slots only cover rolls of 2-11, leaving 12 uncovered",
+	}, bodyIssues(body))
+}
+
+func TestValidateBodyLocations(t *testing.T) {
+	body := newBody("1d", 6)
+	body.Locations[0].LocID = ""
+	body.Locations[0].ChoiceName = ""
+	assert.Equal(t, []string{
+		"Test / a: an ID is required",
+		"Test / a: both a choice name and a table name should be provided",
+	}, bodyIssues(body))
+
+	body = newBody("1d", 3, 3)
+	body.Roll = nil
+	assert.Equal(t, []string{"Test: the roll must use at least one die with at least one side"}, bodyIssues(body))
+}
+
+func emptyAsNil(list []string) []string {
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
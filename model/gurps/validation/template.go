@@ -0,0 +1,58 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package validation
+
+import (
+	"fmt"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/toolbox/i18n"
+)
+
+// ValidateTemplate checks the template, returning the issues found. Currently, this verifies that the selection
+// required by each container with a template picker can actually be made from that container's children.
+func ValidateTemplate(template *gurps.Template) []*Issue {
+	v := &validator{}
+	checkTemplatePickers(v, i18n.Text("Traits"), template.Traits,
+		func(t *gurps.Trait) *gurps.TemplatePicker { return t.TemplatePicker },
+		func(t *gurps.Trait) fxp.Int { return t.AdjustedPoints() })
+	checkTemplatePickers(v, i18n.Text("Skills"), template.Skills,
+		func(s *gurps.Skill) *gurps.TemplatePicker { return s.TemplatePicker },
+		func(s *gurps.Skill) fxp.Int { return s.AdjustedPoints(nil) })
+	checkTemplatePickers(v, i18n.Text("Spells"), template.Spells,
+		func(s *gurps.Spell) *gurps.TemplatePicker { return s.TemplatePicker },
+		func(s *gurps.Spell) fxp.Int { return s.AdjustedPoints(nil) })
+	checkTemplatePickers(v, i18n.Text("Equipment"), template.Equipment,
+		func(e *gurps.Equipment) *gurps.TemplatePicker { return e.TemplatePicker },
+		func(e *gurps.Equipment) fxp.Int { return e.ExtendedValue() })
+	return v.issues
+}
+
+func checkTemplatePickers[T gurps.NodeConstraint[T]](v *validator, category string, list []T, picker func(T) *gurps.TemplatePicker, points func(T) fxp.Int) {
+	gurps.Traverse(func(node T) bool {
+		if !node.Container() {
+			return false
+		}
+		if p := picker(node); p.IsSet() {
+			children := node.NodeChildren()
+			optionPoints := make([]fxp.Int, len(children))
+			for i, child := range children {
+				optionPoints[i] = points(child)
+			}
+			if problem := p.Problem(optionPoints); problem != "" {
+				v.add(Error, category, fmt.Sprint(node), problem)
+			}
+		}
+		return false
+	}, false, false, list...)
+}
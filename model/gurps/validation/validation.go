@@ -0,0 +1,399 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+// Package validation checks characters for problems that the sheet doesn't otherwise prevent, such as unmet
+// prerequisites or overspent point budgets.
+package validation
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/datafile"
+	"github.com/richardwilkes/gcs/v5/model/gurps/feature"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+)
+
+// Severity holds the seriousness of an issue.
+type Severity uint8
+
+// Possible Severity values.
+const (
+	Warning Severity = iota
+	Error
+)
+
+// String implements fmt.Stringer.
+func (s Severity) String() string {
+	if s == Error {
+		return i18n.Text("Error")
+	}
+	return i18n.Text("Warning")
+}
+
+// Issue holds a single problem found on a character.
+type Issue struct {
+	Severity Severity
+	Category string
+	Subject  string
+	Message  string
+}
+
+// String implements fmt.Stringer.
+func (i *Issue) String() string {
+	if i.Subject == "" {
+		return fmt.Sprintf("%s: [%s] %s", i.Severity, i.Category, i.Message)
+	}
+	return fmt.Sprintf("%s: [%s] %s: %s", i.Severity, i.Category, i.Subject, i.Message)
+}
+
+// Budget holds the point limits a character is checked against. A limit of zero means there is no limit.
+type Budget struct {
+	DisadvantageLimit fxp.Int
+	QuirkLimit        fxp.Int
+}
+
+// DefaultBudget returns the Budget configured in the general settings.
+func DefaultBudget() *Budget {
+	general := gurps.SettingsProvider.GeneralSettings()
+	return &Budget{
+		DisadvantageLimit: general.DisadvantageLimit,
+		QuirkLimit:        general.QuirkLimit,
+	}
+}
+
+// CampaignBudget returns the Budget specified by the campaign.
+func CampaignBudget(campaign *gurps.Campaign) *Budget {
+	return &Budget{
+		DisadvantageLimit: campaign.DisadvantageLimit,
+		QuirkLimit:        campaign.QuirkLimit,
+	}
+}
+
+// HasErrors returns true if any of the issues has a severity of Error.
+func HasErrors(issues []*Issue) bool {
+	for _, one := range issues {
+		if one.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+type validator struct {
+	entity *gurps.Entity
+	budget *Budget
+	issues []*Issue
+}
+
+// Validate the entity, returning the issues found. The entity should have been recalculated beforehand, as the results
+// of that calculation are what is checked. 'budget' may be nil, in which case DefaultBudget() is used.
+func Validate(entity *gurps.Entity, budget *Budget) []*Issue {
+	if budget == nil {
+		budget = DefaultBudget()
+	}
+	v := &validator{
+		entity: entity,
+		budget: budget,
+	}
+	v.checkPoints()
+	v.checkTraits()
+	v.checkSkills()
+	v.checkSpells()
+	v.checkEquipment()
+	v.checkEncumbrance()
+	return v.issues
+}
+
+// ValidateForCampaign validates the entity, as Validate() does, but uses the campaign's budget and also checks the
+// campaign's rules. 'campaign' may be nil, in which case this is the same as calling Validate(entity, nil).
+func ValidateForCampaign(entity *gurps.Entity, campaign *gurps.Campaign) []*Issue {
+	if campaign == nil {
+		return Validate(entity, nil)
+	}
+	issues := Validate(entity, CampaignBudget(campaign))
+	v := &validator{
+		entity: entity,
+		issues: issues,
+	}
+	v.checkCampaign(campaign)
+	return v.issues
+}
+
+func (v *validator) add(severity Severity, category, subject, msg string) {
+	v.issues = append(v.issues, &Issue{
+		Severity: severity,
+		Category: category,
+		Subject:  subject,
+		Message:  msg,
+	})
+}
+
+func (v *validator) addUnsatisfied(subject, reason string) {
+	if reason != "" {
+		reason = strings.ReplaceAll(strings.TrimSpace(strings.TrimPrefix(reason,
+			i18n.Text("Prerequisites have not been met:"))), "\n● ", "; ")
+		v.add(Error, i18n.Text("Prerequisites"), subject, strings.TrimPrefix(reason, "● "))
+	}
+}
+
+func (v *validator) checkPoints() {
+	category := i18n.Text("Points")
+	if unspent := v.entity.UnspentPoints(); unspent < 0 {
+		v.add(Error, category, "", fmt.Sprintf(i18n.Text("%s points have been spent beyond the total available"),
+			(-unspent).String()))
+	}
+	_, disad, _, quirk := v.entity.TraitPoints()
+	if limit := v.budget.DisadvantageLimit; limit > 0 && -disad > limit {
+		v.add(Error, category, "", fmt.Sprintf(i18n.Text("%s points of disadvantages exceeds the limit of %s"),
+			(-disad).String(), limit.String()))
+	}
+	if limit := v.budget.QuirkLimit; limit > 0 && -quirk > limit {
+		v.add(Error, category, "", fmt.Sprintf(i18n.Text("%s points of quirks exceeds the limit of %s"),
+			(-quirk).String(), limit.String()))
+	}
+}
+
+func (v *validator) checkTraits() {
+	gurps.Traverse(func(t *gurps.Trait) bool {
+		v.addUnsatisfied(t.String(), t.UnsatisfiedReason)
+		if !t.Container() {
+			v.checkFeatures(t.String(), t.Features)
+		}
+		gurps.Traverse(func(mod *gurps.TraitModifier) bool {
+			v.checkFeatures(t.String(), mod.Features)
+			return false
+		}, true, false, t.Modifiers...)
+		return false
+	}, false, true, v.entity.Traits...)
+}
+
+func (v *validator) checkSkills() {
+	gurps.Traverse(func(s *gurps.Skill) bool {
+		subject := s.String()
+		v.addUnsatisfied(subject, s.UnsatisfiedReason)
+		v.checkFeatures(subject, s.Features)
+		if strings.HasPrefix(s.Type, gid.Technique) {
+			// A missing base skill is already reported as an unmet prerequisite, so only the limit is checked here.
+			if s.TechniqueLimitModifier != nil && s.TechniqueDefault != nil {
+				unlimited := gurps.CalculateTechniqueLevel(v.entity, s.Name, s.Specialization, s.Tags,
+					s.TechniqueDefault, s.Difficulty.Difficulty, s.AdjustedPoints(nil), true, nil)
+				if unlimited.Level > s.LevelData.Level {
+					v.add(Warning, i18n.Text("Techniques"), subject,
+						fmt.Sprintf(i18n.Text("points would raise the level to %s, but it is limited to %s"),
+							unlimited.Level.Trunc().String(), s.LevelData.Level.Trunc().String()))
+				}
+			}
+		} else if s.LevelData.Level == fxp.Min {
+			v.add(Error, i18n.Text("Skills"), subject, i18n.Text("has no points and no valid default"))
+		}
+		return false
+	}, true, false, v.entity.Skills...)
+}
+
+func (v *validator) checkSpells() {
+	gurps.Traverse(func(s *gurps.Spell) bool {
+		v.addUnsatisfied(s.String(), s.UnsatisfiedReason)
+		return false
+	}, true, false, v.entity.Spells...)
+}
+
+func (v *validator) checkEquipment() {
+	f := func(e *gurps.Equipment) bool {
+		subject := e.String()
+		v.addUnsatisfied(subject, e.UnsatisfiedReason)
+		v.checkFeatures(subject, e.Features)
+		gurps.Traverse(func(mod *gurps.EquipmentModifier) bool {
+			v.checkFeatures(subject, mod.Features)
+			return false
+		}, true, false, e.Modifiers...)
+		if e.MaxUses > 0 && e.Uses > e.MaxUses {
+			v.add(Error, i18n.Text("Equipment"), subject,
+				fmt.Sprintf(i18n.Text("%d uses exceeds the maximum of %d"), e.Uses, e.MaxUses))
+		}
+		return false
+	}
+	gurps.Traverse(f, false, false, v.entity.CarriedEquipment...)
+	gurps.Traverse(f, false, false, v.entity.OtherEquipment...)
+}
+
+func (v *validator) checkEncumbrance() {
+	units := gurps.SheetSettingsFor(v.entity).DefaultWeightUnits
+	carried := v.entity.WeightCarried(false)
+	if max := v.entity.MaximumCarry(datafile.ExtraHeavy); carried > max {
+		v.add(Error, i18n.Text("Equipment"), "",
+			fmt.Sprintf(i18n.Text("carried weight of %s exceeds the extra-heavy limit of %s"),
+				units.Format(carried), units.Format(max)))
+	}
+	f := func(e *gurps.Equipment) bool {
+		if e.OverCapacity(units) {
+			v.add(Error, i18n.Text("Equipment"), e.String(),
+				fmt.Sprintf(i18n.Text("contents weigh %s, which exceeds its capacity of %s"),
+					units.Format(e.ContentsWeight(units)), units.Format(e.Capacity)))
+		}
+		return false
+	}
+	gurps.Traverse(f, false, false, v.entity.CarriedEquipment...)
+	gurps.Traverse(f, false, false, v.entity.OtherEquipment...)
+}
+
+func (v *validator) checkCampaign(campaign *gurps.Campaign) {
+	category := i18n.Text("Campaign")
+	if v.entity.TotalPoints < campaign.StartingPoints {
+		v.add(Warning, category, "", fmt.Sprintf(i18n.Text("total points of %s are less than the starting points of %s"),
+			v.entity.TotalPoints.String(), campaign.StartingPoints.String()))
+	}
+	min, max := campaign.TechLevelRange()
+	if tl, start, _ := gurps.ExtractTechLevel(v.entity.Profile.TechLevel); start != -1 {
+		if (min >= 0 && tl < min) || (max >= 0 && tl > max) {
+			v.add(Error, category, "", fmt.Sprintf(i18n.Text("tech level %s is outside of the permitted range"),
+				tl.String()))
+		}
+	}
+	for _, rule := range campaign.ForbiddenTraits {
+		gurps.Traverse(func(t *gurps.Trait) bool {
+			if gurps.TraitMatches(t, rule) {
+				v.add(Error, category, t.String(), i18n.Text("is not permitted in this campaign"))
+			}
+			return false
+		}, true, true, v.entity.Traits...)
+	}
+	for _, rule := range campaign.RequiredTraits {
+		found := false
+		gurps.Traverse(func(t *gurps.Trait) bool {
+			found = gurps.TraitMatches(t, rule)
+			return found
+		}, true, true, v.entity.Traits...)
+		if !found {
+			v.add(Error, category, "", fmt.Sprintf(i18n.Text("the required trait \"%s\" is missing"),
+				strings.TrimSpace(rule)))
+		}
+	}
+	for _, list := range [][]*gurps.Equipment{v.entity.CarriedEquipment, v.entity.OtherEquipment} {
+		for _, e := range list {
+			if reason := campaign.EquipmentRestriction(e, true); reason != "" {
+				v.add(Warning, category, "", reason)
+			}
+		}
+	}
+	if campaign.LibrariesRestricted() {
+		v.checkLibraries(campaign)
+	}
+}
+
+func (v *validator) checkLibraries(campaign *gurps.Campaign) {
+	msg := i18n.Text("was not found in any of the campaign's libraries")
+	category := i18n.Text("Campaign")
+	gurps.Traverse(func(t *gurps.Trait) bool {
+		if !campaign.LibraryHas(library.TraitsExt, t.Name) {
+			v.add(Warning, category, t.String(), msg)
+		}
+		return false
+	}, true, false, v.entity.Traits...)
+	gurps.Traverse(func(s *gurps.Skill) bool {
+		if !campaign.LibraryHas(library.SkillsExt, s.Name) {
+			v.add(Warning, category, s.String(), msg)
+		}
+		return false
+	}, true, false, v.entity.Skills...)
+	gurps.Traverse(func(s *gurps.Spell) bool {
+		if !campaign.LibraryHas(library.SpellsExt, s.Name) {
+			v.add(Warning, category, s.String(), msg)
+		}
+		return false
+	}, true, false, v.entity.Spells...)
+	f := func(e *gurps.Equipment) bool {
+		if !campaign.LibraryHas(library.EquipmentExt, e.Name) {
+			v.add(Warning, category, e.String(), msg)
+		}
+		return false
+	}
+	gurps.Traverse(f, true, false, v.entity.CarriedEquipment...)
+	gurps.Traverse(f, true, false, v.entity.OtherEquipment...)
+}
+
+func (v *validator) checkFeatures(subject string, features feature.Features) {
+	for _, f := range features {
+		var attrID string
+		switch one := f.(type) {
+		case *feature.AttributeBonus:
+			attrID = one.Attribute
+		case *feature.CostReduction:
+			attrID = one.Attribute
+		default:
+			continue
+		}
+		if !v.validAttributeID(attrID) {
+			v.add(Error, i18n.Text("Features"), subject,
+				fmt.Sprintf(i18n.Text("references the non-existent attribute \"%s\""), attrID))
+		}
+	}
+}
+
+func (v *validator) validAttributeID(attrID string) bool {
+	switch attrID {
+	case gid.SizeModifier, gid.Dodge, gid.Parry, gid.Block:
+		return true
+	}
+	_, exists := gurps.AttributeDefsFor(v.entity).Set[attrID]
+	return exists
+}
+
+// CheckFiles validates each of the sheets, writing any issues found to w. Returns true if any issues were found.
+func CheckFiles(w io.Writer, fileList []string) (bool, error) {
+	budget := DefaultBudget()
+	found := false
+	for _, one := range fileList {
+		if !strings.EqualFold(filepath.Ext(one), library.SheetExt) {
+			jot.Warn("ignoring: " + one)
+			continue
+		}
+		entity, err := gurps.NewEntityFromFile(os.DirFS(filepath.Dir(one)), filepath.Base(one))
+		if err != nil {
+			return found, err
+		}
+		var issues []*Issue
+		if entity.Campaign != "" {
+			campaign, _, campaignErr := gurps.LoadCampaignForSheet(one, entity.Campaign)
+			if campaignErr != nil {
+				jot.Warn(campaignErr)
+				issues = append(issues, &Issue{
+					Severity: Warning,
+					Category: i18n.Text("Campaign"),
+					Message:  fmt.Sprintf(i18n.Text("unable to load the campaign \"%s\""), entity.Campaign),
+				})
+				issues = append(issues, Validate(entity, budget)...)
+			} else {
+				issues = ValidateForCampaign(entity, campaign)
+			}
+		} else {
+			issues = Validate(entity, budget)
+		}
+		if len(issues) == 0 {
+			fmt.Fprintf(w, i18n.Text("%s: no issues\n"), one)
+			continue
+		}
+		found = true
+		fmt.Fprintf(w, i18n.Text("%s: %d issue(s)\n"), one, len(issues))
+		for _, issue := range issues {
+			fmt.Fprintf(w, "  %s\n", issue)
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package validation_test
+
+import (
+	"testing"
+
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/datafile"
+	"github.com/richardwilkes/gcs/v5/model/gurps/measure"
+	"github.com/richardwilkes/gcs/v5/model/gurps/validation"
+	"github.com/richardwilkes/gcs/v5/model/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEntity() *gurps.Entity {
+	settings.Global()
+	e := gurps.NewEntity(datafile.PC)
+	e.Traits = nil
+	e.CarriedEquipment = nil
+	e.OtherEquipment = nil
+	return e
+}
+
+func issuesIn(issues []*validation.Issue, category string) []string {
+	var list []string
+	for _, one := range issues {
+		if one.Category == category {
+			list = append(list, one.Message)
+		}
+	}
+	return list
+}
+
+func TestCampaignEquipmentRestrictions(t *testing.T) {
+	settings.Global()
+	campaign := gurps.NewCampaign()
+	campaign.MinimumTechLevel = "3"
+	campaign.MaximumTechLevel = "5"
+	campaign.MinLegalityClass = "2"
+	for _, tc := range []struct {
+		name     string
+		tl       string
+		lc       string
+		carried  bool
+		contents bool
+		expected []string
+	}{
+		{name: "Sword", tl: "4", lc: "3", carried: true},
+		{name: "Sword", tl: "", lc: "", carried: true},
+		{
+			name:     "Club",
+			tl:       "0",
+			lc:       "4",
+			carried:  true,
+			expected: []string{"Club: tech level 0 is below the campaign's minimum"},
+		},
+		{
+			name:     "Rifle",
+			tl:       "7",
+			lc:       "3",
+			expected: []string{"Rifle: tech level 7 is above the campaign's maximum"},
+		},
+		{
+			name:     "Poison",
+			tl:       "4",
+			lc:       "1",
+			carried:  true,
+			expected: []string{"Poison: legality class 1 is below the campaign's minimum"},
+		},
+		{
+			name:     "Laser",
+			tl:       "9",
+			lc:       "3",
+			carried:  true,
+			contents: true,
+			expected: []string{"Laser: tech level 9 is above the campaign's maximum"},
+		},
+	} {
+		e := newEntity()
+		eqp := gurps.NewEquipment(e, nil, false)
+		eqp.Name = tc.name
+		eqp.TechLevel = tc.tl
+		eqp.LegalityClass = tc.lc
+		top := eqp
+		if tc.contents {
+			top = gurps.NewEquipment(e, nil, true)
+			top.Name = "Backpack"
+			top.LegalityClass = "4"
+			eqp = gurps.NewEquipment(e, top, false)
+			eqp.Name = tc.name
+			eqp.TechLevel = tc.tl
+			eqp.LegalityClass = tc.lc
+			top.Children = []*gurps.Equipment{eqp}
+		}
+		if tc.carried {
+			e.CarriedEquipment = []*gurps.Equipment{top}
+		} else {
+			e.OtherEquipment = []*gurps.Equipment{top}
+		}
+		assert.Equal(t, tc.expected, issuesIn(validation.ValidateForCampaign(e, campaign), "Campaign"), tc.name)
+	}
+}
+
+func TestContainerCapacity(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		capacity int
+		contents []int
+		nested   bool
+		expected []string
+	}{
+		{name: "Unlimited", contents: []int{50, 60}},
+		{name: "Within", capacity: 20, contents: []int{5, 15}},
+		{
+			name:     "Over",
+			capacity: 20,
+			contents: []int{5, 16},
+			expected: []string{"contents weigh 21 lb, which exceeds its capacity of 20 lb"},
+		},
+		{
+			name:     "Nested",
+			capacity: 10,
+			contents: []int{4, 8},
+			nested:   true,
+			expected: []string{"contents weigh 12 lb, which exceeds its capacity of 10 lb"},
+		},
+	} {
+		e := newEntity()
+		e.SheetSettings.DefaultWeightUnits = measure.Pound
+		pack := gurps.NewEquipment(e, nil, true)
+		pack.Name = "Pack"
+		pack.Capacity = measure.WeightFromInteger(tc.capacity, measure.Pound)
+		holder := pack
+		if tc.nested {
+			// The inner container has no capacity of its own, so only the pack should be reported.
+			holder = gurps.NewEquipment(e, pack, true)
+			pack.Children = []*gurps.Equipment{holder}
+		}
+		for _, weight := range tc.contents {
+			item := gurps.NewEquipment(e, holder, false)
+			item.Weight = measure.WeightFromInteger(weight, measure.Pound)
+			holder.Children = append(holder.Children, item)
+		}
+		e.OtherEquipment = []*gurps.Equipment{pack}
+		assert.Equal(t, tc.expected, issuesIn(validation.Validate(e, nil), "Equipment"), tc.name)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"bufio"
 	"fmt"
 	"hash/fnv"
+	"strconv"
 	"strings"
 	"unsafe"
 
@@ -79,6 +80,7 @@ type WeaponData struct {
 	Shots           string          `json:"shots,omitempty"`
 	Bulk            string          `json:"bulk,omitempty"`
 	Recoil          string          `json:"recoil,omitempty"`
+	Ammo            *WeaponAmmo     `json:"ammo,omitempty"`
 	Defaults        []*SkillDefault `json:"defaults,omitempty"`
 }
 
@@ -148,6 +150,7 @@ func (w *Weapon) Clone(_ *Entity, _ *Weapon, preserveID bool) *Weapon {
 		other.id = uuid.New()
 	}
 	other.Damage = *other.Damage.Clone(&other)
+	other.Ammo = w.Ammo.Clone()
 	if other.Defaults != nil {
 		other.Defaults = make([]*SkillDefault, 0, len(w.Defaults))
 		for _, one := range w.Defaults {
@@ -202,6 +205,14 @@ func (w *Weapon) HashCode() uint32 {
 	h.Write([]byte(w.Bulk))
 	h.Write([]byte(w.Recoil))
 	h.Write([]byte(w.MinimumStrength))
+	if w.Ammo != nil {
+		h.Write([]byte(w.Ammo.Source))
+		h.Write([]byte(w.Ammo.Name))
+		if w.Ammo.Loose {
+			h.Write([]byte{1})
+		}
+		h.Write([]byte(strconv.Itoa(w.Ammo.Loaded)))
+	}
 	return h.Sum32()
 }
 
@@ -688,6 +699,10 @@ func (w *Weapon) CellData(column int, data *CellData) {
 		data.Primary = w.RateOfFire
 	case WeaponShotsColumn:
 		data.Primary = w.Shots
+		data.Secondary = w.AmmoStatus()
+		if eqp := w.AmmoSource(); eqp != nil {
+			data.Tooltip = fmt.Sprintf(i18n.Text("Ammunition: %s"), eqp.Description())
+		}
 	case WeaponBulkColumn:
 		data.Primary = w.Bulk
 	case WeaponRecoilColumn:
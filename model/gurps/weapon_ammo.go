@@ -0,0 +1,244 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package gurps
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/xmath"
+)
+
+// WeaponAmmo links a ranged weapon to the equipment that supplies its ammunition.
+type WeaponAmmo struct {
+	// Source is either the ID of a piece of equipment or a tag. When it is a tag, the first carried piece of equipment
+	// with that tag and a non-zero quantity is used.
+	Source string `json:"source"`
+	// Name is the name of the equipment Source referred to when it was linked by ID. Equipment is given a new ID when
+	// it is copied, such as when dragged in from a library or bought from a shop, so the link falls back to carried
+	// equipment with this name when the ID can't be found.
+	Name string `json:"name,omitempty"`
+	// Loose is true if the ammunition is loaded a round at a time (arrows, shotgun shells, etc.) and the equipment
+	// quantity counts rounds, rather than being loaded from a magazine where the equipment quantity counts magazines.
+	Loose bool `json:"loose,omitempty"`
+	// Loaded is the number of shots currently loaded in the weapon.
+	Loaded int `json:"loaded,omitempty"`
+}
+
+// Clone returns a copy of the ammo link.
+func (a *WeaponAmmo) Clone() *WeaponAmmo {
+	if a == nil {
+		return nil
+	}
+	other := *a
+	return &other
+}
+
+// SourceID returns the equipment ID the ammo link refers to, if it refers to one by ID.
+func (a *WeaponAmmo) SourceID() (uuid.UUID, bool) {
+	id, err := uuid.Parse(a.Source)
+	return id, err == nil
+}
+
+// ShotsPerAttack returns the number of shots a single attack uses, as determined by the leading number of the rate of
+// fire. Multipliers, such as the pellet count of a shotgun's "3x9", don't use additional shots.
+func (w *Weapon) ShotsPerAttack() int {
+	if n := leadingInt(w.RateOfFire); n > 0 {
+		return n
+	}
+	return 1
+}
+
+// ShotCapacity returns the number of shots the weapon holds when fully loaded, including any extra round in the
+// chamber, e.g. "30+1(3)" yields 31. Returns 0 if the weapon doesn't hold its own ammunition, such as with thrown
+// weapons.
+func (w *Weapon) ShotCapacity() int {
+	magazine, chamber := w.shotCapacities()
+	return magazine + chamber
+}
+
+// shotCapacities returns the number of shots held by the magazine and by the chamber, e.g. "30+1(3)" yields 30 and 1.
+func (w *Weapon) shotCapacities() (magazine, chamber int) {
+	shots := strings.TrimSpace(w.Shots)
+	if magazine = leadingInt(shots); magazine == 0 {
+		return 0, 0
+	}
+	if i := strings.IndexByte(shots, '+'); i != -1 {
+		chamber = leadingInt(shots[i+1:])
+	}
+	return magazine, chamber
+}
+
+func leadingInt(s string) int {
+	s = strings.TrimSpace(s)
+	value := 0
+	for _, ch := range s {
+		if ch < '0' || ch > '9' {
+			break
+		}
+		value = value*10 + int(ch-'0')
+	}
+	return value
+}
+
+// AmmoSource returns the carried equipment supplying the ammunition for this weapon, or nil if there isn't any.
+func (w *Weapon) AmmoSource() *Equipment {
+	if w.Ammo == nil || strings.TrimSpace(w.Ammo.Source) == "" {
+		return nil
+	}
+	entity := w.Entity()
+	if entity == nil {
+		return nil
+	}
+	if id, ok := w.Ammo.SourceID(); ok {
+		var found *Equipment
+		Traverse(func(eqp *Equipment) bool {
+			if eqp.ID == id {
+				found = eqp
+				return true
+			}
+			return false
+		}, false, false, entity.CarriedEquipment...)
+		if found != nil {
+			return found
+		}
+		name := strings.TrimSpace(w.Ammo.Name)
+		if name == "" {
+			return nil
+		}
+		return firstAmmoMatch(entity, func(eqp *Equipment) bool { return strings.EqualFold(eqp.Name, name) })
+	}
+	tag := strings.TrimSpace(w.Ammo.Source)
+	return firstAmmoMatch(entity, func(eqp *Equipment) bool {
+		for _, one := range eqp.Tags {
+			if strings.EqualFold(one, tag) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// firstAmmoMatch returns the first carried equipment that matches and has a non-zero quantity, or the first that
+// matches if none have any quantity left.
+func firstAmmoMatch(entity *Entity, matches func(eqp *Equipment) bool) *Equipment {
+	var found, fallback *Equipment
+	Traverse(func(eqp *Equipment) bool {
+		if matches(eqp) {
+			if eqp.Quantity > 0 {
+				found = eqp
+				return true
+			}
+			if fallback == nil {
+				fallback = eqp
+			}
+		}
+		return false
+	}, false, false, entity.CarriedEquipment...)
+	if found == nil {
+		found = fallback
+	}
+	return found
+}
+
+func (w *Weapon) ammoQuantity() int {
+	if eqp := w.AmmoSource(); eqp != nil {
+		return xmath.Max(fxp.As[int](eqp.Quantity.Trunc()), 0)
+	}
+	return 0
+}
+
+// CanFire returns true if the weapon is linked to ammunition and has a shot available.
+func (w *Weapon) CanFire() bool {
+	if w.Ammo == nil {
+		return false
+	}
+	if w.ShotCapacity() == 0 {
+		return w.ammoQuantity() > 0
+	}
+	return w.Ammo.Loaded > 0
+}
+
+// Fire uses the shots for a single attack, at most the number currently loaded, and returns the number of shots used.
+// Weapons that don't hold their own ammunition take it directly from the linked equipment.
+func (w *Weapon) Fire() int {
+	if !w.CanFire() {
+		return 0
+	}
+	if w.ShotCapacity() == 0 {
+		eqp := w.AmmoSource()
+		shots := xmath.Min(w.ShotsPerAttack(), w.ammoQuantity())
+		eqp.Quantity -= fxp.From(shots)
+		return shots
+	}
+	shots := xmath.Min(w.ShotsPerAttack(), w.Ammo.Loaded)
+	w.Ammo.Loaded -= shots
+	return shots
+}
+
+// CanReload returns true if the weapon holds its own ammunition, isn't full and has ammunition to reload from.
+func (w *Weapon) CanReload() bool {
+	if w.Ammo == nil {
+		return false
+	}
+	capacity := w.ShotCapacity()
+	return capacity > 0 && w.Ammo.Loaded < capacity && w.ammoQuantity() > 0
+}
+
+// Reload the weapon from the linked equipment. Loose ammunition tops up the weapon a round at a time, while a magazine
+// replaces whatever was loaded, discarding any rounds left in the old one. A round already in the chamber stays there
+// when the magazine is swapped; an empty weapon has to chamber its first round from the new magazine.
+func (w *Weapon) Reload() {
+	if !w.CanReload() {
+		return
+	}
+	eqp := w.AmmoSource()
+	magazine, chamber := w.shotCapacities()
+	if w.Ammo.Loose {
+		rounds := xmath.Min(magazine+chamber-w.Ammo.Loaded, w.ammoQuantity())
+		eqp.Quantity -= fxp.From(rounds)
+		w.Ammo.Loaded += rounds
+	} else {
+		eqp.Quantity -= fxp.One
+		w.Ammo.Loaded = magazine + xmath.Min(chamber, w.Ammo.Loaded)
+	}
+}
+
+// Reloads returns the number of full reloads available from the linked equipment.
+func (w *Weapon) Reloads() int {
+	quantity := w.ammoQuantity()
+	if w.Ammo != nil && w.Ammo.Loose {
+		if capacity := w.ShotCapacity(); capacity > 0 {
+			return quantity / capacity
+		}
+	}
+	return quantity
+}
+
+// AmmoStatus returns a short description of the remaining shots and reloads, or an empty string if the weapon isn't
+// linked to any ammunition.
+func (w *Weapon) AmmoStatus() string {
+	if w.Ammo == nil {
+		return ""
+	}
+	if w.AmmoSource() == nil {
+		return i18n.Text("No ammunition")
+	}
+	capacity := w.ShotCapacity()
+	if capacity == 0 {
+		return fmt.Sprintf(i18n.Text("%d left"), w.ammoQuantity())
+	}
+	return fmt.Sprintf(i18n.Text("%d/%d loaded, %d reloads"), w.Ammo.Loaded, capacity, w.Reloads())
+}
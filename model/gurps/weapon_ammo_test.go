@@ -0,0 +1,130 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package gurps_test
+
+import (
+	"testing"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/datafile"
+	"github.com/richardwilkes/gcs/v5/model/gurps/weapon"
+	"github.com/richardwilkes/gcs/v5/model/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAmmoWeapon returns a ranged weapon with the given shots and rate of fire, linked by ID to carried ammunition with
+// the given quantity.
+func newAmmoWeapon(shots, rof string, quantity int, loose bool) (w *gurps.Weapon, ammo *gurps.Equipment) {
+	settings.Global()
+	entity := gurps.NewEntity(datafile.PC)
+	entity.Traits = nil
+	gun := gurps.NewEquipment(entity, nil, false)
+	gun.Name = "Rifle"
+	w = gurps.NewWeapon(gun, weapon.Ranged)
+	w.Shots = shots
+	w.RateOfFire = rof
+	gun.Weapons = []*gurps.Weapon{w}
+	ammo = gurps.NewEquipment(entity, nil, false)
+	ammo.Name = "Magazine"
+	ammo.Quantity = fxp.From(quantity)
+	entity.CarriedEquipment = []*gurps.Equipment{gun, ammo}
+	entity.OtherEquipment = nil
+	w.Ammo = &gurps.WeaponAmmo{
+		Source: ammo.ID.String(),
+		Name:   ammo.Name,
+		Loose:  loose,
+	}
+	return w, ammo
+}
+
+func TestWeaponFire(t *testing.T) {
+	w, ammo := newAmmoWeapon("30+1(3)", "3", 2, false)
+	assert.Equal(t, 31, w.ShotCapacity())
+	assert.False(t, w.CanFire(), "nothing loaded yet")
+	assert.Equal(t, 0, w.Fire())
+
+	w.Ammo.Loaded = 7
+	assert.Equal(t, 3, w.Fire())
+	assert.Equal(t, 4, w.Ammo.Loaded)
+	assert.Equal(t, 3, w.Fire())
+	assert.Equal(t, 1, w.Fire(), "only the shots that remain are fired")
+	assert.Equal(t, 0, w.Ammo.Loaded)
+	assert.False(t, w.CanFire())
+	assert.Equal(t, fxp.Two, ammo.Quantity, "firing a loaded weapon doesn't touch the magazines")
+
+	// Weapons that don't hold their own ammunition fire straight from the linked equipment.
+	w, ammo = newAmmoWeapon("T(1)", "1", 2, true)
+	assert.Equal(t, 0, w.ShotCapacity())
+	assert.True(t, w.CanFire())
+	assert.Equal(t, 1, w.Fire())
+	assert.Equal(t, 1, w.Fire())
+	assert.Equal(t, 0, w.Fire())
+	assert.Equal(t, fxp.From(0), ammo.Quantity)
+}
+
+func TestWeaponReload(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		shots    string
+		loose    bool
+		quantity int
+		loaded   int
+		expected int
+		left     int
+	}{
+		{name: "empty magazine weapon", shots: "30+1(3)", quantity: 2, loaded: 0, expected: 30, left: 1},
+		{name: "chambered magazine weapon", shots: "30+1(3)", quantity: 2, loaded: 5, expected: 31, left: 1},
+		{name: "magazine without chamber", shots: "6(3i)", quantity: 2, loaded: 2, expected: 6, left: 1},
+		{name: "loose rounds", shots: "2(2i)", loose: true, quantity: 10, loaded: 1, expected: 2, left: 9},
+		{name: "too few loose rounds", shots: "5(2i)", loose: true, quantity: 2, loaded: 1, expected: 3, left: 0},
+		{name: "full", shots: "6(3i)", quantity: 2, loaded: 6, expected: 6, left: 2},
+		{name: "no ammunition", shots: "6(3i)", quantity: 0, loaded: 0, expected: 0, left: 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w, ammo := newAmmoWeapon(tc.shots, "1", tc.quantity, tc.loose)
+			w.Ammo.Loaded = tc.loaded
+			w.Reload()
+			assert.Equal(t, tc.expected, w.Ammo.Loaded)
+			assert.Equal(t, fxp.From(tc.left), ammo.Quantity)
+		})
+	}
+}
+
+func TestWeaponAmmoSurvivesCopy(t *testing.T) {
+	w, ammo := newAmmoWeapon("30(3)", "1", 2, false)
+	entity := w.Entity()
+	require.NotNil(t, entity)
+	require.Same(t, ammo, w.AmmoSource())
+
+	// Copying the weapon and its ammunition, as happens when dragging them in from a library or buying them, gives
+	// them new IDs.
+	gun := entity.CarriedEquipment[0].Clone(entity, nil, false)
+	copied := ammo.Clone(entity, nil, false)
+	require.NotEqual(t, ammo.ID, copied.ID)
+	entity.CarriedEquipment = []*gurps.Equipment{gun, copied}
+	require.Len(t, gun.Weapons, 1)
+	assert.Same(t, copied, gun.Weapons[0].AmmoSource())
+
+	copied.Name = "Something Else"
+	assert.Nil(t, gun.Weapons[0].AmmoSource())
+}
+
+func TestWeaponHashIgnoresAmmoSupply(t *testing.T) {
+	w, ammo := newAmmoWeapon("30(3)", "1", 2, false)
+	hash := w.HashCode()
+	ammo.Quantity = fxp.Ten
+	assert.Equal(t, hash, w.HashCode())
+	w.Ammo.Loaded = 30
+	assert.NotEqual(t, hash, w.HashCode())
+}
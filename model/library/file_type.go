@@ -37,6 +37,10 @@ const (
 	NotesExt              = ".not"
 	TemplatesExt          = ".gct"
 	SheetExt              = ".gcs"
+	CampaignExt           = ".campaign"
+	AncestryExt           = ".ancestry"
+	NameGeneratorExt      = ".names"
+	BodyExt               = ".body"
 )
 
 // FileInfo contains some static information about a given file type.
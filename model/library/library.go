@@ -12,10 +12,10 @@
 package library
 
 import (
-	"archive/zip"
 	"bytes"
 	"context"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -35,11 +35,12 @@ const releaseFile = "release.txt"
 
 // Library holds information about a library of data files.
 type Library struct {
-	Title             string `json:"title,omitempty"`
-	GitHubAccountName string `json:"-"`
-	RepoName          string `json:"-"`
-	PathOnDisk        string `json:"path,omitempty"`
-	LastSeen          string `json:"last_seen,omitempty"`
+	Title             string               `json:"title,omitempty"`
+	GitHubAccountName string               `json:"-"`
+	RepoName          string               `json:"-"`
+	PathOnDisk        string               `json:"path,omitempty"`
+	LastSeen          string               `json:"last_seen,omitempty"`
+	Source            *ReleaseSourceConfig `json:"source,omitempty"`
 	monitor           *monitor
 	lock              sync.RWMutex
 	upgrade           *Release
@@ -119,6 +120,15 @@ func (l *Library) IsUser() bool {
 	return l.GitHubAccountName == userGitHubAccountName && l.RepoName == userRepoName
 }
 
+// ReleaseSource returns the source of releases for this library. Unless a Source has been configured, this is the
+// GitHub repo identified by the library's key.
+func (l *Library) ReleaseSource() (ReleaseSource, error) {
+	if l.Source != nil {
+		return l.Source.NewReleaseSource()
+	}
+	return &GitHubSource{AccountName: l.GitHubAccountName, RepoName: l.RepoName}, nil
+}
+
 // CheckForAvailableUpgrade returns releases that can be upgraded to.
 func (l *Library) CheckForAvailableUpgrade(ctx context.Context, client *http.Client) {
 	l.lock.Lock()
@@ -126,12 +136,17 @@ func (l *Library) CheckForAvailableUpgrade(ctx context.Context, client *http.Cli
 	l.lock.Unlock()
 	incompatibleFutureLibraryVersion := strconv.Itoa(gid.CurrentDataVersion + 1)
 	minimumLibraryVersion := strconv.Itoa(gid.MinimumLibraryVersion)
-	available, err := LoadReleases(ctx, client, l.GitHubAccountName, l.RepoName, l.VersionOnDisk(),
-		func(version, notes string) bool {
-			return incompatibleFutureLibraryVersion == version ||
-				txt.NaturalLess(version, minimumLibraryVersion, true) ||
-				txt.NaturalLess(incompatibleFutureLibraryVersion, version, true)
-		})
+	var available []Release
+	source, err := l.ReleaseSource()
+	if err == nil {
+		if available, err = source.Releases(ctx, client); err == nil {
+			available = FilterReleases(available, l.VersionOnDisk(), func(version, notes string) bool {
+				return incompatibleFutureLibraryVersion == version ||
+					txt.NaturalLess(version, minimumLibraryVersion, true) ||
+					txt.NaturalLess(incompatibleFutureLibraryVersion, version, true)
+			})
+		}
+	}
 	var upgrade *Release
 	if err != nil {
 		jot.Error(err)
@@ -194,64 +209,101 @@ func (l *Library) VersionOnDisk() string {
 	return strings.TrimSpace(string(bytes.SplitN(data, []byte{'\n'}, 2)[0]))
 }
 
-// Download the release onto the local disk.
+// Download the release onto the local disk, replacing whatever was there before. The release is extracted next to the
+// existing copy and only swapped in once it is complete, so files dropped by the release don't linger and a failed
+// download leaves the existing copy intact.
 func (l *Library) Download(ctx context.Context, client *http.Client, release Release) error {
-	p := l.Path()
-	if err := os.MkdirAll(p, 0o750); err != nil {
-		return errs.NewWithCause("unable to create "+p, err)
+	root := filepath.Clean(l.Path())
+	parent := filepath.Dir(root)
+	if err := os.MkdirAll(parent, 0o750); err != nil {
+		return errs.NewWithCause("unable to create "+parent, err)
 	}
-	data, err := l.downloadRelease(ctx, client, release)
+	source, err := l.ReleaseSource()
 	if err != nil {
 		return err
 	}
-	var zr *zip.Reader
-	if zr, err = zip.NewReader(bytes.NewReader(data), int64(len(data))); err != nil {
-		return errs.NewWithCause("unable to open archive "+release.ZipFileURL, err)
+	var content fs.FS
+	if content, err = source.Content(ctx, client, release); err != nil {
+		return err
+	}
+	var staging string
+	if staging, err = os.MkdirTemp(parent, "."+filepath.Base(root)+"-"); err != nil {
+		return errs.NewWithCause("unable to create a staging area in "+parent, err)
+	}
+	if err = os.Chmod(staging, 0o750); err != nil {
+		jot.Warn(errs.NewWithCause("unable to set permissions on "+staging, err))
+	}
+	if err = extractRelease(content, staging, release); err != nil {
+		if rmErr := os.RemoveAll(staging); rmErr != nil {
+			jot.Warn(errs.NewWithCause("unable to remove "+staging, rmErr))
+		}
+		return err
+	}
+	tokens := l.monitor.stop()
+	defer func() {
+		for _, token := range tokens {
+			l.monitor.startWatch(token, true)
+		}
+	}()
+	if err = os.RemoveAll(root); err != nil {
+		return errs.NewWithCause("unable to remove "+root, err)
+	}
+	if err = os.Rename(staging, root); err != nil {
+		return errs.NewWithCause("unable to move "+staging+" to "+root, err)
 	}
-	root := filepath.Clean(p)
+	return nil
+}
+
+func extractRelease(content fs.FS, root string, release Release) error {
 	rootWithTrailingSep := root
 	if !strings.HasSuffix(rootWithTrailingSep, string(filepath.Separator)) {
 		rootWithTrailingSep += string(filepath.Separator)
 	}
-	for _, f := range zr.File {
-		fi := f.FileInfo()
-		mode := fi.Mode()
-		if mode&os.ModeType == 0 { // normal files only
-			parts := strings.SplitN(filepath.ToSlash(f.Name), "/", 3)
-			if len(parts) != 3 {
-				continue
-			}
-			if !strings.EqualFold("Library", parts[1]) {
-				continue
-			}
-			fullPath := filepath.Join(root, parts[2])
-			if !strings.HasPrefix(fullPath, rootWithTrailingSep) {
-				return errs.Newf("path outside of root is not permitted: %s", fullPath)
-			}
-			parent := filepath.Dir(fullPath)
-			if err = os.MkdirAll(parent, 0o750); err != nil {
-				return errs.NewWithCause("unable to create "+parent, err)
-			}
-			if err = extractFile(f, fullPath); err != nil {
-				return errs.NewWithCause("unable to create "+fullPath, err)
-			}
+	if err := fs.WalkDir(content, ".", func(name string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return errs.Wrap(walkErr)
+		}
+		if !d.Type().IsRegular() || name == releaseFile {
+			return nil
 		}
+		fullPath := filepath.Join(root, filepath.FromSlash(name))
+		if !strings.HasPrefix(fullPath, rootWithTrailingSep) {
+			return errs.Newf("path outside of root is not permitted: %s", fullPath)
+		}
+		parent := filepath.Dir(fullPath)
+		if mkErr := os.MkdirAll(parent, 0o750); mkErr != nil {
+			return errs.NewWithCause("unable to create "+parent, mkErr)
+		}
+		if extractErr := extractFile(content, name, fullPath); extractErr != nil {
+			return errs.NewWithCause("unable to create "+fullPath, extractErr)
+		}
+		return nil
+	}); err != nil {
+		return err
 	}
 	f := filepath.Join(root, releaseFile)
-	if err = os.WriteFile(f, []byte(release.Version+"\n"), 0o640); err != nil {
+	if err := os.WriteFile(f, []byte(release.Version+"\n"), 0o640); err != nil {
 		return errs.NewWithCause("unable to create "+f, err)
 	}
 	return nil
 }
 
-func extractFile(f *zip.File, dst string) (err error) {
-	var r io.ReadCloser
-	if r, err = f.Open(); err != nil {
+func extractFile(content fs.FS, name, dst string) (err error) {
+	var r fs.File
+	if r, err = content.Open(name); err != nil {
 		return errs.Wrap(err)
 	}
 	defer xio.CloseIgnoringErrors(r)
+	var fi fs.FileInfo
+	if fi, err = r.Stat(); err != nil {
+		return errs.Wrap(err)
+	}
+	perm := fi.Mode().Perm() & 0o750
+	if perm == 0 {
+		perm = 0o640
+	}
 	var file *os.File
-	if file, err = os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, f.FileInfo().Mode().Perm()&0o750); err != nil {
+	if file, err = os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm); err != nil {
 		return errs.Wrap(err)
 	}
 	if _, err = io.Copy(file, r); err != nil {
@@ -262,20 +314,3 @@ func extractFile(f *zip.File, dst string) (err error) {
 	}
 	return
 }
-
-func (l *Library) downloadRelease(ctx context.Context, client *http.Client, release Release) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, release.ZipFileURL, http.NoBody)
-	if err != nil {
-		return nil, errs.NewWithCause("unable to create request for "+release.ZipFileURL, err)
-	}
-	var rsp *http.Response
-	if rsp, err = client.Do(req); err != nil {
-		return nil, errs.NewWithCause("unable to connect to "+release.ZipFileURL, err)
-	}
-	defer xio.DiscardAndCloseIgnoringErrors(rsp.Body)
-	var data []byte
-	if data, err = io.ReadAll(rsp.Body); err != nil {
-		return nil, errs.NewWithCause("unable to download "+release.ZipFileURL, err)
-	}
-	return data, nil
-}
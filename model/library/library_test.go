@@ -0,0 +1,78 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package library_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o750))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o640))
+	}
+}
+
+func TestDownloadReplacesPreviousRelease(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "source")
+	writeFiles(t, src, map[string]string{
+		"release.txt":          "2.0.0\nNew release",
+		"Library/Kept.gct":     "new",
+		"Library/Sub/Added.gt": "added",
+	})
+	target := filepath.Join(dir, "Library")
+	writeFiles(t, target, map[string]string{
+		"release.txt":       "1.0.0\n",
+		"Kept.gct":          "old",
+		"Stale.gct":         "dropped by the new release",
+		"Gone/Also Old.gct": "dropped by the new release",
+	})
+	lib := library.NewLibrary("Test", "someone", "test_library", target)
+	lib.Source = &library.ReleaseSourceConfig{Kind: library.FolderReleaseSource, Location: src}
+	ctx := context.Background()
+
+	source, err := lib.Source.NewReleaseSource()
+	require.NoError(t, err)
+	var list []library.Release
+	list, err = source.Releases(ctx, http.DefaultClient)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.NoError(t, lib.Download(ctx, http.DefaultClient, list[0]))
+
+	assert.Equal(t, "2.0.0", lib.VersionOnDisk())
+	data, err := os.ReadFile(filepath.Join(target, "Kept.gct"))
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+	assert.FileExists(t, filepath.Join(target, "Sub", "Added.gt"))
+	assert.NoFileExists(t, filepath.Join(target, "Stale.gct"))
+	assert.NoDirExists(t, filepath.Join(target, "Gone"))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "no staging area should be left behind")
+
+	// A release that can't be retrieved leaves the existing copy alone.
+	lib.Source.Location = filepath.Join(dir, "missing")
+	assert.Error(t, lib.Download(ctx, http.DefaultClient, library.Release{Version: "3.0.0"}))
+	assert.Equal(t, "2.0.0", lib.VersionOnDisk())
+	assert.FileExists(t, filepath.Join(target, "Kept.gct"))
+}
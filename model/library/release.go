@@ -15,18 +15,15 @@ import (
 	"context"
 	"net/http"
 	"sort"
-	"strings"
 
-	"github.com/richardwilkes/json"
-	"github.com/richardwilkes/toolbox/errs"
 	"github.com/richardwilkes/toolbox/txt"
-	"github.com/richardwilkes/toolbox/xio"
 )
 
-// Release holds information about a single release of a GitHub repo.
+// Release holds information about a single release of a library or the application.
 type Release struct {
-	Version     string
-	Notes       string
+	Version string
+	Notes   string
+	// ZipFileURL holds the location of the archive for the release, for those sources that provide one.
 	ZipFileURL  string
 	CheckFailed bool
 }
@@ -38,42 +35,23 @@ func (r *Release) HasUpdate() bool {
 
 // LoadReleases loads the list of releases available from a given GitHub repo.
 func LoadReleases(ctx context.Context, client *http.Client, githubAccountName, repoName, currentVersion string, filter func(version, notes string) bool) ([]Release, error) {
-	if githubAccountName == "" || githubAccountName == "*" || repoName == "" {
-		return nil, nil
-	}
-	var versions []Release
-	uri := "https://api.github.com/repos/" + githubAccountName + "/" + repoName + "/releases"
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, http.NoBody)
+	source := &GitHubSource{AccountName: githubAccountName, RepoName: repoName}
+	releases, err := source.Releases(ctx, client)
 	if err != nil {
-		return nil, errs.NewWithCause("unable to create GitHub API request "+uri, err)
-	}
-	var rsp *http.Response
-	if rsp, err = client.Do(req); err != nil {
-		return nil, errs.NewWithCause("GitHub API request failed "+uri, err)
-	}
-	defer xio.DiscardAndCloseIgnoringErrors(rsp.Body)
-	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
-		return nil, errs.New("unexpected response code from GitHub API " + uri + " -> " + rsp.Status)
-	}
-	var releases []struct {
-		TagName    string `json:"tag_name"`
-		Body       string `json:"body"`
-		ZipBallURL string `json:"zipball_url"`
-	}
-	if err = json.NewDecoder(rsp.Body).Decode(&releases); err != nil {
-		return nil, errs.NewWithCause("unable to decode response from GitHub API "+uri, err)
+		return nil, err
 	}
+	return FilterReleases(releases, currentVersion, filter), nil
+}
+
+// FilterReleases returns the releases that are the same as or newer than the current version and which the filter
+// does not reject, sorted from newest to oldest. The current version is only retained if it is the sole release
+// remaining. A nil filter rejects nothing.
+func FilterReleases(releases []Release, currentVersion string, filter func(version, notes string) bool) []Release {
+	var versions []Release
 	for _, one := range releases {
-		if strings.HasPrefix(one.TagName, "v") {
-			if version := strings.TrimSpace(one.TagName[1:]); version != "" &&
-				(currentVersion == version || txt.NaturalLess(currentVersion, version, true)) {
-				if filter == nil || !filter(version, one.Body) {
-					versions = append(versions, Release{
-						Version:    version,
-						Notes:      one.Body,
-						ZipFileURL: one.ZipBallURL,
-					})
-				}
+		if one.Version != "" && (currentVersion == one.Version || txt.NaturalLess(currentVersion, one.Version, true)) {
+			if filter == nil || !filter(one.Version, one.Notes) {
+				versions = append(versions, one)
 			}
 		}
 	}
@@ -81,5 +59,5 @@ func LoadReleases(ctx context.Context, client *http.Client, githubAccountName, r
 	if len(versions) > 1 && versions[len(versions)-1].Version == currentVersion {
 		versions = versions[:len(versions)-1]
 	}
-	return versions, nil
+	return versions
 }
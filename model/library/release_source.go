@@ -0,0 +1,351 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package library
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/richardwilkes/json"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/xio"
+)
+
+// Possible ReleaseSourceKind values.
+const (
+	GitHubReleaseSource ReleaseSourceKind = "github"
+	FolderReleaseSource ReleaseSourceKind = "folder"
+	ZipReleaseSource    ReleaseSourceKind = "zip"
+	HTTPReleaseSource   ReleaseSourceKind = "http"
+)
+
+// ReleaseSourceKind identifies a kind of ReleaseSource.
+type ReleaseSourceKind string
+
+// ReleaseSource provides the releases of a library, along with the content of each.
+type ReleaseSource interface {
+	// Releases returns the releases the source has available, in no particular order.
+	Releases(ctx context.Context, client *http.Client) ([]Release, error)
+	// Content returns the files that make up the library for the release. The returned file system is rooted at the
+	// top of the library.
+	Content(ctx context.Context, client *http.Client, release Release) (fs.FS, error)
+}
+
+// ReleaseSourceConfig holds the configuration of a library's ReleaseSource. The meaning of the location depends on the
+// kind of source:
+//
+//	github: the account and repo names, e.g. "richardwilkes/gcs_master_library"
+//	folder: the path to a folder holding the library and a release.txt file
+//	zip:    the path to a zip file holding the library and a release.txt file
+//	http:   the URL of a release index (see HTTPIndexSource)
+type ReleaseSourceConfig struct {
+	Kind     ReleaseSourceKind `json:"kind"`
+	Location string            `json:"location,omitempty"`
+}
+
+var (
+	releaseSourceLock      sync.RWMutex
+	releaseSourceFactories = map[ReleaseSourceKind]func(location string) ReleaseSource{
+		GitHubReleaseSource: func(location string) ReleaseSource {
+			parts := strings.SplitN(location, "/", 2)
+			source := &GitHubSource{AccountName: strings.TrimSpace(parts[0])}
+			if len(parts) > 1 {
+				source.RepoName = strings.TrimSpace(parts[1])
+			}
+			return source
+		},
+		FolderReleaseSource: func(location string) ReleaseSource { return &FolderSource{Path: location} },
+		ZipReleaseSource:    func(location string) ReleaseSource { return &ZipSource{Path: location} },
+		HTTPReleaseSource:   func(location string) ReleaseSource { return &HTTPIndexSource{IndexURL: location} },
+	}
+)
+
+// RegisterReleaseSourceKind registers a factory for a kind of ReleaseSource, replacing any existing one for that kind.
+func RegisterReleaseSourceKind(kind ReleaseSourceKind, factory func(location string) ReleaseSource) {
+	releaseSourceLock.Lock()
+	releaseSourceFactories[kind] = factory
+	releaseSourceLock.Unlock()
+}
+
+// NewReleaseSource creates the ReleaseSource described by the configuration.
+func (c *ReleaseSourceConfig) NewReleaseSource() (ReleaseSource, error) {
+	releaseSourceLock.RLock()
+	factory, ok := releaseSourceFactories[c.Kind]
+	releaseSourceLock.RUnlock()
+	if !ok {
+		return nil, errs.Newf("unknown release source kind: %s", c.Kind)
+	}
+	return factory(c.Location), nil
+}
+
+// GitHubSource provides the releases of a GitHub repo. Only releases whose tags are of the form "v<version>" are
+// considered.
+type GitHubSource struct {
+	AccountName string
+	RepoName    string
+}
+
+// Releases implements ReleaseSource.
+func (s *GitHubSource) Releases(ctx context.Context, client *http.Client) ([]Release, error) {
+	if s.AccountName == "" || s.AccountName == "*" || s.RepoName == "" {
+		return nil, nil
+	}
+	uri := "https://api.github.com/repos/" + s.AccountName + "/" + s.RepoName + "/releases"
+	var releases []struct {
+		TagName    string `json:"tag_name"`
+		Body       string `json:"body"`
+		ZipBallURL string `json:"zipball_url"`
+	}
+	if err := fetchJSON(ctx, client, uri, &releases); err != nil {
+		return nil, err
+	}
+	list := make([]Release, 0, len(releases))
+	for _, one := range releases {
+		if strings.HasPrefix(one.TagName, "v") {
+			if version := strings.TrimSpace(one.TagName[1:]); version != "" {
+				list = append(list, Release{
+					Version:    version,
+					Notes:      one.Body,
+					ZipFileURL: one.ZipBallURL,
+				})
+			}
+		}
+	}
+	return list, nil
+}
+
+// Content implements ReleaseSource.
+func (s *GitHubSource) Content(ctx context.Context, client *http.Client, release Release) (fs.FS, error) {
+	return fetchZipContent(ctx, client, release.ZipFileURL)
+}
+
+// FolderSource provides a single release from a folder on disk. The folder must hold a release.txt file, whose first
+// line is the version and whose remaining lines are the release notes. The library itself may be placed directly
+// within the folder or within a "Library" sub-folder.
+type FolderSource struct {
+	Path string
+}
+
+// Releases implements ReleaseSource.
+func (s *FolderSource) Releases(_ context.Context, _ *http.Client) ([]Release, error) {
+	if _, err := os.Stat(s.Path); err != nil {
+		return nil, errs.NewWithCause("unable to access library folder "+s.Path, err)
+	}
+	return releaseFromFS(os.DirFS(s.Path), s.Path)
+}
+
+// Content implements ReleaseSource.
+func (s *FolderSource) Content(_ context.Context, _ *http.Client, _ Release) (fs.FS, error) {
+	return libraryRoot(os.DirFS(s.Path))
+}
+
+// ZipSource provides a single release from a zip file on disk. The zip file is laid out the same way as the folder for
+// a FolderSource, although the whole of it may also be placed within a single top-level folder.
+type ZipSource struct {
+	Path string
+}
+
+// Releases implements ReleaseSource.
+func (s *ZipSource) Releases(_ context.Context, _ *http.Client) ([]Release, error) {
+	zr, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	return releaseFromFS(zr, s.Path)
+}
+
+// Content implements ReleaseSource.
+func (s *ZipSource) Content(_ context.Context, _ *http.Client, _ Release) (fs.FS, error) {
+	zr, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	return libraryRoot(zr)
+}
+
+func (s *ZipSource) open() (*zip.Reader, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, errs.NewWithCause("unable to read "+s.Path, err)
+	}
+	return openZip(data, s.Path)
+}
+
+// HTTPIndexSource provides releases from a plain HTTP server. The index is a JSON array of objects, each with a
+// "version", optional "notes" and the "url" of a zip file holding the release, laid out as for a ZipSource. Relative
+// URLs are resolved against the URL of the index.
+type HTTPIndexSource struct {
+	IndexURL string
+}
+
+// Releases implements ReleaseSource.
+func (s *HTTPIndexSource) Releases(ctx context.Context, client *http.Client) ([]Release, error) {
+	base, err := url.Parse(s.IndexURL)
+	if err != nil {
+		return nil, errs.NewWithCause("invalid release index URL "+s.IndexURL, err)
+	}
+	var index []struct {
+		Version string `json:"version"`
+		Notes   string `json:"notes"`
+		URL     string `json:"url"`
+	}
+	if err = fetchJSON(ctx, client, s.IndexURL, &index); err != nil {
+		return nil, err
+	}
+	list := make([]Release, 0, len(index))
+	for _, one := range index {
+		version := strings.TrimPrefix(strings.TrimSpace(one.Version), "v")
+		if version == "" || one.URL == "" {
+			continue
+		}
+		var ref *url.URL
+		if ref, err = url.Parse(one.URL); err != nil {
+			return nil, errs.NewWithCause("invalid release URL "+one.URL+" in "+s.IndexURL, err)
+		}
+		list = append(list, Release{
+			Version:    version,
+			Notes:      one.Notes,
+			ZipFileURL: base.ResolveReference(ref).String(),
+		})
+	}
+	return list, nil
+}
+
+// Content implements ReleaseSource.
+func (s *HTTPIndexSource) Content(ctx context.Context, client *http.Client, release Release) (fs.FS, error) {
+	return fetchZipContent(ctx, client, release.ZipFileURL)
+}
+
+func fetchJSON(ctx context.Context, client *http.Client, uri string, data any) error {
+	body, err := fetch(ctx, client, uri)
+	if err != nil {
+		return err
+	}
+	if err = json.NewDecoder(bytes.NewReader(body)).Decode(data); err != nil {
+		return errs.NewWithCause("unable to decode response from "+uri, err)
+	}
+	return nil
+}
+
+func fetchZipContent(ctx context.Context, client *http.Client, uri string) (fs.FS, error) {
+	data, err := fetch(ctx, client, uri)
+	if err != nil {
+		return nil, err
+	}
+	var zr *zip.Reader
+	if zr, err = openZip(data, uri); err != nil {
+		return nil, err
+	}
+	return libraryRoot(zr)
+}
+
+func fetch(ctx context.Context, client *http.Client, uri string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, http.NoBody)
+	if err != nil {
+		return nil, errs.NewWithCause("unable to create request for "+uri, err)
+	}
+	var rsp *http.Response
+	if rsp, err = client.Do(req); err != nil {
+		return nil, errs.NewWithCause("unable to connect to "+uri, err)
+	}
+	defer xio.DiscardAndCloseIgnoringErrors(rsp.Body)
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return nil, errs.New("unexpected response code from " + uri + " -> " + rsp.Status)
+	}
+	var data []byte
+	if data, err = io.ReadAll(rsp.Body); err != nil {
+		return nil, errs.NewWithCause("unable to download "+uri, err)
+	}
+	return data, nil
+}
+
+func openZip(data []byte, name string) (*zip.Reader, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errs.NewWithCause("unable to open archive "+name, err)
+	}
+	return zr, nil
+}
+
+// releaseFromFS reads the release.txt file from the release and returns the single release it describes.
+func releaseFromFS(fsys fs.FS, name string) ([]Release, error) {
+	root, err := releaseRoot(fsys)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	if data, err = fs.ReadFile(root, releaseFile); err != nil {
+		var lib fs.FS
+		if lib, err = libraryRoot(fsys); err != nil {
+			return nil, err
+		}
+		if data, err = fs.ReadFile(lib, releaseFile); err != nil {
+			return nil, errs.NewWithCause("unable to load "+releaseFile+" from "+name, err)
+		}
+	}
+	parts := strings.SplitN(string(data), "\n", 2)
+	release := Release{Version: strings.TrimPrefix(strings.TrimSpace(parts[0]), "v")}
+	if release.Version == "" {
+		return nil, errs.New("no version specified in " + releaseFile + " from " + name)
+	}
+	if len(parts) > 1 {
+		release.Notes = strings.TrimSpace(parts[1])
+	}
+	return []Release{release}, nil
+}
+
+// releaseRoot returns the top of the release, looking through a single enclosing folder, such as those that GitHub
+// places around the contents of its archives.
+func releaseRoot(fsys fs.FS) (fs.FS, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	if len(entries) == 1 && entries[0].IsDir() && !strings.EqualFold(entries[0].Name(), "Library") {
+		var sub fs.FS
+		if sub, err = fs.Sub(fsys, entries[0].Name()); err != nil {
+			return nil, errs.Wrap(err)
+		}
+		return sub, nil
+	}
+	return fsys, nil
+}
+
+// libraryRoot returns the "Library" folder within the release, if there is one, or the top of the release otherwise.
+func libraryRoot(fsys fs.FS) (fs.FS, error) {
+	root, err := releaseRoot(fsys)
+	if err != nil {
+		return nil, err
+	}
+	var entries []fs.DirEntry
+	if entries, err = fs.ReadDir(root, "."); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	for _, one := range entries {
+		if one.IsDir() && strings.EqualFold(one.Name(), "Library") {
+			var sub fs.FS
+			if sub, err = fs.Sub(root, one.Name()); err != nil {
+				return nil, errs.Wrap(err)
+			}
+			return sub, nil
+		}
+	}
+	return root, nil
+}
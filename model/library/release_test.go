@@ -0,0 +1,76 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package library_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterReleases(t *testing.T) {
+	releases := []library.Release{
+		{Version: "4.1.0"},
+		{Version: "4.10.0", Notes: "experimental build"},
+		{Version: "4.2.0"},
+		{Version: ""},
+		{Version: "3.9.0"},
+		{Version: "4.9.1"},
+	}
+	skipExperimental := func(_, notes string) bool { return strings.Contains(notes, "experimental") }
+	for _, tc := range []struct {
+		name     string
+		current  string
+		filter   func(version, notes string) bool
+		expected []string
+	}{
+		{
+			name:     "newer releases, newest first, dropping the current one",
+			current:  "4.1.0",
+			expected: []string{"4.10.0", "4.9.1", "4.2.0"},
+		},
+		{
+			name:     "versions compare naturally rather than alphabetically",
+			current:  "4.9.1",
+			expected: []string{"4.10.0"},
+		},
+		{
+			name:     "the filter rejects releases",
+			current:  "4.1.0",
+			filter:   skipExperimental,
+			expected: []string{"4.9.1", "4.2.0"},
+		},
+		{
+			name:     "the current release is kept when it is the only one",
+			current:  "4.10.0",
+			expected: []string{"4.10.0"},
+		},
+		{
+			name:     "nothing at or above the current version",
+			current:  "5.0.0",
+			expected: nil,
+		},
+		{
+			name:     "no current version keeps everything with a version",
+			current:  "",
+			expected: []string{"4.10.0", "4.9.1", "4.2.0", "4.1.0", "3.9.0"},
+		},
+	} {
+		var versions []string
+		for _, one := range library.FilterReleases(releases, tc.current, tc.filter) {
+			versions = append(versions, one.Version)
+		}
+		assert.Equal(t, tc.expected, versions, tc.name)
+	}
+}
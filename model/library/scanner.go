@@ -92,3 +92,48 @@ func scanForNamedFileSets(fileSystem fs.FS, dirPath string, extensions []string,
 	}
 	return list
 }
+
+// ScanForDataFiles scans the full content of each library for data files with the given extensions, rather than just
+// the settings directory.
+func ScanForDataFiles(libraries Libraries, extensions ...string) []*NamedFileSet {
+	list := make([]*NamedFileSet, 0)
+	for _, lib := range libraries.List() {
+		fileSystem := os.DirFS(lib.Path())
+		refs := make([]*NamedFileRef, 0)
+		if err := fs.WalkDir(fileSystem, ".", func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			name := d.Name()
+			if d.IsDir() {
+				if p != "." && strings.HasPrefix(name, ".") {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			for _, extension := range extensions {
+				if strings.EqualFold(path.Ext(name), extension) {
+					refs = append(refs, &NamedFileRef{
+						Name:       xfs.TrimExtension(name),
+						FileSystem: fileSystem,
+						FilePath:   p,
+					})
+					break
+				}
+			}
+			return nil
+		}); err != nil {
+			jot.Error(errs.Wrap(err))
+		}
+		if len(refs) != 0 {
+			list = append(list, &NamedFileSet{
+				Name: lib.Title,
+				List: refs,
+			})
+		}
+	}
+	return list
+}
@@ -0,0 +1,231 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package pdfdoc
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"io"
+	"time"
+
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+// Document holds a PDF document under construction.
+type Document struct {
+	Title    string
+	Author   string
+	Subject  string
+	Creator  string
+	Modified time.Time
+	pages    []*Page
+	images   []image.Image
+}
+
+// NewDocument creates a new, empty document.
+func NewDocument() *Document {
+	return &Document{Modified: time.Now()}
+}
+
+// NewPage appends a new page with the given dimensions, in points, to the document.
+func (d *Document) NewPage(width, height float64) *Page {
+	p := &Page{
+		doc:    d,
+		width:  width,
+		height: height,
+		images: make(map[int]bool),
+	}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Pages returns the pages that have been added to the document.
+func (d *Document) Pages() []*Page {
+	return d.pages
+}
+
+func (d *Document) addImage(img image.Image) int {
+	d.images = append(d.images, img)
+	return len(d.images) - 1
+}
+
+// Write the document in PDF format.
+func (d *Document) Write(w io.Writer) error {
+	if len(d.pages) == 0 {
+		return errs.New("document has no pages")
+	}
+	const (
+		catalogObj = 1 + iota
+		pagesObj
+		infoObj
+		firstFontObj
+	)
+	firstImageObj := firstFontObj + len(allFonts)
+	firstPageObj := firstImageObj + len(d.images)
+	var buffer bytes.Buffer
+	offsets := make([]int, firstPageObj+2*len(d.pages))
+	startObj := func(num int) {
+		offsets[num] = buffer.Len()
+		fmt.Fprintf(&buffer, "%d 0 obj\n", num)
+	}
+	endObj := func() {
+		buffer.WriteString("endobj\n")
+	}
+	writeStream := func(dict string, data []byte) error {
+		compressed, err := compress(data)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buffer, "<<%s/Filter/FlateDecode/Length %d>>\nstream\n", dict, len(compressed))
+		buffer.Write(compressed)
+		buffer.WriteString("\nendstream\n")
+		return nil
+	}
+
+	buffer.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	startObj(catalogObj)
+	fmt.Fprintf(&buffer, "<</Type/Catalog/Pages %d 0 R>>\n", pagesObj)
+	endObj()
+
+	startObj(pagesObj)
+	buffer.WriteString("<</Type/Pages/Kids[")
+	for i := range d.pages {
+		if i != 0 {
+			buffer.WriteByte(' ')
+		}
+		fmt.Fprintf(&buffer, "%d 0 R", firstPageObj+i*2)
+	}
+	fmt.Fprintf(&buffer, "]/Count %d>>\n", len(d.pages))
+	endObj()
+
+	startObj(infoObj)
+	buffer.WriteString("<<")
+	writeInfoString(&buffer, "Title", d.Title)
+	writeInfoString(&buffer, "Author", d.Author)
+	writeInfoString(&buffer, "Subject", d.Subject)
+	writeInfoString(&buffer, "Creator", d.Creator)
+	writeInfoString(&buffer, "Producer", d.Creator)
+	if !d.Modified.IsZero() {
+		date := d.Modified.Format("D:20060102150405")
+		fmt.Fprintf(&buffer, "/CreationDate(%s)/ModDate(%s)", date, date)
+	}
+	buffer.WriteString(">>\n")
+	endObj()
+
+	for i, f := range allFonts {
+		startObj(firstFontObj + i)
+		fmt.Fprintf(&buffer, "<</Type/Font/Subtype/Type1/BaseFont/%s/Encoding/WinAnsiEncoding>>\n", f)
+		endObj()
+	}
+
+	for i, img := range d.images {
+		startObj(firstImageObj + i)
+		bounds := img.Bounds()
+		if err := writeStream(fmt.Sprintf("/Type/XObject/Subtype/Image/Width %d/Height %d/ColorSpace/DeviceRGB/BitsPerComponent 8",
+			bounds.Dx(), bounds.Dy()), imageRGB(img)); err != nil {
+			return err
+		}
+		endObj()
+	}
+
+	for i, p := range d.pages {
+		pageObj := firstPageObj + i*2
+		startObj(pageObj)
+		fmt.Fprintf(&buffer, "<</Type/Page/Parent %d 0 R/MediaBox[0 0 %s %s]/Contents %d 0 R/Resources<</Font<<",
+			pagesObj, num(p.width), num(p.height), pageObj+1)
+		for j, f := range allFonts {
+			fmt.Fprintf(&buffer, "/F%d %d 0 R", int(f), firstFontObj+j)
+		}
+		buffer.WriteString(">>")
+		if len(p.images) != 0 {
+			buffer.WriteString("/XObject<<")
+			for j := range d.images {
+				if p.images[j] {
+					fmt.Fprintf(&buffer, "/Im%d %d 0 R", j, firstImageObj+j)
+				}
+			}
+			buffer.WriteString(">>")
+		}
+		buffer.WriteString(">>>>\n")
+		endObj()
+		startObj(pageObj + 1)
+		if err := writeStream("", p.content.Bytes()); err != nil {
+			return err
+		}
+		endObj()
+	}
+
+	xref := buffer.Len()
+	fmt.Fprintf(&buffer, "xref\n0 %d\n0000000000 65535 f \n", len(offsets))
+	for _, offset := range offsets[1:] {
+		fmt.Fprintf(&buffer, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buffer, "trailer\n<</Size %d/Root %d 0 R/Info %d 0 R>>\nstartxref\n%d\n%%%%EOF\n", len(offsets),
+		catalogObj, infoObj, xref)
+	if _, err := w.Write(buffer.Bytes()); err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+func writeInfoString(buffer *bytes.Buffer, key, value string) {
+	if value != "" {
+		fmt.Fprintf(buffer, "/%s(", key)
+		writeEscaped(buffer, Encode(value))
+		buffer.WriteByte(')')
+	}
+}
+
+func writeEscaped(buffer *bytes.Buffer, data []byte) {
+	for _, b := range data {
+		switch b {
+		case '(', ')', '\\':
+			buffer.WriteByte('\\')
+			buffer.WriteByte(b)
+		case '\r':
+			buffer.WriteString(`\r`)
+		case '\n':
+			buffer.WriteString(`\n`)
+		default:
+			buffer.WriteByte(b)
+		}
+	}
+}
+
+func compress(data []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	w := zlib.NewWriter(&buffer)
+	if _, err := w.Write(data); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// imageRGB returns the pixels of the image as 8-bit RGB triplets, with any transparency composited onto white.
+func imageRGB(img image.Image) []byte {
+	bounds := img.Bounds()
+	data := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			white := 0xFFFF - a
+			data = append(data, byte((r+white)>>8), byte((g+white)>>8), byte((b+white)>>8))
+		}
+	}
+	return data
+}
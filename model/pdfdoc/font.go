@@ -0,0 +1,151 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package pdfdoc
+
+import "strings"
+
+// Possible fonts. These map to the standard PDF base fonts, which every PDF reader is required to provide, so no font
+// data needs to be embedded.
+const (
+	Helvetica Font = iota
+	HelveticaBold
+	HelveticaOblique
+)
+
+const (
+	fontAscent     = 0.718
+	fontDescent    = 0.207
+	fontLineFactor = 1.15
+	unknownWidth   = 556
+)
+
+var (
+	allFonts  = []Font{Helvetica, HelveticaBold, HelveticaOblique}
+	fontNames = []string{"Helvetica", "Helvetica-Bold", "Helvetica-Oblique"}
+	// Glyph widths for the characters 32 through 126, in 1/1000ths of the font size.
+	helveticaWidths = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBoldWidths = [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+	// Characters in the WinAnsi encoding that differ from their Unicode code point.
+	winAnsiSpecials = map[rune]byte{
+		'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88, '‰': 0x89,
+		'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95,
+		'–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+	}
+	// Reasonable stand-ins for characters commonly found in GCS data that the WinAnsi encoding cannot represent.
+	winAnsiSubstitutions = map[rune]string{
+		'√': "v", '∑': "Sum ", '≤': "<=", '≥': ">=", '≠': "!=", '−': "-", '′': "'", '″': "\"", '\t': " ",
+	}
+)
+
+// Font holds one of the standard PDF fonts.
+type Font byte
+
+// String implements fmt.Stringer.
+func (f Font) String() string {
+	if int(f) < len(fontNames) {
+		return fontNames[f]
+	}
+	return fontNames[0]
+}
+
+// Width returns the width of the text when rendered in this font at the given size.
+func (f Font) Width(text string, size float64) float64 {
+	widths := &helveticaWidths
+	if f == HelveticaBold {
+		widths = &helveticaBoldWidths
+	}
+	total := 0
+	for _, ch := range Encode(text) {
+		if ch >= 32 && ch <= 126 {
+			total += widths[ch-32]
+		} else {
+			total += unknownWidth
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// Ascent returns the distance from the baseline to the top of the tallest glyphs.
+func (f Font) Ascent(size float64) float64 {
+	return size * fontAscent
+}
+
+// Descent returns the distance from the baseline to the bottom of the lowest glyphs.
+func (f Font) Descent(size float64) float64 {
+	return size * fontDescent
+}
+
+// LineHeight returns the recommended distance between baselines of consecutive lines of text.
+func (f Font) LineHeight(size float64) float64 {
+	return size * fontLineFactor
+}
+
+// Encode converts the text into the WinAnsi encoding used by the standard fonts. Characters that cannot be represented
+// are replaced with a question mark.
+func Encode(text string) []byte {
+	buffer := make([]byte, 0, len(text))
+	for _, ch := range text {
+		switch {
+		case ch < 0x80:
+			buffer = append(buffer, byte(ch))
+		case ch >= 0xA0 && ch <= 0xFF:
+			buffer = append(buffer, byte(ch))
+		default:
+			if b, ok := winAnsiSpecials[ch]; ok {
+				buffer = append(buffer, b)
+			} else if s, exists := winAnsiSubstitutions[ch]; exists {
+				buffer = append(buffer, s...)
+			} else {
+				buffer = append(buffer, '?')
+			}
+		}
+	}
+	return buffer
+}
+
+// Wrap the text so that each line fits within the specified width. Existing line breaks are preserved. Words that are
+// too long to fit on a line by themselves are left intact.
+func (f Font) Wrap(text string, size, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		line := words[0]
+		for _, word := range words[1:] {
+			if candidate := line + " " + word; f.Width(candidate, size) <= width {
+				line = candidate
+			} else {
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
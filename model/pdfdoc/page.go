@@ -0,0 +1,117 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package pdfdoc
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+
+	"github.com/richardwilkes/unison"
+)
+
+// Page holds a single page of a Document. All coordinates are in points, with the origin at the top-left corner of the
+// page and y increasing downward.
+type Page struct {
+	doc     *Document
+	content bytes.Buffer
+	images  map[int]bool
+	width   float64
+	height  float64
+}
+
+// Width returns the width of the page.
+func (p *Page) Width() float64 {
+	return p.width
+}
+
+// Height returns the height of the page.
+func (p *Page) Height() float64 {
+	return p.height
+}
+
+// FillRect fills a rectangle with the color.
+func (p *Page) FillRect(x, y, width, height float64, color unison.Color) {
+	fmt.Fprintf(&p.content, "%s rg %s %s %s %s re f\n", rgb(color), num(x), num(p.height-(y+height)), num(width),
+		num(height))
+}
+
+// StrokeRect outlines a rectangle with the color.
+func (p *Page) StrokeRect(x, y, width, height, lineWidth float64, color unison.Color) {
+	fmt.Fprintf(&p.content, "%s RG %s w %s %s %s %s re S\n", rgb(color), num(lineWidth), num(x),
+		num(p.height-(y+height)), num(width), num(height))
+}
+
+// Line draws a line between two points.
+func (p *Page) Line(x1, y1, x2, y2, lineWidth float64, color unison.Color) {
+	fmt.Fprintf(&p.content, "%s RG %s w %s %s m %s %s l S\n", rgb(color), num(lineWidth), num(x1), num(p.height-y1),
+		num(x2), num(p.height-y2))
+}
+
+// Polyline draws connected line segments through the points, which are provided as x, y pairs.
+func (p *Page) Polyline(lineWidth float64, color unison.Color, points ...float64) {
+	if len(points) < 4 {
+		return
+	}
+	fmt.Fprintf(&p.content, "%s RG %s w 1 J 1 j %s %s m", rgb(color), num(lineWidth), num(points[0]),
+		num(p.height-points[1]))
+	for i := 2; i+1 < len(points); i += 2 {
+		fmt.Fprintf(&p.content, " %s %s l", num(points[i]), num(p.height-points[i+1]))
+	}
+	p.content.WriteString(" S 0 J 0 j\n")
+}
+
+// PushClip saves the current graphics state and restricts subsequent drawing to the rectangle.
+func (p *Page) PushClip(x, y, width, height float64) {
+	fmt.Fprintf(&p.content, "q %s %s %s %s re W n\n", num(x), num(p.height-(y+height)), num(width), num(height))
+}
+
+// PopClip restores the graphics state saved by the matching call to PushClip.
+func (p *Page) PopClip() {
+	p.content.WriteString("Q\n")
+}
+
+// Text draws the text with its baseline starting at the given point.
+func (p *Page) Text(x, baseline float64, font Font, size float64, color unison.Color, text string) {
+	if text == "" {
+		return
+	}
+	fmt.Fprintf(&p.content, "BT %s rg /F%d %s Tf %s %s Td (", rgb(color), int(font), num(size), num(x),
+		num(p.height-baseline))
+	writeEscaped(&p.content, Encode(text))
+	p.content.WriteString(") Tj ET\n")
+}
+
+// Image draws the image scaled into the rectangle.
+func (p *Page) Image(x, y, width, height float64, img image.Image) {
+	index := p.doc.addImage(img)
+	p.images[index] = true
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /Im%d Do Q\n", num(width), num(height), num(x),
+		num(p.height-(y+height)), index)
+}
+
+func rgb(color unison.Color) string {
+	return fmt.Sprintf("%s %s %s", num(float64(color.RedIntensity())), num(float64(color.GreenIntensity())),
+		num(float64(color.BlueIntensity())))
+}
+
+func num(value float64) string {
+	s := strconv.FormatFloat(value, 'f', 3, 64)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
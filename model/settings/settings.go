@@ -13,6 +13,7 @@ package settings
 
 import (
 	"context"
+	"os"
 	"path"
 	"path/filepath"
 	"runtime"
@@ -27,6 +28,7 @@ import (
 	"github.com/richardwilkes/rpgtools/dice"
 	"github.com/richardwilkes/toolbox"
 	"github.com/richardwilkes/toolbox/cmdline"
+	"github.com/richardwilkes/toolbox/errs"
 	"github.com/richardwilkes/toolbox/xio/fs"
 	"github.com/richardwilkes/toolbox/xio/fs/paths"
 	"github.com/richardwilkes/unison"
@@ -80,6 +82,7 @@ func Global() *Settings {
 			global = Default()
 		}
 		global.EnsureValidity()
+		global.loadAPIServerToken()
 		gurps.SettingsProvider = global
 		gurps.InstallEvaluatorFunctions(fxp.EvalFuncs)
 		global.Colors.MakeCurrent()
@@ -90,7 +93,37 @@ func Global() *Settings {
 
 // Save to the standard path.
 func (s *Settings) Save() error {
-	return jio.SaveToFile(context.Background(), Path(), s)
+	if err := jio.SaveToFile(context.Background(), Path(), s); err != nil {
+		return err
+	}
+	return s.saveAPIServerToken()
+}
+
+// APIServerTokenPath returns the path the token for the local API server is stored at.
+func APIServerTokenPath() string {
+	return filepath.Join(paths.AppDataDir(), cmdline.AppCmdName+"_api_token")
+}
+
+func (s *Settings) loadAPIServerToken() {
+	data, err := os.ReadFile(APIServerTokenPath())
+	if err != nil {
+		return
+	}
+	if token := strings.TrimSpace(string(data)); token != "" {
+		s.General.APIServerToken = token
+	}
+}
+
+func (s *Settings) saveAPIServerToken() error {
+	p := APIServerTokenPath()
+	if err := os.WriteFile(p, []byte(s.General.APIServerToken+"\n"), 0o600); err != nil {
+		return errs.NewWithCause("unable to save "+p, err)
+	}
+	// WriteFile only applies the permissions when creating the file.
+	if err := os.Chmod(p, 0o600); err != nil {
+		return errs.NewWithCause("unable to restrict access to "+p, err)
+	}
+	return nil
 }
 
 // EnsureValidity checks the current settings for validity and if they aren't valid, makes them so.
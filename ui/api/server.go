@@ -0,0 +1,455 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+// Package api provides an optional HTTP server, bound to the local machine, that gives other programs access to the
+// sheets open in the workspace.
+//
+// All requests must present the token from the general settings, either as a bearer token in the Authorization header
+// or as the "token" query parameter. The token is not saved with the rest of the settings; it lives in a file of its
+// own, readable only by the user (see settings.APIServerTokenPath), so that local programs can pick it up without it
+// being shared along with exported settings. A request that the client abandons before it is carried out makes no
+// changes. The endpoints are:
+//
+//	GET  /api/sheets                            lists the open sheets
+//	GET  /api/sheets/{id}                       returns the computed data for a sheet
+//	POST /api/sheets/{id}/pools/{attrID}        adjusts a pool, such as HP or FP, given {"delta":n} or {"current":n}
+//	POST /api/sheets/{id}/equipment/{eqpID}     sets {"equipped":bool} and/or {"uses":n} for a piece of equipment
+//	GET  /api/events                            a stream of server-sent events describing changes to the sheets
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps/export"
+	"github.com/richardwilkes/gcs/v5/model/settings"
+	"github.com/richardwilkes/gcs/v5/ui/workspace/sheet"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+	"github.com/richardwilkes/unison"
+)
+
+const (
+	pathPrefix   = "/api/"
+	eventBacklog = 32
+)
+
+type server struct {
+	lock    sync.RWMutex
+	http    *http.Server
+	port    int
+	token   string
+	clients map[chan []byte]struct{}
+}
+
+var current = &server{clients: make(map[chan []byte]struct{})}
+
+// SheetInfo holds the summary of an open sheet returned by the list endpoint and sent with events.
+type SheetInfo struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Name     string `json:"name,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Modified bool   `json:"modified"`
+}
+
+type event struct {
+	Kind  string     `json:"kind"`
+	Sheet *SheetInfo `json:"sheet"`
+}
+
+type poolRequest struct {
+	Delta   *fxp.Int `json:"delta,omitempty"`
+	Current *fxp.Int `json:"current,omitempty"`
+}
+
+type equipmentRequest struct {
+	Equipped *bool `json:"equipped,omitempty"`
+	Uses     *int  `json:"uses,omitempty"`
+}
+
+// Sync starts, stops or reconfigures the server to match the current general settings. Must be called on the UI
+// thread.
+func Sync() {
+	general := settings.Global().General
+	current.lock.Lock()
+	current.token = general.APIServerToken
+	running := current.http != nil
+	samePort := current.port == general.APIServerPort
+	current.lock.Unlock()
+	switch {
+	case !general.APIServerEnabled:
+		if running {
+			current.stop()
+		}
+	case !running:
+		current.start(general.APIServerPort)
+	case !samePort:
+		current.stop()
+		current.start(general.APIServerPort)
+	}
+}
+
+func (s *server) start(port int) {
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		jot.Error(errs.NewWithCause(fmt.Sprintf("unable to start the API server on port %d", port), err))
+		return
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(pathPrefix, s.serve)
+	svr := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	s.lock.Lock()
+	s.http = svr
+	s.port = port
+	s.lock.Unlock()
+	sheet.SetEventListener(s.broadcast)
+	go func() {
+		if serveErr := svr.Serve(listener); serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+			jot.Error(errs.Wrap(serveErr))
+		}
+	}()
+}
+
+func (s *server) stop() {
+	sheet.SetEventListener(nil)
+	s.lock.Lock()
+	svr := s.http
+	s.http = nil
+	s.port = 0
+	for ch := range s.clients {
+		close(ch)
+		delete(s.clients, ch)
+	}
+	s.lock.Unlock()
+	if svr != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := svr.Shutdown(ctx); err != nil {
+			jot.Error(errs.Wrap(err))
+		}
+	}
+}
+
+func (s *server) authorized(r *http.Request) bool {
+	s.lock.RLock()
+	expected := s.token
+	s.lock.RUnlock()
+	token := r.URL.Query().Get("token")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	return expected != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+func (s *server) serve(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, i18n.Text("missing or invalid token"))
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, pathPrefix), "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "events":
+		if requireMethod(w, r, http.MethodGet) {
+			s.streamEvents(w, r)
+		}
+	case len(parts) == 1 && parts[0] == "sheets":
+		if requireMethod(w, r, http.MethodGet) {
+			listSheets(w, r)
+		}
+	case len(parts) == 2 && parts[0] == "sheets":
+		if requireMethod(w, r, http.MethodGet) {
+			getSheet(w, r, parts[1])
+		}
+	case len(parts) == 4 && parts[0] == "sheets" && parts[2] == "pools":
+		if requireMethod(w, r, http.MethodPost) {
+			adjustPool(w, r, parts[1], parts[3])
+		}
+	case len(parts) == 4 && parts[0] == "sheets" && parts[2] == "equipment":
+		if requireMethod(w, r, http.MethodPost) {
+			updateEquipment(w, r, parts[1], parts[3])
+		}
+	default:
+		writeError(w, http.StatusNotFound, i18n.Text("not found"))
+	}
+}
+
+func listSheets(w http.ResponseWriter, r *http.Request) {
+	var list []*SheetInfo
+	if !onUIThread(r, func() {
+		for _, one := range sheet.OpenSheets() {
+			list = append(list, newSheetInfo(one))
+		}
+	}) {
+		return
+	}
+	if list == nil {
+		list = []*SheetInfo{}
+	}
+	writeJSON(w, list)
+}
+
+func getSheet(w http.ResponseWriter, r *http.Request, id string) {
+	var buffer strings.Builder
+	var err error
+	var found bool
+	if !onUIThread(r, func() {
+		if s := lookupSheet(id); s != nil {
+			found = true
+			err = export.WriteEntityJSON(s.Entity(), &buffer)
+		}
+	}) {
+		return
+	}
+	switch {
+	case !found:
+		writeError(w, http.StatusNotFound, i18n.Text("no such sheet"))
+	case err != nil:
+		jot.Error(err)
+		writeError(w, http.StatusInternalServerError, err.Error())
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(buffer.String())) //nolint:errcheck // Nothing useful can be done with a failure here
+	}
+}
+
+func adjustPool(w http.ResponseWriter, r *http.Request, id, attrID string) {
+	var req poolRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if (req.Delta == nil) == (req.Current == nil) {
+		writeError(w, http.StatusBadRequest, i18n.Text("exactly one of 'delta' or 'current' must be provided"))
+		return
+	}
+	mutate(w, r, id, func(s *sheet.Sheet) error {
+		var delta fxp.Int
+		if req.Delta != nil {
+			delta = *req.Delta
+		} else if attr, ok := s.Entity().Attributes.Set[attrID]; ok {
+			delta = *req.Current - attr.Current()
+		}
+		return s.AdjustPool(attrID, delta)
+	})
+}
+
+func updateEquipment(w http.ResponseWriter, r *http.Request, id, eqpIDStr string) {
+	eqpID, err := uuid.Parse(eqpIDStr)
+	if err != nil {
+		writeError(w, http.StatusNotFound, i18n.Text("no such equipment"))
+		return
+	}
+	var req equipmentRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.Equipped == nil && req.Uses == nil {
+		writeError(w, http.StatusBadRequest, i18n.Text("at least one of 'equipped' or 'uses' must be provided"))
+		return
+	}
+	mutate(w, r, id, func(s *sheet.Sheet) error {
+		if req.Equipped != nil {
+			if err2 := s.SetEquipped(eqpID, *req.Equipped); err2 != nil {
+				return err2
+			}
+		}
+		if req.Uses != nil {
+			return s.SetUses(eqpID, *req.Uses)
+		}
+		return nil
+	})
+}
+
+// mutate calls f on the UI thread with the sheet, then responds with the sheet's summary.
+func mutate(w http.ResponseWriter, r *http.Request, id string, f func(s *sheet.Sheet) error) {
+	var info *SheetInfo
+	var err error
+	if !onUIThread(r, func() {
+		if s := lookupSheet(id); s != nil {
+			if err = f(s); err == nil {
+				info = newSheetInfo(s)
+			}
+		}
+	}) {
+		return
+	}
+	switch {
+	case err != nil:
+		writeError(w, http.StatusBadRequest, err.Error())
+	case info == nil:
+		writeError(w, http.StatusNotFound, i18n.Text("no such sheet"))
+	default:
+		writeJSON(w, info)
+	}
+}
+
+func (s *server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, i18n.Text("streaming is not supported"))
+		return
+	}
+	ch := make(chan []byte, eventBacklog)
+	s.lock.Lock()
+	s.clients[ch] = struct{}{}
+	s.lock.Unlock()
+	defer func() {
+		s.lock.Lock()
+		if _, exists := s.clients[ch]; exists {
+			delete(s.clients, ch)
+			close(ch)
+		}
+		s.lock.Unlock()
+	}()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case data, open := <-ch:
+			if !open {
+				return
+			}
+			if _, err := w.Write(data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// broadcast is installed as the sheet event listener and so is called on the UI thread.
+func (s *server) broadcast(target *sheet.Sheet, kind sheet.EventKind) {
+	data, err := json.Marshal(&event{
+		Kind:  kind.String(),
+		Sheet: newSheetInfo(target),
+	})
+	if err != nil {
+		jot.Error(errs.Wrap(err))
+		return
+	}
+	msg := []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", kind, data))
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for ch := range s.clients {
+		select {
+		case ch <- msg:
+		default: // The client isn't keeping up, so drop the event rather than stall the UI
+		}
+	}
+}
+
+func newSheetInfo(s *sheet.Sheet) *SheetInfo {
+	entity := s.Entity()
+	return &SheetInfo{
+		ID:       entity.ID.String(),
+		Title:    s.Title(),
+		Name:     entity.Profile.Name,
+		Path:     s.BackingFilePath(),
+		Modified: s.Modified(),
+	}
+}
+
+// lookupSheet must be called on the UI thread.
+func lookupSheet(id string) *sheet.Sheet {
+	for _, one := range sheet.OpenSheets() {
+		if one.Entity().ID.String() == id {
+			return one
+		}
+	}
+	return nil
+}
+
+// onUIThread runs f on the UI thread and waits for it to finish. Returns false if the request was abandoned before f
+// started, in which case f is never called, so that a request the client has given up on doesn't change anything. Once
+// f has started, it is always waited on.
+func onUIThread(r *http.Request, f func()) bool {
+	var lock sync.Mutex
+	var started, abandoned bool
+	done := make(chan struct{})
+	unison.InvokeTask(func() {
+		defer close(done)
+		lock.Lock()
+		if abandoned || r.Context().Err() != nil {
+			abandoned = true
+			lock.Unlock()
+			return
+		}
+		started = true
+		lock.Unlock()
+		f()
+	})
+	select {
+	case <-done:
+	case <-r.Context().Done():
+		lock.Lock()
+		if !started {
+			abandoned = true
+			lock.Unlock()
+			return false
+		}
+		lock.Unlock()
+		<-done
+	}
+	return !abandoned
+}
+
+func requireMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, i18n.Text("method not allowed"))
+		return false
+	}
+	return true
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, data any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(data); err != nil {
+		writeError(w, http.StatusBadRequest, i18n.Text("invalid request body: ")+err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		jot.Error(errs.Wrap(err))
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]string{"error": msg}); err != nil {
+		jot.Error(errs.Wrap(err))
+	}
+}
@@ -33,6 +33,8 @@ var (
 	CopyToTemplate *unison.Action
 	// ApplyTemplate applies the foremost template to the foremost character sheet.
 	ApplyTemplate *unison.Action
+	// CreateFromTemplate walks through the choices within the foremost template to create a new character sheet.
+	CreateFromTemplate *unison.Action
 	// Increment the points of the selection.
 	Increment *unison.Action
 	// Decrement the points of the selection.
@@ -41,6 +43,10 @@ var (
 	IncreaseUses *unison.Action
 	// DecreaseUses decrements the uses of the selection.
 	DecreaseUses *unison.Action
+	// FireWeapon uses the ammunition for one attack with the selected ranged weapon(s).
+	FireWeapon *unison.Action
+	// ReloadWeapon reloads the selected ranged weapon(s) from their linked ammunition.
+	ReloadWeapon *unison.Action
 	// IncreaseSkillLevel increments the uses of the skill level.
 	IncreaseSkillLevel *unison.Action
 	// DecreaseSkillLevel decrements the uses of the skill level.
@@ -55,6 +61,10 @@ var (
 	SwapDefaults *unison.Action
 	// ConvertToContainer converts the currently selected item into a container.
 	ConvertToContainer *unison.Action
+	// ConvertToMetric converts the lengths and weights of the current document to metric units.
+	ConvertToMetric *unison.Action
+	// ConvertToImperial converts the lengths and weights of the current document to imperial units.
+	ConvertToImperial *unison.Action
 )
 
 func registerEditMenuActions() {
@@ -137,6 +147,12 @@ func registerEditMenuActions() {
 		EnabledCallback: unison.RouteActionToFocusEnabledFunc,
 		ExecuteCallback: unison.RouteActionToFocusExecuteFunc,
 	}
+	CreateFromTemplate = &unison.Action{
+		ID:              constants.CreateFromTemplateItemID,
+		Title:           i18n.Text("Create Character Sheet from Template…"),
+		EnabledCallback: unison.RouteActionToFocusEnabledFunc,
+		ExecuteCallback: unison.RouteActionToFocusExecuteFunc,
+	}
 	Increment = &unison.Action{
 		ID:              constants.IncrementItemID,
 		Title:           i18n.Text("Increment"),
@@ -165,6 +181,18 @@ func registerEditMenuActions() {
 		EnabledCallback: unison.RouteActionToFocusEnabledFunc,
 		ExecuteCallback: unison.RouteActionToFocusExecuteFunc,
 	}
+	FireWeapon = &unison.Action{
+		ID:              constants.FireWeaponItemID,
+		Title:           i18n.Text("Fire Weapon"),
+		EnabledCallback: unison.RouteActionToFocusEnabledFunc,
+		ExecuteCallback: unison.RouteActionToFocusExecuteFunc,
+	}
+	ReloadWeapon = &unison.Action{
+		ID:              constants.ReloadWeaponItemID,
+		Title:           i18n.Text("Reload Weapon"),
+		EnabledCallback: unison.RouteActionToFocusEnabledFunc,
+		ExecuteCallback: unison.RouteActionToFocusExecuteFunc,
+	}
 	IncreaseSkillLevel = &unison.Action{
 		ID:              constants.IncrementSkillLevelItemID,
 		Title:           i18n.Text("Increase Skill Level"),
@@ -213,6 +241,18 @@ func registerEditMenuActions() {
 		EnabledCallback: unison.RouteActionToFocusEnabledFunc,
 		ExecuteCallback: unison.RouteActionToFocusExecuteFunc,
 	}
+	ConvertToMetric = &unison.Action{
+		ID:              constants.ConvertToMetricItemID,
+		Title:           i18n.Text("Convert to Metric Units"),
+		EnabledCallback: unison.RouteActionToFocusEnabledFunc,
+		ExecuteCallback: unison.RouteActionToFocusExecuteFunc,
+	}
+	ConvertToImperial = &unison.Action{
+		ID:              constants.ConvertToImperialItemID,
+		Title:           i18n.Text("Convert to Imperial Units"),
+		EnabledCallback: unison.RouteActionToFocusEnabledFunc,
+		ExecuteCallback: unison.RouteActionToFocusExecuteFunc,
+	}
 
 	settings.RegisterKeyBinding("undo", Undo)
 	settings.RegisterKeyBinding("redo", Redo)
@@ -226,10 +266,13 @@ func registerEditMenuActions() {
 	settings.RegisterKeyBinding("copy.to_sheet", CopyToSheet)
 	settings.RegisterKeyBinding("copy.to_template", CopyToTemplate)
 	settings.RegisterKeyBinding("apply.template", ApplyTemplate)
+	settings.RegisterKeyBinding("create.from_template", CreateFromTemplate)
 	settings.RegisterKeyBinding("inc", Increment)
 	settings.RegisterKeyBinding("dec", Decrement)
 	settings.RegisterKeyBinding("inc.uses", IncreaseUses)
 	settings.RegisterKeyBinding("dec.uses", DecreaseUses)
+	settings.RegisterKeyBinding("fire.weapon", FireWeapon)
+	settings.RegisterKeyBinding("reload.weapon", ReloadWeapon)
 	settings.RegisterKeyBinding("inc.sl", IncreaseSkillLevel)
 	settings.RegisterKeyBinding("dec.sl", DecreaseSkillLevel)
 	settings.RegisterKeyBinding("inc.tl", IncreaseTechLevel)
@@ -237,6 +280,8 @@ func registerEditMenuActions() {
 	settings.RegisterKeyBinding("toggle", ToggleState)
 	settings.RegisterKeyBinding("swap.defaults", SwapDefaults)
 	settings.RegisterKeyBinding("convert.to_container", ConvertToContainer)
+	settings.RegisterKeyBinding("convert.to_metric", ConvertToMetric)
+	settings.RegisterKeyBinding("convert.to_imperial", ConvertToImperial)
 }
 
 func setupEditMenu(bar unison.Menu) {
@@ -256,12 +301,15 @@ func setupEditMenu(bar unison.Menu) {
 	i = insertItem(m, i, CopyToSheet.NewMenuItem(f))
 	i = insertItem(m, i, CopyToTemplate.NewMenuItem(f))
 	i = insertItem(m, i, ApplyTemplate.NewMenuItem(f))
+	i = insertItem(m, i, CreateFromTemplate.NewMenuItem(f))
 
 	i = insertSeparator(m, i)
 	i = insertItem(m, i, Increment.NewMenuItem(f))
 	i = insertItem(m, i, Decrement.NewMenuItem(f))
 	i = insertItem(m, i, IncreaseUses.NewMenuItem(f))
 	i = insertItem(m, i, DecreaseUses.NewMenuItem(f))
+	i = insertItem(m, i, FireWeapon.NewMenuItem(f))
+	i = insertItem(m, i, ReloadWeapon.NewMenuItem(f))
 	i = insertItem(m, i, IncreaseSkillLevel.NewMenuItem(f))
 	i = insertItem(m, i, DecreaseSkillLevel.NewMenuItem(f))
 	i = insertItem(m, i, IncreaseTechLevel.NewMenuItem(f))
@@ -270,5 +318,7 @@ func setupEditMenu(bar unison.Menu) {
 	i = insertSeparator(m, i)
 	i = insertItem(m, i, ToggleState.NewMenuItem(f))
 	i = insertItem(m, i, SwapDefaults.NewMenuItem(f))
-	insertItem(m, i, ConvertToContainer.NewMenuItem(f))
+	i = insertItem(m, i, ConvertToContainer.NewMenuItem(f))
+	i = insertItem(m, i, ConvertToMetric.NewMenuItem(f))
+	insertItem(m, i, ConvertToImperial.NewMenuItem(f))
 }
@@ -20,6 +20,7 @@ import (
 
 	"github.com/richardwilkes/gcs/v5/constants"
 	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/ancestry"
 	"github.com/richardwilkes/gcs/v5/model/gurps/datafile"
 	"github.com/richardwilkes/gcs/v5/model/gurps/export"
 	"github.com/richardwilkes/gcs/v5/model/library"
@@ -42,6 +43,18 @@ var (
 	NewCharacterSheet *unison.Action
 	// NewCharacterTemplate creates a new character template.
 	NewCharacterTemplate *unison.Action
+	// NewCampaign creates a new campaign.
+	NewCampaign *unison.Action
+	// NewAncestry creates a new ancestry.
+	NewAncestry *unison.Action
+	// NewNameGenerator creates a new name generator.
+	NewNameGenerator *unison.Action
+	// CombatTracker shows the combat tracker.
+	CombatTracker *unison.Action
+	// GenerateNPCs generates randomized characters from an ancestry, a template and a point total.
+	GenerateNPCs *unison.Action
+	// OpenShop opens an equipment library as a shop.
+	OpenShop *unison.Action
 	// NewTraitsLibrary creates a new traits library.
 	NewTraitsLibrary *unison.Action
 	// NewTraitModifiersLibrary creates a new trait modifiers library.
@@ -64,6 +77,10 @@ var (
 	Save *unison.Action
 	// SaveAs saves to a new file.
 	SaveAs *unison.Action
+	// ExportToJSON exports the computed values of the current sheet to a JSON file.
+	ExportToJSON *unison.Action
+	// CompareWith compares the current sheet with another one.
+	CompareWith *unison.Action
 	// Print the content.
 	Print *unison.Action
 )
@@ -85,6 +102,44 @@ func registerFileMenuActions() {
 			workspace.DisplayNewDockable(nil, sheet.NewTemplate("untitled"+library.TemplatesExt, gurps.NewTemplate()))
 		},
 	}
+	NewCampaign = &unison.Action{
+		ID:    constants.NewCampaignItemID,
+		Title: i18n.Text("New Campaign"),
+		ExecuteCallback: func(_ *unison.Action, _ any) {
+			workspace.DisplayNewDockable(nil, sheet.NewCampaignEditor("untitled"+library.CampaignExt, gurps.NewCampaign()))
+		},
+	}
+	NewAncestry = &unison.Action{
+		ID:    constants.NewAncestryItemID,
+		Title: i18n.Text("New Ancestry"),
+		ExecuteCallback: func(_ *unison.Action, _ any) {
+			workspace.DisplayNewDockable(nil, sheet.NewAncestryEditor("untitled"+library.AncestryExt,
+				ancestry.NewAncestry()))
+		},
+	}
+	NewNameGenerator = &unison.Action{
+		ID:    constants.NewNameGeneratorItemID,
+		Title: i18n.Text("New Name Generator"),
+		ExecuteCallback: func(_ *unison.Action, _ any) {
+			workspace.DisplayNewDockable(nil, sheet.NewNameGeneratorEditor("untitled"+library.NameGeneratorExt,
+				&ancestry.NameGenerator{Type: ancestry.MarkovChain}))
+		},
+	}
+	CombatTracker = &unison.Action{
+		ID:              constants.CombatTrackerItemID,
+		Title:           i18n.Text("Combat Tracker"),
+		ExecuteCallback: func(_ *unison.Action, _ any) { sheet.ShowCombatTracker() },
+	}
+	GenerateNPCs = &unison.Action{
+		ID:              constants.GenerateNPCsItemID,
+		Title:           i18n.Text("Generate NPCs…"),
+		ExecuteCallback: func(_ *unison.Action, _ any) { sheet.ShowNPCGenerator() },
+	}
+	OpenShop = &unison.Action{
+		ID:              constants.OpenShopItemID,
+		Title:           i18n.Text("Open Shop…"),
+		ExecuteCallback: func(_ *unison.Action, _ any) { sheet.OpenShop() },
+	}
 	NewTraitsLibrary = &unison.Action{
 		ID:    constants.NewTraitsLibraryItemID,
 		Title: i18n.Text("New Traits Library"),
@@ -198,6 +253,32 @@ func registerFileMenuActions() {
 		EnabledCallback: unison.RouteActionToFocusEnabledFunc,
 		ExecuteCallback: unison.RouteActionToFocusExecuteFunc,
 	}
+	ExportToJSON = &unison.Action{
+		ID:              constants.ExportToJSONItemID,
+		Title:           i18n.Text("Computed Data (JSON)…"),
+		EnabledCallback: enabledForSheet,
+		ExecuteCallback: func(_ *unison.Action, _ any) {
+			if s := sheet.ActiveSheet(); s != nil {
+				dialog := unison.NewSaveDialog()
+				dialog.SetAllowedExtensions(export.JSONExt)
+				if dialog.RunModal() {
+					if err := export.JSONExport(s.Entity(), dialog.Path()); err != nil {
+						unison.ErrorDialogWithError(i18n.Text("Export failed"), err)
+					}
+				}
+			}
+		},
+	}
+	CompareWith = &unison.Action{
+		ID:              constants.CompareWithItemID,
+		Title:           i18n.Text("Compare With…"),
+		EnabledCallback: enabledForSheet,
+		ExecuteCallback: func(_ *unison.Action, _ any) {
+			if s := sheet.ActiveSheet(); s != nil {
+				s.CompareWith()
+			}
+		},
+	}
 	Print = &unison.Action{
 		ID:              constants.PrintItemID,
 		Title:           i18n.Text("Print…"),
@@ -208,6 +289,12 @@ func registerFileMenuActions() {
 
 	settings.RegisterKeyBinding("new.char.sheet", NewCharacterSheet)
 	settings.RegisterKeyBinding("new.char.template", NewCharacterTemplate)
+	settings.RegisterKeyBinding("new.campaign", NewCampaign)
+	settings.RegisterKeyBinding("new.ancestry", NewAncestry)
+	settings.RegisterKeyBinding("new.names", NewNameGenerator)
+	settings.RegisterKeyBinding("combat.tracker", CombatTracker)
+	settings.RegisterKeyBinding("npc.generator", GenerateNPCs)
+	settings.RegisterKeyBinding("open.shop", OpenShop)
 	settings.RegisterKeyBinding("new.adq.lib", NewTraitsLibrary)
 	settings.RegisterKeyBinding("new.adm.lib", NewTraitModifiersLibrary)
 	settings.RegisterKeyBinding("new.eqp.lib", NewEquipmentLibrary)
@@ -219,6 +306,8 @@ func registerFileMenuActions() {
 	settings.RegisterKeyBinding("close", CloseTab)
 	settings.RegisterKeyBinding("save", Save)
 	settings.RegisterKeyBinding("save_as", SaveAs)
+	settings.RegisterKeyBinding("export.json", ExportToJSON)
+	settings.RegisterKeyBinding("compare", CompareWith)
 	settings.RegisterKeyBinding("print", Print)
 }
 
@@ -227,6 +316,12 @@ func setupFileMenu(bar unison.Menu) {
 	m := bar.Menu(unison.FileMenuID)
 	i := insertItem(m, 0, NewCharacterSheet.NewMenuItem(f))
 	i = insertItem(m, i, NewCharacterTemplate.NewMenuItem(f))
+	i = insertItem(m, i, NewCampaign.NewMenuItem(f))
+	i = insertItem(m, i, NewAncestry.NewMenuItem(f))
+	i = insertItem(m, i, NewNameGenerator.NewMenuItem(f))
+	i = insertItem(m, i, CombatTracker.NewMenuItem(f))
+	i = insertItem(m, i, GenerateNPCs.NewMenuItem(f))
+	i = insertItem(m, i, OpenShop.NewMenuItem(f))
 
 	i = insertSeparator(m, i)
 	i = insertItem(m, i, NewTraitsLibrary.NewMenuItem(f))
@@ -249,6 +344,7 @@ func setupFileMenu(bar unison.Menu) {
 	i = insertItem(m, i, Save.NewMenuItem(f))
 	i = insertItem(m, i, SaveAs.NewMenuItem(f))
 	i = insertMenu(m, i, f.NewMenu(constants.ExportToMenuID, i18n.Text("Export To…"), exportToUpdater))
+	i = insertItem(m, i, CompareWith.NewMenuItem(f))
 
 	i = insertSeparator(m, i)
 	insertItem(m, i, Print.NewMenuItem(f))
@@ -286,6 +382,7 @@ func createOpenRecentFileAction(index int, path, title string) *unison.Action {
 
 func exportToUpdater(menu unison.Menu) {
 	menu.RemoveAll()
+	menu.InsertItem(-1, ExportToJSON.NewMenuItem(menu.Factory()))
 	index := 0
 	for _, lib := range settings.Global().Libraries().List() {
 		dir := lib.Path()
@@ -306,6 +403,7 @@ func exportToUpdater(menu unison.Menu) {
 		}
 		if len(list) > 0 {
 			txt.SortStringsNaturalAscending(list)
+			menu.InsertSeparator(-1, false)
 			appendDisabledMenuItem(menu, lib.Title)
 			for _, one := range list {
 				menu.InsertItem(-1, createExportToTextAction(index, one).NewMenuItem(menu.Factory()))
@@ -313,7 +411,8 @@ func exportToUpdater(menu unison.Menu) {
 			}
 		}
 	}
-	if menu.Count() == 0 {
+	if index == 0 {
+		menu.InsertSeparator(-1, false)
 		appendDisabledMenuItem(menu, i18n.Text("No export templates available"))
 	}
 }
@@ -321,14 +420,14 @@ func exportToUpdater(menu unison.Menu) {
 func createExportToTextAction(index int, path string) *unison.Action {
 	return &unison.Action{
 		ID:              constants.ExportToTextBaseItemID + index,
-		Title:           xfs.TrimExtension(filepath.Base(path)),
+		Title:           export.TemplateTitle(path),
 		EnabledCallback: enabledForSheet,
 		ExecuteCallback: func(_ *unison.Action, _ any) {
 			if s := sheet.ActiveSheet(); s != nil {
 				dialog := unison.NewSaveDialog()
-				dialog.SetAllowedExtensions(filepath.Ext(path))
+				dialog.SetAllowedExtensions(export.TemplateOutputExtension(path))
 				if dialog.RunModal() {
-					if err := export.LegacyExport(s.Entity(), path, dialog.Path()); err != nil {
+					if err := export.Export(s.Entity(), path, dialog.Path()); err != nil {
 						unison.ErrorDialogWithError(i18n.Text("Export failed"), err)
 					}
 				}
@@ -65,6 +65,8 @@ var (
 	OpenOnePageReference *unison.Action
 	// OpenEachPageReference opens each page reference associated with the selected items.
 	OpenEachPageReference *unison.Action
+	// Roll makes a roll for the selected item.
+	Roll *unison.Action
 )
 
 func registerItemMenuActions() {
@@ -251,6 +253,15 @@ func registerItemMenuActions() {
 		ExecuteCallback: unison.RouteActionToFocusExecuteFunc,
 	}
 
+	// Roll makes a roll for the selected item.
+	Roll = &unison.Action{
+		ID:              constants.RollItemID,
+		Title:           i18n.Text("Roll"),
+		KeyBinding:      unison.KeyBinding{KeyCode: unison.KeyR, Modifiers: unison.OSMenuCmdModifier()},
+		EnabledCallback: unison.RouteActionToFocusEnabledFunc,
+		ExecuteCallback: unison.RouteActionToFocusExecuteFunc,
+	}
+
 	settings.RegisterKeyBinding("new.adq", NewTrait)
 	settings.RegisterKeyBinding("new.adq.container", NewTraitContainer)
 	settings.RegisterKeyBinding("new.adm", NewTraitModifier)
@@ -274,6 +285,7 @@ func registerItemMenuActions() {
 	settings.RegisterKeyBinding("new.ranged", NewRangedWeapon)
 	settings.RegisterKeyBinding("pageref.open.first", OpenOnePageReference)
 	settings.RegisterKeyBinding("pageref.open.all", OpenEachPageReference)
+	settings.RegisterKeyBinding("roll", Roll)
 }
 
 func createItemMenu(f unison.MenuFactory) unison.Menu {
@@ -314,5 +326,8 @@ func createItemMenu(f unison.MenuFactory) unison.Menu {
 	m.InsertSeparator(-1, false)
 	m.InsertItem(-1, OpenOnePageReference.NewMenuItem(f))
 	m.InsertItem(-1, OpenEachPageReference.NewMenuItem(f))
+
+	m.InsertSeparator(-1, false)
+	m.InsertItem(-1, Roll.NewMenuItem(f))
 	return m
 }
@@ -17,13 +17,19 @@ import (
 	"github.com/richardwilkes/gcs/v5/constants"
 	"github.com/richardwilkes/gcs/v5/model/library"
 	"github.com/richardwilkes/gcs/v5/model/settings"
+	"github.com/richardwilkes/gcs/v5/ui/workspace/lists"
+	uisettings "github.com/richardwilkes/gcs/v5/ui/workspace/settings"
 	"github.com/richardwilkes/toolbox/desktop"
 	"github.com/richardwilkes/toolbox/i18n"
 	"github.com/richardwilkes/unison"
 )
 
-// ChangeLibraryLocations brings up the dialog that allows the user to edit the library locations.
-var ChangeLibraryLocations *unison.Action
+var (
+	// ChangeLibraryLocations brings up the dialog that allows the user to edit the library locations.
+	ChangeLibraryLocations *unison.Action
+	// SearchLibraries shows the library search.
+	SearchLibraries *unison.Action
+)
 
 func registerLibraryMenuActions() {
 	ChangeLibraryLocations = &unison.Action{
@@ -32,7 +38,15 @@ func registerLibraryMenuActions() {
 		ExecuteCallback: unimplemented,
 	}
 
+	SearchLibraries = &unison.Action{
+		ID:              constants.SearchLibrariesItemID,
+		Title:           i18n.Text("Search Libraries…"),
+		KeyBinding:      unison.KeyBinding{KeyCode: unison.KeyF, Modifiers: unison.ShiftModifier | unison.OSMenuCmdModifier()},
+		ExecuteCallback: func(_ *unison.Action, _ any) { lists.ShowLibrarySearch() },
+	}
+
 	settings.RegisterKeyBinding("change_library_locations", ChangeLibraryLocations)
+	settings.RegisterKeyBinding("search_libraries", SearchLibraries)
 }
 
 func updateLibraryMenu(m unison.Menu) {
@@ -42,11 +56,13 @@ func updateLibraryMenu(m unison.Menu) {
 	f := m.Factory()
 	for i, lib := range settings.Global().LibrarySet.List() {
 		if !lib.IsUser() {
-			m.InsertItem(-1, newUpdateLibraryAction(constants.LibraryBaseItemID+i*2, lib).NewMenuItem(f))
+			m.InsertItem(-1, newUpdateLibraryAction(constants.LibraryBaseItemID+i*3, lib).NewMenuItem(f))
+			m.InsertItem(-1, newReleaseSourceAction(constants.LibraryBaseItemID+i*3+1, lib).NewMenuItem(f))
 		}
-		m.InsertItem(-1, newShowLibraryFolderAction(constants.LibraryBaseItemID+i*2+1, lib).NewMenuItem(f))
+		m.InsertItem(-1, newShowLibraryFolderAction(constants.LibraryBaseItemID+i*3+2, lib).NewMenuItem(f))
 		m.InsertSeparator(-1, false)
 	}
+	m.InsertItem(-1, SearchLibraries.NewMenuItem(f))
 	m.InsertItem(-1, ChangeLibraryLocations.NewMenuItem(f))
 }
 
@@ -75,6 +91,14 @@ func newUpdateLibraryAction(id int, lib *library.Library) *unison.Action {
 	return action
 }
 
+func newReleaseSourceAction(id int, lib *library.Library) *unison.Action {
+	return &unison.Action{
+		ID:              id,
+		Title:           fmt.Sprintf(i18n.Text("Change %s Release Source…"), lib.Title),
+		ExecuteCallback: func(_ *unison.Action, _ any) { uisettings.ShowReleaseSourceDialog(lib) },
+	}
+}
+
 func newShowLibraryFolderAction(id int, lib *library.Library) *unison.Action {
 	return &unison.Action{
 		ID:    id,
@@ -13,9 +13,11 @@ package ui
 
 import (
 	"github.com/richardwilkes/gcs/v5/model/settings"
+	"github.com/richardwilkes/gcs/v5/ui/api"
 	"github.com/richardwilkes/gcs/v5/ui/menus"
 	"github.com/richardwilkes/gcs/v5/ui/updates"
 	"github.com/richardwilkes/gcs/v5/ui/workspace"
+	wsettings "github.com/richardwilkes/gcs/v5/ui/workspace/settings"
 	"github.com/richardwilkes/toolbox/cmdline"
 	"github.com/richardwilkes/toolbox/log/jot"
 	"github.com/richardwilkes/unison"
@@ -33,6 +35,8 @@ func Start(files []string) {
 			menus.Setup(wnd)
 			workspace.NewWorkspace(wnd)
 			workspace.OpenFiles(files)
+			wsettings.APIServerSettingsChanged = api.Sync
+			api.Sync()
 		}),
 		unison.OpenFilesCallback(workspace.OpenFiles),
 		unison.AllowQuitCallback(func() bool {
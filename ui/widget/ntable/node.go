@@ -29,6 +29,13 @@ import (
 
 const excludeMarker = "exclude"
 
+// Roller defines the methods required of an ancestor of a table whose cells may be clicked to make a roll, such as
+// against a skill level or for a weapon's damage. column is one of the column constants for the row's data type.
+type Roller interface {
+	CanRoll(data any, column int) bool
+	Roll(data any, column int)
+}
+
 var _ unison.TableRowData[*Node[*gurps.Trait]] = &Node[*gurps.Trait]{}
 
 // Node represents a row in a table.
@@ -120,7 +127,8 @@ func (n *Node[T]) CellDataForSort(index int) string {
 // ColumnCell implements unison.TableRowData.
 func (n *Node[T]) ColumnCell(row, col int, foreground, _ unison.Ink, _, _, _ bool) unison.Paneler {
 	var cellData gurps.CellData
-	if column, exists := n.colMap[col]; exists {
+	column, exists := n.colMap[col]
+	if exists {
 		n.data.CellData(column, &cellData)
 	}
 	width := n.table.CellWidth(row, col)
@@ -129,6 +137,9 @@ func (n *Node[T]) ColumnCell(row, col int, foreground, _ unison.Ink, _, _, _ boo
 		return n.cellCache[col].Panel
 	}
 	cell := n.CellFromCellData(&cellData, width, foreground)
+	if exists && n.forPage {
+		n.installRollHandler(cell, column)
+	}
 	n.cellCache[col] = &CellCache{
 		Panel: cell,
 		Data:  cellData,
@@ -137,6 +148,32 @@ func (n *Node[T]) ColumnCell(row, col int, foreground, _ unison.Ink, _, _, _ boo
 	return cell
 }
 
+func (n *Node[T]) installRollHandler(cell unison.Paneler, column int) {
+	if roller := unison.AncestorOrSelf[Roller](n.table); roller != nil && roller.CanRoll(n.data, column) {
+		p := cell.AsPanel()
+		if p.Tooltip == nil {
+			p.Tooltip = unison.NewTooltipWithText(i18n.Text("Click to roll"))
+		}
+		data := n.data
+		// A plain click rolls. The roll waits out the double-click interval, so that a double-click can still open the
+		// editor without also rolling. The click is always passed on to the table, so that the row is still selected
+		// and modified clicks and the context menu behave as they do elsewhere in the table.
+		var lastClickCount int
+		p.MouseDownCallback = func(_ unison.Point, button, clickCount int, mod unison.Modifiers) bool {
+			lastClickCount = clickCount
+			if button == unison.ButtonLeft && clickCount == 1 && mod&unison.NonStickyModifiers == 0 {
+				delay, _ := unison.DoubleClickParameters()
+				unison.InvokeTaskAfter(func() {
+					if lastClickCount == 1 {
+						roller.Roll(data, column)
+					}
+				}, delay)
+			}
+			return false
+		}
+	}
+}
+
 func applyForegroundInkRecursively(panel *unison.Panel, foreground unison.Ink) {
 	if label, ok := panel.Self.(*unison.Label); ok {
 		if _, exists := label.ClientData()[excludeMarker]; !exists {
@@ -13,6 +13,7 @@ package editors
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/richardwilkes/gcs/v5/constants"
 	"github.com/richardwilkes/gcs/v5/model/fxp"
@@ -43,14 +44,18 @@ func EditEquipment(owner widget.Rebuildable, equipment *gurps.Equipment, carried
 			} else {
 				addLabelAndDecimalField(content, nil, "", qtyLabel, "", &e.editorData.Quantity, 0, fxp.Max-1)
 			}
+			sheetSettings := gurps.SheetSettingsFor(e.target.Entity)
 			valueLabel := i18n.Text("Value")
-			wrapper := addFlowWrapper(content, valueLabel, 3)
-			addDecimalField(wrapper, nil, "", valueLabel, "", &e.editorData.Value, 0, fxp.Max-1)
+			wrapper := addFlowWrapper(content, valueLabel, 4)
+			valueField := addDecimalField(wrapper, nil, "", valueLabel, "", &e.editorData.Value, 0, fxp.Max-1)
+			addCurrencyPopup(wrapper, sheetSettings, &e.editorData.Currency)
 			wrapper.AddChild(widget.NewFieldInteriorLeadingLabel(i18n.Text("Extended")))
 			wrapper.AddChild(widget.NewNonEditableField(func(field *widget.NonEditableField) {
 				var value fxp.Int
 				if e.editorData.Quantity > 0 {
-					value = gurps.ValueAdjustedForModifiers(e.editorData.Value, e.editorData.Modifiers)
+					value = gurps.ValueAdjustedForModifiers(e.editorData.EffectiveValue(e.target.Entity),
+						e.editorData.Modifiers)
+					value = e.editorData.ValueToBase(e.target.Entity, value)
 					if e.target.Container() {
 						for _, one := range e.target.Children {
 							value += one.ExtendedValue()
@@ -58,25 +63,55 @@ func EditEquipment(owner widget.Rebuildable, equipment *gurps.Equipment, carried
 					}
 					value = value.Mul(e.editorData.Quantity)
 				}
-				field.Text = value.Comma()
+				field.Text = sheetSettings.FormatValue(value)
 				field.MarkForLayoutAndRedraw()
 			}))
 			weightLabel := i18n.Text("Weight")
 			wrapper = addFlowWrapper(content, weightLabel, 3)
-			addWeightField(wrapper, nil, "", weightLabel, "", e.target.Entity, &e.editorData.Weight, false)
+			weightField := addWeightField(wrapper, nil, "", weightLabel, "", e.target.Entity, &e.editorData.Weight, false)
 			wrapper.AddChild(widget.NewFieldInteriorLeadingLabel(i18n.Text("Extended")))
 			wrapper.AddChild(widget.NewNonEditableField(func(field *widget.NonEditableField) {
 				var weight measure.Weight
-				defUnits := gurps.SheetSettingsFor(e.target.Entity).DefaultWeightUnits
+				defUnits := sheetSettings.DefaultWeightUnits
 				if e.editorData.Quantity > 0 {
-					weight = gurps.ExtendedWeightAdjustedForModifiers(defUnits, e.editorData.Quantity, e.editorData.Weight,
-						e.editorData.Modifiers, e.editorData.Features, e.target.Children, false, false)
+					weight = gurps.ExtendedWeightAdjustedForModifiers(defUnits, e.editorData.Quantity,
+						e.editorData.EffectiveWeight(e.target.Entity), e.editorData.Modifiers, e.editorData.Features,
+						e.target.Children, false, false)
 				}
 				field.Text = defUnits.Format(weight)
 				field.MarkForLayoutAndRedraw()
 			}))
+			if e.target.Container() {
+				capacityLabel := i18n.Text("Capacity")
+				wrapper = addFlowWrapper(content, capacityLabel, 1)
+				addWeightField(wrapper, nil, "", capacityLabel,
+					i18n.Text("The most the contents may weigh. Zero means there is no limit."), e.target.Entity,
+					&e.editorData.Capacity, false)
+			}
 			content.AddChild(unison.NewPanel())
-			addCheckBox(content, i18n.Text("Ignore weight for skills"), &e.editorData.WeightIgnoredForSkills)
+			wrapper = unison.NewPanel()
+			wrapper.SetLayout(&unison.FlexLayout{
+				Columns:  2,
+				HSpacing: unison.StdHSpacing,
+				VSpacing: unison.StdVSpacing,
+				VAlign:   unison.MiddleAlignment,
+			})
+			content.AddChild(wrapper)
+			addCheckBox(wrapper, i18n.Text("Ignore weight for skills"), &e.editorData.WeightIgnoredForSkills)
+			coinsCheckBox := widget.NewCheckBox(nil, "", i18n.Text("Coins"),
+				func() unison.CheckState { return unison.CheckStateFromBool(e.editorData.Coins) },
+				func(state unison.CheckState) {
+					e.editorData.Coins = state == unison.OnCheckState
+					widget.MarkModified(wrapper)
+				})
+			coinsCheckBox.Tooltip = unison.NewTooltipWithText(i18n.Text(`When checked, each item is a single coin of the chosen currency:
+its value is one unit of that currency and its weight is the coin weight set for that currency, if any`))
+			wrapper.AddChild(coinsCheckBox)
+			adjustCoins := func() {
+				adjustFieldBlank(valueField, e.editorData.Coins && e.editorData.CurrencyFor(e.target.Entity) != nil)
+				adjustFieldBlank(weightField, e.editorData.EffectiveWeight(e.target.Entity) != e.editorData.Weight)
+			}
+			adjustCoins()
 			usesLabel := i18n.Text("Uses")
 			wrapper = addFlowWrapper(content, usesLabel, 3)
 			usesField := addIntegerField(wrapper, nil, "", usesLabel, "", &e.editorData.Uses, 0, 9999999)
@@ -86,6 +121,10 @@ func EditEquipment(owner widget.Rebuildable, equipment *gurps.Equipment, carried
 			addTagsLabelAndField(content, &e.editorData.Tags)
 			addPageRefLabelAndField(content, &e.editorData.PageRef)
 			adjustFieldBlank(usesField, e.editorData.MaxUses <= 0)
+			var adjustTemplatePicker func()
+			if e.target.Container() {
+				adjustTemplatePicker = addTemplatePickerLabelAndFields(content, e.editorData.TemplatePicker)
+			}
 			content.AddChild(newPrereqPanel(e.target.Entity, &e.editorData.Prereq))
 			content.AddChild(newFeaturesPanel(e.target.Entity, e.target, &e.editorData.Features))
 			modifiersPanel := newEquipmentModifiersPanel(e.target.Entity, &e.editorData.Modifiers)
@@ -98,10 +137,41 @@ func EditEquipment(owner widget.Rebuildable, equipment *gurps.Equipment, carried
 			e.InstallCmdHandlers(constants.NewEquipmentContainerModifierItemID, unison.AlwaysEnabled,
 				func(_ any) { modifiersPanel.provider.CreateItem(e, modifiersPanel.table, ntable.ContainerItemVariant) })
 			return func() {
+				adjustCoins()
 				if e.editorData.Uses > e.editorData.MaxUses {
 					usesField.SetText(strconv.Itoa(e.editorData.MaxUses))
 				}
 				adjustFieldBlank(usesField, e.editorData.MaxUses <= 0)
+				if adjustTemplatePicker != nil {
+					adjustTemplatePicker()
+				}
 			}
 		})
 }
+
+func addCurrencyPopup(parent *unison.Panel, sheetSettings *gurps.SheetSettings, fieldData *string) {
+	if len(sheetSettings.Currencies) == 0 && *fieldData == "" {
+		return
+	}
+	popup := unison.NewPopupMenu[*gurps.Currency]()
+	base := &gurps.Currency{Name: "$"}
+	popup.AddItem(base)
+	selected := base
+	for _, one := range sheetSettings.Currencies {
+		popup.AddItem(one)
+		if strings.EqualFold(one.ID, *fieldData) {
+			selected = one
+		}
+	}
+	if selected == base && *fieldData != "" {
+		// Keep a currency that isn't defined by these settings, rather than silently switching to the base currency
+		selected = &gurps.Currency{ID: *fieldData}
+		popup.AddItem(selected)
+	}
+	popup.Select(selected)
+	popup.SelectionCallback = func(_ int, item *gurps.Currency) {
+		*fieldData = item.ID
+		widget.MarkModified(parent)
+	}
+	parent.AddChild(popup)
+}
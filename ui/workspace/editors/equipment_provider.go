@@ -235,11 +235,16 @@ func (p *equipmentProvider) descriptionText() string {
 	if p.forPage {
 		if entity, ok := p.provider.(*gurps.Entity); ok {
 			if p.carried {
-				title = fmt.Sprintf(i18n.Text("Carried Equipment (%s; $%s)"),
+				title = fmt.Sprintf(i18n.Text("Carried Equipment (%s; %s)"),
 					entity.SheetSettings.DefaultWeightUnits.Format(entity.WeightCarried(false)),
-					entity.WealthCarried().String())
+					entity.SheetSettings.FormatWealth(entity.WealthCarried()))
+			} else if entity.Funds != 0 {
+				title = fmt.Sprintf(i18n.Text("Other Equipment (%s; %s in funds)"),
+					entity.SheetSettings.FormatWealth(entity.WealthNotCarried()),
+					entity.SheetSettings.FormatWealth(entity.Funds))
 			} else {
-				title = fmt.Sprintf(i18n.Text("Other Equipment ($%s)"), entity.WealthNotCarried().String())
+				title = fmt.Sprintf(i18n.Text("Other Equipment (%s)"),
+					entity.SheetSettings.FormatWealth(entity.WealthNotCarried()))
 			}
 		}
 	}
@@ -173,13 +173,14 @@ func initSkillEditor(e *editor[*gurps.Skill, *gurps.SkillEditData], content *uni
 		}
 	}
 	addPageRefLabelAndField(content, &e.editorData.PageRef)
-	if !e.target.Container() {
-		content.AddChild(newPrereqPanel(e.target.Entity, &e.editorData.Prereq))
-		content.AddChild(newDefaultsPanel(e.target.Entity, &e.editorData.Defaults))
-		content.AddChild(newFeaturesPanel(e.target.Entity, e.target, &e.editorData.Features))
-		for _, wt := range weapon.AllType {
-			content.AddChild(newWeaponsPanel(e, e.target, wt, &e.editorData.Weapons))
-		}
+	if e.target.Container() {
+		return addTemplatePickerLabelAndFields(content, e.editorData.TemplatePicker)
+	}
+	content.AddChild(newPrereqPanel(e.target.Entity, &e.editorData.Prereq))
+	content.AddChild(newDefaultsPanel(e.target.Entity, &e.editorData.Defaults))
+	content.AddChild(newFeaturesPanel(e.target.Entity, e.target, &e.editorData.Features))
+	for _, wt := range weapon.AllType {
+		content.AddChild(newWeaponsPanel(e, e.target, wt, &e.editorData.Weapons))
 	}
 	return nil
 }
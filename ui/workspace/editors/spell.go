@@ -93,11 +93,12 @@ func initSpellEditor(e *editor[*gurps.Spell, *gurps.SpellEditData], content *uni
 	addVTTNotesLabelAndField(content, &e.editorData.VTTNotes)
 	addTagsLabelAndField(content, &e.editorData.Tags)
 	addPageRefLabelAndField(content, &e.editorData.PageRef)
-	if !e.target.Container() {
-		content.AddChild(newPrereqPanel(e.target.Entity, &e.editorData.Prereq))
-		for _, wt := range weapon.AllType {
-			content.AddChild(newWeaponsPanel(e, e.target, wt, &e.editorData.Weapons))
-		}
+	if e.target.Container() {
+		return addTemplatePickerLabelAndFields(content, e.editorData.TemplatePicker)
+	}
+	content.AddChild(newPrereqPanel(e.target.Entity, &e.editorData.Prereq))
+	for _, wt := range weapon.AllType {
+		content.AddChild(newWeaponsPanel(e, e.target, wt, &e.editorData.Weapons))
 	}
 	return nil
 }
@@ -70,6 +70,7 @@ func initTraitEditor(e *editor[*gurps.Trait, *gurps.TraitEditData], content *uni
 		crAdjPopup.SetEnabled(false)
 	}
 	var ancestryPopup *unison.PopupMenu[string]
+	var adjustTemplatePicker func()
 	if e.target.Container() {
 		addLabelAndPopup(content, i18n.Text("Container Type"), "", trait.AllContainerType,
 			&e.editorData.ContainerType)
@@ -81,6 +82,7 @@ func initTraitEditor(e *editor[*gurps.Trait, *gurps.TraitEditData], content *uni
 		}
 		ancestryPopup = addLabelAndPopup(content, i18n.Text("Ancestry"), "", choices, &e.editorData.Ancestry)
 		adjustPopupBlank(ancestryPopup, e.editorData.ContainerType != trait.Race)
+		adjustTemplatePicker = addTemplatePickerLabelAndFields(content, e.editorData.TemplatePicker)
 	}
 	addPageRefLabelAndField(content, &e.editorData.PageRef)
 	modifiersPanel := newTraitModifiersPanel(e.target.Entity, &e.editorData.Modifiers)
@@ -121,5 +123,8 @@ func initTraitEditor(e *editor[*gurps.Trait, *gurps.TraitEditData], content *uni
 				adjustPopupBlank(ancestryPopup, true)
 			}
 		}
+		if adjustTemplatePicker != nil {
+			adjustTemplatePicker()
+		}
 	}
 }
@@ -51,7 +51,146 @@ func initWeaponEditor(e *editor[*gurps.Weapon, *gurps.Weapon], content *unison.P
 		addLabelAndStringField(content, i18n.Text("Recoil"), "", &e.editorData.Recoil)
 		addLabelAndStringField(content, i18n.Text("Shots"), "", &e.editorData.Shots)
 		addLabelAndStringField(content, i18n.Text("Bulk"), "", &e.editorData.Bulk)
+		modCallback := addAmmoLabelAndFields(content, e.editorData)
+		content.AddChild(newDefaultsPanel(e.editorData.Entity(), &e.editorData.Defaults))
+		return modCallback
 	}
 	content.AddChild(newDefaultsPanel(e.editorData.Entity(), &e.editorData.Defaults))
 	return nil
 }
+
+type ammoChoice struct {
+	title  string
+	source string
+	name   string
+	tagged bool
+}
+
+func (c *ammoChoice) String() string {
+	return c.title
+}
+
+func addAmmoLabelAndFields(parent *unison.Panel, w *gurps.Weapon) func() {
+	ammo := &gurps.WeaponAmmo{}
+	if w.Ammo != nil {
+		ammo = w.Ammo
+	}
+	noneChoice := &ammoChoice{title: i18n.Text("None")}
+	taggedChoice := &ammoChoice{title: i18n.Text("Equipment tagged"), tagged: true}
+	choices := []*ammoChoice{noneChoice, taggedChoice}
+	var current *ammoChoice
+	if w.Ammo == nil {
+		current = noneChoice
+	}
+	if entity := w.Entity(); entity != nil {
+		gurps.Traverse(func(eqp *gurps.Equipment) bool {
+			choice := &ammoChoice{
+				title:  eqp.Description(),
+				source: eqp.ID.String(),
+				name:   eqp.Name,
+			}
+			choices = append(choices, choice)
+			if current == nil && choice.source == ammo.Source {
+				current = choice
+			}
+			return false
+		}, false, false, entity.CarriedEquipment...)
+	}
+	if _, ok := ammo.SourceID(); ok && current == nil {
+		// The linked equipment may have been copied since it was linked, giving it a new ID.
+		if eqp := w.AmmoSource(); eqp != nil {
+			for _, one := range choices {
+				if one.source == eqp.ID.String() {
+					current = one
+					break
+				}
+			}
+		}
+	}
+	if current == nil {
+		if _, ok := ammo.SourceID(); ok {
+			current = &ammoChoice{
+				title:  i18n.Text("Equipment not carried"),
+				source: ammo.Source,
+				name:   ammo.Name,
+			}
+			choices = append(choices, current)
+		} else {
+			current = taggedChoice
+		}
+	}
+	var tag string
+	if current == taggedChoice {
+		tag = ammo.Source
+	}
+
+	ammoLabel := i18n.Text("Ammunition")
+	wrapper := addFlowWrapper(parent, ammoLabel, 2)
+	popup := unison.NewPopupMenu[*ammoChoice]()
+	for _, one := range choices {
+		popup.AddItem(one)
+	}
+	popup.Select(current)
+	wrapper.AddChild(popup)
+	tagField := widget.NewStringField(nil, "", i18n.Text("Ammunition Tag"),
+		func() string { return tag },
+		func(value string) {
+			tag = value
+			if current == taggedChoice {
+				ammo.Source = value
+			}
+			widget.MarkModified(parent)
+		})
+	tagField.Tooltip = unison.NewTooltipWithText(i18n.Text("The first carried equipment with this tag and a non-zero quantity will be used"))
+	tagField.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+	wrapper.AddChild(tagField)
+	popup.SelectionCallback = func(_ int, item *ammoChoice) {
+		current = item
+		switch {
+		case item == noneChoice:
+			w.Ammo = nil
+		case item.tagged:
+			ammo.Source = tag
+			ammo.Name = ""
+			w.Ammo = ammo
+		default:
+			ammo.Source = item.source
+			ammo.Name = item.name
+			w.Ammo = ammo
+		}
+		widget.MarkModified(parent)
+	}
+
+	parent.AddChild(unison.NewPanel())
+	wrapper = unison.NewPanel()
+	wrapper.SetLayout(&unison.FlexLayout{
+		Columns:  3,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+		VAlign:   unison.MiddleAlignment,
+	})
+	parent.AddChild(wrapper)
+	looseCheckBox := widget.NewCheckBox(nil, "", i18n.Text("Loaded a round at a time"),
+		func() unison.CheckState { return unison.CheckStateFromBool(ammo.Loose) },
+		func(state unison.CheckState) {
+			ammo.Loose = state == unison.OnCheckState
+			widget.MarkModified(parent)
+		})
+	looseCheckBox.Tooltip = unison.NewTooltipWithText(i18n.Text("When checked, the equipment quantity counts rounds rather than magazines"))
+	wrapper.AddChild(looseCheckBox)
+	loadedLabel := i18n.Text("Shots Loaded")
+	wrapper.AddChild(widget.NewFieldInteriorLeadingLabel(loadedLabel))
+	loadedField := addIntegerField(wrapper, nil, "", loadedLabel, "", &ammo.Loaded, 0, 9999999)
+
+	adjust := func() {
+		adjustFieldBlank(tagField, current != taggedChoice)
+		linked := current != noneChoice
+		looseCheckBox.SetEnabled(linked)
+		adjustFieldBlank(loadedField, !linked)
+	}
+	adjust()
+	return adjust
+}
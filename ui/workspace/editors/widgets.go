@@ -19,6 +19,7 @@ import (
 	"github.com/richardwilkes/gcs/v5/model/gurps"
 	"github.com/richardwilkes/gcs/v5/model/gurps/feature"
 	"github.com/richardwilkes/gcs/v5/model/gurps/measure"
+	"github.com/richardwilkes/gcs/v5/model/gurps/picker"
 	"github.com/richardwilkes/gcs/v5/model/gurps/skill"
 	"github.com/richardwilkes/gcs/v5/ui/widget"
 	"github.com/richardwilkes/rpgtools/dice"
@@ -558,3 +559,22 @@ func addLeveledAmountPanel(parent *unison.Panel, targetMgr *widget.TargetMgr, ta
 		}, fxp.Min, fxp.Max, true, false))
 	addCheckBox(parent, i18n.Text("per level"), &amount.PerLevel)
 }
+
+// addTemplatePickerLabelAndFields adds the fields for editing a container's template picker. The returned function
+// should be called whenever the editor's data changes.
+func addTemplatePickerLabelAndFields(parent *unison.Panel, fieldData *gurps.TemplatePicker) func() {
+	wrapper := addFlowWrapper(parent, i18n.Text("Template Picker"), 5)
+	addPopup(wrapper, picker.AllType, &fieldData.Type)
+	minLabel := i18n.Text("Minimum")
+	wrapper.AddChild(widget.NewFieldInteriorLeadingLabel(minLabel))
+	minField := addDecimalField(wrapper, nil, "", minLabel, "", &fieldData.Min, -fxp.MaxBasePoints, fxp.MaxBasePoints)
+	maxLabel := i18n.Text("Maximum")
+	wrapper.AddChild(widget.NewFieldInteriorLeadingLabel(maxLabel))
+	maxField := addDecimalField(wrapper, nil, "", maxLabel, "", &fieldData.Max, -fxp.MaxBasePoints, fxp.MaxBasePoints)
+	adjust := func() {
+		adjustFieldBlank(minField, fieldData.Type == picker.NotApplicable)
+		adjustFieldBlank(maxField, fieldData.Type == picker.NotApplicable)
+	}
+	adjust()
+	return adjust
+}
@@ -12,9 +12,15 @@
 package lists
 
 import (
+	"os"
+	"path/filepath"
+
+	"github.com/richardwilkes/gcs/v5/model/gurps/importer"
 	"github.com/richardwilkes/gcs/v5/model/library"
 	"github.com/richardwilkes/gcs/v5/res"
+	"github.com/richardwilkes/gcs/v5/ui/workspace/settings/body"
 	"github.com/richardwilkes/gcs/v5/ui/workspace/sheet"
+	"github.com/richardwilkes/toolbox/i18n"
 	"github.com/richardwilkes/unison"
 )
 
@@ -22,6 +28,10 @@ import (
 func RegisterFileTypes() {
 	registerExportableGCSFileInfo(library.SheetExt, res.GCSSheetSVG, sheet.NewSheetFromFile)
 	registerGCSFileInfo(library.TemplatesExt, []string{library.TemplatesExt}, res.GCSTemplateSVG, sheet.NewTemplateFromFile)
+	registerGCSFileInfo(library.CampaignExt, []string{library.CampaignExt}, res.BookmarkSVG, sheet.NewCampaignEditorFromFile)
+	ancestryGroupWith := []string{library.AncestryExt, library.NameGeneratorExt}
+	registerGCSFileInfo(library.AncestryExt, ancestryGroupWith, res.RandomizeSVG, sheet.NewAncestryEditorFromFile)
+	registerGCSFileInfo(library.NameGeneratorExt, ancestryGroupWith, res.RandomizeSVG, sheet.NewNameGeneratorEditorFromFile)
 	groupWith := []string{library.TraitsExt, library.TraitModifiersExt, library.EquipmentExt, library.EquipmentModifiersExt, library.SkillsExt, library.SpellsExt, library.NotesExt}
 	registerGCSFileInfo(library.TraitsExt, groupWith, res.GCSTraitsSVG, NewTraitTableDockableFromFile)
 	registerGCSFileInfo(library.TraitModifiersExt, groupWith, res.GCSTraitModifiersSVG, NewTraitModifierTableDockableFromFile)
@@ -30,6 +40,10 @@ func RegisterFileTypes() {
 	registerGCSFileInfo(library.SkillsExt, groupWith, res.GCSSkillsSVG, NewSkillTableDockableFromFile)
 	registerGCSFileInfo(library.SpellsExt, groupWith, res.GCSSpellsSVG, NewSpellTableDockableFromFile)
 	registerGCSFileInfo(library.NotesExt, groupWith, res.GCSNotesSVG, NewNoteTableDockableFromFile)
+	registerGCSFileInfo(library.BodyExt, []string{library.BodyExt}, res.BodyTypeSVG, body.NewFileDockableFromFile)
+	legacyGroupWith := []string{importer.GCA4Ext, importer.GCA5Ext}
+	registerGCSFileInfo(importer.GCA4Ext, legacyGroupWith, res.GCSSheetSVG, NewDockableFromLegacyFile)
+	registerGCSFileInfo(importer.GCA5Ext, legacyGroupWith, res.GCSSheetSVG, NewDockableFromLegacyFile)
 }
 
 func registerGCSFileInfo(ext string, groupWith []string, svg *unison.SVG, loader func(filePath string) (unison.Dockable, error)) {
@@ -37,7 +51,7 @@ func registerGCSFileInfo(ext string, groupWith []string, svg *unison.SVG, loader
 		Extension:             ext,
 		ExtensionsToGroupWith: groupWith,
 		SVG:                   svg,
-		Load:                  loader,
+		Load:                  legacyAwareLoader(loader),
 		IsGCSData:             true,
 	}.Register()
 }
@@ -47,8 +61,54 @@ func registerExportableGCSFileInfo(ext string, svg *unison.SVG, loader func(file
 		Extension:             ext,
 		ExtensionsToGroupWith: []string{ext},
 		SVG:                   svg,
-		Load:                  loader,
+		Load:                  legacyAwareLoader(loader),
 		IsGCSData:             true,
 		IsExportable:          true,
 	}.Register()
 }
+
+func legacyAwareLoader(loader func(filePath string) (unison.Dockable, error)) func(filePath string) (unison.Dockable, error) {
+	return func(filePath string) (unison.Dockable, error) {
+		if importer.IsLegacyFile(os.DirFS(filepath.Dir(filePath)), filepath.Base(filePath)) {
+			return NewDockableFromLegacyFile(filePath)
+		}
+		return loader(filePath)
+	}
+}
+
+// NewDockableFromLegacyFile imports a file written in one of the legacy formats and creates a new unison.Dockable for
+// the result. Since the data has been converted, the user will be prompted for a location the first time it is saved.
+// Anything that couldn't be converted is presented to the user once the dockable has been displayed.
+func NewDockableFromLegacyFile(filePath string) (unison.Dockable, error) {
+	result, err := importer.Import(os.DirFS(filepath.Dir(filePath)), filepath.Base(filePath))
+	if err != nil {
+		return nil, err
+	}
+	var d unison.Dockable
+	switch result.Extension() {
+	case library.SheetExt:
+		d = sheet.NewSheet(filePath, result.Entity)
+	case library.TemplatesExt:
+		d = sheet.NewTemplate(filePath, result.Template)
+	case library.TraitsExt:
+		d = NewTraitTableDockable(filePath, result.Traits)
+	case library.TraitModifiersExt:
+		d = NewTraitModifierTableDockable(filePath, result.TraitModifiers)
+	case library.SkillsExt:
+		d = NewSkillTableDockable(filePath, result.Skills)
+	case library.SpellsExt:
+		d = NewSpellTableDockable(filePath, result.Spells)
+	case library.EquipmentExt:
+		d = NewEquipmentTableDockable(filePath, result.Equipment)
+	case library.EquipmentModifiersExt:
+		d = NewEquipmentModifierTableDockable(filePath, result.EquipmentModifiers)
+	default:
+		d = NewNoteTableDockable(filePath, result.Notes)
+	}
+	if !result.Report.Empty() {
+		unison.InvokeTask(func() {
+			unison.WarningDialogWithMessage(i18n.Text("Some data could not be imported"), result.Report.String())
+		})
+	}
+	return d, nil
+}
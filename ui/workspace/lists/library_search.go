@@ -0,0 +1,445 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package lists
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/model/gurps/search"
+	"github.com/richardwilkes/gcs/v5/model/settings"
+	"github.com/richardwilkes/gcs/v5/res"
+	"github.com/richardwilkes/gcs/v5/ui/widget/ntable"
+	"github.com/richardwilkes/gcs/v5/ui/workspace"
+	"github.com/richardwilkes/gcs/v5/ui/workspace/editors"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+	"github.com/richardwilkes/unison"
+)
+
+const maxSearchResults = 500
+
+const (
+	searchNameColumn = iota
+	searchTypeColumn
+	searchPointsColumn
+	searchCostColumn
+	searchTLColumn
+	searchRefColumn
+	searchLocationColumn
+	searchColumnCount
+)
+
+var (
+	_ unison.Dockable                       = &LibrarySearch{}
+	_ unison.TabCloser                      = &LibrarySearch{}
+	_ unison.TableRowData[*searchResultRow] = &searchResultRow{}
+)
+
+// LibrarySearch holds a dockable that searches the data files of all of the libraries. Results may be dragged onto
+// sheets, templates and lists.
+type LibrarySearch struct {
+	unison.Panel
+	index       *search.Index
+	searchField *unison.Field
+	statusLabel *unison.Label
+	table       *unison.Table[*searchResultRow]
+}
+
+// ShowLibrarySearch shows the library search, creating it if it isn't open yet.
+func ShowLibrarySearch() {
+	ws, _, found := workspace.Activate(func(d unison.Dockable) bool {
+		_, ok := d.(*LibrarySearch)
+		return ok
+	})
+	if !found && ws != nil {
+		d := newLibrarySearch()
+		workspace.DisplayNewDockable(nil, d)
+		d.searchField.RequestFocus()
+	}
+}
+
+func newLibrarySearch() *LibrarySearch {
+	d := &LibrarySearch{
+		index: search.NewIndex(),
+		table: unison.NewTable[*searchResultRow](&unison.SimpleTableModel[*searchResultRow]{}),
+	}
+	d.Self = d
+	d.SetLayout(&unison.FlexLayout{Columns: 1})
+
+	d.searchField = unison.NewField()
+	d.searchField.Watermark = i18n.Text("Search")
+	d.searchField.Tooltip = unison.NewTooltipWithText(search.QuerySyntax)
+	d.searchField.ModifiedCallback = d.runQuery
+	d.searchField.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		VAlign: unison.MiddleAlignment,
+		HGrab:  true,
+	})
+
+	d.statusLabel = unison.NewLabel()
+
+	toolbar := unison.NewPanel()
+	toolbar.SetBorder(unison.NewCompoundBorder(unison.NewLineBorder(unison.DividerColor, 0, unison.Insets{Bottom: 1},
+		false), unison.NewEmptyBorder(unison.StdInsets())))
+	toolbar.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+	toolbar.AddChild(d.searchField)
+	toolbar.AddChild(d.statusLabel)
+	toolbar.SetLayout(&unison.FlexLayout{
+		Columns:  len(toolbar.Children()),
+		HSpacing: unison.StdHSpacing,
+	})
+	d.AddChild(toolbar)
+
+	headers := []unison.TableColumnHeader[*searchResultRow]{
+		unison.NewTableColumnHeader[*searchResultRow](i18n.Text("Name"), ""),
+		unison.NewTableColumnHeader[*searchResultRow](i18n.Text("Type"), ""),
+		unison.NewTableColumnHeader[*searchResultRow](i18n.Text("Pts"), i18n.Text("Points")),
+		unison.NewTableColumnHeader[*searchResultRow](i18n.Text("Cost"), i18n.Text("Cost or value")),
+		unison.NewTableColumnHeader[*searchResultRow](i18n.Text("TL"), i18n.Text("Tech Level")),
+		unison.NewTableColumnHeader[*searchResultRow](i18n.Text("Ref"), i18n.Text("Page Reference")),
+		unison.NewTableColumnHeader[*searchResultRow](i18n.Text("Location"), i18n.Text("The file containing the item")),
+	}
+	d.table.ColumnSizes = make([]unison.ColumnSize, searchColumnCount)
+	for i := range d.table.ColumnSizes {
+		_, pref, _ := headers[i].AsPanel().Sizes(unison.Size{})
+		pref.Width += d.table.Padding.Left + d.table.Padding.Right
+		d.table.ColumnSizes[i].AutoMinimum = pref.Width
+		d.table.ColumnSizes[i].AutoMaximum = 800
+		d.table.ColumnSizes[i].Minimum = pref.Width
+		d.table.ColumnSizes[i].Maximum = 10000
+	}
+	d.table.DoubleClickCallback = d.openSelection
+	d.table.MouseDragCallback = func(where unison.Point, _ int, _ unison.Modifiers) bool {
+		if d.table.HasSelection() && d.table.IsDragGesture(where) {
+			d.startDrag()
+		}
+		return false
+	}
+	header := unison.NewTableHeader(d.table, headers...)
+	header.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		VAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+
+	scroll := unison.NewScrollPanel()
+	scroll.SetColumnHeader(header)
+	scroll.SetContent(d.table, unison.FillBehavior, unison.FillBehavior)
+	scroll.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		VAlign: unison.FillAlignment,
+		HGrab:  true,
+		VGrab:  true,
+	})
+	d.AddChild(scroll)
+
+	d.index.SetChangeCallback(func() { unison.InvokeTask(d.runQuery) })
+	go d.index.Build(settings.Global().LibrarySet)
+	d.runQuery()
+	return d
+}
+
+// TitleIcon implements unison.Dockable
+func (d *LibrarySearch) TitleIcon(suggestedSize unison.Size) unison.Drawable {
+	return &unison.DrawableSVG{
+		SVG:  res.SearchSVG,
+		Size: suggestedSize,
+	}
+}
+
+// Title implements unison.Dockable
+func (d *LibrarySearch) Title() string {
+	return i18n.Text("Library Search")
+}
+
+// Tooltip implements unison.Dockable
+func (d *LibrarySearch) Tooltip() string {
+	return ""
+}
+
+// Modified implements unison.Dockable
+func (d *LibrarySearch) Modified() bool {
+	return false
+}
+
+// MayAttemptClose implements unison.TabCloser
+func (d *LibrarySearch) MayAttemptClose() bool {
+	return true
+}
+
+// AttemptClose implements unison.TabCloser
+func (d *LibrarySearch) AttemptClose() bool {
+	d.index.SetChangeCallback(nil)
+	d.index.Stop()
+	if dc := unison.Ancestor[*unison.DockContainer](d); dc != nil {
+		dc.Close(d)
+	}
+	return true
+}
+
+func (d *LibrarySearch) runQuery() {
+	var rows []*searchResultRow
+	status := ""
+	query, err := search.ParseQuery(d.searchField.Text())
+	switch {
+	case err != nil:
+		var e *errs.Error
+		if errors.As(err, &e) {
+			status = e.Message()
+		} else {
+			status = err.Error()
+		}
+	case d.index.Building():
+		status = i18n.Text("Indexing…")
+	case query.Empty():
+		status = fmt.Sprintf(i18n.Text("%d items indexed"), d.index.Count())
+	default:
+		results := d.index.Search(query)
+		if len(results) > maxSearchResults {
+			status = fmt.Sprintf(i18n.Text("%d matches (showing the first %d)"), len(results), maxSearchResults)
+			results = results[:maxSearchResults]
+		} else {
+			status = fmt.Sprintf(i18n.Text("%d matches"), len(results))
+		}
+		rows = make([]*searchResultRow, len(results))
+		for i, one := range results {
+			rows[i] = &searchResultRow{id: uuid.New(), entry: one}
+		}
+	}
+	d.statusLabel.Text = status
+	d.statusLabel.MarkForLayoutAndRedraw()
+	d.table.SetRootRows(rows)
+	d.table.SizeColumnsToFit(true)
+}
+
+func (d *LibrarySearch) openSelection() {
+	wnd := d.Window()
+	opened := make(map[string]bool)
+	for _, row := range d.table.SelectedRows(false) {
+		if !opened[row.entry.FilePath] {
+			opened[row.entry.FilePath] = true
+			workspace.OpenFile(wnd, row.entry.FilePath)
+		}
+	}
+}
+
+// startDrag loads the selected items from their files and offers them using the same drag keys and data the list
+// tables use, so that any table that accepts a drop of a given kind of item will accept them.
+func (d *LibrarySearch) startDrag() {
+	var kinds []string
+	items := make(map[string][]any)
+	for _, row := range d.table.SelectedRows(false) {
+		if row.entry.Kind == search.TemplateKind {
+			continue
+		}
+		item, err := row.entry.Load()
+		if err != nil {
+			jot.Error(err)
+			continue
+		}
+		if item == nil {
+			continue
+		}
+		if _, exists := items[row.entry.Kind]; !exists {
+			kinds = append(kinds, row.entry.Kind)
+		}
+		items[row.entry.Kind] = append(items[row.entry.Kind], item)
+	}
+	if len(kinds) == 0 {
+		return
+	}
+	data := make(map[string]any, len(kinds))
+	var drawable unison.Drawable
+	for _, kind := range kinds {
+		var dragData any
+		var dragDrawable unison.Drawable
+		list := items[kind]
+		switch kind {
+		case gid.Trait:
+			dragData, dragDrawable = newSearchDragData(editors.NewTraitsProvider(
+				&traitListProvider{traits: itemsOfType[*gurps.Trait](list)}, false))
+		case gid.TraitModifier:
+			dragData, dragDrawable = newSearchDragData(editors.NewTraitModifiersProvider(
+				&traitModifierListProvider{modifiers: itemsOfType[*gurps.TraitModifier](list)}, false))
+		case gid.Skill:
+			dragData, dragDrawable = newSearchDragData(editors.NewSkillsProvider(
+				&skillListProvider{skills: itemsOfType[*gurps.Skill](list)}, false))
+		case gid.Spell:
+			dragData, dragDrawable = newSearchDragData(editors.NewSpellsProvider(
+				&spellListProvider{spells: itemsOfType[*gurps.Spell](list)}, false))
+		case gid.Equipment:
+			dragData, dragDrawable = newSearchDragData(editors.NewEquipmentProvider(
+				&equipmentListProvider{other: itemsOfType[*gurps.Equipment](list)}, false, false))
+		case gid.EquipmentModifier:
+			dragData, dragDrawable = newSearchDragData(editors.NewEquipmentModifiersProvider(
+				&equipmentModifierListProvider{modifiers: itemsOfType[*gurps.EquipmentModifier](list)}, false))
+		case gid.Note:
+			dragData, dragDrawable = newSearchDragData(editors.NewNotesProvider(
+				&noteListProvider{notes: itemsOfType[*gurps.Note](list)}, false))
+		default:
+			continue
+		}
+		data[kind] = dragData
+		if drawable == nil {
+			drawable = dragDrawable
+		}
+	}
+	if drawable == nil {
+		return
+	}
+	size := drawable.LogicalSize()
+	d.table.StartDataDrag(&unison.DragData{
+		Data:     data,
+		Drawable: drawable,
+		Ink:      d.table.OnBackgroundInk,
+		Offset:   unison.Point{X: 0, Y: -size.Height / 2},
+	})
+}
+
+func newSearchDragData[T gurps.NodeConstraint[T]](provider ntable.TableProvider[T]) (*unison.TableDragData[*ntable.Node[T]], unison.Drawable) {
+	_, table := ntable.NewNodeTable[T](provider, nil)
+	data := &unison.TableDragData[*ntable.Node[T]]{
+		Table: table,
+		Rows:  table.RootRows(),
+	}
+	singular, plural := provider.ItemNames()
+	return data, unison.NewTableDragDrawable(data, provider.DragSVG(), singular, plural)
+}
+
+func itemsOfType[T any](items []any) []T {
+	list := make([]T, 0, len(items))
+	for _, one := range items {
+		if item, ok := one.(T); ok {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+type searchResultRow struct {
+	id    uuid.UUID
+	entry *search.Entry
+}
+
+// CloneForTarget implements unison.TableRowData. Not permitted.
+func (r *searchResultRow) CloneForTarget(_ unison.Paneler, _ *searchResultRow) *searchResultRow {
+	return nil
+}
+
+// UUID implements unison.TableRowData.
+func (r *searchResultRow) UUID() uuid.UUID {
+	return r.id
+}
+
+// Parent implements unison.TableRowData.
+func (r *searchResultRow) Parent() *searchResultRow {
+	return nil
+}
+
+// SetParent implements unison.TableRowData.
+func (r *searchResultRow) SetParent(_ *searchResultRow) {
+}
+
+// CanHaveChildren implements unison.TableRowData.
+func (r *searchResultRow) CanHaveChildren() bool {
+	return false
+}
+
+// Children implements unison.TableRowData.
+func (r *searchResultRow) Children() []*searchResultRow {
+	return nil
+}
+
+// SetChildren implements unison.TableRowData.
+func (r *searchResultRow) SetChildren(_ []*searchResultRow) {
+}
+
+// CellDataForSort implements unison.TableRowData.
+func (r *searchResultRow) CellDataForSort(col int) string {
+	e := r.entry
+	switch col {
+	case searchNameColumn:
+		return e.Name
+	case searchTypeColumn:
+		return searchKindTitle(e.Kind)
+	case searchPointsColumn:
+		if e.HasPoints {
+			return e.Points.String()
+		}
+	case searchCostColumn:
+		if e.HasCost {
+			return e.Cost.String()
+		}
+	case searchTLColumn:
+		return e.TechLevel
+	case searchRefColumn:
+		return e.PageRef
+	case searchLocationColumn:
+		if rel, err := filepath.Rel(e.Library.Path(), e.FilePath); err == nil {
+			return e.Library.Title + ": " + filepath.ToSlash(rel)
+		}
+		return e.FilePath
+	}
+	return ""
+}
+
+// ColumnCell implements unison.TableRowData.
+func (r *searchResultRow) ColumnCell(_, col int, foreground, _ unison.Ink, _, _, _ bool) unison.Paneler {
+	label := unison.NewLabel()
+	label.LabelTheme.OnBackgroundInk = foreground
+	label.Text = r.CellDataForSort(col)
+	if col == searchPointsColumn || col == searchCostColumn {
+		label.HAlign = unison.EndAlignment
+	}
+	return label
+}
+
+// IsOpen implements unison.TableRowData.
+func (r *searchResultRow) IsOpen() bool {
+	return false
+}
+
+// SetOpen implements unison.TableRowData.
+func (r *searchResultRow) SetOpen(_ bool) {
+}
+
+func searchKindTitle(kind string) string {
+	switch kind {
+	case gid.Trait:
+		return i18n.Text("Trait")
+	case gid.TraitModifier:
+		return i18n.Text("Trait Modifier")
+	case gid.Skill:
+		return i18n.Text("Skill")
+	case gid.Spell:
+		return i18n.Text("Spell")
+	case gid.Equipment:
+		return i18n.Text("Equipment")
+	case gid.EquipmentModifier:
+		return i18n.Text("Equipment Modifier")
+	case gid.Note:
+		return i18n.Text("Note")
+	case search.TemplateKind:
+		return i18n.Text("Template")
+	default:
+		return kind
+	}
+}
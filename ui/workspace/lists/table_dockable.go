@@ -205,6 +205,7 @@ func NewTableDockable[T gurps.NodeConstraint[T]](filePath, extension string, pro
 		func(_ any) { ntable.DuplicateSelection(d.table) })
 	d.InstallCmdHandlers(constants.CopyToSheetItemID, d.canCopySelectionToSheet, d.copySelectionToSheet)
 	d.InstallCmdHandlers(constants.CopyToTemplateItemID, d.canCopySelectionToTemplate, d.copySelectionToTemplate)
+	sheet.InstallUnitConversionHandlers(d, d.convertUnits)
 	for _, id := range canCreateIDs {
 		variant := ntable.ItemVariant(-1)
 		switch {
@@ -422,6 +423,23 @@ func (d *TableDockable[T]) Rebuild(_ bool) {
 	d.scroll.SetPosition(h, v)
 }
 
+func (d *TableDockable[T]) convertUnits(c *gurps.UnitConverter) {
+	switch data := any(d.provider.RootData()).(type) {
+	case []*gurps.Trait:
+		c.Traits(data)
+	case []*gurps.TraitModifier:
+		c.TraitModifiers(data)
+	case []*gurps.Skill:
+		c.Skills(data)
+	case []*gurps.Spell:
+		c.Spells(data)
+	case []*gurps.Equipment:
+		c.Equipment(data)
+	case []*gurps.EquipmentModifier:
+		c.EquipmentModifiers(data)
+	}
+}
+
 func (d *TableDockable[T]) crc64() uint64 {
 	var buffer bytes.Buffer
 	rows := d.provider.RootRows()
@@ -282,18 +282,7 @@ func (d *attributesDockable) apply() {
 	}
 	entity := d.owner.Entity()
 	entity.SheetSettings.Attributes = d.defs.Clone()
-	for attrID, def := range entity.SheetSettings.Attributes.Set {
-		if attr, exists := entity.Attributes.Set[attrID]; exists {
-			attr.Order = def.Order
-		} else {
-			entity.Attributes.Set[attrID] = gurps.NewAttribute(entity, attrID, def.Order)
-		}
-	}
-	for attrID := range entity.Attributes.Set {
-		if _, exists := d.defs.Set[attrID]; !exists {
-			delete(entity.Attributes.Set, attrID)
-		}
-	}
+	entity.SyncAttributesWithDefs()
 	for _, wnd := range unison.Windows() {
 		if ws := workspace.FromWindow(wnd); ws != nil {
 			ws.DocumentDock.RootDockLayout().ForEachDockContainer(func(dc *unison.DockContainer) bool {
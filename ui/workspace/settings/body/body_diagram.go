@@ -0,0 +1,181 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package body
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/xmath"
+	"github.com/richardwilkes/unison"
+)
+
+const (
+	diagramCellPadding = 4
+	diagramMinCellSize = 24
+)
+
+// bodyDiagram draws each table of a body type as a strip of roll values, with each hit location spanning the rolls it
+// covers. Rolls that aren't covered, or that the dice can't produce, are highlighted.
+type bodyDiagram struct {
+	unison.Panel
+	body func() *gurps.Body
+}
+
+type bodyDiagramRow struct {
+	title string
+	table *gurps.Body
+	low   int
+	high  int
+	last  int
+}
+
+func newBodyDiagram(body func() *gurps.Body) *bodyDiagram {
+	d := &bodyDiagram{body: body}
+	d.Self = d
+	d.SetBorder(unison.NewEmptyBorder(unison.Insets{Top: unison.StdVSpacing, Bottom: unison.StdVSpacing}))
+	d.SetSizer(d.sizer)
+	d.DrawCallback = d.draw
+	return d
+}
+
+func (d *bodyDiagram) rows() []*bodyDiagramRow {
+	var rows []*bodyDiagramRow
+	var collect func(title string, table *gurps.Body)
+	collect = func(title string, table *gurps.Body) {
+		row := &bodyDiagramRow{
+			title: title,
+			table: table,
+		}
+		if table.Roll != nil && table.Roll.Count > 0 && table.Roll.Sides > 0 {
+			row.low = table.Roll.Minimum(false)
+			row.high = table.Roll.Maximum(false)
+			slots := 0
+			for _, loc := range table.Locations {
+				slots += loc.Slots
+			}
+			row.last = xmath.Max(row.high, row.low+slots-1)
+		} else {
+			row.last = -1
+		}
+		rows = append(rows, row)
+		for _, loc := range table.Locations {
+			if loc.SubTable != nil {
+				collect(locationName(loc), loc.SubTable)
+			}
+		}
+	}
+	bodyType := d.body()
+	title := bodyType.Name
+	if title == "" {
+		title = i18n.Text("Body")
+	}
+	collect(title, bodyType)
+	return rows
+}
+
+func (d *bodyDiagram) metrics(rows []*bodyDiagramRow) (titleWidth, cellWidth, rowHeight float32) {
+	for _, row := range rows {
+		titleWidth = xmath.Max(titleWidth, unison.LabelFont.SimpleWidth(row.title))
+		if row.last >= row.low {
+			cellWidth = xmath.Max(cellWidth, unison.LabelFont.SimpleWidth(strconv.Itoa(row.last)))
+		}
+	}
+	cellWidth = xmath.Max(cellWidth+diagramCellPadding*2, diagramMinCellSize)
+	rowHeight = unison.LabelFont.LineHeight()*2 + diagramCellPadding*3
+	return titleWidth + unison.StdHSpacing, cellWidth, rowHeight
+}
+
+func (d *bodyDiagram) sizer(_ unison.Size) (min, pref, max unison.Size) {
+	rows := d.rows()
+	titleWidth, cellWidth, rowHeight := d.metrics(rows)
+	cells := 0
+	for _, row := range rows {
+		if row.last >= row.low {
+			cells = xmath.Max(cells, row.last-row.low+1)
+		}
+	}
+	pref.Width = titleWidth + cellWidth*float32(cells)
+	pref.Height = (rowHeight + unison.StdVSpacing) * float32(len(rows))
+	if border := d.Border(); border != nil {
+		insets := border.Insets()
+		pref.Width += insets.Left + insets.Right
+		pref.Height += insets.Top + insets.Bottom
+	}
+	return pref, pref, pref
+}
+
+func (d *bodyDiagram) draw(gc *unison.Canvas, rect unison.Rect) {
+	rows := d.rows()
+	titleWidth, cellWidth, rowHeight := d.metrics(rows)
+	r := d.ContentRect(false)
+	font := unison.LabelFont
+	textPaint := unison.OnContentColor.Paint(gc, rect, unison.Fill)
+	errorTextPaint := unison.OnErrorColor.Paint(gc, rect, unison.Fill)
+	linePaint := unison.DividerColor.Paint(gc, rect, unison.Stroke)
+	y := r.Y
+	for _, row := range rows {
+		gc.DrawSimpleString(row.title, r.X, y+diagramCellPadding+font.Baseline(), font, textPaint)
+		if row.last >= row.low {
+			covered := make(map[int]bool)
+			x := r.X + titleWidth
+			start := row.low
+			nameTop := y + font.LineHeight() + diagramCellPadding*2
+			for i, loc := range row.table.Locations {
+				if loc.Slots < 1 {
+					continue
+				}
+				for v := start; v < start+loc.Slots; v++ {
+					covered[v] = true
+				}
+				segment := unison.NewRect(x+cellWidth*float32(start-row.low), nameTop, cellWidth*float32(loc.Slots),
+					rowHeight-(nameTop-y))
+				color := unison.ContentColor
+				if i%2 == 0 {
+					color = unison.BandingColor
+				}
+				gc.DrawRect(segment, color.Paint(gc, segment, unison.Fill))
+				gc.DrawRect(segment, linePaint)
+				gc.Save()
+				gc.ClipRect(segment, unison.IntersectClipOp, false)
+				gc.DrawSimpleString(locationName(loc), segment.X+diagramCellPadding,
+					segment.Y+diagramCellPadding+font.Baseline(), font, textPaint)
+				gc.Restore()
+				start += loc.Slots
+			}
+			for v := row.low; v <= row.last; v++ {
+				cell := unison.NewRect(x+cellWidth*float32(v-row.low), y, cellWidth, nameTop-y)
+				paint := textPaint
+				if v > row.high || !covered[v] {
+					gc.DrawRect(cell, unison.ErrorColor.Paint(gc, cell, unison.Fill))
+					paint = errorTextPaint
+				} else {
+					gc.DrawRect(cell, unison.ControlColor.Paint(gc, cell, unison.Fill))
+				}
+				gc.DrawRect(cell, linePaint)
+				s := strconv.Itoa(v)
+				gc.DrawSimpleString(s, cell.X+(cell.Width-font.SimpleWidth(s))/2, cell.Y+diagramCellPadding+font.Baseline(),
+					font, paint)
+			}
+		}
+		y += rowHeight + unison.StdVSpacing
+	}
+}
+
+func locationName(loc *gurps.HitLocation) string {
+	if name := strings.TrimSpace(loc.TableName); name != "" {
+		return name
+	}
+	return loc.ChoiceName
+}
@@ -0,0 +1,387 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package body
+
+import (
+	"fmt"
+
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/validation"
+	"github.com/richardwilkes/gcs/v5/res"
+	"github.com/richardwilkes/gcs/v5/ui/widget"
+	"github.com/richardwilkes/rpgtools/dice"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/unison"
+)
+
+// bodyHost is implemented by the dockables that use a bodyEditor.
+type bodyHost interface {
+	unison.Paneler
+	UndoManager() *unison.UndoManager
+	Entity() *gurps.Entity
+	MarkModified()
+	body() *gurps.Body
+	applyBodyType(bodyType *gurps.Body)
+	sync()
+}
+
+// bodyEditor builds and maintains the content used to edit a body type.
+type bodyEditor struct {
+	host        bodyHost
+	targetMgr   *widget.TargetMgr
+	content     *unison.Panel
+	issuesPanel *unison.Panel
+	diagram     *bodyDiagram
+	rollLabels  map[*gurps.HitLocation]*unison.Label
+}
+
+func newBodyEditor(host bodyHost, targetMgr *widget.TargetMgr) *bodyEditor {
+	return &bodyEditor{
+		host:      host,
+		targetMgr: targetMgr,
+	}
+}
+
+func (e *bodyEditor) initContent(content *unison.Panel) {
+	e.content = content
+	content.SetLayout(&unison.FlexLayout{
+		Columns:  1,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	e.rebuild()
+}
+
+func (e *bodyEditor) rebuild() {
+	e.content.RemoveAllChildren()
+	e.rollLabels = make(map[*gurps.HitLocation]*unison.Label)
+	e.issuesPanel = unison.NewPanel()
+	e.issuesPanel.SetLayout(&unison.FlexLayout{
+		Columns:  1,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	e.issuesPanel.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+	e.content.AddChild(e.issuesPanel)
+	e.diagram = newBodyDiagram(e.host.body)
+	e.content.AddChild(e.diagram)
+	e.content.AddChild(e.createTablePanel(e.host.body(), true))
+	e.refresh()
+}
+
+// refresh recalculates the roll ranges and updates the validation results and diagram to match.
+func (e *bodyEditor) refresh() {
+	if e.issuesPanel == nil {
+		return
+	}
+	bodyType := e.host.body()
+	bodyType.Update(e.host.Entity())
+	e.issuesPanel.RemoveAllChildren()
+	issues := validation.ValidateBody(bodyType)
+	if len(issues) == 0 {
+		label := unison.NewLabel()
+		label.Text = i18n.Text("All rolls are covered by exactly one hit location.")
+		e.issuesPanel.AddChild(label)
+	}
+	for _, issue := range issues {
+		label := unison.NewLabel()
+		label.Text = issue.String()
+		if issue.Severity == validation.Error {
+			label.OnBackgroundInk = unison.ErrorColor
+		} else {
+			label.OnBackgroundInk = unison.WarningColor
+		}
+		e.issuesPanel.AddChild(label)
+	}
+	for loc, label := range e.rollLabels {
+		label.Text = rollRangeText(loc)
+	}
+	e.diagram.MarkForLayoutAndRedraw()
+	e.content.MarkForLayoutAndRedraw()
+}
+
+// edit makes a structural change to the body type as a single undoable action.
+func (e *bodyEditor) edit(name string, f func()) {
+	entity := e.host.Entity()
+	undo := &unison.UndoEdit[*gurps.Body]{
+		ID:         unison.NextUndoID(),
+		EditName:   name,
+		UndoFunc:   func(ed *unison.UndoEdit[*gurps.Body]) { e.host.applyBodyType(ed.BeforeData) },
+		RedoFunc:   func(ed *unison.UndoEdit[*gurps.Body]) { e.host.applyBodyType(ed.AfterData) },
+		AbsorbFunc: func(ed *unison.UndoEdit[*gurps.Body], other unison.Undoable) bool { return false },
+		BeforeData: e.host.body().Clone(entity, nil),
+	}
+	f()
+	undo.AfterData = e.host.body().Clone(entity, nil)
+	e.host.UndoManager().Add(undo)
+	e.host.sync()
+}
+
+func (e *bodyEditor) createTablePanel(table *gurps.Body, topLevel bool) *unison.Panel {
+	panel := unison.NewPanel()
+	panel.SetLayout(&unison.FlexLayout{
+		Columns:  1,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	panel.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+	if !topLevel {
+		panel.SetBorder(unison.NewLineBorder(unison.DividerColor, 0, unison.NewUniformInsets(1), false))
+	}
+
+	header := unison.NewPanel()
+	header.SetBorder(unison.NewEmptyBorder(unison.Insets{
+		Top:    unison.StdVSpacing,
+		Left:   unison.StdHSpacing,
+		Bottom: unison.StdVSpacing,
+		Right:  unison.StdHSpacing,
+	}))
+	header.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+	addButton := unison.NewSVGButton(res.CircledAddSVG)
+	addButton.Tooltip = unison.NewTooltipWithText(i18n.Text("Add Hit Location"))
+	addButton.ClickCallback = func() { e.insertLocation(table, len(table.Locations)) }
+	header.AddChild(addButton)
+	if topLevel {
+		text := i18n.Text("Name")
+		header.AddChild(widget.NewFieldLeadingLabel(text))
+		header.AddChild(widget.NewStringField(e.targetMgr, table.KeyPrefix+"name", text,
+			func() string { return table.Name },
+			func(s string) { table.Name = s }))
+	} else {
+		label := widget.NewFieldLeadingLabel(fmt.Sprintf(i18n.Text("Sub-table for %s"), locationName(table.OwningLocation())))
+		label.SetLayoutData(&unison.FlexLayoutData{HGrab: true})
+		header.AddChild(label)
+	}
+	text := i18n.Text("Roll")
+	header.AddChild(widget.NewFieldLeadingLabel(text))
+	rollField := widget.NewStringField(e.targetMgr, table.KeyPrefix+"roll", text,
+		func() string { return table.Roll.String() },
+		func(s string) { table.Roll = dice.New(s) })
+	rollField.SetMinimumTextWidthUsing("10d+10")
+	rollField.SetLayoutData(&unison.FlexLayoutData{HAlign: unison.FillAlignment})
+	rollField.Tooltip = unison.NewTooltipWithText(i18n.Text("The dice rolled to determine which location is hit"))
+	header.AddChild(rollField)
+	header.SetLayout(&unison.FlexLayout{
+		Columns:  len(header.Children()),
+		HSpacing: unison.StdHSpacing,
+	})
+	panel.AddChild(header)
+
+	for i, loc := range table.Locations {
+		panel.AddChild(e.createLocationPanel(table, loc, i))
+	}
+	return panel
+}
+
+func (e *bodyEditor) createLocationPanel(table *gurps.Body, loc *gurps.HitLocation, index int) *unison.Panel {
+	panel := unison.NewPanel()
+	panel.SetBorder(unison.NewEmptyBorder(unison.Insets{
+		Top:    unison.StdVSpacing,
+		Left:   unison.StdHSpacing,
+		Bottom: unison.StdVSpacing,
+		Right:  unison.StdHSpacing,
+	}))
+	panel.DrawCallback = func(gc *unison.Canvas, rect unison.Rect) {
+		color := unison.ContentColor
+		if index%2 == 0 {
+			color = unison.BandingColor
+		}
+		gc.DrawRect(rect, color.Paint(gc, rect, unison.Fill))
+	}
+	panel.SetLayout(&unison.FlexLayout{
+		Columns:  2,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	panel.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+
+	buttons := unison.NewPanel()
+	buttons.SetLayout(&unison.FlexLayout{
+		Columns:  1,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	buttons.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.MiddleAlignment,
+		VAlign: unison.StartAlignment,
+	})
+	deleteButton := unison.NewSVGButton(res.TrashSVG)
+	deleteButton.Tooltip = unison.NewTooltipWithText(i18n.Text("Remove Hit Location"))
+	deleteButton.ClickCallback = func() {
+		e.edit(i18n.Text("Remove Hit Location"), func() { table.RemoveLocation(loc) })
+	}
+	deleteButton.SetEnabled(len(table.Locations) > 1)
+	buttons.AddChild(deleteButton)
+	insertButton := unison.NewSVGButton(res.CircledAddSVG)
+	insertButton.Tooltip = unison.NewTooltipWithText(i18n.Text("Insert Hit Location After This One"))
+	insertButton.ClickCallback = func() { e.insertLocation(table, index+1) }
+	buttons.AddChild(insertButton)
+	subTableButton := unison.NewSVGButton(res.HierarchySVG)
+	if loc.SubTable == nil {
+		subTableButton.Tooltip = unison.NewTooltipWithText(i18n.Text("Add Sub-Table"))
+		subTableButton.ClickCallback = func() {
+			e.edit(i18n.Text("Add Sub-Table"), func() {
+				subTable := &gurps.Body{Roll: dice.New("1d")}
+				subTable.AddLocation(e.newLocation())
+				loc.SetSubTable(subTable)
+				subTable.ResetTargetKeyPrefixes(e.targetMgr.NextPrefix)
+			})
+		}
+	} else {
+		subTableButton.Tooltip = unison.NewTooltipWithText(i18n.Text("Remove Sub-Table"))
+		subTableButton.ClickCallback = func() {
+			e.edit(i18n.Text("Remove Sub-Table"), func() { loc.SetSubTable(nil) })
+		}
+	}
+	buttons.AddChild(subTableButton)
+	panel.AddChild(buttons)
+
+	fields := unison.NewPanel()
+	fields.SetLayout(&unison.FlexLayout{
+		Columns:  6,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	fields.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+
+	text := i18n.Text("ID")
+	fields.AddChild(widget.NewFieldLeadingLabel(text))
+	field := widget.NewStringField(e.targetMgr, loc.KeyPrefix+"id", text,
+		func() string { return loc.ID() },
+		func(s string) { loc.SetID(s) })
+	field.SetMinimumTextWidthUsing("right_leg")
+	field.Tooltip = unison.NewTooltipWithText(i18n.Text("A unique ID for the hit location"))
+	fields.AddChild(field)
+
+	text = i18n.Text("Choice Name")
+	fields.AddChild(widget.NewFieldLeadingLabel(text))
+	field = widget.NewStringField(e.targetMgr, loc.KeyPrefix+"choice", text,
+		func() string { return loc.ChoiceName },
+		func(s string) { loc.ChoiceName = s })
+	field.SetMinimumTextWidthUsing("Right Leg")
+	field.Tooltip = unison.NewTooltipWithText(i18n.Text("The name used when choosing this location, such as for armor coverage"))
+	fields.AddChild(field)
+
+	text = i18n.Text("Table Name")
+	fields.AddChild(widget.NewFieldLeadingLabel(text))
+	field = widget.NewStringField(e.targetMgr, loc.KeyPrefix+"table", text,
+		func() string { return loc.TableName },
+		func(s string) { loc.TableName = s })
+	field.SetMinimumTextWidthUsing("Right Leg")
+	field.Tooltip = unison.NewTooltipWithText(i18n.Text("The name shown in the hit location table"))
+	fields.AddChild(field)
+
+	text = i18n.Text("Slots")
+	fields.AddChild(widget.NewFieldLeadingLabel(text))
+	numField := widget.NewIntegerField(e.targetMgr, loc.KeyPrefix+"slots", text,
+		func() int { return loc.Slots },
+		func(v int) { loc.Slots = v },
+		0, 100, false, false)
+	numField.Tooltip = unison.NewTooltipWithText(i18n.Text("The number of consecutive rolls that hit this location"))
+	fields.AddChild(e.wrapWithRollRange(numField, loc))
+
+	text = i18n.Text("Hit Penalty")
+	fields.AddChild(widget.NewFieldLeadingLabel(text))
+	fields.AddChild(widget.NewIntegerField(e.targetMgr, loc.KeyPrefix+"penalty", text,
+		func() int { return loc.HitPenalty },
+		func(v int) { loc.HitPenalty = v },
+		-100, 100, true, false))
+
+	text = i18n.Text("DR Bonus")
+	fields.AddChild(widget.NewFieldLeadingLabel(text))
+	fields.AddChild(widget.NewIntegerField(e.targetMgr, loc.KeyPrefix+"dr", text,
+		func() int { return loc.DRBonus },
+		func(v int) { loc.DRBonus = v },
+		0, 100, false, false))
+
+	text = i18n.Text("Description")
+	fields.AddChild(widget.NewFieldLeadingLabel(text))
+	field = widget.NewMultiLineStringField(e.targetMgr, loc.KeyPrefix+"desc", text,
+		func() string { return loc.Description },
+		func(s string) { loc.Description = s })
+	field.SetLayoutData(&unison.FlexLayoutData{
+		HSpan:  5,
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+	fields.AddChild(field)
+
+	if loc.SubTable != nil {
+		subTable := e.createTablePanel(loc.SubTable, false)
+		subTable.SetLayoutData(&unison.FlexLayoutData{
+			HSpan:  6,
+			HAlign: unison.FillAlignment,
+			HGrab:  true,
+		})
+		fields.AddChild(subTable)
+	}
+	panel.AddChild(fields)
+	return panel
+}
+
+// wrapWithRollRange places a label showing the rolls that hit the location after the field. The label is updated by
+// refresh().
+func (e *bodyEditor) wrapWithRollRange(field unison.Paneler, loc *gurps.HitLocation) *unison.Panel {
+	wrapper := unison.NewPanel()
+	wrapper.SetLayout(&unison.FlexLayout{
+		Columns:  2,
+		HSpacing: unison.StdHSpacing,
+	})
+	wrapper.AddChild(field)
+	label := unison.NewLabel()
+	label.Text = rollRangeText(loc)
+	e.rollLabels[loc] = label
+	wrapper.AddChild(label)
+	return wrapper
+}
+
+func rollRangeText(loc *gurps.HitLocation) string {
+	return fmt.Sprintf(i18n.Text("Rolls: %s"), loc.RollRange)
+}
+
+func (e *bodyEditor) insertLocation(table *gurps.Body, index int) {
+	e.edit(i18n.Text("Add Hit Location"), func() { table.InsertLocation(index, e.newLocation()) })
+}
+
+func (e *bodyEditor) newLocation() *gurps.HitLocation {
+	root := e.host.body()
+	root.Update(e.host.Entity())
+	loc := &gurps.HitLocation{
+		HitLocationData: gurps.HitLocationData{
+			ChoiceName: i18n.Text("New Location"),
+			TableName:  i18n.Text("New Location"),
+			Slots:      1,
+		},
+		KeyPrefix: e.targetMgr.NextPrefix(),
+	}
+	base := "location"
+	loc.SetID(base)
+	for i := 2; root.LookupLocationByID(e.host.Entity(), loc.ID()) != nil; i++ {
+		loc.SetID(fmt.Sprintf("%s%d", base, i))
+	}
+	return loc
+}
@@ -0,0 +1,245 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package body
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/richardwilkes/gcs/v5/constants"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/gcs/v5/model/settings"
+	"github.com/richardwilkes/gcs/v5/res"
+	"github.com/richardwilkes/gcs/v5/ui/widget"
+	"github.com/richardwilkes/gcs/v5/ui/workspace"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+	"github.com/richardwilkes/toolbox/xio/fs"
+	"github.com/richardwilkes/unison"
+)
+
+var (
+	_ workspace.FileBackedDockable = &FileDockable{}
+	_ unison.UndoManagerProvider   = &FileDockable{}
+	_ widget.ModifiableRoot        = &FileDockable{}
+	_ unison.TabCloser             = &FileDockable{}
+	_ bodyHost                     = &FileDockable{}
+)
+
+// FileDockable holds the view for a body type file.
+type FileDockable struct {
+	unison.Panel
+	path              string
+	targetMgr         *widget.TargetMgr
+	undoMgr           *unison.UndoManager
+	bodyType          *gurps.Body
+	editor            *bodyEditor
+	content           *unison.Panel
+	toolbar           *unison.Panel
+	crc               uint64
+	needsSaveAsPrompt bool
+}
+
+// NewFileDockableFromFile loads a body type file and creates a new unison.Dockable for it.
+func NewFileDockableFromFile(filePath string) (unison.Dockable, error) {
+	bodyType, err := gurps.NewBodyFromFile(os.DirFS(filepath.Dir(filePath)), filepath.Base(filePath))
+	if err != nil {
+		return nil, err
+	}
+	d := NewFileDockable(filePath, bodyType)
+	d.needsSaveAsPrompt = false
+	return d, nil
+}
+
+// NewFileDockable creates a new unison.Dockable for body type files.
+func NewFileDockable(filePath string, bodyType *gurps.Body) *FileDockable {
+	d := &FileDockable{
+		path:              filePath,
+		undoMgr:           unison.NewUndoManager(100, func(err error) { jot.Error(err) }),
+		bodyType:          bodyType,
+		crc:               bodyType.CRC64(),
+		needsSaveAsPrompt: true,
+	}
+	d.Self = d
+	d.targetMgr = widget.NewTargetMgr(d)
+	d.editor = newBodyEditor(d, d.targetMgr)
+	d.bodyType.ResetTargetKeyPrefixes(d.targetMgr.NextPrefix)
+	d.SetLayout(&unison.FlexLayout{Columns: 1})
+
+	d.toolbar = unison.NewPanel()
+	d.toolbar.SetBorder(unison.NewCompoundBorder(unison.NewLineBorder(unison.DividerColor, 0, unison.Insets{Bottom: 1},
+		false), unison.NewEmptyBorder(unison.StdInsets())))
+	d.toolbar.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+	useAsDefaultButton := unison.NewSVGButton(res.BodyTypeSVG)
+	useAsDefaultButton.Tooltip = unison.NewTooltipWithText(i18n.Text("Use as the default body type for new sheets"))
+	useAsDefaultButton.ClickCallback = d.useAsDefault
+	d.toolbar.AddChild(useAsDefaultButton)
+	d.toolbar.SetLayout(&unison.FlexLayout{
+		Columns:  len(d.toolbar.Children()),
+		HSpacing: unison.StdHSpacing,
+	})
+	d.AddChild(d.toolbar)
+
+	d.content = unison.NewPanel()
+	d.content.SetBorder(unison.NewEmptyBorder(unison.NewUniformInsets(unison.StdHSpacing * 2)))
+	d.editor.initContent(d.content)
+	scroller := unison.NewScrollPanel()
+	scroller.SetContent(d.content, unison.FillBehavior, unison.FillBehavior)
+	scroller.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		VAlign: unison.FillAlignment,
+		HGrab:  true,
+		VGrab:  true,
+	})
+	d.AddChild(scroller)
+
+	d.InstallCmdHandlers(constants.SaveItemID, func(_ any) bool { return d.Modified() }, func(_ any) { d.save(false) })
+	d.InstallCmdHandlers(constants.SaveAsItemID, unison.AlwaysEnabled, func(_ any) { d.save(true) })
+	return d
+}
+
+func (d *FileDockable) useAsDefault() {
+	if unison.QuestionDialog(fmt.Sprintf(i18n.Text("Use %s as the default body type?"), d.Title()),
+		i18n.Text("Existing sheets are not affected.")) == unison.ModalResponseOK {
+		d.Window().FocusNext() // Intentionally move the focus to ensure any pending edits are flushed
+		settings.Global().Sheet.BodyType = d.bodyType.Clone(nil, nil)
+	}
+}
+
+// Entity implements bodyHost. Body type files are not associated with a character, so this always returns nil.
+func (d *FileDockable) Entity() *gurps.Entity {
+	return nil
+}
+
+func (d *FileDockable) body() *gurps.Body {
+	return d.bodyType
+}
+
+func (d *FileDockable) applyBodyType(bodyType *gurps.Body) {
+	d.bodyType = bodyType.Clone(nil, nil)
+	d.sync()
+}
+
+func (d *FileDockable) sync() {
+	var focusRefKey string
+	if focus := d.Window().Focus(); unison.AncestorOrSelf[*FileDockable](focus) == d {
+		focusRefKey = focus.RefKey
+	}
+	scrollRoot := d.content.ScrollRoot()
+	h, v := scrollRoot.Position()
+	d.editor.rebuild()
+	d.MarkForLayoutAndRedraw()
+	d.ValidateLayout()
+	d.MarkModified()
+	if focusRefKey != "" {
+		if focus := d.targetMgr.Find(focusRefKey); focus != nil {
+			focus.RequestFocus()
+		} else {
+			widget.FocusFirstContent(d.toolbar, d.content)
+		}
+	}
+	scrollRoot.SetPosition(h, v)
+}
+
+// UndoManager implements undo.Provider
+func (d *FileDockable) UndoManager() *unison.UndoManager {
+	return d.undoMgr
+}
+
+// TitleIcon implements workspace.FileBackedDockable
+func (d *FileDockable) TitleIcon(suggestedSize unison.Size) unison.Drawable {
+	return &unison.DrawableSVG{
+		SVG:  library.FileInfoFor(d.path).SVG,
+		Size: suggestedSize,
+	}
+}
+
+// Title implements workspace.FileBackedDockable
+func (d *FileDockable) Title() string {
+	return fs.BaseName(d.path)
+}
+
+func (d *FileDockable) String() string {
+	return d.Title()
+}
+
+// Tooltip implements workspace.FileBackedDockable
+func (d *FileDockable) Tooltip() string {
+	return d.path
+}
+
+// BackingFilePath implements workspace.FileBackedDockable
+func (d *FileDockable) BackingFilePath() string {
+	return d.path
+}
+
+// Modified implements workspace.FileBackedDockable
+func (d *FileDockable) Modified() bool {
+	return d.crc != d.bodyType.CRC64()
+}
+
+// MarkModified implements widget.ModifiableRoot.
+func (d *FileDockable) MarkModified() {
+	d.editor.refresh()
+	if dc := unison.Ancestor[*unison.DockContainer](d); dc != nil {
+		dc.UpdateTitle(d)
+	}
+	widget.DeepSync(d)
+}
+
+// MayAttemptClose implements unison.TabCloser
+func (d *FileDockable) MayAttemptClose() bool {
+	return workspace.MayAttemptCloseOfGroup(d)
+}
+
+// AttemptClose implements unison.TabCloser
+func (d *FileDockable) AttemptClose() bool {
+	if !workspace.CloseGroup(d) {
+		return false
+	}
+	if d.Modified() {
+		switch unison.YesNoCancelDialog(fmt.Sprintf(i18n.Text("Save changes made to\n%s?"), d.Title()), "") {
+		case unison.ModalResponseDiscard:
+		case unison.ModalResponseOK:
+			if !d.save(false) {
+				return false
+			}
+		case unison.ModalResponseCancel:
+			return false
+		}
+	}
+	if dc := unison.Ancestor[*unison.DockContainer](d); dc != nil {
+		dc.Close(d)
+	}
+	return true
+}
+
+func (d *FileDockable) save(forceSaveAs bool) bool {
+	success := false
+	if forceSaveAs || d.needsSaveAsPrompt {
+		success = workspace.SaveDockableAs(d, library.BodyExt, d.bodyType.Save, func(path string) {
+			d.crc = d.bodyType.CRC64()
+			d.path = path
+		})
+	} else {
+		success = workspace.SaveDockable(d, d.bodyType.Save, func() { d.crc = d.bodyType.CRC64() })
+	}
+	if success {
+		d.needsSaveAsPrompt = false
+	}
+	return success
+}
@@ -5,6 +5,7 @@ import (
 	"io/fs"
 
 	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/library"
 	"github.com/richardwilkes/gcs/v5/model/settings"
 	"github.com/richardwilkes/gcs/v5/res"
 	"github.com/richardwilkes/gcs/v5/ui/widget"
@@ -15,7 +16,10 @@ import (
 	"github.com/richardwilkes/unison"
 )
 
-var _ widget.GroupedCloser = &bodyDockable{}
+var (
+	_ widget.GroupedCloser = &bodyDockable{}
+	_ bodyHost             = &bodyDockable{}
+)
 
 type bodyDockable struct {
 	settings2.Dockable
@@ -23,6 +27,7 @@ type bodyDockable struct {
 	targetMgr     *widget.TargetMgr
 	undoMgr       *unison.UndoManager
 	bodyType      *gurps.Body
+	editor        *bodyEditor
 	originalCRC   uint64
 	toolbar       *unison.Panel
 	content       *unison.Panel
@@ -46,6 +51,7 @@ func ShowBodySettings(owner widget.EntityPanel) {
 		}
 		d.Self = d
 		d.targetMgr = widget.NewTargetMgr(d)
+		d.editor = newBodyEditor(d, d.targetMgr)
 		if owner != nil {
 			entity := d.owner.Entity()
 			d.bodyType = entity.SheetSettings.BodyType.Clone(entity, nil)
@@ -57,7 +63,7 @@ func ShowBodySettings(owner widget.EntityPanel) {
 		d.TabIcon = res.BodyTypeSVG
 		d.bodyType.ResetTargetKeyPrefixes(d.targetMgr.NextPrefix)
 		d.originalCRC = d.bodyType.CRC64()
-		d.Extensions = []string{".body", ".ghl"}
+		d.Extensions = []string{library.BodyExt, ".ghl"}
 		d.undoMgr = unison.NewUndoManager(100, func(err error) { jot.Error(err) })
 		d.Loader = d.load
 		d.Saver = d.save
@@ -72,6 +78,12 @@ func (d *bodyDockable) UndoManager() *unison.UndoManager {
 	return d.undoMgr
 }
 
+// MarkModified implements widget.ModifiableRoot
+func (d *bodyDockable) MarkModified() {
+	d.editor.refresh()
+	d.Dockable.MarkModified()
+}
+
 func (d *bodyDockable) modified() bool {
 	modified := d.originalCRC != d.bodyType.CRC64()
 	d.applyButton.SetEnabled(modified)
@@ -120,9 +132,7 @@ func (d *bodyDockable) addToStartToolbar(toolbar *unison.Panel) {
 
 func (d *bodyDockable) initContent(content *unison.Panel) {
 	d.content = content
-	content.SetBorder(nil)
-	content.SetLayout(&unison.FlexLayout{Columns: 1})
-	// TODO: Insert content here
+	d.editor.initContent(content)
 }
 
 func (d *bodyDockable) Entity() *gurps.Entity {
@@ -132,6 +142,10 @@ func (d *bodyDockable) Entity() *gurps.Entity {
 	return nil
 }
 
+func (d *bodyDockable) body() *gurps.Body {
+	return d.bodyType
+}
+
 func (d *bodyDockable) applyBodyType(bodyType *gurps.Body) {
 	d.bodyType = bodyType.Clone(d.Entity(), nil)
 	d.sync()
@@ -148,9 +162,9 @@ func (d *bodyDockable) reset() {
 		BeforeData: d.bodyType.Clone(entity, nil),
 	}
 	if d.owner != nil {
-		entity.SheetSettings.BodyType = settings.Global().Sheet.BodyType.Clone(entity, nil)
+		d.bodyType = settings.Global().Sheet.BodyType.Clone(entity, nil)
 	} else {
-		settings.Global().Sheet.BodyType = gurps.FactoryBody()
+		d.bodyType = gurps.FactoryBody()
 	}
 	d.bodyType.ResetTargetKeyPrefixes(d.targetMgr.NextPrefix)
 	undo.AfterData = d.bodyType.Clone(entity, nil)
@@ -165,8 +179,7 @@ func (d *bodyDockable) sync() {
 	}
 	scrollRoot := d.content.ScrollRoot()
 	h, v := scrollRoot.Position()
-	d.content.RemoveAllChildren()
-	// TODO: Rebuild display here
+	d.editor.rebuild()
 	d.MarkForLayoutAndRedraw()
 	d.ValidateLayout()
 	d.MarkModified()
@@ -0,0 +1,208 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package settings
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/measure"
+	"github.com/richardwilkes/gcs/v5/res"
+	"github.com/richardwilkes/gcs/v5/ui/widget"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/unison"
+)
+
+// baseCurrency stands in for the base currency, which isn't part of the configurable list.
+var baseCurrency = &gurps.Currency{Name: "$"}
+
+func (d *sheetSettingsDockable) createCurrencies(content *unison.Panel) {
+	panel := unison.NewPanel()
+	panel.SetLayout(&unison.FlexLayout{
+		Columns:  2,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	panel.SetLayoutData(&unison.FlexLayoutData{HAlign: unison.FillAlignment})
+	d.createHeader(panel, i18n.Text("Currencies"), 2)
+	label := widget.NewFieldLeadingLabel(i18n.Text("Display Wealth In"))
+	label.Tooltip = unison.NewTooltipWithText(i18n.Text("The currency used for extended values and wealth totals"))
+	panel.AddChild(label)
+	d.displayCurrencyPopup = unison.NewPopupMenu[*gurps.Currency]()
+	d.displayCurrencyPopup.SelectionCallback = func(_ int, item *gurps.Currency) {
+		d.settings().DisplayCurrency = item.ID
+		d.syncSheet(false)
+	}
+	panel.AddChild(d.displayCurrencyPopup)
+	d.currenciesPanel = unison.NewPanel()
+	d.currenciesPanel.SetLayout(&unison.FlexLayout{
+		Columns:  7,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	d.currenciesPanel.SetLayoutData(&unison.FlexLayoutData{HSpan: 2})
+	panel.AddChild(d.currenciesPanel)
+	addButton := unison.NewSVGButton(res.CircledAddSVG)
+	addButton.Tooltip = unison.NewTooltipWithText(i18n.Text("Add a currency"))
+	addButton.ClickCallback = d.addCurrency
+	addButton.SetLayoutData(&unison.FlexLayoutData{HSpan: 2})
+	panel.AddChild(addButton)
+	d.rebuildCurrencies()
+	content.AddChild(panel)
+}
+
+func (d *sheetSettingsDockable) rebuildCurrencies() {
+	s := d.settings()
+	d.displayCurrencyPopup.RemoveAllItems()
+	d.displayCurrencyPopup.AddItem(baseCurrency)
+	selected := baseCurrency
+	for _, one := range s.Currencies {
+		d.displayCurrencyPopup.AddItem(one)
+		if strings.EqualFold(one.ID, s.DisplayCurrency) {
+			selected = one
+		}
+	}
+	d.displayCurrencyPopup.Select(selected)
+
+	d.currenciesPanel.RemoveAllChildren()
+	if len(s.Currencies) != 0 {
+		for _, title := range []string{
+			i18n.Text("ID"),
+			i18n.Text("Name"),
+			i18n.Text("Symbol"),
+			"",
+			i18n.Text("Worth in $"),
+			i18n.Text("Coin Weight"),
+			"",
+		} {
+			d.currenciesPanel.AddChild(widget.NewFieldTrailingLabel(title))
+		}
+	}
+	for _, one := range s.Currencies {
+		d.createCurrencyRow(one)
+	}
+	d.MarkForLayoutAndRedraw()
+}
+
+func (d *sheetSettingsDockable) createCurrencyRow(c *gurps.Currency) {
+	field := widget.NewStringField(nil, "", i18n.Text("Currency ID"),
+		func() string { return c.ID },
+		func(value string) {
+			d.renameCurrency(c, strings.TrimSpace(value))
+			d.syncSheet(false)
+		})
+	field.SetMinimumTextWidthUsing("credits")
+	field.Tooltip = unison.NewTooltipWithText(i18n.Text("The key equipment uses to refer to this currency"))
+	d.currenciesPanel.AddChild(field)
+
+	field = widget.NewStringField(nil, "", i18n.Text("Currency Name"),
+		func() string { return c.Name },
+		func(value string) {
+			c.Name = value
+			d.displayCurrencyPopup.MarkForRedraw()
+		})
+	field.SetMinimumTextWidthUsing("Silver Pieces")
+	d.currenciesPanel.AddChild(field)
+
+	field = widget.NewStringField(nil, "", i18n.Text("Currency Symbol"),
+		func() string { return c.Symbol },
+		func(value string) {
+			c.Symbol = value
+			d.syncSheet(false)
+		})
+	field.SetMinimumTextWidthUsing("Cr")
+	d.currenciesPanel.AddChild(field)
+
+	d.currenciesPanel.AddChild(widget.NewCheckBox(nil, "", i18n.Text("Symbol after value"),
+		func() unison.CheckState { return unison.CheckStateFromBool(c.SymbolAfter) },
+		func(state unison.CheckState) {
+			c.SymbolAfter = state == unison.OnCheckState
+			d.syncSheet(false)
+		}))
+
+	d.currenciesPanel.AddChild(widget.NewDecimalField(nil, "", i18n.Text("Currency Rate"),
+		func() fxp.Int { return c.Rate },
+		func(value fxp.Int) {
+			c.Rate = value
+			d.syncSheet(false)
+		}, fxp.FromStringForced("0.0001"), fxp.Max, false, false))
+
+	weightField := widget.NewWeightField(nil, "", i18n.Text("Coin Weight"), d.entity(),
+		func() measure.Weight { return c.CoinWeight },
+		func(value measure.Weight) {
+			c.CoinWeight = value
+			d.syncSheet(false)
+		}, 0, measure.Weight(fxp.Max), false)
+	weightField.Tooltip = unison.NewTooltipWithText(i18n.Text("The weight of a single coin, used by equipment marked as coins"))
+	d.currenciesPanel.AddChild(weightField)
+
+	deleteButton := unison.NewSVGButton(res.TrashSVG)
+	deleteButton.Tooltip = unison.NewTooltipWithText(i18n.Text("Remove this currency"))
+	deleteButton.ClickCallback = func() { d.removeCurrency(c) }
+	d.currenciesPanel.AddChild(deleteButton)
+}
+
+func (d *sheetSettingsDockable) entity() *gurps.Entity {
+	if d.owner != nil {
+		return d.owner.Entity()
+	}
+	return nil
+}
+
+// renameCurrency changes the ID of the currency, updating the display currency and, for a sheet, any equipment that
+// refers to it.
+func (d *sheetSettingsDockable) renameCurrency(c *gurps.Currency, id string) {
+	if c.ID == "" {
+		c.ID = id
+		return
+	}
+	s := d.settings()
+	if strings.EqualFold(s.DisplayCurrency, c.ID) {
+		s.DisplayCurrency = id
+	}
+	if entity := d.entity(); entity != nil {
+		rename := func(eqp *gurps.Equipment) bool {
+			if strings.EqualFold(eqp.Currency, c.ID) {
+				eqp.Currency = id
+			}
+			return false
+		}
+		gurps.Traverse(rename, false, false, entity.CarriedEquipment...)
+		gurps.Traverse(rename, false, false, entity.OtherEquipment...)
+	}
+	c.ID = id
+}
+
+func (d *sheetSettingsDockable) addCurrency() {
+	s := d.settings()
+	id := "c"
+	for i := 2; s.Currency(id) != nil; i++ {
+		id = fmt.Sprintf("c%d", i)
+	}
+	s.Currencies = append(s.Currencies, gurps.NewCurrency(id))
+	d.rebuildCurrencies()
+	d.syncSheet(false)
+}
+
+func (d *sheetSettingsDockable) removeCurrency(c *gurps.Currency) {
+	s := d.settings()
+	for i, one := range s.Currencies {
+		if one == c {
+			s.Currencies = append(s.Currencies[:i], s.Currencies[i+1:]...)
+			break
+		}
+	}
+	d.rebuildCurrencies()
+	d.syncSheet(false)
+}
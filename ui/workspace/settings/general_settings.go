@@ -32,6 +32,8 @@ type generalSettingsDockable struct {
 	autoFillProfileCheckbox             *widget.CheckBox
 	autoAddNaturalAttacksCheckbox       *widget.CheckBox
 	pointsField                         *widget.DecimalField
+	disadvantageLimitField              *widget.DecimalField
+	quirkLimitField                     *widget.DecimalField
 	includeUnspentPointsInTotalCheckbox *widget.CheckBox
 	techLevelField                      *widget.StringField
 	calendarPopup                       *unison.PopupMenu[string]
@@ -40,6 +42,18 @@ type generalSettingsDockable struct {
 	exportResolutionField               *widget.IntegerField
 	tooltipDelayField                   *widget.DecimalField
 	tooltipDismissalField               *widget.DecimalField
+	apiServerEnabledCheckbox            *widget.CheckBox
+	apiServerPortField                  *widget.IntegerField
+	apiServerTokenField                 *widget.StringField
+}
+
+// APIServerSettingsChanged is called, if set, after the settings for the local API server have been changed.
+var APIServerSettingsChanged func()
+
+func notifyAPIServerSettingsChanged() {
+	if APIServerSettingsChanged != nil {
+		APIServerSettingsChanged()
+	}
 }
 
 // ShowGeneralSettings the General Settings window.
@@ -71,6 +85,7 @@ func (d *generalSettingsDockable) initContent(content *unison.Panel) {
 	d.createPlayerAndDescFields(content)
 	d.createCheckboxBlock(content)
 	d.createInitialPointsFields(content)
+	d.createPointLimitFields(content)
 	d.createTechLevelField(content)
 	d.createCalendarPopup(content)
 	initialListScaleTitle := i18n.Text("Initial List Scale")
@@ -90,6 +105,7 @@ func (d *generalSettingsDockable) initContent(content *unison.Panel) {
 	d.createImageResolutionField(content)
 	d.createTooltipDelayField(content)
 	d.createTooltipDismissalField(content)
+	d.createAPIServerFields(content)
 }
 
 func (d *generalSettingsDockable) createPlayerAndDescFields(content *unison.Panel) {
@@ -150,6 +166,26 @@ func (d *generalSettingsDockable) createInitialPointsFields(content *unison.Pane
 	content.AddChild(d.pointsField)
 }
 
+func (d *generalSettingsDockable) createPointLimitFields(content *unison.Panel) {
+	tooltip := i18n.Text("The most points a character may take in this category before the sheet is flagged. Zero means no limit.")
+	title := i18n.Text("Disadvantage Limit")
+	content.AddChild(widget.NewFieldLeadingLabel(title))
+	d.disadvantageLimitField = widget.NewDecimalField(nil, "", title,
+		func() fxp.Int { return settings.Global().General.DisadvantageLimit },
+		func(v fxp.Int) { settings.Global().General.DisadvantageLimit = v }, gsettings.DisadvantageLimitMin,
+		gsettings.DisadvantageLimitMax, false, false)
+	d.disadvantageLimitField.Tooltip = unison.NewTooltipWithText(tooltip)
+	content.AddChild(widget.WrapWithSpan(2, d.disadvantageLimitField, widget.NewFieldTrailingLabel(i18n.Text("points"))))
+	title = i18n.Text("Quirk Limit")
+	content.AddChild(widget.NewFieldLeadingLabel(title))
+	d.quirkLimitField = widget.NewDecimalField(nil, "", title,
+		func() fxp.Int { return settings.Global().General.QuirkLimit },
+		func(v fxp.Int) { settings.Global().General.QuirkLimit = v }, gsettings.QuirkLimitMin,
+		gsettings.QuirkLimitMax, false, false)
+	d.quirkLimitField.Tooltip = unison.NewTooltipWithText(tooltip)
+	content.AddChild(widget.WrapWithSpan(2, d.quirkLimitField, widget.NewFieldTrailingLabel(i18n.Text("points"))))
+}
+
 func (d *generalSettingsDockable) createTechLevelField(content *unison.Panel) {
 	title := i18n.Text("Default Tech Level")
 	content.AddChild(widget.NewFieldLeadingLabel(title))
@@ -216,9 +252,64 @@ func (d *generalSettingsDockable) createTooltipDismissalField(content *unison.Pa
 	content.AddChild(widget.WrapWithSpan(2, d.tooltipDismissalField, widget.NewFieldTrailingLabel(i18n.Text("seconds"))))
 }
 
+func (d *generalSettingsDockable) createAPIServerFields(content *unison.Panel) {
+	d.apiServerEnabledCheckbox = widget.NewCheckBox(nil, "", i18n.Text("Enable the local API server"),
+		func() unison.CheckState {
+			return unison.CheckStateFromBool(settings.Global().General.APIServerEnabled)
+		},
+		func(state unison.CheckState) {
+			settings.Global().General.APIServerEnabled = state == unison.OnCheckState
+			notifyAPIServerSettingsChanged()
+		})
+	d.apiServerEnabledCheckbox.Tooltip = unison.NewTooltipWithText(i18n.Text(`Allows other programs on this computer to
+read the sheets open in the workspace and make a few changes to them`))
+	d.apiServerEnabledCheckbox.SetLayoutData(&unison.FlexLayoutData{HSpan: 2})
+	content.AddChild(widget.NewFieldLeadingLabel(""))
+	content.AddChild(d.apiServerEnabledCheckbox)
+
+	title := i18n.Text("API Server Port")
+	content.AddChild(widget.NewFieldLeadingLabel(title))
+	d.apiServerPortField = widget.NewIntegerField(nil, "", title,
+		func() int { return settings.Global().General.APIServerPort },
+		func(v int) {
+			settings.Global().General.APIServerPort = v
+			notifyAPIServerSettingsChanged()
+		}, gsettings.APIServerPortMin, gsettings.APIServerPortMax, false, false)
+	d.apiServerPortField.SetLayoutData(&unison.FlexLayoutData{HSpan: 2})
+	content.AddChild(d.apiServerPortField)
+
+	title = i18n.Text("API Server Token")
+	content.AddChild(widget.NewFieldLeadingLabel(title))
+	d.apiServerTokenField = widget.NewStringField(nil, "", title,
+		func() string { return settings.Global().General.APIServerToken },
+		func(s string) {
+			settings.Global().General.APIServerToken = s
+			notifyAPIServerSettingsChanged()
+		})
+	d.apiServerTokenField.Tooltip = unison.NewTooltipWithText(i18n.Text("Clients must present this token with each request"))
+	d.apiServerTokenField.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+	content.AddChild(d.apiServerTokenField)
+	newTokenButton := unison.NewButton()
+	newTokenButton.Text = i18n.Text("New Token")
+	newTokenButton.ClickCallback = func() {
+		token := gsettings.NewAPIServerToken()
+		settings.Global().General.APIServerToken = token
+		d.apiServerTokenField.SetText(token)
+		notifyAPIServerSettingsChanged()
+	}
+	content.AddChild(newTokenButton)
+}
+
 func (d *generalSettingsDockable) reset() {
-	*settings.Global().General = *gsettings.NewGeneral()
+	general := settings.Global().General
+	token := general.APIServerToken
+	*general = *gsettings.NewGeneral()
+	general.APIServerToken = token
 	d.sync()
+	notifyAPIServerSettingsChanged()
 }
 
 func (d *generalSettingsDockable) sync() {
@@ -228,6 +319,8 @@ func (d *generalSettingsDockable) sync() {
 	widget.SetCheckBoxState(d.autoAddNaturalAttacksCheckbox, s.AutoAddNaturalAttacks)
 	d.pointsField.SetText(s.InitialPoints.String())
 	widget.SetCheckBoxState(d.includeUnspentPointsInTotalCheckbox, s.IncludeUnspentPointsInTotal)
+	d.disadvantageLimitField.SetText(s.DisadvantageLimit.String())
+	d.quirkLimitField.SetText(s.QuirkLimit.String())
 	d.techLevelField.SetText(s.DefaultTechLevel)
 	d.calendarPopup.Select(s.CalendarRef(settings.Global().Libraries()).Name)
 	widget.SetFieldValue(d.initialListScaleField.Field, d.initialListScaleField.Format(s.InitialListUIScale))
@@ -235,6 +328,9 @@ func (d *generalSettingsDockable) sync() {
 	d.exportResolutionField.SetText(strconv.Itoa(s.ImageResolution))
 	d.tooltipDelayField.SetText(s.TooltipDelay.String())
 	d.tooltipDismissalField.SetText(s.TooltipDismissal.String())
+	widget.SetCheckBoxState(d.apiServerEnabledCheckbox, s.APIServerEnabled)
+	d.apiServerPortField.SetText(strconv.Itoa(s.APIServerPort))
+	d.apiServerTokenField.SetText(s.APIServerToken)
 	d.MarkForRedraw()
 }
 
@@ -243,8 +339,11 @@ func (d *generalSettingsDockable) load(fileSystem fs.FS, filePath string) error
 	if err != nil {
 		return err
 	}
-	*settings.Global().General = *s
+	general := settings.Global().General
+	s.APIServerToken = general.APIServerToken // Not part of the settings file
+	*general = *s
 	d.sync()
+	notifyAPIServerSettingsChanged()
 	return nil
 }
 
@@ -0,0 +1,135 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package settings
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/gcs/v5/ui/widget"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+	"github.com/richardwilkes/unison"
+)
+
+type releaseSourceChoice struct {
+	title   string
+	kind    library.ReleaseSourceKind
+	tooltip string
+}
+
+func (c *releaseSourceChoice) String() string {
+	return c.title
+}
+
+// ShowReleaseSourceDialog allows the user to choose where the releases of the library come from.
+func ShowReleaseSourceDialog(lib *library.Library) {
+	choices := []*releaseSourceChoice{
+		{title: i18n.Text("Default")},
+		{
+			title:   i18n.Text("GitHub Repo"),
+			kind:    library.GitHubReleaseSource,
+			tooltip: i18n.Text("The account and repo names, e.g. richardwilkes/gcs_master_library"),
+		},
+		{
+			title:   i18n.Text("Folder"),
+			kind:    library.FolderReleaseSource,
+			tooltip: i18n.Text("The path to a folder holding the library and a release.txt file"),
+		},
+		{
+			title:   i18n.Text("Zip File"),
+			kind:    library.ZipReleaseSource,
+			tooltip: i18n.Text("The path to a zip file holding the library and a release.txt file"),
+		},
+		{
+			title:   i18n.Text("Release Index URL"),
+			kind:    library.HTTPReleaseSource,
+			tooltip: i18n.Text("The URL of a JSON release index"),
+		},
+	}
+	current := choices[0]
+	var location string
+	if lib.Source != nil {
+		location = lib.Source.Location
+		for _, one := range choices[1:] {
+			if one.kind == lib.Source.Kind {
+				current = one
+				break
+			}
+		}
+	}
+
+	panel := unison.NewPanel()
+	panel.SetLayout(&unison.FlexLayout{
+		Columns:  2,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	title := i18n.Text("Source")
+	panel.AddChild(widget.NewFieldLeadingLabel(title))
+	popup := unison.NewPopupMenu[*releaseSourceChoice]()
+	for _, one := range choices {
+		popup.AddItem(one)
+	}
+	popup.Select(current)
+	panel.AddChild(popup)
+	title = i18n.Text("Location")
+	panel.AddChild(widget.NewFieldLeadingLabel(title))
+	locationField := widget.NewStringField(nil, "", title,
+		func() string { return location },
+		func(value string) { location = value })
+	locationField.SetMinimumTextWidthUsing(strings.Repeat("x", 50))
+	panel.AddChild(locationField)
+	adjust := func() {
+		locationField.SetEnabled(current != choices[0])
+		if current.tooltip == "" {
+			locationField.Tooltip = unison.NewTooltipWithText(i18n.Text("Releases come from the GitHub repo for this library"))
+		} else {
+			locationField.Tooltip = unison.NewTooltipWithText(current.tooltip)
+		}
+	}
+	adjust()
+	popup.SelectionCallback = func(_ int, item *releaseSourceChoice) {
+		current = item
+		adjust()
+	}
+
+	dialog, err := unison.NewDialog(nil, nil, panel, []*unison.DialogButtonInfo{
+		unison.NewCancelButtonInfo(),
+		unison.NewOKButtonInfo(),
+	})
+	if err != nil {
+		jot.Error(err)
+		return
+	}
+	dialog.Window().SetTitle(fmt.Sprintf(i18n.Text("%s Release Source"), lib.Title))
+	if dialog.RunModal() != unison.ModalResponseOK {
+		return
+	}
+	if current == choices[0] {
+		lib.Source = nil
+	} else {
+		lib.Source = &library.ReleaseSourceConfig{
+			Kind:     current.kind,
+			Location: strings.TrimSpace(location),
+		}
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute*5)
+		defer cancel()
+		lib.CheckForAvailableUpgrade(ctx, &http.Client{})
+	}()
+}
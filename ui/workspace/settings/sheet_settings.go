@@ -41,6 +41,8 @@ type sheetSettingsDockable struct {
 	useModifyDicePlusAdds              *unison.CheckBox
 	lengthUnitsPopup                   *unison.PopupMenu[measure.LengthUnits]
 	weightUnitsPopup                   *unison.PopupMenu[measure.WeightUnits]
+	displayCurrencyPopup               *unison.PopupMenu[*gurps.Currency]
+	currenciesPanel                    *unison.Panel
 	userDescDisplayPopup               *unison.PopupMenu[display.Option]
 	modifiersDisplayPopup              *unison.PopupMenu[display.Option]
 	notesDisplayPopup                  *unison.PopupMenu[display.Option]
@@ -99,6 +101,7 @@ func (d *sheetSettingsDockable) initContent(content *unison.Panel) {
 	d.createDamageProgression(content)
 	d.createOptions(content)
 	d.createUnitsOfMeasurement(content)
+	d.createCurrencies(content)
 	d.createWhereToDisplay(content)
 	d.createPageSettings(content)
 	d.createBlockLayout(content)
@@ -350,6 +353,7 @@ func (d *sheetSettingsDockable) sync() {
 	d.useModifyDicePlusAdds.State = unison.CheckStateFromBool(s.UseModifyingDicePlusAdds)
 	d.lengthUnitsPopup.Select(s.DefaultLengthUnits)
 	d.weightUnitsPopup.Select(s.DefaultWeightUnits)
+	d.rebuildCurrencies()
 	d.userDescDisplayPopup.Select(s.UserDescriptionDisplay)
 	d.modifiersDisplayPopup.Select(s.ModifiersDisplay)
 	d.notesDisplayPopup.Select(s.NotesDisplay)
@@ -0,0 +1,190 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"fmt"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	gsettings "github.com/richardwilkes/gcs/v5/model/gurps/settings"
+	"github.com/richardwilkes/gcs/v5/res"
+	"github.com/richardwilkes/gcs/v5/ui/widget"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+	"github.com/richardwilkes/unison"
+)
+
+const awardPointsResponse = unison.ModalResponseUserBase
+
+type advancementUndoData struct {
+	totalPoints fxp.Int
+	log         *gurps.AdvancementLog
+}
+
+func (s *Sheet) createAdvancementButton() *unison.Button {
+	b := unison.NewSVGButton(res.HistorySVG)
+	b.Tooltip = unison.NewTooltipWithText(i18n.Text("Advancement Log"))
+	b.ClickCallback = s.showAdvancementLog
+	return b
+}
+
+func (s *Sheet) showAdvancementLog() {
+	for {
+		dialog, err := unison.NewDialog(nil, nil, s.createAdvancementLogPanel(), []*unison.DialogButtonInfo{
+			{Title: i18n.Text("Award Points…"), ResponseCode: awardPointsResponse},
+			unison.NewOKButtonInfoWithTitle(i18n.Text("Close")),
+		})
+		if err != nil {
+			jot.Error(err)
+			return
+		}
+		if dialog.RunModal() != awardPointsResponse || !s.awardPoints() {
+			return
+		}
+	}
+}
+
+func (s *Sheet) createAdvancementLogPanel() unison.Paneler {
+	boldFD := unison.DefaultLabelTheme.Font.Descriptor()
+	boldFD.Weight = unison.BoldFontWeight
+	boldFont := boldFD.Font()
+	panel := unison.NewPanel()
+	panel.SetLayout(&unison.FlexLayout{
+		Columns:  1,
+		VSpacing: unison.StdVSpacing,
+	})
+	addLine := func(text string, bold, indent bool) {
+		label := unison.NewLabel()
+		label.Text = text
+		if bold {
+			label.Font = boldFont
+		}
+		if indent {
+			label.SetBorder(unison.NewEmptyBorder(unison.Insets{Left: unison.StdHSpacing * 3}))
+		}
+		panel.AddChild(label)
+	}
+	var entries []*gurps.AdvancementEntry
+	if s.entity.Advancement != nil {
+		entries = s.entity.Advancement.Entries
+	}
+	if len(entries) == 0 {
+		addLine(i18n.Text("No points have been awarded yet."), false, false)
+		addLine(i18n.Text("Changes will be recorded automatically once the first award has been made."), false, false)
+	}
+	for _, entry := range entries {
+		addLine(advancementEntryTitle(entry), true, false)
+		for _, change := range entry.Changes {
+			addLine(change.String(), false, true)
+		}
+	}
+	if pending := s.entity.PendingAdvancement(); len(pending) != 0 {
+		addLine(i18n.Text("Not yet recorded (will be captured when the sheet is saved)"), true, false)
+		for _, change := range pending {
+			addLine(change.String(), false, true)
+		}
+	}
+	scroll := unison.NewScrollPanel()
+	scroll.SetContent(panel, unison.UnmodifiedBehavior, unison.UnmodifiedBehavior)
+	return scroll
+}
+
+func advancementEntryTitle(entry *gurps.AdvancementEntry) string {
+	when := entry.Date
+	if when == "" {
+		when = entry.RecordedOn.String()
+	}
+	if entry.Points != 0 {
+		if entry.Reason != "" {
+			return fmt.Sprintf(i18n.Text("%s: %s points awarded for %s"), when, entry.Points.StringWithSign(),
+				entry.Reason)
+		}
+		return fmt.Sprintf(i18n.Text("%s: %s points awarded"), when, entry.Points.StringWithSign())
+	}
+	return fmt.Sprintf(i18n.Text("%s: %s points spent"), when, entry.Spent().String())
+}
+
+// awardPoints asks the user for the details of a point award and records it. Returns true if an award was made.
+func (s *Sheet) awardPoints() bool {
+	points := fxp.Ten
+	date := s.entity.LastAdvancementDate()
+	var reason string
+
+	panel := unison.NewPanel()
+	panel.SetLayout(&unison.FlexLayout{
+		Columns:  2,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	title := i18n.Text("Points")
+	panel.AddChild(widget.NewFieldLeadingLabel(title))
+	panel.AddChild(widget.NewDecimalField(nil, "", title,
+		func() fxp.Int { return points },
+		func(v fxp.Int) { points = v }, -gsettings.InitialPointsMax, gsettings.InitialPointsMax, false, false))
+	title = i18n.Text("Date")
+	panel.AddChild(widget.NewFieldLeadingLabel(title))
+	dateField := widget.NewStringField(nil, "", title,
+		func() string { return date },
+		func(v string) { date = v })
+	dateField.Tooltip = unison.NewTooltipWithText(i18n.Text("The in-game date, using the calendar chosen in the general settings"))
+	dateField.SetMinimumTextWidthUsing("September 30, 2022 AD")
+	panel.AddChild(dateField)
+	title = i18n.Text("Reason")
+	panel.AddChild(widget.NewFieldLeadingLabel(title))
+	reasonField := widget.NewStringField(nil, "", title,
+		func() string { return reason },
+		func(v string) { reason = v })
+	panel.AddChild(reasonField)
+
+	dialog, err := unison.NewDialog(nil, nil, panel, []*unison.DialogButtonInfo{
+		unison.NewCancelButtonInfo(),
+		unison.NewOKButtonInfoWithTitle(i18n.Text("Award")),
+	})
+	if err != nil {
+		jot.Error(err)
+		return false
+	}
+	if dialog.RunModal() != unison.ModalResponseOK {
+		return false
+	}
+	before := s.advancementUndoData()
+	if err = s.entity.AwardPoints(points, date, reason); err != nil {
+		unison.ErrorDialogWithError(i18n.Text("Unable to award points"), err)
+		return false
+	}
+	s.undoMgr.Add(&unison.UndoEdit[*advancementUndoData]{
+		ID:         unison.NextUndoID(),
+		EditName:   i18n.Text("Award Points"),
+		UndoFunc:   func(e *unison.UndoEdit[*advancementUndoData]) { s.applyAdvancementUndoData(e.BeforeData) },
+		RedoFunc:   func(e *unison.UndoEdit[*advancementUndoData]) { s.applyAdvancementUndoData(e.AfterData) },
+		BeforeData: before,
+		AfterData:  s.advancementUndoData(),
+	})
+	s.Rebuild(true)
+	s.MarkModified()
+	return true
+}
+
+func (s *Sheet) advancementUndoData() *advancementUndoData {
+	return &advancementUndoData{
+		totalPoints: s.entity.TotalPoints,
+		log:         s.entity.Advancement.Clone(),
+	}
+}
+
+func (s *Sheet) applyAdvancementUndoData(data *advancementUndoData) {
+	s.entity.TotalPoints = data.totalPoints
+	s.entity.Advancement = data.log.Clone()
+	s.Rebuild(true)
+	s.MarkModified()
+}
@@ -0,0 +1,441 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/constants"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/ancestry"
+	"github.com/richardwilkes/gcs/v5/model/gurps/datafile"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/gcs/v5/res"
+	"github.com/richardwilkes/gcs/v5/ui/widget"
+	"github.com/richardwilkes/gcs/v5/ui/workspace"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+	"github.com/richardwilkes/toolbox/xio/fs"
+	"github.com/richardwilkes/unison"
+)
+
+const ancestryPreviewCount = 5
+
+var (
+	_ workspace.FileBackedDockable = &AncestryEditor{}
+	_ unison.UndoManagerProvider   = &AncestryEditor{}
+	_ widget.ModifiableRoot        = &AncestryEditor{}
+	_ unison.TabCloser             = &AncestryEditor{}
+)
+
+// AncestryEditor holds the view for an ancestry file.
+type AncestryEditor struct {
+	unison.Panel
+	path              string
+	undoMgr           *unison.UndoManager
+	ancestry          *ancestry.Ancestry
+	crc               uint64
+	content           *unison.Panel
+	needsSaveAsPrompt bool
+}
+
+// NewAncestryEditorFromFile loads an ancestry file and creates a new unison.Dockable for it.
+func NewAncestryEditorFromFile(filePath string) (unison.Dockable, error) {
+	a, err := ancestry.NewAncestryFromFile(os.DirFS(filepath.Dir(filePath)), filepath.Base(filePath))
+	if err != nil {
+		return nil, err
+	}
+	d := NewAncestryEditor(filePath, a)
+	d.needsSaveAsPrompt = false
+	return d, nil
+}
+
+// NewAncestryEditor creates a new unison.Dockable for ancestry files.
+func NewAncestryEditor(filePath string, a *ancestry.Ancestry) *AncestryEditor {
+	if a.CommonOptions == nil {
+		a.CommonOptions = &ancestry.Options{}
+	}
+	d := &AncestryEditor{
+		path:              filePath,
+		undoMgr:           unison.NewUndoManager(100, func(err error) { jot.Error(err) }),
+		ancestry:          a,
+		crc:               a.CRC64(),
+		needsSaveAsPrompt: true,
+	}
+	d.Self = d
+	d.SetLayout(&unison.FlexLayout{Columns: 1})
+
+	addGenderButton := unison.NewSVGButton(res.CircledAddSVG)
+	addGenderButton.Tooltip = unison.NewTooltipWithText(i18n.Text("Add gender-specific options"))
+	addGenderButton.ClickCallback = func() {
+		d.applyChange(i18n.Text("Add Gender"), func(a *ancestry.Ancestry) {
+			a.GenderOptions = append(a.GenderOptions, &ancestry.WeightedAncestryOptions{
+				Weight: 1,
+				Value:  &ancestry.Options{},
+			})
+		})
+	}
+
+	previewButton := unison.NewSVGButton(res.RandomizeSVG)
+	previewButton.Tooltip = unison.NewTooltipWithText(i18n.Text("Preview some randomized results"))
+	previewButton.ClickCallback = d.preview
+
+	d.AddChild(newEditorToolbar(addGenderButton, previewButton))
+
+	d.content = unison.NewPanel()
+	d.content.SetBorder(unison.NewEmptyBorder(unison.NewUniformInsets(unison.StdHSpacing * 2)))
+	d.content.SetLayout(&unison.FlexLayout{
+		Columns:  2,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	d.createFields()
+	scroller := unison.NewScrollPanel()
+	scroller.SetContent(d.content, unison.FillBehavior, unison.FillBehavior)
+	scroller.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		VAlign: unison.FillAlignment,
+		HGrab:  true,
+		VGrab:  true,
+	})
+	d.AddChild(scroller)
+
+	d.InstallCmdHandlers(constants.SaveItemID, func(_ any) bool { return d.Modified() }, func(_ any) { d.save(false) })
+	d.InstallCmdHandlers(constants.SaveAsItemID, unison.AlwaysEnabled, func(_ any) { d.save(true) })
+	return d
+}
+
+func newEditorToolbar(buttons ...*unison.Button) *unison.Panel {
+	toolbar := unison.NewPanel()
+	toolbar.SetBorder(unison.NewCompoundBorder(unison.NewLineBorder(unison.DividerColor, 0, unison.Insets{Bottom: 1},
+		false), unison.NewEmptyBorder(unison.StdInsets())))
+	toolbar.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+	for _, one := range buttons {
+		toolbar.AddChild(one)
+	}
+	toolbar.SetLayout(&unison.FlexLayout{
+		Columns:  len(toolbar.Children()),
+		HSpacing: unison.StdHSpacing,
+	})
+	return toolbar
+}
+
+func (d *AncestryEditor) createFields() {
+	d.content.RemoveAllChildren()
+	a := d.ancestry
+	title := i18n.Text("Name")
+	d.content.AddChild(widget.NewFieldLeadingLabel(title))
+	d.content.AddChild(widget.NewStringField(nil, "", title,
+		func() string { return a.Name },
+		func(s string) { a.Name = s }))
+	d.addHeading(i18n.Text("Common Options"), nil)
+	d.addOptionsFields(a.CommonOptions)
+	for i, one := range a.GenderOptions {
+		index := i
+		option := one
+		if option.Value == nil {
+			option.Value = &ancestry.Options{}
+		}
+		removeButton := unison.NewSVGButton(res.TrashSVG)
+		removeButton.Tooltip = unison.NewTooltipWithText(i18n.Text("Remove these gender-specific options"))
+		removeButton.ClickCallback = func() {
+			d.applyChange(i18n.Text("Remove Gender"), func(a *ancestry.Ancestry) {
+				a.GenderOptions = append(a.GenderOptions[:index], a.GenderOptions[index+1:]...)
+			})
+		}
+		d.addHeading(i18n.Text("Gender Options"), removeButton)
+		title = i18n.Text("Gender")
+		d.content.AddChild(widget.NewFieldLeadingLabel(title))
+		d.content.AddChild(widget.NewStringField(nil, "", title,
+			func() string { return option.Value.Name },
+			func(s string) { option.Value.Name = s }))
+		title = i18n.Text("Weight")
+		d.content.AddChild(widget.NewFieldLeadingLabel(title))
+		weightField := widget.NewIntegerField(nil, "", title,
+			func() int { return option.Weight },
+			func(v int) { option.Weight = v }, 0, 999999, false, false)
+		weightField.Tooltip = unison.NewTooltipWithText(i18n.Text("The relative likelihood of this gender being chosen"))
+		d.content.AddChild(widget.WrapWithSpan(1, weightField))
+		d.addOptionsFields(option.Value)
+	}
+	d.content.MarkForLayoutAndRedraw()
+}
+
+func (d *AncestryEditor) addHeading(title string, button *unison.Button) {
+	boldFD := unison.DefaultLabelTheme.Font.Descriptor()
+	boldFD.Weight = unison.BoldFontWeight
+	label := unison.NewLabel()
+	label.Font = boldFD.Font()
+	label.Text = title
+	panel := unison.NewPanel()
+	panel.SetBorder(unison.NewCompoundBorder(unison.NewEmptyBorder(unison.Insets{Top: unison.StdVSpacing * 2}),
+		unison.NewLineBorder(unison.DividerColor, 0, unison.Insets{Bottom: 1}, false)))
+	panel.SetLayoutData(&unison.FlexLayoutData{
+		HSpan:  2,
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+	label.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		VAlign: unison.MiddleAlignment,
+		HGrab:  true,
+	})
+	panel.AddChild(label)
+	if button != nil {
+		panel.AddChild(button)
+	}
+	panel.SetLayout(&unison.FlexLayout{
+		Columns:  len(panel.Children()),
+		HSpacing: unison.StdHSpacing,
+	})
+	d.content.AddChild(panel)
+}
+
+func (d *AncestryEditor) addOptionsFields(options *ancestry.Options) {
+	formulaTip := i18n.Text("An expression that is evaluated against the character, e.g. roll(1d12+14). Leave blank to use the common option or default.")
+	d.addFormulaField(i18n.Text("Height Formula"), i18n.Text("The result is in inches. ")+formulaTip, &options.HeightFormula)
+	d.addFormulaField(i18n.Text("Weight Formula"), i18n.Text("The result is in pounds. ")+formulaTip, &options.WeightFormula)
+	d.addFormulaField(i18n.Text("Age Formula"), formulaTip, &options.AgeFormula)
+	d.addWeightedOptionsField(i18n.Text("Hair"), &options.HairOptions)
+	d.addWeightedOptionsField(i18n.Text("Eyes"), &options.EyeOptions)
+	d.addWeightedOptionsField(i18n.Text("Skin"), &options.SkinOptions)
+	d.addWeightedOptionsField(i18n.Text("Handedness"), &options.HandednessOptions)
+	d.addNameGeneratorsField(&options.NameGenerators)
+}
+
+func (d *AncestryEditor) addFormulaField(title, tooltip string, value *string) {
+	d.content.AddChild(widget.NewFieldLeadingLabel(title))
+	field := widget.NewStringField(nil, "", title,
+		func() string { return *value },
+		func(s string) { *value = strings.TrimSpace(s) })
+	field.Tooltip = unison.NewTooltipWithText(tooltip)
+	d.content.AddChild(field)
+}
+
+func (d *AncestryEditor) addWeightedOptionsField(title string, list *[]*ancestry.StringOption) {
+	label := widget.NewFieldLeadingLabel(title)
+	label.SetLayoutData(&unison.FlexLayoutData{VAlign: unison.StartAlignment})
+	d.content.AddChild(label)
+	field := widget.NewMultiLineStringField(nil, "", title,
+		func() string { return formatWeightedOptions(*list) },
+		func(s string) { *list = parseWeightedOptions(s) })
+	field.Tooltip = unison.NewTooltipWithText(i18n.Text(`One option per line, preceded by its relative likelihood of being chosen, e.g. "14 Black". Leave blank to use the common options or default.`))
+	d.content.AddChild(field)
+}
+
+func formatWeightedOptions(list []*ancestry.StringOption) string {
+	lines := make([]string, 0, len(list))
+	for _, one := range list {
+		lines = append(lines, fmt.Sprintf("%d %s", one.Weight, one.Value))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseWeightedOptions parses lines of the form "<weight> <value>". Lines without a leading weight are given a weight
+// of 1.
+func parseWeightedOptions(text string) []*ancestry.StringOption {
+	var list []*ancestry.StringOption
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		option := &ancestry.StringOption{Weight: 1, Value: line}
+		if weight, value, found := strings.Cut(line, " "); found {
+			if w, err := strconv.Atoi(weight); err == nil {
+				option.Weight = w
+				option.Value = strings.TrimSpace(value)
+			}
+		}
+		list = append(list, option)
+	}
+	return list
+}
+
+func (d *AncestryEditor) addNameGeneratorsField(list *[]string) {
+	title := i18n.Text("Name Generators")
+	label := widget.NewFieldLeadingLabel(title)
+	label.SetLayoutData(&unison.FlexLayoutData{VAlign: unison.StartAlignment})
+	d.content.AddChild(label)
+	field := widget.NewMultiLineStringField(nil, "", title,
+		func() string { return strings.Join(*list, "\n") },
+		func(s string) {
+			var names []string
+			for _, one := range strings.Split(s, "\n") {
+				if one = strings.TrimSpace(one); one != "" {
+					names = append(names, one)
+				}
+			}
+			*list = names
+		})
+	available := make([]string, 0)
+	for _, one := range ancestry.AvailableNameGenerators(gurps.SettingsProvider.Libraries()) {
+		available = append(available, one.FileRef.Name)
+	}
+	field.Tooltip = unison.NewTooltipWithText(fmt.Sprintf(i18n.Text("One name generator per line. The names each one produces are joined together, in order, to form the full name.\n\nAvailable name generators:\n%s"),
+		strings.Join(available, "\n")))
+	d.content.AddChild(field)
+}
+
+func (d *AncestryEditor) applyChange(name string, change func(a *ancestry.Ancestry)) {
+	before := d.ancestry.Clone()
+	change(d.ancestry)
+	d.undoMgr.Add(&unison.UndoEdit[*ancestry.Ancestry]{
+		ID:         unison.NextUndoID(),
+		EditName:   name,
+		UndoFunc:   func(e *unison.UndoEdit[*ancestry.Ancestry]) { d.setAncestry(e.BeforeData.Clone()) },
+		RedoFunc:   func(e *unison.UndoEdit[*ancestry.Ancestry]) { d.setAncestry(e.AfterData.Clone()) },
+		BeforeData: before,
+		AfterData:  d.ancestry.Clone(),
+	})
+	d.createFields()
+	d.MarkModified()
+}
+
+// setAncestry replaces the contents of the ancestry being edited. The existing Ancestry and common Options objects are
+// updated in place, so that any field-level undo records that refer to them remain valid.
+func (d *AncestryEditor) setAncestry(a *ancestry.Ancestry) {
+	common := d.ancestry.CommonOptions
+	if a.CommonOptions != nil {
+		*common = *a.CommonOptions
+	} else {
+		*common = ancestry.Options{}
+	}
+	*d.ancestry = *a
+	d.ancestry.CommonOptions = common
+	d.createFields()
+	d.MarkModified()
+}
+
+func (d *AncestryEditor) preview() {
+	entity := gurps.NewEntity(datafile.PC)
+	units := gurps.SheetSettingsFor(entity)
+	refs := ancestry.AvailableNameGenerators(gurps.SettingsProvider.Libraries())
+	lines := make([]string, 0, ancestryPreviewCount)
+	for i := 0; i < ancestryPreviewCount; i++ {
+		gender := d.ancestry.RandomGender("")
+		name := d.ancestry.RandomName(refs, gender)
+		if name == "" {
+			name = i18n.Text("Unnamed")
+		}
+		lines = append(lines, fmt.Sprintf(i18n.Text("%s: %s, age %d, %s, %s; %s hair, %s eyes, %s skin, %s-handed"),
+			name, gender, d.ancestry.RandomAge(entity, gender, 0),
+			units.DefaultLengthUnits.Format(d.ancestry.RandomHeight(entity, gender, 0)),
+			units.DefaultWeightUnits.Format(d.ancestry.RandomWeight(entity, gender, 0)),
+			d.ancestry.RandomHair(gender, ""), d.ancestry.RandomEyes(gender, ""), d.ancestry.RandomSkin(gender, ""),
+			d.ancestry.RandomHandedness(gender, "")))
+	}
+	showPreviewDialog(i18n.Text("Randomized results for an average character"), lines)
+}
+
+func showPreviewDialog(title string, lines []string) {
+	dialog, err := unison.NewDialog(nil, nil, unison.NewMessagePanel(title, strings.Join(lines, "\n")),
+		[]*unison.DialogButtonInfo{unison.NewOKButtonInfo()})
+	if err != nil {
+		jot.Error(err)
+		return
+	}
+	dialog.RunModal()
+}
+
+// UndoManager implements undo.Provider
+func (d *AncestryEditor) UndoManager() *unison.UndoManager {
+	return d.undoMgr
+}
+
+// TitleIcon implements workspace.FileBackedDockable
+func (d *AncestryEditor) TitleIcon(suggestedSize unison.Size) unison.Drawable {
+	return &unison.DrawableSVG{
+		SVG:  library.FileInfoFor(d.path).SVG,
+		Size: suggestedSize,
+	}
+}
+
+// Title implements workspace.FileBackedDockable
+func (d *AncestryEditor) Title() string {
+	return fs.BaseName(d.path)
+}
+
+func (d *AncestryEditor) String() string {
+	return d.Title()
+}
+
+// Tooltip implements workspace.FileBackedDockable
+func (d *AncestryEditor) Tooltip() string {
+	return d.path
+}
+
+// BackingFilePath implements workspace.FileBackedDockable
+func (d *AncestryEditor) BackingFilePath() string {
+	return d.path
+}
+
+// Modified implements workspace.FileBackedDockable
+func (d *AncestryEditor) Modified() bool {
+	return d.crc != d.ancestry.CRC64()
+}
+
+// MarkModified implements widget.ModifiableRoot.
+func (d *AncestryEditor) MarkModified() {
+	if dc := unison.Ancestor[*unison.DockContainer](d); dc != nil {
+		dc.UpdateTitle(d)
+	}
+}
+
+// MayAttemptClose implements unison.TabCloser
+func (d *AncestryEditor) MayAttemptClose() bool {
+	return workspace.MayAttemptCloseOfGroup(d)
+}
+
+// AttemptClose implements unison.TabCloser
+func (d *AncestryEditor) AttemptClose() bool {
+	if !workspace.CloseGroup(d) {
+		return false
+	}
+	if d.Modified() {
+		switch unison.YesNoCancelDialog(fmt.Sprintf(i18n.Text("Save changes made to\n%s?"), d.Title()), "") {
+		case unison.ModalResponseDiscard:
+		case unison.ModalResponseOK:
+			if !d.save(false) {
+				return false
+			}
+		case unison.ModalResponseCancel:
+			return false
+		}
+	}
+	if dc := unison.Ancestor[*unison.DockContainer](d); dc != nil {
+		dc.Close(d)
+	}
+	return true
+}
+
+func (d *AncestryEditor) save(forceSaveAs bool) bool {
+	success := false
+	if forceSaveAs || d.needsSaveAsPrompt {
+		success = workspace.SaveDockableAs(d, library.AncestryExt, d.ancestry.Save, func(path string) {
+			d.crc = d.ancestry.CRC64()
+			d.path = path
+		})
+	} else {
+		success = workspace.SaveDockable(d, d.ancestry.Save, func() { d.crc = d.ancestry.CRC64() })
+	}
+	if success {
+		d.needsSaveAsPrompt = false
+	}
+	return success
+}
@@ -0,0 +1,203 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/combat"
+	"github.com/richardwilkes/gcs/v5/res"
+	"github.com/richardwilkes/gcs/v5/ui/widget"
+	"github.com/richardwilkes/rpgtools/dice"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+	"github.com/richardwilkes/unison"
+)
+
+func (s *Sheet) createDamageButton() *unison.Button {
+	b := unison.NewSVGButton(res.MeleeWeaponSVG)
+	b.Tooltip = unison.NewTooltipWithText(i18n.Text("Apply Damage"))
+	b.ClickCallback = s.applyDamage
+	return b
+}
+
+// applyDamage asks the user for the details of an attack against this character, resolves it and, once confirmed,
+// applies the resulting injury.
+func (s *Sheet) applyDamage() {
+	damageText := "1d"
+	damageType := combat.DamageTypes[0]
+	armorDivisor := fxp.One
+	var tightBeam bool
+	locations := gurps.BodyFor(s.entity).UniqueHitLocations(s.entity)
+
+	panel := unison.NewPanel()
+	panel.SetLayout(&unison.FlexLayout{
+		Columns:  2,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	title := i18n.Text("Damage")
+	panel.AddChild(widget.NewFieldLeadingLabel(title))
+	damageField := widget.NewStringField(nil, "", title,
+		func() string { return damageText },
+		func(v string) { damageText = v })
+	damageField.Tooltip = unison.NewTooltipWithText(i18n.Text("The basic damage, either as a number or as dice to be rolled, e.g. 2d+1"))
+	damageField.SetMinimumTextWidthUsing("10d+10")
+	panel.AddChild(damageField)
+
+	panel.AddChild(widget.NewFieldLeadingLabel(i18n.Text("Type")))
+	typePopup := unison.NewPopupMenu[string]()
+	for _, one := range combat.DamageTypes {
+		typePopup.AddItem(fmt.Sprintf("%s (%s)", one.Name, one.Key))
+	}
+	typePopup.SelectIndex(0)
+	typePopup.SelectionCallback = func(index int, _ string) { damageType = combat.DamageTypes[index] }
+	panel.AddChild(typePopup)
+
+	title = i18n.Text("Armor Divisor")
+	panel.AddChild(widget.NewFieldLeadingLabel(title))
+	divisorField := widget.NewDecimalField(nil, "", title,
+		func() fxp.Int { return armorDivisor },
+		func(v fxp.Int) { armorDivisor = v }, fxp.Half.Div(fxp.Five), fxp.Hundred, false, false)
+	divisorField.Tooltip = unison.NewTooltipWithText(i18n.Text("Use 1 for no armor divisor, or a value less than 1 for attacks whose armor divisor multiplies DR"))
+	panel.AddChild(divisorField)
+
+	panel.AddChild(widget.NewFieldLeadingLabel(i18n.Text("Location")))
+	locationPopup := unison.NewPopupMenu[string]()
+	locationPopup.AddItem(i18n.Text("Random (roll for it)"))
+	for _, one := range locations {
+		locationPopup.AddItem(one.ChoiceName)
+	}
+	locationPopup.SelectIndex(0)
+	panel.AddChild(locationPopup)
+
+	panel.AddChild(unison.NewPanel())
+	panel.AddChild(widget.NewCheckBox(nil, "", i18n.Text("Tight-beam burning (lasers and the like)"),
+		func() unison.CheckState { return unison.CheckStateFromBool(tightBeam) },
+		func(state unison.CheckState) { tightBeam = state == unison.OnCheckState }))
+
+	dialog, err := unison.NewDialog(nil, nil, panel, []*unison.DialogButtonInfo{
+		unison.NewCancelButtonInfo(),
+		unison.NewOKButtonInfoWithTitle(i18n.Text("Resolve")),
+	})
+	if err != nil {
+		jot.Error(err)
+		return
+	}
+	if dialog.RunModal() != unison.ModalResponseOK {
+		return
+	}
+
+	attack := &combat.Attack{
+		Type:             damageType,
+		ArmorDivisor:     armorDivisor,
+		TightBeamBurning: tightBeam,
+	}
+	var rolled string
+	if attack.Damage, rolled, err = parseDamage(damageText); err != nil {
+		unison.ErrorDialogWithError(i18n.Text("Invalid damage"), err)
+		return
+	}
+	if index := locationPopup.SelectedIndex(); index > 0 && index <= len(locations) {
+		attack.LocationID = locations[index-1].LocID
+	}
+	var result *combat.Result
+	if result, err = combat.Resolve(s.entity, attack); err != nil {
+		unison.ErrorDialogWithError(i18n.Text("Unable to resolve the attack"), err)
+		return
+	}
+	if !s.confirmDamage(result, rolled) {
+		return
+	}
+	before := s.poolUndoData(result.PoolID)
+	result.Apply(s.entity)
+	s.addPoolUndo(i18n.Text("Apply Damage"), before)
+}
+
+// parseDamage accepts either a plain number or a dice expression, which is rolled. When dice were rolled, the second
+// return value describes the roll.
+func parseDamage(text string) (damage int, rolled string, err error) {
+	text = strings.TrimSpace(text)
+	if damage, err = strconv.Atoi(text); err == nil {
+		if damage < 0 {
+			return 0, "", errs.New(i18n.Text("damage may not be negative"))
+		}
+		return damage, "", nil
+	}
+	if !strings.ContainsAny(strings.ToLower(text), "d") {
+		return 0, "", errs.NewWithCause(fmt.Sprintf(i18n.Text("%s is not a number or a dice expression"), text), err)
+	}
+	d := dice.New(text)
+	if damage = d.Roll(false); damage < 0 {
+		damage = 0
+	}
+	return damage, fmt.Sprintf(i18n.Text("rolled %d on %s"), damage, d.String()), nil
+}
+
+func (s *Sheet) confirmDamage(result *combat.Result, rolled string) bool {
+	lines := make([]string, 0, 8+len(result.Notes))
+	if rolled != "" {
+		lines = append(lines, fmt.Sprintf(i18n.Text("Damage: %d %s (%s)"), result.Attack.Damage,
+			result.Attack.Type.Key, rolled))
+	} else {
+		lines = append(lines, fmt.Sprintf(i18n.Text("Damage: %d %s"), result.Attack.Damage, result.Attack.Type.Key))
+	}
+	if result.LocationRoll != 0 {
+		lines = append(lines, fmt.Sprintf(i18n.Text("Location: %s (rolled %d)"), result.Location.ChoiceName,
+			result.LocationRoll))
+	} else {
+		lines = append(lines, fmt.Sprintf(i18n.Text("Location: %s"), result.Location.ChoiceName))
+	}
+	if result.EffectiveDR != result.DR {
+		lines = append(lines, fmt.Sprintf(i18n.Text("DR: %d, reduced to %d by the armor divisor"), result.DR,
+			result.EffectiveDR))
+	} else {
+		lines = append(lines, fmt.Sprintf(i18n.Text("DR: %d"), result.DR))
+	}
+	lines = append(lines,
+		fmt.Sprintf(i18n.Text("Penetrating damage: %d"), result.Penetrating),
+		fmt.Sprintf(i18n.Text("Wound modifier: ×%s"), result.WoundModifier.String()))
+	if result.Crippled {
+		lines = append(lines, fmt.Sprintf(i18n.Text("Injury: %d (crippled at %d)"), result.Injury,
+			result.CripplingInjury))
+	} else {
+		lines = append(lines, fmt.Sprintf(i18n.Text("Injury: %d"), result.Injury))
+	}
+	if result.ShockPenalty != 0 {
+		lines = append(lines, fmt.Sprintf(i18n.Text("Shock: -%d to DX and IQ next turn"), result.ShockPenalty))
+	}
+	poolName := result.PoolName
+	if poolName == "" {
+		poolName = strings.ToUpper(result.PoolID)
+	}
+	lines = append(lines, fmt.Sprintf(i18n.Text("%s: %s → %s"), poolName, result.PoolBefore.String(),
+		result.PoolAfter.String()))
+	lines = append(lines, result.Notes...)
+	buttons := []*unison.DialogButtonInfo{unison.NewCancelButtonInfo()}
+	if result.Injury > 0 {
+		buttons = append(buttons, unison.NewOKButtonInfoWithTitle(i18n.Text("Apply")))
+	} else {
+		lines = append(lines, i18n.Text("The attack caused no injury."))
+		buttons[0] = unison.NewOKButtonInfoWithTitle(i18n.Text("Close"))
+	}
+	dialog, err := unison.NewDialog(nil, nil, createTextListPanel(lines), buttons)
+	if err != nil {
+		jot.Error(err)
+		return false
+	}
+	return dialog.RunModal() == unison.ModalResponseOK && result.Injury > 0
+}
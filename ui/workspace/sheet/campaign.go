@@ -0,0 +1,222 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/validation"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/gcs/v5/res"
+	"github.com/richardwilkes/gcs/v5/ui/workspace"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+	"github.com/richardwilkes/toolbox/xio/fs"
+	"github.com/richardwilkes/unison"
+)
+
+// loadCampaign loads the campaign the entity is linked to, if any, and applies its sheet settings to the entity.
+func (s *Sheet) loadCampaign() {
+	s.campaign = nil
+	s.campaignPath = ""
+	s.campaignErr = nil
+	if s.entity.Campaign == "" {
+		return
+	}
+	campaign, path, err := gurps.LoadCampaignForSheet(s.path, s.entity.Campaign)
+	if err != nil {
+		jot.Warn(err)
+		s.campaignErr = err
+		return
+	}
+	s.campaign = campaign
+	s.campaignPath = path
+	campaign.ApplyTo(s.entity)
+}
+
+func (s *Sheet) createCampaignButton() *unison.Button {
+	s.campaignButton = unison.NewSVGButton(res.BookmarkSVG)
+	s.campaignButton.ClickCallback = s.showCampaignMenu
+	s.updateCampaignButton()
+	return s.campaignButton
+}
+
+func (s *Sheet) updateCampaignButton() {
+	if s.campaignButton == nil {
+		return
+	}
+	var tip string
+	switch {
+	case s.campaign != nil:
+		name := s.campaign.Name
+		if name == "" {
+			name = fs.BaseName(s.campaignPath)
+		}
+		tip = fmt.Sprintf(i18n.Text("Campaign: %s"), name)
+	case s.campaignErr != nil:
+		tip = fmt.Sprintf(i18n.Text("Unable to load the campaign \"%s\""), s.entity.Campaign)
+	default:
+		tip = i18n.Text("Not linked to a campaign")
+	}
+	s.campaignButton.Tooltip = unison.NewTooltipWithText(tip)
+	s.campaignButton.MarkForRedraw()
+}
+
+func (s *Sheet) showCampaignMenu() {
+	f := unison.DefaultMenuFactory()
+	id := unison.ContextMenuIDFlag
+	m := f.NewMenu(id, "", nil)
+	id++
+	m.InsertItem(-1, f.NewItem(id, i18n.Text("Link to Campaign…"), unison.KeyBinding{}, nil,
+		func(_ unison.MenuItem) { s.linkToCampaign() }))
+	id++
+	if s.campaign != nil {
+		m.InsertItem(-1, f.NewItem(id, i18n.Text("Open Campaign"), unison.KeyBinding{}, nil,
+			func(_ unison.MenuItem) { workspace.OpenFile(s.Window(), s.campaignPath) }))
+		id++
+	}
+	if s.entity.Campaign != "" {
+		m.InsertItem(-1, f.NewItem(id, i18n.Text("Unlink from Campaign"), unison.KeyBinding{}, nil,
+			func(_ unison.MenuItem) { s.unlinkFromCampaign() }))
+	}
+	m.Popup(s.campaignButton.RectToRoot(s.campaignButton.ContentRect(true)), 0)
+}
+
+func (s *Sheet) linkToCampaign() {
+	dialog := unison.NewOpenDialog()
+	dialog.SetResolvesAliases(true)
+	dialog.SetAllowedExtensions(library.CampaignExt)
+	dialog.SetAllowsMultipleSelection(false)
+	dialog.SetCanChooseDirectories(false)
+	dialog.SetCanChooseFiles(true)
+	if !dialog.RunModal() {
+		return
+	}
+	p, err := filepath.Abs(dialog.Path())
+	if err != nil {
+		unison.ErrorDialogWithError(i18n.Text("Unable to link to campaign"), err)
+		return
+	}
+	campaign, err := gurps.NewCampaignFromFile(os.DirFS(filepath.Dir(p)), filepath.Base(p))
+	if err != nil {
+		unison.ErrorDialogWithError(i18n.Text("Unable to link to campaign"), err)
+		return
+	}
+	before := s.campaignLinkState()
+	campaign.Link(s.entity, gurps.CampaignRef(s.path, p))
+	s.campaign = campaign
+	s.campaignPath = p
+	s.campaignErr = nil
+	s.recordCampaignLinkChange(i18n.Text("Link to Campaign"), before)
+}
+
+func (s *Sheet) unlinkFromCampaign() {
+	before := s.campaignLinkState()
+	s.entity.UnlinkCampaign()
+	s.campaign = nil
+	s.campaignPath = ""
+	s.campaignErr = nil
+	s.recordCampaignLinkChange(i18n.Text("Unlink from Campaign"), before)
+}
+
+type campaignLinkUndoEdit = *unison.UndoEdit[*campaignLinkState]
+
+// campaignLinkState holds everything that linking a sheet to, or unlinking it from, a campaign changes.
+type campaignLinkState struct {
+	owner               *Sheet
+	totalPoints         fxp.Int
+	campaignRef         string
+	sheetSettings       *gurps.SheetSettings
+	preCampaignSettings *gurps.SheetSettings
+	attributes          *gurps.Attributes
+	campaign            *gurps.Campaign
+	campaignPath        string
+	campaignErr         error
+}
+
+func (s *Sheet) campaignLinkState() *campaignLinkState {
+	return &campaignLinkState{
+		owner:               s,
+		totalPoints:         s.entity.TotalPoints,
+		campaignRef:         s.entity.Campaign,
+		sheetSettings:       s.entity.SheetSettings,
+		preCampaignSettings: s.entity.PreCampaignSettings,
+		attributes:          s.entity.Attributes.Clone(s.entity),
+		campaign:            s.campaign,
+		campaignPath:        s.campaignPath,
+		campaignErr:         s.campaignErr,
+	}
+}
+
+func (c *campaignLinkState) Apply() {
+	s := c.owner
+	s.entity.TotalPoints = c.totalPoints
+	s.entity.Campaign = c.campaignRef
+	s.entity.SheetSettings = c.sheetSettings
+	s.entity.PreCampaignSettings = c.preCampaignSettings
+	s.entity.Attributes = c.attributes.Clone(s.entity)
+	s.campaign = c.campaign
+	s.campaignPath = c.campaignPath
+	s.campaignErr = c.campaignErr
+	s.campaignLinkChanged()
+}
+
+func (s *Sheet) recordCampaignLinkChange(name string, before *campaignLinkState) {
+	s.undoMgr.Add(&unison.UndoEdit[*campaignLinkState]{
+		ID:         unison.NextUndoID(),
+		EditName:   name,
+		UndoFunc:   func(edit campaignLinkUndoEdit) { edit.BeforeData.Apply() },
+		RedoFunc:   func(edit campaignLinkUndoEdit) { edit.AfterData.Apply() },
+		BeforeData: before,
+		AfterData:  s.campaignLinkState(),
+	})
+	s.campaignLinkChanged()
+}
+
+func (s *Sheet) campaignLinkChanged() {
+	if s.entity.SheetSettings != nil {
+		s.entity.SheetSettings.SetOwningEntity(s.entity)
+	}
+	s.updateCampaignButton()
+	s.Rebuild(true)
+	s.MarkModified()
+}
+
+// campaignUpdated is called when a campaign file has been saved, so that sheets linked to it can pick up the changes.
+func (s *Sheet) campaignUpdated(campaignPath string) {
+	if s.entity.Campaign == "" {
+		return
+	}
+	if s.campaignPath != "" && s.campaignPath != campaignPath {
+		return
+	}
+	s.loadCampaign()
+	s.updateCampaignButton()
+	s.Rebuild(true)
+	s.MarkModified()
+}
+
+func (s *Sheet) validate() []*validation.Issue {
+	issues := validation.ValidateForCampaign(s.entity, s.campaign)
+	if s.campaignErr != nil {
+		issues = append([]*validation.Issue{{
+			Severity: validation.Warning,
+			Category: i18n.Text("Campaign"),
+			Message:  fmt.Sprintf(i18n.Text("unable to load the campaign \"%s\""), s.entity.Campaign),
+		}}, issues...)
+	}
+	return issues
+}
@@ -0,0 +1,389 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/constants"
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	gsettings "github.com/richardwilkes/gcs/v5/model/gurps/settings"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/gcs/v5/model/settings"
+	"github.com/richardwilkes/gcs/v5/res"
+	"github.com/richardwilkes/gcs/v5/ui/widget"
+	"github.com/richardwilkes/gcs/v5/ui/workspace"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+	"github.com/richardwilkes/toolbox/xio/fs"
+	"github.com/richardwilkes/unison"
+)
+
+var (
+	_ workspace.FileBackedDockable = &CampaignEditor{}
+	_ unison.UndoManagerProvider   = &CampaignEditor{}
+	_ widget.ModifiableRoot        = &CampaignEditor{}
+	_ unison.TabCloser             = &CampaignEditor{}
+)
+
+// CampaignEditor holds the view for a campaign file.
+type CampaignEditor struct {
+	unison.Panel
+	path               string
+	undoMgr            *unison.UndoManager
+	campaign           *gurps.Campaign
+	crc                uint64
+	content            *unison.Panel
+	sheetSettingsLabel *unison.Label
+	needsSaveAsPrompt  bool
+}
+
+// NewCampaignEditorFromFile loads a campaign file and creates a new unison.Dockable for it.
+func NewCampaignEditorFromFile(filePath string) (unison.Dockable, error) {
+	campaign, err := gurps.NewCampaignFromFile(os.DirFS(filepath.Dir(filePath)), filepath.Base(filePath))
+	if err != nil {
+		return nil, err
+	}
+	d := NewCampaignEditor(filePath, campaign)
+	d.needsSaveAsPrompt = false
+	return d, nil
+}
+
+// NewCampaignEditor creates a new unison.Dockable for campaign files.
+func NewCampaignEditor(filePath string, campaign *gurps.Campaign) *CampaignEditor {
+	d := &CampaignEditor{
+		path:              filePath,
+		undoMgr:           unison.NewUndoManager(100, func(err error) { jot.Error(err) }),
+		campaign:          campaign,
+		crc:               campaign.CRC64(),
+		needsSaveAsPrompt: true,
+	}
+	d.Self = d
+	d.SetLayout(&unison.FlexLayout{Columns: 1})
+
+	useDefaultsButton := unison.NewSVGButton(res.SettingsSVG)
+	useDefaultsButton.Tooltip = unison.NewTooltipWithText(i18n.Text("Use the current default sheet settings"))
+	useDefaultsButton.ClickCallback = func() { d.setSheetSettings(gurps.SheetSettingsFor(nil).Clone(nil)) }
+
+	loadSettingsButton := unison.NewSVGButton(res.OpenFolderSVG)
+	loadSettingsButton.Tooltip = unison.NewTooltipWithText(i18n.Text("Load sheet settings from a file"))
+	loadSettingsButton.ClickCallback = d.loadSheetSettings
+
+	toolbar := unison.NewPanel()
+	toolbar.SetBorder(unison.NewCompoundBorder(unison.NewLineBorder(unison.DividerColor, 0, unison.Insets{Bottom: 1},
+		false), unison.NewEmptyBorder(unison.StdInsets())))
+	toolbar.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+	toolbar.AddChild(useDefaultsButton)
+	toolbar.AddChild(loadSettingsButton)
+	toolbar.SetLayout(&unison.FlexLayout{
+		Columns:  len(toolbar.Children()),
+		HSpacing: unison.StdHSpacing,
+	})
+	d.AddChild(toolbar)
+
+	d.content = unison.NewPanel()
+	d.content.SetBorder(unison.NewEmptyBorder(unison.NewUniformInsets(unison.StdHSpacing * 2)))
+	d.content.SetLayout(&unison.FlexLayout{
+		Columns:  2,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	d.createFields()
+	scroller := unison.NewScrollPanel()
+	scroller.SetContent(d.content, unison.FillBehavior, unison.FillBehavior)
+	scroller.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		VAlign: unison.FillAlignment,
+		HGrab:  true,
+		VGrab:  true,
+	})
+	d.AddChild(scroller)
+
+	d.InstallCmdHandlers(constants.SaveItemID, func(_ any) bool { return d.Modified() }, func(_ any) { d.save(false) })
+	d.InstallCmdHandlers(constants.SaveAsItemID, unison.AlwaysEnabled, func(_ any) { d.save(true) })
+	return d
+}
+
+func (d *CampaignEditor) createFields() {
+	c := d.campaign
+	title := i18n.Text("Name")
+	d.content.AddChild(widget.NewFieldLeadingLabel(title))
+	d.content.AddChild(widget.NewStringField(nil, "", title,
+		func() string { return c.Name },
+		func(s string) { c.Name = s }))
+	d.addPointsField(i18n.Text("Starting Points"), &c.StartingPoints)
+	d.addPointsField(i18n.Text("Disadvantage Limit"), &c.DisadvantageLimit)
+	d.addPointsField(i18n.Text("Quirk Limit"), &c.QuirkLimit)
+	d.addTechLevelField(i18n.Text("Minimum Tech Level"), &c.MinimumTechLevel)
+	d.addTechLevelField(i18n.Text("Maximum Tech Level"), &c.MaximumTechLevel)
+	d.addLegalityClassPopup()
+	d.addTraitRulesField(i18n.Text("Forbidden Traits"), &c.ForbiddenTraits)
+	d.addTraitRulesField(i18n.Text("Required Traits"), &c.RequiredTraits)
+	d.addLibraryCheckboxes()
+	d.content.AddChild(widget.NewFieldLeadingLabel(i18n.Text("Sheet Settings")))
+	d.sheetSettingsLabel = unison.NewLabel()
+	d.updateSheetSettingsLabel()
+	d.content.AddChild(d.sheetSettingsLabel)
+}
+
+func (d *CampaignEditor) addPointsField(title string, value *fxp.Int) {
+	d.content.AddChild(widget.NewFieldLeadingLabel(title))
+	field := widget.NewDecimalField(nil, "", title,
+		func() fxp.Int { return *value },
+		func(v fxp.Int) { *value = v }, 0, gsettings.InitialPointsMax, false, false)
+	if value != &d.campaign.StartingPoints {
+		field.Tooltip = unison.NewTooltipWithText(i18n.Text("Zero means no limit"))
+	}
+	d.content.AddChild(widget.WrapWithSpan(1, field, widget.NewFieldTrailingLabel(i18n.Text("points"))))
+}
+
+func (d *CampaignEditor) addTechLevelField(title string, value *string) {
+	d.content.AddChild(widget.NewFieldLeadingLabel(title))
+	field := widget.NewStringField(nil, "", title,
+		func() string { return *value },
+		func(s string) { *value = s })
+	field.Tooltip = unison.NewTooltipWithText(i18n.Text("Leave blank for no limit"))
+	field.SetMinimumTextWidthUsing("12^")
+	field.SetLayoutData(&unison.FlexLayoutData{})
+	d.content.AddChild(field)
+}
+
+func (d *CampaignEditor) addLegalityClassPopup() {
+	label := widget.NewFieldLeadingLabel(i18n.Text("Minimum Legality Class"))
+	label.Tooltip = unison.NewTooltipWithText(i18n.Text("Equipment with a lower legality class may not be acquired"))
+	d.content.AddChild(label)
+	noLimit := i18n.Text("No limit")
+	popup := unison.NewPopupMenu[string]()
+	popup.AddItem(noLimit)
+	for _, one := range strings.Split(gurps.LegalityClassInfo, "\n") {
+		popup.AddItem(one)
+	}
+	if lc, err := strconv.Atoi(strings.TrimSpace(d.campaign.MinLegalityClass)); err == nil {
+		popup.SelectIndex(lc + 1)
+	} else {
+		popup.SelectIndex(0)
+	}
+	popup.SelectionCallback = func(index int, _ string) {
+		if index == 0 {
+			d.campaign.MinLegalityClass = ""
+		} else {
+			d.campaign.MinLegalityClass = strconv.Itoa(index - 1)
+		}
+		d.MarkModified()
+	}
+	d.content.AddChild(popup)
+}
+
+func (d *CampaignEditor) addTraitRulesField(title string, list *[]string) {
+	label := widget.NewFieldLeadingLabel(title)
+	label.SetLayoutData(&unison.FlexLayoutData{VAlign: unison.StartAlignment})
+	d.content.AddChild(label)
+	field := widget.NewMultiLineStringField(nil, "", title,
+		func() string { return strings.Join(*list, "\n") },
+		func(s string) {
+			var rules []string
+			for _, one := range strings.Split(s, "\n") {
+				if one = strings.TrimSpace(one); one != "" {
+					rules = append(rules, one)
+				}
+			}
+			*list = rules
+		})
+	field.Tooltip = unison.NewTooltipWithText(fmt.Sprintf(i18n.Text("One trait name per line. Prefix an entry with \"%s\" to match a tag instead."), gurps.CampaignTagPrefix))
+	d.content.AddChild(field)
+}
+
+func (d *CampaignEditor) addLibraryCheckboxes() {
+	label := widget.NewFieldLeadingLabel(i18n.Text("Allowed Libraries"))
+	label.SetLayoutData(&unison.FlexLayoutData{VAlign: unison.StartAlignment})
+	label.Tooltip = unison.NewTooltipWithText(i18n.Text("If none are checked, any library may be used"))
+	d.content.AddChild(label)
+	panel := unison.NewPanel()
+	panel.SetLayout(&unison.FlexLayout{
+		Columns:  1,
+		VSpacing: unison.StdVSpacing,
+	})
+	for _, lib := range settings.Global().Libraries().List() {
+		key := lib.Key()
+		panel.AddChild(widget.NewCheckBox(nil, "", lib.Title,
+			func() unison.CheckState { return unison.CheckStateFromBool(d.libraryAllowed(key)) },
+			func(state unison.CheckState) { d.setLibraryAllowed(key, state == unison.OnCheckState) }))
+	}
+	d.content.AddChild(panel)
+}
+
+func (d *CampaignEditor) libraryAllowed(key string) bool {
+	for _, one := range d.campaign.AllowedLibraries {
+		if one == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *CampaignEditor) setLibraryAllowed(key string, allowed bool) {
+	list := make([]string, 0, len(d.campaign.AllowedLibraries)+1)
+	for _, one := range d.campaign.AllowedLibraries {
+		if one != key {
+			list = append(list, one)
+		}
+	}
+	if allowed {
+		list = append(list, key)
+	}
+	d.campaign.AllowedLibraries = list
+}
+
+func (d *CampaignEditor) updateSheetSettingsLabel() {
+	s := d.campaign.SheetSettings
+	d.sheetSettingsLabel.Text = fmt.Sprintf(i18n.Text("%d attributes, %s body type, %s damage progression"),
+		len(s.Attributes.Set), s.BodyType.Name, s.DamageProgression)
+	d.sheetSettingsLabel.MarkForLayoutAndRedraw()
+}
+
+func (d *CampaignEditor) setSheetSettings(s *gurps.SheetSettings) {
+	undo := &unison.UndoEdit[*gurps.SheetSettings]{
+		ID:       unison.NextUndoID(),
+		EditName: i18n.Text("Change Sheet Settings"),
+		UndoFunc: func(e *unison.UndoEdit[*gurps.SheetSettings]) { d.applySheetSettings(e.BeforeData) },
+		RedoFunc: func(e *unison.UndoEdit[*gurps.SheetSettings]) { d.applySheetSettings(e.AfterData) },
+		AbsorbFunc: func(e *unison.UndoEdit[*gurps.SheetSettings], other unison.Undoable) bool {
+			return false
+		},
+		BeforeData: d.campaign.SheetSettings,
+		AfterData:  s,
+	}
+	d.applySheetSettings(s)
+	d.undoMgr.Add(undo)
+}
+
+func (d *CampaignEditor) applySheetSettings(s *gurps.SheetSettings) {
+	d.campaign.SheetSettings = s
+	d.updateSheetSettingsLabel()
+	d.MarkModified()
+}
+
+func (d *CampaignEditor) loadSheetSettings() {
+	dialog := unison.NewOpenDialog()
+	dialog.SetResolvesAliases(true)
+	dialog.SetAllowedExtensions(".sheet")
+	dialog.SetAllowsMultipleSelection(false)
+	dialog.SetCanChooseDirectories(false)
+	dialog.SetCanChooseFiles(true)
+	if dialog.RunModal() {
+		p := dialog.Path()
+		s, err := gurps.NewSheetSettingsFromFile(os.DirFS(filepath.Dir(p)), filepath.Base(p))
+		if err != nil {
+			unison.ErrorDialogWithError(i18n.Text("Unable to load sheet settings"), err)
+			return
+		}
+		d.setSheetSettings(s)
+	}
+}
+
+// UndoManager implements undo.Provider
+func (d *CampaignEditor) UndoManager() *unison.UndoManager {
+	return d.undoMgr
+}
+
+// TitleIcon implements workspace.FileBackedDockable
+func (d *CampaignEditor) TitleIcon(suggestedSize unison.Size) unison.Drawable {
+	return &unison.DrawableSVG{
+		SVG:  library.FileInfoFor(d.path).SVG,
+		Size: suggestedSize,
+	}
+}
+
+// Title implements workspace.FileBackedDockable
+func (d *CampaignEditor) Title() string {
+	return fs.BaseName(d.path)
+}
+
+func (d *CampaignEditor) String() string {
+	return d.Title()
+}
+
+// Tooltip implements workspace.FileBackedDockable
+func (d *CampaignEditor) Tooltip() string {
+	return d.path
+}
+
+// BackingFilePath implements workspace.FileBackedDockable
+func (d *CampaignEditor) BackingFilePath() string {
+	return d.path
+}
+
+// Modified implements workspace.FileBackedDockable
+func (d *CampaignEditor) Modified() bool {
+	return d.crc != d.campaign.CRC64()
+}
+
+// MarkModified implements widget.ModifiableRoot.
+func (d *CampaignEditor) MarkModified() {
+	if dc := unison.Ancestor[*unison.DockContainer](d); dc != nil {
+		dc.UpdateTitle(d)
+	}
+}
+
+// MayAttemptClose implements unison.TabCloser
+func (d *CampaignEditor) MayAttemptClose() bool {
+	return workspace.MayAttemptCloseOfGroup(d)
+}
+
+// AttemptClose implements unison.TabCloser
+func (d *CampaignEditor) AttemptClose() bool {
+	if !workspace.CloseGroup(d) {
+		return false
+	}
+	if d.Modified() {
+		switch unison.YesNoCancelDialog(fmt.Sprintf(i18n.Text("Save changes made to\n%s?"), d.Title()), "") {
+		case unison.ModalResponseDiscard:
+		case unison.ModalResponseOK:
+			if !d.save(false) {
+				return false
+			}
+		case unison.ModalResponseCancel:
+			return false
+		}
+	}
+	if dc := unison.Ancestor[*unison.DockContainer](d); dc != nil {
+		dc.Close(d)
+	}
+	return true
+}
+
+func (d *CampaignEditor) save(forceSaveAs bool) bool {
+	success := false
+	if forceSaveAs || d.needsSaveAsPrompt {
+		success = workspace.SaveDockableAs(d, library.CampaignExt, d.campaign.Save, func(path string) {
+			d.crc = d.campaign.CRC64()
+			d.path = path
+		})
+	} else {
+		success = workspace.SaveDockable(d, d.campaign.Save, func() { d.crc = d.campaign.CRC64() })
+	}
+	if success {
+		d.needsSaveAsPrompt = false
+		for _, s := range OpenSheets() {
+			s.campaignUpdated(d.path)
+		}
+	}
+	return success
+}
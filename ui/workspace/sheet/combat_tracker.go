@@ -0,0 +1,401 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"fmt"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/combat"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/res"
+	"github.com/richardwilkes/gcs/v5/ui/workspace"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/unison"
+)
+
+var (
+	_ unison.Dockable  = &CombatTracker{}
+	_ unison.TabCloser = &CombatTracker{}
+)
+
+// CombatTracker holds a dockable that tracks the turn order, rounds and point pools of the combatants in a fight. Each
+// combatant is an open sheet, and changes made to them are made through that sheet, so they may be undone there.
+type CombatTracker struct {
+	unison.Panel
+	sheets     []*Sheet
+	current    int
+	round      int
+	roundLabel *unison.Label
+	content    *unison.Panel
+}
+
+// ShowCombatTracker shows the combat tracker, creating it if it isn't open yet.
+func ShowCombatTracker() {
+	ws, _, found := workspace.Activate(func(d unison.Dockable) bool {
+		_, ok := d.(*CombatTracker)
+		return ok
+	})
+	if !found && ws != nil {
+		workspace.DisplayNewDockable(nil, newCombatTracker())
+	}
+}
+
+func newCombatTracker() *CombatTracker {
+	t := &CombatTracker{round: 1}
+	t.Self = t
+	t.SetLayout(&unison.FlexLayout{Columns: 1})
+
+	toolbar := unison.NewPanel()
+	toolbar.SetBorder(unison.NewCompoundBorder(unison.NewLineBorder(unison.DividerColor, 0, unison.Insets{Bottom: 1},
+		false), unison.NewEmptyBorder(unison.StdInsets())))
+	toolbar.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+	addButton := unison.NewSVGButton(res.CircledAddSVG)
+	addButton.Tooltip = unison.NewTooltipWithText(i18n.Text("Add Combatant"))
+	addButton.ClickCallback = func() { t.showAddMenu(addButton) }
+	toolbar.AddChild(addButton)
+	toolbar.AddChild(t.createToolbarButton(res.ResetSVG, i18n.Text("Start New Combat"), t.restart))
+	toolbar.AddChild(t.createToolbarButton(res.PreviousSVG, i18n.Text("Previous Turn"), t.previousTurn))
+	toolbar.AddChild(t.createToolbarButton(res.NextSVG, i18n.Text("Next Turn"), t.nextTurn))
+	t.roundLabel = unison.NewLabel()
+	toolbar.AddChild(t.roundLabel)
+	toolbar.SetLayout(&unison.FlexLayout{
+		Columns:  len(toolbar.Children()),
+		HSpacing: unison.StdHSpacing,
+	})
+	t.AddChild(toolbar)
+
+	t.content = unison.NewPanel()
+	t.content.SetBorder(unison.NewEmptyBorder(unison.NewUniformInsets(unison.StdHSpacing * 2)))
+	scroller := unison.NewScrollPanel()
+	scroller.SetContent(t.content, unison.FillBehavior, unison.FillBehavior)
+	scroller.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		VAlign: unison.FillAlignment,
+		HGrab:  true,
+		VGrab:  true,
+	})
+	t.AddChild(scroller)
+	t.rebuild()
+	return t
+}
+
+func (t *CombatTracker) createToolbarButton(svg *unison.SVG, tooltip string, clickCallback func()) *unison.Button {
+	b := unison.NewSVGButton(svg)
+	b.Tooltip = unison.NewTooltipWithText(tooltip)
+	b.ClickCallback = clickCallback
+	return b
+}
+
+// TitleIcon implements unison.Dockable
+func (t *CombatTracker) TitleIcon(suggestedSize unison.Size) unison.Drawable {
+	return &unison.DrawableSVG{
+		SVG:  res.MeleeWeaponSVG,
+		Size: suggestedSize,
+	}
+}
+
+// Title implements unison.Dockable
+func (t *CombatTracker) Title() string {
+	return i18n.Text("Combat Tracker")
+}
+
+// Tooltip implements unison.Dockable
+func (t *CombatTracker) Tooltip() string {
+	return ""
+}
+
+// Modified implements unison.Dockable
+func (t *CombatTracker) Modified() bool {
+	return false
+}
+
+// MayAttemptClose implements unison.TabCloser
+func (t *CombatTracker) MayAttemptClose() bool {
+	return true
+}
+
+// AttemptClose implements unison.TabCloser
+func (t *CombatTracker) AttemptClose() bool {
+	if dc := unison.Ancestor[*unison.DockContainer](t); dc != nil {
+		dc.Close(t)
+	}
+	return true
+}
+
+func (t *CombatTracker) showAddMenu(b *unison.Button) {
+	f := unison.DefaultMenuFactory()
+	id := unison.ContextMenuIDFlag
+	m := f.NewMenu(id, "", nil)
+	id++
+	var available []*Sheet
+	for _, one := range OpenSheets() {
+		if !t.contains(one) {
+			available = append(available, one)
+		}
+	}
+	for _, one := range available {
+		s := one
+		m.InsertItem(-1, f.NewItem(id, s.Title(), unison.KeyBinding{}, nil, func(_ unison.MenuItem) { t.add(s) }))
+		id++
+	}
+	if len(available) > 1 {
+		m.InsertItem(-1, f.NewItem(id, i18n.Text("All Open Sheets"), unison.KeyBinding{}, nil,
+			func(_ unison.MenuItem) { t.add(available...) }))
+		id++
+	}
+	if len(available) != 0 {
+		m.InsertSeparator(-1, false)
+	}
+	m.InsertItem(-1, f.NewItem(id, i18n.Text("Open Sheet…"), unison.KeyBinding{}, nil, func(_ unison.MenuItem) {
+		if filePath := chooseSheetFile(); filePath != "" {
+			if d, _ := workspace.OpenFile(nil, filePath); d != nil {
+				if s, ok := d.(*Sheet); ok {
+					t.add(s)
+					workspace.Activate(func(d unison.Dockable) bool { return d == t })
+				}
+			}
+		}
+	}))
+	m.Popup(b.RectToRoot(b.ContentRect(true)), 0)
+}
+
+func (t *CombatTracker) contains(s *Sheet) bool {
+	for _, one := range t.sheets {
+		if one == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *CombatTracker) add(sheets ...*Sheet) {
+	var acting *Sheet
+	if t.current < len(t.sheets) {
+		acting = t.sheets[t.current]
+	}
+	for _, s := range sheets {
+		if !t.contains(s) {
+			t.sheets = append(t.sheets, s)
+		}
+	}
+	t.sort(acting)
+}
+
+func (t *CombatTracker) remove(s *Sheet) {
+	for i, one := range t.sheets {
+		if one == s {
+			t.sheets = append(t.sheets[:i], t.sheets[i+1:]...)
+			if i < t.current || (t.current >= len(t.sheets) && t.current > 0) {
+				t.current--
+			}
+			t.rebuild()
+			return
+		}
+	}
+}
+
+// sort puts the combatants into initiative order, keeping the turn with 'acting', if it is still present.
+func (t *CombatTracker) sort(acting *Sheet) {
+	combat.SortByInitiative(t.sheets, func(s *Sheet) *gurps.Entity { return s.entity })
+	t.current = 0
+	for i, one := range t.sheets {
+		if one == acting {
+			t.current = i
+			break
+		}
+	}
+	t.rebuild()
+}
+
+func (t *CombatTracker) restart() {
+	t.round = 1
+	t.sort(nil)
+}
+
+func (t *CombatTracker) nextTurn() {
+	if len(t.sheets) == 0 {
+		return
+	}
+	if t.current++; t.current >= len(t.sheets) {
+		t.current = 0
+		t.round++
+	}
+	t.rebuild()
+}
+
+func (t *CombatTracker) previousTurn() {
+	if len(t.sheets) == 0 || (t.round == 1 && t.current == 0) {
+		return
+	}
+	if t.current--; t.current < 0 {
+		t.current = len(t.sheets) - 1
+		t.round--
+	}
+	t.rebuild()
+}
+
+func (t *CombatTracker) rebuild() {
+	t.roundLabel.Text = fmt.Sprintf(i18n.Text("Round %d"), t.round)
+	t.content.RemoveAllChildren()
+	if len(t.sheets) == 0 {
+		t.content.SetLayout(&unison.FlexLayout{Columns: 1})
+		label := unison.NewLabel()
+		label.Text = i18n.Text("Add the sheets of the combatants using the button in the toolbar.")
+		t.content.AddChild(label)
+	} else {
+		t.content.SetLayout(&unison.FlexLayout{
+			Columns:  9,
+			HSpacing: unison.StdHSpacing,
+			VSpacing: unison.StdVSpacing,
+		})
+		for i, title := range []string{"", i18n.Text("Combatant"), i18n.Text("Speed"), i18n.Text("DX"),
+			i18n.Text("HP"), "", i18n.Text("FP"), "", ""} {
+			t.content.AddChild(t.createHeader(title, i == 1))
+		}
+		for i, s := range t.sheets {
+			t.addRow(s, i == t.current)
+		}
+	}
+	t.MarkForLayoutAndRedraw()
+	t.ValidateLayout()
+}
+
+func (t *CombatTracker) createHeader(title string, grab bool) *unison.Label {
+	label := unison.NewLabel()
+	label.Text = title
+	desc := unison.DefaultLabelTheme.Font.Descriptor()
+	desc.Weight = unison.BoldFontWeight
+	label.Font = desc.Font()
+	label.SetBorder(unison.NewLineBorder(unison.DividerColor, 0, unison.Insets{Bottom: 1}, false))
+	label.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		HGrab:  grab,
+	})
+	return label
+}
+
+func (t *CombatTracker) addRow(s *Sheet, acting bool) {
+	marker := unison.NewLabel()
+	if acting {
+		marker.Text = "▶"
+	}
+	t.content.AddChild(marker)
+
+	name := unison.NewButton()
+	name.HideBase = true
+	name.Text = s.entity.Profile.Name
+	if name.Text == "" {
+		name.Text = s.Title()
+	}
+	name.Tooltip = unison.NewTooltipWithText(i18n.Text("Show the sheet"))
+	name.ClickCallback = func() { workspace.Activate(func(d unison.Dockable) bool { return d == s }) }
+	name.SetLayoutData(&unison.FlexLayoutData{HAlign: unison.StartAlignment})
+	t.content.AddChild(name)
+
+	initiative := combat.InitiativeFor(s.entity)
+	t.content.AddChild(t.createValueLabel(initiative.BasicSpeed.String()))
+	t.content.AddChild(t.createValueLabel(initiative.DX.String()))
+
+	t.addPool(s, gid.HitPoints)
+	t.addPool(s, gid.FatiguePoints)
+
+	buttons := unison.NewPanel()
+	buttons.SetLayout(&unison.FlexLayout{
+		Columns:  2,
+		HSpacing: unison.StdHSpacing,
+	})
+	buttons.AddChild(t.createToolbarButton(res.MeleeWeaponSVG, i18n.Text("Apply Damage"), s.applyDamage))
+	buttons.AddChild(t.createToolbarButton(res.TrashSVG, i18n.Text("Remove from Combat"), func() { t.remove(s) }))
+	t.content.AddChild(buttons)
+}
+
+func (t *CombatTracker) createValueLabel(text string) *unison.Label {
+	label := unison.NewLabel()
+	label.Text = text
+	label.HAlign = unison.EndAlignment
+	label.SetLayoutData(&unison.FlexLayoutData{HAlign: unison.FillAlignment})
+	return label
+}
+
+// addPool adds the current value of the pool, buttons to adjust it by one and its current threshold state, if any.
+func (t *CombatTracker) addPool(s *Sheet, id string) {
+	attr, ok := s.entity.Attributes.Set[id]
+	if !ok {
+		t.content.AddChild(unison.NewPanel())
+		t.content.AddChild(unison.NewPanel())
+		return
+	}
+	name := s.entity.ResolveAttributeName(id)
+	value := t.createValueLabel(fmt.Sprintf("%s/%s", attr.Current().String(), attr.Maximum().String()))
+	if threshold := attr.CurrentThreshold(); threshold != nil {
+		value.Text += " [" + threshold.State + "]"
+		if threshold.Explanation != "" {
+			value.Tooltip = unison.NewTooltipWithText(threshold.Explanation)
+		}
+	}
+	t.content.AddChild(value)
+
+	buttons := unison.NewPanel()
+	buttons.SetLayout(&unison.FlexLayout{
+		Columns:  2,
+		HSpacing: 2,
+	})
+	lose := unison.NewButton()
+	lose.Text = "-"
+	lose.Tooltip = unison.NewTooltipWithText(fmt.Sprintf(i18n.Text("Lose 1 %s"), name))
+	lose.ClickCallback = func() { s.adjustPool(id, -fxp.One, fmt.Sprintf(i18n.Text("Lose %s"), name)) }
+	buttons.AddChild(lose)
+	regain := unison.NewButton()
+	regain.Text = "+"
+	regain.Tooltip = unison.NewTooltipWithText(fmt.Sprintf(i18n.Text("Regain 1 %s"), name))
+	regain.ClickCallback = func() {
+		if attr.Damage > 0 {
+			s.adjustPool(id, fxp.One, fmt.Sprintf(i18n.Text("Regain %s"), name))
+		}
+	}
+	buttons.AddChild(regain)
+	t.content.AddChild(buttons)
+}
+
+// syncCombatTrackers refreshes any combat trackers that include the sheet.
+func syncCombatTrackers(s *Sheet) {
+	forEachCombatTracker(func(t *CombatTracker) {
+		if t.contains(s) {
+			t.rebuild()
+		}
+	})
+}
+
+// removeFromCombatTrackers removes the sheet from any combat trackers, which is needed when the sheet is closed.
+func removeFromCombatTrackers(s *Sheet) {
+	forEachCombatTracker(func(t *CombatTracker) { t.remove(s) })
+}
+
+func forEachCombatTracker(f func(t *CombatTracker)) {
+	for _, wnd := range unison.Windows() {
+		if ws := workspace.FromWindow(wnd); ws != nil {
+			ws.DocumentDock.RootDockLayout().ForEachDockContainer(func(dc *unison.DockContainer) bool {
+				for _, one := range dc.Dockables() {
+					if t, ok := one.(*CombatTracker); ok {
+						f(t)
+					}
+				}
+				return false
+			})
+		}
+	}
+}
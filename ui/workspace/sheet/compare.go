@@ -0,0 +1,152 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/diff"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/gcs/v5/ui/workspace"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+	"github.com/richardwilkes/toolbox/xio/fs"
+	"github.com/richardwilkes/unison"
+)
+
+const mergeResponse = unison.ModalResponseUserBase
+
+// CompareWith asks the user for another sheet and shows how it differs from this one. From there, the two sheets may
+// be merged.
+func (s *Sheet) CompareWith() {
+	otherPath := chooseSheetFile()
+	if otherPath == "" {
+		return
+	}
+	other, err := loadSheetEntity(otherPath)
+	if err != nil {
+		unison.ErrorDialogWithError(i18n.Text("Unable to load sheet"), err)
+		return
+	}
+	var changes []*diff.Change
+	if changes, err = diff.Entities(s.entity, other); err != nil {
+		unison.ErrorDialogWithError(i18n.Text("Unable to compare sheets"), err)
+		return
+	}
+	var lines []string
+	if len(changes) == 0 {
+		lines = append(lines, i18n.Text("The sheets are identical."))
+	} else {
+		lines = append(lines, fmt.Sprintf(i18n.Text("Changes needed to turn %s into %s:"), s.Title(),
+			fs.BaseName(otherPath)))
+		for _, one := range changes {
+			lines = append(lines, one.String())
+		}
+	}
+	buttons := []*unison.DialogButtonInfo{unison.NewOKButtonInfoWithTitle(i18n.Text("Close"))}
+	if len(changes) != 0 {
+		buttons = append([]*unison.DialogButtonInfo{{Title: i18n.Text("Merge…"), ResponseCode: mergeResponse}},
+			buttons...)
+	}
+	dialog, dialogErr := unison.NewDialog(nil, nil, createTextListPanel(lines), buttons)
+	if dialogErr != nil {
+		jot.Error(dialogErr)
+		return
+	}
+	if dialog.RunModal() == mergeResponse {
+		s.mergeWith(otherPath, other)
+	}
+}
+
+// mergeWith asks the user for the sheet both this one and the other one were derived from, then performs a three-way
+// merge. The result is opened as a new, unsaved sheet so that it can be reviewed before replacing anything.
+func (s *Sheet) mergeWith(otherPath string, other *gurps.Entity) {
+	if unison.QuestionDialog(i18n.Text("Choose the common ancestor"),
+		i18n.Text("A three-way merge needs the version of the sheet that both sheets were derived from,\nsuch as the copy last shared with the other player.")) != unison.ModalResponseOK {
+		return
+	}
+	basePath := chooseSheetFile()
+	if basePath == "" {
+		return
+	}
+	base, err := loadSheetEntity(basePath)
+	if err != nil {
+		unison.ErrorDialogWithError(i18n.Text("Unable to load sheet"), err)
+		return
+	}
+	merged, conflicts, mergeErr := diff.Merge(base, s.entity, other)
+	if mergeErr != nil {
+		unison.ErrorDialogWithError(i18n.Text("Unable to merge sheets"), mergeErr)
+		return
+	}
+	if len(conflicts) != 0 {
+		lines := make([]string, 0, len(conflicts)+1)
+		lines = append(lines, fmt.Sprintf(i18n.Text("Both sheets changed the following; the values from %s were kept:"),
+			s.Title()))
+		for _, one := range conflicts {
+			lines = append(lines, one.String())
+		}
+		dialog, dialogErr := unison.NewDialog(unison.DefaultDialogTheme.WarningIcon,
+			unison.DefaultDialogTheme.WarningIconInk, createTextListPanel(lines), []*unison.DialogButtonInfo{
+				unison.NewCancelButtonInfo(),
+				unison.NewOKButtonInfoWithTitle(i18n.Text("Open Merged Sheet")),
+			})
+		if dialogErr != nil {
+			jot.Error(dialogErr)
+			return
+		}
+		if dialog.RunModal() != unison.ModalResponseOK {
+			return
+		}
+	}
+	name := fmt.Sprintf(i18n.Text("%s (merged)"), fs.TrimExtension(filepath.Base(s.path)))
+	workspace.DisplayNewDockable(nil, NewSheet(filepath.Join(filepath.Dir(otherPath), name+library.SheetExt), merged))
+}
+
+func chooseSheetFile() string {
+	dialog := unison.NewOpenDialog()
+	dialog.SetResolvesAliases(true)
+	dialog.SetAllowedExtensions(library.SheetExt)
+	dialog.SetAllowsMultipleSelection(false)
+	dialog.SetCanChooseDirectories(false)
+	dialog.SetCanChooseFiles(true)
+	if !dialog.RunModal() {
+		return ""
+	}
+	return dialog.Path()
+}
+
+func loadSheetEntity(filePath string) (*gurps.Entity, error) {
+	return gurps.NewEntityFromFile(os.DirFS(filepath.Dir(filePath)), filepath.Base(filePath))
+}
+
+func createTextListPanel(lines []string) unison.Paneler {
+	decoration := &unison.TextDecoration{Font: unison.DefaultLabelTheme.Font}
+	panel := unison.NewPanel()
+	panel.SetLayout(&unison.FlexLayout{
+		Columns:  1,
+		VSpacing: unison.StdVSpacing,
+	})
+	for _, line := range lines {
+		for _, one := range unison.NewTextWrappedLines(line, decoration, maxIssueTextWidth) {
+			label := unison.NewLabel()
+			label.Text = one.String()
+			panel.AddChild(label)
+		}
+	}
+	scroll := unison.NewScrollPanel()
+	scroll.SetContent(panel, unison.UnmodifiedBehavior, unison.UnmodifiedBehavior)
+	return scroll
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"github.com/richardwilkes/gcs/v5/constants"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/measure"
+	"github.com/richardwilkes/gcs/v5/ui/widget"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/unison"
+)
+
+type unitConversionUndoEdit = *unison.UndoEdit[*unitConversion]
+
+type unitConversion struct {
+	Owner     widget.Rebuildable
+	Converter *gurps.UnitConverter
+	Converted bool
+}
+
+func (u *unitConversion) Apply() {
+	if u.Converted {
+		u.Converter.Reapply()
+	} else {
+		u.Converter.Restore()
+	}
+	u.Owner.Rebuild(true)
+	widget.MarkModified(u.Owner)
+}
+
+// UnitConvertible defines the methods required of a dockable whose lengths and weights can be converted.
+type UnitConvertible interface {
+	widget.Rebuildable
+	gurps.EntityProvider
+}
+
+// InstallUnitConversionHandlers installs the handlers for converting the owner's lengths and weights to metric or
+// imperial units. 'convert' is called to apply the converter to the owner's data.
+func InstallUnitConversionHandlers(owner UnitConvertible, convert func(c *gurps.UnitConverter)) {
+	p := owner.AsPanel()
+	p.InstallCmdHandlers(constants.ConvertToMetricItemID, unison.AlwaysEnabled,
+		func(_ any) { convertUnits(owner, measure.Metric, convert) })
+	p.InstallCmdHandlers(constants.ConvertToImperialItemID, unison.AlwaysEnabled,
+		func(_ any) { convertUnits(owner, measure.Imperial, convert) })
+}
+
+func convertUnits(owner UnitConvertible, system measure.UnitSystem, convert func(c *gurps.UnitConverter)) {
+	c := gurps.NewUnitConverter(owner.Entity(), system)
+	convert(c)
+	if !c.Changed() {
+		return
+	}
+	if mgr := unison.UndoManagerFor(owner); mgr != nil {
+		var name string
+		if system == measure.Metric {
+			name = i18n.Text("Convert to Metric Units")
+		} else {
+			name = i18n.Text("Convert to Imperial Units")
+		}
+		mgr.Add(&unison.UndoEdit[*unitConversion]{
+			ID:         unison.NextUndoID(),
+			EditName:   name,
+			UndoFunc:   func(edit unitConversionUndoEdit) { edit.BeforeData.Apply() },
+			RedoFunc:   func(edit unitConversionUndoEdit) { edit.AfterData.Apply() },
+			BeforeData: &unitConversion{Owner: owner, Converter: c},
+			AfterData:  &unitConversion{Owner: owner, Converter: c, Converted: true},
+		})
+	}
+	owner.Rebuild(true)
+	widget.MarkModified(owner)
+}
@@ -0,0 +1,398 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"fmt"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/model/gurps/picker"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/gcs/v5/res"
+	"github.com/richardwilkes/gcs/v5/ui/widget"
+	"github.com/richardwilkes/gcs/v5/ui/workspace"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/txt"
+	"github.com/richardwilkes/unison"
+)
+
+var (
+	_ unison.Dockable  = &CreationWizard{}
+	_ unison.TabCloser = &CreationWizard{}
+)
+
+// CreationWizard holds a dockable that walks the user through the choices within a template, producing a new sheet.
+type CreationWizard struct {
+	unison.Panel
+	title        string
+	builder      *gurps.TemplateBuilder
+	names        map[string]string
+	nameKeys     []string
+	step         int
+	stepLabel    *unison.Label
+	pointsLabel  *unison.Label
+	content      *unison.Panel
+	scroll       *unison.ScrollPanel
+	backButton   *unison.Button
+	nextButton   *unison.Button
+	finishButton *unison.Button
+}
+
+// ShowCreationWizard opens a new creation wizard for the template.
+func ShowCreationWizard(title string, template *gurps.Template) {
+	workspace.DisplayNewDockable(nil, newCreationWizard(title, template))
+}
+
+func newCreationWizard(title string, template *gurps.Template) *CreationWizard {
+	w := &CreationWizard{
+		title:   title,
+		builder: gurps.NewTemplateBuilder(template, true),
+		names:   make(map[string]string),
+	}
+	w.Self = w
+	w.SetLayout(&unison.FlexLayout{Columns: 1})
+
+	header := unison.NewPanel()
+	header.SetBorder(unison.NewCompoundBorder(unison.NewLineBorder(unison.DividerColor, 0, unison.Insets{Bottom: 1},
+		false), unison.NewEmptyBorder(unison.StdInsets())))
+	header.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+	w.stepLabel = unison.NewLabel()
+	w.stepLabel.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+	header.AddChild(w.stepLabel)
+	w.pointsLabel = unison.NewLabel()
+	header.AddChild(w.pointsLabel)
+	header.SetLayout(&unison.FlexLayout{
+		Columns:  len(header.Children()),
+		HSpacing: unison.StdHSpacing,
+	})
+	w.AddChild(header)
+
+	w.content = unison.NewPanel()
+	w.content.SetBorder(unison.NewEmptyBorder(unison.NewUniformInsets(unison.StdHSpacing * 2)))
+	w.scroll = unison.NewScrollPanel()
+	w.scroll.SetContent(w.content, unison.FillBehavior, unison.FillBehavior)
+	w.scroll.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		VAlign: unison.FillAlignment,
+		HGrab:  true,
+		VGrab:  true,
+	})
+	w.AddChild(w.scroll)
+
+	footer := unison.NewPanel()
+	footer.SetBorder(unison.NewCompoundBorder(unison.NewLineBorder(unison.DividerColor, 0, unison.Insets{Top: 1},
+		false), unison.NewEmptyBorder(unison.StdInsets())))
+	footer.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+	footer.SetLayout(&unison.FlexLayout{
+		Columns:  3,
+		HSpacing: unison.StdHSpacing,
+		HAlign:   unison.EndAlignment,
+	})
+	w.backButton = w.createButton(footer, i18n.Text("Back"), func() { w.setStep(w.step - 1) })
+	w.nextButton = w.createButton(footer, i18n.Text("Next"), func() { w.setStep(w.step + 1) })
+	w.finishButton = w.createButton(footer, i18n.Text("Create Sheet"), w.finish)
+	w.AddChild(footer)
+
+	w.setStep(0)
+	return w
+}
+
+func (w *CreationWizard) createButton(parent *unison.Panel, title string, clickCallback func()) *unison.Button {
+	b := unison.NewButton()
+	b.Text = title
+	b.ClickCallback = clickCallback
+	parent.AddChild(b)
+	return b
+}
+
+// TitleIcon implements unison.Dockable
+func (w *CreationWizard) TitleIcon(suggestedSize unison.Size) unison.Drawable {
+	return &unison.DrawableSVG{
+		SVG:  res.GCSTemplateSVG,
+		Size: suggestedSize,
+	}
+}
+
+// Title implements unison.Dockable
+func (w *CreationWizard) Title() string {
+	return fmt.Sprintf(i18n.Text("Create from %s"), w.title)
+}
+
+// Tooltip implements unison.Dockable
+func (w *CreationWizard) Tooltip() string {
+	return ""
+}
+
+// Modified implements unison.Dockable
+func (w *CreationWizard) Modified() bool {
+	return false
+}
+
+// MayAttemptClose implements unison.TabCloser
+func (w *CreationWizard) MayAttemptClose() bool {
+	return true
+}
+
+// AttemptClose implements unison.TabCloser
+func (w *CreationWizard) AttemptClose() bool {
+	if dc := unison.Ancestor[*unison.DockContainer](w); dc != nil {
+		dc.Close(w)
+	}
+	return true
+}
+
+// lastStep returns the index of the final step. The first step sets the point total, followed by one step per choice
+// and a final step that provides the substitutions for any nameable keys.
+func (w *CreationWizard) lastStep() int {
+	return len(w.builder.Choices) + 1
+}
+
+func (w *CreationWizard) setStep(step int) {
+	if step < 0 || step > w.lastStep() {
+		return
+	}
+	w.step = step
+	w.content.RemoveAllChildren()
+	w.content.SetLayout(&unison.FlexLayout{
+		Columns:  1,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	switch {
+	case step == 0:
+		w.stepLabel.Text = i18n.Text("Starting Points")
+		w.buildStartStep()
+	case step == w.lastStep():
+		w.stepLabel.Text = i18n.Text("Finish")
+		w.buildFinishStep()
+	default:
+		choice := w.builder.Choices[step-1]
+		w.stepLabel.Text = fmt.Sprintf(i18n.Text("Choice %d of %d"), step, len(w.builder.Choices))
+		w.buildChoiceStep(choice)
+	}
+	w.sync()
+	w.content.MarkForLayoutAndRedraw()
+	w.scroll.MarkForLayoutAndRedraw()
+}
+
+func (w *CreationWizard) buildStartStep() {
+	entity := w.builder.Entity()
+	w.content.AddChild(w.createHeading(w.title))
+	var text string
+	switch len(w.builder.Choices) {
+	case 0:
+		text = i18n.Text("This template has no choices to make.")
+	case 1:
+		text = i18n.Text("This template has 1 choice to make.")
+	default:
+		text = fmt.Sprintf(i18n.Text("This template has %d choices to make."), len(w.builder.Choices))
+	}
+	w.content.AddChild(w.createLabel(text))
+	row := unison.NewPanel()
+	row.SetLayout(&unison.FlexLayout{
+		Columns:  2,
+		HSpacing: unison.StdHSpacing,
+	})
+	row.AddChild(widget.NewFieldLeadingLabel(i18n.Text("Total Points")))
+	row.AddChild(widget.NewDecimalField(nil, "", i18n.Text("Total Points"),
+		func() fxp.Int { return entity.TotalPoints },
+		func(v fxp.Int) {
+			entity.TotalPoints = v
+			w.sync()
+		}, fxp.Min, fxp.Max, false, false))
+	w.content.AddChild(row)
+}
+
+func (w *CreationWizard) buildChoiceStep(choice *gurps.TemplateChoice) {
+	w.content.AddChild(w.createHeading(fmt.Sprintf("%s: %s", choiceKindTitle(choice.Kind), choice.Title)))
+	if choice.Notes != "" {
+		w.content.AddChild(w.createLabel(choice.Notes))
+	}
+	status := w.createLabel("")
+	updateStatus := func() {
+		var text string
+		if choice.Picker.Type == picker.Points {
+			text = fmt.Sprintf(i18n.Text("%s — %s points selected"), choice.Picker.Description(),
+				choice.SelectedPoints().String())
+		} else {
+			text = fmt.Sprintf(i18n.Text("%s — %d selected"), choice.Picker.Description(), choice.SelectedCount())
+		}
+		status.Text = text
+		status.MarkForLayoutAndRedraw()
+	}
+	w.content.AddChild(status)
+	list := unison.NewPanel()
+	list.SetBorder(unison.NewEmptyBorder(unison.Insets{Left: unison.StdHSpacing * 2}))
+	list.SetLayout(&unison.FlexLayout{
+		Columns:  2,
+		HSpacing: unison.StdHSpacing * 2,
+		VSpacing: unison.StdVSpacing,
+	})
+	for _, one := range choice.Options {
+		option := one
+		checkBox := unison.NewCheckBox()
+		checkBox.Text = option.Name
+		checkBox.State = unison.CheckStateFromBool(option.Selected)
+		checkBox.ClickCallback = func() {
+			option.Selected = checkBox.State == unison.OnCheckState
+			updateStatus()
+			w.sync()
+		}
+		list.AddChild(checkBox)
+		points := unison.NewLabel()
+		if choice.Kind == gid.Equipment {
+			points.Text = option.Points.String()
+		} else {
+			points.Text = fmt.Sprintf(i18n.Text("%s pts"), option.Points.String())
+		}
+		points.HAlign = unison.EndAlignment
+		points.SetLayoutData(&unison.FlexLayoutData{HAlign: unison.EndAlignment})
+		list.AddChild(points)
+	}
+	w.content.AddChild(list)
+	updateStatus()
+}
+
+func (w *CreationWizard) buildFinishStep() {
+	m := w.builder.NameableKeys()
+	w.nameKeys = w.nameKeys[:0]
+	for k := range m {
+		w.nameKeys = append(w.nameKeys, k)
+	}
+	txt.SortStringsNaturalAscending(w.nameKeys)
+	if len(w.nameKeys) == 0 {
+		w.content.AddChild(w.createLabel(i18n.Text("All choices have been made. The new sheet is ready to be created.")))
+		return
+	}
+	w.content.AddChild(w.createLabel(i18n.Text("Provide substitutions:")))
+	list := unison.NewPanel()
+	list.SetLayout(&unison.FlexLayout{
+		Columns:  2,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	for _, one := range w.nameKeys {
+		key := one
+		label := unison.NewLabel()
+		label.Text = key
+		label.SetLayoutData(&unison.FlexLayoutData{
+			HAlign: unison.EndAlignment,
+			VAlign: unison.MiddleAlignment,
+		})
+		list.AddChild(label)
+		field := unison.NewField()
+		field.SetMinimumTextWidthUsing("Something reasonable")
+		field.SetText(w.names[key])
+		field.SetLayoutData(&unison.FlexLayoutData{
+			HAlign: unison.FillAlignment,
+			VAlign: unison.MiddleAlignment,
+			HGrab:  true,
+		})
+		field.ModifiedCallback = func() { w.names[key] = field.Text() }
+		list.AddChild(field)
+	}
+	list.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+	w.content.AddChild(list)
+}
+
+// sync rebuilds the character from the current selections and updates the running point total and the buttons.
+func (w *CreationWizard) sync() {
+	entity := w.builder.Entity()
+	w.pointsLabel.Text = fmt.Sprintf(i18n.Text("%s of %s points spent"), entity.SpentPoints().String(),
+		entity.TotalPoints.String())
+	w.pointsLabel.Parent().MarkForLayoutAndRedraw()
+	w.backButton.SetEnabled(w.step > 0)
+	w.nextButton.SetEnabled(w.step < w.lastStep() && w.currentStepSatisfied())
+	w.finishButton.SetEnabled(w.step == w.lastStep() && w.allChoicesSatisfied())
+}
+
+func (w *CreationWizard) currentStepSatisfied() bool {
+	if w.step > 0 && w.step <= len(w.builder.Choices) {
+		return w.builder.Choices[w.step-1].Satisfied()
+	}
+	return true
+}
+
+func (w *CreationWizard) allChoicesSatisfied() bool {
+	for _, one := range w.builder.Choices {
+		if !one.Satisfied() {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *CreationWizard) finish() {
+	if !w.allChoicesSatisfied() {
+		return
+	}
+	entity := w.builder.Entity()
+	m := make(map[string]string)
+	for _, key := range w.nameKeys {
+		if value := w.names[key]; value != "" {
+			m[key] = value
+		}
+	}
+	if len(m) != 0 {
+		w.builder.ApplyNameableKeys(m)
+	}
+	name := entity.Profile.Name
+	if name == "" {
+		name = w.title
+	}
+	workspace.DisplayNewDockable(nil, NewSheet(name+library.SheetExt, entity))
+	w.AttemptClose()
+}
+
+func (w *CreationWizard) createHeading(title string) *unison.Label {
+	label := unison.NewLabel()
+	label.Text = title
+	desc := unison.DefaultLabelTheme.Font.Descriptor()
+	desc.Weight = unison.BoldFontWeight
+	label.Font = desc.Font()
+	return label
+}
+
+func (w *CreationWizard) createLabel(text string) *unison.Label {
+	label := unison.NewLabel()
+	label.Text = text
+	return label
+}
+
+func choiceKindTitle(kind string) string {
+	switch kind {
+	case gid.Trait:
+		return i18n.Text("Traits")
+	case gid.Skill:
+		return i18n.Text("Skills")
+	case gid.Spell:
+		return i18n.Text("Spells")
+	case gid.Equipment:
+		return i18n.Text("Equipment")
+	default:
+		return kind
+	}
+}
@@ -0,0 +1,247 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/res"
+	"github.com/richardwilkes/gcs/v5/ui/widget"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+	"github.com/richardwilkes/unison"
+)
+
+type loadoutUndoData struct {
+	state    *gurps.EquipmentState
+	loadouts []*gurps.Loadout
+}
+
+func (s *Sheet) createLoadoutsButton() *unison.Button {
+	b := unison.NewSVGButton(res.StackSVG)
+	b.Tooltip = unison.NewTooltipWithText(i18n.Text("Equipment Loadouts"))
+	b.ClickCallback = func() { s.showLoadoutsMenu(b) }
+	return b
+}
+
+func (s *Sheet) showLoadoutsMenu(b *unison.Button) {
+	f := unison.DefaultMenuFactory()
+	id := unison.ContextMenuIDFlag
+	m := f.NewMenu(id, "", nil)
+	id++
+	for _, one := range s.entity.Loadouts {
+		loadout := one
+		m.InsertItem(-1, f.NewItem(id, fmt.Sprintf(i18n.Text("Switch to %s…"), loadout.Name), unison.KeyBinding{}, nil,
+			func(_ unison.MenuItem) { s.switchLoadout(loadout) }))
+		id++
+	}
+	if len(s.entity.Loadouts) != 0 {
+		m.InsertSeparator(-1, false)
+	}
+	m.InsertItem(-1, f.NewItem(id, i18n.Text("Save Current Equipment as New Loadout…"), unison.KeyBinding{}, nil,
+		func(_ unison.MenuItem) { s.addLoadout() }))
+	id++
+	if len(s.entity.Loadouts) != 0 {
+		updateMenu := f.NewMenu(id, i18n.Text("Update Loadout from Current Equipment"), nil)
+		id++
+		deleteMenu := f.NewMenu(id, i18n.Text("Delete Loadout"), nil)
+		id++
+		for i, one := range s.entity.Loadouts {
+			index := i
+			updateMenu.InsertItem(-1, f.NewItem(id, one.Name, unison.KeyBinding{}, nil,
+				func(_ unison.MenuItem) { s.updateLoadout(index) }))
+			id++
+			deleteMenu.InsertItem(-1, f.NewItem(id, one.Name, unison.KeyBinding{}, nil,
+				func(_ unison.MenuItem) { s.deleteLoadout(index) }))
+			id++
+		}
+		m.InsertMenu(-1, updateMenu)
+		m.InsertMenu(-1, deleteMenu)
+	}
+	m.Popup(b.RectToRoot(b.ContentRect(true)), 0)
+}
+
+// switchLoadout shows the effect of switching to the loadout and, if the user accepts, applies it.
+func (s *Sheet) switchLoadout(loadout *gurps.Loadout) {
+	before, after := gurps.PreviewLoadout(s.entity, loadout)
+	panel := unison.NewPanel()
+	panel.SetLayout(&unison.FlexLayout{
+		Columns:  4,
+		HSpacing: unison.StdHSpacing * 2,
+		VSpacing: unison.StdVSpacing,
+	})
+	boldFD := unison.DefaultLabelTheme.Font.Descriptor()
+	boldFD.Weight = unison.BoldFontWeight
+	boldFont := boldFD.Font()
+	addRow := func(bold bool, text ...string) {
+		for i, one := range text {
+			label := unison.NewLabel()
+			label.Text = one
+			if bold {
+				label.Font = boldFont
+			}
+			if i != 0 {
+				label.HAlign = unison.EndAlignment
+				label.SetLayoutData(&unison.FlexLayoutData{HAlign: unison.EndAlignment})
+			}
+			panel.AddChild(label)
+		}
+	}
+	units := s.entity.SheetSettings.DefaultWeightUnits
+	addRow(true, "", i18n.Text("Current"), loadout.Name, i18n.Text("Change"))
+	addRow(false, i18n.Text("Weight"), units.Format(before.Weight), units.Format(after.Weight),
+		weightDelta(units.Format(after.Weight-before.Weight), after.Weight > before.Weight))
+	addRow(false, i18n.Text("Encumbrance"), before.Encumbrance.String(), after.Encumbrance.String(),
+		intDelta(int(after.Encumbrance)-int(before.Encumbrance)))
+	addRow(false, i18n.Text("Move"), strconv.Itoa(before.Move), strconv.Itoa(after.Move), intDelta(after.Move-before.Move))
+	addRow(false, i18n.Text("Dodge"), strconv.Itoa(before.Dodge), strconv.Itoa(after.Dodge),
+		intDelta(after.Dodge-before.Dodge))
+	if missing := loadout.Missing(s.entity); missing != 0 {
+		label := unison.NewLabel()
+		label.Text = fmt.Sprintf(i18n.Text("%d item(s) recorded in this loadout no longer exist and will be ignored"),
+			missing)
+		label.SetLayoutData(&unison.FlexLayoutData{HSpan: 4})
+		panel.AddChild(label)
+	}
+
+	dialog, err := unison.NewDialog(nil, nil, panel, []*unison.DialogButtonInfo{
+		unison.NewCancelButtonInfo(),
+		unison.NewOKButtonInfoWithTitle(i18n.Text("Switch")),
+	})
+	if err != nil {
+		jot.Error(err)
+		return
+	}
+	if dialog.RunModal() != unison.ModalResponseOK {
+		return
+	}
+	s.changeLoadouts(fmt.Sprintf(i18n.Text("Switch to %s"), loadout.Name), true, func() { loadout.Apply(s.entity) })
+}
+
+func weightDelta(text string, increased bool) string {
+	if increased {
+		return "+" + text
+	}
+	return text
+}
+
+func intDelta(delta int) string {
+	if delta == 0 {
+		return "—"
+	}
+	return fmt.Sprintf("%+d", delta)
+}
+
+func (s *Sheet) addLoadout() {
+	name := s.askForLoadoutName()
+	if name == "" {
+		return
+	}
+	s.changeLoadouts(i18n.Text("Save Loadout"), false, func() {
+		for i, one := range s.entity.Loadouts {
+			if strings.EqualFold(one.Name, name) {
+				s.entity.Loadouts[i] = gurps.NewLoadout(s.entity, name)
+				return
+			}
+		}
+		s.entity.Loadouts = append(s.entity.Loadouts, gurps.NewLoadout(s.entity, name))
+	})
+}
+
+func (s *Sheet) updateLoadout(index int) {
+	s.changeLoadouts(i18n.Text("Update Loadout"), false, func() { s.entity.Loadouts[index].Record(s.entity) })
+}
+
+func (s *Sheet) deleteLoadout(index int) {
+	s.changeLoadouts(i18n.Text("Delete Loadout"), false, func() {
+		list := make([]*gurps.Loadout, 0, len(s.entity.Loadouts)-1)
+		list = append(list, s.entity.Loadouts[:index]...)
+		s.entity.Loadouts = append(list, s.entity.Loadouts[index+1:]...)
+	})
+}
+
+func (s *Sheet) askForLoadoutName() string {
+	var name string
+	panel := unison.NewPanel()
+	panel.SetLayout(&unison.FlexLayout{
+		Columns:  2,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	title := i18n.Text("Name")
+	panel.AddChild(widget.NewFieldLeadingLabel(title))
+	field := widget.NewStringField(nil, "", title,
+		func() string { return name },
+		func(v string) { name = v })
+	field.SetMinimumTextWidthUsing("Travel Gear for Winter")
+	field.Tooltip = unison.NewTooltipWithText(i18n.Text("Using the name of an existing loadout will replace it"))
+	panel.AddChild(field)
+	dialog, err := unison.NewDialog(nil, nil, panel, []*unison.DialogButtonInfo{
+		unison.NewCancelButtonInfo(),
+		unison.NewOKButtonInfoWithTitle(i18n.Text("Save")),
+	})
+	if err != nil {
+		jot.Error(err)
+		return ""
+	}
+	if dialog.RunModal() != unison.ModalResponseOK {
+		return ""
+	}
+	return strings.TrimSpace(name)
+}
+
+// changeLoadouts makes a change to the loadouts, and optionally the equipment, as a single undoable action.
+func (s *Sheet) changeLoadouts(name string, affectsEquipment bool, f func()) {
+	before := s.loadoutUndoData(affectsEquipment)
+	f()
+	s.undoMgr.Add(&unison.UndoEdit[*loadoutUndoData]{
+		ID:         unison.NextUndoID(),
+		EditName:   name,
+		UndoFunc:   func(e *unison.UndoEdit[*loadoutUndoData]) { s.applyLoadoutUndoData(e.BeforeData) },
+		RedoFunc:   func(e *unison.UndoEdit[*loadoutUndoData]) { s.applyLoadoutUndoData(e.AfterData) },
+		BeforeData: before,
+		AfterData:  s.loadoutUndoData(affectsEquipment),
+	})
+	s.Rebuild(affectsEquipment)
+	s.MarkModified()
+}
+
+func (s *Sheet) loadoutUndoData(includeEquipment bool) *loadoutUndoData {
+	data := &loadoutUndoData{loadouts: cloneLoadouts(s.entity.Loadouts)}
+	if includeEquipment {
+		data.state = gurps.NewEquipmentState(s.entity)
+	}
+	return data
+}
+
+func (s *Sheet) applyLoadoutUndoData(data *loadoutUndoData) {
+	s.entity.Loadouts = cloneLoadouts(data.loadouts)
+	if data.state != nil {
+		data.state.Restore()
+	}
+	s.Rebuild(data.state != nil)
+	s.MarkModified()
+}
+
+func cloneLoadouts(list []*gurps.Loadout) []*gurps.Loadout {
+	if list == nil {
+		return nil
+	}
+	clone := make([]*gurps.Loadout, len(list))
+	for i, one := range list {
+		clone[i] = one.Clone()
+	}
+	return clone
+}
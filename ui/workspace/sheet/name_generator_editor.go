@@ -0,0 +1,232 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/richardwilkes/gcs/v5/constants"
+	"github.com/richardwilkes/gcs/v5/model/gurps/ancestry"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/gcs/v5/res"
+	"github.com/richardwilkes/gcs/v5/ui/widget"
+	"github.com/richardwilkes/gcs/v5/ui/workspace"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+	"github.com/richardwilkes/toolbox/xio/fs"
+	"github.com/richardwilkes/unison"
+)
+
+const nameGeneratorPreviewCount = 10
+
+var (
+	_ workspace.FileBackedDockable = &NameGeneratorEditor{}
+	_ unison.UndoManagerProvider   = &NameGeneratorEditor{}
+	_ widget.ModifiableRoot        = &NameGeneratorEditor{}
+	_ unison.TabCloser             = &NameGeneratorEditor{}
+)
+
+// NameGeneratorEditor holds the view for a name generator file.
+type NameGeneratorEditor struct {
+	unison.Panel
+	path              string
+	undoMgr           *unison.UndoManager
+	generator         *ancestry.NameGenerator
+	crc               uint64
+	needsSaveAsPrompt bool
+}
+
+// NewNameGeneratorEditorFromFile loads a name generator file and creates a new unison.Dockable for it.
+func NewNameGeneratorEditorFromFile(filePath string) (unison.Dockable, error) {
+	generator, err := ancestry.NewNameGeneratorFromFS(os.DirFS(filepath.Dir(filePath)), filepath.Base(filePath))
+	if err != nil {
+		return nil, err
+	}
+	d := NewNameGeneratorEditor(filePath, generator)
+	d.needsSaveAsPrompt = false
+	return d, nil
+}
+
+// NewNameGeneratorEditor creates a new unison.Dockable for name generator files.
+func NewNameGeneratorEditor(filePath string, generator *ancestry.NameGenerator) *NameGeneratorEditor {
+	generator.Type = generator.Type.EnsureValid()
+	d := &NameGeneratorEditor{
+		path:              filePath,
+		undoMgr:           unison.NewUndoManager(100, func(err error) { jot.Error(err) }),
+		generator:         generator,
+		crc:               generator.CRC64(),
+		needsSaveAsPrompt: true,
+	}
+	d.Self = d
+	d.SetLayout(&unison.FlexLayout{Columns: 1})
+
+	previewButton := unison.NewSVGButton(res.RandomizeSVG)
+	previewButton.Tooltip = unison.NewTooltipWithText(i18n.Text("Preview some generated names"))
+	previewButton.ClickCallback = d.preview
+	d.AddChild(newEditorToolbar(previewButton))
+
+	content := unison.NewPanel()
+	content.SetBorder(unison.NewEmptyBorder(unison.NewUniformInsets(unison.StdHSpacing * 2)))
+	content.SetLayout(&unison.FlexLayout{
+		Columns:  2,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	title := i18n.Text("Type")
+	content.AddChild(widget.NewFieldLeadingLabel(title))
+	typePopup := widget.NewPopup[ancestry.NameGenerationType](nil, "", title,
+		func() ancestry.NameGenerationType { return d.generator.Type },
+		func(t ancestry.NameGenerationType) { d.generator.Type = t }, ancestry.AllNameGenerationTypes...)
+	typePopup.Tooltip = unison.NewTooltipWithText(i18n.Text(`"Simple" picks one of the training names at random, while "Markov Chain" creates new names that resemble the training names`))
+	content.AddChild(typePopup)
+	title = i18n.Text("Training Data")
+	label := widget.NewFieldLeadingLabel(title)
+	label.SetLayoutData(&unison.FlexLayoutData{VAlign: unison.StartAlignment})
+	content.AddChild(label)
+	field := widget.NewMultiLineStringField(nil, "", title,
+		func() string { return strings.Join(d.generator.TrainingData, "\n") },
+		func(s string) {
+			var list []string
+			for _, one := range strings.Split(s, "\n") {
+				if one = strings.TrimSpace(one); one != "" {
+					list = append(list, one)
+				}
+			}
+			d.generator.TrainingData = list
+		})
+	field.Tooltip = unison.NewTooltipWithText(i18n.Text("One name per line"))
+	field.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		VAlign: unison.FillAlignment,
+		HGrab:  true,
+		VGrab:  true,
+	})
+	content.AddChild(field)
+	scroller := unison.NewScrollPanel()
+	scroller.SetContent(content, unison.FillBehavior, unison.FillBehavior)
+	scroller.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		VAlign: unison.FillAlignment,
+		HGrab:  true,
+		VGrab:  true,
+	})
+	d.AddChild(scroller)
+
+	d.InstallCmdHandlers(constants.SaveItemID, func(_ any) bool { return d.Modified() }, func(_ any) { d.save(false) })
+	d.InstallCmdHandlers(constants.SaveAsItemID, unison.AlwaysEnabled, func(_ any) { d.save(true) })
+	return d
+}
+
+func (d *NameGeneratorEditor) preview() {
+	// Generation normalizes the training data, so work from a copy to keep the user's data intact.
+	generator := d.generator.Clone()
+	lines := make([]string, 0, nameGeneratorPreviewCount)
+	for i := 0; i < nameGeneratorPreviewCount; i++ {
+		if name := generator.Generate(); name != "" {
+			lines = append(lines, name)
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, i18n.Text("No names could be generated from the training data."))
+	}
+	showPreviewDialog(i18n.Text("Generated names"), lines)
+}
+
+// UndoManager implements undo.Provider
+func (d *NameGeneratorEditor) UndoManager() *unison.UndoManager {
+	return d.undoMgr
+}
+
+// TitleIcon implements workspace.FileBackedDockable
+func (d *NameGeneratorEditor) TitleIcon(suggestedSize unison.Size) unison.Drawable {
+	return &unison.DrawableSVG{
+		SVG:  library.FileInfoFor(d.path).SVG,
+		Size: suggestedSize,
+	}
+}
+
+// Title implements workspace.FileBackedDockable
+func (d *NameGeneratorEditor) Title() string {
+	return fs.BaseName(d.path)
+}
+
+func (d *NameGeneratorEditor) String() string {
+	return d.Title()
+}
+
+// Tooltip implements workspace.FileBackedDockable
+func (d *NameGeneratorEditor) Tooltip() string {
+	return d.path
+}
+
+// BackingFilePath implements workspace.FileBackedDockable
+func (d *NameGeneratorEditor) BackingFilePath() string {
+	return d.path
+}
+
+// Modified implements workspace.FileBackedDockable
+func (d *NameGeneratorEditor) Modified() bool {
+	return d.crc != d.generator.CRC64()
+}
+
+// MarkModified implements widget.ModifiableRoot.
+func (d *NameGeneratorEditor) MarkModified() {
+	if dc := unison.Ancestor[*unison.DockContainer](d); dc != nil {
+		dc.UpdateTitle(d)
+	}
+}
+
+// MayAttemptClose implements unison.TabCloser
+func (d *NameGeneratorEditor) MayAttemptClose() bool {
+	return workspace.MayAttemptCloseOfGroup(d)
+}
+
+// AttemptClose implements unison.TabCloser
+func (d *NameGeneratorEditor) AttemptClose() bool {
+	if !workspace.CloseGroup(d) {
+		return false
+	}
+	if d.Modified() {
+		switch unison.YesNoCancelDialog(fmt.Sprintf(i18n.Text("Save changes made to\n%s?"), d.Title()), "") {
+		case unison.ModalResponseDiscard:
+		case unison.ModalResponseOK:
+			if !d.save(false) {
+				return false
+			}
+		case unison.ModalResponseCancel:
+			return false
+		}
+	}
+	if dc := unison.Ancestor[*unison.DockContainer](d); dc != nil {
+		dc.Close(d)
+	}
+	return true
+}
+
+func (d *NameGeneratorEditor) save(forceSaveAs bool) bool {
+	success := false
+	if forceSaveAs || d.needsSaveAsPrompt {
+		success = workspace.SaveDockableAs(d, library.NameGeneratorExt, d.generator.Save, func(path string) {
+			d.crc = d.generator.CRC64()
+			d.path = path
+		})
+	} else {
+		success = workspace.SaveDockable(d, d.generator.Save, func() { d.crc = d.generator.CRC64() })
+	}
+	if success {
+		d.needsSaveAsPrompt = false
+	}
+	return success
+}
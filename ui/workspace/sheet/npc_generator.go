@@ -0,0 +1,162 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"fmt"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/ancestry"
+	gsettings "github.com/richardwilkes/gcs/v5/model/gurps/settings"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/gcs/v5/ui/widget"
+	"github.com/richardwilkes/gcs/v5/ui/workspace"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+	"github.com/richardwilkes/unison"
+)
+
+const maxNPCBatchSize = 1000
+
+type npcTemplateChoice struct {
+	title    string
+	template *gurps.Template
+	ref      *library.NamedFileRef
+}
+
+func (c *npcTemplateChoice) String() string {
+	return c.title
+}
+
+// load returns the template for this choice, loading it if needed. Returns nil if no template was chosen.
+func (c *npcTemplateChoice) load() (*gurps.Template, error) {
+	if c.template == nil && c.ref != nil {
+		t, err := gurps.NewTemplateFromFile(c.ref.FileSystem, c.ref.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		c.template = t
+	}
+	return c.template, nil
+}
+
+// ShowNPCGenerator asks for an ancestry, a template and a point total, then generates one or more randomized characters
+// from them. A single character is opened as a new sheet, while a batch is written to a folder of the user's choosing.
+func ShowNPCGenerator() {
+	panel := unison.NewPanel()
+	panel.SetLayout(&unison.FlexLayout{
+		Columns:  2,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+
+	title := i18n.Text("Ancestry")
+	panel.AddChild(widget.NewFieldLeadingLabel(title))
+	fromTraits := i18n.Text("As specified by the template")
+	ancestryPopup := unison.NewPopupMenu[string]()
+	ancestryPopup.AddItem(fromTraits)
+	seen := make(map[string]bool)
+	for _, lib := range ancestry.AvailableAncestries(gurps.SettingsProvider.Libraries()) {
+		for _, one := range lib.List {
+			if !seen[one.Name] {
+				seen[one.Name] = true
+				ancestryPopup.AddItem(one.Name)
+			}
+		}
+	}
+	ancestryPopup.SelectIndex(0)
+	panel.AddChild(ancestryPopup)
+
+	title = i18n.Text("Template")
+	panel.AddChild(widget.NewFieldLeadingLabel(title))
+	templatePopup := unison.NewPopupMenu[*npcTemplateChoice]()
+	templatePopup.AddItem(&npcTemplateChoice{title: i18n.Text("None")})
+	for _, one := range OpenTemplates() {
+		templatePopup.AddItem(&npcTemplateChoice{
+			title:    one.Title(),
+			template: one.template,
+		})
+	}
+	for _, set := range library.ScanForDataFiles(gurps.SettingsProvider.Libraries(), library.TemplatesExt) {
+		for _, one := range set.List {
+			templatePopup.AddItem(&npcTemplateChoice{
+				title: fmt.Sprintf("%s (%s)", one.Name, set.Name),
+				ref:   one,
+			})
+		}
+	}
+	templatePopup.SelectIndex(0)
+	panel.AddChild(templatePopup)
+
+	points := gurps.SettingsProvider.GeneralSettings().InitialPoints
+	title = i18n.Text("Total Points")
+	panel.AddChild(widget.NewFieldLeadingLabel(title))
+	panel.AddChild(widget.NewDecimalField(nil, "", title,
+		func() fxp.Int { return points },
+		func(v fxp.Int) { points = v }, -gsettings.InitialPointsMax, gsettings.InitialPointsMax, false, false))
+
+	count := 1
+	title = i18n.Text("Count")
+	panel.AddChild(widget.NewFieldLeadingLabel(title))
+	countField := widget.NewIntegerField(nil, "", title,
+		func() int { return count },
+		func(v int) { count = v }, 1, maxNPCBatchSize, false, false)
+	countField.Tooltip = unison.NewTooltipWithText(i18n.Text("A single character is opened as a new sheet. More than one will be saved to a folder of your choosing."))
+	panel.AddChild(countField)
+
+	dialog, err := unison.NewDialog(nil, nil, panel, []*unison.DialogButtonInfo{
+		unison.NewCancelButtonInfo(),
+		unison.NewOKButtonInfoWithTitle(i18n.Text("Generate")),
+	})
+	if err != nil {
+		jot.Error(err)
+		return
+	}
+	if dialog.RunModal() != unison.ModalResponseOK {
+		return
+	}
+
+	g := &gurps.NPCGenerator{TotalPoints: points}
+	if choice, ok := ancestryPopup.Selected(); ok && choice != fromTraits {
+		if g.Ancestry = ancestry.Lookup(choice, gurps.SettingsProvider.Libraries()); g.Ancestry == nil {
+			unison.ErrorDialogWithMessage(i18n.Text("Unable to load ancestry"), choice)
+			return
+		}
+	}
+	if choice, ok := templatePopup.Selected(); ok {
+		if g.Template, err = choice.load(); err != nil {
+			unison.ErrorDialogWithError(i18n.Text("Unable to load template"), err)
+			return
+		}
+	}
+	if count == 1 {
+		entity := g.Generate()
+		workspace.DisplayNewDockable(nil, NewSheet(entity.Profile.Name+library.SheetExt, entity))
+		return
+	}
+	folderDialog := unison.NewOpenDialog()
+	folderDialog.SetResolvesAliases(true)
+	folderDialog.SetAllowsMultipleSelection(false)
+	folderDialog.SetCanChooseDirectories(true)
+	folderDialog.SetCanChooseFiles(false)
+	if !folderDialog.RunModal() {
+		return
+	}
+	paths, err := g.GenerateToFolder(folderDialog.Path(), count)
+	if err != nil {
+		unison.ErrorDialogWithError(fmt.Sprintf(i18n.Text("Unable to save all of the characters; %d were written"),
+			len(paths)), err)
+		return
+	}
+	showPreviewDialog(fmt.Sprintf(i18n.Text("%d characters were written to %s"), len(paths), folderDialog.Path()), nil)
+}
@@ -114,7 +114,10 @@ func NewMeleeWeaponsPageList(entity *gurps.Entity) *PageList[*gurps.Weapon] {
 
 // NewRangedWeaponsPageList creates the ranged weapons page list.
 func NewRangedWeaponsPageList(entity *gurps.Entity) *PageList[*gurps.Weapon] {
-	return newPageList(nil, editors.NewWeaponsProvider(entity, weapon.Ranged, true))
+	p := newPageList(nil, editors.NewWeaponsProvider(entity, weapon.Ranged, true))
+	p.installFireWeaponHandler()
+	p.installReloadWeaponHandler()
+	return p
 }
 
 func newPageList[T gurps.NodeConstraint[T]](owner widget.Rebuildable, provider ntable.TableProvider[T]) *PageList[T] {
@@ -167,6 +170,7 @@ func newPageList[T gurps.NodeConstraint[T]](owner widget.Rebuildable, provider n
 			func(_ any) { ntable.DuplicateSelection(p.Table) })
 	}
 	p.installOpenPageReferenceHandlers()
+	p.installRollHandler()
 	p.SetLayoutData(&unison.FlexLayoutData{
 		HAlign: unison.FillAlignment,
 		VAlign: unison.FillAlignment,
@@ -185,6 +189,43 @@ func (p *PageList[T]) installOpenPageReferenceHandlers() {
 		func(_ any) { editors.OpenEachPageRef(p.Table) })
 }
 
+func (p *PageList[T]) installRollHandler() {
+	p.InstallCmdHandlers(constants.RollItemID,
+		func(_ any) bool {
+			s := unison.Ancestor[*Sheet](p)
+			return s != nil && canRollSelection(s, p.Table)
+		},
+		func(_ any) {
+			if s := unison.Ancestor[*Sheet](p); s != nil {
+				rollSelection(s, p.Table)
+			}
+		})
+}
+
+func (p *PageList[T]) installFireWeaponHandler() {
+	if t, ok := (any(p.Table)).(*unison.Table[*ntable.Node[*gurps.Weapon]]); ok {
+		p.InstallCmdHandlers(constants.FireWeaponItemID,
+			func(_ any) bool { return canFireWeapon(t) },
+			func(_ any) {
+				if s := unison.Ancestor[*Sheet](p); s != nil {
+					fireWeapon(s, t)
+				}
+			})
+	}
+}
+
+func (p *PageList[T]) installReloadWeaponHandler() {
+	if t, ok := (any(p.Table)).(*unison.Table[*ntable.Node[*gurps.Weapon]]); ok {
+		p.InstallCmdHandlers(constants.ReloadWeaponItemID,
+			func(_ any) bool { return canReloadWeapon(t) },
+			func(_ any) {
+				if s := unison.Ancestor[*Sheet](p); s != nil {
+					reloadWeapon(s, t)
+				}
+			})
+	}
+}
+
 func (p *PageList[T]) installToggleDisabledHandler(owner widget.Rebuildable) {
 	if t, ok := (any(p.Table)).(*unison.Table[*ntable.Node[*gurps.Trait]]); ok {
 		p.InstallCmdHandlers(constants.ToggleStateItemID,
@@ -0,0 +1,64 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/unison"
+)
+
+type poolUndoData struct {
+	id     string
+	damage fxp.Int
+}
+
+// adjustPool changes the current value of the pool with the given attribute ID by delta, as an undoable edit.
+func (s *Sheet) adjustPool(id string, delta fxp.Int, editName string) {
+	attr, ok := s.entity.Attributes.Set[id]
+	if !ok || delta == 0 {
+		return
+	}
+	before := s.poolUndoData(id)
+	attr.Damage -= delta
+	s.addPoolUndo(editName, before)
+}
+
+// addPoolUndo records an undoable edit for a change made to a pool since 'before' was captured, then refreshes the
+// sheet.
+func (s *Sheet) addPoolUndo(editName string, before *poolUndoData) {
+	s.undoMgr.Add(&unison.UndoEdit[*poolUndoData]{
+		ID:         unison.NextUndoID(),
+		EditName:   editName,
+		UndoFunc:   func(e *unison.UndoEdit[*poolUndoData]) { s.applyPoolUndoData(e.BeforeData) },
+		RedoFunc:   func(e *unison.UndoEdit[*poolUndoData]) { s.applyPoolUndoData(e.AfterData) },
+		BeforeData: before,
+		AfterData:  s.poolUndoData(before.id),
+	})
+	s.Rebuild(true)
+	s.MarkModified()
+}
+
+func (s *Sheet) poolUndoData(id string) *poolUndoData {
+	data := &poolUndoData{id: id}
+	if attr, ok := s.entity.Attributes.Set[id]; ok {
+		data.damage = attr.Damage
+	}
+	return data
+}
+
+func (s *Sheet) applyPoolUndoData(data *poolUndoData) {
+	if attr, ok := s.entity.Attributes.Set[data.id]; ok {
+		attr.Damage = data.damage
+	}
+	s.Rebuild(true)
+	s.MarkModified()
+}
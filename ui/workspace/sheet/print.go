@@ -0,0 +1,106 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	gsettings "github.com/richardwilkes/gcs/v5/model/gurps/settings"
+	"github.com/richardwilkes/toolbox"
+	"github.com/richardwilkes/toolbox/desktop"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+	"github.com/richardwilkes/unison"
+)
+
+const savePrintAsPDFResponse = unison.ModalResponseUserBase
+
+// printPDF paginates the content using the page settings and hands it off to the system print pipeline. When no print
+// pipeline is available, or the user prefers it, the generated PDF is saved to a file instead.
+func printPDF(title, backingFilePath string, page *gsettings.Page, generator func(w io.Writer) error) {
+	cmd := systemPrintCommand(title)
+	buttons := []*unison.DialogButtonInfo{
+		unison.NewCancelButtonInfo(),
+		{Title: i18n.Text("Save as PDF…"), ResponseCode: savePrintAsPDFResponse},
+	}
+	var detail string
+	if cmd != nil {
+		buttons = append(buttons, unison.NewOKButtonInfoWithTitle(i18n.Text("Print")))
+		detail = i18n.Text("The document will be sent to the default printer.")
+	} else {
+		detail = i18n.Text("No system print service is available, so the document can only be saved as a PDF.")
+	}
+	msg := unison.NewMessagePanel(fmt.Sprintf(i18n.Text("Print %s?"), title),
+		fmt.Sprintf(i18n.Text("Paper: %s, %s\nMargins: %s top, %s left, %s bottom, %s right\n\n%s"), page.Size,
+			page.Orientation, page.TopMargin, page.LeftMargin, page.BottomMargin, page.RightMargin, detail))
+	dialog, err := unison.NewDialog(unison.DefaultDialogTheme.QuestionIcon, unison.DefaultDialogTheme.QuestionIconInk,
+		msg, buttons)
+	if err != nil {
+		jot.Error(err)
+		return
+	}
+	response := dialog.RunModal()
+	if response == unison.ModalResponseCancel {
+		return
+	}
+	var buffer bytes.Buffer
+	if err = generator(&buffer); err != nil {
+		unison.ErrorDialogWithError(i18n.Text("Unable to generate the printable document"), err)
+		return
+	}
+	if response == unison.ModalResponseOK && cmd != nil {
+		cmd.Stdin = &buffer
+		if out, cmdErr := cmd.CombinedOutput(); cmdErr != nil {
+			unison.ErrorDialogWithError(i18n.Text("Unable to print"),
+				errs.NewWithCause(strings.TrimSpace(string(out)), cmdErr))
+		}
+		return
+	}
+	saveDialog := unison.NewSaveDialog()
+	if dir := filepath.Dir(backingFilePath); dir != backingFilePath {
+		saveDialog.SetInitialDirectory(dir)
+	}
+	saveDialog.SetAllowedExtensions(".pdf")
+	if saveDialog.RunModal() {
+		path := saveDialog.Path()
+		if err = os.WriteFile(path, buffer.Bytes(), 0o640); err != nil {
+			unison.ErrorDialogWithError(i18n.Text("Unable to save PDF"), err)
+			return
+		}
+		if err = desktop.Open(path); err != nil {
+			unison.ErrorDialogWithError(i18n.Text("Unable to open PDF"), err)
+		}
+	}
+}
+
+// systemPrintCommand returns a command that will print PDF data fed to its standard input, or nil if no such command
+// is available on this platform.
+func systemPrintCommand(title string) *exec.Cmd {
+	if runtime.GOOS == toolbox.WindowsOS {
+		return nil
+	}
+	if path, err := exec.LookPath("lp"); err == nil {
+		return exec.Command(path, "-t", title)
+	}
+	if path, err := exec.LookPath("lpr"); err == nil {
+		return exec.Command(path, "-T", title)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/attribute"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/unison"
+)
+
+// Possible EventKind values.
+const (
+	SheetOpened EventKind = iota
+	SheetModified
+	SheetClosed
+)
+
+// EventKind identifies the kind of change an EventListener is being notified of.
+type EventKind uint8
+
+// EventListener is called on the UI thread when a sheet is opened, modified or closed.
+type EventListener func(s *Sheet, kind EventKind)
+
+var eventListener EventListener
+
+// SetEventListener sets the listener that will be notified of changes to sheets. Pass nil to remove it.
+func SetEventListener(listener EventListener) {
+	eventListener = listener
+}
+
+func notifyEventListener(s *Sheet, kind EventKind) {
+	if eventListener != nil {
+		eventListener(s, kind)
+	}
+}
+
+// String implements fmt.Stringer.
+func (k EventKind) String() string {
+	switch k {
+	case SheetOpened:
+		return "opened"
+	case SheetModified:
+		return "modified"
+	case SheetClosed:
+		return "closed"
+	default:
+		return ""
+	}
+}
+
+// AdjustPool changes the current value of the pool with the given attribute ID by delta. The change is undoable, just
+// as it would be had it been made from the sheet itself.
+func (s *Sheet) AdjustPool(attrID string, delta fxp.Int) error {
+	attr, ok := s.entity.Attributes.Set[attrID]
+	if !ok {
+		return errs.New(fmt.Sprintf(i18n.Text("no attribute with ID '%s'"), attrID))
+	}
+	def := attr.AttributeDef()
+	if def == nil || def.Type != attribute.Pool {
+		return errs.New(fmt.Sprintf(i18n.Text("attribute '%s' is not a pool"), attrID))
+	}
+	var editName string
+	if delta < 0 {
+		editName = fmt.Sprintf(i18n.Text("Decrease %s"), def.Name)
+	} else {
+		editName = fmt.Sprintf(i18n.Text("Increase %s"), def.Name)
+	}
+	s.adjustPool(attrID, delta, editName)
+	return nil
+}
+
+// SetEquipped sets the equipped state of the piece of equipment with the given ID, as an undoable edit.
+func (s *Sheet) SetEquipped(eqpID uuid.UUID, equipped bool) error {
+	eqp := s.findEquipment(eqpID)
+	if eqp == nil {
+		return errs.New(fmt.Sprintf(i18n.Text("no equipment with ID '%s'"), eqpID))
+	}
+	if eqp.Equipped == equipped {
+		return nil
+	}
+	before := &toggleEquippedList{Owner: s, List: []*equippedAdjuster{newEquippedAdjuster(eqp)}}
+	eqp.Equipped = equipped
+	after := &toggleEquippedList{Owner: s, List: []*equippedAdjuster{newEquippedAdjuster(eqp)}}
+	s.undoMgr.Add(&unison.UndoEdit[*toggleEquippedList]{
+		ID:         unison.NextUndoID(),
+		EditName:   i18n.Text("Toggle Equipped"),
+		UndoFunc:   func(edit toggleEquippedUndoEdit) { edit.BeforeData.Apply() },
+		RedoFunc:   func(edit toggleEquippedUndoEdit) { edit.AfterData.Apply() },
+		BeforeData: before,
+		AfterData:  after,
+	})
+	before.Finish()
+	return nil
+}
+
+// SetUses sets the number of uses remaining for the piece of equipment with the given ID, as an undoable edit.
+func (s *Sheet) SetUses(eqpID uuid.UUID, uses int) error {
+	eqp := s.findEquipment(eqpID)
+	if eqp == nil {
+		return errs.New(fmt.Sprintf(i18n.Text("no equipment with ID '%s'"), eqpID))
+	}
+	if uses < 0 || uses > eqp.MaxUses {
+		return errs.New(fmt.Sprintf(i18n.Text("uses must be between 0 and %d"), eqp.MaxUses))
+	}
+	if eqp.Uses == uses {
+		return nil
+	}
+	var name string
+	if uses < eqp.Uses {
+		name = i18n.Text("Decrease Uses")
+	} else {
+		name = i18n.Text("Increase Uses")
+	}
+	before := &adjustUsesList{Owner: s, List: []*usesAdjuster{newUsesAdjuster(eqp)}}
+	eqp.Uses = uses
+	after := &adjustUsesList{Owner: s, List: []*usesAdjuster{newUsesAdjuster(eqp)}}
+	s.undoMgr.Add(&unison.UndoEdit[*adjustUsesList]{
+		ID:         unison.NextUndoID(),
+		EditName:   name,
+		UndoFunc:   func(edit adjustUsesListUndoEdit) { edit.BeforeData.Apply() },
+		RedoFunc:   func(edit adjustUsesListUndoEdit) { edit.AfterData.Apply() },
+		BeforeData: before,
+		AfterData:  after,
+	})
+	s.MarkModified()
+	return nil
+}
+
+func (s *Sheet) findEquipment(id uuid.UUID) *gurps.Equipment {
+	var found *gurps.Equipment
+	f := func(eqp *gurps.Equipment) bool {
+		if eqp.ID == id {
+			found = eqp
+			return true
+		}
+		return false
+	}
+	gurps.Traverse(f, false, false, s.entity.CarriedEquipment...)
+	if found == nil {
+		gurps.Traverse(f, false, false, s.entity.OtherEquipment...)
+	}
+	return found
+}
@@ -0,0 +1,243 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/model/gurps/roll"
+	"github.com/richardwilkes/gcs/v5/model/gurps/trait"
+	"github.com/richardwilkes/gcs/v5/ui/widget"
+	"github.com/richardwilkes/gcs/v5/ui/widget/ntable"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+	"github.com/richardwilkes/unison"
+)
+
+// defaultRollColumn may be passed as the column to CanRoll() and Roll() to make the most natural roll for the data,
+// e.g. a roll against the skill level of a skill or a self-control roll for a trait.
+const defaultRollColumn = -1
+
+var _ ntable.Roller = &Sheet{}
+
+// CanRoll implements ntable.Roller.
+func (s *Sheet) CanRoll(data any, column int) bool {
+	switch item := data.(type) {
+	case *gurps.Skill:
+		return (column == defaultRollColumn || column == gurps.SkillLevelColumn) && !item.Container() &&
+			item.LevelData.Level > 0
+	case *gurps.Spell:
+		return (column == defaultRollColumn || column == gurps.SpellLevelColumn) && !item.Container() &&
+			item.LevelData.Level > 0
+	case *gurps.Weapon:
+		switch column {
+		case defaultRollColumn, gurps.WeaponSLColumn:
+			return item.SkillLevel(nil) > 0
+		case gurps.WeaponParryColumn:
+			_, ok := leadingLevel(item.ResolvedParry(nil))
+			return ok
+		case gurps.WeaponBlockColumn:
+			_, ok := leadingLevel(item.ResolvedBlock(nil))
+			return ok
+		case gurps.WeaponDamageColumn:
+			return item.Damage.ResolvedDamage(nil) != ""
+		}
+	case *gurps.Trait:
+		return column == defaultRollColumn && !item.Container() && item.CR != trait.None
+	}
+	return false
+}
+
+// Roll implements ntable.Roller.
+func (s *Sheet) Roll(data any, column int) {
+	switch item := data.(type) {
+	case *gurps.Skill:
+		s.makeSuccessRoll(item.String(), fxp.As[int](item.LevelData.Level), false)
+	case *gurps.Spell:
+		s.makeSuccessRoll(item.String(), fxp.As[int](item.LevelData.Level), false)
+	case *gurps.Weapon:
+		name := weaponRollName(item)
+		switch column {
+		case defaultRollColumn, gurps.WeaponSLColumn:
+			s.makeSuccessRoll(name, fxp.As[int](item.SkillLevel(nil)), false)
+		case gurps.WeaponParryColumn:
+			if level, ok := leadingLevel(item.ResolvedParry(nil)); ok {
+				s.makeSuccessRoll(fmt.Sprintf(i18n.Text("Parry with %s"), name), level, false)
+			}
+		case gurps.WeaponBlockColumn:
+			if level, ok := leadingLevel(item.ResolvedBlock(nil)); ok {
+				s.makeSuccessRoll(fmt.Sprintf(i18n.Text("Block with %s"), name), level, false)
+			}
+		case gurps.WeaponDamageColumn:
+			s.makeDamageRoll(name, &item.Damage)
+		}
+	case *gurps.Trait:
+		s.makeSuccessRoll(fmt.Sprintf(i18n.Text("Self-control for %s"), item.String()), int(item.CR), true)
+	}
+}
+
+// rollAttribute makes a success roll against the current value of the attribute.
+func (s *Sheet) rollAttribute(attrID string) {
+	if attr, ok := s.entity.Attributes.Set[attrID]; ok {
+		name := attrID
+		if def := attr.AttributeDef(); def != nil {
+			name = def.Name
+		}
+		s.makeSuccessRoll(name, fxp.As[int](attr.Current()), false)
+	}
+}
+
+// newAttributeRollLabel creates the label for an attribute, which may be clicked to roll against it. Basic Speed and
+// Basic Move aren't rolled against, so their labels are left alone.
+func newAttributeRollLabel(def *gurps.AttributeDef) *unison.Label {
+	label := widget.NewPageLabel(def.CombinedName())
+	if id := def.ID(); id != gid.BasicSpeed && id != gid.BasicMove {
+		label.Tooltip = unison.NewTooltipWithText(i18n.Text("Click to roll"))
+		label.MouseDownCallback = func(_ unison.Point, _, _ int, _ unison.Modifiers) bool {
+			if s := unison.Ancestor[*Sheet](label); s != nil {
+				s.rollAttribute(id)
+			}
+			return true
+		}
+	}
+	return label
+}
+
+func weaponRollName(w *gurps.Weapon) string {
+	if w.Usage != "" {
+		return fmt.Sprintf("%s (%s)", w.String(), w.Usage)
+	}
+	return w.String()
+}
+
+// leadingLevel extracts the level at the start of a resolved defense, e.g. 9 from "9F".
+func leadingLevel(text string) (int, bool) {
+	value, remainder := fxp.Extract(text)
+	if remainder == text {
+		return 0, false
+	}
+	return fxp.As[int](value), true
+}
+
+// makeSuccessRoll lets the user choose any situational modifiers that apply, then makes the roll and records it.
+func (s *Sheet) makeSuccessRoll(description string, level int, noCriticals bool) {
+	modifiers, ok := s.chooseRollModifiers(description, level)
+	if !ok {
+		return
+	}
+	r := roll.NewSuccessRoll(description, level, modifiers, noCriticals)
+	s.addRollLogEntry(&rollLogEntry{
+		when:      r.When,
+		text:      r.String(),
+		succeeded: r.Outcome.Succeeded(),
+		failed:    !r.Outcome.Succeeded(),
+	})
+}
+
+func (s *Sheet) makeDamageRoll(description string, damage *gurps.WeaponDamage) {
+	damageType := damage.Type
+	if damage.ArmorDivisor != 0 && damage.ArmorDivisor != fxp.One {
+		damageType = "(" + damage.ArmorDivisor.String() + ") " + damageType
+	}
+	r, err := roll.NewDamageRoll(description, damage.ResolvedDamage(nil), damageType)
+	if err != nil {
+		unison.ErrorDialogWithError(i18n.Text("Unable to roll damage"), err)
+		return
+	}
+	s.addRollLogEntry(&rollLogEntry{
+		when: r.When,
+		text: r.String(),
+	})
+}
+
+func (s *Sheet) addRollLogEntry(entry *rollLogEntry) {
+	if entry.when.IsZero() {
+		entry.when = time.Now()
+	}
+	s.rollLog = append(s.rollLog, entry)
+	s.showRollLog()
+}
+
+// chooseRollModifiers asks the user which of the character's conditional modifiers apply to the roll, along with any
+// other modifier they'd like to add. Returns false if the user cancelled.
+func (s *Sheet) chooseRollModifiers(description string, level int) ([]roll.Modifier, bool) {
+	conditional := s.entity.ConditionalModifiers()
+	selected := make([]bool, len(conditional))
+	var other int
+
+	panel := unison.NewPanel()
+	panel.SetLayout(&unison.FlexLayout{
+		Columns:  2,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	label := unison.NewLabel()
+	label.Text = fmt.Sprintf(i18n.Text("Roll against %s (%d)"), description, level)
+	label.SetLayoutData(&unison.FlexLayoutData{HSpan: 2})
+	panel.AddChild(label)
+	for i, one := range conditional {
+		index := i
+		panel.AddChild(unison.NewPanel())
+		panel.AddChild(widget.NewCheckBox(nil, "", fmt.Sprintf("%s %s", one.Total().StringWithSign(), one.From),
+			func() unison.CheckState { return unison.CheckStateFromBool(selected[index]) },
+			func(state unison.CheckState) { selected[index] = state == unison.OnCheckState }))
+	}
+	title := i18n.Text("Other Modifier")
+	panel.AddChild(widget.NewFieldLeadingLabel(title))
+	panel.AddChild(widget.NewIntegerField(nil, "", title,
+		func() int { return other },
+		func(v int) { other = v }, -99, 99, true, false))
+
+	dialog, err := unison.NewDialog(nil, nil, panel, []*unison.DialogButtonInfo{
+		unison.NewCancelButtonInfo(),
+		unison.NewOKButtonInfoWithTitle(i18n.Text("Roll")),
+	})
+	if err != nil {
+		jot.Error(err)
+		return nil, false
+	}
+	if dialog.RunModal() != unison.ModalResponseOK {
+		return nil, false
+	}
+	var modifiers []roll.Modifier
+	for i, one := range conditional {
+		if selected[i] {
+			modifiers = append(modifiers, roll.Modifier{
+				Description: one.From,
+				Amount:      fxp.As[int](one.Total()),
+			})
+		}
+	}
+	if other != 0 {
+		modifiers = append(modifiers, roll.Modifier{
+			Description: i18n.Text("other"),
+			Amount:      other,
+		})
+	}
+	return modifiers, true
+}
+
+// canRollSelection returns true if the selection within the table holds a single row that can be rolled for.
+func canRollSelection[T gurps.NodeConstraint[T]](s *Sheet, table *unison.Table[*ntable.Node[T]]) bool {
+	rows := table.SelectedRows(false)
+	return len(rows) == 1 && s.CanRoll(rows[0].Data(), defaultRollColumn)
+}
+
+func rollSelection[T gurps.NodeConstraint[T]](s *Sheet, table *unison.Table[*ntable.Node[T]]) {
+	if rows := table.SelectedRows(false); len(rows) == 1 {
+		s.Roll(rows[0].Data(), defaultRollColumn)
+	}
+}
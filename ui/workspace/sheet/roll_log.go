@@ -0,0 +1,184 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/richardwilkes/gcs/v5/model/theme"
+	"github.com/richardwilkes/gcs/v5/res"
+	"github.com/richardwilkes/gcs/v5/ui/widget"
+	"github.com/richardwilkes/gcs/v5/ui/workspace"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/unison"
+)
+
+var (
+	_ unison.Dockable      = &RollLog{}
+	_ widget.GroupedCloser = &RollLog{}
+)
+
+type rollLogEntry struct {
+	when      time.Time
+	text      string
+	succeeded bool
+	failed    bool
+}
+
+// RollLog holds a dockable that shows the rolls made from a sheet, most recent first.
+type RollLog struct {
+	unison.Panel
+	owner   *Sheet
+	content *unison.Panel
+}
+
+// showRollLog shows the roll log for the sheet, creating it if needed. The log is placed beside the sheet, rather
+// than stacked with it, so that both remain visible.
+func (s *Sheet) showRollLog() {
+	ws, _, found := workspace.Activate(func(d unison.Dockable) bool {
+		if l, ok := d.(*RollLog); ok && l.owner == s {
+			l.rebuild()
+			return true
+		}
+		return false
+	})
+	if found {
+		s.refocus()
+		return
+	}
+	if ws == nil {
+		return
+	}
+	l := &RollLog{owner: s}
+	l.Self = l
+	l.SetLayout(&unison.FlexLayout{Columns: 1})
+
+	toolbar := unison.NewPanel()
+	toolbar.SetBorder(unison.NewCompoundBorder(unison.NewLineBorder(unison.DividerColor, 0, unison.Insets{Bottom: 1},
+		false), unison.NewEmptyBorder(unison.StdInsets())))
+	toolbar.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+	clearButton := unison.NewSVGButton(res.TrashSVG)
+	clearButton.Tooltip = unison.NewTooltipWithText(i18n.Text("Clear the log"))
+	clearButton.ClickCallback = func() {
+		l.owner.rollLog = nil
+		l.rebuild()
+	}
+	toolbar.AddChild(clearButton)
+	toolbar.SetLayout(&unison.FlexLayout{Columns: len(toolbar.Children())})
+	l.AddChild(toolbar)
+
+	l.content = unison.NewPanel()
+	l.content.SetBorder(unison.NewEmptyBorder(unison.NewUniformInsets(unison.StdHSpacing * 2)))
+	l.content.SetLayout(&unison.FlexLayout{
+		Columns:  2,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	scroller := unison.NewScrollPanel()
+	scroller.SetContent(l.content, unison.FillBehavior, unison.FillBehavior)
+	scroller.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		VAlign: unison.FillAlignment,
+		HGrab:  true,
+		VGrab:  true,
+	})
+	l.AddChild(scroller)
+	l.rebuild()
+
+	if dc := unison.Ancestor[*unison.DockContainer](s); dc != nil {
+		ws.DocumentDock.DockTo(l, dc, unison.RightSide)
+	} else {
+		ws.DocumentDock.DockTo(l, nil, unison.RightSide)
+	}
+	s.refocus()
+}
+
+// refocus returns the focus to the sheet, which showing the roll log takes away.
+func (s *Sheet) refocus() {
+	if dc := unison.Ancestor[*unison.DockContainer](s); dc != nil {
+		dc.SetCurrentDockable(s)
+		dc.AcquireFocus()
+	}
+}
+
+func (l *RollLog) rebuild() {
+	l.content.RemoveAllChildren()
+	if len(l.owner.rollLog) == 0 {
+		label := unison.NewLabel()
+		label.Text = i18n.Text("No rolls have been made yet.")
+		l.content.AddChild(label)
+		l.content.AddChild(unison.NewPanel())
+	}
+	for i := len(l.owner.rollLog) - 1; i >= 0; i-- {
+		entry := l.owner.rollLog[i]
+		when := unison.NewLabel()
+		when.Text = entry.when.Format(time.Kitchen)
+		when.SetLayoutData(&unison.FlexLayoutData{VAlign: unison.StartAlignment})
+		l.content.AddChild(when)
+		text := unison.NewLabel()
+		text.Text = entry.text
+		switch {
+		case entry.succeeded:
+			text.OnBackgroundInk = theme.AccentColor
+		case entry.failed:
+			text.OnBackgroundInk = unison.ErrorColor
+		}
+		l.content.AddChild(text)
+	}
+	l.MarkForLayoutAndRedraw()
+	l.ValidateLayout()
+}
+
+// TitleIcon implements unison.Dockable
+func (l *RollLog) TitleIcon(suggestedSize unison.Size) unison.Drawable {
+	return &unison.DrawableSVG{
+		SVG:  res.RandomizeSVG,
+		Size: suggestedSize,
+	}
+}
+
+// Title implements unison.Dockable
+func (l *RollLog) Title() string {
+	return fmt.Sprintf(i18n.Text("Rolls for %s"), l.owner.Title())
+}
+
+// Tooltip implements unison.Dockable
+func (l *RollLog) Tooltip() string {
+	return ""
+}
+
+// Modified implements unison.Dockable
+func (l *RollLog) Modified() bool {
+	return false
+}
+
+// CloseWithGroup implements widget.GroupedCloser
+func (l *RollLog) CloseWithGroup(other unison.Paneler) bool {
+	return l.owner != nil && l.owner == other
+}
+
+// MayAttemptClose implements unison.TabCloser
+func (l *RollLog) MayAttemptClose() bool {
+	return true
+}
+
+// AttemptClose implements unison.TabCloser
+func (l *RollLog) AttemptClose() bool {
+	if dc := unison.Ancestor[*unison.DockContainer](l); dc != nil {
+		dc.Close(l)
+	}
+	return true
+}
@@ -73,7 +73,7 @@ func (p *SecondaryAttrPanel) rebuild(attrs *gurps.AttributeDefs) {
 		}
 		p.AddChild(p.createPointsField(attr))
 		p.AddChild(p.createValueField(def, attr))
-		p.AddChild(widget.NewPageLabel(def.CombinedName()))
+		p.AddChild(newAttributeRollLabel(def))
 	}
 }
 
@@ -13,14 +13,17 @@ package sheet
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/richardwilkes/gcs/v5/constants"
 	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/export"
 	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
 	gsettings "github.com/richardwilkes/gcs/v5/model/gurps/settings"
+	"github.com/richardwilkes/gcs/v5/model/gurps/validation"
 	"github.com/richardwilkes/gcs/v5/model/library"
 	"github.com/richardwilkes/gcs/v5/model/settings"
 	"github.com/richardwilkes/gcs/v5/model/theme"
@@ -60,6 +63,13 @@ type Sheet struct {
 	crc                  uint64
 	scale                int
 	scaleField           *widget.PercentageField
+	validationButton     *unison.Button
+	issues               []*validation.Issue
+	campaignButton       *unison.Button
+	rollLog              []*rollLogEntry
+	campaign             *gurps.Campaign
+	campaignPath         string
+	campaignErr          error
 	pages                *unison.Panel
 	PortraitPanel        *PortraitPanel
 	IdentityPanel        *IdentityPanel
@@ -132,12 +142,14 @@ func NewSheet(filePath string, entity *gurps.Entity) *Sheet {
 		undoMgr:           unison.NewUndoManager(200, func(err error) { jot.Error(err) }),
 		scroll:            unison.NewScrollPanel(),
 		entity:            entity,
-		crc:               entity.CRC64(),
 		scale:             settings.Global().General.InitialSheetUIScale,
 		pages:             unison.NewPanel(),
 		needsSaveAsPrompt: true,
 	}
 	s.Self = s
+	s.loadCampaign()
+	// Applying the campaign's settings may have altered the entity, so the baseline is only taken afterward.
+	s.crc = entity.CRC64()
 	s.SetLayout(&unison.FlexLayout{
 		Columns: 1,
 		HAlign:  unison.FillAlignment,
@@ -193,6 +205,11 @@ func NewSheet(filePath string, entity *gurps.Entity) *Sheet {
 	toolbar.AddChild(sheetSettingsButton)
 	toolbar.AddChild(attributesButton)
 	toolbar.AddChild(bodyTypeButton)
+	toolbar.AddChild(s.createCampaignButton())
+	toolbar.AddChild(s.createAdvancementButton())
+	toolbar.AddChild(s.createLoadoutsButton())
+	toolbar.AddChild(s.createDamageButton())
+	toolbar.AddChild(s.createValidationButton())
 	toolbar.AddChild(s.scaleField)
 	toolbar.SetLayout(&unison.FlexLayout{
 		Columns:  len(toolbar.Children()),
@@ -206,6 +223,7 @@ func NewSheet(filePath string, entity *gurps.Entity) *Sheet {
 
 	s.InstallCmdHandlers(constants.SaveItemID, func(_ any) bool { return s.Modified() }, func(_ any) { s.save(false) })
 	s.InstallCmdHandlers(constants.SaveAsItemID, unison.AlwaysEnabled, func(_ any) { s.save(true) })
+	s.InstallCmdHandlers(constants.PrintItemID, unison.AlwaysEnabled, func(_ any) { s.print() })
 	s.installNewItemCmdHandlers(constants.NewTraitItemID, constants.NewTraitContainerItemID, s.Traits)
 	s.installNewItemCmdHandlers(constants.NewSkillItemID, constants.NewSkillContainerItemID, s.Skills)
 	s.installNewItemCmdHandlers(constants.NewTechniqueItemID, -1, s.Skills)
@@ -223,7 +241,14 @@ func NewSheet(filePath string, entity *gurps.Entity) *Sheet {
 			}, gurps.NewNaturalAttacks(s.entity, nil))
 	})
 	s.InstallCmdHandlers(constants.SwapDefaultsItemID, s.canSwapDefaults, s.swapDefaults)
+	InstallUnitConversionHandlers(s, func(c *gurps.UnitConverter) { c.Entity(s.entity) })
 
+	unison.InvokeTask(func() {
+		// Only sheets that were placed into the workspace count as having been opened.
+		if unison.Ancestor[*unison.DockContainer](s) != nil {
+			notifyEventListener(s, SheetOpened)
+		}
+	})
 	return s
 }
 
@@ -305,6 +330,9 @@ func (s *Sheet) MarkModified() {
 				dc.UpdateTitle(s)
 			}
 			s.scroll.SetPosition(h, v)
+			syncCombatTrackers(s)
+			syncShops(s)
+			notifyEventListener(s, SheetModified)
 			s.awaitingUpdate = false
 		}, time.Millisecond*100)
 	}
@@ -334,18 +362,26 @@ func (s *Sheet) AttemptClose() bool {
 	if dc := unison.Ancestor[*unison.DockContainer](s); dc != nil {
 		dc.Close(s)
 	}
+	removeFromCombatTrackers(s)
+	removeFromShops(s)
+	notifyEventListener(s, SheetClosed)
 	return true
 }
 
+func (s *Sheet) print() {
+	page := gurps.SheetSettingsFor(s.entity).Page
+	printPDF(s.Title(), s.path, page, func(w io.Writer) error { return export.WriteEntityPDF(s.entity, page, w) })
+}
+
 func (s *Sheet) save(forceSaveAs bool) bool {
 	success := false
 	if forceSaveAs || s.needsSaveAsPrompt {
-		success = workspace.SaveDockableAs(s, library.SheetExt, s.entity.Save, func(path string) {
+		success = workspace.SaveDockableAs(s, library.SheetExt, s.saveEntity, func(path string) {
 			s.crc = s.entity.CRC64()
 			s.path = path
 		})
 	} else {
-		success = workspace.SaveDockable(s, s.entity.Save, func() { s.crc = s.entity.CRC64() })
+		success = workspace.SaveDockable(s, s.saveEntity, func() { s.crc = s.entity.CRC64() })
 	}
 	if success {
 		s.needsSaveAsPrompt = false
@@ -353,6 +389,16 @@ func (s *Sheet) save(forceSaveAs bool) bool {
 	return success
 }
 
+// saveEntity writes the entity to the given path, first updating its campaign reference so that it remains valid
+// relative to the new location and capturing any pending entries for the advancement log.
+func (s *Sheet) saveEntity(filePath string) error {
+	s.entity.CaptureAdvancement()
+	if s.campaignPath != "" {
+		s.entity.Campaign = gurps.CampaignRef(filePath, s.campaignPath)
+	}
+	return s.entity.Save(filePath)
+}
+
 func (s *Sheet) createTopBlock() *Page {
 	p := NewPage(s.entity)
 	p.AddChild(s.createFirstRow())
@@ -700,6 +746,7 @@ func (s *Sheet) Rebuild(full bool) {
 		s.createLists()
 	}
 	widget.DeepSync(s)
+	s.updateValidation()
 	if dc := unison.Ancestor[*unison.DockContainer](s); dc != nil {
 		dc.UpdateTitle(s)
 	}
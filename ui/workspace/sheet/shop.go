@@ -0,0 +1,702 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/library"
+	"github.com/richardwilkes/gcs/v5/res"
+	"github.com/richardwilkes/gcs/v5/ui/widget"
+	"github.com/richardwilkes/gcs/v5/ui/widget/ntable"
+	"github.com/richardwilkes/gcs/v5/ui/workspace"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/xio/fs"
+	"github.com/richardwilkes/unison"
+)
+
+const (
+	shopNameColumn = iota
+	shopTLColumn
+	shopLCColumn
+	shopPriceColumn
+	shopRestrictionColumn
+)
+
+const (
+	shopOwnedNameColumn = iota
+	shopOwnedQuantityColumn
+	shopOwnedOfferColumn
+)
+
+var (
+	_ unison.Dockable               = &Shop{}
+	_ unison.TabCloser              = &Shop{}
+	_ unison.TableRowData[*shopRow] = &shopRow{}
+)
+
+// Shop holds a dockable that offers the contents of an equipment library for sale to the character of an open sheet,
+// and buys equipment back from it. Purchases and sales are made through the sheet, so they may be undone there.
+type Shop struct {
+	unison.Panel
+	path          string
+	shop          *gurps.Shop
+	customer      *Sheet
+	showAll       bool
+	open          map[uuid.UUID]bool
+	customerPopup *unison.PopupMenu[*shopChoice[*Sheet]]
+	pursePopup    *unison.PopupMenu[*shopChoice[*gurps.Equipment]]
+	fundsField    *widget.DecimalField
+	stockTable    *unison.Table[*shopRow]
+	ownedTable    *unison.Table[*shopRow]
+	statusLabel   *unison.Label
+}
+
+type shopChoice[T comparable] struct {
+	title string
+	value T
+}
+
+func (c *shopChoice[T]) String() string {
+	return c.title
+}
+
+// OpenShop asks for an equipment library and opens it as a shop, unless it is already open as one.
+func OpenShop() {
+	dialog := unison.NewOpenDialog()
+	dialog.SetResolvesAliases(true)
+	dialog.SetAllowedExtensions(library.EquipmentExt)
+	dialog.SetAllowsMultipleSelection(false)
+	dialog.SetCanChooseDirectories(false)
+	dialog.SetCanChooseFiles(true)
+	if !dialog.RunModal() {
+		return
+	}
+	p, err := filepath.Abs(dialog.Path())
+	if err != nil {
+		unison.ErrorDialogWithError(i18n.Text("Unable to open shop"), err)
+		return
+	}
+	ws, _, found := workspace.Activate(func(d unison.Dockable) bool {
+		shop, ok := d.(*Shop)
+		return ok && shop.path == p
+	})
+	if found || ws == nil {
+		return
+	}
+	var stock []*gurps.Equipment
+	if stock, err = gurps.NewEquipmentFromFile(os.DirFS(filepath.Dir(p)), filepath.Base(p)); err != nil {
+		unison.ErrorDialogWithError(i18n.Text("Unable to open shop"), err)
+		return
+	}
+	workspace.DisplayNewDockable(nil, newShop(p, stock))
+}
+
+func newShop(filePath string, stock []*gurps.Equipment) *Shop {
+	d := &Shop{
+		path: filePath,
+		shop: gurps.NewShop(stock),
+		open: make(map[uuid.UUID]bool),
+	}
+	d.Self = d
+	d.SetLayout(&unison.FlexLayout{Columns: 1})
+	d.AddChild(d.createToolbar())
+
+	var scroll *unison.ScrollPanel
+	d.stockTable, scroll = d.createTable([]unison.TableColumnHeader[*shopRow]{
+		unison.NewTableColumnHeader[*shopRow](i18n.Text("For Sale"), ""),
+		unison.NewTableColumnHeader[*shopRow](i18n.Text("TL"), i18n.Text("Tech Level")),
+		unison.NewTableColumnHeader[*shopRow](i18n.Text("LC"), i18n.Text("Legality Class")),
+		unison.NewTableColumnHeader[*shopRow](i18n.Text("Price"), i18n.Text("The price, including any contents")),
+		unison.NewTableColumnHeader[*shopRow](i18n.Text("Availability"), i18n.Text("Why the item can't be bought")),
+	})
+	d.AddChild(scroll)
+	d.AddChild(d.createButtonBar(i18n.Text("Buy"), d.buy))
+
+	d.ownedTable, scroll = d.createTable([]unison.TableColumnHeader[*shopRow]{
+		unison.NewTableColumnHeader[*shopRow](i18n.Text("Owned"), ""),
+		unison.NewTableColumnHeader[*shopRow](i18n.Text("#"), i18n.Text("Quantity")),
+		unison.NewTableColumnHeader[*shopRow](i18n.Text("Offer"), i18n.Text("What the shop will pay, including any contents")),
+	})
+	d.AddChild(scroll)
+	d.statusLabel = unison.NewLabel()
+	bar := d.createButtonBar(i18n.Text("Sell"), d.sell)
+	bar.AddChildAtIndex(d.statusLabel, 0)
+	d.statusLabel.SetLayoutData(&unison.FlexLayoutData{HGrab: true, VAlign: unison.MiddleAlignment})
+	bar.SetLayout(&unison.FlexLayout{
+		Columns:  2,
+		HSpacing: unison.StdHSpacing,
+	})
+	d.AddChild(bar)
+
+	if sheets := OpenSheets(); len(sheets) != 0 {
+		d.customer = sheets[0]
+	}
+	d.rebuild()
+	return d
+}
+
+func (d *Shop) createToolbar() *unison.Panel {
+	toolbar := unison.NewPanel()
+	toolbar.SetBorder(unison.NewCompoundBorder(unison.NewLineBorder(unison.DividerColor, 0, unison.Insets{Bottom: 1},
+		false), unison.NewEmptyBorder(unison.StdInsets())))
+	toolbar.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+	toolbar.SetLayout(&unison.FlexLayout{
+		Columns:  6,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+
+	toolbar.AddChild(widget.NewFieldLeadingLabel(i18n.Text("Customer")))
+	d.customerPopup = unison.NewPopupMenu[*shopChoice[*Sheet]]()
+	d.customerPopup.MouseDownCallback = func(where unison.Point, button, clickCount int, mod unison.Modifiers) bool {
+		d.rebuild()
+		return d.customerPopup.DefaultMouseDown(where, button, clickCount, mod)
+	}
+	toolbar.AddChild(d.customerPopup)
+
+	toolbar.AddChild(widget.NewFieldLeadingLabel(i18n.Text("Pay With")))
+	d.pursePopup = unison.NewPopupMenu[*shopChoice[*gurps.Equipment]]()
+	d.pursePopup.Tooltip = unison.NewTooltipWithText(i18n.Text("Equipment marked as coins may be used to pay, as may the character's funds"))
+	toolbar.AddChild(d.pursePopup)
+
+	title := i18n.Text("Funds")
+	toolbar.AddChild(widget.NewFieldLeadingLabel(title))
+	d.fundsField = widget.NewDecimalField(nil, "", title,
+		func() fxp.Int {
+			if d.customer == nil {
+				return 0
+			}
+			entity := d.customer.entity
+			if c := entity.SheetSettings.Currency(entity.SheetSettings.DisplayCurrency); c != nil {
+				return c.FromBase(entity.Funds)
+			}
+			return entity.Funds
+		},
+		func(value fxp.Int) {
+			if d.customer == nil {
+				return
+			}
+			entity := d.customer.entity
+			if c := entity.SheetSettings.Currency(entity.SheetSettings.DisplayCurrency); c != nil {
+				value = c.ToBase(value)
+			}
+			if entity.Funds != value {
+				entity.Funds = value
+				d.customer.MarkModified()
+			}
+		}, 0, fxp.Max, false, false)
+	d.fundsField.Tooltip = unison.NewTooltipWithText(i18n.Text("Money the character has that isn't represented by equipment, in the sheet's display currency"))
+	toolbar.AddChild(d.fundsField)
+
+	title = i18n.Text("Prices")
+	toolbar.AddChild(widget.NewFieldLeadingLabel(title))
+	priceField := widget.NewPercentageField(nil, "", title,
+		func() int { return fxp.As[int](d.shop.PriceMultiplier.Mul(fxp.Hundred)) },
+		func(value int) {
+			d.shop.PriceMultiplier = fxp.From(value).Div(fxp.Hundred)
+			d.rebuild()
+		}, 1, 10000, false, false)
+	priceField.Tooltip = unison.NewTooltipWithText(i18n.Text("The percentage of an item's value the shop charges for it"))
+	toolbar.AddChild(priceField)
+
+	title = i18n.Text("Buys At")
+	toolbar.AddChild(widget.NewFieldLeadingLabel(title))
+	sellField := widget.NewPercentageField(nil, "", title,
+		func() int { return fxp.As[int](d.shop.SellRate.Mul(fxp.Hundred)) },
+		func(value int) {
+			d.shop.SellRate = fxp.From(value).Div(fxp.Hundred)
+			d.rebuild()
+		}, 0, 1000, false, false)
+	sellField.Tooltip = unison.NewTooltipWithText(i18n.Text("The percentage of an item's value the shop pays for it"))
+	toolbar.AddChild(sellField)
+
+	showAll := widget.NewCheckBox(nil, "", i18n.Text("Show items the campaign doesn't allow"),
+		func() unison.CheckState { return unison.CheckStateFromBool(d.showAll) },
+		func(state unison.CheckState) {
+			d.showAll = state == unison.OnCheckState
+			d.rebuild()
+		})
+	showAll.SetLayoutData(&unison.FlexLayoutData{HSpan: 2})
+	toolbar.AddChild(showAll)
+	return toolbar
+}
+
+func (d *Shop) createTable(headers []unison.TableColumnHeader[*shopRow]) (*unison.Table[*shopRow], *unison.ScrollPanel) {
+	table := unison.NewTable[*shopRow](&unison.SimpleTableModel[*shopRow]{})
+	table.HierarchyColumnIndex = 0
+	table.ColumnSizes = make([]unison.ColumnSize, len(headers))
+	for i := range table.ColumnSizes {
+		_, pref, _ := headers[i].AsPanel().Sizes(unison.Size{})
+		pref.Width += table.Padding.Left + table.Padding.Right
+		table.ColumnSizes[i].AutoMinimum = pref.Width
+		table.ColumnSizes[i].AutoMaximum = 800
+		table.ColumnSizes[i].Minimum = pref.Width
+		table.ColumnSizes[i].Maximum = 10000
+	}
+	mouseUpCallback := table.MouseUpCallback
+	table.MouseUpCallback = func(where unison.Point, button int, mod unison.Modifiers) bool {
+		result := mouseUpCallback(where, button, mod)
+		d.updateStatus()
+		return result
+	}
+	keyDownCallback := table.KeyDownCallback
+	table.KeyDownCallback = func(keyCode unison.KeyCode, mod unison.Modifiers, repeat bool) bool {
+		result := keyDownCallback(keyCode, mod, repeat)
+		d.updateStatus()
+		return result
+	}
+	header := unison.NewTableHeader(table, headers...)
+	header.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		VAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+	scroll := unison.NewScrollPanel()
+	scroll.SetColumnHeader(header)
+	scroll.SetContent(table, unison.FillBehavior, unison.FillBehavior)
+	scroll.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		VAlign: unison.FillAlignment,
+		HGrab:  true,
+		VGrab:  true,
+	})
+	return table, scroll
+}
+
+func (d *Shop) createButtonBar(title string, clickCallback func()) *unison.Panel {
+	bar := unison.NewPanel()
+	bar.SetBorder(unison.NewEmptyBorder(unison.StdInsets()))
+	bar.SetLayoutData(&unison.FlexLayoutData{
+		HAlign: unison.FillAlignment,
+		HGrab:  true,
+	})
+	bar.SetLayout(&unison.FlexLayout{
+		Columns: 1,
+		HAlign:  unison.EndAlignment,
+	})
+	b := unison.NewButton()
+	b.Text = title
+	b.ClickCallback = clickCallback
+	bar.AddChild(b)
+	return bar
+}
+
+// TitleIcon implements unison.Dockable
+func (d *Shop) TitleIcon(suggestedSize unison.Size) unison.Drawable {
+	return &unison.DrawableSVG{
+		SVG:  res.CoinsSVG,
+		Size: suggestedSize,
+	}
+}
+
+// Title implements unison.Dockable
+func (d *Shop) Title() string {
+	return fmt.Sprintf(i18n.Text("%s Shop"), fs.BaseName(d.path))
+}
+
+// Tooltip implements unison.Dockable
+func (d *Shop) Tooltip() string {
+	return d.path
+}
+
+// Modified implements unison.Dockable
+func (d *Shop) Modified() bool {
+	return false
+}
+
+// MayAttemptClose implements unison.TabCloser
+func (d *Shop) MayAttemptClose() bool {
+	return true
+}
+
+// AttemptClose implements unison.TabCloser
+func (d *Shop) AttemptClose() bool {
+	if dc := unison.Ancestor[*unison.DockContainer](d); dc != nil {
+		dc.Close(d)
+	}
+	return true
+}
+
+func (d *Shop) entity() *gurps.Entity {
+	if d.customer != nil {
+		return d.customer.entity
+	}
+	return nil
+}
+
+func (d *Shop) campaign() *gurps.Campaign {
+	if d.customer != nil {
+		return d.customer.campaign
+	}
+	return nil
+}
+
+func (d *Shop) purse() *gurps.Equipment {
+	if choice, ok := d.pursePopup.Selected(); ok {
+		return choice.value
+	}
+	return nil
+}
+
+// rebuild brings the popups and tables up to date with the open sheets and the current customer's state.
+func (d *Shop) rebuild() {
+	sheets := OpenSheets()
+	found := false
+	for _, one := range sheets {
+		if one == d.customer {
+			found = true
+			break
+		}
+	}
+	if !found {
+		d.customer = nil
+		if len(sheets) != 0 {
+			d.customer = sheets[0]
+		}
+	}
+
+	d.customerPopup.SelectionCallback = nil
+	d.customerPopup.RemoveAllItems()
+	if len(sheets) == 0 {
+		d.customerPopup.AddDisabledItem(&shopChoice[*Sheet]{title: i18n.Text("No open sheets")})
+		d.customerPopup.SelectIndex(0)
+	}
+	for i, one := range sheets {
+		d.customerPopup.AddItem(&shopChoice[*Sheet]{title: one.Title(), value: one})
+		if one == d.customer {
+			d.customerPopup.SelectIndex(i)
+		}
+	}
+	d.customerPopup.SelectionCallback = func(_ int, item *shopChoice[*Sheet]) {
+		d.customer = item.value
+		d.rebuild()
+	}
+
+	purse := d.purse()
+	d.pursePopup.SelectionCallback = nil
+	d.pursePopup.RemoveAllItems()
+	d.pursePopup.AddItem(&shopChoice[*gurps.Equipment]{title: i18n.Text("Funds")})
+	d.pursePopup.SelectIndex(0)
+	if entity := d.entity(); entity != nil {
+		for _, one := range entity.Purses() {
+			d.pursePopup.AddItem(&shopChoice[*gurps.Equipment]{title: one.String(), value: one})
+			if one == purse {
+				d.pursePopup.SelectIndex(d.pursePopup.ItemCount() - 1)
+			}
+		}
+	}
+	d.pursePopup.SelectionCallback = func(_ int, _ *shopChoice[*gurps.Equipment]) { d.updateStatus() }
+
+	d.fundsField.SetEnabled(d.customer != nil)
+	d.fundsField.Sync()
+	d.syncTable(d.stockTable, d.stockRows(nil, d.shop.Stock))
+	var owned []*shopRow
+	if entity := d.entity(); entity != nil {
+		owned = d.ownedRows(nil, entity.CarriedEquipment)
+		owned = append(owned, d.ownedRows(nil, entity.OtherEquipment)...)
+	}
+	d.syncTable(d.ownedTable, owned)
+	d.updateStatus()
+	d.MarkForLayoutAndRedraw()
+}
+
+func (d *Shop) syncTable(table *unison.Table[*shopRow], rows []*shopRow) {
+	sel := table.CopySelectionMap()
+	table.SetRootRows(rows)
+	table.SetSelectionMap(sel)
+	table.SizeColumnsToFit(true)
+}
+
+func (d *Shop) stockRows(parent *shopRow, list []*gurps.Equipment) []*shopRow {
+	campaign := d.campaign()
+	entity := d.entity()
+	rows := make([]*shopRow, 0, len(list))
+	for _, one := range list {
+		row := &shopRow{owner: d, eqp: one, parent: parent, value: d.shop.Price(entity, one)}
+		if campaign != nil {
+			row.restriction = campaign.EquipmentRestriction(one, true)
+			if !d.showAll && campaign.EquipmentRestriction(one, false) != "" {
+				continue
+			}
+		}
+		if one.Container() {
+			row.children = d.stockRows(row, one.Children)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func (d *Shop) ownedRows(parent *shopRow, list []*gurps.Equipment) []*shopRow {
+	rows := make([]*shopRow, 0, len(list))
+	for _, one := range list {
+		row := &shopRow{owner: d, eqp: one, parent: parent, value: d.shop.Offer(one), owned: true}
+		if one.Container() {
+			row.children = d.ownedRows(row, one.Children)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func (d *Shop) formatWealth(value fxp.Int) string {
+	return gurps.SheetSettingsFor(d.entity()).FormatWealth(value)
+}
+
+func (d *Shop) updateStatus() {
+	var buying, selling fxp.Int
+	entity := d.entity()
+	for _, row := range d.stockTable.SelectedRows(true) {
+		buying += row.value
+	}
+	for _, row := range d.ownedTable.SelectedRows(true) {
+		selling += row.value
+	}
+	var available string
+	if entity != nil {
+		available = d.formatWealth(entity.Money(d.purse()))
+	} else {
+		available = d.formatWealth(0)
+	}
+	d.statusLabel.Text = fmt.Sprintf(i18n.Text("%s available; buying for %s; selling for %s"), available,
+		d.formatWealth(buying), d.formatWealth(selling))
+	d.statusLabel.MarkForLayoutAndRedraw()
+}
+
+func (d *Shop) buy() {
+	if d.customer == nil {
+		unison.ErrorDialogWithMessage(i18n.Text("Unable to buy"), i18n.Text("A sheet must be open to buy equipment for."))
+		return
+	}
+	rows := d.stockTable.SelectedRows(true)
+	if len(rows) == 0 {
+		return
+	}
+	items := make([]*gurps.Equipment, 0, len(rows))
+	for _, row := range rows {
+		if row.restriction != "" {
+			unison.ErrorDialogWithMessage(i18n.Text("Unable to buy"), row.restriction)
+			return
+		}
+		items = append(items, row.eqp)
+	}
+	s := d.customer
+	before := gurps.NewTransactionState(s.entity)
+	bought, err := d.shop.Buy(s.entity, d.purse(), items)
+	if err != nil {
+		unison.ErrorDialogWithError(i18n.Text("Unable to buy"), err)
+		return
+	}
+	s.recordTransaction(i18n.Text("Buy Equipment"), before)
+	if s.CarriedEquipment != nil {
+		selMap := make(map[uuid.UUID]bool, len(bought))
+		for _, one := range bought {
+			selMap[one.ID] = true
+		}
+		s.CarriedEquipment.Table.SetSelectionMap(selMap)
+		ntable.ProcessNameablesForSelection(s.CarriedEquipment.Table)
+	}
+	d.stockTable.ClearSelection()
+	d.rebuild()
+}
+
+func (d *Shop) sell() {
+	if d.customer == nil {
+		return
+	}
+	rows := d.ownedTable.SelectedRows(true)
+	if len(rows) == 0 {
+		return
+	}
+	items := make([]*gurps.Equipment, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, row.eqp)
+	}
+	s := d.customer
+	before := gurps.NewTransactionState(s.entity)
+	d.shop.Sell(s.entity, d.purse(), items)
+	s.recordTransaction(i18n.Text("Sell Equipment"), before)
+	d.ownedTable.ClearSelection()
+	d.rebuild()
+}
+
+// recordTransaction adds an undo for a purchase or sale made from the given prior state, then updates the sheet.
+func (s *Sheet) recordTransaction(name string, before *gurps.TransactionState) {
+	s.undoMgr.Add(&unison.UndoEdit[*gurps.TransactionState]{
+		ID:         unison.NextUndoID(),
+		EditName:   name,
+		UndoFunc:   func(e *unison.UndoEdit[*gurps.TransactionState]) { s.applyTransactionState(e.BeforeData) },
+		RedoFunc:   func(e *unison.UndoEdit[*gurps.TransactionState]) { s.applyTransactionState(e.AfterData) },
+		BeforeData: before,
+		AfterData:  gurps.NewTransactionState(s.entity),
+	})
+	s.Rebuild(true)
+	s.MarkModified()
+}
+
+func (s *Sheet) applyTransactionState(state *gurps.TransactionState) {
+	state.Restore()
+	s.Rebuild(true)
+	s.MarkModified()
+}
+
+// syncShops updates any shops serving the sheet.
+func syncShops(s *Sheet) {
+	forEachShop(func(d *Shop) {
+		if d.customer == s {
+			d.rebuild()
+		}
+	})
+}
+
+// removeFromShops stops any shops from serving the sheet, which is needed when the sheet is closed.
+func removeFromShops(s *Sheet) {
+	forEachShop(func(d *Shop) {
+		if d.customer == s {
+			d.customer = nil
+			d.rebuild()
+		}
+	})
+}
+
+func forEachShop(f func(d *Shop)) {
+	for _, wnd := range unison.Windows() {
+		if ws := workspace.FromWindow(wnd); ws != nil {
+			ws.DocumentDock.RootDockLayout().ForEachDockContainer(func(dc *unison.DockContainer) bool {
+				for _, one := range dc.Dockables() {
+					if d, ok := one.(*Shop); ok {
+						f(d)
+					}
+				}
+				return false
+			})
+		}
+	}
+}
+
+type shopRow struct {
+	owner       *Shop
+	eqp         *gurps.Equipment
+	parent      *shopRow
+	children    []*shopRow
+	value       fxp.Int
+	restriction string
+	owned       bool
+}
+
+// CloneForTarget implements unison.TableRowData. Not permitted.
+func (r *shopRow) CloneForTarget(_ unison.Paneler, _ *shopRow) *shopRow {
+	return nil
+}
+
+// UUID implements unison.TableRowData.
+func (r *shopRow) UUID() uuid.UUID {
+	return r.eqp.ID
+}
+
+// Parent implements unison.TableRowData.
+func (r *shopRow) Parent() *shopRow {
+	return r.parent
+}
+
+// SetParent implements unison.TableRowData.
+func (r *shopRow) SetParent(parent *shopRow) {
+	r.parent = parent
+}
+
+// CanHaveChildren implements unison.TableRowData.
+func (r *shopRow) CanHaveChildren() bool {
+	return r.eqp.Container()
+}
+
+// Children implements unison.TableRowData.
+func (r *shopRow) Children() []*shopRow {
+	return r.children
+}
+
+// SetChildren implements unison.TableRowData.
+func (r *shopRow) SetChildren(children []*shopRow) {
+	r.children = children
+}
+
+// CellDataForSort implements unison.TableRowData.
+func (r *shopRow) CellDataForSort(col int) string {
+	if r.owned {
+		switch col {
+		case shopOwnedNameColumn:
+			return r.eqp.String()
+		case shopOwnedQuantityColumn:
+			return r.eqp.Quantity.String()
+		case shopOwnedOfferColumn:
+			return r.owner.formatWealth(r.value)
+		}
+		return ""
+	}
+	switch col {
+	case shopNameColumn:
+		return r.eqp.String()
+	case shopTLColumn:
+		return r.eqp.TechLevel
+	case shopLCColumn:
+		return r.eqp.LegalityClass
+	case shopPriceColumn:
+		return r.owner.formatWealth(r.value)
+	case shopRestrictionColumn:
+		return r.restriction
+	}
+	return ""
+}
+
+// ColumnCell implements unison.TableRowData.
+func (r *shopRow) ColumnCell(_, col int, foreground, _ unison.Ink, _, _, _ bool) unison.Paneler {
+	label := unison.NewLabel()
+	label.LabelTheme.OnBackgroundInk = foreground
+	label.Text = r.CellDataForSort(col)
+	if r.owned {
+		if col == shopOwnedQuantityColumn || col == shopOwnedOfferColumn {
+			label.HAlign = unison.EndAlignment
+		}
+	} else {
+		switch col {
+		case shopLCColumn:
+			label.Tooltip = unison.NewTooltipWithText(r.eqp.DisplayLegalityClass())
+		case shopPriceColumn:
+			label.HAlign = unison.EndAlignment
+		}
+	}
+	return label
+}
+
+// IsOpen implements unison.TableRowData.
+func (r *shopRow) IsOpen() bool {
+	open, exists := r.owner.open[r.eqp.ID]
+	return open || !exists
+}
+
+// SetOpen implements unison.TableRowData.
+func (r *shopRow) SetOpen(open bool) {
+	r.owner.open[r.eqp.ID] = open
+	if r.owned {
+		r.owner.ownedTable.SyncToModel()
+	} else {
+		r.owner.stockTable.SyncToModel()
+	}
+}
@@ -13,19 +13,24 @@ package sheet
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
 	"github.com/google/uuid"
 	"github.com/richardwilkes/gcs/v5/constants"
 	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/export"
 	gsettings "github.com/richardwilkes/gcs/v5/model/gurps/settings"
+	"github.com/richardwilkes/gcs/v5/model/gurps/validation"
 	"github.com/richardwilkes/gcs/v5/model/library"
 	"github.com/richardwilkes/gcs/v5/model/settings"
 	"github.com/richardwilkes/gcs/v5/model/theme"
+	"github.com/richardwilkes/gcs/v5/res"
 	"github.com/richardwilkes/gcs/v5/ui/widget"
 	"github.com/richardwilkes/gcs/v5/ui/widget/ntable"
 	"github.com/richardwilkes/gcs/v5/ui/workspace"
+	"github.com/richardwilkes/gcs/v5/ui/workspace/editors"
 	"github.com/richardwilkes/toolbox/i18n"
 	"github.com/richardwilkes/toolbox/log/jot"
 	"github.com/richardwilkes/toolbox/xio/fs"
@@ -58,6 +63,8 @@ type Template struct {
 	Spells            *PageList[*gurps.Spell]
 	Equipment         *PageList[*gurps.Equipment]
 	Notes             *PageList[*gurps.Note]
+	validationButton  *unison.Button
+	issues            []*validation.Issue
 	needsSaveAsPrompt bool
 }
 
@@ -134,6 +141,7 @@ func NewTemplate(filePath string, template *gurps.Template) *Template {
 		HGrab:  true,
 	})
 	toolbar.AddChild(d.scaleField)
+	toolbar.AddChild(d.createValidationButton())
 	toolbar.SetLayout(&unison.FlexLayout{
 		Columns:  len(toolbar.Children()),
 		HSpacing: unison.StdHSpacing,
@@ -146,6 +154,7 @@ func NewTemplate(filePath string, template *gurps.Template) *Template {
 
 	d.InstallCmdHandlers(constants.SaveItemID, func(_ any) bool { return d.Modified() }, func(_ any) { d.save(false) })
 	d.InstallCmdHandlers(constants.SaveAsItemID, unison.AlwaysEnabled, func(_ any) { d.save(true) })
+	d.InstallCmdHandlers(constants.PrintItemID, unison.AlwaysEnabled, func(_ any) { d.print() })
 	d.installNewItemCmdHandlers(constants.NewTraitItemID, constants.NewTraitContainerItemID, d.Traits)
 	d.installNewItemCmdHandlers(constants.NewSkillItemID, constants.NewSkillContainerItemID, d.Skills)
 	d.installNewItemCmdHandlers(constants.NewTechniqueItemID, -1, d.Skills)
@@ -161,6 +170,9 @@ func NewTemplate(filePath string, template *gurps.Template) *Template {
 			}, gurps.NewNaturalAttacks(nil, nil))
 	})
 	d.InstallCmdHandlers(constants.ApplyTemplateItemID, d.canApplyTemplate, d.applyTemplate)
+	d.InstallCmdHandlers(constants.CreateFromTemplateItemID, unison.AlwaysEnabled,
+		func(_ any) { ShowCreationWizard(d.Title(), d.template) })
+	InstallUnitConversionHandlers(d, func(c *gurps.UnitConverter) { c.Template(d.template) })
 
 	return d
 }
@@ -170,7 +182,18 @@ func (d *Template) canApplyTemplate(_ any) bool {
 }
 
 func (d *Template) applyTemplate(_ any) {
-	for _, sheet := range workspace.PromptForDestination(OpenSheets()) {
+	sheets := workspace.PromptForDestination(OpenSheets())
+	if len(sheets) == 0 {
+		return
+	}
+	template := d.template
+	if builder := gurps.NewTemplateBuilder(d.template, false); len(builder.Choices) != 0 {
+		if !runTemplateChoicesDialog(d.Title(), builder) {
+			return
+		}
+		template = builder.Resolve()
+	}
+	for _, sheet := range sheets {
 		var undo *unison.UndoEdit[*ApplyTemplateUndoEditData]
 		mgr := unison.UndoManagerFor(sheet)
 		if mgr != nil {
@@ -188,11 +211,11 @@ func (d *Template) applyTemplate(_ any) {
 				}
 			}
 		}
-		copyRowsTo(sheet.Traits.Table, d.Traits.Table.RootRows())
-		copyRowsTo(sheet.Skills.Table, d.Skills.Table.RootRows())
-		copyRowsTo(sheet.Spells.Table, d.Spells.Table.RootRows())
-		copyRowsTo(sheet.CarriedEquipment.Table, d.Equipment.Table.RootRows())
-		copyRowsTo(sheet.Notes.Table, d.Notes.Table.RootRows())
+		copyRowsTo(sheet.Traits.Table, editors.NewTraitsProvider(template, true).RootRows())
+		copyRowsTo(sheet.Skills.Table, editors.NewSkillsProvider(template, true).RootRows())
+		copyRowsTo(sheet.Spells.Table, editors.NewSpellsProvider(template, true).RootRows())
+		copyRowsTo(sheet.CarriedEquipment.Table, editors.NewEquipmentProvider(template, true, true).RootRows())
+		copyRowsTo(sheet.Notes.Table, editors.NewNotesProvider(template, true).RootRows())
 		sheet.Rebuild(true)
 		ntable.ProcessNameablesForSelection(sheet.Traits.Table)
 		ntable.ProcessNameablesForSelection(sheet.Skills.Table)
@@ -210,6 +233,25 @@ func (d *Template) applyTemplate(_ any) {
 	}
 }
 
+func (d *Template) createValidationButton() *unison.Button {
+	d.validationButton = unison.NewSVGButton(res.CheckmarkSVG)
+	d.validationButton.ClickCallback = func() {
+		d.updateValidation()
+		showValidationIssues(d.issues, i18n.Text("This template passed all of the validation checks."))
+	}
+	d.updateValidation()
+	return d.validationButton
+}
+
+// updateValidation re-runs the validation checks and updates the toolbar button to reflect the results.
+func (d *Template) updateValidation() {
+	if d.validationButton == nil {
+		return
+	}
+	d.issues = validation.ValidateTemplate(d.template)
+	updateValidationButton(d.validationButton, d.issues)
+}
+
 func copyRowsTo[T gurps.NodeConstraint[T]](table *unison.Table[*ntable.Node[T]], rows []*ntable.Node[T]) {
 	rows = slices.Clone(rows)
 	for j, row := range rows {
@@ -288,6 +330,7 @@ func (d *Template) Modified() bool {
 
 // MarkModified implements widget.ModifiableRoot.
 func (d *Template) MarkModified() {
+	d.updateValidation()
 	if dc := unison.Ancestor[*unison.DockContainer](d); dc != nil {
 		dc.UpdateTitle(d)
 	}
@@ -326,6 +369,13 @@ func (d *Template) createContent() unison.Paneler {
 	return d.content
 }
 
+func (d *Template) print() {
+	page := gurps.SheetSettingsFor(nil).Page
+	printPDF(d.Title(), d.path, page, func(w io.Writer) error {
+		return export.WriteTemplatePDF(d.template, d.Title(), page, w)
+	})
+}
+
 func (d *Template) save(forceSaveAs bool) bool {
 	success := false
 	if forceSaveAs || d.needsSaveAsPrompt {
@@ -470,6 +520,7 @@ func (d *Template) Rebuild(full bool) {
 		d.createLists()
 	}
 	widget.DeepSync(d)
+	d.updateValidation()
 	if dc := unison.Ancestor[*unison.DockContainer](d); dc != nil {
 		dc.UpdateTitle(d)
 	}
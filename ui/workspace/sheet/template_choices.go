@@ -0,0 +1,125 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"fmt"
+
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/model/gurps/gid"
+	"github.com/richardwilkes/gcs/v5/model/gurps/picker"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+	"github.com/richardwilkes/unison"
+)
+
+// runTemplateChoicesDialog asks the user to make the selections required by the template's choices. Returns false if
+// the user cancels.
+func runTemplateChoicesDialog(title string, builder *gurps.TemplateBuilder) bool {
+	boldFD := unison.DefaultLabelTheme.Font.Descriptor()
+	boldFD.Weight = unison.BoldFontWeight
+	boldFont := boldFD.Font()
+
+	panel := unison.NewPanel()
+	panel.SetLayout(&unison.FlexLayout{
+		Columns:  1,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	label := unison.NewLabel()
+	label.Text = fmt.Sprintf(i18n.Text("Make the selections required by %s:"), title)
+	panel.AddChild(label)
+
+	var dialog *unison.Dialog
+	adjustOKButton := func() {
+		if dialog == nil {
+			return
+		}
+		enabled := true
+		for _, choice := range builder.Choices {
+			if !choice.Satisfied() {
+				enabled = false
+				break
+			}
+		}
+		dialog.Button(unison.ModalResponseOK).SetEnabled(enabled)
+	}
+	for _, one := range builder.Choices {
+		choice := one
+		heading := unison.NewLabel()
+		heading.Font = boldFont
+		heading.Text = fmt.Sprintf("%s: %s", choiceKindTitle(choice.Kind), choice.Title)
+		heading.SetBorder(unison.NewEmptyBorder(unison.Insets{Top: unison.StdVSpacing * 2}))
+		panel.AddChild(heading)
+		status := unison.NewLabel()
+		updateStatus := func() {
+			if choice.Picker.Type == picker.Points {
+				status.Text = fmt.Sprintf(i18n.Text("%s — %s points selected"), choice.Picker.Description(),
+					choice.SelectedPoints().String())
+			} else {
+				status.Text = fmt.Sprintf(i18n.Text("%s — %d selected"), choice.Picker.Description(),
+					choice.SelectedCount())
+			}
+			if choice.Satisfied() {
+				status.OnBackgroundInk = unison.DefaultLabelTheme.OnBackgroundInk
+			} else {
+				status.OnBackgroundInk = unison.ErrorColor
+			}
+			status.MarkForLayoutAndRedraw()
+		}
+		panel.AddChild(status)
+		list := unison.NewPanel()
+		list.SetBorder(unison.NewEmptyBorder(unison.Insets{Left: unison.StdHSpacing * 2}))
+		list.SetLayout(&unison.FlexLayout{
+			Columns:  2,
+			HSpacing: unison.StdHSpacing * 2,
+			VSpacing: unison.StdVSpacing,
+		})
+		for _, o := range choice.Options {
+			option := o
+			checkBox := unison.NewCheckBox()
+			checkBox.Text = option.Name
+			checkBox.State = unison.CheckStateFromBool(option.Selected)
+			checkBox.ClickCallback = func() {
+				option.Selected = checkBox.State == unison.OnCheckState
+				updateStatus()
+				adjustOKButton()
+			}
+			list.AddChild(checkBox)
+			points := unison.NewLabel()
+			if choice.Kind == gid.Equipment {
+				points.Text = option.Points.String()
+			} else {
+				points.Text = fmt.Sprintf(i18n.Text("%s pts"), option.Points.String())
+			}
+			points.HAlign = unison.EndAlignment
+			points.SetLayoutData(&unison.FlexLayoutData{HAlign: unison.EndAlignment})
+			list.AddChild(points)
+		}
+		panel.AddChild(list)
+		updateStatus()
+	}
+	scroll := unison.NewScrollPanel()
+	scroll.SetContent(panel, unison.UnmodifiedBehavior, unison.UnmodifiedBehavior)
+
+	var err error
+	dialog, err = unison.NewDialog(nil, nil, scroll, []*unison.DialogButtonInfo{
+		unison.NewCancelButtonInfo(),
+		unison.NewOKButtonInfoWithTitle(i18n.Text("Apply")),
+	})
+	if err != nil {
+		jot.Error(err)
+		return false
+	}
+	adjustOKButton()
+	return dialog.RunModal() == unison.ModalResponseOK
+}
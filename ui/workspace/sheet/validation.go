@@ -0,0 +1,119 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"fmt"
+
+	"github.com/richardwilkes/gcs/v5/model/gurps/validation"
+	"github.com/richardwilkes/gcs/v5/res"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/toolbox/log/jot"
+	"github.com/richardwilkes/unison"
+)
+
+const maxIssueTextWidth = 600
+
+func (s *Sheet) createValidationButton() *unison.Button {
+	s.validationButton = unison.NewSVGButton(res.CheckmarkSVG)
+	s.validationButton.ClickCallback = s.showValidationIssues
+	s.updateValidation()
+	return s.validationButton
+}
+
+// updateValidation re-runs the validation checks and updates the toolbar button to reflect the results.
+func (s *Sheet) updateValidation() {
+	if s.validationButton == nil {
+		return
+	}
+	s.issues = s.validate()
+	updateValidationButton(s.validationButton, s.issues)
+}
+
+func (s *Sheet) showValidationIssues() {
+	s.updateValidation()
+	showValidationIssues(s.issues, i18n.Text("This sheet passed all of the validation checks."))
+}
+
+func updateValidationButton(button *unison.Button, issues []*validation.Issue) {
+	var svg *unison.SVG
+	var tip string
+	if len(issues) == 0 {
+		svg = res.CheckmarkSVG
+		tip = i18n.Text("No problems were found")
+	} else {
+		svg = res.NotSVG
+		tip = fmt.Sprintf(i18n.Text("%d problem(s) were found; click to review them"), len(issues))
+	}
+	if drawable, ok := button.Drawable.(*unison.DrawableSVG); ok {
+		drawable.SVG = svg
+	}
+	button.Tooltip = unison.NewTooltipWithText(tip)
+	button.MarkForRedraw()
+}
+
+func showValidationIssues(issues []*validation.Issue, passedMsg string) {
+	if len(issues) == 0 {
+		runValidationDialog(&unison.DrawableSVG{
+			SVG:  res.CheckmarkSVG,
+			Size: unison.NewSize(48, 48),
+		}, unison.DefaultLabelTheme.OnBackgroundInk, unison.NewMessagePanel(i18n.Text("No problems were found"),
+			passedMsg))
+		return
+	}
+	boldFD := unison.DefaultLabelTheme.Font.Descriptor()
+	boldFD.Weight = unison.BoldFontWeight
+	boldFont := boldFD.Font()
+	decoration := &unison.TextDecoration{Font: unison.DefaultLabelTheme.Font}
+	panel := unison.NewPanel()
+	panel.SetLayout(&unison.FlexLayout{
+		Columns:  2,
+		HSpacing: unison.StdHSpacing,
+		VSpacing: unison.StdVSpacing,
+	})
+	for _, issue := range issues {
+		label := unison.NewLabel()
+		label.Font = boldFont
+		label.Text = issue.Category
+		if issue.Severity == validation.Error {
+			label.OnBackgroundInk = unison.ErrorColor
+		} else {
+			label.OnBackgroundInk = unison.WarningColor
+		}
+		label.SetLayoutData(&unison.FlexLayoutData{VAlign: unison.StartAlignment})
+		panel.AddChild(label)
+		text := issue.Message
+		if issue.Subject != "" {
+			text = issue.Subject + ": " + text
+		}
+		lines := unison.NewPanel()
+		lines.SetLayout(&unison.FlexLayout{Columns: 1})
+		for _, line := range unison.NewTextWrappedLines(text, decoration, maxIssueTextWidth) {
+			one := unison.NewLabel()
+			one.Text = line.String()
+			lines.AddChild(one)
+		}
+		panel.AddChild(lines)
+	}
+	scroll := unison.NewScrollPanel()
+	scroll.SetContent(panel, unison.UnmodifiedBehavior, unison.UnmodifiedBehavior)
+	runValidationDialog(unison.DefaultDialogTheme.WarningIcon, unison.DefaultDialogTheme.WarningIconInk, scroll)
+}
+
+func runValidationDialog(icon unison.Drawable, iconInk unison.Ink, content unison.Paneler) {
+	dialog, err := unison.NewDialog(icon, iconInk, content, []*unison.DialogButtonInfo{unison.NewOKButtonInfo()})
+	if err != nil {
+		jot.Error(err)
+		return
+	}
+	dialog.RunModal()
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright ©1998-2022 by Richard A. Wilkes. All rights reserved.
+ *
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, version 2.0. If a copy of the MPL was not distributed with
+ * this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ *
+ * This Source Code Form is "Incompatible With Secondary Licenses", as
+ * defined by the Mozilla Public License, version 2.0.
+ */
+
+package sheet
+
+import (
+	"github.com/richardwilkes/gcs/v5/model/fxp"
+	"github.com/richardwilkes/gcs/v5/model/gurps"
+	"github.com/richardwilkes/gcs/v5/ui/widget"
+	"github.com/richardwilkes/gcs/v5/ui/widget/ntable"
+	"github.com/richardwilkes/toolbox/i18n"
+	"github.com/richardwilkes/unison"
+)
+
+type weaponAmmoListUndoEdit = *unison.UndoEdit[*weaponAmmoList]
+
+type weaponAmmoList struct {
+	Owner widget.Rebuildable
+	List  []*weaponAmmoAdjuster
+}
+
+func (a *weaponAmmoList) Apply() {
+	for _, one := range a.List {
+		one.Apply()
+	}
+	a.Finish()
+}
+
+func (a *weaponAmmoList) Finish() {
+	entity := a.List[0].Target.Entity()
+	if entity != nil {
+		entity.Recalculate()
+	}
+	widget.MarkModified(a.Owner)
+}
+
+type weaponAmmoAdjuster struct {
+	Target   *gurps.Weapon
+	Loaded   int
+	Source   *gurps.Equipment
+	Quantity fxp.Int
+}
+
+func newWeaponAmmoAdjuster(target *gurps.Weapon, source *gurps.Equipment) *weaponAmmoAdjuster {
+	a := &weaponAmmoAdjuster{
+		Target: target,
+		Loaded: target.Ammo.Loaded,
+		Source: source,
+	}
+	if source != nil {
+		a.Quantity = source.Quantity
+	}
+	return a
+}
+
+func (a *weaponAmmoAdjuster) Apply() {
+	if a.Target.Ammo != nil {
+		a.Target.Ammo.Loaded = a.Loaded
+	}
+	if a.Source != nil {
+		a.Source.Quantity = a.Quantity
+	}
+}
+
+func canFireWeapon(table *unison.Table[*ntable.Node[*gurps.Weapon]]) bool {
+	for _, row := range table.SelectedRows(false) {
+		if w := row.Data(); w != nil && w.CanFire() {
+			return true
+		}
+	}
+	return false
+}
+
+func fireWeapon(owner widget.Rebuildable, table *unison.Table[*ntable.Node[*gurps.Weapon]]) {
+	adjustWeaponAmmo(owner, table, i18n.Text("Fire Weapon"), (*gurps.Weapon).CanFire,
+		func(w *gurps.Weapon) { w.Fire() })
+}
+
+func canReloadWeapon(table *unison.Table[*ntable.Node[*gurps.Weapon]]) bool {
+	for _, row := range table.SelectedRows(false) {
+		if w := row.Data(); w != nil && w.CanReload() {
+			return true
+		}
+	}
+	return false
+}
+
+func reloadWeapon(owner widget.Rebuildable, table *unison.Table[*ntable.Node[*gurps.Weapon]]) {
+	adjustWeaponAmmo(owner, table, i18n.Text("Reload Weapon"), (*gurps.Weapon).CanReload, (*gurps.Weapon).Reload)
+}
+
+func adjustWeaponAmmo(owner widget.Rebuildable, table *unison.Table[*ntable.Node[*gurps.Weapon]], name string, can func(*gurps.Weapon) bool, adjust func(*gurps.Weapon)) {
+	before := &weaponAmmoList{Owner: owner}
+	after := &weaponAmmoList{Owner: owner}
+	var weapons []*gurps.Weapon
+	var sources []*gurps.Equipment
+	for _, row := range table.SelectedRows(false) {
+		if w := row.Data(); w != nil && can(w) {
+			weapons = append(weapons, w)
+			sources = append(sources, w.AmmoSource())
+		}
+	}
+	// Several weapons may draw from the same ammunition, so all of the prior state must be captured before any of it
+	// is changed, and all of the resulting state after every change has been made.
+	for i, w := range weapons {
+		before.List = append(before.List, newWeaponAmmoAdjuster(w, sources[i]))
+	}
+	for _, w := range weapons {
+		if can(w) {
+			adjust(w)
+		}
+	}
+	for i, w := range weapons {
+		after.List = append(after.List, newWeaponAmmoAdjuster(w, sources[i]))
+	}
+	if len(before.List) > 0 {
+		if mgr := unison.UndoManagerFor(table); mgr != nil {
+			mgr.Add(&unison.UndoEdit[*weaponAmmoList]{
+				ID:         unison.NextUndoID(),
+				EditName:   name,
+				UndoFunc:   func(edit weaponAmmoListUndoEdit) { edit.BeforeData.Apply() },
+				RedoFunc:   func(edit weaponAmmoListUndoEdit) { edit.AfterData.Apply() },
+				BeforeData: before,
+				AfterData:  after,
+			})
+		}
+		before.Finish()
+	}
+}